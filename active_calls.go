@@ -0,0 +1,52 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// argDigestSize is the number of bytes of the SHA-256 digests of the call
+// arguments that are logged and listed.
+const argDigestSize = 8
+
+// argDigest returns the hex of the truncated SHA-256 digest of v.
+func argDigest(v []byte) string {
+	sum := sha256.Sum256(v)
+	return hex.EncodeToString(sum[:argDigestSize])
+}
+
+// TrackActiveCalls makes ActiveCalls list the native calls starting from now
+// on, and returns a function stopping it. It is off by default, as tracking
+// takes the id of the goroutine of every call.
+func TrackActiveCalls() (stop func()) {
+	return cgo.TrackRunningCalls()
+}
+
+// ActiveCalls returns the native calls running, the oldest first, to see
+// what the prover is busy with when it seems stuck. Only the calls started
+// while tracked by TrackActiveCalls are listed.
+func ActiveCalls() []ActiveCall {
+	running := cgo.RunningCalls()
+	out := make([]ActiveCall, len(running))
+	for i, call := range running {
+		out[i] = ActiveCall{
+			CallInfo:  toCallInfo(call.Call),
+			Start:     call.Start,
+			Goroutine: call.Goroutine,
+		}
+		if call.Args != nil {
+			var args []string
+			for _, arg := range call.Args() {
+				args = append(args, arg.Name+"="+argDigest(arg.Value))
+			}
+			out[i].Args = strings.Join(args, " ")
+		}
+	}
+	return out
+}
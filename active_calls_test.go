@@ -0,0 +1,50 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// snapshotHooks lists the active calls when a call starts.
+type snapshotHooks struct {
+	active []ActiveCall
+}
+
+func (h *snapshotHooks) OnCallStart(CallInfo) {
+	h.active = ActiveCalls()
+}
+
+func (h *snapshotHooks) OnCallEnd(CallInfo, error, time.Duration) {}
+
+func TestActiveCalls(t *testing.T) {
+	defer TrackActiveCalls()()
+
+	h := &snapshotHooks{}
+	unregister := RegisterHooks(h)
+	defer unregister()
+
+	var zero cgo.ByteArray32
+	proof := []byte{1, 2, 3}
+	// An invalid proof type fails before calling into the library.
+	_, err := cgo.VerifySeal(cgo.RegisteredSealProof(1000), &zero, &zero, &zero, &zero, &zero, 7, cgo.AsSliceRefUint8(proof))
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	require.Len(t, h.active, 1)
+	call := h.active[0]
+	assert.Equal(t, "verify_seal", call.Name)
+	assert.Equal(t, uint64(7), call.SectorID)
+	assert.NotZero(t, call.Goroutine)
+	assert.WithinDuration(t, time.Now(), call.Start, time.Minute)
+	assert.Contains(t, call.Args, "proof="+argDigest(proof))
+	assert.Contains(t, call.Args, "comm_r="+argDigest(make([]byte, 32)))
+
+	assert.Empty(t, ActiveCalls())
+}
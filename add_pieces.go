@@ -0,0 +1,159 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io"
+	"os"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// PieceData is a piece to add to a staged sector.
+type PieceData struct {
+	// Size is the unpadded size of the piece. Data must hold exactly Size
+	// bytes, zero-filled up to the piece size by the caller, for example
+	// with a PaddedPieceReader.
+	Size abi.UnpaddedPieceSize
+	Data io.Reader
+}
+
+// AddedPiece is a piece written by AddPieces.
+type AddedPiece struct {
+	abi.PieceInfo
+	// Offset is the position of the piece in the unpadded sector, as given
+	// to UnsealRange.
+	Offset uint64
+	// LeftAlignment is the zero padding written before the piece to align
+	// it on a multiple of its size.
+	LeftAlignment abi.UnpaddedPieceSize
+}
+
+// AddPieces writes pieces to the staged sector one after the other, each
+// aligned to its size with WriteWithSparseAlignment, and returns where each
+// was written with its commitment. existingPieceSizes are the pieces already
+// in the sector, and stagedSectorFile must be positioned at their end.
+//
+// The layout, that of PlanPieces, is checked to fit in the sector before
+// anything is written. On error, the pieces before the failing one have been
+// written to the staged sector and are returned.
+func AddPieces(
+	proofType abi.RegisteredSealProof,
+	stagedSectorFile *os.File,
+	existingPieceSizes []abi.UnpaddedPieceSize,
+	pieces []PieceData,
+) ([]AddedPiece, error) {
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make([]abi.UnpaddedPieceSize, 0, len(existingPieceSizes)+len(pieces))
+	sizes = append(sizes, existingPieceSizes...)
+	incoming := make([]abi.UnpaddedPieceSize, len(pieces))
+	for i, p := range pieces {
+		incoming[i] = p.Size
+	}
+	plan, err := PlanPieces(ssize, sizes, incoming)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Overflow {
+		return nil, xerrors.Errorf("pieces of %d padded bytes do not fit in a %d byte sector", plan.End, ssize)
+	}
+
+	out := make([]AddedPiece, 0, len(pieces))
+	for i, p := range pieces {
+		placed := plan.Pieces[i]
+		pieceCID, err := writePieceData(proofType, p, stagedSectorFile, sizes)
+		if err != nil {
+			return out, xerrors.Errorf("adding piece %d: %w", i, err)
+		}
+
+		out = append(out, AddedPiece{
+			PieceInfo:     abi.PieceInfo{Size: p.Size.Padded(), PieceCID: pieceCID},
+			Offset:        placed.Offset,
+			LeftAlignment: placed.LeftAlignment,
+		})
+		sizes = append(sizes, p.Size)
+	}
+	return out, nil
+}
+
+// writePieceData writes a piece with WriteWithSparseAlignment, through a
+// pipe unless its data is a file.
+func writePieceData(proofType abi.RegisteredSealProof, p PieceData, staged *os.File, existing []abi.UnpaddedPieceSize) (cid.Cid, error) {
+	f, isFile := p.Data.(*os.File)
+	var copied chan error
+	if !isFile {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return cid.Undef, xerrors.Errorf("creating piece pipe: %w", err)
+		}
+		defer pr.Close() // nolint:errcheck
+
+		copied = make(chan error, 1)
+		go func() {
+			_, err := io.CopyN(pw, p.Data, int64(p.Size))
+			_ = pw.Close()
+			copied <- err
+		}()
+		f = pr
+	}
+
+	_, _, pieceCID, err := WriteWithSparseAlignment(proofType, f, p.Size, staged, existing)
+	if copied != nil {
+		// Unblock the copy when the native call stopped reading early.
+		_ = f.Close()
+		if cerr := <-copied; cerr != nil && err == nil {
+			err = xerrors.Errorf("reading piece: %w", cerr)
+		}
+	}
+	if err != nil {
+		return cid.Undef, err
+	}
+	return pieceCID, nil
+}
+
+// WriteWithSparseAlignment is WriteWithAlignment leaving the alignment before
+// the piece as a hole in the staged sector, rather than writing its zeros.
+// Packing a small piece before a large one thus doesn't write up to the size
+// of the large one, on filesystems supporting sparse files.
+//
+// The staged sector must be positioned at the end of the existing pieces. It
+// has been moved after the alignment when writing the piece fails.
+func WriteWithSparseAlignment(
+	proofType abi.RegisteredSealProof,
+	pieceFile *os.File,
+	pieceBytes abi.UnpaddedPieceSize,
+	stagedSectorFile *os.File,
+	existingPieceSizes []abi.UnpaddedPieceSize,
+) (leftAlignment, total abi.UnpaddedPieceSize, pieceCID cid.Cid, retErr error) {
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return 0, 0, cid.Undef, err
+	}
+	plan, err := PlanPieces(ssize, existingPieceSizes, []abi.UnpaddedPieceSize{pieceBytes})
+	if err != nil {
+		return 0, 0, cid.Undef, err
+	}
+	if plan.Overflow {
+		return 0, 0, cid.Undef, xerrors.Errorf("piece of %d bytes does not fit in the sector after %d padded bytes", pieceBytes, piecesEnd(existingPieceSizes))
+	}
+
+	left := plan.Pieces[0].LeftAlignment
+	if left > 0 {
+		if _, err := stagedSectorFile.Seek(int64(left.Padded()), io.SeekCurrent); err != nil {
+			return 0, 0, cid.Undef, xerrors.Errorf("skipping alignment: %w", err)
+		}
+	}
+
+	written, pieceCID, err := WriteWithoutAlignment(proofType, pieceFile, pieceBytes, stagedSectorFile)
+	if err != nil {
+		return 0, 0, cid.Undef, err
+	}
+	return left, left + written, pieceCID, nil
+}
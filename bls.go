@@ -1,5 +1,5 @@
-//go:build cgo
-// +build cgo
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
 
 package ffi
 
@@ -43,12 +43,7 @@ func Verify(signature *Signature, digests []Digest, publicKeys []PublicKey) bool
 
 // HashVerify verifies that a signature is the aggregated signature of hashed messages.
 func HashVerify(signature *Signature, messages []Message, publicKeys []PublicKey) bool {
-	var flattenedMessages []byte
-	messagesSizes := make([]uint, len(messages))
-	for idx := range messages {
-		flattenedMessages = append(flattenedMessages, messages[idx]...)
-		messagesSizes[idx] = uint(len(messages[idx]))
-	}
+	flattenedMessages, messagesSizes := flattenMessages(messages)
 
 	flattenedPublicKeys := make([]byte, PublicKeyBytes*len(publicKeys))
 	for idx, publicKey := range publicKeys {
@@ -63,6 +58,53 @@ func HashVerify(signature *Signature, messages []Message, publicKeys []PublicKey
 	)
 }
 
+// BatchVerify verifies a batch of independent signatures, where signatures[i]
+// is the signature of messages[i] by publicKeys[i]. It returns true only if
+// every signature is valid. Unlike HashVerify on the aggregate of the
+// signatures it is safe on untrusted input, and messages need not be
+// distinct.
+func BatchVerify(signatures []Signature, messages []Message, publicKeys []PublicKey) bool {
+	if len(signatures) != len(messages) || len(signatures) != len(publicKeys) {
+		return false
+	}
+
+	flattenedSignatures := make([]byte, SignatureBytes*len(signatures))
+	for idx, sig := range signatures {
+		copy(flattenedSignatures[(SignatureBytes*idx):(SignatureBytes*(1+idx))], sig[:])
+	}
+
+	flattenedMessages, messagesSizes := flattenMessages(messages)
+
+	flattenedPublicKeys := make([]byte, PublicKeyBytes*len(publicKeys))
+	for idx, publicKey := range publicKeys {
+		copy(flattenedPublicKeys[(PublicKeyBytes*idx):(PublicKeyBytes*(1+idx))], publicKey[:])
+	}
+
+	return cgo.BatchVerify(
+		cgo.AsSliceRefUint8(flattenedSignatures),
+		cgo.AsSliceRefUint8(flattenedMessages),
+		cgo.AsSliceRefUint(messagesSizes),
+		cgo.AsSliceRefUint8(flattenedPublicKeys),
+	)
+}
+
+// flattenMessages concatenates messages, returning the lengths the native
+// library splits them back with.
+func flattenMessages(messages []Message) ([]byte, []uint) {
+	total := 0
+	for _, message := range messages {
+		total += len(message)
+	}
+
+	flattenedMessages := make([]byte, 0, total)
+	messagesSizes := make([]uint, len(messages))
+	for idx, message := range messages {
+		flattenedMessages = append(flattenedMessages, message...)
+		messagesSizes[idx] = uint(len(message))
+	}
+	return flattenedMessages, messagesSizes
+}
+
 // Aggregate aggregates signatures together into a new signature. If the
 // provided signatures cannot be aggregated (due to invalid input or an
 // an operational error), Aggregate will return nil.
@@ -76,6 +118,22 @@ func Aggregate(signatures []Signature) *Signature {
 	return cgo.Aggregate(cgo.AsSliceRefUint8(flattenedSignatures))
 }
 
+// AggregatePublicKeys aggregates public keys together into a new public key,
+// which verifies the aggregate of the signatures of a single message by all
+// the keys. It returns nil when there is no key, or a key is malformed or the
+// identity.
+//
+// The keys must have been proven to be owned by their signers, or one of them
+// can be chosen to cancel the others out.
+func AggregatePublicKeys(publicKeys []PublicKey) *PublicKey {
+	flattenedPublicKeys := make([]byte, PublicKeyBytes*len(publicKeys))
+	for idx, publicKey := range publicKeys {
+		copy(flattenedPublicKeys[(PublicKeyBytes*idx):(PublicKeyBytes*(1+idx))], publicKey[:])
+	}
+
+	return cgo.AggregatePublicKeys(cgo.AsSliceRefUint8(flattenedPublicKeys))
+}
+
 // PrivateKeyGenerate generates a private key
 func PrivateKeyGenerate() PrivateKey {
 	key := cgo.PrivateKeyGenerate()
@@ -113,3 +171,26 @@ func CreateZeroSignature() Signature {
 	}
 	return *signature
 }
+
+// PublicKeyStatus decodes a public key the way HashVerify does, telling
+// whether it is a point which signatures can be verified with.
+func PublicKeyStatus(publicKey PublicKey) PointStatus {
+	return pointStatus(cgo.PublicKeyStatus(cgo.AsSliceRefUint8(publicKey[:])))
+}
+
+// SignatureStatus decodes a signature the way HashVerify does, telling
+// whether it is a point which can be verified.
+func SignatureStatus(signature Signature) PointStatus {
+	return pointStatus(cgo.SignatureStatus(cgo.AsSliceRefUint8(signature[:])))
+}
+
+func pointStatus(status cgo.BLSPointStatus) PointStatus {
+	switch status {
+	case cgo.BLSPointStatusValid:
+		return PointValid
+	case cgo.BLSPointStatusIdentity:
+		return PointIdentity
+	default:
+		return PointMalformed
+	}
+}
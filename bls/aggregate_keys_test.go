@@ -0,0 +1,40 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package bls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The mock doesn't verify signatures against aggregated keys.
+func TestAggregatePublicKeysVerify(t *testing.T) {
+	msg := []byte("block")
+
+	var (
+		pks  []PublicKey
+		sigs []Signature
+	)
+	for i := 0; i < 3; i++ {
+		sk := SecretKeyFromSeed([32]byte{byte(i + 1)})
+		pk, err := sk.PublicKey()
+		require.NoError(t, err)
+		sig, err := sk.Sign(msg)
+		require.NoError(t, err)
+
+		pks, sigs = append(pks, pk), append(sigs, sig)
+	}
+
+	sig, err := Aggregate(sigs...)
+	require.NoError(t, err)
+	pk, err := AggregatePublicKeys(pks...)
+	require.NoError(t, err)
+	assert.True(t, sig.Verify(msg, pk))
+
+	pk, err = AggregatePublicKeys(pks[1:]...)
+	require.NoError(t, err)
+	assert.False(t, sig.Verify(msg, pk))
+}
@@ -0,0 +1,210 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package bls wraps the BLS signature functions of the ffi package in typed
+// keys and signatures, so that callers don't deal with flattened buffers and
+// nil results.
+//
+// Messages are signed and verified as is, hashed to the curve by the native
+// library, the way Filecoin signs its messages and blocks.
+package bls
+
+import (
+	"fmt"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"golang.org/x/xerrors"
+)
+
+// ErrInvalidSecretKey is returned for secret keys the native library rejects.
+var ErrInvalidSecretKey = xerrors.New("invalid BLS secret key")
+
+// ErrInvalidPublicKey is returned for encodings which aren't public keys.
+var ErrInvalidPublicKey = xerrors.New("invalid BLS public key")
+
+// ErrInvalidSignature is returned for encodings which aren't signatures, and
+// for signatures which can't be aggregated.
+var ErrInvalidSignature = xerrors.New("invalid BLS signature")
+
+// SecretKey is a BLS secret key.
+type SecretKey [ffi.PrivateKeyBytes]byte
+
+// PublicKey is the BLS public key of a SecretKey.
+type PublicKey [ffi.PublicKeyBytes]byte
+
+// Signature is a BLS signature, or the aggregate of several.
+type Signature [ffi.SignatureBytes]byte
+
+// GenerateSecretKey returns a new random secret key.
+func GenerateSecretKey() SecretKey {
+	return SecretKey(ffi.PrivateKeyGenerate())
+}
+
+// SecretKeyFromSeed derives a secret key from seed, always the same for a
+// seed.
+func SecretKeyFromSeed(seed [32]byte) SecretKey {
+	return SecretKey(ffi.PrivateKeyGenerateWithSeed(seed))
+}
+
+// SecretKeyFromBytes returns the secret key encoded in b, which must be a
+// scalar of the field.
+func SecretKeyFromBytes(b []byte) (SecretKey, error) {
+	var sk SecretKey
+	if len(b) != len(sk) {
+		return SecretKey{}, fmt.Errorf("%w: %d bytes instead of %d", ErrInvalidSecretKey, len(b), len(sk))
+	}
+	copy(sk[:], b)
+	if _, err := sk.PublicKey(); err != nil {
+		return SecretKey{}, err
+	}
+	return sk, nil
+}
+
+// Bytes returns the encoding of sk.
+func (sk SecretKey) Bytes() []byte {
+	return sk[:]
+}
+
+// PublicKey returns the public key of sk.
+func (sk SecretKey) PublicKey() (PublicKey, error) {
+	pk := ffi.PrivateKeyPublicKey(ffi.PrivateKey(sk))
+	if pk == nil {
+		return PublicKey{}, ErrInvalidSecretKey
+	}
+	return PublicKey(*pk), nil
+}
+
+// Sign returns the signature of msg by sk.
+func (sk SecretKey) Sign(msg []byte) (Signature, error) {
+	sig := ffi.PrivateKeySign(ffi.PrivateKey(sk), msg)
+	if sig == nil {
+		return Signature{}, ErrInvalidSecretKey
+	}
+	return Signature(*sig), nil
+}
+
+// PublicKeyFromBytes returns the public key encoded in b, which must be a
+// point of the G1 subgroup other than the identity.
+func PublicKeyFromBytes(b []byte) (PublicKey, error) {
+	var pk PublicKey
+	if len(b) != len(pk) {
+		return PublicKey{}, fmt.Errorf("%w: %d bytes instead of %d", ErrInvalidPublicKey, len(b), len(pk))
+	}
+	copy(pk[:], b)
+	switch ffi.PublicKeyStatus(ffi.PublicKey(pk)) {
+	case ffi.PointValid:
+		return pk, nil
+	case ffi.PointIdentity:
+		return PublicKey{}, fmt.Errorf("%w: identity point", ErrInvalidPublicKey)
+	default:
+		return PublicKey{}, fmt.Errorf("%w: not a point of the G1 subgroup", ErrInvalidPublicKey)
+	}
+}
+
+// Bytes returns the encoding of pk.
+func (pk PublicKey) Bytes() []byte {
+	return pk[:]
+}
+
+// Verify tells whether sig is the signature of msg by pk.
+func (pk PublicKey) Verify(msg []byte, sig Signature) bool {
+	return sig.Verify(msg, pk)
+}
+
+// SignatureFromBytes returns the signature encoded in b, which must be a
+// point of the G2 subgroup. The identity, the ZeroSignature, is accepted.
+func SignatureFromBytes(b []byte) (Signature, error) {
+	var sig Signature
+	if len(b) != len(sig) {
+		return Signature{}, fmt.Errorf("%w: %d bytes instead of %d", ErrInvalidSignature, len(b), len(sig))
+	}
+	copy(sig[:], b)
+	if ffi.SignatureStatus(ffi.Signature(sig)) == ffi.PointMalformed {
+		return Signature{}, fmt.Errorf("%w: not a point of the G2 subgroup", ErrInvalidSignature)
+	}
+	return sig, nil
+}
+
+// ZeroSignature returns the placeholder signature of Filecoin.
+func ZeroSignature() Signature {
+	return Signature(ffi.CreateZeroSignature())
+}
+
+// Bytes returns the encoding of sig.
+func (sig Signature) Bytes() []byte {
+	return sig[:]
+}
+
+// Verify tells whether sig is the signature of msg by pk.
+func (sig Signature) Verify(msg []byte, pk PublicKey) bool {
+	return sig.VerifyAggregate([][]byte{msg}, []PublicKey{pk})
+}
+
+// VerifyAggregate tells whether sig is the aggregate of the signatures of
+// msgs[i] by pks[i]. The messages must be distinct: use BatchVerify for
+// signatures of untrusted messages.
+func (sig Signature) VerifyAggregate(msgs [][]byte, pks []PublicKey) bool {
+	return ffi.AggregateVerify(ffi.Signature(sig), msgs, toFFIPublicKeys(pks))
+}
+
+// Aggregate returns the aggregate of sigs, which VerifyAggregate checks
+// against all their messages at once.
+func Aggregate(sigs ...Signature) (Signature, error) {
+	if len(sigs) == 0 {
+		return Signature{}, fmt.Errorf("%w: no signatures to aggregate", ErrInvalidSignature)
+	}
+
+	signatures := make([]ffi.Signature, len(sigs))
+	for i, sig := range sigs {
+		signatures[i] = ffi.Signature(sig)
+	}
+	agg := ffi.Aggregate(signatures)
+	if agg == nil {
+		return Signature{}, ErrInvalidSignature
+	}
+	return Signature(*agg), nil
+}
+
+// AggregatePublicKeys returns the aggregate of pks, which verifies the
+// Aggregate of the signatures of a single message by all the keys.
+//
+// Each key must have been proven to be owned by its signer, with a proof of
+// possession, or one of them can be chosen to cancel the others out.
+func AggregatePublicKeys(pks ...PublicKey) (PublicKey, error) {
+	if len(pks) == 0 {
+		return PublicKey{}, fmt.Errorf("%w: no public keys to aggregate", ErrInvalidPublicKey)
+	}
+
+	agg := ffi.AggregatePublicKeys(toFFIPublicKeys(pks))
+	if agg == nil {
+		return PublicKey{}, ErrInvalidPublicKey
+	}
+	return PublicKey(*agg), nil
+}
+
+// BatchVerify tells whether every sigs[i] is the signature of msgs[i] by
+// pks[i], verifying them together. Unlike VerifyAggregate, it is safe on
+// untrusted messages.
+func BatchVerify(sigs []Signature, msgs [][]byte, pks []PublicKey) bool {
+	if len(sigs) != len(msgs) || len(sigs) != len(pks) {
+		return false
+	}
+
+	signatures := make([]ffi.Signature, len(sigs))
+	for i, sig := range sigs {
+		signatures[i] = ffi.Signature(sig)
+	}
+	messages := make([]ffi.Message, len(msgs))
+	for i, msg := range msgs {
+		messages[i] = msg
+	}
+	return ffi.BatchVerify(signatures, messages, toFFIPublicKeys(pks))
+}
+
+func toFFIPublicKeys(pks []PublicKey) []ffi.PublicKey {
+	out := make([]ffi.PublicKey, len(pks))
+	for i, pk := range pks {
+		out[i] = ffi.PublicKey(pk)
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package bls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	sk := GenerateSecretKey()
+	pk, err := sk.PublicKey()
+	require.NoError(t, err)
+
+	msg := []byte("hello")
+	sig, err := sk.Sign(msg)
+	require.NoError(t, err)
+
+	assert.True(t, sig.Verify(msg, pk))
+	assert.True(t, pk.Verify(msg, sig))
+	assert.False(t, sig.Verify([]byte("hello!"), pk))
+
+	otherPK, err := GenerateSecretKey().PublicKey()
+	require.NoError(t, err)
+	assert.False(t, sig.Verify(msg, otherPK))
+
+	// round trip through the encodings
+	sk2, err := SecretKeyFromBytes(sk.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, sk, sk2)
+	pk2, err := PublicKeyFromBytes(pk.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, pk, pk2)
+	sig2, err := SignatureFromBytes(sig.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, sig, sig2)
+
+	_, err = SignatureFromBytes(sig.Bytes()[1:])
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = SecretKeyFromBytes(nil)
+	assert.ErrorIs(t, err, ErrInvalidSecretKey)
+}
+
+func TestSecretKeyFromSeed(t *testing.T) {
+	seed := [32]byte{1, 2, 3}
+	assert.Equal(t, SecretKeyFromSeed(seed), SecretKeyFromSeed(seed))
+	assert.NotEqual(t, SecretKeyFromSeed(seed), SecretKeyFromSeed([32]byte{4}))
+}
+
+func TestAggregate(t *testing.T) {
+	var (
+		msgs [][]byte
+		pks  []PublicKey
+		sigs []Signature
+	)
+	for i := 0; i < 3; i++ {
+		sk := SecretKeyFromSeed([32]byte{byte(i + 1)})
+		pk, err := sk.PublicKey()
+		require.NoError(t, err)
+		msg := []byte{'m', byte(i)}
+		sig, err := sk.Sign(msg)
+		require.NoError(t, err)
+
+		msgs, pks, sigs = append(msgs, msg), append(pks, pk), append(sigs, sig)
+	}
+
+	agg, err := Aggregate(sigs...)
+	require.NoError(t, err)
+	assert.True(t, agg.VerifyAggregate(msgs, pks))
+	assert.False(t, agg.VerifyAggregate(msgs[:2], pks[:2]))
+	assert.False(t, agg.VerifyAggregate(msgs, pks[:2]))
+
+	assert.True(t, BatchVerify(sigs, msgs, pks))
+	sigs[0], sigs[1] = sigs[1], sigs[0]
+	assert.False(t, BatchVerify(sigs, msgs, pks))
+
+	_, err = Aggregate()
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	assert.EqualError(t, err, "invalid BLS signature: no signatures to aggregate")
+
+	_, err = AggregatePublicKeys(pks...)
+	assert.NoError(t, err)
+	_, err = AggregatePublicKeys()
+	assert.ErrorIs(t, err, ErrInvalidPublicKey)
+	assert.EqualError(t, err, "invalid BLS public key: no public keys to aggregate")
+	_, err = AggregatePublicKeys(pks[0], PublicKey{})
+	assert.ErrorIs(t, err, ErrInvalidPublicKey)
+}
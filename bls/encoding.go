@@ -0,0 +1,112 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package bls
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// The keys and signatures encode to their bytes in binary, and to the hex of
+// their bytes in text and JSON. Decoding checks them as their FromBytes
+// functions do, so that a value read from a wallet or an RPC is usable.
+
+// MarshalBinary returns the bytes of sk.
+func (sk SecretKey) MarshalBinary() ([]byte, error) {
+	return sk.Bytes(), nil
+}
+
+// UnmarshalBinary decodes sk with SecretKeyFromBytes.
+func (sk *SecretKey) UnmarshalBinary(b []byte) (err error) {
+	*sk, err = SecretKeyFromBytes(b)
+	return err
+}
+
+// MarshalText returns the hex of the bytes of sk.
+func (sk SecretKey) MarshalText() ([]byte, error) {
+	return marshalHex(sk[:]), nil
+}
+
+// UnmarshalText decodes sk from hex.
+func (sk *SecretKey) UnmarshalText(text []byte) error {
+	b, err := unmarshalHex(text)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSecretKey, err)
+	}
+	return sk.UnmarshalBinary(b)
+}
+
+// MarshalBinary returns the bytes of pk.
+func (pk PublicKey) MarshalBinary() ([]byte, error) {
+	return pk.Bytes(), nil
+}
+
+// UnmarshalBinary decodes pk with PublicKeyFromBytes.
+func (pk *PublicKey) UnmarshalBinary(b []byte) (err error) {
+	*pk, err = PublicKeyFromBytes(b)
+	return err
+}
+
+// MarshalText returns the hex of the bytes of pk.
+func (pk PublicKey) MarshalText() ([]byte, error) {
+	return marshalHex(pk[:]), nil
+}
+
+// UnmarshalText decodes pk from hex.
+func (pk *PublicKey) UnmarshalText(text []byte) error {
+	b, err := unmarshalHex(text)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPublicKey, err)
+	}
+	return pk.UnmarshalBinary(b)
+}
+
+// String returns the hex of the bytes of pk.
+func (pk PublicKey) String() string {
+	return hex.EncodeToString(pk[:])
+}
+
+// MarshalBinary returns the bytes of sig.
+func (sig Signature) MarshalBinary() ([]byte, error) {
+	return sig.Bytes(), nil
+}
+
+// UnmarshalBinary decodes sig with SignatureFromBytes.
+func (sig *Signature) UnmarshalBinary(b []byte) (err error) {
+	*sig, err = SignatureFromBytes(b)
+	return err
+}
+
+// MarshalText returns the hex of the bytes of sig.
+func (sig Signature) MarshalText() ([]byte, error) {
+	return marshalHex(sig[:]), nil
+}
+
+// UnmarshalText decodes sig from hex.
+func (sig *Signature) UnmarshalText(text []byte) error {
+	b, err := unmarshalHex(text)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	return sig.UnmarshalBinary(b)
+}
+
+// String returns the hex of the bytes of sig.
+func (sig Signature) String() string {
+	return hex.EncodeToString(sig[:])
+}
+
+func marshalHex(b []byte) []byte {
+	out := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(out, b)
+	return out
+}
+
+func unmarshalHex(text []byte) ([]byte, error) {
+	b := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(b, text); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,62 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package bls
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	sk := SecretKeyFromSeed([32]byte{7})
+	pk, err := sk.PublicKey()
+	require.NoError(t, err)
+	sig, err := sk.Sign([]byte("hello"))
+	require.NoError(t, err)
+
+	type wallet struct {
+		SecretKey SecretKey
+		PublicKey PublicKey
+		Signature Signature
+		Zero      Signature
+	}
+	in := wallet{SecretKey: sk, PublicKey: pk, Signature: sig, Zero: ZeroSignature()}
+
+	b, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"PublicKey":"`+pk.String()+`"`)
+
+	var out wallet
+	require.NoError(t, json.Unmarshal(b, &out))
+	assert.Equal(t, in, out)
+
+	bin, err := sig.MarshalBinary()
+	require.NoError(t, err)
+	var sig2 Signature
+	require.NoError(t, sig2.UnmarshalBinary(bin))
+	assert.Equal(t, sig, sig2)
+}
+
+func TestDecodingRejectsInvalid(t *testing.T) {
+	pk, err := SecretKeyFromSeed([32]byte{7}).PublicKey()
+	require.NoError(t, err)
+	text, err := pk.MarshalText()
+	require.NoError(t, err)
+
+	var out PublicKey
+	assert.ErrorIs(t, out.UnmarshalText(text[2:]), ErrInvalidPublicKey)
+	assert.ErrorIs(t, out.UnmarshalText(append([]byte("zz"), text[2:]...)), ErrInvalidPublicKey)
+	assert.ErrorIs(t, out.UnmarshalBinary(make([]byte, len(pk))), ErrInvalidPublicKey)
+	assert.Equal(t, PublicKey{}, out)
+
+	var sig Signature
+	assert.ErrorIs(t, json.Unmarshal([]byte(`"00"`), &sig), ErrInvalidSignature)
+
+	var sk SecretKey
+	assert.ErrorIs(t, sk.UnmarshalText([]byte("00")), ErrInvalidSecretKey)
+	assert.EqualError(t, sk.UnmarshalText([]byte("zz")), "invalid BLS secret key: encoding/hex: invalid byte: U+007A 'z'")
+}
@@ -0,0 +1,221 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrBatcherClosed is returned by BLSBatcher.Verify once the batcher is
+// closed.
+var ErrBatcherClosed = xerrors.New("bls batcher closed")
+
+// BLSBatcherConfig configures a BLSBatcher.
+type BLSBatcherConfig struct {
+	// MaxBatch is the maximum number of signatures verified in one call.
+	// Defaults to 512.
+	MaxBatch int
+
+	// MaxDelay is how long requests arriving while a batch is being verified
+	// are collected before they are dispatched together. Defaults to 2ms.
+	MaxDelay time.Duration
+
+	// MaxInFlight is the maximum number of batches verified concurrently.
+	// Defaults to 1, the native verification being parallel already.
+	MaxInFlight int
+}
+
+// BLSBatcher verifies independent signatures, transparently batching them
+// under load. A request arriving while the batcher is idle is verified right
+// away; requests arriving while verifications are running are collected for
+// up to MaxDelay and verified with one BatchVerify call. When a batch fails,
+// it is bisected to find the invalid signatures.
+type BLSBatcher struct {
+	cfg BLSBatcherConfig
+
+	// batchVerify is BatchVerify, replaced in tests
+	batchVerify func([]Signature, []Message, []PublicKey) bool
+
+	reqs    chan *blsVerifyRequest
+	closing chan struct{}
+	done    chan struct{}
+
+	closeOnce sync.Once
+}
+
+type blsVerifyRequest struct {
+	signature Signature
+	message   Message
+	publicKey PublicKey
+
+	result chan bool
+}
+
+// NewBLSBatcher starts a BLSBatcher. Close must be called to release it.
+func NewBLSBatcher(cfg BLSBatcherConfig) *BLSBatcher {
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 512
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 2 * time.Millisecond
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 1
+	}
+
+	b := &BLSBatcher{
+		cfg:         cfg,
+		batchVerify: BatchVerify,
+		reqs:        make(chan *blsVerifyRequest),
+		closing:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go b.run()
+
+	return b
+}
+
+// Verify returns whether signature is the signature of message by publicKey.
+// An error is only returned when the context is done or the batcher closed.
+func (b *BLSBatcher) Verify(ctx context.Context, signature *Signature, message Message, publicKey PublicKey) (bool, error) {
+	req := &blsVerifyRequest{
+		signature: *signature,
+		message:   message,
+		publicKey: publicKey,
+		result:    make(chan bool, 1),
+	}
+
+	select {
+	case b.reqs <- req:
+	case <-b.closing:
+		return false, ErrBatcherClosed
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	select {
+	case ok := <-req.result:
+		return ok, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// Close stops accepting requests, and returns once the pending ones have
+// been verified.
+func (b *BLSBatcher) Close() {
+	b.closeOnce.Do(func() {
+		close(b.closing)
+	})
+	<-b.done
+}
+
+func (b *BLSBatcher) run() {
+	defer close(b.done)
+
+	var (
+		pending  []*blsVerifyRequest
+		inFlight int
+		finished = make(chan struct{})
+
+		timer      = time.NewTimer(0)
+		timerArmed bool
+	)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	dispatch := func() {
+		n := len(pending)
+		if n > b.cfg.MaxBatch {
+			n = b.cfg.MaxBatch
+		}
+		batch := pending[:n:n]
+		pending = pending[n:]
+
+		inFlight++
+		go func() {
+			b.verify(batch)
+			finished <- struct{}{}
+		}()
+	}
+
+	closing := b.closing
+	for {
+		select {
+		case req := <-b.reqs:
+			pending = append(pending, req)
+
+			switch {
+			case inFlight == 0 && !timerArmed:
+				// idle, don't wait for more requests
+				dispatch()
+			case len(pending) >= b.cfg.MaxBatch && inFlight < b.cfg.MaxInFlight:
+				dispatch()
+			case !timerArmed:
+				timer.Reset(b.cfg.MaxDelay)
+				timerArmed = true
+			}
+
+		case <-timer.C:
+			timerArmed = false
+			// when at capacity, the batch is dispatched once a slot frees up
+			for len(pending) > 0 && inFlight < b.cfg.MaxInFlight {
+				dispatch()
+			}
+
+		case <-finished:
+			inFlight--
+			if len(pending) > 0 && !timerArmed {
+				dispatch()
+			}
+
+		case <-closing:
+			// stop selecting on closing, and flush everything
+			closing = nil
+		}
+
+		if closing == nil {
+			for len(pending) > 0 && inFlight < b.cfg.MaxInFlight {
+				dispatch()
+			}
+			if len(pending) == 0 && inFlight == 0 {
+				timer.Stop()
+				return
+			}
+		}
+	}
+}
+
+// verify checks a batch, bisecting it when it fails so that every request
+// gets its own result.
+func (b *BLSBatcher) verify(batch []*blsVerifyRequest) {
+	signatures := make([]Signature, len(batch))
+	messages := make([]Message, len(batch))
+	publicKeys := make([]PublicKey, len(batch))
+	for i, req := range batch {
+		signatures[i] = req.signature
+		messages[i] = req.message
+		publicKeys[i] = req.publicKey
+	}
+
+	if b.batchVerify(signatures, messages, publicKeys) {
+		for _, req := range batch {
+			req.result <- true
+		}
+		return
+	}
+
+	if len(batch) == 1 {
+		batch[0].result <- false
+		return
+	}
+
+	b.verify(batch[:len(batch)/2])
+	b.verify(batch[len(batch)/2:])
+}
@@ -0,0 +1,61 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBLSBatcher(t *testing.T) {
+	var calls, batched int64
+
+	b := NewBLSBatcher(BLSBatcherConfig{MaxBatch: 16})
+	// a signature is valid when its first byte is set
+	b.batchVerify = func(signatures []Signature, _ []Message, _ []PublicKey) bool {
+		atomic.AddInt64(&calls, 1)
+		if len(signatures) > 1 {
+			atomic.AddInt64(&batched, 1)
+		}
+		time.Sleep(time.Millisecond)
+
+		for _, sig := range signatures {
+			if sig[0] == 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var sig Signature
+			if i%17 != 0 {
+				sig[0] = 1
+			}
+
+			ok, err := b.Verify(context.Background(), &sig, Message("msg"), PublicKey{})
+			require.NoError(t, err)
+			assert.Equal(t, i%17 != 0, ok, "request %d", i)
+		}(i)
+	}
+	wg.Wait()
+	b.Close()
+
+	assert.Greater(t, atomic.LoadInt64(&batched), int64(0))
+	assert.Less(t, atomic.LoadInt64(&calls), int64(200))
+
+	_, err := b.Verify(context.Background(), &Signature{}, Message("msg"), PublicKey{})
+	assert.Equal(t, ErrBatcherClosed, err)
+}
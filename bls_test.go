@@ -77,6 +77,30 @@ func TestBLSSigningAndVerification(t *testing.T) {
 	assert.False(t, HashVerify(aggregateSign, []Message{fooMessage, barMessage}, []PublicKey{*fooPublicKey}))
 }
 
+func TestBatchVerify(t *testing.T) {
+	// generate private keys
+	fooPrivateKey := PrivateKeyGenerate()
+	barPrivateKey := PrivateKeyGenerate()
+
+	// get the public keys for the private keys
+	fooPublicKey := PrivateKeyPublicKey(fooPrivateKey)
+	barPublicKey := PrivateKeyPublicKey(barPrivateKey)
+
+	// make messages to sign with the keys
+	fooMessage := Message("hello foo")
+	barMessage := Message("hello bar!")
+
+	// get the signature when signing the messages with the private keys
+	fooSignature := PrivateKeySign(fooPrivateKey, fooMessage)
+	barSignature := PrivateKeySign(barPrivateKey, barMessage)
+
+	// assert the foo and bar signatures verify as a batch
+	assert.True(t, BatchVerify([]Signature{*fooSignature, *barSignature}, []Message{fooMessage, barMessage}, []PublicKey{*fooPublicKey, *barPublicKey}))
+
+	// assert swapped signatures don't verify as a batch, although their aggregate is the same
+	assert.False(t, BatchVerify([]Signature{*barSignature, *fooSignature}, []Message{fooMessage, barMessage}, []PublicKey{*fooPublicKey, *barPublicKey}))
+}
+
 func BenchmarkBLSVerify(b *testing.B) {
 	priv := PrivateKeyGenerate()
 
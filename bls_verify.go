@@ -0,0 +1,85 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+)
+
+var (
+	// ErrMalformedSignature is returned by VerifyE and HashVerifyE for
+	// signatures which aren't points of the G2 subgroup, or are the identity.
+	ErrMalformedSignature = xerrors.New("malformed BLS signature")
+	// ErrMalformedPublicKey is returned by VerifyE and HashVerifyE for public
+	// keys which aren't points of the G1 subgroup, or are the identity.
+	ErrMalformedPublicKey = xerrors.New("malformed BLS public key")
+)
+
+// AggregateVerify tells whether signature is the aggregate of the signatures
+// of messages[i] by publicKeys[i]. It is HashVerify, which flattens the
+// messages for the native library, returning false rather than calling it
+// when messages and publicKeys don't pair up. The messages must be distinct.
+func AggregateVerify(signature Signature, messages [][]byte, publicKeys []PublicKey) bool {
+	if len(messages) == 0 || len(messages) != len(publicKeys) {
+		return false
+	}
+	return HashVerify(&signature, messages, publicKeys)
+}
+
+// VerifyE is Verify telling a malformed input from a signature which doesn't
+// verify: it returns false and an error wrapping ErrMalformedSignature or
+// ErrMalformedPublicKey for points which can't be verified, and
+// ErrInvalidInput when digests and publicKeys don't pair up. The keys are
+// decoded once to check them, then again by Verify.
+func VerifyE(signature *Signature, digests []Digest, publicKeys []PublicKey) (bool, error) {
+	if err := checkVerifyInputs(signature, len(digests), publicKeys); err != nil {
+		return false, err
+	}
+	for i := range digests {
+		if SignatureStatus(digests[i]) != PointValid {
+			return false, xerrors.Errorf("digest %d is not a point of the G2 subgroup: %w", i, ErrInvalidInput)
+		}
+	}
+	return Verify(signature, digests, publicKeys), nil
+}
+
+// HashVerifyE is HashVerify telling a malformed input from a signature which
+// doesn't verify, as VerifyE does.
+func HashVerifyE(signature *Signature, messages []Message, publicKeys []PublicKey) (bool, error) {
+	if err := checkVerifyInputs(signature, len(messages), publicKeys); err != nil {
+		return false, err
+	}
+	return HashVerify(signature, messages, publicKeys), nil
+}
+
+func checkVerifyInputs(signature *Signature, messages int, publicKeys []PublicKey) error {
+	if messages == 0 || messages != len(publicKeys) {
+		return xerrors.Errorf("%d messages for %d public keys: %w", messages, len(publicKeys), ErrInvalidInput)
+	}
+	if signature == nil {
+		return fmt.Errorf("%w: no signature", ErrMalformedSignature)
+	}
+	if err := pointError(SignatureStatus(*signature), "G2"); err != nil {
+		return fmt.Errorf("%w: %s", ErrMalformedSignature, err)
+	}
+	for i, pk := range publicKeys {
+		if err := pointError(PublicKeyStatus(pk), "G1"); err != nil {
+			return fmt.Errorf("%w %d: %s", ErrMalformedPublicKey, i, err)
+		}
+	}
+	return nil
+}
+
+func pointError(status PointStatus, group string) error {
+	switch status {
+	case PointValid:
+		return nil
+	case PointIdentity:
+		return xerrors.New("identity point")
+	default:
+		return xerrors.Errorf("not a point of the %s subgroup", group)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashVerifyE(t *testing.T) {
+	privateKey := PrivateKeyGenerateWithSeed(PrivateKeyGenSeed{1})
+	publicKey := PrivateKeyPublicKey(privateKey)
+	message := Message("hello")
+	signature := PrivateKeySign(privateKey, message)
+
+	ok, err := HashVerifyE(signature, []Message{message}, []PublicKey{*publicKey})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = HashVerifyE(signature, []Message{Message("bye")}, []PublicKey{*publicKey})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = VerifyE(signature, []Digest{Hash(message)}, []PublicKey{*publicKey})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	zero := CreateZeroSignature()
+	_, err = HashVerifyE(&zero, []Message{message}, []PublicKey{*publicKey})
+	assert.ErrorIs(t, err, ErrMalformedSignature)
+
+	_, err = HashVerifyE(signature, []Message{message}, []PublicKey{{}})
+	assert.ErrorIs(t, err, ErrMalformedPublicKey)
+
+	_, err = HashVerifyE(nil, []Message{message}, []PublicKey{*publicKey})
+	assert.EqualError(t, err, "malformed BLS signature: no signature")
+
+	_, err = HashVerifyE(signature, []Message{message}, nil)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestAggregateVerify(t *testing.T) {
+	var (
+		messages   [][]byte
+		publicKeys []PublicKey
+		signatures []Signature
+	)
+	for i := byte(1); i <= 3; i++ {
+		privateKey := PrivateKeyGenerateWithSeed(PrivateKeyGenSeed{i})
+		message := []byte{'m', i}
+		messages = append(messages, message)
+		publicKeys = append(publicKeys, *PrivateKeyPublicKey(privateKey))
+		signatures = append(signatures, *PrivateKeySign(privateKey, message))
+	}
+	// an empty message is flattened like any other
+	privateKey := PrivateKeyGenerateWithSeed(PrivateKeyGenSeed{4})
+	messages = append(messages, []byte{})
+	publicKeys = append(publicKeys, *PrivateKeyPublicKey(privateKey))
+	signatures = append(signatures, *PrivateKeySign(privateKey, []byte{}))
+
+	aggregate := Aggregate(signatures)
+	require.NotNil(t, aggregate)
+
+	assert.True(t, AggregateVerify(*aggregate, messages, publicKeys))
+	assert.False(t, AggregateVerify(*aggregate, messages[:3], publicKeys[:3]))
+	assert.False(t, AggregateVerify(*aggregate, messages, publicKeys[:3]))
+	assert.False(t, AggregateVerify(*aggregate, nil, nil))
+
+	messages[0], messages[1] = messages[1], messages[0]
+	assert.False(t, AggregateVerify(*aggregate, messages, publicKeys))
+}
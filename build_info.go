@@ -0,0 +1,56 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+// Cargo features of the native library reported by GetLibraryBuildInfo.
+const (
+	FeatureCUDA         = "cuda"
+	FeatureOpenCL       = "opencl"
+	FeatureMulticoreSDR = "multicore-sdr"
+	FeatureBLSTPortable = "blst-portable"
+	FeatureInjectPanic  = "inject-panic"
+)
+
+// LibraryBuildInfo describes the native library linked in.
+type LibraryBuildInfo struct {
+	// Version is the version of the filcrypto crate.
+	Version string `json:"version"`
+	// GitRevision is the commit the library was built from, empty when it
+	// wasn't built by install-filcrypto from a git checkout.
+	GitRevision string `json:"git_revision"`
+	// Features are the cargo features the library was built with.
+	Features []string `json:"features"`
+}
+
+// HasFeature tells whether the library was built with a cargo feature.
+func (i LibraryBuildInfo) HasFeature(feature string) bool {
+	for _, f := range i.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLibraryBuildInfo returns the version, git revision and features of the
+// native library, for diagnostics. The library of this release has no
+// SupraSeal feature.
+func GetLibraryBuildInfo() (LibraryBuildInfo, error) {
+	raw, err := buildInfo()
+	if err != nil {
+		return LibraryBuildInfo{}, err
+	}
+
+	var info LibraryBuildInfo
+	if err := json.Unmarshal([]byte(raw), &info); err != nil {
+		return LibraryBuildInfo{}, xerrors.Errorf("decoding library build info: %w", err)
+	}
+	return info, nil
+}
@@ -0,0 +1,143 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// pAuxSize is the size of p_aux, which holds comm_c and comm_r_last.
+const pAuxSize = 2 * nodeSize
+
+// CacheFileStatus is the result of checking a cache file.
+type CacheFileStatus int
+
+const (
+	CacheFileOK CacheFileStatus = iota
+	CacheFileMissing
+	CacheFileWrongSize
+)
+
+func (s CacheFileStatus) String() string {
+	switch s {
+	case CacheFileOK:
+		return "ok"
+	case CacheFileMissing:
+		return "missing"
+	case CacheFileWrongSize:
+		return "wrong size"
+	default:
+		return fmt.Sprintf("CacheFileStatus(%d)", int(s))
+	}
+}
+
+// CacheFileCheck is the check of a file of a cache directory.
+type CacheFileCheck struct {
+	Name string
+	// Required is set for the files proving the sector needs, which
+	// ClearCache keeps. The others are only needed to finish sealing.
+	Required bool
+	Status   CacheFileStatus
+	// Size is the size of the file, and ExpectedSize the size it should
+	// have, or 0 when it varies.
+	Size         int64
+	ExpectedSize int64
+}
+
+// CacheReport is the result of CheckCache.
+type CacheReport struct {
+	Files []CacheFileCheck
+}
+
+// OK tells whether the sector can be proven: every required file is there,
+// and the files present have the expected size.
+func (r CacheReport) OK() bool {
+	return len(r.Problems()) == 0
+}
+
+// Problems returns the checks of the missing required files, and of the
+// files present with a wrong size.
+func (r CacheReport) Problems() []CacheFileCheck {
+	var out []CacheFileCheck
+	for _, f := range r.Files {
+		if f.Status == CacheFileWrongSize || (f.Status == CacheFileMissing && f.Required) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// CheckCache checks the files of the cache directory of a sector sealed with
+// proofType against the layout rust-fil-proofs writes: p_aux, t_aux and the
+// tree_r_last files, and the SDR layers, tree_d and tree_c files until the
+// cache is cleared. It only looks at the sizes of the files, which catches
+// truncated and missing files but not damaged contents.
+//
+// Errors are only returned for invalid proof types and unreadable files,
+// the state of the cache is in the report.
+func CheckCache(cacheDirPath string, proofType abi.RegisteredSealProof) (CacheReport, error) {
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return CacheReport{}, err
+	}
+	leaves := uint64(ssize) / nodeSize
+	files := treeRLastFileCount(ssize)
+
+	var report CacheReport
+	check := func(name string, required bool, expected int64) error {
+		f := CacheFileCheck{Name: name, Required: required, ExpectedSize: expected}
+		st, err := os.Stat(filepath.Join(cacheDirPath, name))
+		switch {
+		case os.IsNotExist(err):
+			f.Status = CacheFileMissing
+		case err != nil:
+			return xerrors.Errorf("checking cache: %w", err)
+		default:
+			f.Size = st.Size()
+			if (expected > 0 && f.Size != expected) || f.Size == 0 {
+				f.Status = CacheFileWrongSize
+			}
+		}
+		report.Files = append(report.Files, f)
+		return nil
+	}
+
+	if err := check(cachePAuxName, true, pAuxSize); err != nil {
+		return CacheReport{}, err
+	}
+	if err := check(cacheTAuxName, true, 0); err != nil {
+		return CacheReport{}, err
+	}
+	for i := uint64(0); i < files; i++ {
+		name := filepath.Base(treeRLastPath(cacheDirPath, i, files))
+		if err := check(name, true, treeRLastCachedSize(leaves/files)); err != nil {
+			return CacheReport{}, err
+		}
+	}
+
+	for layer := uint64(1); layer <= sdrLayers(ssize); layer++ {
+		if err := check(fmt.Sprintf("%s%d.dat", cacheLayerPrefix, layer), false, int64(ssize)); err != nil {
+			return CacheReport{}, err
+		}
+	}
+	if err := check(cacheTreeDName, false, int64(2*leaves-1)*nodeSize); err != nil {
+		return CacheReport{}, err
+	}
+	for i := uint64(0); i < files; i++ {
+		name := cacheTreeCPrefix + ".dat"
+		if files > 1 {
+			name = fmt.Sprintf("%s-%d.dat", cacheTreeCPrefix, i)
+		}
+		if err := check(name, false, treeCFileSize(leaves/files)); err != nil {
+			return CacheReport{}, err
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,57 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644))
+	}
+
+	// A sealed 2KiB sector: 64 leaves in a single tree_r_last and tree_c.
+	write("p_aux", 64)
+	write("t_aux", 100)
+	write("sc-02-data-tree-r-last.dat", 32)
+	write("sc-02-data-layer-1.dat", 2048)
+	write("sc-02-data-layer-2.dat", 2048)
+	write("sc-02-data-tree-d.dat", 127*32)
+	write("sc-02-data-tree-c.dat", 73*32)
+
+	report, err := CheckCache(dir, abi.RegisteredSealProof_StackedDrg2KiBV1_1)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems())
+	assert.Len(t, report.Files, 7)
+
+	// Clearing the cache keeps the sector provable.
+	_, err = ClearCacheFiles(dir, ClearOptions{KeepTreeRLast: true, KeepPAux: true})
+	require.NoError(t, err)
+	report, err = CheckCache(dir, abi.RegisteredSealProof_StackedDrg2KiBV1_1)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems())
+	assert.Equal(t, CacheFileMissing, report.Files[3].Status)
+
+	write("p_aux", 10)
+	require.NoError(t, os.Remove(filepath.Join(dir, "sc-02-data-tree-r-last.dat")))
+	report, err = CheckCache(dir, abi.RegisteredSealProof_StackedDrg2KiBV1_1)
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []CacheFileCheck{
+		{Name: "p_aux", Required: true, Status: CacheFileWrongSize, Size: 10, ExpectedSize: 64},
+		{Name: "sc-02-data-tree-r-last.dat", Required: true, Status: CacheFileMissing},
+	}, report.Problems())
+
+	_, err = CheckCache(dir, abi.RegisteredSealProof(-1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// The files of a sector cache directory, as written by rust-fil-proofs.
+const (
+	cacheLayerPrefix     = "sc-02-data-layer-"
+	cacheTreeDName       = "sc-02-data-tree-d.dat"
+	cacheTreeCPrefix     = "sc-02-data-tree-c"
+	cacheTreeRLastPrefix = "sc-02-data-tree-r-last"
+	cachePAuxName        = "p_aux"
+	cacheTAuxName        = "t_aux"
+
+	// cacheSyntheticProofsName holds the vanilla proofs of synthetic PoRep,
+	// written by the proofs releases supporting it.
+	cacheSyntheticProofsName = "syn-porep-vanilla-proofs.dat"
+)
+
+// ClearOptions selects the files ClearCacheFiles keeps. The zero value
+// removes every sealing file of the cache directory.
+type ClearOptions struct {
+	// KeepTreeRLast keeps the tree_r_last files, which proving the sector
+	// reads.
+	KeepTreeRLast bool
+	// KeepPAux keeps the p_aux and t_aux files, which hold the roots of the
+	// trees and their configuration.
+	KeepPAux bool
+	// KeepSyntheticProofs keeps the vanilla proofs of synthetic PoRep.
+	KeepSyntheticProofs bool
+}
+
+// ClearReport lists the files removed from a cache directory.
+type ClearReport struct {
+	// Removed are the names of the removed files, sorted.
+	Removed []string
+	// Bytes is the space taken by the removed files.
+	Bytes int64
+}
+
+// ClearCacheFiles removes the sealing files of a cache directory not kept by
+// opts. Other files are left alone.
+//
+// ClearCacheFiles with KeepTreeRLast and KeepPAux removes the same files as
+// ClearCache: the SDR layers, tree_d and tree_c.
+func ClearCacheFiles(cacheDirPath string, opts ClearOptions) (ClearReport, error) {
+	return clearCacheFiles(cacheDirPath, func(name string) bool {
+		switch {
+		case isLayerFile(name), name == cacheTreeDName, strings.HasPrefix(name, cacheTreeCPrefix):
+			return true
+		case strings.HasPrefix(name, cacheTreeRLastPrefix):
+			return !opts.KeepTreeRLast
+		case name == cachePAuxName, name == cacheTAuxName:
+			return !opts.KeepPAux
+		case name == cacheSyntheticProofsName:
+			return !opts.KeepSyntheticProofs
+		default:
+			return false
+		}
+	})
+}
+
+// ClearLayerData removes the SDR layers of a cache directory, the largest of
+// its files, which are only needed until PC2 completes.
+func ClearLayerData(cacheDirPath string) (ClearReport, error) {
+	return clearCacheFiles(cacheDirPath, isLayerFile)
+}
+
+// ClearSyntheticProofs removes the vanilla proofs of synthetic PoRep of a
+// cache directory, once the commit proof is computed.
+func ClearSyntheticProofs(cacheDirPath string) (ClearReport, error) {
+	return clearCacheFiles(cacheDirPath, func(name string) bool { return name == cacheSyntheticProofsName })
+}
+
+func isLayerFile(name string) bool {
+	return strings.HasPrefix(name, cacheLayerPrefix) && strings.HasSuffix(name, ".dat")
+}
+
+func clearCacheFiles(cacheDirPath string, remove func(name string) bool) (ClearReport, error) {
+	entries, err := os.ReadDir(cacheDirPath)
+	if err != nil {
+		return ClearReport{}, xerrors.Errorf("clearing cache: %w", err)
+	}
+
+	var report ClearReport
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !remove(e.Name()) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return report, xerrors.Errorf("clearing cache: %w", err)
+		}
+		if err := os.Remove(filepath.Join(cacheDirPath, e.Name())); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return report, xerrors.Errorf("clearing cache: %w", err)
+		}
+		report.Removed = append(report.Removed, e.Name())
+		report.Bytes += info.Size()
+	}
+	sort.Strings(report.Removed)
+	return report, nil
+}
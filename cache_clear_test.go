@@ -0,0 +1,81 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCacheFiles(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"sc-02-data-layer-1.dat", "sc-02-data-layer-2.dat",
+		"sc-02-data-tree-d.dat",
+		"sc-02-data-tree-c-0.dat", "sc-02-data-tree-c-1.dat",
+		"sc-02-data-tree-r-last-0.dat", "sc-02-data-tree-r-last-1.dat",
+		"p_aux", "t_aux",
+		"syn-porep-vanilla-proofs.dat",
+		"notes.txt",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), make([]byte, 10), 0644))
+	}
+	return dir
+}
+
+func cacheFileNames(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestClearCacheFiles(t *testing.T) {
+	dir := writeCacheFiles(t)
+	report, err := ClearCacheFiles(dir, ClearOptions{KeepTreeRLast: true, KeepPAux: true, KeepSyntheticProofs: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"sc-02-data-layer-1.dat", "sc-02-data-layer-2.dat",
+		"sc-02-data-tree-c-0.dat", "sc-02-data-tree-c-1.dat",
+		"sc-02-data-tree-d.dat",
+	}, report.Removed)
+	assert.Equal(t, int64(50), report.Bytes)
+	assert.Equal(t, []string{
+		"notes.txt", "p_aux",
+		"sc-02-data-tree-r-last-0.dat", "sc-02-data-tree-r-last-1.dat",
+		"syn-porep-vanilla-proofs.dat", "t_aux",
+	}, cacheFileNames(t, dir))
+
+	report, err = ClearCacheFiles(dir, ClearOptions{})
+	require.NoError(t, err)
+	assert.Len(t, report.Removed, 5)
+	assert.Equal(t, []string{"notes.txt"}, cacheFileNames(t, dir))
+
+	_, err = ClearCacheFiles(filepath.Join(dir, "missing"), ClearOptions{})
+	assert.Error(t, err)
+}
+
+func TestClearLayerData(t *testing.T) {
+	dir := writeCacheFiles(t)
+	report, err := ClearLayerData(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ClearReport{Removed: []string{"sc-02-data-layer-1.dat", "sc-02-data-layer-2.dat"}, Bytes: 20}, report)
+
+	report, err = ClearSyntheticProofs(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ClearReport{Removed: []string{"syn-porep-vanilla-proofs.dat"}, Bytes: 10}, report)
+
+	report, err = ClearSyntheticProofs(dir)
+	require.NoError(t, err)
+	assert.Empty(t, report.Removed)
+	assert.Len(t, cacheFileNames(t, dir), 8)
+}
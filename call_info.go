@@ -0,0 +1,61 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import "time"
+
+// CallInfo describes a native call.
+type CallInfo struct {
+	// Name is the name of the native function, e.g. "seal_commit_phase2".
+	Name string
+	// ProofType is the name of the registered proof of the call, if any.
+	ProofType string
+	// SectorID is the number of the sector of the call, if HasSectorID.
+	SectorID    uint64
+	HasSectorID bool
+}
+
+// Hooks receives the native calls, for custom metrics, audit logs or
+// accounting. Its methods run on the goroutine making the call, so they should
+// be quick, and may be called concurrently.
+type Hooks interface {
+	// OnCallStart is called before the native call.
+	OnCallStart(call CallInfo)
+	// OnCallEnd is called once the native call returned, with its error and
+	// duration.
+	OnCallEnd(call CallInfo, err error, duration time.Duration)
+}
+
+// CallReport is the timing and resource usage of a native call.
+type CallReport struct {
+	CallInfo
+	Err error
+
+	WallTime time.Duration
+	// CPUTime is the user and system CPU time used by the process during the
+	// call, which includes the threads of the native library and those of
+	// any call running concurrently.
+	CPUTime time.Duration
+	// PeakRSS is the peak resident set size of the process when the call
+	// returned, in bytes. It only says how much memory a call needs when run
+	// alone in the process.
+	PeakRSS uint64
+	// GPUTime is the time a GPU heavy call held its GPU, as metered by
+	// AddGPUMeter: the native call runs from its admission, by the package
+	// Scheduler if any, to its release. It is zero for the other calls.
+	GPUTime time.Duration
+}
+
+// ActiveCall is a native call in flight.
+type ActiveCall struct {
+	CallInfo
+	// Args summarizes the commitments and proofs the call was given, as
+	// name=digest pairs of their truncated SHA-256 digests, like the call
+	// logs. It is empty for the calls not reporting their arguments.
+	Args  string
+	Start time.Time
+	// Goroutine is the id of the goroutine making the call, to look it up
+	// in a goroutine dump.
+	Goroutine uint64
+}
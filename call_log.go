@@ -0,0 +1,80 @@
+//go:build cgo && !ffimock && go1.21
+// +build cgo,!ffimock,go1.21
+
+package ffi
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+var (
+	callLoggingLk     sync.Mutex
+	removeCallLogging func()
+)
+
+// SetCallLogger logs every native call to logger once it returned, with the
+// proof type, sector number, truncated SHA-256 digests of the commitments and
+// proofs it was given, duration and response status. Calls succeeding are
+// logged at debug level, and failing ones at warning level. A nil logger
+// disables call logging, which is the default.
+//
+// The digests allow matching the inputs of a call with those of another
+// without logging them.
+func SetCallLogger(logger *slog.Logger) {
+	callLoggingLk.Lock()
+	defer callLoggingLk.Unlock()
+
+	if removeCallLogging != nil {
+		removeCallLogging()
+		removeCallLogging = nil
+	}
+	if logger == nil {
+		return
+	}
+
+	removeCallLogging = cgo.AddCallHook(callLogHook(logger))
+}
+
+func callLogHook(logger *slog.Logger) cgo.CallHook {
+	return func(call cgo.Call) func(cgo.CallResult) {
+		return func(res cgo.CallResult) {
+			level := slog.LevelDebug
+			if res.Err != nil {
+				level = slog.LevelWarn
+			}
+			ctx := context.Background()
+			if !logger.Enabled(ctx, level) {
+				return
+			}
+
+			attrs := make([]slog.Attr, 0, 7)
+			if call.ProofType != "" {
+				attrs = append(attrs, slog.String("proof_type", call.ProofType))
+			}
+			if call.HasSectorID {
+				attrs = append(attrs, slog.Uint64("sector_id", call.SectorID))
+			}
+			if call.Args != nil {
+				args := call.Args()
+				digests := make([]any, 0, len(args))
+				for _, arg := range args {
+					digests = append(digests, slog.String(arg.Name, argDigest(arg.Value)))
+				}
+				attrs = append(attrs, slog.Group("args", digests...))
+			}
+			attrs = append(attrs,
+				slog.Duration("duration", res.Duration),
+				slog.Int("returned_bytes", res.ReturnedBytes),
+				slog.String("status", callStatus(res.Err)),
+			)
+			if res.Err != nil {
+				attrs = append(attrs, slog.String("error", res.Err.Error()))
+			}
+			logger.LogAttrs(ctx, level, call.Name, attrs...)
+		}
+	}
+}
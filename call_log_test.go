@@ -0,0 +1,53 @@
+//go:build !ffimock && go1.21
+// +build !ffimock,go1.21
+
+package ffi
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+func TestCallLogHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	hook := callLogHook(logger)
+
+	proof := []byte("proof")
+	call := cgo.Call{
+		Name:        "verify_seal",
+		ProofType:   "StackedDrg2KiBV1_1",
+		SectorID:    7,
+		HasSectorID: true,
+		Args:        func() []cgo.CallArg { return []cgo.CallArg{{Name: "proof", Value: proof}} },
+	}
+	hook(call)(cgo.CallResult{Duration: time.Second})
+	hook(call)(cgo.CallResult{Err: ErrInvalidInput, Duration: time.Millisecond})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var ok, failed map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &ok))
+	require.NoError(t, json.Unmarshal(lines[1], &failed))
+
+	assert.Equal(t, "DEBUG", ok["level"])
+	assert.Equal(t, "verify_seal", ok["msg"])
+	assert.Equal(t, "StackedDrg2KiBV1_1", ok["proof_type"])
+	assert.Equal(t, 7.0, ok["sector_id"])
+	assert.Equal(t, map[string]interface{}{"proof": argDigest(proof)}, ok["args"])
+	assert.Equal(t, "ok", ok["status"])
+	assert.Len(t, argDigest(proof), 2*argDigestSize)
+
+	assert.Equal(t, "WARN", failed["level"])
+	assert.Equal(t, "invalid_input", failed["status"])
+	assert.NotEmpty(t, failed["error"])
+}
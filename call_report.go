@@ -0,0 +1,51 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import "github.com/filecoin-project/filecoin-ffi/cgo"
+
+// AddCallReporter calls report once every native call returned, and returns a
+// function removing it. report runs on the goroutine making the call, so it
+// should be quick.
+//
+// CPUTime and PeakRSS are only reported on Linux.
+func AddCallReporter(report func(CallReport)) (remove func()) {
+	return cgo.AddCallHook(reportHook(report))
+}
+
+// gpuCalls are the native functions of the GPU heavy calls, which run
+// holding the GPU they were admitted to.
+var gpuCalls = map[string]OpClass{
+	"seal_pre_commit_phase2":                          OpSealPreCommit2,
+	"seal_commit_phase2":                              OpSealCommit2,
+	"generate_winning_post":                           OpWinningPoSt,
+	"generate_winning_post_with_vanilla":              OpWinningPoSt,
+	"generate_window_post":                            OpWindowPoSt,
+	"generate_window_post_with_vanilla":               OpWindowPoSt,
+	"generate_single_window_post_with_vanilla":        OpWindowPoSt,
+	"empty_sector_update_encode_into":                 OpSectorUpdate,
+	"generate_empty_sector_update_proof":              OpSectorUpdate,
+	"generate_empty_sector_update_proof_with_vanilla": OpSectorUpdate,
+}
+
+func reportHook(report func(CallReport)) cgo.CallHook {
+	return func(call cgo.Call) func(cgo.CallResult) {
+		start, _ := processUsage()
+		return func(res cgo.CallResult) {
+			r := CallReport{
+				CallInfo: toCallInfo(call),
+				Err:      res.Err,
+				WallTime: res.Duration,
+			}
+			if _, ok := gpuCalls[call.Name]; ok {
+				r.GPUTime = res.Duration
+			}
+			if end, ok := processUsage(); ok {
+				r.CPUTime = end.cpu - start.cpu
+				r.PeakRSS = end.maxRSS
+			}
+			report(r)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+func TestReportHook(t *testing.T) {
+	var reports []CallReport
+	hook := reportHook(func(r CallReport) { reports = append(reports, r) })
+
+	call := cgo.Call{Name: "seal_pre_commit_phase1", ProofType: "StackedDrg2KiBV1_1", SectorID: 42, HasSectorID: true}
+	done := hook(call)
+	// Burn some CPU time.
+	for end := time.Now().Add(20 * time.Millisecond); time.Now().Before(end); {
+	}
+	done(cgo.CallResult{Err: ErrInvalidInput, Duration: time.Second})
+
+	require.Len(t, reports, 1)
+	r := reports[0]
+	assert.Equal(t, call.Name, r.Name)
+	assert.Equal(t, call.ProofType, r.ProofType)
+	assert.Equal(t, uint64(42), r.SectorID)
+	assert.True(t, r.HasSectorID)
+	assert.Equal(t, ErrInvalidInput, r.Err)
+	assert.Equal(t, time.Second, r.WallTime)
+	assert.Zero(t, r.GPUTime)
+	if runtime.GOOS == "linux" {
+		assert.Greater(t, int64(r.CPUTime), int64(0))
+		assert.Greater(t, r.PeakRSS, uint64(0))
+	}
+}
+
+func TestReportHookGPUTime(t *testing.T) {
+	var reports []CallReport
+	hook := reportHook(func(r CallReport) { reports = append(reports, r) })
+
+	hook(cgo.Call{Name: "seal_commit_phase2"})(cgo.CallResult{Duration: time.Minute})
+	require.Len(t, reports, 1)
+	assert.Equal(t, time.Minute, reports[0].GPUTime)
+}
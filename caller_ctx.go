@@ -0,0 +1,46 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+var (
+	// callerCtxUsers counts the enabled call hooks using the contexts of the
+	// Ctx variants, such as the profiler labels and tracing.
+	callerCtxUsers int32
+
+	// callerCtxs holds the contexts of the Ctx variants running while
+	// callerCtxUsers > 0, by goroutine id.
+	callerCtxs sync.Map
+)
+
+// withCallerCtx runs fn, on the goroutine of a Ctx variant, so that the hooks
+// of its native calls find ctx with callerCtx.
+func withCallerCtx(ctx context.Context, fn func() error) error {
+	if atomic.LoadInt32(&callerCtxUsers) == 0 {
+		return fn()
+	}
+	id := cgo.CurrentGoroutine()
+	callerCtxs.Store(id, ctx)
+	defer callerCtxs.Delete(id)
+	return fn()
+}
+
+// callerCtx returns the context of the Ctx variant making the native call on
+// the current goroutine, or context.Background for other functions.
+func callerCtx() context.Context {
+	if atomic.LoadInt32(&callerCtxUsers) == 0 {
+		return context.Background()
+	}
+	if ctx, ok := callerCtxs.Load(cgo.CurrentGoroutine()); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
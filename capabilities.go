@@ -0,0 +1,59 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"sort"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// ErrUnsupportedProof is returned by CheckSealProofSupported and
+// CheckPoStProofSupported for proof types the native library can't handle.
+var ErrUnsupportedProof = xerrors.New("proof type not supported by the native library")
+
+// ListSupportedSealProofs returns the registered seal proofs known to
+// go-state-types which the native library can seal and verify, in order.
+func ListSupportedSealProofs() []abi.RegisteredSealProof {
+	var out []abi.RegisteredSealProof
+	for p := range abi.SealProofInfos {
+		if _, err := GetSealVersion(p); err == nil {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// ListSupportedPoStProofs returns the registered PoSt proofs known to
+// go-state-types which the native library can generate and verify, in order.
+func ListSupportedPoStProofs() []abi.RegisteredPoStProof {
+	var out []abi.RegisteredPoStProof
+	for p := range abi.PoStProofInfos {
+		if _, err := GetPoStVersion(p); err == nil {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// CheckSealProofSupported returns an error wrapping ErrUnsupportedProof if the
+// native library can't seal sectors with proofType.
+func CheckSealProofSupported(proofType abi.RegisteredSealProof) error {
+	if _, err := GetSealVersion(proofType); err != nil {
+		return xerrors.Errorf("seal proof %d (%s): %w", proofType, err, ErrUnsupportedProof)
+	}
+	return nil
+}
+
+// CheckPoStProofSupported returns an error wrapping ErrUnsupportedProof if the
+// native library can't prove sectors with proofType.
+func CheckPoStProofSupported(proofType abi.RegisteredPoStProof) error {
+	if _, err := GetPoStVersion(proofType); err != nil {
+		return xerrors.Errorf("PoSt proof %d (%s): %w", proofType, err, ErrUnsupportedProof)
+	}
+	return nil
+}
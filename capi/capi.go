@@ -0,0 +1,238 @@
+//go:build cgo
+// +build cgo
+
+// Command capi exposes the Go proving layer to non-Go hosts. It is meant to be
+// built as a C archive or shared library:
+//
+//	go build -buildmode=c-archive -o libfilgo.a ./capi
+//
+// which also produces libfilgo.h. The resulting archive embeds libfilcrypto,
+// so hosts must link it the same way this package does (see filcrypto.pc).
+//
+// Calls are made with JSON-RPC 2.0 requests using the lotus worker method set
+// of the worker package, e.g.
+//
+//	{"jsonrpc":"2.0","id":1,"method":"Filecoin.SealCommit2","params":[{"ID":{"Miner":1000,"Number":1},"ProofType":8},"<base64 c1 output>"]}
+//
+// either served in-process (FilGoCall) or forwarded to a remote worker
+// (FilGoRemoteCall). As with a lotus worker, SealCommit2 answers with a
+// CallID; the proof of a call served in-process is then obtained with
+// FilGoWaitCall. The GPU heavy calls served in-process wait for the scheduler
+// set with FilGoSetScheduler, if any. Every returned string is allocated with
+// malloc and must be released with FilGoFree.
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"unsafe"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/filecoin-ffi/worker"
+)
+
+var (
+	lk      sync.Mutex
+	local   *worker.Server
+	results *worker.Results
+	remotes = map[uint64]*worker.Client{}
+	nextID  uint64
+)
+
+func main() {}
+
+// FilGoInit sets up the in-process server. PoSt sectors are looked up in the
+// lotus storage layout under storageRoot, which may be NULL when only
+// SealCommit2 is used. It returns NULL on success, or an error message.
+//
+//export FilGoInit
+func FilGoInit(storageRoot *C.char) *C.char {
+	var paths worker.PathResolver
+	if storageRoot != nil {
+		paths = worker.StoragePaths(C.GoString(storageRoot))
+	}
+
+	srv, err := worker.NewServer(ffi.Proofs, paths)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	res := worker.NewResults()
+	srv.SetReturn(res)
+
+	lk.Lock()
+	local, results = srv, res
+	lk.Unlock()
+
+	return nil
+}
+
+// FilGoSetScheduler bounds the GPU heavy calls served in-process with a
+// scheduler configured by the JSON encoding of an ffi.SchedulerConfig, e.g.
+//
+//	{"MaxConcurrent":{"SealCommit2":1,"WindowPoSt":1},"GPUSlots":[2]}
+//
+// A NULL config removes the scheduler. It returns NULL on success, or an
+// error message.
+//
+//export FilGoSetScheduler
+func FilGoSetScheduler(config *C.char) *C.char {
+	if config == nil {
+		ffi.SetScheduler(nil)
+		return nil
+	}
+
+	var cfg ffi.SchedulerConfig
+	if err := json.Unmarshal([]byte(C.GoString(config)), &cfg); err != nil {
+		return C.CString(err.Error())
+	}
+
+	ffi.SetScheduler(ffi.NewScheduler(cfg))
+	return nil
+}
+
+// FilGoCall executes a JSON-RPC request in-process and returns the response.
+// It returns NULL for notifications. The proofs are computed with ffi.Proofs,
+// that is the Ctx variants of the proving functions, which wait for the
+// scheduler of FilGoSetScheduler.
+//
+//export FilGoCall
+func FilGoCall(request *C.char) *C.char {
+	lk.Lock()
+	srv := local
+	lk.Unlock()
+
+	if srv == nil {
+		return errorResponse(request, "FilGoInit has not been called")
+	}
+
+	return toCString(srv.Handle(context.Background(), []byte(C.GoString(request))))
+}
+
+// FilGoWaitCall waits for the result of the SealCommit2 call served
+// in-process whose CallID is given in JSON, and returns it in JSON, e.g.
+//
+//	{"Proof":"<base64 proof>"}
+//	{"Error":"storage call error 0: ..."}
+//
+//export FilGoWaitCall
+func FilGoWaitCall(callID *C.char) *C.char {
+	lk.Lock()
+	res := results
+	lk.Unlock()
+
+	var out struct {
+		Proof []byte `json:",omitempty"`
+		Error string `json:",omitempty"`
+	}
+
+	var id worker.CallID
+	switch err := json.Unmarshal([]byte(C.GoString(callID)), &id); {
+	case res == nil:
+		out.Error = "FilGoInit has not been called"
+	case err != nil:
+		out.Error = err.Error()
+	default:
+		out.Proof, err = res.Wait(context.Background(), id)
+		if err != nil {
+			out.Error = err.Error()
+		}
+	}
+
+	b, _ := json.Marshal(out)
+	return toCString(b)
+}
+
+// FilGoRemoteOpen returns a handle to a remote worker RPC endpoint, e.g.
+// "http://10.0.0.2:3456/rpc/v0". The token is sent as a bearer token and may
+// be NULL.
+//
+//export FilGoRemoteOpen
+func FilGoRemoteOpen(addr *C.char, token *C.char) C.uint64_t {
+	header := http.Header{}
+	if token != nil {
+		header.Set("Authorization", "Bearer "+C.GoString(token))
+	}
+
+	lk.Lock()
+	defer lk.Unlock()
+
+	nextID++
+	remotes[nextID] = worker.NewClient(C.GoString(addr), header)
+
+	return C.uint64_t(nextID)
+}
+
+// FilGoRemoteCall forwards a JSON-RPC request to a remote worker and returns
+// its response. It returns NULL for notifications.
+//
+//export FilGoRemoteCall
+func FilGoRemoteCall(handle C.uint64_t, request *C.char) *C.char {
+	lk.Lock()
+	client, ok := remotes[uint64(handle)]
+	lk.Unlock()
+
+	if !ok {
+		return errorResponse(request, "invalid remote handle")
+	}
+
+	resp, err := client.Forward(context.Background(), []byte(C.GoString(request)))
+	if err != nil {
+		return errorResponse(request, err.Error())
+	}
+
+	return toCString(resp)
+}
+
+// FilGoRemoteClose releases a handle returned by FilGoRemoteOpen.
+//
+//export FilGoRemoteClose
+func FilGoRemoteClose(handle C.uint64_t) {
+	lk.Lock()
+	delete(remotes, uint64(handle))
+	lk.Unlock()
+}
+
+// FilGoFree releases a string returned by this library.
+//
+//export FilGoFree
+func FilGoFree(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+func toCString(b []byte) *C.char {
+	if b == nil {
+		return nil
+	}
+	return C.CString(string(b))
+}
+
+// errorResponse builds a JSON-RPC error response for failures which happen
+// before the request reaches a server.
+func errorResponse(request *C.char, msg string) *C.char {
+	var req struct {
+		ID json.RawMessage `json:"id"`
+	}
+	_ = json.Unmarshal([]byte(C.GoString(request)), &req)
+	if req.ID == nil {
+		req.ID = json.RawMessage("null")
+	}
+
+	out, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"error": map[string]interface{}{
+			"code":    -32000,
+			"message": msg,
+		},
+	})
+
+	return toCString(out)
+}
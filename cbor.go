@@ -0,0 +1,57 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+)
+
+//go:generate go run ./gen
+
+// maxSortedSectors bounds the number of sectors decoded in a
+// SortedPrivateSectorInfo. Window PoSt messages prove up to a few tens of
+// thousands of sectors, above the cbor-gen MaxLength.
+const maxSortedSectors = 1 << 16
+
+// MarshalCBOR encodes the SortedPrivateSectorInfo as an array of
+// PrivateSectorInfo.
+func (s *SortedPrivateSectorInfo) MarshalCBOR(w io.Writer) error {
+	if err := cbg.WriteMajorTypeHeader(w, cbg.MajArray, uint64(len(s.f))); err != nil {
+		return err
+	}
+	for i := range s.f {
+		if err := s.f[i].MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalCBOR decodes an array of PrivateSectorInfo. Unlike UnmarshalJSON,
+// it sorts and deduplicates the sectors, as NewSortedPrivateSectorInfo does.
+func (s *SortedPrivateSectorInfo) UnmarshalCBOR(r io.Reader) error {
+	maj, n, err := cbg.CborReadHeader(r)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return xerrors.Errorf("expected a CBOR array, got major type %d", maj)
+	}
+	if n > maxSortedSectors {
+		return xerrors.Errorf("too many sectors: %d", n)
+	}
+
+	sectors := make([]PrivateSectorInfo, n)
+	for i := range sectors {
+		if err := sectors[i].UnmarshalCBOR(r); err != nil {
+			return xerrors.Errorf("decoding sector %d: %w", i, err)
+		}
+	}
+
+	*s = NewSortedPrivateSectorInfo(sectors...)
+	return nil
+}
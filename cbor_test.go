@@ -0,0 +1,80 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/actors/runtime/proof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPrivateSectorInfo(t *testing.T, number abi.SectorNumber) PrivateSectorInfo {
+	commR, err := commcid.ReplicaCommitmentV1ToCID(bytes.Repeat([]byte{byte(number)}, 32))
+	require.NoError(t, err)
+
+	return PrivateSectorInfo{
+		SectorInfo: proof.SectorInfo{
+			SealProof:    abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+			SectorNumber: number,
+			SealedCID:    commR,
+		},
+		CacheDirPath:     "/cache",
+		PoStProofType:    abi.RegisteredPoStProof_StackedDrgWindow2KiBV1,
+		SealedSectorPath: "/sealed",
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	sectors := NewSortedPrivateSectorInfo(testPrivateSectorInfo(t, 2), testPrivateSectorInfo(t, 1))
+
+	var buf bytes.Buffer
+	require.NoError(t, sectors.MarshalCBOR(&buf))
+
+	var decoded SortedPrivateSectorInfo
+	require.NoError(t, decoded.UnmarshalCBOR(&buf))
+	assert.Equal(t, sectors, decoded)
+
+	ref := SectorRef{
+		ID:               abi.SectorID{Miner: 1000, Number: 42},
+		ProofType:        abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+		SealedSectorPath: "/sealed",
+	}
+	buf.Reset()
+	require.NoError(t, ref.MarshalCBOR(&buf))
+
+	var decodedRef SectorRef
+	require.NoError(t, decodedRef.UnmarshalCBOR(&buf))
+	assert.Equal(t, ref, decodedRef)
+
+	pp := PartitionProof{PoStProof: abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, ProofBytes: []byte("proof")}
+	buf.Reset()
+	require.NoError(t, pp.MarshalCBOR(&buf))
+
+	var decodedPP PartitionProof
+	require.NoError(t, decodedPP.UnmarshalCBOR(&buf))
+	assert.Equal(t, pp, decodedPP)
+}
+
+func TestPrivateSectorInfoJSON(t *testing.T) {
+	info := testPrivateSectorInfo(t, 1)
+
+	data, err := json.Marshal(info)
+	require.NoError(t, err)
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	for _, name := range []string{"SealProof", "SectorNumber", "SealedCID", "CacheDirPath", "PoStProofType", "SealedSectorPath"} {
+		assert.Contains(t, fields, name)
+	}
+
+	var decoded PrivateSectorInfo
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, info, decoded)
+}
@@ -0,0 +1,120 @@
+package cgo
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Destroyer is a Go value owning native memory, freed by Destroy.
+type Destroyer interface {
+	Destroy()
+}
+
+var autoDestroy int32
+
+// SetAutoDestroy enables, or disables, the finalizers of the AutoDestroy and
+// Make functions, which free the native memory of the objects the caller
+// forgot to destroy once they are garbage collected. It is disabled by
+// default.
+//
+// The values of AllocSliceBoxedUint8, NewPrivateReplicaInfo, NewPoStProof and
+// NewPartitionSnarkProof only hold native pointers, which the garbage
+// collector doesn't see: they are covered once stored in a slice of the Make
+// functions, which the package and its callers in this module build them in.
+// The responses of the native library are destroyed before the functions of
+// the package return.
+//
+// Explicit Destroy calls remain the fast path: the memory is freed right
+// away, and the finalizer later finds nothing to free. Only the memory a
+// finalizer is attached to must be destroyed explicitly, not copies of it.
+func SetAutoDestroy(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&autoDestroy, v)
+}
+
+func autoDestroyEnabled() bool {
+	return atomic.LoadInt32(&autoDestroy) == 1
+}
+
+// AutoDestroy attaches a finalizer destroying obj if auto destroy is enabled.
+// obj must be a pointer to the start of a Go allocation, such as one returned
+// by new.
+func AutoDestroy(obj Destroyer) {
+	if autoDestroyEnabled() {
+		runtime.SetFinalizer(obj, Destroyer.Destroy)
+	}
+}
+
+// MakeSlicesBoxedUint8 returns a slice of n boxed slices, whose elements are
+// destroyed once it is garbage collected if auto destroy is enabled.
+func MakeSlicesBoxedUint8(n int) []SliceBoxedUint8 {
+	s := make([]SliceBoxedUint8, n)
+	AutoDestroySlicesBoxedUint8(s)
+	return s
+}
+
+// MakePrivateReplicaInfos returns a slice of n replica infos, whose elements
+// are destroyed once it is garbage collected if auto destroy is enabled.
+func MakePrivateReplicaInfos(n int) []PrivateReplicaInfo {
+	s := make([]PrivateReplicaInfo, n)
+	AutoDestroyPrivateReplicaInfos(s)
+	return s
+}
+
+// MakePoStProofs returns a slice of n PoSt proofs, whose elements are
+// destroyed once it is garbage collected if auto destroy is enabled.
+func MakePoStProofs(n int) []PoStProof {
+	s := make([]PoStProof, n)
+	AutoDestroyPoStProofs(s)
+	return s
+}
+
+// AutoDestroySlicesBoxedUint8 attaches a finalizer destroying all the elements
+// of s, which must have been allocated by make, if auto destroy is enabled.
+func AutoDestroySlicesBoxedUint8(s []SliceBoxedUint8) {
+	if len(s) == 0 || !autoDestroyEnabled() {
+		return
+	}
+	n := len(s)
+	runtime.SetFinalizer(&s[0], func(first *SliceBoxedUint8) {
+		all := unsafe.Slice(first, n)
+		for i := range all {
+			all[i].Destroy()
+		}
+	})
+}
+
+// AutoDestroyPrivateReplicaInfos attaches a finalizer destroying all the
+// elements of s, which must have been allocated by make, if auto destroy is
+// enabled.
+func AutoDestroyPrivateReplicaInfos(s []PrivateReplicaInfo) {
+	if len(s) == 0 || !autoDestroyEnabled() {
+		return
+	}
+	n := len(s)
+	runtime.SetFinalizer(&s[0], func(first *PrivateReplicaInfo) {
+		all := unsafe.Slice(first, n)
+		for i := range all {
+			all[i].Destroy()
+		}
+	})
+}
+
+// AutoDestroyPoStProofs attaches a finalizer destroying all the elements of
+// s, which must have been allocated by make, if auto destroy is enabled.
+func AutoDestroyPoStProofs(s []PoStProof) {
+	if len(s) == 0 || !autoDestroyEnabled() {
+		return
+	}
+	n := len(s)
+	runtime.SetFinalizer(&s[0], func(first *PoStProof) {
+		all := unsafe.Slice(first, n)
+		for i := range all {
+			all[i].Destroy()
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package cgo
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDestroyer struct {
+	destroyed chan struct{}
+	_         [64]byte // not a tiny allocation
+}
+
+func (f *fakeDestroyer) Destroy() {
+	close(f.destroyed)
+}
+
+func TestAutoDestroy(t *testing.T) {
+	SetAutoDestroy(true)
+	defer SetAutoDestroy(false)
+
+	destroyed := make(chan struct{})
+	AutoDestroy(&fakeDestroyer{destroyed: destroyed})
+
+	deadline := time.After(10 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-destroyed:
+			return
+		case <-deadline:
+			t.Fatal("object not destroyed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestMakeSlicesAutoDestroy(t *testing.T) {
+	SetAutoDestroy(true)
+	defer SetAutoDestroy(false)
+	SetLeakDetection(true)
+	defer SetLeakDetection(false)
+
+	func() {
+		s := MakeSlicesBoxedUint8(2)
+		s[0] = AllocSliceBoxedUint8([]byte("forgotten"))
+		s[1] = AllocSliceBoxedUint8([]byte("destroyed"))
+		s[1].Destroy()
+
+		proofs := MakePoStProofs(1)
+		proofs[0] = NewPoStProof(RegisteredPoStProofStackedDrgWindow2KiBV1, []byte("forgotten"))
+	}()
+	require.Len(t, LeakReport(), 2)
+
+	deadline := time.After(10 * time.Second)
+	for len(LeakReport()) > 0 {
+		runtime.GC()
+		select {
+		case <-deadline:
+			t.Fatalf("not destroyed: %v", LeakReport())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
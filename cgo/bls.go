@@ -9,53 +9,85 @@ package cgo
 import "C"
 
 func Hash(message SliceRefUint8) *[96]byte {
+	defer beginCall(Call{Name: "hash"}).end(nil, nil)
 	resp := C.hash(message)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	return resp.copyAsArray()
 }
 
 func Aggregate(flattenedSignatures SliceRefUint8) *[96]byte {
+	defer beginCall(Call{Name: "aggregate"}).end(nil, nil)
 	resp := C.aggregate(flattenedSignatures)
-	defer resp.destroy()
+	defer track(resp).destroy()
+	return resp.copyAsArray()
+}
+
+func AggregatePublicKeys(flattenedPublicKeys SliceRefUint8) *[48]byte {
+	defer beginCall(Call{Name: "aggregate_public_keys"}).end(nil, nil)
+	resp := C.aggregate_public_keys(flattenedPublicKeys)
+	defer track(resp).destroy()
 	return resp.copyAsArray()
 }
 
 func Verify(signature SliceRefUint8, flattenedDigests SliceRefUint8, flattenedPublicKeys SliceRefUint8) bool {
+	defer beginCall(Call{Name: "verify"}).end(nil, nil)
 	resp := C.verify(signature, flattenedDigests, flattenedPublicKeys)
 	return bool(resp)
 }
 
 func HashVerify(signature SliceRefUint8, flattenedMessages SliceRefUint8, messageSizes SliceRefUint, flattenedPublicKeys SliceRefUint8) bool {
+	defer beginCall(Call{Name: "hash_verify"}).end(nil, nil)
 	resp := C.hash_verify(signature, flattenedMessages, messageSizes, flattenedPublicKeys)
 	return bool(resp)
 }
 
+func BatchVerify(flattenedSignatures SliceRefUint8, flattenedMessages SliceRefUint8, messageSizes SliceRefUint, flattenedPublicKeys SliceRefUint8) bool {
+	defer beginCall(Call{Name: "batch_verify"}).end(nil, nil)
+	resp := C.batch_verify(flattenedSignatures, flattenedMessages, messageSizes, flattenedPublicKeys)
+	return bool(resp)
+}
+
 func PrivateKeyGenerate() *[32]byte {
+	defer beginCall(Call{Name: "private_key_generate"}).end(nil, nil)
 	resp := C.private_key_generate()
-	defer resp.destroy()
+	defer track(resp).destroy()
 	return resp.copyAsArray()
 }
 
 func PrivateKeyGenerateWithSeed(rawSeed *ByteArray32) *[32]byte {
+	defer beginCall(Call{Name: "private_key_generate_with_seed"}).end(nil, nil)
 	resp := C.private_key_generate_with_seed(rawSeed)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	return resp.copyAsArray()
 }
 
 func PrivateKeySign(rawPrivateKey SliceRefUint8, message SliceRefUint8) *[96]byte {
+	defer beginCall(Call{Name: "private_key_sign"}).end(nil, nil)
 	resp := C.private_key_sign(rawPrivateKey, message)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	return resp.copyAsArray()
 }
 
 func PrivateKeyPublicKey(rawPrivateKey SliceRefUint8) *[48]byte {
+	defer beginCall(Call{Name: "private_key_public_key"}).end(nil, nil)
 	resp := C.private_key_public_key(rawPrivateKey)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	return resp.copyAsArray()
 }
 
 func CreateZeroSignature() *[96]byte {
+	defer beginCall(Call{Name: "create_zero_signature"}).end(nil, nil)
 	resp := C.create_zero_signature()
-	defer resp.destroy()
+	defer track(resp).destroy()
 	return resp.copyAsArray()
 }
+
+func PublicKeyStatus(rawPublicKey SliceRefUint8) BLSPointStatus {
+	defer beginCall(Call{Name: "public_key_status"}).end(nil, nil)
+	return BLSPointStatus(C.public_key_status(rawPublicKey))
+}
+
+func SignatureStatus(signature SliceRefUint8) BLSPointStatus {
+	defer beginCall(Call{Name: "signature_status"}).end(nil, nil)
+	return BLSPointStatus(C.signature_status(signature))
+}
@@ -0,0 +1,63 @@
+package cgo
+
+/*
+#cgo LDFLAGS: -L${SRCDIR}/..
+#cgo pkg-config: ${SRCDIR}/../filcrypto.pc
+#include "../filcrypto.h"
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// ByteArray32FromSlice returns b as a ByteArray32. Unlike AsByteArray32, it
+// fails unless b is exactly 32 bytes long.
+func ByteArray32FromSlice(b []byte) (ByteArray32, error) {
+	var ary ByteArray32
+	if len(b) != len(ary.idx) {
+		return ary, fmt.Errorf("%w: expected 32 bytes, got %d", ErrInvalidInput, len(b))
+	}
+	for i := range b {
+		ary.idx[i] = C.uchar(b[i])
+	}
+	return ary, nil
+}
+
+// ByteArray32FromHex decodes 64 hex digits, with an optional 0x prefix.
+func ByteArray32FromHex(s string) (ByteArray32, error) {
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return ByteArray32{}, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return ByteArray32FromSlice(b)
+}
+
+// Bytes returns a copy of the array.
+func (ptr ByteArray32) Bytes() []byte {
+	return ptr.copy()
+}
+
+// String returns the array as hex digits.
+func (ptr ByteArray32) String() string {
+	return hex.EncodeToString(ptr.slice())
+}
+
+// MarshalText encodes the array as hex digits, and so as a JSON string.
+func (ptr ByteArray32) MarshalText() ([]byte, error) {
+	return []byte(ptr.String()), nil
+}
+
+// UnmarshalText decodes the hex digits of ByteArray32FromHex.
+func (ptr *ByteArray32) UnmarshalText(b []byte) error {
+	ary, err := ByteArray32FromHex(string(b))
+	if err != nil {
+		return err
+	}
+	*ptr = ary
+	return nil
+}
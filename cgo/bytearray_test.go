@@ -0,0 +1,46 @@
+package cgo
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestByteArray32Encoding(t *testing.T) {
+	raw := make([]byte, 32)
+	for i := range raw {
+		raw[i] = byte(i)
+	}
+	const digits = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+	ary, err := ByteArray32FromSlice(raw)
+	require.NoError(t, err)
+	assert.Equal(t, raw, ary.Bytes())
+	assert.Equal(t, digits, ary.String())
+
+	fromHex, err := ByteArray32FromHex("0x" + digits)
+	require.NoError(t, err)
+	assert.Equal(t, ary, fromHex)
+
+	_, err = ByteArray32FromSlice(raw[:31])
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	_, err = ByteArray32FromHex("zz")
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	_, err = ByteArray32FromHex(digits[:62])
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	type config struct {
+		ProverID ByteArray32
+	}
+	b, err := json.Marshal(config{ProverID: ary})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ProverID":"`+digits+`"}`, string(b))
+
+	var decoded config
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, ary, decoded.ProverID)
+	assert.Error(t, json.Unmarshal([]byte(`{"ProverID":"00"}`), &decoded))
+}
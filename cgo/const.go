@@ -16,39 +16,45 @@ const (
 )
 
 const (
-	RegisteredSealProofStackedDrg2KiBV1    = C.REGISTERED_SEAL_PROOF_STACKED_DRG2_KI_B_V1
-	RegisteredSealProofStackedDrg8MiBV1    = C.REGISTERED_SEAL_PROOF_STACKED_DRG8_MI_B_V1
-	RegisteredSealProofStackedDrg512MiBV1  = C.REGISTERED_SEAL_PROOF_STACKED_DRG512_MI_B_V1
-	RegisteredSealProofStackedDrg32GiBV1   = C.REGISTERED_SEAL_PROOF_STACKED_DRG32_GI_B_V1
-	RegisteredSealProofStackedDrg64GiBV1   = C.REGISTERED_SEAL_PROOF_STACKED_DRG64_GI_B_V1
-	RegisteredSealProofStackedDrg2KiBV11   = C.REGISTERED_SEAL_PROOF_STACKED_DRG2_KI_B_V1_1
-	RegisteredSealProofStackedDrg8MiBV11   = C.REGISTERED_SEAL_PROOF_STACKED_DRG8_MI_B_V1_1
-	RegisteredSealProofStackedDrg512MiBV11 = C.REGISTERED_SEAL_PROOF_STACKED_DRG512_MI_B_V1_1
-	RegisteredSealProofStackedDrg32GiBV11  = C.REGISTERED_SEAL_PROOF_STACKED_DRG32_GI_B_V1_1
-	RegisteredSealProofStackedDrg64GiBV11  = C.REGISTERED_SEAL_PROOF_STACKED_DRG64_GI_B_V1_1
+	BLSPointStatusValid     = BLSPointStatus(C.B_L_S_POINT_STATUS_VALID)
+	BLSPointStatusMalformed = BLSPointStatus(C.B_L_S_POINT_STATUS_MALFORMED)
+	BLSPointStatusIdentity  = BLSPointStatus(C.B_L_S_POINT_STATUS_IDENTITY)
 )
 
 const (
-	RegisteredAggregationProofSnarkPackV1 = C.REGISTERED_AGGREGATION_PROOF_SNARK_PACK_V1
+	RegisteredSealProofStackedDrg2KiBV1    = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG2_KI_B_V1)
+	RegisteredSealProofStackedDrg8MiBV1    = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG8_MI_B_V1)
+	RegisteredSealProofStackedDrg512MiBV1  = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG512_MI_B_V1)
+	RegisteredSealProofStackedDrg32GiBV1   = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG32_GI_B_V1)
+	RegisteredSealProofStackedDrg64GiBV1   = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG64_GI_B_V1)
+	RegisteredSealProofStackedDrg2KiBV11   = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG2_KI_B_V1_1)
+	RegisteredSealProofStackedDrg8MiBV11   = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG8_MI_B_V1_1)
+	RegisteredSealProofStackedDrg512MiBV11 = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG512_MI_B_V1_1)
+	RegisteredSealProofStackedDrg32GiBV11  = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG32_GI_B_V1_1)
+	RegisteredSealProofStackedDrg64GiBV11  = RegisteredSealProof(C.REGISTERED_SEAL_PROOF_STACKED_DRG64_GI_B_V1_1)
 )
 
 const (
-	RegisteredPoStProofStackedDrgWinning2KiBV1   = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING2_KI_B_V1
-	RegisteredPoStProofStackedDrgWinning8MiBV1   = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING8_MI_B_V1
-	RegisteredPoStProofStackedDrgWinning512MiBV1 = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING512_MI_B_V1
-	RegisteredPoStProofStackedDrgWinning32GiBV1  = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING32_GI_B_V1
-	RegisteredPoStProofStackedDrgWinning64GiBV1  = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING64_GI_B_V1
-	RegisteredPoStProofStackedDrgWindow2KiBV1    = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW2_KI_B_V1
-	RegisteredPoStProofStackedDrgWindow8MiBV1    = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW8_MI_B_V1
-	RegisteredPoStProofStackedDrgWindow512MiBV1  = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW512_MI_B_V1
-	RegisteredPoStProofStackedDrgWindow32GiBV1   = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW32_GI_B_V1
-	RegisteredPoStProofStackedDrgWindow64GiBV1   = C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW64_GI_B_V1
+	RegisteredAggregationProofSnarkPackV1 = RegisteredAggregationProof(C.REGISTERED_AGGREGATION_PROOF_SNARK_PACK_V1)
 )
 
 const (
-	RegisteredUpdateProofStackedDrg2KiBV1   = C.REGISTERED_UPDATE_PROOF_STACKED_DRG2_KI_B_V1
-	RegisteredUpdateProofStackedDrg8MiBV1   = C.REGISTERED_UPDATE_PROOF_STACKED_DRG8_MI_B_V1
-	RegisteredUpdateProofStackedDrg512MiBV1 = C.REGISTERED_UPDATE_PROOF_STACKED_DRG512_MI_B_V1
-	RegisteredUpdateProofStackedDrg32GiBV1  = C.REGISTERED_UPDATE_PROOF_STACKED_DRG32_GI_B_V1
-	RegisteredUpdateProofStackedDrg64GiBV1  = C.REGISTERED_UPDATE_PROOF_STACKED_DRG64_GI_B_V1
+	RegisteredPoStProofStackedDrgWinning2KiBV1   = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING2_KI_B_V1)
+	RegisteredPoStProofStackedDrgWinning8MiBV1   = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING8_MI_B_V1)
+	RegisteredPoStProofStackedDrgWinning512MiBV1 = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING512_MI_B_V1)
+	RegisteredPoStProofStackedDrgWinning32GiBV1  = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING32_GI_B_V1)
+	RegisteredPoStProofStackedDrgWinning64GiBV1  = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINNING64_GI_B_V1)
+	RegisteredPoStProofStackedDrgWindow2KiBV1    = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW2_KI_B_V1)
+	RegisteredPoStProofStackedDrgWindow8MiBV1    = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW8_MI_B_V1)
+	RegisteredPoStProofStackedDrgWindow512MiBV1  = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW512_MI_B_V1)
+	RegisteredPoStProofStackedDrgWindow32GiBV1   = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW32_GI_B_V1)
+	RegisteredPoStProofStackedDrgWindow64GiBV1   = RegisteredPoStProof(C.REGISTERED_PO_ST_PROOF_STACKED_DRG_WINDOW64_GI_B_V1)
+)
+
+const (
+	RegisteredUpdateProofStackedDrg2KiBV1   = RegisteredUpdateProof(C.REGISTERED_UPDATE_PROOF_STACKED_DRG2_KI_B_V1)
+	RegisteredUpdateProofStackedDrg8MiBV1   = RegisteredUpdateProof(C.REGISTERED_UPDATE_PROOF_STACKED_DRG8_MI_B_V1)
+	RegisteredUpdateProofStackedDrg512MiBV1 = RegisteredUpdateProof(C.REGISTERED_UPDATE_PROOF_STACKED_DRG512_MI_B_V1)
+	RegisteredUpdateProofStackedDrg32GiBV1  = RegisteredUpdateProof(C.REGISTERED_UPDATE_PROOF_STACKED_DRG32_GI_B_V1)
+	RegisteredUpdateProofStackedDrg64GiBV1  = RegisteredUpdateProof(C.REGISTERED_UPDATE_PROOF_STACKED_DRG64_GI_B_V1)
 )
@@ -0,0 +1,32 @@
+package cgo
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestroyNilSafe(t *testing.T) {
+	assert.NotPanics(t, func() {
+		var boxed *SliceBoxedUint8
+		boxed.Destroy()
+
+		var empty SliceBoxedUint8
+		empty.Destroy()
+		empty.Destroy()
+
+		var info *PrivateReplicaInfo
+		info.Destroy()
+
+		var proof *PoStProof
+		proof.Destroy()
+
+		var machine *FvmMachine
+		machine.Destroy()
+
+		// not created by CreateFvmMachine, or already dropped
+		var unknown [64]byte
+		(*FvmMachine)(unsafe.Pointer(&unknown)).Destroy()
+	})
+}
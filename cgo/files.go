@@ -0,0 +1,88 @@
+package cgo
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// fileFd returns the descriptor of f to give to a native call. The caller
+// must keep f alive until the call returns, with runtime.KeepAlive: an
+// unreachable *os.File closes its descriptor when it is finalized, possibly
+// while the native library still uses it.
+func fileFd(f *os.File, name string) (int32, error) {
+	fd := f.Fd()
+	if fd == ^uintptr(0) {
+		return 0, fmt.Errorf("%w: %s file is closed", ErrInvalidInput, name)
+	}
+	if fd > 1<<31-1 {
+		return 0, fmt.Errorf("%w: %s file descriptor %d out of range", ErrInvalidInput, name, fd)
+	}
+	return int32(fd), nil
+}
+
+// GeneratePieceCommitmentFile is GeneratePieceCommitment reading the piece
+// from pieceFile.
+func GeneratePieceCommitmentFile(registeredProof RegisteredSealProof, pieceFile *os.File, unpaddedPieceSize uint64) ([]byte, error) {
+	fd, err := fileFd(pieceFile, "piece")
+	if err != nil {
+		return nil, err
+	}
+	defer runtime.KeepAlive(pieceFile)
+
+	return GeneratePieceCommitment(registeredProof, fd, unpaddedPieceSize)
+}
+
+// WriteWithAlignmentFile is WriteWithAlignment reading the piece from src and
+// writing it to the staged sector dst.
+func WriteWithAlignmentFile(registeredProof RegisteredSealProof, src *os.File, srcSize uint64, dst *os.File, existingPieceSizes SliceRefUint64) (uint64, uint64, []byte, error) {
+	srcFd, err := fileFd(src, "piece")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer runtime.KeepAlive(src)
+
+	dstFd, err := fileFd(dst, "staged sector")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer runtime.KeepAlive(dst)
+
+	return WriteWithAlignment(registeredProof, srcFd, srcSize, dstFd, existingPieceSizes)
+}
+
+// WriteWithoutAlignmentFile is WriteWithoutAlignment reading the piece from
+// src and writing it to the staged sector dst.
+func WriteWithoutAlignmentFile(registeredProof RegisteredSealProof, src *os.File, srcSize uint64, dst *os.File) (uint64, []byte, error) {
+	srcFd, err := fileFd(src, "piece")
+	if err != nil {
+		return 0, nil, err
+	}
+	defer runtime.KeepAlive(src)
+
+	dstFd, err := fileFd(dst, "staged sector")
+	if err != nil {
+		return 0, nil, err
+	}
+	defer runtime.KeepAlive(dst)
+
+	return WriteWithoutAlignment(registeredProof, srcFd, srcSize, dstFd)
+}
+
+// UnsealRangeFile is UnsealRange reading sealedSector and writing to
+// unsealOutput.
+func UnsealRangeFile(registeredProof RegisteredSealProof, cacheDirPath SliceRefUint8, sealedSector *os.File, unsealOutput *os.File, sectorId uint64, proverId *ByteArray32, ticket *ByteArray32, commD *ByteArray32, unpaddedByteIndex uint64, unpaddedBytesAmount uint64) error {
+	sealedFd, err := fileFd(sealedSector, "sealed sector")
+	if err != nil {
+		return err
+	}
+	defer runtime.KeepAlive(sealedSector)
+
+	outputFd, err := fileFd(unsealOutput, "unseal output")
+	if err != nil {
+		return err
+	}
+	defer runtime.KeepAlive(unsealOutput)
+
+	return UnsealRange(registeredProof, cacheDirPath, sealedFd, outputFd, sectorId, proverId, ticket, commD, unpaddedByteIndex, unpaddedBytesAmount)
+}
@@ -0,0 +1,30 @@
+package cgo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileFd(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "piece"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fd, err := fileFd(f, "piece")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uintptr(fd) != f.Fd() {
+		t.Fatalf("got fd %d, want %d", fd, f.Fd())
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileFd(f, "piece"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("closed file: got %v, want ErrInvalidInput", err)
+	}
+}
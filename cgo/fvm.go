@@ -7,8 +7,18 @@ package cgo
 #include <stdlib.h>
 */
 import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// liveFvmMachines holds the machines not dropped yet, so that dropping one
+// twice is a no-op.
+var liveFvmMachines sync.Map
+
+func CreateFvmMachine(fvmVersion FvmRegisteredVersion, chainEpoch, baseFeeHi, baseFeeLo, baseCircSupplyHi, baseCircSupplyLo, networkVersion uint64, stateRoot SliceRefUint8, manifestCid SliceRefUint8, tracing bool, blockstoreId, externsId uint64) (_ *FvmMachine, err error) {
+	defer beginCall(Call{Name: "create_fvm_machine"}).end(&err, nil)
 
-func CreateFvmMachine(fvmVersion FvmRegisteredVersion, chainEpoch, baseFeeHi, baseFeeLo, baseCircSupplyHi, baseCircSupplyLo, networkVersion uint64, stateRoot SliceRefUint8, manifestCid SliceRefUint8, tracing bool, blockstoreId, externsId uint64) (*FvmMachine, error) {
 	resp := C.create_fvm_machine(
 		fvmVersion,
 		C.uint64_t(chainEpoch),
@@ -26,23 +36,27 @@ func CreateFvmMachine(fvmVersion FvmRegisteredVersion, chainEpoch, baseFeeHi, ba
 	// take out the pointer from the result to ensure it doesn't get freed
 	executor := resp.value
 	resp.value = nil
-	defer resp.destroy()
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
+	liveFvmMachines.Store(unsafe.Pointer(executor), struct{}{})
+	trackFvmMachine(executor)
 
 	return executor, nil
 }
 
-func FvmMachineExecuteMessage(executor *FvmMachine, message SliceRefUint8, chainLen, applyKind uint64) (FvmMachineExecuteResponseGo, error) {
+func FvmMachineExecuteMessage(executor *FvmMachine, message SliceRefUint8, chainLen, applyKind uint64) (_ FvmMachineExecuteResponseGo, err error) {
+	defer beginCall(Call{Name: "fvm_machine_execute_message"}).end(&err, nil)
+
 	resp := C.fvm_machine_execute_message(
 		executor,
 		message,
 		C.uint64_t(chainLen),
 		C.uint64_t(applyKind),
 	)
-	defer resp.destroy()
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return FvmMachineExecuteResponseGo{}, err
@@ -51,9 +65,11 @@ func FvmMachineExecuteMessage(executor *FvmMachine, message SliceRefUint8, chain
 	return resp.value.copy(), nil
 }
 
-func FvmMachineFlush(executor *FvmMachine) ([]byte, error) {
+func FvmMachineFlush(executor *FvmMachine) (out []byte, err error) {
+	defer beginCall(Call{Name: "fvm_machine_flush"}).end(&err, &out)
+
 	resp := C.fvm_machine_flush(executor)
-	defer resp.destroy()
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return nil, err
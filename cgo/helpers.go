@@ -25,6 +25,11 @@ var (
 	emptySliceBoxedUint8    C.slice_boxed_uint8_t  = C.slice_boxed_uint8_t{}
 )
 
+// The AsSliceRef functions return references to Go memory, without copying
+// it, for the native calls they are passed to as arguments. A reference keeps
+// its memory alive; references stored in other memory passed to the native
+// library, or kept past a call, must be built with a Pinner instead.
+
 func AsSliceRefUint8(goBytes []byte) SliceRefUint8 {
 	len := len(goBytes)
 
@@ -62,10 +67,18 @@ func AllocSliceBoxedUint8(goBytes []byte) SliceBoxedUint8 {
 
 	ptr := C.alloc_boxed_slice(C.size_t(len))
 	copy(ptr.slice(), goBytes)
+	trackBoxedSlice(ptr)
 
 	return ptr
 }
 
+// PinSlicesBoxedUint8 passes byte slices, such as vanilla proofs, to the
+// native library with a Pinner. The returned function releases them.
+func PinSlicesBoxedUint8(src [][]byte) ([]SliceBoxedUint8, func()) {
+	var pinner Pinner
+	return pinner.SlicesBoxedUint8(src), pinner.Unpin
+}
+
 func AsSliceRefUint(goSlice []uint) SliceRefUint {
 	len := len(goSlice)
 
@@ -222,7 +235,7 @@ func CheckErr(resp result) error {
 		return nil
 	}
 
-	return errors.New(string(resp.errorMsg().slice()))
+	return newStatusError(resp.statusCode(), string(resp.errorMsg().slice()))
 }
 
 func NewAggregationInputs(commR ByteArray32, commD ByteArray32, sectorId uint64, ticket ByteArray32, seed ByteArray32) AggregationInputs {
@@ -237,7 +250,7 @@ func NewAggregationInputs(commR ByteArray32, commD ByteArray32, sectorId uint64,
 
 func NewPrivateReplicaInfo(pp RegisteredPoStProof, cacheDirPath string, commR ByteArray32, replicaPath string, sectorId uint64) PrivateReplicaInfo {
 	return PrivateReplicaInfo{
-		registered_proof: pp,
+		registered_proof: C.RegisteredPoStProof_t(pp),
 		cache_dir_path:   AllocSliceBoxedUint8([]byte(cacheDirPath)),
 		replica_path:     AllocSliceBoxedUint8([]byte(replicaPath)),
 		sector_id:        C.uint64_t(sectorId),
@@ -247,7 +260,7 @@ func NewPrivateReplicaInfo(pp RegisteredPoStProof, cacheDirPath string, commR By
 
 func NewPublicReplicaInfo(pp RegisteredPoStProof, commR ByteArray32, sectorId uint64) PublicReplicaInfo {
 	return PublicReplicaInfo{
-		registered_proof: pp,
+		registered_proof: C.RegisteredPoStProof_t(pp),
 		sector_id:        C.uint64_t(sectorId),
 		comm_r:           commR,
 	}
@@ -255,7 +268,7 @@ func NewPublicReplicaInfo(pp RegisteredPoStProof, commR ByteArray32, sectorId ui
 
 func NewPoStProof(pp RegisteredPoStProof, proof []byte) PoStProof {
 	return PoStProof{
-		registered_proof: pp,
+		registered_proof: C.RegisteredPoStProof_t(pp),
 		proof:            AllocSliceBoxedUint8(proof),
 	}
 }
@@ -269,7 +282,7 @@ func NewPublicPieceInfo(numBytes uint64, commP ByteArray32) PublicPieceInfo {
 
 func NewPartitionSnarkProof(pp RegisteredPoStProof, proof []byte) PartitionSnarkProof {
 	return PartitionSnarkProof{
-		registered_proof: pp,
+		registered_proof: C.RegisteredPoStProof_t(pp),
 		proof:            AllocSliceBoxedUint8(proof),
 	}
 }
@@ -0,0 +1,299 @@
+package cgo
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Call describes a native call to the call hooks.
+type Call struct {
+	// Name is the name of the native function, e.g. "seal_commit_phase2".
+	Name string
+	// ProofType is the name of the registered proof of the call, if any.
+	ProofType string
+	// SectorID is the number of the sector of the call, if HasSectorID.
+	SectorID    uint64
+	HasSectorID bool
+	// Args returns the byte arguments of the call, such as its commitments
+	// and proofs, if the binding reports them. The returned slices are only
+	// valid while the hooks of the call run.
+	Args func() []CallArg
+}
+
+// CallArg is a byte argument of a native call.
+type CallArg struct {
+	Name  string
+	Value []byte
+}
+
+func array32Arg(name string, v *ByteArray32) CallArg {
+	arg := CallArg{Name: name}
+	if v != nil {
+		arg.Value = v.slice()
+	}
+	return arg
+}
+
+// CallResult is the outcome of a native call.
+type CallResult struct {
+	Err      error
+	Duration time.Duration
+	// ReturnedBytes is the size of the main byte slice returned by the call,
+	// such as a proof or a phase output.
+	ReturnedBytes int
+}
+
+// CallHook is called before every native call, and the function it returns, if
+// not nil, once the call returned. Hooks run on the goroutine making the call,
+// so they should be quick.
+type CallHook func(call Call) func(res CallResult)
+
+var (
+	hooksLk sync.Mutex
+	// hooks holds a []*CallHook, replaced on every change.
+	hooks    atomic.Value
+	numHooks int32
+)
+
+// AddCallHook registers a hook for all the native calls, and returns a
+// function removing it.
+func AddCallHook(hook CallHook) (remove func()) {
+	hooksLk.Lock()
+	defer hooksLk.Unlock()
+
+	h := &hook
+	current, _ := hooks.Load().([]*CallHook)
+	hooks.Store(append(append([]*CallHook(nil), current...), h))
+	atomic.AddInt32(&numHooks, 1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			hooksLk.Lock()
+			defer hooksLk.Unlock()
+
+			current, _ := hooks.Load().([]*CallHook)
+			next := make([]*CallHook, 0, len(current))
+			for _, c := range current {
+				if c != h {
+					next = append(next, c)
+				}
+			}
+			hooks.Store(next)
+			atomic.AddInt32(&numHooks, -1)
+		})
+	}
+}
+
+// RunningCall is a native call in flight.
+type RunningCall struct {
+	// Call is the call, its Args returning copies of the arguments.
+	Call
+	Start time.Time
+	// Goroutine is the id of the goroutine making the call, as printed in
+	// its stack trace.
+	Goroutine uint64
+}
+
+var (
+	activeLk  sync.Mutex
+	numActive int
+	// idle is closed once the running calls returned, and only made when
+	// Idle is called while calls run.
+	idle chan struct{}
+	// closedIdle is returned by Idle while no call is running.
+	closedIdle = closedChan()
+
+	// running holds the calls in flight while numTracking > 0.
+	running     = make(map[*runningCall]struct{})
+	numTracking int32
+)
+
+func closedChan() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}
+
+// TrackRunningCalls makes RunningCalls list the calls starting from now on,
+// and returns a function stopping it. Tracking takes the id of the goroutine
+// of every call, which is why it is off by default.
+func TrackRunningCalls() (stop func()) {
+	atomic.AddInt32(&numTracking, 1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt32(&numTracking, -1)
+		})
+	}
+}
+
+// RunningCalls returns the native calls in flight started while tracked by
+// TrackRunningCalls, the oldest first.
+func RunningCalls() []RunningCall {
+	activeLk.Lock()
+	calls := make([]*runningCall, 0, len(running))
+	for r := range running {
+		calls = append(calls, r)
+	}
+	activeLk.Unlock()
+
+	// copying the arguments only holds up the calls they belong to
+	out := make([]RunningCall, 0, len(calls))
+	for _, r := range calls {
+		if call, ok := r.snapshot(); ok {
+			out = append(out, call)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// ActiveCalls returns the number of native calls running.
+func ActiveCalls() int {
+	activeLk.Lock()
+	defer activeLk.Unlock()
+
+	return numActive
+}
+
+// Idle returns a channel closed once no native call is running, right away
+// when none is.
+func Idle() <-chan struct{} {
+	activeLk.Lock()
+	defer activeLk.Unlock()
+
+	if numActive == 0 {
+		return closedIdle
+	}
+	if idle == nil {
+		idle = make(chan struct{})
+	}
+	return idle
+}
+
+func enterCall(r *runningCall) {
+	activeLk.Lock()
+	numActive++
+	if r != nil {
+		running[r] = struct{}{}
+	}
+	activeLk.Unlock()
+}
+
+func exitCall(r *runningCall) {
+	if r != nil {
+		r.lk.Lock()
+		r.ended = true
+		r.lk.Unlock()
+	}
+
+	activeLk.Lock()
+	if r != nil {
+		delete(running, r)
+	}
+	if numActive--; numActive == 0 && idle != nil {
+		close(idle)
+		idle = nil
+	}
+	activeLk.Unlock()
+}
+
+// CurrentGoroutine returns the id of the calling goroutine, from the header
+// of its stack trace: "goroutine 42 [running]:".
+func CurrentGoroutine() uint64 {
+	var buf [64]byte
+	fields := bytes.Fields(buf[:runtime.Stack(buf[:], false)])
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(fields[1]), 10, 64)
+	return id
+}
+
+// runningCall is a call tracked by TrackRunningCalls.
+type runningCall struct {
+	call      Call
+	start     time.Time
+	goroutine uint64
+
+	// lk keeps the call from returning, which invalidates its arguments,
+	// while RunningCalls copies them.
+	lk    sync.Mutex
+	ended bool
+}
+
+// snapshot returns the call with copies of its arguments, unless it ended.
+func (r *runningCall) snapshot() (RunningCall, bool) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.ended {
+		return RunningCall{}, false
+	}
+	call := r.call
+	if call.Args != nil {
+		args := call.Args()
+		for i := range args {
+			args[i].Value = append([]byte(nil), args[i].Value...)
+		}
+		call.Args = func() []CallArg { return args }
+	}
+	return RunningCall{Call: call, Start: r.start, Goroutine: r.goroutine}, true
+}
+
+// callTracker registers a running call, and runs its hooks. Without hooks
+// nor tracking, it allocates nothing.
+type callTracker struct {
+	start   time.Time
+	running *runningCall
+	done    []func(CallResult)
+}
+
+// beginCall registers the call as running, and runs the start of the hooks.
+// It is deferred by the bindings as `defer beginCall(call).end(&err, &out)`.
+func beginCall(call Call) callTracker {
+	t := callTracker{start: time.Now()}
+	if atomic.LoadInt32(&numTracking) > 0 {
+		t.running = &runningCall{call: call, start: t.start, goroutine: CurrentGoroutine()}
+	}
+	enterCall(t.running)
+	if atomic.LoadInt32(&numHooks) == 0 {
+		return t
+	}
+
+	current, _ := hooks.Load().([]*CallHook)
+	for _, h := range current {
+		if done := (*h)(call); done != nil {
+			t.done = append(t.done, done)
+		}
+	}
+	return t
+}
+
+// end runs the end of the hooks, and unregisters the call. err is the error
+// returned by the call, or nil if it returns none, and out is the main
+// returned byte slice, or nil.
+func (t callTracker) end(err *error, out *[]byte) {
+	defer exitCall(t.running)
+	if len(t.done) == 0 {
+		return
+	}
+
+	res := CallResult{Duration: time.Since(t.start)}
+	if err != nil {
+		res.Err = *err
+	}
+	if out != nil {
+		res.ReturnedBytes = len(*out)
+	}
+	for _, done := range t.done {
+		done(res)
+	}
+}
@@ -0,0 +1,133 @@
+package cgo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeCall(call Call, fail bool) (out []byte, err error) {
+	defer beginCall(call).end(&err, &out)
+
+	if fail {
+		return nil, errors.New("failed")
+	}
+	return make([]byte, 192), nil
+}
+
+func TestCallHooks(t *testing.T) {
+	var noErr error
+	none := beginCall(Call{Name: "none"})
+	assert.Empty(t, none.done)
+	none.end(&noErr, nil)
+
+	var calls []Call
+	var results []CallResult
+	remove := AddCallHook(func(call Call) func(CallResult) {
+		calls = append(calls, call)
+		return func(res CallResult) {
+			results = append(results, res)
+		}
+	})
+	startOnly := 0
+	removeStartOnly := AddCallHook(func(Call) func(CallResult) {
+		startOnly++
+		return nil
+	})
+
+	call := Call{Name: "seal_commit_phase2", ProofType: "StackedDrg2KiBV1_1", SectorID: 7, HasSectorID: true}
+	_, err := fakeCall(call, false)
+	require.NoError(t, err)
+	_, err = fakeCall(Call{Name: "verify_seal"}, true)
+	require.Error(t, err)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, []Call{call, {Name: "verify_seal"}}, calls)
+	assert.Equal(t, 2, startOnly)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 192, results[0].ReturnedBytes)
+	assert.EqualError(t, results[1].Err, "failed")
+	assert.Equal(t, 0, results[1].ReturnedBytes)
+
+	remove()
+	remove()
+	_, _ = fakeCall(call, false)
+	assert.Len(t, calls, 2)
+	assert.Equal(t, 3, startOnly)
+
+	removeStartOnly()
+	none = beginCall(call)
+	assert.Empty(t, none.done)
+	none.end(&noErr, nil)
+}
+
+func TestActiveCalls(t *testing.T) {
+	defer TrackRunningCalls()()
+
+	var err error
+	require.Equal(t, 0, ActiveCalls())
+	idle := Idle()
+	select {
+	case <-idle:
+	default:
+		t.Fatal("not idle without calls")
+	}
+
+	first := beginCall(Call{Name: "first"})
+	second := beginCall(Call{Name: "second"})
+	assert.Equal(t, 2, ActiveCalls())
+	idle = Idle()
+
+	first.end(&err, nil)
+	select {
+	case <-idle:
+		t.Fatal("idle with a call running")
+	default:
+	}
+
+	running := RunningCalls()
+	require.Len(t, running, 1)
+	assert.Equal(t, "second", running[0].Name)
+	assert.Equal(t, CurrentGoroutine(), running[0].Goroutine)
+	assert.NotZero(t, running[0].Goroutine)
+
+	second.end(&err, nil)
+	assert.Equal(t, 0, ActiveCalls())
+	assert.Empty(t, RunningCalls())
+	select {
+	case <-idle:
+	default:
+		t.Fatal("not idle once the calls returned")
+	}
+}
+
+func TestRunningCallsArgs(t *testing.T) {
+	defer TrackRunningCalls()()
+
+	var err error
+	proof := []byte{1, 2, 3}
+	call := beginCall(Call{Name: "verify_seal", Args: func() []CallArg { return []CallArg{{Name: "proof", Value: proof}} }})
+
+	running := RunningCalls()
+	call.end(&err, nil)
+	proof[0] = 9
+
+	require.Len(t, running, 1)
+	assert.Equal(t, []CallArg{{Name: "proof", Value: []byte{1, 2, 3}}}, running[0].Args())
+}
+
+func TestCallsUntracked(t *testing.T) {
+	var err error
+	call := Call{Name: "verify_seal", Args: func() []CallArg { return nil }}
+	allocs := testing.AllocsPerRun(100, func() {
+		beginCall(call).end(&err, nil)
+	})
+	assert.Zero(t, allocs)
+
+	tracked := beginCall(call)
+	assert.Empty(t, RunningCalls())
+	assert.Equal(t, 1, ActiveCalls())
+	tracked.end(&err, nil)
+}
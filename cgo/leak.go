@@ -0,0 +1,181 @@
+package cgo
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Leak is a group of live native objects of the same type, created from the
+// same call site.
+type Leak struct {
+	// Type is the name of the native type, e.g. "Result_PoStProof".
+	Type  string
+	Count int
+	// Stack is the stack of the call that created the objects.
+	Stack string
+}
+
+func (l Leak) String() string {
+	return fmt.Sprintf("%d %s created at:\n%s", l.Count, l.Type, l.Stack)
+}
+
+var (
+	leakDetection int32
+
+	liveLk sync.Mutex
+	// live maps the native objects created while leak detection was enabled
+	// to their type and creation site.
+	live = map[interface{}]liveObject{}
+	// numLive is len(live), for destroys to skip the lock when nothing is
+	// tracked.
+	numLive int64
+)
+
+type liveObject struct {
+	typ string
+	pcs []uintptr
+}
+
+// SetLeakDetection enables, or disables, the tracking of the native objects
+// created and destroyed by the package: responses, boxed slices and FVM
+// machines. It is a debugging aid with a cost on every call. Objects created
+// while it's disabled are never reported.
+func SetLeakDetection(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&leakDetection, v)
+}
+
+// LeakReport returns the native objects created and not destroyed since leak
+// detection was enabled, the most frequent first.
+func LeakReport() []Leak {
+	liveLk.Lock()
+	defer liveLk.Unlock()
+
+	groups := map[string]*Leak{}
+	for _, obj := range live {
+		stack := formatStack(obj.pcs)
+		key := obj.typ + "\n" + stack
+		if g, ok := groups[key]; ok {
+			g.Count++
+			continue
+		}
+		groups[key] = &Leak{Type: obj.typ, Count: 1, Stack: stack}
+	}
+
+	out := make([]Leak, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return out[i].Stack < out[j].Stack
+	})
+	return out
+}
+
+func formatStack(pcs []uintptr) string {
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+func leakDetectionEnabled() bool {
+	return atomic.LoadInt32(&leakDetection) == 1
+}
+
+// trackAlloc records the creation of a native object, identified by key, by
+// the caller of its caller.
+func trackAlloc(key interface{}, typ string) {
+	pcs := make([]uintptr, 32)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+
+	liveLk.Lock()
+	if _, ok := live[key]; !ok {
+		atomic.AddInt64(&numLive, 1)
+	}
+	live[key] = liveObject{typ: typ, pcs: pcs}
+	liveLk.Unlock()
+}
+
+func trackFree(key interface{}) {
+	if atomic.LoadInt64(&numLive) == 0 {
+		return
+	}
+
+	liveLk.Lock()
+	if _, ok := live[key]; ok {
+		delete(live, key)
+		atomic.AddInt64(&numLive, -1)
+	}
+	liveLk.Unlock()
+}
+
+// destroyer is a native response, freed with destroy.
+type destroyer interface {
+	destroy()
+}
+
+// trackedResponse untracks a response when destroying it.
+type trackedResponse struct {
+	destroyer
+}
+
+func (t *trackedResponse) destroy() {
+	trackFree(t)
+	t.destroyer.destroy()
+}
+
+// track records a response for the leak detection, returning the response to
+// destroy instead, as `defer track(resp).destroy()`.
+func track(resp destroyer) destroyer {
+	if !leakDetectionEnabled() {
+		return resp
+	}
+
+	t := &trackedResponse{resp}
+	trackAlloc(t, nativeTypeName(resp))
+	return t
+}
+
+// nativeTypeName returns the C name of the type of v.
+func nativeTypeName(v interface{}) string {
+	name := strings.TrimLeft(fmt.Sprintf("%T", v), "*")
+	name = strings.TrimPrefix(name, "cgo.")
+	name = strings.TrimPrefix(name, "_Ctype_")
+	return strings.TrimPrefix(name, "struct_")
+}
+
+// trackFvmMachine records a machine created by the native library.
+func trackFvmMachine(m *FvmMachine) {
+	if leakDetectionEnabled() {
+		trackAlloc(unsafe.Pointer(m), "InnerFvmMachine")
+	}
+}
+
+// trackBoxedSlice records a boxed slice allocated for the native library.
+func trackBoxedSlice(s SliceBoxedUint8) {
+	// empty boxed slices don't allocate and share the same pointer
+	if s.len > 0 && leakDetectionEnabled() {
+		trackAlloc(unsafe.Pointer(s.ptr), "slice_boxed_uint8")
+	}
+}
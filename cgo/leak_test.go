@@ -0,0 +1,61 @@
+package cgo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResponse struct {
+	destroyed bool
+}
+
+func (r *fakeResponse) destroy() {
+	r.destroyed = true
+}
+
+func fakeBinding(leak bool) *fakeResponse {
+	resp := &fakeResponse{}
+	d := track(resp)
+	if !leak {
+		d.destroy()
+	}
+	return resp
+}
+
+func TestLeakReport(t *testing.T) {
+	assert.Equal(t, &fakeResponse{}, track(&fakeResponse{}), "not tracked while disabled")
+
+	SetLeakDetection(true)
+	defer SetLeakDetection(false)
+
+	resp := fakeBinding(false)
+	assert.True(t, resp.destroyed)
+	assert.Empty(t, LeakReport())
+
+	for i := 0; i < 2; i++ {
+		fakeBinding(true)
+	}
+	fakeBinding(false)
+
+	leaks := LeakReport()
+	require.Len(t, leaks, 1)
+	assert.Equal(t, "fakeResponse", leaks[0].Type)
+	assert.Equal(t, 2, leaks[0].Count)
+	assert.True(t, strings.Contains(leaks[0].Stack, "cgo.fakeBinding"), leaks[0].Stack)
+	assert.True(t, strings.HasPrefix(leaks[0].String(), "2 fakeResponse created at:\n"))
+
+	liveLk.Lock()
+	for k := range live {
+		delete(live, k)
+	}
+	numLive = 0
+	liveLk.Unlock()
+}
+
+func TestNativeTypeName(t *testing.T) {
+	var resp resultPoStProof
+	assert.Equal(t, "Result_PoStProof", nativeTypeName(&resp))
+}
@@ -0,0 +1,115 @@
+//go:build go1.21
+// +build go1.21
+
+package cgo
+
+/*
+#cgo LDFLAGS: -L${SRCDIR}/..
+#cgo pkg-config: ${SRCDIR}/../filcrypto.pc
+#include "../filcrypto.h"
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"runtime"
+	"unsafe"
+)
+
+// Pinner builds the arguments of native calls from Go memory without copying
+// it, pinning the memory until Unpin. It is needed for Go memory referenced
+// from other memory passed to the native library, such as the proofs of a
+// SliceRefSliceBoxedUint8, which would otherwise be copied to native memory.
+//
+// Before Go 1.21, which added runtime.Pinner, such memory is copied instead
+// and freed by Unpin. The zero value is ready to use.
+type Pinner struct {
+	p runtime.Pinner
+}
+
+// SliceRefUint8 returns a reference to b, valid until Unpin.
+func (p *Pinner) SliceRefUint8(b []byte) SliceRefUint8 {
+	pinFirst(&p.p, b)
+	return AsSliceRefUint8(b)
+}
+
+// SliceRefUint64 returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefUint64(s []uint64) SliceRefUint64 {
+	pinFirst(&p.p, s)
+	return AsSliceRefUint64(s)
+}
+
+// SliceRefUint returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefUint(s []uint) SliceRefUint {
+	pinFirst(&p.p, s)
+	return AsSliceRefUint(s)
+}
+
+// SliceRefByteArray32 returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefByteArray32(s []ByteArray32) SliceRefByteArray32 {
+	pinFirst(&p.p, s)
+	return AsSliceRefByteArray32(s)
+}
+
+// SliceRefAggregationInputs returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefAggregationInputs(s []AggregationInputs) SliceRefAggregationInputs {
+	pinFirst(&p.p, s)
+	return AsSliceRefAggregationInputs(s)
+}
+
+// SliceRefPublicReplicaInfo returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefPublicReplicaInfo(s []PublicReplicaInfo) SliceRefPublicReplicaInfo {
+	pinFirst(&p.p, s)
+	return AsSliceRefPublicReplicaInfo(s)
+}
+
+// SliceRefPrivateReplicaInfo returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefPrivateReplicaInfo(s []PrivateReplicaInfo) SliceRefPrivateReplicaInfo {
+	pinFirst(&p.p, s)
+	return AsSliceRefPrivateReplicaInfo(s)
+}
+
+// SliceRefPoStProof returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefPoStProof(s []PoStProof) SliceRefPoStProof {
+	pinFirst(&p.p, s)
+	return AsSliceRefPoStProof(s)
+}
+
+// SliceRefPublicPieceInfo returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefPublicPieceInfo(s []PublicPieceInfo) SliceRefPublicPieceInfo {
+	pinFirst(&p.p, s)
+	return AsSliceRefPublicPieceInfo(s)
+}
+
+// SliceRefSliceBoxedUint8 returns a reference to s, valid until Unpin.
+func (p *Pinner) SliceRefSliceBoxedUint8(s []SliceBoxedUint8) SliceRefSliceBoxedUint8 {
+	pinFirst(&p.p, s)
+	return AsSliceRefSliceBoxedUint8(s)
+}
+
+// SlicesBoxedUint8 returns src as boxed slices, valid until Unpin. They are
+// borrowed from src and must not be destroyed.
+func (p *Pinner) SlicesBoxedUint8(src [][]byte) []SliceBoxedUint8 {
+	out := make([]SliceBoxedUint8, len(src))
+	for i, b := range src {
+		out[i].len = C.size_t(len(b))
+		if len(b) == 0 {
+			out[i].ptr = &emptyUint8
+			continue
+		}
+		pinFirst(&p.p, b)
+		out[i].ptr = (*C.uint8_t)(unsafe.Pointer(&b[0]))
+	}
+	return out
+}
+
+// pinFirst pins the backing array of s.
+func pinFirst[T any](p *runtime.Pinner, s []T) {
+	if len(s) > 0 {
+		p.Pin(&s[0])
+	}
+}
+
+// Unpin releases the memory of all the references returned by p.
+func (p *Pinner) Unpin() {
+	p.p.Unpin()
+}
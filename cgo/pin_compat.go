@@ -0,0 +1,151 @@
+//go:build !go1.21
+// +build !go1.21
+
+package cgo
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import "unsafe"
+
+// Pinner builds the arguments of native calls from Go memory, copying to
+// native memory what runtime.Pinner would pin from Go 1.21 on. The zero value
+// is ready to use.
+//
+// The copies of the slices are shallow: the memory their elements point to,
+// such as the boxed slices of PrivateReplicaInfos, must be native already.
+type Pinner struct {
+	boxed  []SliceBoxedUint8
+	copies []unsafe.Pointer
+}
+
+// copy returns a native copy of the size bytes at ptr, freed by Unpin.
+func (p *Pinner) copy(ptr unsafe.Pointer, size uintptr) unsafe.Pointer {
+	c := C.CBytes(unsafe.Slice((*byte)(ptr), size))
+	p.copies = append(p.copies, c)
+	return c
+}
+
+// SliceRefUint8 returns a reference to a native copy of b, valid until
+// Unpin.
+func (p *Pinner) SliceRefUint8(b []byte) SliceRefUint8 {
+	if len(b) == 0 {
+		return AsSliceRefUint8(b)
+	}
+	c := p.copy(unsafe.Pointer(&b[0]), uintptr(len(b))*unsafe.Sizeof(b[0]))
+	return AsSliceRefUint8(unsafe.Slice((*byte)(c), len(b)))
+}
+
+// SliceRefUint64 returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefUint64(s []uint64) SliceRefUint64 {
+	if len(s) == 0 {
+		return AsSliceRefUint64(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefUint64(unsafe.Slice((*uint64)(c), len(s)))
+}
+
+// SliceRefUint returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefUint(s []uint) SliceRefUint {
+	if len(s) == 0 {
+		return AsSliceRefUint(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefUint(unsafe.Slice((*uint)(c), len(s)))
+}
+
+// SliceRefByteArray32 returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefByteArray32(s []ByteArray32) SliceRefByteArray32 {
+	if len(s) == 0 {
+		return AsSliceRefByteArray32(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefByteArray32(unsafe.Slice((*ByteArray32)(c), len(s)))
+}
+
+// SliceRefAggregationInputs returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefAggregationInputs(s []AggregationInputs) SliceRefAggregationInputs {
+	if len(s) == 0 {
+		return AsSliceRefAggregationInputs(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefAggregationInputs(unsafe.Slice((*AggregationInputs)(c), len(s)))
+}
+
+// SliceRefPublicReplicaInfo returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefPublicReplicaInfo(s []PublicReplicaInfo) SliceRefPublicReplicaInfo {
+	if len(s) == 0 {
+		return AsSliceRefPublicReplicaInfo(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefPublicReplicaInfo(unsafe.Slice((*PublicReplicaInfo)(c), len(s)))
+}
+
+// SliceRefPrivateReplicaInfo returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefPrivateReplicaInfo(s []PrivateReplicaInfo) SliceRefPrivateReplicaInfo {
+	if len(s) == 0 {
+		return AsSliceRefPrivateReplicaInfo(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefPrivateReplicaInfo(unsafe.Slice((*PrivateReplicaInfo)(c), len(s)))
+}
+
+// SliceRefPoStProof returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefPoStProof(s []PoStProof) SliceRefPoStProof {
+	if len(s) == 0 {
+		return AsSliceRefPoStProof(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefPoStProof(unsafe.Slice((*PoStProof)(c), len(s)))
+}
+
+// SliceRefPublicPieceInfo returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefPublicPieceInfo(s []PublicPieceInfo) SliceRefPublicPieceInfo {
+	if len(s) == 0 {
+		return AsSliceRefPublicPieceInfo(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefPublicPieceInfo(unsafe.Slice((*PublicPieceInfo)(c), len(s)))
+}
+
+// SliceRefSliceBoxedUint8 returns a reference to a native copy of s, valid until
+// Unpin.
+func (p *Pinner) SliceRefSliceBoxedUint8(s []SliceBoxedUint8) SliceRefSliceBoxedUint8 {
+	if len(s) == 0 {
+		return AsSliceRefSliceBoxedUint8(s)
+	}
+	c := p.copy(unsafe.Pointer(&s[0]), uintptr(len(s))*unsafe.Sizeof(s[0]))
+	return AsSliceRefSliceBoxedUint8(unsafe.Slice((*SliceBoxedUint8)(c), len(s)))
+}
+
+// SlicesBoxedUint8 returns copies of src as boxed slices, valid until Unpin.
+// They are owned by p and must not be destroyed.
+func (p *Pinner) SlicesBoxedUint8(src [][]byte) []SliceBoxedUint8 {
+	out := make([]SliceBoxedUint8, len(src))
+	for i := range src {
+		out[i] = AllocSliceBoxedUint8(src[i])
+	}
+	p.boxed = append(p.boxed, out...)
+	return out
+}
+
+// Unpin frees the copies made by p.
+func (p *Pinner) Unpin() {
+	for i := range p.boxed {
+		p.boxed[i].Destroy()
+	}
+	p.boxed = nil
+	for _, c := range p.copies {
+		C.free(c)
+	}
+	p.copies = nil
+}
@@ -0,0 +1,31 @@
+//go:build !go1.21
+// +build !go1.21
+
+package cgo
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinnerCopies(t *testing.T) {
+	var p Pinner
+	defer p.Unpin()
+
+	b := []byte("cache dir")
+	ref := p.SliceRefUint8(b)
+	assert.NotEqual(t, unsafe.Pointer(&b[0]), unsafe.Pointer(ref.ptr))
+	assert.Equal(t, len(b), int(ref.len))
+	assert.Equal(t, b, unsafe.Slice((*byte)(unsafe.Pointer(ref.ptr)), len(b)))
+
+	infos := []PublicPieceInfo{NewPublicPieceInfo(127, ByteArray32{}), NewPublicPieceInfo(254, ByteArray32{})}
+	infosRef := p.SliceRefPublicPieceInfo(infos)
+	assert.NotEqual(t, unsafe.Pointer(&infos[0]), unsafe.Pointer(infosRef.ptr))
+	assert.Equal(t, infos, unsafe.Slice((*PublicPieceInfo)(unsafe.Pointer(infosRef.ptr)), len(infos)))
+
+	empty := p.SliceRefAggregationInputs(nil)
+	assert.NotNil(t, empty.ptr)
+	assert.Equal(t, 0, int(empty.len))
+}
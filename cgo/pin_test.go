@@ -0,0 +1,49 @@
+//go:build go1.21
+// +build go1.21
+
+package cgo
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnerSlicesBoxedUint8(t *testing.T) {
+	src := [][]byte{[]byte("vanilla proof"), nil, {1, 2, 3}}
+
+	var p Pinner
+	defer p.Unpin()
+
+	boxed := p.SlicesBoxedUint8(src)
+	require.Len(t, boxed, len(src))
+	for i, b := range boxed {
+		assert.Equal(t, len(src[i]), int(b.len))
+		assert.NotNil(t, b.ptr)
+		if len(src[i]) > 0 {
+			// borrowed, not copied
+			assert.Equal(t, unsafe.Pointer(&src[i][0]), unsafe.Pointer(b.ptr))
+			assert.Equal(t, src[i], b.slice())
+		}
+	}
+
+	ref := p.SliceRefUint8(src[0])
+	assert.Equal(t, unsafe.Pointer(&src[0][0]), unsafe.Pointer(ref.ptr))
+	assert.Equal(t, len(src[0]), int(ref.len))
+}
+
+func TestPinnerSliceRefs(t *testing.T) {
+	var p Pinner
+	defer p.Unpin()
+
+	infos := []PublicPieceInfo{NewPublicPieceInfo(127, ByteArray32{}), NewPublicPieceInfo(254, ByteArray32{})}
+	ref := p.SliceRefPublicPieceInfo(infos)
+	assert.Equal(t, unsafe.Pointer(&infos[0]), unsafe.Pointer(ref.ptr))
+	assert.Equal(t, 2, int(ref.len))
+
+	empty := p.SliceRefAggregationInputs(nil)
+	assert.NotNil(t, empty.ptr)
+	assert.Equal(t, 0, int(empty.len))
+}
@@ -8,9 +8,17 @@ package cgo
 */
 import "C"
 
-func VerifySeal(registeredProof RegisteredSealProof, commR *ByteArray32, commD *ByteArray32, proverId *ByteArray32, ticket *ByteArray32, seed *ByteArray32, sectorId uint64, proof SliceRefUint8) (bool, error) {
-	resp := C.verify_seal(registeredProof, commR, commD, proverId, ticket, seed, C.uint64_t(sectorId), proof)
-	defer resp.destroy()
+func VerifySeal(registeredProof RegisteredSealProof, commR *ByteArray32, commD *ByteArray32, proverId *ByteArray32, ticket *ByteArray32, seed *ByteArray32, sectorId uint64, proof SliceRefUint8) (_ bool, err error) {
+	defer beginCall(Call{Name: "verify_seal", ProofType: registeredProof.String(), SectorID: sectorId, HasSectorID: true, Args: func() []CallArg {
+		return []CallArg{array32Arg("comm_r", commR), array32Arg("comm_d", commD), array32Arg("ticket", ticket), array32Arg("seed", seed), {Name: "proof", Value: proof.slice()}}
+	}}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return false, err
+	}
+
+	resp := C.verify_seal(C.RegisteredSealProof_t(registeredProof), commR, commD, proverId, ticket, seed, C.uint64_t(sectorId), proof)
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return false, err
@@ -19,9 +27,20 @@ func VerifySeal(registeredProof RegisteredSealProof, commR *ByteArray32, commD *
 	return bool(resp.value), nil
 }
 
-func VerifyAggregateSealProof(registeredProof RegisteredSealProof, registeredAggregation RegisteredAggregationProof, proverId *ByteArray32, proof SliceRefUint8, commitInputs SliceRefAggregationInputs) (bool, error) {
-	resp := C.verify_aggregate_seal_proof(registeredProof, registeredAggregation, proverId, proof, commitInputs)
-	defer resp.destroy()
+func VerifyAggregateSealProof(registeredProof RegisteredSealProof, registeredAggregation RegisteredAggregationProof, proverId *ByteArray32, proof SliceRefUint8, commitInputs SliceRefAggregationInputs) (_ bool, err error) {
+	defer beginCall(Call{Name: "verify_aggregate_seal_proof", ProofType: registeredProof.String(), Args: func() []CallArg {
+		return []CallArg{{Name: "proof", Value: proof.slice()}}
+	}}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return false, err
+	}
+	if err := registeredAggregation.Validate(); err != nil {
+		return false, err
+	}
+
+	resp := C.verify_aggregate_seal_proof(C.RegisteredSealProof_t(registeredProof), C.RegisteredAggregationProof_t(registeredAggregation), proverId, proof, commitInputs)
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return false, err
@@ -29,9 +48,13 @@ func VerifyAggregateSealProof(registeredProof RegisteredSealProof, registeredAgg
 	return bool(resp.value), nil
 }
 
-func VerifyWinningPoSt(randomness *ByteArray32, replicas SliceRefPublicReplicaInfo, proofs SliceRefPoStProof, proverId *ByteArray32) (bool, error) {
+func VerifyWinningPoSt(randomness *ByteArray32, replicas SliceRefPublicReplicaInfo, proofs SliceRefPoStProof, proverId *ByteArray32) (_ bool, err error) {
+	defer beginCall(Call{Name: "verify_winning_post", Args: func() []CallArg {
+		return []CallArg{array32Arg("randomness", randomness)}
+	}}).end(&err, nil)
+
 	resp := C.verify_winning_post(randomness, replicas, proofs, proverId)
-	defer resp.destroy()
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return false, err
@@ -40,9 +63,13 @@ func VerifyWinningPoSt(randomness *ByteArray32, replicas SliceRefPublicReplicaIn
 	return bool(resp.value), nil
 }
 
-func VerifyWindowPoSt(randomness *ByteArray32, replicas SliceRefPublicReplicaInfo, proofs SliceRefPoStProof, proverId *ByteArray32) (bool, error) {
+func VerifyWindowPoSt(randomness *ByteArray32, replicas SliceRefPublicReplicaInfo, proofs SliceRefPoStProof, proverId *ByteArray32) (_ bool, err error) {
+	defer beginCall(Call{Name: "verify_window_post", Args: func() []CallArg {
+		return []CallArg{array32Arg("randomness", randomness)}
+	}}).end(&err, nil)
+
 	resp := C.verify_window_post(randomness, replicas, proofs, proverId)
-	defer resp.destroy()
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return false, err
@@ -51,9 +78,56 @@ func VerifyWindowPoSt(randomness *ByteArray32, replicas SliceRefPublicReplicaInf
 	return bool(resp.value), nil
 }
 
-func GeneratePieceCommitment(registeredProof RegisteredSealProof, pieceFdRaw int32, unpaddedPieceSize uint64) ([]byte, error) {
-	resp := C.generate_piece_commitment(registeredProof, C.int32_t(pieceFdRaw), C.uint64_t(unpaddedPieceSize))
-	defer resp.destroy()
+// VerifySeals verifies seal proofs of registeredProof in a single call, the
+// proof i being that of inputs[i] sealed by proverIds[i].
+func VerifySeals(registeredProof RegisteredSealProof, inputs SliceRefAggregationInputs, proverIds SliceRefByteArray32, proofs SliceRefSliceBoxedUint8) (_ []bool, err error) {
+	defer beginCall(Call{Name: "verify_seals", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.verify_seals(C.RegisteredSealProof_t(registeredProof), inputs, proverIds, proofs)
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return nil, err
+	}
+
+	return asBools(resp.value.copy()), nil
+}
+
+// VerifyWindowPoSts verifies window PoSts in a single call, the PoSt i being
+// over the next replicaCounts[i] replicas and made of the next proofCounts[i]
+// proofs.
+func VerifyWindowPoSts(randomness SliceRefByteArray32, proverIds SliceRefByteArray32, replicas SliceRefPublicReplicaInfo, replicaCounts SliceRefUint, proofs SliceRefPoStProof, proofCounts SliceRefUint) (_ []bool, err error) {
+	defer beginCall(Call{Name: "verify_window_posts"}).end(&err, nil)
+
+	resp := C.verify_window_posts(randomness, proverIds, replicas, replicaCounts, proofs, proofCounts)
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return nil, err
+	}
+
+	return asBools(resp.value.copy()), nil
+}
+
+func asBools(b []byte) []bool {
+	out := make([]bool, len(b))
+	for i, v := range b {
+		out[i] = v != 0
+	}
+	return out
+}
+
+func GeneratePieceCommitment(registeredProof RegisteredSealProof, pieceFdRaw int32, unpaddedPieceSize uint64) (out []byte, err error) {
+	defer beginCall(Call{Name: "generate_piece_commitment", ProofType: registeredProof.String()}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.generate_piece_commitment(C.RegisteredSealProof_t(registeredProof), C.int32_t(pieceFdRaw), C.uint64_t(unpaddedPieceSize))
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return nil, err
@@ -62,9 +136,15 @@ func GeneratePieceCommitment(registeredProof RegisteredSealProof, pieceFdRaw int
 	return resp.value.comm_p.copy(), nil
 }
 
-func GenerateDataCommitment(registeredProof RegisteredSealProof, pieces SliceRefPublicPieceInfo) ([]byte, error) {
-	resp := C.generate_data_commitment(registeredProof, pieces)
-	defer resp.destroy()
+func GenerateDataCommitment(registeredProof RegisteredSealProof, pieces SliceRefPublicPieceInfo) (out []byte, err error) {
+	defer beginCall(Call{Name: "generate_data_commitment", ProofType: registeredProof.String()}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.generate_data_commitment(C.RegisteredSealProof_t(registeredProof), pieces)
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return nil, err
@@ -73,9 +153,15 @@ func GenerateDataCommitment(registeredProof RegisteredSealProof, pieces SliceRef
 	return resp.value.copy(), nil
 }
 
-func WriteWithAlignment(registeredProof RegisteredSealProof, srcFd int32, srcSize uint64, dstFd int32, existingPieceSizes SliceRefUint64) (uint64, uint64, []byte, error) {
-	resp := C.write_with_alignment(registeredProof, C.int32_t(srcFd), C.uint64_t(srcSize), C.int32_t(dstFd), existingPieceSizes)
-	defer resp.destroy()
+func WriteWithAlignment(registeredProof RegisteredSealProof, srcFd int32, srcSize uint64, dstFd int32, existingPieceSizes SliceRefUint64) (_ uint64, _ uint64, _ []byte, err error) {
+	defer beginCall(Call{Name: "write_with_alignment", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return 0, 0, nil, err
+	}
+
+	resp := C.write_with_alignment(C.RegisteredSealProof_t(registeredProof), C.int32_t(srcFd), C.uint64_t(srcSize), C.int32_t(dstFd), existingPieceSizes)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return 0, 0, nil, err
 	}
@@ -83,9 +169,15 @@ func WriteWithAlignment(registeredProof RegisteredSealProof, srcFd int32, srcSiz
 	return uint64(resp.value.left_alignment_unpadded), uint64(resp.value.total_write_unpadded), resp.value.comm_p.copy(), nil
 }
 
-func WriteWithoutAlignment(registeredProof RegisteredSealProof, srcFd int32, srcSize uint64, dstFd int32) (uint64, []byte, error) {
-	resp := C.write_without_alignment(registeredProof, C.int32_t(srcFd), C.uint64_t(srcSize), C.int32_t(dstFd))
-	defer resp.destroy()
+func WriteWithoutAlignment(registeredProof RegisteredSealProof, srcFd int32, srcSize uint64, dstFd int32) (_ uint64, _ []byte, err error) {
+	defer beginCall(Call{Name: "write_without_alignment", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return 0, nil, err
+	}
+
+	resp := C.write_without_alignment(C.RegisteredSealProof_t(registeredProof), C.int32_t(srcFd), C.uint64_t(srcSize), C.int32_t(dstFd))
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return 0, nil, err
 	}
@@ -93,27 +185,47 @@ func WriteWithoutAlignment(registeredProof RegisteredSealProof, srcFd int32, src
 	return uint64(resp.value.total_write_unpadded), resp.value.comm_p.copy(), nil
 }
 
-func SealPreCommitPhase1(registeredProof RegisteredSealProof, cacheDirPath SliceRefUint8, stagedSectorPath SliceRefUint8, sealedSectorPath SliceRefUint8, sectorId uint64, proverId *ByteArray32, ticket *ByteArray32, pieces SliceRefPublicPieceInfo) ([]byte, error) {
-	resp := C.seal_pre_commit_phase1(registeredProof, cacheDirPath, stagedSectorPath, sealedSectorPath, C.uint64_t(sectorId), proverId, ticket, pieces)
-	defer resp.destroy()
+func SealPreCommitPhase1(registeredProof RegisteredSealProof, cacheDirPath SliceRefUint8, stagedSectorPath SliceRefUint8, sealedSectorPath SliceRefUint8, sectorId uint64, proverId *ByteArray32, ticket *ByteArray32, pieces SliceRefPublicPieceInfo) (out []byte, err error) {
+	defer beginCall(Call{Name: "seal_pre_commit_phase1", ProofType: registeredProof.String(), SectorID: sectorId, HasSectorID: true, Args: func() []CallArg {
+		return []CallArg{array32Arg("ticket", ticket)}
+	}}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.seal_pre_commit_phase1(C.RegisteredSealProof_t(registeredProof), cacheDirPath, stagedSectorPath, sealedSectorPath, C.uint64_t(sectorId), proverId, ticket, pieces)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
 	return resp.value.copy(), nil
 }
 
-func SealPreCommitPhase2(sealPreCommitPhase1Output SliceRefUint8, cacheDirPath SliceRefUint8, sealedSectorPath SliceRefUint8) ([]byte, []byte, error) {
+func SealPreCommitPhase2(sealPreCommitPhase1Output SliceRefUint8, cacheDirPath SliceRefUint8, sealedSectorPath SliceRefUint8) (out []byte, _ []byte, err error) {
+	defer beginCall(Call{Name: "seal_pre_commit_phase2", Args: func() []CallArg {
+		return []CallArg{{Name: "phase1_output", Value: sealPreCommitPhase1Output.slice()}}
+	}}).end(&err, &out)
+
 	resp := C.seal_pre_commit_phase2(sealPreCommitPhase1Output, cacheDirPath, sealedSectorPath)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, nil, err
 	}
 	return resp.value.comm_r.copy(), resp.value.comm_d.copy(), nil
 }
 
-func SealCommitPhase1(registeredProof RegisteredSealProof, commR *ByteArray32, commD *ByteArray32, cacheDirPath SliceRefUint8, replicaPath SliceRefUint8, sectorId uint64, proverId *ByteArray32, ticket *ByteArray32, seed *ByteArray32, pieces SliceRefPublicPieceInfo) ([]byte, error) {
-	resp := C.seal_commit_phase1(registeredProof, commR, commD, cacheDirPath, replicaPath, C.uint64_t(sectorId), proverId, ticket, seed, pieces)
-	defer resp.destroy()
+func SealCommitPhase1(registeredProof RegisteredSealProof, commR *ByteArray32, commD *ByteArray32, cacheDirPath SliceRefUint8, replicaPath SliceRefUint8, sectorId uint64, proverId *ByteArray32, ticket *ByteArray32, seed *ByteArray32, pieces SliceRefPublicPieceInfo) (out []byte, err error) {
+	defer beginCall(Call{Name: "seal_commit_phase1", ProofType: registeredProof.String(), SectorID: sectorId, HasSectorID: true, Args: func() []CallArg {
+		return []CallArg{array32Arg("comm_r", commR), array32Arg("comm_d", commD), array32Arg("ticket", ticket), array32Arg("seed", seed)}
+	}}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.seal_commit_phase1(C.RegisteredSealProof_t(registeredProof), commR, commD, cacheDirPath, replicaPath, C.uint64_t(sectorId), proverId, ticket, seed, pieces)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
@@ -121,54 +233,83 @@ func SealCommitPhase1(registeredProof RegisteredSealProof, commR *ByteArray32, c
 	return resp.value.copy(), nil
 }
 
-func SealCommitPhase2(sealCommitPhase1Output SliceRefUint8, sectorId uint64, proverId *ByteArray32) ([]byte, error) {
+func SealCommitPhase2(sealCommitPhase1Output SliceRefUint8, sectorId uint64, proverId *ByteArray32) (out []byte, err error) {
+	defer beginCall(Call{Name: "seal_commit_phase2", SectorID: sectorId, HasSectorID: true, Args: func() []CallArg {
+		return []CallArg{{Name: "phase1_output", Value: sealCommitPhase1Output.slice()}}
+	}}).end(&err, &out)
+
 	resp := C.seal_commit_phase2(sealCommitPhase1Output, C.uint64_t(sectorId), proverId)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
 	return resp.value.copy(), nil
 }
 
-func AggregateSealProofs(registeredProof RegisteredSealProof, registeredAggregation RegisteredAggregationProof, commRs SliceRefByteArray32, seeds SliceRefByteArray32, sealCommitResponses SliceRefSliceBoxedUint8) ([]byte, error) {
-	resp := C.aggregate_seal_proofs(registeredProof, registeredAggregation, commRs, seeds, sealCommitResponses)
-	defer resp.destroy()
+func AggregateSealProofs(registeredProof RegisteredSealProof, registeredAggregation RegisteredAggregationProof, commRs SliceRefByteArray32, seeds SliceRefByteArray32, sealCommitResponses SliceRefSliceBoxedUint8) (out []byte, err error) {
+	defer beginCall(Call{Name: "aggregate_seal_proofs", ProofType: registeredProof.String()}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+	if err := registeredAggregation.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.aggregate_seal_proofs(C.RegisteredSealProof_t(registeredProof), C.RegisteredAggregationProof_t(registeredAggregation), commRs, seeds, sealCommitResponses)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
 	return resp.value.copy(), nil
 }
 
-func UnsealRange(registeredProof RegisteredSealProof, cacheDirPath SliceRefUint8, sealedSectorFdRaw int32, unsealOutputFdRaw int32, sectorId uint64, proverId *ByteArray32, ticket *ByteArray32, commD *ByteArray32, unpaddedByteIndex uint64, unpaddedBytesAmount uint64) error {
-	resp := C.unseal_range(registeredProof, cacheDirPath, C.int32_t(sealedSectorFdRaw), C.int32_t(unsealOutputFdRaw), C.uint64_t(sectorId), proverId, ticket, commD, C.uint64_t(unpaddedByteIndex), C.uint64_t(unpaddedBytesAmount))
-	defer resp.destroy()
+func UnsealRange(registeredProof RegisteredSealProof, cacheDirPath SliceRefUint8, sealedSectorFdRaw int32, unsealOutputFdRaw int32, sectorId uint64, proverId *ByteArray32, ticket *ByteArray32, commD *ByteArray32, unpaddedByteIndex uint64, unpaddedBytesAmount uint64) (err error) {
+	defer beginCall(Call{Name: "unseal_range", ProofType: registeredProof.String(), SectorID: sectorId, HasSectorID: true}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return err
+	}
+
+	resp := C.unseal_range(C.RegisteredSealProof_t(registeredProof), cacheDirPath, C.int32_t(sealedSectorFdRaw), C.int32_t(unsealOutputFdRaw), C.uint64_t(sectorId), proverId, ticket, commD, C.uint64_t(unpaddedByteIndex), C.uint64_t(unpaddedBytesAmount))
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return err
 	}
 	return nil
 }
 
-func GenerateWinningPoStSectorChallenge(registeredProof RegisteredPoStProof, randomness *ByteArray32, sectorSetLen uint64, proverId *ByteArray32) ([]uint64, error) {
-	resp := C.generate_winning_post_sector_challenge(registeredProof, randomness, C.uint64_t(sectorSetLen), proverId)
-	defer resp.destroy()
+func GenerateWinningPoStSectorChallenge(registeredProof RegisteredPoStProof, randomness *ByteArray32, sectorSetLen uint64, proverId *ByteArray32) (_ []uint64, err error) {
+	defer beginCall(Call{Name: "generate_winning_post_sector_challenge", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.generate_winning_post_sector_challenge(C.RegisteredPoStProof_t(registeredProof), randomness, C.uint64_t(sectorSetLen), proverId)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
 	return resp.value.copy(), nil
 }
 
-func GenerateWinningPoSt(randomness *ByteArray32, replicas SliceRefPrivateReplicaInfo, proverId *ByteArray32) ([]PoStProofGo, error) {
+func GenerateWinningPoSt(randomness *ByteArray32, replicas SliceRefPrivateReplicaInfo, proverId *ByteArray32) (_ []PoStProofGo, err error) {
+	defer beginCall(Call{Name: "generate_winning_post"}).end(&err, nil)
+
 	resp := C.generate_winning_post(randomness, replicas, proverId)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
 	return resp.value.copy(), nil
 }
 
-func GenerateWindowPoSt(randomness *ByteArray32, replicas SliceRefPrivateReplicaInfo, proverId *ByteArray32) ([]PoStProofGo, []uint64, error) {
+func GenerateWindowPoSt(randomness *ByteArray32, replicas SliceRefPrivateReplicaInfo, proverId *ByteArray32) (_ []PoStProofGo, _ []uint64, err error) {
+	defer beginCall(Call{Name: "generate_window_post"}).end(&err, nil)
+
 	resp := C.generate_window_post(randomness, replicas, proverId)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		faults := resp.value.faulty_sectors.copy()
 		return nil, faults, err
@@ -178,9 +319,23 @@ func GenerateWindowPoSt(randomness *ByteArray32, replicas SliceRefPrivateReplica
 	return proofs, []uint64{}, nil
 }
 
-func GetGpuDevices() ([]string, error) {
+func GetGpuDevices() (_ []string, err error) {
+	defer beginCall(Call{Name: "get_gpu_devices"}).end(&err, nil)
+
 	resp := C.get_gpu_devices()
-	defer resp.destroy()
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return nil, err
+	}
+
+	return resp.value.copyAsStrings(), nil
+}
+
+func GetGpuFrameworks() (_ []string, err error) {
+	defer beginCall(Call{Name: "get_gpu_frameworks"}).end(&err, nil)
+
+	resp := C.get_gpu_frameworks()
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
@@ -188,9 +343,27 @@ func GetGpuDevices() ([]string, error) {
 	return resp.value.copyAsStrings(), nil
 }
 
-func GetSealVersion(registeredProof RegisteredSealProof) (string, error) {
-	resp := C.get_seal_version(registeredProof)
-	defer resp.destroy()
+func GetBuildInfo() (_ string, err error) {
+	defer beginCall(Call{Name: "get_build_info"}).end(&err, nil)
+
+	resp := C.get_build_info()
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return "", err
+	}
+
+	return string(resp.value.copy()), nil
+}
+
+func GetSealVersion(registeredProof RegisteredSealProof) (_ string, err error) {
+	defer beginCall(Call{Name: "get_seal_version", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return "", err
+	}
+
+	resp := C.get_seal_version(C.RegisteredSealProof_t(registeredProof))
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return "", err
 	}
@@ -198,9 +371,15 @@ func GetSealVersion(registeredProof RegisteredSealProof) (string, error) {
 	return string(resp.value.copy()), nil
 }
 
-func GetPoStVersion(registeredProof RegisteredPoStProof) (string, error) {
-	resp := C.get_post_version(registeredProof)
-	defer resp.destroy()
+func GetPoStVersion(registeredProof RegisteredPoStProof) (_ string, err error) {
+	defer beginCall(Call{Name: "get_post_version", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return "", err
+	}
+
+	resp := C.get_post_version(C.RegisteredPoStProof_t(registeredProof))
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return "", err
 	}
@@ -208,9 +387,31 @@ func GetPoStVersion(registeredProof RegisteredPoStProof) (string, error) {
 	return string(resp.value.copy()), nil
 }
 
-func GetNumPartitionForFallbackPost(registeredProof RegisteredPoStProof, numSectors uint) (uint, error) {
-	resp := C.get_num_partition_for_fallback_post(registeredProof, C.size_t(numSectors))
-	defer resp.destroy()
+func GetNumPartitionForFallbackPost(registeredProof RegisteredPoStProof, numSectors uint) (_ uint, err error) {
+	defer beginCall(Call{Name: "get_num_partition_for_fallback_post", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return 0, err
+	}
+
+	resp := C.get_num_partition_for_fallback_post(C.RegisteredPoStProof_t(registeredProof), C.size_t(numSectors))
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return 0, err
+	}
+
+	return uint(resp.value), nil
+}
+
+func GetNumPartitionForUpdate(registeredProof RegisteredUpdateProof) (_ uint, err error) {
+	defer beginCall(Call{Name: "get_num_partition_for_update", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return 0, err
+	}
+
+	resp := C.get_num_partition_for_update(C.RegisteredUpdateProof_t(registeredProof))
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return 0, err
 	}
@@ -218,24 +419,48 @@ func GetNumPartitionForFallbackPost(registeredProof RegisteredPoStProof, numSect
 	return uint(resp.value), nil
 }
 
-func ClearCache(sectorSize uint64, cacheDirPath SliceRefUint8) error {
+func GetMaxUserBytesPerStagedSector(registeredProof RegisteredSealProof) (_ uint64, err error) {
+	defer beginCall(Call{Name: "get_max_user_bytes_per_staged_sector", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return 0, err
+	}
+
+	return uint64(C.get_max_user_bytes_per_staged_sector(C.RegisteredSealProof_t(registeredProof))), nil
+}
+
+func ClearCache(sectorSize uint64, cacheDirPath SliceRefUint8) (err error) {
+	defer beginCall(Call{Name: "clear_cache"}).end(&err, nil)
+
 	resp := C.clear_cache(C.uint64_t(sectorSize), cacheDirPath)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	return CheckErr(resp)
 }
 
-func Fauxrep(registeredProf RegisteredSealProof, cacheDirPath SliceRefUint8, sealedSectorPath SliceRefUint8) ([]byte, error) {
-	resp := C.fauxrep(registeredProf, cacheDirPath, sealedSectorPath)
-	defer resp.destroy()
+func Fauxrep(registeredProf RegisteredSealProof, cacheDirPath SliceRefUint8, sealedSectorPath SliceRefUint8) (out []byte, err error) {
+	defer beginCall(Call{Name: "fauxrep", ProofType: registeredProf.String()}).end(&err, &out)
+
+	if err := registeredProf.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.fauxrep(C.RegisteredSealProof_t(registeredProf), cacheDirPath, sealedSectorPath)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
 	return resp.value.copy(), nil
 }
 
-func Fauxrep2(registeredProf RegisteredSealProof, cacheDirPath SliceRefUint8, existingPAuxPath SliceRefUint8) ([]byte, error) {
-	resp := C.fauxrep2(registeredProf, cacheDirPath, existingPAuxPath)
-	defer resp.destroy()
+func Fauxrep2(registeredProf RegisteredSealProof, cacheDirPath SliceRefUint8, existingPAuxPath SliceRefUint8) (out []byte, err error) {
+	defer beginCall(Call{Name: "fauxrep2", ProofType: registeredProf.String()}).end(&err, &out)
+
+	if err := registeredProf.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.fauxrep2(C.RegisteredSealProof_t(registeredProf), cacheDirPath, existingPAuxPath)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
@@ -244,45 +469,75 @@ func Fauxrep2(registeredProf RegisteredSealProof, cacheDirPath SliceRefUint8, ex
 
 // sector update
 
-func EmptySectorUpdateEncodeInto(registeredProof RegisteredUpdateProof, newReplicaPath SliceRefUint8, newCacheDirPath SliceRefUint8, sectorKeyPath SliceRefUint8, sectorKeyCacheDirPath SliceRefUint8, stagedDataPath SliceRefUint8, pieces SliceRefPublicPieceInfo) ([]byte, []byte, error) {
-	resp := C.empty_sector_update_encode_into(registeredProof, newReplicaPath, newCacheDirPath, sectorKeyPath, sectorKeyCacheDirPath, stagedDataPath, pieces)
-	defer resp.destroy()
+func EmptySectorUpdateEncodeInto(registeredProof RegisteredUpdateProof, newReplicaPath SliceRefUint8, newCacheDirPath SliceRefUint8, sectorKeyPath SliceRefUint8, sectorKeyCacheDirPath SliceRefUint8, stagedDataPath SliceRefUint8, pieces SliceRefPublicPieceInfo) (out []byte, _ []byte, err error) {
+	defer beginCall(Call{Name: "empty_sector_update_encode_into", ProofType: registeredProof.String()}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	resp := C.empty_sector_update_encode_into(C.RegisteredUpdateProof_t(registeredProof), newReplicaPath, newCacheDirPath, sectorKeyPath, sectorKeyCacheDirPath, stagedDataPath, pieces)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, nil, err
 	}
 	return resp.value.comm_r_new.copy(), resp.value.comm_d_new.copy(), nil
 }
 
-func EmptySectorUpdateDecodeFrom(registeredProof RegisteredUpdateProof, outDataPath SliceRefUint8, replicaPath SliceRefUint8, sectorKeyPath SliceRefUint8, sectorKeyCacheDirPath SliceRefUint8, commDNew *ByteArray32) error {
-	resp := C.empty_sector_update_decode_from(registeredProof, outDataPath, replicaPath, sectorKeyPath, sectorKeyCacheDirPath, commDNew)
-	defer resp.destroy()
+func EmptySectorUpdateDecodeFrom(registeredProof RegisteredUpdateProof, outDataPath SliceRefUint8, replicaPath SliceRefUint8, sectorKeyPath SliceRefUint8, sectorKeyCacheDirPath SliceRefUint8, commDNew *ByteArray32) (err error) {
+	defer beginCall(Call{Name: "empty_sector_update_decode_from", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return err
+	}
+
+	resp := C.empty_sector_update_decode_from(C.RegisteredUpdateProof_t(registeredProof), outDataPath, replicaPath, sectorKeyPath, sectorKeyCacheDirPath, commDNew)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return err
 	}
 	return nil
 }
 
-func EmptySectorUpdateRemoveEncodedData(registeredProof RegisteredUpdateProof, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath, dataPath SliceRefUint8, commDNew *ByteArray32) error {
-	resp := C.empty_sector_update_remove_encoded_data(registeredProof, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath, dataPath, commDNew)
-	defer resp.destroy()
+func EmptySectorUpdateRemoveEncodedData(registeredProof RegisteredUpdateProof, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath, dataPath SliceRefUint8, commDNew *ByteArray32) (err error) {
+	defer beginCall(Call{Name: "empty_sector_update_remove_encoded_data", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return err
+	}
+
+	resp := C.empty_sector_update_remove_encoded_data(C.RegisteredUpdateProof_t(registeredProof), sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath, dataPath, commDNew)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return err
 	}
 	return nil
 }
 
-func GenerateEmptySectorUpdatePartitionProofs(registeredProof RegisteredUpdateProof, commROld, commRNew, commDNew *ByteArray32, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath SliceRefUint8) ([][]byte, error) {
-	resp := C.generate_empty_sector_update_partition_proofs(registeredProof, commROld, commRNew, commDNew, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath)
-	defer resp.destroy()
+func GenerateEmptySectorUpdatePartitionProofs(registeredProof RegisteredUpdateProof, commROld, commRNew, commDNew *ByteArray32, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath SliceRefUint8) (_ [][]byte, err error) {
+	defer beginCall(Call{Name: "generate_empty_sector_update_partition_proofs", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.generate_empty_sector_update_partition_proofs(C.RegisteredUpdateProof_t(registeredProof), commROld, commRNew, commDNew, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
 	return resp.value.copyAsBytes(), nil
 }
 
-func VerifyEmptySectorUpdatePartitionProofs(registeredProof RegisteredUpdateProof, proofs SliceRefSliceBoxedUint8, commROld, commRNew, commDNew *ByteArray32) (bool, error) {
-	resp := C.verify_empty_sector_update_partition_proofs(registeredProof, proofs, commROld, commRNew, commDNew)
-	defer resp.destroy()
+func VerifyEmptySectorUpdatePartitionProofs(registeredProof RegisteredUpdateProof, proofs SliceRefSliceBoxedUint8, commROld, commRNew, commDNew *ByteArray32) (_ bool, err error) {
+	defer beginCall(Call{Name: "verify_empty_sector_update_partition_proofs", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return false, err
+	}
+
+	resp := C.verify_empty_sector_update_partition_proofs(C.RegisteredUpdateProof_t(registeredProof), proofs, commROld, commRNew, commDNew)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return false, err
 	}
@@ -290,9 +545,15 @@ func VerifyEmptySectorUpdatePartitionProofs(registeredProof RegisteredUpdateProo
 	return bool(resp.value), nil
 }
 
-func GenerateEmptySectorUpdateProofWithVanilla(registeredProof RegisteredUpdateProof, vanillaProofs SliceRefSliceBoxedUint8, commROld, commRNew, commDNew *ByteArray32) ([]byte, error) {
-	resp := C.generate_empty_sector_update_proof_with_vanilla(registeredProof, vanillaProofs, commROld, commRNew, commDNew)
-	defer resp.destroy()
+func GenerateEmptySectorUpdateProofWithVanilla(registeredProof RegisteredUpdateProof, vanillaProofs SliceRefSliceBoxedUint8, commROld, commRNew, commDNew *ByteArray32) (out []byte, err error) {
+	defer beginCall(Call{Name: "generate_empty_sector_update_proof_with_vanilla", ProofType: registeredProof.String()}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.generate_empty_sector_update_proof_with_vanilla(C.RegisteredUpdateProof_t(registeredProof), vanillaProofs, commROld, commRNew, commDNew)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
@@ -300,9 +561,15 @@ func GenerateEmptySectorUpdateProofWithVanilla(registeredProof RegisteredUpdateP
 	return resp.value.copy(), nil
 }
 
-func GenerateEmptySectorUpdateProof(registeredProof RegisteredUpdateProof, commROld, commRNew, commDNew *ByteArray32, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath SliceRefUint8) ([]byte, error) {
-	resp := C.generate_empty_sector_update_proof(registeredProof, commROld, commRNew, commDNew, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath)
-	defer resp.destroy()
+func GenerateEmptySectorUpdateProof(registeredProof RegisteredUpdateProof, commROld, commRNew, commDNew *ByteArray32, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath SliceRefUint8) (out []byte, err error) {
+	defer beginCall(Call{Name: "generate_empty_sector_update_proof", ProofType: registeredProof.String()}).end(&err, &out)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.generate_empty_sector_update_proof(C.RegisteredUpdateProof_t(registeredProof), commROld, commRNew, commDNew, sectorKeyPath, sectorKeyCacheDirPath, replicaPath, replicaCachePath)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
@@ -310,9 +577,15 @@ func GenerateEmptySectorUpdateProof(registeredProof RegisteredUpdateProof, commR
 	return resp.value.copy(), nil
 }
 
-func VerifyEmptySectorUpdateProof(registeredProof RegisteredUpdateProof, proof SliceRefUint8, commROld, commRNew, commDNew *ByteArray32) (bool, error) {
-	resp := C.verify_empty_sector_update_proof(registeredProof, proof, commROld, commRNew, commDNew)
-	defer resp.destroy()
+func VerifyEmptySectorUpdateProof(registeredProof RegisteredUpdateProof, proof SliceRefUint8, commROld, commRNew, commDNew *ByteArray32) (_ bool, err error) {
+	defer beginCall(Call{Name: "verify_empty_sector_update_proof", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return false, err
+	}
+
+	resp := C.verify_empty_sector_update_proof(C.RegisteredUpdateProof_t(registeredProof), proof, commROld, commRNew, commDNew)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return false, err
 	}
@@ -322,9 +595,15 @@ func VerifyEmptySectorUpdateProof(registeredProof RegisteredUpdateProof, proof S
 
 // -- distributed
 
-func GenerateFallbackSectorChallenges(registeredProof RegisteredPoStProof, randomness *ByteArray32, sectorIds SliceRefUint64, proverId *ByteArray32) ([]uint64, [][]uint64, error) {
-	resp := C.generate_fallback_sector_challenges(registeredProof, randomness, sectorIds, proverId)
-	defer resp.destroy()
+func GenerateFallbackSectorChallenges(registeredProof RegisteredPoStProof, randomness *ByteArray32, sectorIds SliceRefUint64, proverId *ByteArray32) (_ []uint64, _ [][]uint64, err error) {
+	defer beginCall(Call{Name: "generate_fallback_sector_challenges", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	resp := C.generate_fallback_sector_challenges(C.RegisteredPoStProof_t(registeredProof), randomness, sectorIds, proverId)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, nil, err
 	}
@@ -332,9 +611,11 @@ func GenerateFallbackSectorChallenges(registeredProof RegisteredPoStProof, rando
 	return resp.value.ids.copy(), resp.value.challenges.copy(), nil
 }
 
-func GenerateSingleVanillaProof(replica PrivateReplicaInfo, challenges SliceRefUint64) ([]byte, error) {
+func GenerateSingleVanillaProof(replica PrivateReplicaInfo, challenges SliceRefUint64) (out []byte, err error) {
+	defer beginCall(Call{Name: "generate_single_vanilla_proof"}).end(&err, &out)
+
 	resp := C.generate_single_vanilla_proof(replica, challenges)
-	defer resp.destroy()
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
@@ -342,9 +623,15 @@ func GenerateSingleVanillaProof(replica PrivateReplicaInfo, challenges SliceRefU
 	return resp.value.copy(), nil
 }
 
-func GenerateWinningPoStWithVanilla(registeredProof RegisteredPoStProof, randomness, proverId *ByteArray32, vanillaProofs SliceRefSliceBoxedUint8) ([]PoStProofGo, error) {
-	resp := C.generate_winning_post_with_vanilla(registeredProof, randomness, proverId, vanillaProofs)
-	defer resp.destroy()
+func GenerateWinningPoStWithVanilla(registeredProof RegisteredPoStProof, randomness, proverId *ByteArray32, vanillaProofs SliceRefSliceBoxedUint8) (_ []PoStProofGo, err error) {
+	defer beginCall(Call{Name: "generate_winning_post_with_vanilla", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp := C.generate_winning_post_with_vanilla(C.RegisteredPoStProof_t(registeredProof), randomness, proverId, vanillaProofs)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, err
 	}
@@ -352,9 +639,15 @@ func GenerateWinningPoStWithVanilla(registeredProof RegisteredPoStProof, randomn
 	return resp.value.copy(), nil
 }
 
-func GenerateWindowPoStWithVanilla(registeredProof RegisteredPoStProof, randomness, proverId *ByteArray32, vanillaProofs SliceRefSliceBoxedUint8) ([]PoStProofGo, []uint64, error) {
-	resp := C.generate_window_post_with_vanilla(registeredProof, randomness, proverId, vanillaProofs)
-	defer resp.destroy()
+func GenerateWindowPoStWithVanilla(registeredProof RegisteredPoStProof, randomness, proverId *ByteArray32, vanillaProofs SliceRefSliceBoxedUint8) (_ []PoStProofGo, _ []uint64, err error) {
+	defer beginCall(Call{Name: "generate_window_post_with_vanilla", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	resp := C.generate_window_post_with_vanilla(C.RegisteredPoStProof_t(registeredProof), randomness, proverId, vanillaProofs)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return nil, nil, err
 	}
@@ -362,9 +655,15 @@ func GenerateWindowPoStWithVanilla(registeredProof RegisteredPoStProof, randomne
 	return resp.value.proofs.copy(), resp.value.faulty_sectors.copy(), nil
 }
 
-func GenerateSingleWindowPoStWithVanilla(registeredProof RegisteredPoStProof, randomness, proverId *ByteArray32, vanillaProofs SliceRefSliceBoxedUint8, partitionIndex uint) (PartitionSnarkProofGo, []uint64, error) {
-	resp := C.generate_single_window_post_with_vanilla(registeredProof, randomness, proverId, vanillaProofs, C.size_t(partitionIndex))
-	defer resp.destroy()
+func GenerateSingleWindowPoStWithVanilla(registeredProof RegisteredPoStProof, randomness, proverId *ByteArray32, vanillaProofs SliceRefSliceBoxedUint8, partitionIndex uint) (_ PartitionSnarkProofGo, _ []uint64, err error) {
+	defer beginCall(Call{Name: "generate_single_window_post_with_vanilla", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return PartitionSnarkProofGo{}, nil, err
+	}
+
+	resp := C.generate_single_window_post_with_vanilla(C.RegisteredPoStProof_t(registeredProof), randomness, proverId, vanillaProofs, C.size_t(partitionIndex))
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return PartitionSnarkProofGo{}, nil, err
 	}
@@ -372,12 +671,82 @@ func GenerateSingleWindowPoStWithVanilla(registeredProof RegisteredPoStProof, ra
 	return resp.value.partition_proof.copy(), resp.value.faulty_sectors.copy(), nil
 }
 
-func MergeWindowPoStPartitionProofs(registeredProof RegisteredPoStProof, partitionProofs SliceRefSliceBoxedUint8) (PoStProofGo, error) {
-	resp := C.merge_window_post_partition_proofs(registeredProof, partitionProofs)
-	defer resp.destroy()
+func MergeWindowPoStPartitionProofs(registeredProof RegisteredPoStProof, partitionProofs SliceRefSliceBoxedUint8) (_ PoStProofGo, err error) {
+	defer beginCall(Call{Name: "merge_window_post_partition_proofs", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return PoStProofGo{}, err
+	}
+
+	resp := C.merge_window_post_partition_proofs(C.RegisteredPoStProof_t(registeredProof), partitionProofs)
+	defer track(resp).destroy()
 	if err := CheckErr(resp); err != nil {
 		return PoStProofGo{}, err
 	}
 
 	return resp.value.copy(), nil
 }
+
+func GetSealParamsPath(registeredProof RegisteredSealProof) (_ string, err error) {
+	defer beginCall(Call{Name: "get_seal_params_path", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return "", err
+	}
+
+	resp := C.get_seal_params_path(C.RegisteredSealProof_t(registeredProof))
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return "", err
+	}
+
+	return string(resp.value.copy()), nil
+}
+
+func GetSealVerifyingKeyPath(registeredProof RegisteredSealProof) (_ string, err error) {
+	defer beginCall(Call{Name: "get_seal_verifying_key_path", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return "", err
+	}
+
+	resp := C.get_seal_verifying_key_path(C.RegisteredSealProof_t(registeredProof))
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return "", err
+	}
+
+	return string(resp.value.copy()), nil
+}
+
+func GetPoStParamsPath(registeredProof RegisteredPoStProof) (_ string, err error) {
+	defer beginCall(Call{Name: "get_post_params_path", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return "", err
+	}
+
+	resp := C.get_post_params_path(C.RegisteredPoStProof_t(registeredProof))
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return "", err
+	}
+
+	return string(resp.value.copy()), nil
+}
+
+func GetPoStVerifyingKeyPath(registeredProof RegisteredPoStProof) (_ string, err error) {
+	defer beginCall(Call{Name: "get_post_verifying_key_path", ProofType: registeredProof.String()}).end(&err, nil)
+
+	if err := registeredProof.Validate(); err != nil {
+		return "", err
+	}
+
+	resp := C.get_post_verifying_key_path(C.RegisteredPoStProof_t(registeredProof))
+	defer track(resp).destroy()
+	if err := CheckErr(resp); err != nil {
+		return "", err
+	}
+
+	return string(resp.value.copy()), nil
+}
@@ -0,0 +1,286 @@
+package cgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The names of the registered proofs are those of their filecoin-proofs-api
+// variants.
+
+var sealProofNames = map[RegisteredSealProof]string{
+	RegisteredSealProofStackedDrg2KiBV1:    "StackedDrg2KiBV1",
+	RegisteredSealProofStackedDrg8MiBV1:    "StackedDrg8MiBV1",
+	RegisteredSealProofStackedDrg512MiBV1:  "StackedDrg512MiBV1",
+	RegisteredSealProofStackedDrg32GiBV1:   "StackedDrg32GiBV1",
+	RegisteredSealProofStackedDrg64GiBV1:   "StackedDrg64GiBV1",
+	RegisteredSealProofStackedDrg2KiBV11:   "StackedDrg2KiBV1_1",
+	RegisteredSealProofStackedDrg8MiBV11:   "StackedDrg8MiBV1_1",
+	RegisteredSealProofStackedDrg512MiBV11: "StackedDrg512MiBV1_1",
+	RegisteredSealProofStackedDrg32GiBV11:  "StackedDrg32GiBV1_1",
+	RegisteredSealProofStackedDrg64GiBV11:  "StackedDrg64GiBV1_1",
+}
+
+var postProofNames = map[RegisteredPoStProof]string{
+	RegisteredPoStProofStackedDrgWinning2KiBV1:   "StackedDrgWinning2KiBV1",
+	RegisteredPoStProofStackedDrgWinning8MiBV1:   "StackedDrgWinning8MiBV1",
+	RegisteredPoStProofStackedDrgWinning512MiBV1: "StackedDrgWinning512MiBV1",
+	RegisteredPoStProofStackedDrgWinning32GiBV1:  "StackedDrgWinning32GiBV1",
+	RegisteredPoStProofStackedDrgWinning64GiBV1:  "StackedDrgWinning64GiBV1",
+	RegisteredPoStProofStackedDrgWindow2KiBV1:    "StackedDrgWindow2KiBV1",
+	RegisteredPoStProofStackedDrgWindow8MiBV1:    "StackedDrgWindow8MiBV1",
+	RegisteredPoStProofStackedDrgWindow512MiBV1:  "StackedDrgWindow512MiBV1",
+	RegisteredPoStProofStackedDrgWindow32GiBV1:   "StackedDrgWindow32GiBV1",
+	RegisteredPoStProofStackedDrgWindow64GiBV1:   "StackedDrgWindow64GiBV1",
+}
+
+var aggregationProofNames = map[RegisteredAggregationProof]string{
+	RegisteredAggregationProofSnarkPackV1: "SnarkPackV1",
+}
+
+var updateProofNames = map[RegisteredUpdateProof]string{
+	RegisteredUpdateProofStackedDrg2KiBV1:   "StackedDrg2KiBV1",
+	RegisteredUpdateProofStackedDrg8MiBV1:   "StackedDrg8MiBV1",
+	RegisteredUpdateProofStackedDrg512MiBV1: "StackedDrg512MiBV1",
+	RegisteredUpdateProofStackedDrg32GiBV1:  "StackedDrg32GiBV1",
+	RegisteredUpdateProofStackedDrg64GiBV1:  "StackedDrg64GiBV1",
+}
+
+func (p RegisteredSealProof) String() string {
+	if name, ok := sealProofNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("RegisteredSealProof(%d)", int64(p))
+}
+
+// Validate returns an error if p isn't a known seal proof.
+func (p RegisteredSealProof) Validate() error {
+	if _, ok := sealProofNames[p]; !ok {
+		return fmt.Errorf("%w: unknown seal proof %d", ErrInvalidInput, int64(p))
+	}
+	return nil
+}
+
+// RegisteredSealProofFromString returns the seal proof with the given name.
+func RegisteredSealProofFromString(s string) (RegisteredSealProof, error) {
+	for p, name := range sealProofNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown seal proof %q", ErrInvalidInput, s)
+}
+
+// MarshalJSON encodes the proof as its name.
+func (p RegisteredSealProof) MarshalJSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON decodes a proof name or number.
+func (p *RegisteredSealProof) UnmarshalJSON(b []byte) error {
+	name, num, err := unmarshalProofJSON(b)
+	if err != nil {
+		return err
+	}
+
+	out := RegisteredSealProof(num)
+	if name != "" {
+		out, err = RegisteredSealProofFromString(name)
+	} else {
+		err = out.Validate()
+	}
+	if err != nil {
+		return err
+	}
+
+	*p = out
+	return nil
+}
+
+func (p RegisteredPoStProof) String() string {
+	if name, ok := postProofNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("RegisteredPoStProof(%d)", int64(p))
+}
+
+// Validate returns an error if p isn't a known PoSt proof.
+func (p RegisteredPoStProof) Validate() error {
+	if _, ok := postProofNames[p]; !ok {
+		return fmt.Errorf("%w: unknown PoSt proof %d", ErrInvalidInput, int64(p))
+	}
+	return nil
+}
+
+// RegisteredPoStProofFromString returns the PoSt proof with the given name.
+func RegisteredPoStProofFromString(s string) (RegisteredPoStProof, error) {
+	for p, name := range postProofNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown PoSt proof %q", ErrInvalidInput, s)
+}
+
+// MarshalJSON encodes the proof as its name.
+func (p RegisteredPoStProof) MarshalJSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON decodes a proof name or number.
+func (p *RegisteredPoStProof) UnmarshalJSON(b []byte) error {
+	name, num, err := unmarshalProofJSON(b)
+	if err != nil {
+		return err
+	}
+
+	out := RegisteredPoStProof(num)
+	if name != "" {
+		out, err = RegisteredPoStProofFromString(name)
+	} else {
+		err = out.Validate()
+	}
+	if err != nil {
+		return err
+	}
+
+	*p = out
+	return nil
+}
+
+func (p RegisteredAggregationProof) String() string {
+	if name, ok := aggregationProofNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("RegisteredAggregationProof(%d)", int64(p))
+}
+
+// Validate returns an error if p isn't a known aggregation proof.
+func (p RegisteredAggregationProof) Validate() error {
+	if _, ok := aggregationProofNames[p]; !ok {
+		return fmt.Errorf("%w: unknown aggregation proof %d", ErrInvalidInput, int64(p))
+	}
+	return nil
+}
+
+// RegisteredAggregationProofFromString returns the aggregation proof with the
+// given name.
+func RegisteredAggregationProofFromString(s string) (RegisteredAggregationProof, error) {
+	for p, name := range aggregationProofNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown aggregation proof %q", ErrInvalidInput, s)
+}
+
+// MarshalJSON encodes the proof as its name.
+func (p RegisteredAggregationProof) MarshalJSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON decodes a proof name or number.
+func (p *RegisteredAggregationProof) UnmarshalJSON(b []byte) error {
+	name, num, err := unmarshalProofJSON(b)
+	if err != nil {
+		return err
+	}
+
+	out := RegisteredAggregationProof(num)
+	if name != "" {
+		out, err = RegisteredAggregationProofFromString(name)
+	} else {
+		err = out.Validate()
+	}
+	if err != nil {
+		return err
+	}
+
+	*p = out
+	return nil
+}
+
+func (p RegisteredUpdateProof) String() string {
+	if name, ok := updateProofNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("RegisteredUpdateProof(%d)", int64(p))
+}
+
+// Validate returns an error if p isn't a known update proof.
+func (p RegisteredUpdateProof) Validate() error {
+	if _, ok := updateProofNames[p]; !ok {
+		return fmt.Errorf("%w: unknown update proof %d", ErrInvalidInput, int64(p))
+	}
+	return nil
+}
+
+// RegisteredUpdateProofFromString returns the update proof with the given
+// name.
+func RegisteredUpdateProofFromString(s string) (RegisteredUpdateProof, error) {
+	for p, name := range updateProofNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown update proof %q", ErrInvalidInput, s)
+}
+
+// MarshalJSON encodes the proof as its name.
+func (p RegisteredUpdateProof) MarshalJSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON decodes a proof name or number.
+func (p *RegisteredUpdateProof) UnmarshalJSON(b []byte) error {
+	name, num, err := unmarshalProofJSON(b)
+	if err != nil {
+		return err
+	}
+
+	out := RegisteredUpdateProof(num)
+	if name != "" {
+		out, err = RegisteredUpdateProofFromString(name)
+	} else {
+		err = out.Validate()
+	}
+	if err != nil {
+		return err
+	}
+
+	*p = out
+	return nil
+}
+
+// unmarshalProofJSON decodes a JSON string or integer.
+func unmarshalProofJSON(b []byte) (string, int64, error) {
+	if len(b) > 0 && b[0] == '"' {
+		var name string
+		if err := json.Unmarshal(b, &name); err != nil {
+			return "", 0, err
+		}
+		if name == "" {
+			return "", 0, fmt.Errorf("%w: empty proof name", ErrInvalidInput)
+		}
+		return name, 0, nil
+	}
+
+	num, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: registered proof %s", ErrInvalidInput, b)
+	}
+	return "", num, nil
+}
@@ -0,0 +1,209 @@
+package cgo
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/filecoin-ffi/internal/proofparams"
+)
+
+// The parameters of the registered proofs, by sector size, are in proofparams
+// so that the mock shares them.
+var sealProofInfos = map[RegisteredSealProof]*proofparams.SectorSizeInfo{
+	RegisteredSealProofStackedDrg2KiBV1:    &proofparams.Info2KiB,
+	RegisteredSealProofStackedDrg8MiBV1:    &proofparams.Info8MiB,
+	RegisteredSealProofStackedDrg512MiBV1:  &proofparams.Info512MiB,
+	RegisteredSealProofStackedDrg32GiBV1:   &proofparams.Info32GiB,
+	RegisteredSealProofStackedDrg64GiBV1:   &proofparams.Info64GiB,
+	RegisteredSealProofStackedDrg2KiBV11:   &proofparams.Info2KiB,
+	RegisteredSealProofStackedDrg8MiBV11:   &proofparams.Info8MiB,
+	RegisteredSealProofStackedDrg512MiBV11: &proofparams.Info512MiB,
+	RegisteredSealProofStackedDrg32GiBV11:  &proofparams.Info32GiB,
+	RegisteredSealProofStackedDrg64GiBV11:  &proofparams.Info64GiB,
+}
+
+var postProofInfos = map[RegisteredPoStProof]*proofparams.SectorSizeInfo{
+	RegisteredPoStProofStackedDrgWinning2KiBV1:   &proofparams.Info2KiB,
+	RegisteredPoStProofStackedDrgWinning8MiBV1:   &proofparams.Info8MiB,
+	RegisteredPoStProofStackedDrgWinning512MiBV1: &proofparams.Info512MiB,
+	RegisteredPoStProofStackedDrgWinning32GiBV1:  &proofparams.Info32GiB,
+	RegisteredPoStProofStackedDrgWinning64GiBV1:  &proofparams.Info64GiB,
+	RegisteredPoStProofStackedDrgWindow2KiBV1:    &proofparams.Info2KiB,
+	RegisteredPoStProofStackedDrgWindow8MiBV1:    &proofparams.Info8MiB,
+	RegisteredPoStProofStackedDrgWindow512MiBV1:  &proofparams.Info512MiB,
+	RegisteredPoStProofStackedDrgWindow32GiBV1:   &proofparams.Info32GiB,
+	RegisteredPoStProofStackedDrgWindow64GiBV1:   &proofparams.Info64GiB,
+}
+
+var updateProofInfos = map[RegisteredUpdateProof]*proofparams.SectorSizeInfo{
+	RegisteredUpdateProofStackedDrg2KiBV1:   &proofparams.Info2KiB,
+	RegisteredUpdateProofStackedDrg8MiBV1:   &proofparams.Info8MiB,
+	RegisteredUpdateProofStackedDrg512MiBV1: &proofparams.Info512MiB,
+	RegisteredUpdateProofStackedDrg32GiBV1:  &proofparams.Info32GiB,
+	RegisteredUpdateProofStackedDrg64GiBV1:  &proofparams.Info64GiB,
+}
+
+func (p RegisteredSealProof) info() (*proofparams.SectorSizeInfo, error) {
+	if info, ok := sealProofInfos[p]; ok {
+		return info, nil
+	}
+	return nil, fmt.Errorf("%w: unknown seal proof %d", ErrInvalidInput, int64(p))
+}
+
+// SectorSize returns the size of the sectors sealed with p, in bytes.
+func (p RegisteredSealProof) SectorSize() (uint64, error) {
+	info, err := p.info()
+	if err != nil {
+		return 0, err
+	}
+	return info.SectorSize, nil
+}
+
+// Partitions returns the number of partitions of a seal proof.
+func (p RegisteredSealProof) Partitions() (uint64, error) {
+	info, err := p.info()
+	if err != nil {
+		return 0, err
+	}
+	return info.PoRepPartitions, nil
+}
+
+// SealProofSize returns the size of a seal proof, in bytes.
+func (p RegisteredSealProof) SealProofSize() (uint64, error) {
+	partitions, err := p.Partitions()
+	if err != nil {
+		return 0, err
+	}
+	return partitions * proofparams.SnarkProofSize, nil
+}
+
+// ChallengeCount returns the minimum number of challenges of a seal proof,
+// over all its partitions.
+func (p RegisteredSealProof) ChallengeCount() (uint64, error) {
+	info, err := p.info()
+	if err != nil {
+		return 0, err
+	}
+	return info.PoRepChallenges, nil
+}
+
+// WinningPoStProof returns the winning PoSt proof of the sectors sealed with
+// p.
+func (p RegisteredSealProof) WinningPoStProof() (RegisteredPoStProof, error) {
+	return p.postProof(true)
+}
+
+// WindowPoStProof returns the window PoSt proof of the sectors sealed with p.
+func (p RegisteredSealProof) WindowPoStProof() (RegisteredPoStProof, error) {
+	return p.postProof(false)
+}
+
+func (p RegisteredSealProof) postProof(winning bool) (RegisteredPoStProof, error) {
+	info, err := p.info()
+	if err != nil {
+		return 0, err
+	}
+
+	for pp, ppInfo := range postProofInfos {
+		if ppInfo == info && pp.IsWinning() == winning {
+			return pp, nil
+		}
+	}
+	return 0, fmt.Errorf("no PoSt proof for seal proof %s", p)
+}
+
+// UpdateProof returns the update proof of the sectors sealed with p.
+func (p RegisteredSealProof) UpdateProof() (RegisteredUpdateProof, error) {
+	info, err := p.info()
+	if err != nil {
+		return 0, err
+	}
+
+	for up, upInfo := range updateProofInfos {
+		if upInfo == info {
+			return up, nil
+		}
+	}
+	return 0, fmt.Errorf("no update proof for seal proof %s", p)
+}
+
+func (p RegisteredPoStProof) info() (*proofparams.SectorSizeInfo, error) {
+	if info, ok := postProofInfos[p]; ok {
+		return info, nil
+	}
+	return nil, fmt.Errorf("%w: unknown PoSt proof %d", ErrInvalidInput, int64(p))
+}
+
+// IsWinning returns whether p is a winning PoSt proof.
+func (p RegisteredPoStProof) IsWinning() bool {
+	switch p {
+	case RegisteredPoStProofStackedDrgWinning2KiBV1,
+		RegisteredPoStProofStackedDrgWinning8MiBV1,
+		RegisteredPoStProofStackedDrgWinning512MiBV1,
+		RegisteredPoStProofStackedDrgWinning32GiBV1,
+		RegisteredPoStProofStackedDrgWinning64GiBV1:
+		return true
+	default:
+		return false
+	}
+}
+
+// SectorSize returns the size of the sectors proven with p, in bytes.
+func (p RegisteredPoStProof) SectorSize() (uint64, error) {
+	info, err := p.info()
+	if err != nil {
+		return 0, err
+	}
+	return info.SectorSize, nil
+}
+
+// WindowPoStPartitionSectors returns the number of sectors proven by a
+// partition proof: the window PoSt partition size, or the number of sectors
+// challenged by a winning PoSt.
+func (p RegisteredPoStProof) WindowPoStPartitionSectors() (uint64, error) {
+	info, err := p.info()
+	if err != nil {
+		return 0, err
+	}
+	if p.IsWinning() {
+		return proofparams.WinningPoStSectorCount, nil
+	}
+	return info.WindowPoStSectors, nil
+}
+
+// ChallengeCount returns the number of challenges per proven sector.
+func (p RegisteredPoStProof) ChallengeCount() (uint64, error) {
+	if _, err := p.info(); err != nil {
+		return 0, err
+	}
+	if p.IsWinning() {
+		return proofparams.WinningPoStChallengeCount, nil
+	}
+	return proofparams.WindowPoStChallengeCount, nil
+}
+
+// ProofSize returns the size of the proof of one partition, in bytes.
+func (p RegisteredPoStProof) ProofSize() (uint64, error) {
+	if _, err := p.info(); err != nil {
+		return 0, err
+	}
+	return proofparams.SnarkProofSize, nil
+}
+
+// SectorSize returns the size of the sectors updated with p, in bytes.
+func (p RegisteredUpdateProof) SectorSize() (uint64, error) {
+	info, ok := updateProofInfos[p]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown update proof %d", ErrInvalidInput, int64(p))
+	}
+	return info.SectorSize, nil
+}
+
+// Partitions returns the number of partitions of an update proof, which is
+// also the number of vanilla proofs it is generated from.
+func (p RegisteredUpdateProof) Partitions() (uint64, error) {
+	info, ok := updateProofInfos[p]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown update proof %d", ErrInvalidInput, int64(p))
+	}
+	return info.UpdatePartitions, nil
+}
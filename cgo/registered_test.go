@@ -0,0 +1,101 @@
+package cgo
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisteredProofNames(t *testing.T) {
+	sp := RegisteredSealProofStackedDrg32GiBV11
+	assert.Equal(t, "StackedDrg32GiBV1_1", sp.String())
+	parsed, err := RegisteredSealProofFromString("StackedDrg32GiBV1_1")
+	require.NoError(t, err)
+	assert.Equal(t, sp, parsed)
+
+	pp, err := RegisteredPoStProofFromString("StackedDrgWindow2KiBV1")
+	require.NoError(t, err)
+	assert.Equal(t, RegisteredPoStProofStackedDrgWindow2KiBV1, pp)
+
+	_, err = RegisteredAggregationProofFromString("SnarkPackV2")
+	assert.Error(t, err)
+
+	assert.Error(t, RegisteredSealProof(-1).Validate())
+	assert.Equal(t, "RegisteredSealProof(-1)", RegisteredSealProof(-1).String())
+	assert.NoError(t, RegisteredUpdateProofStackedDrg8MiBV1.Validate())
+}
+
+func TestRegisteredProofJSON(t *testing.T) {
+	b, err := json.Marshal(RegisteredPoStProofStackedDrgWinning512MiBV1)
+	require.NoError(t, err)
+	assert.Equal(t, `"StackedDrgWinning512MiBV1"`, string(b))
+
+	var pp RegisteredPoStProof
+	require.NoError(t, json.Unmarshal(b, &pp))
+	assert.Equal(t, RegisteredPoStProofStackedDrgWinning512MiBV1, pp)
+
+	// numbers are accepted too
+	var ap RegisteredAggregationProof
+	require.NoError(t, json.Unmarshal([]byte(strconv.Itoa(int(RegisteredAggregationProofSnarkPackV1))), &ap))
+	assert.Equal(t, RegisteredAggregationProofSnarkPackV1, ap)
+
+	var sp RegisteredSealProof
+	assert.Error(t, json.Unmarshal([]byte(`"StackedDrg1KiBV1"`), &sp))
+	assert.Error(t, json.Unmarshal([]byte(`-1`), &sp))
+
+	_, err = json.Marshal(RegisteredSealProof(-1))
+	assert.Error(t, err)
+}
+
+func TestRegisteredProofInfo(t *testing.T) {
+	size, err := RegisteredSealProofStackedDrg32GiBV11.SectorSize()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(32<<30), size)
+
+	proofSize, err := RegisteredSealProofStackedDrg32GiBV11.SealProofSize()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1920), proofSize)
+
+	window, err := RegisteredSealProofStackedDrg64GiBV11.WindowPoStProof()
+	require.NoError(t, err)
+	assert.Equal(t, RegisteredPoStProofStackedDrgWindow64GiBV1, window)
+
+	winning, err := RegisteredSealProofStackedDrg2KiBV1.WinningPoStProof()
+	require.NoError(t, err)
+	assert.Equal(t, RegisteredPoStProofStackedDrgWinning2KiBV1, winning)
+
+	sectors, err := window.WindowPoStPartitionSectors()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2300), sectors)
+
+	challenges, err := winning.ChallengeCount()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(66), challenges)
+
+	update, err := RegisteredSealProofStackedDrg32GiBV1.UpdateProof()
+	require.NoError(t, err)
+	partitions, err := update.Partitions()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), partitions)
+
+	_, err = RegisteredSealProof(-1).SectorSize()
+	assert.Error(t, err)
+	_, err = RegisteredPoStProof(-1).ChallengeCount()
+	assert.Error(t, err)
+}
+
+func TestUpdateProofPartitions(t *testing.T) {
+	for up := range updateProofInfos {
+		t.Run(up.String(), func(t *testing.T) {
+			partitions, err := up.Partitions()
+			require.NoError(t, err)
+
+			native, err := GetNumPartitionForUpdate(up)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(native), partitions)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package cgo
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors of the native calls, to be tested with errors.Is.
+var (
+	// ErrInvalidInput is returned for arguments rejected before calling into
+	// the native library, such as unknown proof types.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrCallerError matches the errors the native library attributes to its
+	// caller.
+	ErrCallerError = errors.New("caller error")
+	// ErrReceiverError matches the errors the native library attributes to
+	// itself or its environment.
+	ErrReceiverError = errors.New("receiver error")
+	// ErrNativePanic matches the errors of calls that panicked in the native
+	// library.
+	ErrNativePanic = errors.New("native panic")
+)
+
+const (
+	// panicPrefix starts the error messages of the native calls that
+	// panicked.
+	panicPrefix = "Rust panic"
+	// backtraceMarker separates the message of a panic from its backtrace.
+	backtraceMarker = "\nstack backtrace:\n"
+)
+
+// StatusError is the error of a failed native call.
+type StatusError struct {
+	Status  FCPResponseStatus
+	Message string
+
+	// Backtrace is the native backtrace of the panic the call failed with,
+	// if any.
+	Backtrace string
+}
+
+func newStatusError(status FCPResponseStatus, msg string) *StatusError {
+	err := &StatusError{Status: status, Message: msg}
+	if i := strings.Index(msg, backtraceMarker); i >= 0 && strings.HasPrefix(msg, panicPrefix) {
+		err.Message = msg[:i]
+		err.Backtrace = msg[i+len(backtraceMarker):]
+	}
+	return err
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// Is reports whether the error matches one of the sentinel errors.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrCallerError:
+		return e.Status == FCPResponseStatusCallerError
+	case ErrReceiverError:
+		return e.Status == FCPResponseStatusReceiverError
+	case ErrNativePanic:
+		return strings.HasPrefix(e.Message, panicPrefix)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,34 @@
+package cgo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusError(t *testing.T) {
+	var err error = &StatusError{Status: FCPResponseStatusCallerError, Message: "bad proof"}
+	assert.True(t, errors.Is(err, ErrCallerError))
+	assert.False(t, errors.Is(err, ErrReceiverError))
+	assert.Equal(t, "bad proof", err.Error())
+
+	err = fmt.Errorf("sealing: %w", &StatusError{Status: FCPResponseStatusUnclassifiedError, Message: "Rust panic: oops"})
+	assert.True(t, errors.Is(err, ErrNativePanic))
+	assert.False(t, errors.Is(err, ErrCallerError))
+
+	assert.True(t, errors.Is(RegisteredSealProof(-1).Validate(), ErrInvalidInput))
+}
+
+func TestStatusErrorBacktrace(t *testing.T) {
+	err := newStatusError(FCPResponseStatusUnclassifiedError, "Rust panic: injected panic in clear_cache\nstack backtrace:\n   0: filcrypto::util::types::init_panic_hook\n")
+	assert.Equal(t, "Rust panic: injected panic in clear_cache", err.Error())
+	assert.Equal(t, "   0: filcrypto::util::types::init_panic_hook\n", err.Backtrace)
+	assert.True(t, errors.Is(err, ErrNativePanic))
+
+	// only panics carry a backtrace
+	err = newStatusError(FCPResponseStatusUnclassifiedError, "bad input\nstack backtrace:\n")
+	assert.Equal(t, "bad input\nstack backtrace:\n", err.Message)
+	assert.Empty(t, err.Backtrace)
+}
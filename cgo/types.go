@@ -8,15 +8,18 @@ package cgo
 */
 import "C"
 import (
+	"sync/atomic"
 	"unsafe"
 )
 
 type FCPResponseStatus = int64
 
-type RegisteredSealProof = C.RegisteredSealProof_t
-type RegisteredAggregationProof = C.RegisteredAggregationProof_t
-type RegisteredPoStProof = C.RegisteredPoStProof_t
-type RegisteredUpdateProof = C.RegisteredUpdateProof_t
+type RegisteredSealProof C.RegisteredSealProof_t
+type RegisteredAggregationProof C.RegisteredAggregationProof_t
+type RegisteredPoStProof C.RegisteredPoStProof_t
+type RegisteredUpdateProof C.RegisteredUpdateProof_t
+
+type BLSPointStatus C.BLSPointStatus_t
 
 type FvmRegisteredVersion = C.FvmRegisteredVersion_t
 
@@ -140,7 +143,7 @@ func (ptr *resultBool) destroy() {
 }
 
 func (ptr *PoStProof) registeredProof() RegisteredPoStProof {
-	return ptr.registered_proof
+	return RegisteredPoStProof(ptr.registered_proof)
 }
 
 func (ptr *PoStProof) destroy() {
@@ -194,6 +197,13 @@ func (ptr ByteArray32) slice() []byte {
 	return unsafe.Slice((*byte)(unsafe.Pointer(&ptr.idx[0])), 32)
 }
 
+func (ptr SliceRefUint8) slice() []byte {
+	if ptr.ptr == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(ptr.ptr)), int(ptr.len))
+}
+
 func (ptr *ByteArray32) copy() []byte {
 	res := make([]byte, 32)
 	if ptr != nil {
@@ -386,7 +396,7 @@ func (ptr SliceBoxedPoStProof) copy() []PoStProofGo {
 
 func (proof PoStProof) copy() PoStProofGo {
 	return PoStProofGo{
-		RegisteredProof: proof.registered_proof,
+		RegisteredProof: RegisteredPoStProof(proof.registered_proof),
 		Proof:           proof.proof.copy(),
 	}
 }
@@ -547,7 +557,7 @@ func (ptr *resultGenerateFallbackSectorChallenges) destroy() {
 
 func (proof PartitionSnarkProof) copy() PartitionSnarkProofGo {
 	return PartitionSnarkProofGo{
-		RegisteredProof: proof.registered_proof,
+		RegisteredProof: RegisteredPoStProof(proof.registered_proof),
 		Proof:           proof.proof.copy(),
 	}
 }
@@ -582,13 +592,25 @@ func (ptr *resultPoStProof) destroy() {
 	}
 }
 
+// Destroy frees the slice. It is safe to call on nil, and more than once:
+// the pointer is swapped out first, so only one call frees it.
 func (ptr *SliceBoxedUint8) Destroy() {
-	if ptr.ptr != nil {
-		C.destroy_boxed_slice(*ptr)
-		ptr.ptr = nil
+	if ptr == nil {
+		return
+	}
+
+	p := atomic.SwapPointer((*unsafe.Pointer)(unsafe.Pointer(&ptr.ptr)), nil)
+	if p == nil {
+		return
 	}
+	if ptr.len > 0 {
+		trackFree(p)
+	}
+	C.destroy_boxed_slice(SliceBoxedUint8{ptr: (*C.uint8_t)(p), len: ptr.len})
 }
 
+// Destroy frees the paths of the replica. It is safe to call on nil, and more
+// than once.
 func (ptr *PrivateReplicaInfo) Destroy() {
 	if ptr != nil {
 		ptr.cache_dir_path.Destroy()
@@ -597,6 +619,12 @@ func (ptr *PrivateReplicaInfo) Destroy() {
 	}
 }
 
+// Destroy frees the proof bytes. It is safe to call on nil, and more than
+// once.
+func (ptr *PoStProof) Destroy() {
+	ptr.destroy()
+}
+
 func (ptr *resultFvmMachineExecuteResponse) statusCode() FCPResponseStatus {
 	return FCPResponseStatus(ptr.status_code)
 }
@@ -627,11 +655,18 @@ func (ptr *resultFvmMachine) destroy() {
 	}
 }
 
+// Destroy drops the machine. It is safe to call on nil, and more than once:
+// only the first call on a machine returned by CreateFvmMachine drops it.
 func (ptr *FvmMachine) Destroy() {
-	if ptr != nil {
-		C.drop_fvm_machine(ptr)
-		ptr = nil
+	if ptr == nil {
+		return
 	}
+	if _, ok := liveFvmMachines.LoadAndDelete(unsafe.Pointer(ptr)); !ok {
+		return
+	}
+
+	trackFree(unsafe.Pointer(ptr))
+	C.drop_fvm_machine(ptr)
 }
 
 func (r FvmMachineExecuteResponse) copy() FvmMachineExecuteResponseGo {
@@ -8,9 +8,11 @@ package cgo
 */
 import "C"
 
-func InitLogFd(fd int32) error {
+func InitLogFd(fd int32) (err error) {
+	defer beginCall(Call{Name: "init_log_fd"}).end(&err, nil)
+
 	resp := C.init_log_fd(C.int32_t(fd))
-	defer resp.destroy()
+	defer track(resp).destroy()
 
 	if err := CheckErr(resp); err != nil {
 		return err
@@ -18,3 +20,9 @@ func InitLogFd(fd int32) error {
 
 	return nil
 }
+
+func InitThreadPool() uint {
+	defer beginCall(Call{Name: "init_thread_pool"}).end(nil, nil)
+
+	return uint(C.init_thread_pool())
+}
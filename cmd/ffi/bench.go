@@ -0,0 +1,82 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+func runBench(args []string) error {
+	fs := newFlags("bench", "")
+	size := fs.String("proof", "2KiB", "sector size")
+	count := fs.Int("n", 1, "number of sectors to seal")
+	dir := fs.String("dir", "", "directory of the sector files, a temporary one removed afterwards when empty")
+	_ = fs.Parse(args)
+
+	proofType, err := parseSealProof(*size)
+	if err != nil {
+		return err
+	}
+	if *count < 1 {
+		return xerrors.Errorf("at least one sector is needed, got %d", *count)
+	}
+
+	root := *dir
+	if root == "" {
+		if root, err = ioutil.TempDir("", "ffi-bench"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(root) // nolint: errcheck
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "sector\tadd piece\tpre-commit 1\tpre-commit 2\tcommit 1\tcommit 2\tverify\t")
+
+	recs := make([]*sectorRecord, 0, *count)
+	for i := 0; i < *count; i++ {
+		sid := abi.SectorID{Miner: 1000, Number: abi.SectorNumber(i + 1)}
+		rec, times, err := sealSector(proofType, sid, filepath.Join(root, fmt.Sprintf("s-%d", sid.Number)))
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		ok, err := ffi.VerifySeal(rec.SealVerifyInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return xerrors.Errorf("sector %d: invalid seal proof", sid.Number)
+		}
+		verify := time.Since(start)
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t\n", sid.Number,
+			round(times.AddPiece), round(times.PreCommit1), round(times.PreCommit2),
+			round(times.Commit1), round(times.Commit2), round(verify))
+		recs = append(recs, rec)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, elapsed, err := windowPoSt(recs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nwindow PoSt over %d sectors: %s\n", len(recs), round(elapsed))
+	return nil
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,43 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package main
+
+import (
+	"fmt"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+)
+
+func runGPUList(args []string) error {
+	fs := newFlags("gpu-list", "")
+	_ = fs.Parse(args)
+
+	backend, err := ffi.ProcessGPUBackend()
+	if err != nil {
+		return err
+	}
+	backends, err := ffi.GPUBackends()
+	if err != nil {
+		return err
+	}
+	if len(backends) == 0 {
+		fmt.Println("built without GPU support")
+		return nil
+	}
+	fmt.Printf("backends: %v, using %s\n", backends, backend)
+
+	devices, err := ffi.GetGPUDevices()
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		fmt.Println("no GPU found")
+		return nil
+	}
+
+	for i, d := range devices {
+		fmt.Printf("%d: %s\n", i, d)
+	}
+	return nil
+}
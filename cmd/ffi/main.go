@@ -0,0 +1,81 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Command ffi exercises the native proofs library from a shell: it seals
+// sectors, verifies their proofs, generates window PoSts over them and
+// benchmarks the sealing phases. fetch-params downloads the proof parameters
+// they need.
+//
+// Sealed sectors are described by JSON records, written by seal and read by
+// verify-seal and window-post:
+//
+//	ffi seal -proof 2KiB -dir /tmp/s1 > s1.json
+//	ffi verify-seal s1.json
+//	ffi window-post s1.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"seal":         {"seal and prove a sector of random data, printing its record", runSeal},
+	"verify-seal":  {"verify the seal proofs of sector records", runVerifySeal},
+	"window-post":  {"generate and verify a window PoSt over sector records", runWindowPoSt},
+	"bench":        {"time the sealing phases and a window PoSt", runBench},
+	"gpu-list":     {"list the GPUs seen by the native library", runGPUList},
+	"fetch-params": {"download the proof parameters of sector sizes", runFetchParams},
+	"version":      {"print the version and features of the native library", runVersion},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ffi: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "ffi %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ffi <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].usage)
+	}
+}
+
+// newFlags returns the flag set of a command.
+func newFlags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("ffi "+name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: ffi %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
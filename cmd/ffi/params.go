@@ -0,0 +1,84 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/filecoin-ffi/paramfetch"
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+func runFetchParams(args []string) error {
+	fs := newFlags("fetch-params", "")
+	sizes := fs.String("proof", "2KiB", "comma separated sector sizes of the proofs to fetch: 2KiB, 8MiB, 512MiB, 32GiB or 64GiB")
+	dir := fs.String("dir", "", "directory of the parameters, $"+paramfetch.DirEnv+" or "+paramfetch.DefaultDir+" by default")
+	gateway := fs.String("gateway", "", "IPFS gateway, $"+paramfetch.GatewayEnv+" or "+paramfetch.DefaultGateway+" by default")
+	concurrency := fs.Int("concurrency", 4, "number of files downloaded at a time")
+	srs := fs.Bool("srs", false, "also fetch the SRS files used to aggregate seal proofs")
+	trust := fs.Bool("trust", false, "don't check the digests of the files already fetched")
+	_ = fs.Parse(args)
+
+	var sectorSizes []abi.SectorSize
+	for _, name := range strings.Split(*sizes, ",") {
+		proof, err := parseSealProof(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		size, err := proof.SectorSize()
+		if err != nil {
+			return err
+		}
+		sectorSizes = append(sectorSizes, size)
+	}
+
+	params, err := paramfetch.Parse(ffi.ParametersJSON)
+	if err != nil {
+		return err
+	}
+	files := paramfetch.Select(params, sectorSizes...)
+	if *srs {
+		srsFiles, err := paramfetch.Parse(ffi.SRSInnerProductJSON)
+		if err != nil {
+			return err
+		}
+		for name, f := range srsFiles {
+			files[name] = f
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Progress is printed every 10% of a file, and once it is in place.
+	var lk sync.Mutex
+	printed := make(map[string]int64)
+	return paramfetch.Fetch(ctx, files, paramfetch.Options{
+		Dir:         *dir,
+		Gateway:     *gateway,
+		Concurrency: *concurrency,
+		Trust:       *trust,
+		Progress: func(p paramfetch.Progress) {
+			lk.Lock()
+			defer lk.Unlock()
+
+			switch {
+			case p.Finished:
+				fmt.Printf("%s: done\n", p.Name)
+			case p.Total > 0:
+				pct := p.Done * 100 / p.Total / 10 * 10
+				if last, ok := printed[p.Name]; !ok || pct > last {
+					printed[p.Name] = pct
+					fmt.Printf("%s: %d%% of %d bytes\n", p.Name, pct, p.Total)
+				}
+			}
+		},
+	})
+}
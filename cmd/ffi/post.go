@@ -0,0 +1,107 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+func runWindowPoSt(args []string) error {
+	fs := newFlags("window-post", "record.json...")
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	recs := make([]*sectorRecord, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		rec, err := readRecord(path)
+		if err != nil {
+			return err
+		}
+		recs = append(recs, rec)
+	}
+
+	proofs, elapsed, err := windowPoSt(recs)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range proofs {
+		fmt.Printf("proof %d: %d bytes\n", p.PoStProof, len(p.ProofBytes))
+	}
+	fmt.Printf("%d sectors proven and verified in %s\n", len(recs), elapsed.Round(time.Millisecond))
+	return nil
+}
+
+// windowPoSt generates a window PoSt over sectors of a single miner, and
+// verifies it. It returns the time the generation took.
+func windowPoSt(recs []*sectorRecord) ([]proof5.PoStProof, time.Duration, error) {
+	miner := recs[0].SectorID.Miner
+
+	private := make([]ffi.PrivateSectorInfo, 0, len(recs))
+	public := make([]proof5.SectorInfo, 0, len(recs))
+	for _, rec := range recs {
+		if rec.SectorID.Miner != miner {
+			return nil, 0, xerrors.Errorf("sectors of miners %d and %d can't be proven together", miner, rec.SectorID.Miner)
+		}
+
+		postProof, err := rec.SealProof.RegisteredWindowPoStProof()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		info := proof5.SectorInfo{
+			SealProof:    rec.SealProof,
+			SectorNumber: rec.SectorID.Number,
+			SealedCID:    rec.SealedCID,
+		}
+		private = append(private, ffi.PrivateSectorInfo{
+			SectorInfo:       info,
+			CacheDirPath:     rec.CacheDirPath,
+			PoStProofType:    postProof,
+			SealedSectorPath: rec.SealedSectorPath,
+		})
+		public = append(public, info)
+	}
+
+	random, err := randomness()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	proofs, faulty, err := ffi.GenerateWindowPoSt(miner, ffi.NewSortedPrivateSectorInfo(private...), random)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("generating window PoSt: %w", err)
+	}
+	elapsed := time.Since(start)
+	if len(faulty) > 0 {
+		return nil, 0, xerrors.Errorf("faulty sectors: %v", faulty)
+	}
+
+	ok, err := ffi.VerifyWindowPoSt(proof5.WindowPoStVerifyInfo{
+		Randomness:        abi.PoStRandomness(random),
+		Proofs:            proofs,
+		ChallengedSectors: public,
+		Prover:            miner,
+	})
+	if err != nil {
+		return nil, 0, xerrors.Errorf("verifying window PoSt: %w", err)
+	}
+	if !ok {
+		return nil, 0, xerrors.New("window PoSt is invalid")
+	}
+
+	return proofs, elapsed, nil
+}
@@ -0,0 +1,251 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// sealProofs are the seal proof types by sector size name.
+var sealProofs = map[string]abi.RegisteredSealProof{
+	"2KiB":   abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+	"8MiB":   abi.RegisteredSealProof_StackedDrg8MiBV1_1,
+	"512MiB": abi.RegisteredSealProof_StackedDrg512MiBV1_1,
+	"32GiB":  abi.RegisteredSealProof_StackedDrg32GiBV1_1,
+	"64GiB":  abi.RegisteredSealProof_StackedDrg64GiBV1_1,
+}
+
+func parseSealProof(name string) (abi.RegisteredSealProof, error) {
+	for size, p := range sealProofs {
+		if strings.EqualFold(size, name) {
+			return p, nil
+		}
+	}
+	return 0, xerrors.Errorf("unknown sector size %q, expected one of 2KiB, 8MiB, 512MiB, 32GiB or 64GiB", name)
+}
+
+// sectorRecord describes a sealed sector: the inputs verifying its seal proof,
+// and the location of its files.
+type sectorRecord struct {
+	proof5.SealVerifyInfo
+
+	CacheDirPath     string
+	SealedSectorPath string
+}
+
+func readRecord(path string) (*sectorRecord, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rec sectorRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, xerrors.Errorf("decoding %s: %w", path, err)
+	}
+	return &rec, nil
+}
+
+// phaseTimes are the durations of the sealing phases.
+type phaseTimes struct {
+	AddPiece, PreCommit1, PreCommit2, Commit1, Commit2 time.Duration
+}
+
+func runSeal(args []string) error {
+	fs := newFlags("seal", "")
+	size := fs.String("proof", "2KiB", "sector size")
+	miner := fs.Uint64("miner", 1000, "miner actor ID")
+	number := fs.Uint64("sector", 1, "sector number")
+	dir := fs.String("dir", "", "directory of the sector files, created if needed (required)")
+	_ = fs.Parse(args)
+
+	if *dir == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	proofType, err := parseSealProof(*size)
+	if err != nil {
+		return err
+	}
+
+	sid := abi.SectorID{Miner: abi.ActorID(*miner), Number: abi.SectorNumber(*number)}
+	rec, _, err := sealSector(proofType, sid, *dir)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rec)
+}
+
+// sealSector seals a sector of random data in dir, and proves it.
+func sealSector(proofType abi.RegisteredSealProof, sid abi.SectorID, dir string) (*sectorRecord, phaseTimes, error) {
+	var times phaseTimes
+
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return nil, times, err
+	}
+
+	cacheDir := filepath.Join(dir, "cache")
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, times, err
+	}
+	stagedPath := filepath.Join(dir, "staged")
+	sealedPath := filepath.Join(dir, "sealed")
+
+	ticket, err := randomness()
+	if err != nil {
+		return nil, times, err
+	}
+	seed, err := randomness()
+	if err != nil {
+		return nil, times, err
+	}
+
+	start := time.Now()
+	piece, err := addRandomPiece(proofType, abi.PaddedPieceSize(ssize).Unpadded(), dir, stagedPath)
+	if err != nil {
+		return nil, times, xerrors.Errorf("adding piece: %w", err)
+	}
+	times.AddPiece = time.Since(start)
+	pieces := []abi.PieceInfo{piece}
+
+	// the sealed file must exist
+	if err := ioutil.WriteFile(sealedPath, nil, 0644); err != nil {
+		return nil, times, err
+	}
+
+	start = time.Now()
+	pc1o, err := ffi.SealPreCommitPhase1(proofType, cacheDir, stagedPath, sealedPath, sid.Number, sid.Miner, ticket, pieces)
+	if err != nil {
+		return nil, times, xerrors.Errorf("pre-commit 1: %w", err)
+	}
+	times.PreCommit1 = time.Since(start)
+
+	start = time.Now()
+	sealedCID, unsealedCID, err := ffi.SealPreCommitPhase2(pc1o, cacheDir, sealedPath)
+	if err != nil {
+		return nil, times, xerrors.Errorf("pre-commit 2: %w", err)
+	}
+	times.PreCommit2 = time.Since(start)
+
+	start = time.Now()
+	c1o, err := ffi.SealCommitPhase1(proofType, sealedCID, unsealedCID, cacheDir, sealedPath, sid.Number, sid.Miner, ticket, seed, pieces)
+	if err != nil {
+		return nil, times, xerrors.Errorf("commit 1: %w", err)
+	}
+	times.Commit1 = time.Since(start)
+
+	start = time.Now()
+	proof, err := ffi.SealCommitPhase2(c1o, sid.Number, sid.Miner)
+	if err != nil {
+		return nil, times, xerrors.Errorf("commit 2: %w", err)
+	}
+	times.Commit2 = time.Since(start)
+
+	return &sectorRecord{
+		SealVerifyInfo: proof5.SealVerifyInfo{
+			SealProof:             proofType,
+			SectorID:              sid,
+			DealIDs:               []abi.DealID{},
+			Randomness:            ticket,
+			InteractiveRandomness: seed,
+			Proof:                 proof,
+			SealedCID:             sealedCID,
+			UnsealedCID:           unsealedCID,
+		},
+		CacheDirPath:     cacheDir,
+		SealedSectorPath: sealedPath,
+	}, times, nil
+}
+
+// addRandomPiece fills the staged sector file with a piece of random data.
+func addRandomPiece(proofType abi.RegisteredSealProof, size abi.UnpaddedPieceSize, dir, stagedPath string) (abi.PieceInfo, error) {
+	pieceFile, err := ioutil.TempFile(dir, "piece")
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+	defer os.Remove(pieceFile.Name()) // nolint: errcheck
+	defer pieceFile.Close()           // nolint: errcheck
+
+	if _, err := io.CopyN(pieceFile, rand.Reader, int64(size)); err != nil {
+		return abi.PieceInfo{}, err
+	}
+	if _, err := pieceFile.Seek(0, io.SeekStart); err != nil {
+		return abi.PieceInfo{}, err
+	}
+
+	staged, err := os.Create(stagedPath)
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+	defer staged.Close() // nolint: errcheck
+
+	_, pieceCID, err := ffi.WriteWithoutAlignment(proofType, pieceFile, size, staged)
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+
+	return abi.PieceInfo{Size: size.Padded(), PieceCID: pieceCID}, staged.Close()
+}
+
+// randomness returns 32 random bytes, which are a valid field element.
+func randomness() ([]byte, error) {
+	out := make([]byte, 32)
+	if _, err := rand.Read(out); err != nil {
+		return nil, err
+	}
+	out[31] &= 0x3f
+	return out, nil
+}
+
+func runVerifySeal(args []string) error {
+	fs := newFlags("verify-seal", "record.json...")
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	invalid := 0
+	for _, path := range fs.Args() {
+		rec, err := readRecord(path)
+		if err != nil {
+			return err
+		}
+
+		ok, err := ffi.VerifySeal(rec.SealVerifyInfo)
+		if err != nil {
+			return xerrors.Errorf("verifying %s: %w", path, err)
+		}
+
+		status := "valid"
+		if !ok {
+			status = "INVALID"
+			invalid++
+		}
+		fmt.Printf("%s: sector %d of miner %d: %s\n", path, rec.SectorID.Number, rec.SectorID.Miner, status)
+	}
+
+	if invalid > 0 {
+		return xerrors.Errorf("%d invalid seal proofs", invalid)
+	}
+	return nil
+}
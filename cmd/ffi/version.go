@@ -0,0 +1,30 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+)
+
+func runVersion(args []string) error {
+	fs := newFlags("version", "")
+	_ = fs.Parse(args)
+
+	info, err := ffi.GetLibraryBuildInfo()
+	if err != nil {
+		return err
+	}
+
+	revision := info.GitRevision
+	if revision == "" {
+		revision = "unknown"
+	}
+	fmt.Printf("ffi version %d\n", ffi.Version)
+	fmt.Printf("library %s, revision %s\n", info.Version, revision)
+	fmt.Printf("features: %s\n", strings.Join(info.Features, ", "))
+	return nil
+}
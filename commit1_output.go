@@ -0,0 +1,101 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// Commit1OutputVersion is the version of the envelope written by
+// EncodeCommit1Output.
+const Commit1OutputVersion = 1
+
+// commit1OutputMagic prefixes every encoded Commit1 output.
+var commit1OutputMagic = [4]byte{'F', 'C', '1', 'O'}
+
+// commit1HeaderSize is the size of the envelope before the output itself.
+const commit1HeaderSize = 4 + 1 + 8 + 8 + 8 + 8
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCommit1Checksum is returned when decoding a Commit1 output damaged in
+// transit or storage.
+var ErrCommit1Checksum = xerrors.New("commit 1 output checksum mismatch")
+
+// Commit1Output is the output of SealCommitPhase1 together with the sector it
+// was computed for, as shipped to a remote Commit2 prover.
+//
+// The envelope is a flat big-endian layout:
+//
+//	magic "FC1O" | version u8 | seal proof i64 | miner u64 |
+//	sector number u64 | output len u64 | output | CRC-32C u32
+//
+// The checksum (Castagnoli) covers everything before it. The output itself is
+// opaque: its encoding is owned by the native library.
+type Commit1Output struct {
+	Version      uint8
+	ProofType    abi.RegisteredSealProof
+	SectorID     abi.SectorID
+	Phase1Output []byte
+}
+
+// EncodeCommit1Output wraps the output of SealCommitPhase1 in the current
+// version of the envelope.
+func EncodeCommit1Output(proofType abi.RegisteredSealProof, sectorID abi.SectorID, phase1Output []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(commit1HeaderSize + len(phase1Output) + 4)
+
+	buf.Write(commit1OutputMagic[:])
+	buf.WriteByte(Commit1OutputVersion)
+	writeUint64(&buf, uint64(proofType))
+	writeUint64(&buf, uint64(sectorID.Miner))
+	writeUint64(&buf, uint64(sectorID.Number))
+	writeUint64(&buf, uint64(len(phase1Output)))
+	buf.Write(phase1Output)
+	writeUint32(&buf, crc32.Checksum(buf.Bytes(), castagnoli))
+
+	return buf.Bytes()
+}
+
+// DecodeCommit1Output parses and checks an encoded Commit1 output. The
+// returned output aliases data.
+func DecodeCommit1Output(data []byte) (Commit1Output, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], commit1OutputMagic[:]) {
+		return Commit1Output{}, xerrors.New("not an encoded commit 1 output")
+	}
+	if len(data) < 5 {
+		return Commit1Output{}, xerrors.New("truncated commit 1 output")
+	}
+	if data[4] != Commit1OutputVersion {
+		return Commit1Output{}, xerrors.Errorf("unsupported commit 1 output version %d", data[4])
+	}
+	if len(data) < commit1HeaderSize+4 {
+		return Commit1Output{}, xerrors.New("truncated commit 1 output")
+	}
+
+	size := binary.BigEndian.Uint64(data[29:commit1HeaderSize])
+	if size != uint64(len(data)-commit1HeaderSize-4) {
+		return Commit1Output{}, xerrors.Errorf("commit 1 output of %d bytes in an envelope of %d bytes", size, len(data))
+	}
+
+	end := len(data) - 4
+	if crc32.Checksum(data[:end], castagnoli) != binary.BigEndian.Uint32(data[end:]) {
+		return Commit1Output{}, ErrCommit1Checksum
+	}
+
+	return Commit1Output{
+		Version:   data[4],
+		ProofType: abi.RegisteredSealProof(binary.BigEndian.Uint64(data[5:13])),
+		SectorID: abi.SectorID{
+			Miner:  abi.ActorID(binary.BigEndian.Uint64(data[13:21])),
+			Number: abi.SectorNumber(binary.BigEndian.Uint64(data[21:29])),
+		},
+		Phase1Output: data[commit1HeaderSize:end],
+	}, nil
+}
@@ -0,0 +1,43 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommit1OutputRoundTrip(t *testing.T) {
+	sid := abi.SectorID{Miner: 1000, Number: 42}
+	data := EncodeCommit1Output(abi.RegisteredSealProof_StackedDrg32GiBV1_1, sid, []byte("c1o"))
+
+	c1, err := DecodeCommit1Output(data)
+	require.NoError(t, err)
+	assert.Equal(t, Commit1Output{
+		Version:      Commit1OutputVersion,
+		ProofType:    abi.RegisteredSealProof_StackedDrg32GiBV1_1,
+		SectorID:     sid,
+		Phase1Output: []byte("c1o"),
+	}, c1)
+
+	damaged := append([]byte(nil), data...)
+	damaged[commit1HeaderSize] ^= 1
+	_, err = DecodeCommit1Output(damaged)
+	assert.True(t, errors.Is(err, ErrCommit1Checksum), err)
+
+	_, err = DecodeCommit1Output(data[:len(data)-1])
+	assert.Error(t, err)
+
+	future := append([]byte(nil), data...)
+	future[4] = Commit1OutputVersion + 1
+	_, err = DecodeCommit1Output(future)
+	assert.EqualError(t, err, "unsupported commit 1 output version 2")
+
+	_, err = DecodeCommit1Output([]byte("c1o"))
+	assert.Error(t, err)
+}
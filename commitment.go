@@ -0,0 +1,133 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"os"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"golang.org/x/xerrors"
+)
+
+// CommitmentEncoding selects how a commitment is serialized.
+type CommitmentEncoding int
+
+const (
+	// CommitmentEncodingCID returns the binary CID of the commitment.
+	CommitmentEncodingCID CommitmentEncoding = iota
+	// CommitmentEncodingMultihash returns the multihash of the commitment,
+	// without the CID version and codec prefix.
+	CommitmentEncodingMultihash
+	// CommitmentEncodingRaw returns the bare 32 byte commitment.
+	CommitmentEncodingRaw
+)
+
+// CommitmentFormat describes the serialization of a commitment. The zero
+// value produces the binary form of the CID returned by the corresponding
+// CID producing call.
+type CommitmentFormat struct {
+	Encoding CommitmentEncoding
+
+	// MultihashCode, when non-zero, replaces the Filecoin multihash code
+	// (sha2-256-trunc254-padded or poseidon-bls12_381-a2-fc1) the digest is
+	// wrapped in. It is ignored for CommitmentEncodingRaw.
+	MultihashCode uint64
+
+	// Codec, when non-zero, replaces the Filecoin codec
+	// (fil-commitment-unsealed or fil-commitment-sealed) of the CID. It is
+	// only used by CommitmentEncodingCID.
+	Codec uint64
+}
+
+// Encode serializes a commitment CID according to the format.
+func (f CommitmentFormat) Encode(c cid.Cid) ([]byte, error) {
+	codec, hash, commX, err := commcid.CIDToCommitment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Encoding == CommitmentEncodingRaw {
+		return commX, nil
+	}
+
+	mhCode := uint64(hash)
+	if f.MultihashCode != 0 {
+		mhCode = f.MultihashCode
+	}
+
+	mh, err := multihash.Encode(commX, mhCode)
+	if err != nil {
+		return nil, xerrors.Errorf("encoding commitment multihash: %w", err)
+	}
+
+	switch f.Encoding {
+	case CommitmentEncodingMultihash:
+		return mh, nil
+	case CommitmentEncodingCID:
+		cidCodec := uint64(codec)
+		if f.Codec != 0 {
+			cidCodec = f.Codec
+		}
+		return cid.NewCidV1(cidCodec, mh).Bytes(), nil
+	default:
+		return nil, xerrors.Errorf("unknown commitment encoding %d", f.Encoding)
+	}
+}
+
+// GeneratePieceCommitment produces a piece commitment for the provided data
+// stored at a given path, serialized according to format.
+func GeneratePieceCommitment(proofType abi.RegisteredSealProof, piecePath string, pieceSize abi.UnpaddedPieceSize, format CommitmentFormat) ([]byte, error) {
+	pieceFile, err := os.Open(piecePath)
+	if err != nil {
+		return nil, err
+	}
+	defer pieceFile.Close() // nolint:errcheck
+
+	commP, err := GeneratePieceCIDFromFile(proofType, pieceFile, pieceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Encode(commP)
+}
+
+// GenerateDataCommitment produces a commitment for the sector containing the
+// provided pieces, serialized according to format.
+func GenerateDataCommitment(proofType abi.RegisteredSealProof, pieces []abi.PieceInfo, format CommitmentFormat) ([]byte, error) {
+	commD, err := GenerateUnsealedCID(proofType, pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Encode(commD)
+}
+
+// SealPreCommitPhase2Commitments is SealPreCommitPhase2 returning the
+// commitments serialized according to format.
+func SealPreCommitPhase2Commitments(
+	phase1Output []byte,
+	cacheDirPath string,
+	sealedSectorPath string,
+	format CommitmentFormat,
+) (commR []byte, commD []byte, err error) {
+	sealedCID, unsealedCID, err := SealPreCommitPhase2(phase1Output, cacheDirPath, sealedSectorPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commR, err = format.Encode(sealedCID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commD, err = format.Encode(unsealedCID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return commR, commD, nil
+}
@@ -0,0 +1,45 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitmentFormat(t *testing.T) {
+	commX := bytes.Repeat([]byte{7}, 32)
+	commP, err := commcid.PieceCommitmentV1ToCID(commX)
+	require.NoError(t, err)
+
+	out, err := CommitmentFormat{}.Encode(commP)
+	require.NoError(t, err)
+	assert.Equal(t, commP.Bytes(), out)
+
+	out, err = CommitmentFormat{Encoding: CommitmentEncodingRaw}.Encode(commP)
+	require.NoError(t, err)
+	assert.Equal(t, commX, out)
+
+	out, err = CommitmentFormat{Encoding: CommitmentEncodingMultihash}.Encode(commP)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(commP.Hash()), out)
+
+	out, err = CommitmentFormat{Encoding: CommitmentEncodingCID, MultihashCode: multihash.SHA2_256, Codec: cid.Raw}.Encode(commP)
+	require.NoError(t, err)
+	c, err := cid.Cast(out)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(cid.Raw), c.Prefix().Codec)
+	assert.Equal(t, uint64(multihash.SHA2_256), c.Prefix().MhType)
+
+	mh, err := multihash.Sum([]byte("not a commitment"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	_, err = CommitmentFormat{}.Encode(cid.NewCidV1(cid.Raw, mh))
+	assert.Error(t, err)
+}
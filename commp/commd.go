@@ -0,0 +1,114 @@
+package commp
+
+import (
+	"math/bits"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// ZeroPieceCommitment returns the raw CommP of a piece of zeros of the given
+// padded size.
+func ZeroPieceCommitment(size abi.PaddedPieceSize) ([32]byte, error) {
+	if err := size.Validate(); err != nil {
+		return [32]byte{}, err
+	}
+	if size > MaxPieceSize {
+		return [32]byte{}, xerrors.Errorf("piece size %d exceeds the maximum piece size of %d bytes", size, MaxPieceSize)
+	}
+
+	return zeroComms[layer(uint64(size))], nil
+}
+
+// ZeroPieceCID returns the piece CID of a piece of zeros of the given padded
+// size.
+func ZeroPieceCID(size abi.PaddedPieceSize) (cid.Cid, error) {
+	commP, err := ZeroPieceCommitment(size)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return commcid.PieceCommitmentV1ToCID(commP[:])
+}
+
+// GenerateUnsealedCID returns the CommD of a sector of the given proof type
+// holding pieces, in order, as GenerateDataCommitment does: each piece is
+// aligned on its size by zero pieces and the end of the sector is filled with
+// zeros.
+func GenerateUnsealedCID(proofType abi.RegisteredSealProof, pieces []abi.PieceInfo) (cid.Cid, error) {
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return cid.Undef, err
+	}
+	sectorSize := uint64(ssize)
+
+	var s pieceStack
+	for i, p := range pieces {
+		if err := p.Size.Validate(); err != nil {
+			return cid.Undef, xerrors.Errorf("piece %d: %w", i, err)
+		}
+
+		commP, err := commcid.CIDToPieceCommitmentV1(p.PieceCID)
+		if err != nil {
+			return cid.Undef, xerrors.Errorf("piece %d: %w", i, err)
+		}
+
+		size := uint64(p.Size)
+		for s.offset%size != 0 {
+			s.pad()
+		}
+
+		var n node
+		copy(n[:], commP)
+		s.push(size, n)
+
+		if s.offset > sectorSize {
+			return cid.Undef, xerrors.Errorf("pieces take %d bytes, more than the %d bytes of the sector", s.offset, sectorSize)
+		}
+	}
+
+	if s.offset == 0 {
+		s.push(sectorSize, zeroComms[layer(sectorSize)])
+	}
+	for s.offset < sectorSize {
+		s.pad()
+	}
+
+	return commcid.DataCommitmentV1ToCID(s.nodes[0][:])
+}
+
+// pieceStack merges the commitments of consecutive aligned pieces. Its sizes
+// are decreasing powers of two, so that offset, their sum, has the smallest
+// of them as lowest set bit.
+type pieceStack struct {
+	offset uint64
+	sizes  []uint64
+	nodes  []node
+}
+
+func (s *pieceStack) push(size uint64, n node) {
+	s.offset += size
+
+	for len(s.sizes) > 0 && s.sizes[len(s.sizes)-1] == size {
+		last := len(s.sizes) - 1
+		n = hashPair(&s.nodes[last], &n)
+		size *= 2
+		s.sizes = s.sizes[:last]
+		s.nodes = s.nodes[:last]
+	}
+
+	s.sizes = append(s.sizes, size)
+	s.nodes = append(s.nodes, n)
+}
+
+// pad pushes the zero piece completing the smallest piece of the stack.
+func (s *pieceStack) pad() {
+	size := s.offset & -s.offset
+	s.push(size, zeroComms[layer(size)])
+}
+
+// layer returns the height of the tree of a piece of the given padded size.
+func layer(size uint64) int {
+	return bits.TrailingZeros64(size / nodeSize)
+}
@@ -0,0 +1,76 @@
+package commp
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pieceOf(t *testing.T, data []byte) abi.PieceInfo {
+	var w Writer
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	info, err := w.Sum()
+	require.NoError(t, err)
+	return info
+}
+
+func TestZeroPieceCommitment(t *testing.T) {
+	for _, size := range []abi.PaddedPieceSize{128, 2048, 1 << 20} {
+		commP, err := ZeroPieceCommitment(size)
+		require.NoError(t, err)
+		assert.Equal(t, referenceCommP(nil, uint64(size)), commP, "size %d", size)
+	}
+
+	_, err := ZeroPieceCommitment(1000)
+	assert.Error(t, err)
+	_, err = ZeroPieceCommitment(128 << 30)
+	assert.Error(t, err)
+}
+
+func TestGenerateUnsealedCID(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	small := make([]byte, 100)
+	rng.Read(small)
+	large := make([]byte, 1000)
+	rng.Read(large)
+
+	// the small piece is followed by 128+256+512 bytes of zero pieces to align
+	// the large one, and the sector ends with 1024 bytes of zeros
+	var sector bytes.Buffer
+	sector.Write(small)
+	sector.Write(make([]byte, 1016-100))
+	sector.Write(large)
+	sector.Write(make([]byte, 2032-sector.Len()))
+
+	commD, err := GenerateUnsealedCID(abi.RegisteredSealProof_StackedDrg2KiBV1_1, []abi.PieceInfo{
+		pieceOf(t, small),
+		pieceOf(t, large),
+	})
+	require.NoError(t, err)
+
+	raw, err := commcid.CIDToDataCommitmentV1(commD)
+	require.NoError(t, err)
+	expected := referenceCommP(sector.Bytes(), 2048)
+	assert.Equal(t, expected[:], raw)
+}
+
+func TestGenerateUnsealedCIDEmpty(t *testing.T) {
+	commD, err := GenerateUnsealedCID(abi.RegisteredSealProof_StackedDrg2KiBV1, nil)
+	require.NoError(t, err)
+
+	zero, err := ZeroPieceCID(2048)
+	require.NoError(t, err)
+	assert.Equal(t, zero, commD)
+}
+
+func TestGenerateUnsealedCIDOverflow(t *testing.T) {
+	piece := pieceOf(t, make([]byte, 1500))
+	_, err := GenerateUnsealedCID(abi.RegisteredSealProof_StackedDrg2KiBV1, []abi.PieceInfo{piece, piece})
+	assert.Error(t, err)
+}
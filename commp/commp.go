@@ -0,0 +1,188 @@
+// Package commp computes piece commitments (CommP) in Go, without the native
+// library: data is fr32 padded and hashed into a binary SHA-254 merkle tree,
+// SHA-254 being SHA-256 with the two most significant bits of the digest
+// cleared so that every node is a valid field element.
+//
+// The result is the one of GeneratePieceCommitment for the data zero-filled
+// to the next valid unpadded piece size. GenerateUnsealedCID similarly
+// computes the CommD of a sector from the CommPs of its pieces.
+package commp
+
+import (
+	"crypto/sha256"
+	"math/bits"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/fr32"
+)
+
+const (
+	// MaxPieceSize is the largest padded piece a CommP is computed for, the
+	// size of the largest sectors.
+	MaxPieceSize = 64 << 30
+
+	nodeSize = 32
+
+	// maxLayers is the height of the tree of a MaxPieceSize piece.
+	maxLayers = 31
+
+	// bufferBlocks is the number of unpadded blocks padded and hashed at once.
+	bufferBlocks = 1024
+)
+
+type node = [nodeSize]byte
+
+// zeroComms are the roots of the trees of zeros of each height.
+var zeroComms = func() [maxLayers + 1]node {
+	var out [maxLayers + 1]node
+	for i := 1; i <= maxLayers; i++ {
+		out[i] = hashPair(&out[i-1], &out[i-1])
+	}
+	return out
+}()
+
+func hashPair(left, right *node) node {
+	h := sha256.New()
+	_, _ = h.Write(left[:])
+	_, _ = h.Write(right[:])
+
+	var out node
+	h.Sum(out[:0])
+	out[nodeSize-1] &= 0x3f
+	return out
+}
+
+// tree holds the left nodes of each layer still waiting for their right
+// sibling. Layer i nodes are the roots of subtrees of 2^i leaves.
+type tree struct {
+	nodes [maxLayers + 1]node
+	full  [maxLayers + 1]bool
+}
+
+func (t *tree) add(layer int, n node) {
+	for t.full[layer] {
+		n = hashPair(&t.nodes[layer], &n)
+		t.full[layer] = false
+		layer++
+	}
+	t.nodes[layer] = n
+	t.full[layer] = true
+}
+
+// addPadded hashes padded data, a multiple of 128 bytes, into the tree.
+func (t *tree) addPadded(padded []byte) {
+	for i := 0; i < len(padded); i += 2 * nodeSize {
+		var left, right node
+		copy(left[:], padded[i:])
+		copy(right[:], padded[i+nodeSize:])
+		t.add(1, hashPair(&left, &right))
+	}
+}
+
+// root completes the tree to the given height with zero subtrees and returns
+// its root.
+func (t *tree) root(height int) node {
+	for i := 1; i < height; i++ {
+		if t.full[i] {
+			t.full[i] = false
+			t.add(i+1, hashPair(&t.nodes[i], &zeroComms[i]))
+		}
+	}
+	return t.nodes[height]
+}
+
+// Writer computes the CommP of the data written to it. The zero value is
+// ready to use.
+type Writer struct {
+	size     uint64
+	buffered int
+	buf      []byte
+	padded   []byte
+	tree     tree
+}
+
+// Write adds p to the piece. It only fails when the piece would exceed
+// MaxPieceSize.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.size+uint64(len(p)) > uint64(abi.PaddedPieceSize(MaxPieceSize).Unpadded()) {
+		return 0, xerrors.Errorf("piece exceeds the maximum piece size of %d bytes", MaxPieceSize)
+	}
+
+	if w.buf == nil {
+		w.buf = make([]byte, bufferBlocks*fr32.UnpaddedBlockSize)
+		w.padded = make([]byte, bufferBlocks*fr32.PaddedBlockSize)
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		c := copy(w.buf[w.buffered:], p)
+		w.buffered += c
+		p = p[c:]
+
+		if w.buffered == len(w.buf) {
+			if err := fr32.Pad(w.buf, w.padded); err != nil {
+				return 0, err
+			}
+			w.tree.addPadded(w.padded)
+			w.buffered = 0
+		}
+	}
+
+	w.size += uint64(n)
+	return n, nil
+}
+
+// Size returns the number of bytes written.
+func (w *Writer) Size() uint64 {
+	return w.size
+}
+
+// Digest returns the raw CommP of the data written so far and the padded size
+// of the piece. More data can be written afterwards.
+func (w *Writer) Digest() ([32]byte, abi.PaddedPieceSize, error) {
+	if w.size == 0 {
+		return [32]byte{}, 0, xerrors.New("no data written")
+	}
+
+	blocks := (w.size + fr32.UnpaddedBlockSize - 1) / fr32.UnpaddedBlockSize
+	pieceSize := uint64(1) << bits.Len64(blocks*fr32.PaddedBlockSize-1)
+
+	t := w.tree
+	if w.buffered > 0 {
+		tail := (w.buffered + fr32.UnpaddedBlockSize - 1) / fr32.UnpaddedBlockSize
+		in := make([]byte, tail*fr32.UnpaddedBlockSize)
+		copy(in, w.buf[:w.buffered])
+		out := make([]byte, tail*fr32.PaddedBlockSize)
+		if err := fr32.Pad(in, out); err != nil {
+			return [32]byte{}, 0, err
+		}
+		t.addPadded(out)
+	}
+
+	return t.root(layer(pieceSize)), abi.PaddedPieceSize(pieceSize), nil
+}
+
+// Sum returns the piece CID and padded size of the data written so far.
+func (w *Writer) Sum() (abi.PieceInfo, error) {
+	commP, size, err := w.Digest()
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+
+	c, err := commcid.PieceCommitmentV1ToCID(commP[:])
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+
+	return abi.PieceInfo{Size: size, PieceCID: c}, nil
+}
+
+// Reset discards the data written, keeping the buffers.
+func (w *Writer) Reset() {
+	w.size = 0
+	w.buffered = 0
+	w.tree = tree{}
+}
@@ -0,0 +1,112 @@
+package commp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/fr32"
+)
+
+// referenceCommP zero-fills data to an unpadded piece of the given padded
+// size, pads it and hashes the whole tree.
+func referenceCommP(data []byte, pieceSize uint64) [32]byte {
+	in := make([]byte, abi.PaddedPieceSize(pieceSize).Unpadded())
+	copy(in, data)
+	padded := make([]byte, pieceSize)
+	if err := fr32.Pad(in, padded); err != nil {
+		panic(err)
+	}
+
+	layer := make([][32]byte, len(padded)/32)
+	for i := range layer {
+		copy(layer[i][:], padded[i*32:])
+	}
+	for len(layer) > 1 {
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+			next[i][31] &= 0x3f
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+func TestZeroPiece(t *testing.T) {
+	var w Writer
+	_, err := w.Write(make([]byte, 127))
+	require.NoError(t, err)
+
+	commP, size, err := w.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, abi.PaddedPieceSize(128), size)
+	assert.Equal(t, "3731bb99ac689f66eef5973e4a94da188f4ddcae580724fc6f3fd60dfd488333", hex.EncodeToString(commP[:]))
+}
+
+func TestWriter(t *testing.T) {
+	for _, tc := range []struct {
+		size      int
+		pieceSize uint64
+	}{
+		{1, 128},
+		{127, 128},
+		{128, 256},
+		{1000, 1024},
+		{2032, 2048},
+		{2033, 4096},
+		{bufferBlocks * 127, 128 << 10},
+		{bufferBlocks*127*3 + 5, 512 << 10},
+	} {
+		data := make([]byte, tc.size)
+		rand.New(rand.NewSource(int64(tc.size))).Read(data)
+
+		var w Writer
+		// odd write sizes exercise the buffering
+		_, err := io.CopyBuffer(&w, bytes.NewReader(data), make([]byte, 1000))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(tc.size), w.Size())
+
+		commP, size, err := w.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, abi.PaddedPieceSize(tc.pieceSize), size, "%d bytes", tc.size)
+		assert.Equal(t, referenceCommP(data, tc.pieceSize), commP, "%d bytes", tc.size)
+	}
+}
+
+func TestSum(t *testing.T) {
+	data := make([]byte, 3000)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	var w Writer
+	_, err := w.Write(data[:1500])
+	require.NoError(t, err)
+
+	// Digest doesn't finalize the writer
+	_, _, err = w.Digest()
+	require.NoError(t, err)
+
+	_, err = w.Write(data[1500:])
+	require.NoError(t, err)
+
+	info, err := w.Sum()
+	require.NoError(t, err)
+	assert.Equal(t, abi.PaddedPieceSize(4096), info.Size)
+
+	commP, err := commcid.CIDToDataCommitmentV1(info.PieceCID)
+	require.NoError(t, err)
+	expected := referenceCommP(data, 4096)
+	assert.Equal(t, expected[:], commP)
+
+	w.Reset()
+	_, _, err = w.Digest()
+	assert.Error(t, err)
+}
@@ -0,0 +1,322 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrAlreadyInitialized is returned by Init after its first successful call.
+var ErrAlreadyInitialized = xerrors.New("proofs library already initialized")
+
+// Config holds the settings of the native proofs library which are otherwise
+// read from the FIL_PROOFS_* environment variables.
+//
+// The native library reads its settings once, on its first proofs call: Init
+// must be called before it, usually at the start of main.
+type Config struct {
+	// ParameterCache is the directory of the Groth16 parameters and
+	// verifying keys (FIL_PROOFS_PARAMETER_CACHE).
+	ParameterCache string
+
+	// ParentCache is the directory of the SDR parents caches, which are
+	// generated on the first PC1 of each sector size
+	// (FIL_PROOFS_PARENT_CACHE).
+	ParentCache string
+
+	// UseGPUColumnBuilder builds the column hashes of tree_c on the GPU in
+	// PC2 (FIL_PROOFS_USE_GPU_COLUMN_BUILDER).
+	UseGPUColumnBuilder bool
+
+	// UseGPUTreeBuilder builds tree_r_last on the GPU in PC2
+	// (FIL_PROOFS_USE_GPU_TREE_BUILDER).
+	UseGPUTreeBuilder bool
+
+	// UseMulticoreSDR computes the SDR layers of PC1 on several cores
+	// sharing a cache, instead of one (FIL_PROOFS_USE_MULTICORE_SDR).
+	UseMulticoreSDR bool
+
+	// MulticoreSDRProducers is the number of cores loading parents for the
+	// multicore SDR (FIL_PROOFS_MULTICORE_SDR_PRODUCERS).
+	MulticoreSDRProducers int
+
+	// MaximizeCaching keeps the previous SDR layer in memory when building
+	// the next one, rather than reading it back from disk
+	// (FIL_PROOFS_MAXIMIZE_CACHING).
+	MaximizeCaching bool
+
+	// SDRParentsCacheSize is the number of nodes of the parents cache mapped
+	// in memory at a time (FIL_PROOFS_SDR_PARENTS_CACHE_SIZE).
+	SDRParentsCacheSize uint64
+
+	// MaxGPUColumnBatchSize is the number of columns hashed by a GPU batch
+	// (FIL_PROOFS_MAX_GPU_COLUMN_BATCH_SIZE).
+	MaxGPUColumnBatchSize uint64
+
+	// ColumnWriteBatchSize is the number of column hashes written to the
+	// tree_c files at a time (FIL_PROOFS_COLUMN_WRITE_BATCH_SIZE).
+	ColumnWriteBatchSize uint64
+
+	// GPUBackend is the GPU backend of the process, when the native library
+	// is built with both (EC_GPU_FRAMEWORK). Empty selects CUDA when
+	// available.
+	GPUBackend GPUBackend
+}
+
+// The defaults of the native library, used for the unset variables.
+var defaultConfig = Config{
+	ParameterCache:        "/var/tmp/filecoin-proof-parameters/",
+	ParentCache:           "/var/tmp/filecoin-parents",
+	MulticoreSDRProducers: 3,
+	MaximizeCaching:       true,
+	SDRParentsCacheSize:   2048,
+	MaxGPUColumnBatchSize: 400000,
+	ColumnWriteBatchSize:  262144,
+}
+
+// configVar is an environment variable of the native library, with the field
+// of Config it sets.
+type configVar struct {
+	name  string
+	get   func(*Config) string
+	parse func(*Config, string) error
+}
+
+var configVars = []configVar{
+	{"FIL_PROOFS_PARAMETER_CACHE", func(c *Config) string { return c.ParameterCache }, parseString(func(c *Config) *string { return &c.ParameterCache })},
+	{"FIL_PROOFS_PARENT_CACHE", func(c *Config) string { return c.ParentCache }, parseString(func(c *Config) *string { return &c.ParentCache })},
+	{"FIL_PROOFS_USE_GPU_COLUMN_BUILDER", func(c *Config) string { return formatBool(c.UseGPUColumnBuilder) }, parseBool(func(c *Config) *bool { return &c.UseGPUColumnBuilder })},
+	{"FIL_PROOFS_USE_GPU_TREE_BUILDER", func(c *Config) string { return formatBool(c.UseGPUTreeBuilder) }, parseBool(func(c *Config) *bool { return &c.UseGPUTreeBuilder })},
+	{"FIL_PROOFS_USE_MULTICORE_SDR", func(c *Config) string { return formatBool(c.UseMulticoreSDR) }, parseBool(func(c *Config) *bool { return &c.UseMulticoreSDR })},
+	{"FIL_PROOFS_MULTICORE_SDR_PRODUCERS", func(c *Config) string { return strconv.Itoa(c.MulticoreSDRProducers) }, parseInt(func(c *Config) *int { return &c.MulticoreSDRProducers })},
+	{"FIL_PROOFS_MAXIMIZE_CACHING", func(c *Config) string { return formatBool(c.MaximizeCaching) }, parseBool(func(c *Config) *bool { return &c.MaximizeCaching })},
+	{"FIL_PROOFS_SDR_PARENTS_CACHE_SIZE", func(c *Config) string { return formatUint(c.SDRParentsCacheSize) }, parseUint(func(c *Config) *uint64 { return &c.SDRParentsCacheSize })},
+	{"FIL_PROOFS_MAX_GPU_COLUMN_BATCH_SIZE", func(c *Config) string { return formatUint(c.MaxGPUColumnBatchSize) }, parseUint(func(c *Config) *uint64 { return &c.MaxGPUColumnBatchSize })},
+	{"FIL_PROOFS_COLUMN_WRITE_BATCH_SIZE", func(c *Config) string { return formatUint(c.ColumnWriteBatchSize) }, parseUint(func(c *Config) *uint64 { return &c.ColumnWriteBatchSize })},
+	{GPUBackendEnv, func(c *Config) string { return string(c.GPUBackend) }, func(c *Config, s string) error {
+		c.GPUBackend = GPUBackend(s)
+		return nil
+	}},
+}
+
+// Option changes a setting of Config.
+type Option func(*Config)
+
+// WithParameterCache sets Config.ParameterCache.
+func WithParameterCache(dir string) Option {
+	return func(c *Config) { c.ParameterCache = dir }
+}
+
+// WithParentCache sets Config.ParentCache.
+func WithParentCache(dir string) Option {
+	return func(c *Config) { c.ParentCache = dir }
+}
+
+// WithGPU sets Config.UseGPUColumnBuilder and Config.UseGPUTreeBuilder.
+func WithGPU(columnBuilder, treeBuilder bool) Option {
+	return func(c *Config) {
+		c.UseGPUColumnBuilder = columnBuilder
+		c.UseGPUTreeBuilder = treeBuilder
+	}
+}
+
+// WithMulticoreSDR sets Config.UseMulticoreSDR, and Config.MulticoreSDRProducers
+// when producers is not zero.
+func WithMulticoreSDR(enabled bool, producers int) Option {
+	return func(c *Config) {
+		c.UseMulticoreSDR = enabled
+		if producers != 0 {
+			c.MulticoreSDRProducers = producers
+		}
+	}
+}
+
+// WithMaximizeCaching sets Config.MaximizeCaching.
+func WithMaximizeCaching(enabled bool) Option {
+	return func(c *Config) { c.MaximizeCaching = enabled }
+}
+
+// WithSDRParentsCacheSize sets Config.SDRParentsCacheSize.
+func WithSDRParentsCacheSize(nodes uint64) Option {
+	return func(c *Config) { c.SDRParentsCacheSize = nodes }
+}
+
+// WithColumnBatchSizes sets Config.MaxGPUColumnBatchSize and
+// Config.ColumnWriteBatchSize, leaving those given as zero unchanged.
+func WithColumnBatchSizes(gpuBatch, writeBatch uint64) Option {
+	return func(c *Config) {
+		if gpuBatch != 0 {
+			c.MaxGPUColumnBatchSize = gpuBatch
+		}
+		if writeBatch != 0 {
+			c.ColumnWriteBatchSize = writeBatch
+		}
+	}
+}
+
+// WithProcessGPUBackend sets Config.GPUBackend.
+func WithProcessGPUBackend(b GPUBackend) Option {
+	return func(c *Config) { c.GPUBackend = b }
+}
+
+// WithConfig replaces all the settings by those of cfg.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) { *c = cfg }
+}
+
+var initOnce struct {
+	sync.Mutex
+	done bool
+}
+
+// Init applies the options to the current settings, validates them, and sets
+// the environment variables the native library reads them from. The settings
+// not changed by the options keep the values of the environment, or the
+// defaults of the native library.
+//
+// Init can only be called once, before the first proofs call: the native
+// library doesn't read its settings again.
+func Init(opts ...Option) error {
+	initOnce.Lock()
+	defer initOnce.Unlock()
+
+	if initOnce.done {
+		return ErrAlreadyInitialized
+	}
+
+	cfg, err := CurrentConfig()
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.GPUBackend != "" {
+		backends, err := GPUBackends()
+		if err != nil {
+			return err
+		}
+		if err := checkSupported(backends, cfg.GPUBackend); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range configVars {
+		var err error
+		if value := v.get(&cfg); value != "" {
+			err = os.Setenv(v.name, value)
+		} else {
+			err = os.Unsetenv(v.name)
+		}
+		if err != nil {
+			return xerrors.Errorf("setting %s: %w", v.name, err)
+		}
+	}
+
+	initOnce.done = true
+	return nil
+}
+
+// CurrentConfig returns the settings the native library uses: those of the
+// environment, or its defaults.
+func CurrentConfig() (Config, error) {
+	cfg := defaultConfig
+	for _, v := range configVars {
+		value, ok := os.LookupEnv(v.name)
+		if !ok {
+			continue
+		}
+		if err := v.parse(&cfg, value); err != nil {
+			return Config{}, xerrors.Errorf("invalid %s: %w", v.name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// Validate checks the settings.
+func (c Config) Validate() error {
+	if !filepath.IsAbs(c.ParameterCache) {
+		return xerrors.Errorf("invalid parameter cache directory %q: must be an absolute path", c.ParameterCache)
+	}
+	if !filepath.IsAbs(c.ParentCache) {
+		return xerrors.Errorf("invalid parent cache directory %q: must be an absolute path", c.ParentCache)
+	}
+	if c.MulticoreSDRProducers < 1 {
+		return xerrors.Errorf("invalid number of multicore SDR producers %d", c.MulticoreSDRProducers)
+	}
+	if c.SDRParentsCacheSize == 0 {
+		return xerrors.New("invalid SDR parents cache size 0")
+	}
+	if c.MaxGPUColumnBatchSize == 0 || c.ColumnWriteBatchSize == 0 {
+		return xerrors.New("invalid column batch size 0")
+	}
+	if c.GPUBackend != "" && !c.GPUBackend.valid() {
+		return xerrors.Errorf("invalid GPU backend %q", c.GPUBackend)
+	}
+	return nil
+}
+
+func parseString(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		*field(c) = s
+		return nil
+	}
+}
+
+// parseBool accepts the booleans of the native library's configuration.
+func parseBool(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		switch strings.ToLower(s) {
+		case "1", "true", "yes", "on":
+			*field(c) = true
+		case "0", "false", "no", "off":
+			*field(c) = false
+		default:
+			return xerrors.Errorf("invalid boolean %q", s)
+		}
+		return nil
+	}
+}
+
+func parseInt(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*field(c) = v
+		return nil
+	}
+}
+
+func parseUint(field func(*Config) *uint64) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		*field(c) = v
+		return nil
+	}
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
@@ -0,0 +1,97 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+// clearConfigEnv unsets the configuration variables and allows calling Init
+// again for the duration of the test.
+func clearConfigEnv(t *testing.T) {
+	for _, v := range configVars {
+		t.Setenv(v.name, "")
+		require.NoError(t, os.Unsetenv(v.name))
+	}
+	t.Cleanup(func() {
+		initOnce.Lock()
+		initOnce.done = false
+		initOnce.Unlock()
+	})
+}
+
+func TestCurrentConfig(t *testing.T) {
+	clearConfigEnv(t)
+
+	cfg, err := CurrentConfig()
+	require.NoError(t, err)
+	assert.Equal(t, defaultConfig, cfg)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("FIL_PROOFS_PARAMETER_CACHE", "/srv/params")
+	t.Setenv("FIL_PROOFS_USE_GPU_TREE_BUILDER", "true")
+	t.Setenv("FIL_PROOFS_MULTICORE_SDR_PRODUCERS", "5")
+	t.Setenv("FIL_PROOFS_MAXIMIZE_CACHING", "0")
+	cfg, err = CurrentConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/params", cfg.ParameterCache)
+	assert.True(t, cfg.UseGPUTreeBuilder)
+	assert.False(t, cfg.UseGPUColumnBuilder)
+	assert.Equal(t, 5, cfg.MulticoreSDRProducers)
+	assert.False(t, cfg.MaximizeCaching)
+
+	t.Setenv("FIL_PROOFS_USE_MULTICORE_SDR", "maybe")
+	_, err = CurrentConfig()
+	assert.Error(t, err)
+}
+
+func TestInit(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("FIL_PROOFS_PARENT_CACHE", "/srv/parents")
+
+	require.NoError(t, Init(
+		WithParameterCache("/srv/params"),
+		WithGPU(true, true),
+		WithMulticoreSDR(true, 0),
+		WithSDRParentsCacheSize(4096),
+	))
+	assert.Equal(t, "/srv/params", os.Getenv("FIL_PROOFS_PARAMETER_CACHE"))
+	assert.Equal(t, "1", os.Getenv("FIL_PROOFS_USE_GPU_COLUMN_BUILDER"))
+	assert.Equal(t, "1", os.Getenv("FIL_PROOFS_USE_MULTICORE_SDR"))
+	assert.Equal(t, "4096", os.Getenv("FIL_PROOFS_SDR_PARENTS_CACHE_SIZE"))
+
+	cfg, err := CurrentConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/parents", cfg.ParentCache)
+	assert.Equal(t, defaultConfig.MulticoreSDRProducers, cfg.MulticoreSDRProducers)
+	assert.True(t, cfg.UseGPUTreeBuilder)
+
+	err = Init(WithGPU(false, false))
+	assert.True(t, xerrors.Is(err, ErrAlreadyInitialized), err)
+	assert.Equal(t, "1", os.Getenv("FIL_PROOFS_USE_GPU_COLUMN_BUILDER"))
+}
+
+func TestInitValidates(t *testing.T) {
+	clearConfigEnv(t)
+
+	for _, opt := range []Option{
+		WithParameterCache("relative/params"),
+		WithParentCache(""),
+		WithMulticoreSDR(true, -1),
+		WithSDRParentsCacheSize(0),
+		WithConfig(Config{}),
+	} {
+		assert.Error(t, Init(opt))
+	}
+
+	// Failed calls leave the environment unchanged.
+	_, ok := os.LookupEnv("FIL_PROOFS_PARAMETER_CACHE")
+	assert.False(t, ok)
+	assert.NoError(t, Init())
+}
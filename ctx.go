@@ -0,0 +1,409 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// The *Ctx variants below return as soon as their context is done, with
+// ctx.Err(). A native call can't be interrupted once started: it keeps
+// running on its own goroutine until it completes, after which its result is
+// freed and discarded. Callers bailing out of a sealing call must not reuse
+// the sector files until the abandoned call is done writing them; the file
+// descriptors passed to the unseal variants are duplicated, so the caller may
+// close its files right away. Calls waiting for the package Scheduler give up
+// waiting when their context is done.
+
+// runCtx runs fn on its own goroutine, and returns its error, or ctx.Err()
+// if ctx is done first. fn is not started when ctx is already done, or after
+// Shutdown.
+func runCtx(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkShutdown(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- withCallerCtx(ctx, fn)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dupFile returns a new *os.File for the descriptor of f, which stays valid
+// after f is closed.
+func dupFile(f *os.File) (*os.File, error) {
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		return nil, xerrors.Errorf("duplicating %s: %w", f.Name(), err)
+	}
+	return os.NewFile(uintptr(fd), f.Name()), nil
+}
+
+// SealPreCommitPhase1Ctx is SealPreCommitPhase1 honoring ctx, and the
+// binding of WithSDRBinding.
+func SealPreCommitPhase1Ctx(
+	ctx context.Context,
+	proofType abi.RegisteredSealProof,
+	cacheDirPath string,
+	stagedSectorPath string,
+	sealedSectorPath string,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	pieces []abi.PieceInfo,
+) ([]byte, error) {
+	var out []byte
+	binding, bound := SDRBindingFrom(ctx)
+	if err := runCtx(ctx, func() (err error) {
+		if bound {
+			if err := bindThread(binding); err != nil {
+				return err
+			}
+		}
+		out, err = SealPreCommitPhase1(proofType, cacheDirPath, stagedSectorPath, sealedSectorPath, sectorNum, minerID, ticket, pieces)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SealPreCommitPhase2Ctx is SealPreCommitPhase2 honoring ctx.
+func SealPreCommitPhase2Ctx(
+	ctx context.Context,
+	phase1Output []byte,
+	cacheDirPath string,
+	sealedSectorPath string,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	var commR, commD cid.Cid
+	if err := runCtx(ctx, func() (err error) {
+		commR, commD, err = sealPreCommitPhase2(ctx, phase1Output, cacheDirPath, sealedSectorPath)
+		return err
+	}); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	return commR, commD, nil
+}
+
+// SealCommitPhase1Ctx is SealCommitPhase1 honoring ctx.
+func SealCommitPhase1Ctx(
+	ctx context.Context,
+	proofType abi.RegisteredSealProof,
+	sealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	cacheDirPath string,
+	sealedSectorPath string,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	seed abi.InteractiveSealRandomness,
+	pieces []abi.PieceInfo,
+) ([]byte, error) {
+	var out []byte
+	if err := runCtx(ctx, func() (err error) {
+		out, err = SealCommitPhase1(proofType, sealedCID, unsealedCID, cacheDirPath, sealedSectorPath, sectorNum, minerID, ticket, seed, pieces)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SealCommitPhase2Ctx is SealCommitPhase2 honoring ctx.
+func SealCommitPhase2Ctx(
+	ctx context.Context,
+	phase1Output []byte,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+) ([]byte, error) {
+	var out []byte
+	if err := runCtx(ctx, func() (err error) {
+		out, err = sealCommitPhase2(ctx, phase1Output, sectorNum, minerID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AggregateSealProofsCtx is AggregateSealProofs honoring ctx.
+func AggregateSealProofsCtx(ctx context.Context, aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error) {
+	var out []byte
+	if err := runCtx(ctx, func() (err error) {
+		out, err = AggregateSealProofs(aggregateInfo, proofs)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnsealRangeCtx is UnsealRange honoring ctx.
+func UnsealRangeCtx(
+	ctx context.Context,
+	proofType abi.RegisteredSealProof,
+	cacheDirPath string,
+	sealedSector *os.File,
+	unsealOutput *os.File,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	unsealedCID cid.Cid,
+	unpaddedByteIndex uint64,
+	unpaddedBytesAmount uint64,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkShutdown(); err != nil {
+		return err
+	}
+
+	sealed, err := dupFile(sealedSector)
+	if err != nil {
+		return err
+	}
+	output, err := dupFile(unsealOutput)
+	if err != nil {
+		_ = sealed.Close()
+		return err
+	}
+
+	// the descriptors are closed by fn once it starts, or here when runCtx
+	// returns without starting it
+	var started int32
+	err = runCtx(ctx, func() error {
+		if !atomic.CompareAndSwapInt32(&started, 0, 1) {
+			return ctx.Err()
+		}
+		defer sealed.Close() // nolint:errcheck
+		defer output.Close() // nolint:errcheck
+
+		return UnsealRange(proofType, cacheDirPath, sealed, output, sectorNum, minerID, ticket, unsealedCID, unpaddedByteIndex, unpaddedBytesAmount)
+	})
+	if atomic.CompareAndSwapInt32(&started, 0, 1) {
+		_ = sealed.Close()
+		_ = output.Close()
+	}
+	return err
+}
+
+// UnsealCtx is Unseal honoring ctx.
+func UnsealCtx(
+	ctx context.Context,
+	proofType abi.RegisteredSealProof,
+	cacheDirPath string,
+	sealedSector *os.File,
+	unsealOutput *os.File,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	unsealedCID cid.Cid,
+) error {
+	sectorSize, err := proofType.SectorSize()
+	if err != nil {
+		return err
+	}
+
+	unpaddedBytesAmount := abi.PaddedPieceSize(sectorSize).Unpadded()
+
+	return UnsealRangeCtx(ctx, proofType, cacheDirPath, sealedSector, unsealOutput, sectorNum, minerID, ticket, unsealedCID, 0, uint64(unpaddedBytesAmount))
+}
+
+// GeneratePieceCIDCtx is GeneratePieceCID honoring ctx.
+func GeneratePieceCIDCtx(ctx context.Context, proofType abi.RegisteredSealProof, piecePath string, pieceSize abi.UnpaddedPieceSize) (cid.Cid, error) {
+	var out cid.Cid
+	if err := runCtx(ctx, func() (err error) {
+		out, err = GeneratePieceCID(proofType, piecePath, pieceSize)
+		return err
+	}); err != nil {
+		return cid.Undef, err
+	}
+	return out, nil
+}
+
+// GenerateWinningPoStCtx is GenerateWinningPoSt honoring ctx.
+func GenerateWinningPoStCtx(
+	ctx context.Context,
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof5.PoStProof, error) {
+	var out []proof5.PoStProof
+	if err := runCtx(ctx, func() (err error) {
+		out, err = generateWinningPoSt(ctx, minerID, privateSectorInfo, randomness)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GenerateWindowPoStCtx is GenerateWindowPoSt honoring ctx.
+func GenerateWindowPoStCtx(
+	ctx context.Context,
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof5.PoStProof, []abi.SectorNumber, error) {
+	var (
+		out    []proof5.PoStProof
+		faulty []abi.SectorNumber
+	)
+	// the faulty sectors are returned along with the error
+	err := runCtx(ctx, func() (err error) {
+		out, faulty, err = generateWindowPoSt(ctx, minerID, privateSectorInfo, randomness)
+		return err
+	})
+	if ctx.Err() != nil && err == ctx.Err() {
+		return nil, nil, err
+	}
+	return out, faulty, err
+}
+
+// GenerateSingleVanillaProofCtx is GenerateSingleVanillaProof honoring ctx.
+func GenerateSingleVanillaProofCtx(ctx context.Context, replica PrivateSectorInfo, challenges []uint64) ([]byte, error) {
+	var out []byte
+	if err := runCtx(ctx, func() (err error) {
+		out, err = GenerateSingleVanillaProof(replica, challenges)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GenerateWinningPoStWithVanillaCtx is GenerateWinningPoStWithVanilla
+// honoring ctx.
+func GenerateWinningPoStWithVanillaCtx(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof5.PoStProof, error) {
+	var out []proof5.PoStProof
+	if err := runCtx(ctx, func() (err error) {
+		out, err = generateWinningPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GenerateWindowPoStWithVanillaCtx is GenerateWindowPoStWithVanilla honoring
+// ctx.
+func GenerateWindowPoStWithVanillaCtx(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof5.PoStProof, error) {
+	var out []proof5.PoStProof
+	if err := runCtx(ctx, func() (err error) {
+		out, err = generateWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GenerateSinglePartitionWindowPoStWithVanillaCtx is
+// GenerateSinglePartitionWindowPoStWithVanilla honoring ctx.
+func GenerateSinglePartitionWindowPoStWithVanillaCtx(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+	partitionIndex uint,
+) (*PartitionProof, error) {
+	var out *PartitionProof
+	if err := runCtx(ctx, func() (err error) {
+		out, err = generateSinglePartitionWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs, partitionIndex)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EncodeIntoCtx is EncodeInto honoring ctx.
+func (FunctionsSectorUpdate) EncodeIntoCtx(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+	stagedDataPath string,
+	pieces []abi.PieceInfo,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	var commR, commD cid.Cid
+	if err := runCtx(ctx, func() (err error) {
+		commR, commD, err = encodeInto(ctx, proofType, newReplicaPath, newReplicaCachePath, sectorKeyPath, sectorKeyCachePath, stagedDataPath, pieces)
+		return err
+	}); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	return commR, commD, nil
+}
+
+// DecodeFromCtx is DecodeFrom honoring ctx.
+func (FunctionsSectorUpdate) DecodeFromCtx(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	outDataPath string,
+	replicaPath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+	unsealedCID cid.Cid,
+) error {
+	return runCtx(ctx, func() error {
+		return SectorUpdate.DecodeFrom(proofType, outDataPath, replicaPath, sectorKeyPath, sectorKeyCachePath, unsealedCID)
+	})
+}
+
+// GenerateUpdateProofCtx is GenerateUpdateProof honoring ctx.
+func (FunctionsSectorUpdate) GenerateUpdateProofCtx(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+) ([]byte, error) {
+	var out []byte
+	if err := runCtx(ctx, func() (err error) {
+		out, err = generateUpdateProof(ctx, proofType, oldSealedCID, newSealedCID, unsealedCID, newReplicaPath, newReplicaCachePath, sectorKeyPath, sectorKeyCachePath)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package ffi
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCtx(t *testing.T) {
+	// not started when the context is already done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	started := false
+	err := runCtx(ctx, func() error {
+		started = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.False(t, started)
+
+	_, err = SealCommitPhase2Ctx(ctx, []byte{}, 1, 1000)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// returns early while the call keeps running
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	unblock := make(chan struct{})
+	finished := make(chan struct{})
+	err = runCtx(ctx, func() error {
+		<-unblock
+		close(finished)
+		return nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(unblock)
+	<-finished
+
+	require.NoError(t, runCtx(context.Background(), func() error { return nil }))
+}
+
+func TestUnsealRangeCtxClosesFiles(t *testing.T) {
+	if _, err := os.ReadDir("/proc/self/fd"); err != nil {
+		t.Skip("no /proc/self/fd")
+	}
+	openFiles := func() int {
+		fds, err := os.ReadDir("/proc/self/fd")
+		require.NoError(t, err)
+		return len(fds)
+	}
+
+	sealed, err := os.CreateTemp(t.TempDir(), "sealed")
+	require.NoError(t, err)
+	defer sealed.Close() // nolint:errcheck
+	output, err := os.CreateTemp(t.TempDir(), "output")
+	require.NoError(t, err)
+	defer output.Close() // nolint:errcheck
+
+	t.Cleanup(func() { atomic.StoreInt32(&shutDown, 0) })
+	require.NoError(t, Shutdown(context.Background()))
+
+	before := openFiles()
+	err = UnsealRangeCtx(context.Background(), abi.RegisteredSealProof_StackedDrg2KiBV1_1, t.TempDir(), sealed, output, 1, 1000, make(abi.SealRandomness, 32), cid.Undef, 0, 127)
+	require.ErrorIs(t, err, ErrShutdown)
+	assert.Equal(t, before, openFiles())
+}
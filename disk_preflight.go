@@ -0,0 +1,186 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// SealPhase is a phase of sealing writing to the sector directories.
+type SealPhase int
+
+const (
+	// SealPhasePreCommit1 writes the SDR layers and tree_d to the cache
+	// directory, and copies the staged sector to the sealed one.
+	SealPhasePreCommit1 SealPhase = iota
+	// SealPhasePreCommit2 writes tree_c and tree_r_last to the cache
+	// directory, and encodes the sealed sector in place.
+	SealPhasePreCommit2
+)
+
+func (p SealPhase) String() string {
+	switch p {
+	case SealPhasePreCommit1:
+		return "PreCommit1"
+	case SealPhasePreCommit2:
+		return "PreCommit2"
+	default:
+		return fmt.Sprintf("SealPhase(%d)", int(p))
+	}
+}
+
+// sdrLayers returns the number of SDR layers of the sectors of a size.
+func sdrLayers(ssize abi.SectorSize) uint64 {
+	if ssize >= 32<<30 {
+		return 11
+	}
+	return 2
+}
+
+// EstimateCacheSize returns the bytes a sealing phase adds to the cache
+// directory of a sector of the given proof type. The estimate follows the
+// file layout of rust-fil-proofs, within a fraction of a percent.
+func EstimateCacheSize(proofType abi.RegisteredSealProof, phase SealPhase) (uint64, error) {
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return 0, err
+	}
+	leaves := uint64(ssize) / nodeSize
+
+	switch phase {
+	case SealPhasePreCommit1:
+		// The layers, and the binary tree_d over the sector.
+		return sdrLayers(ssize)*uint64(ssize) + (2*leaves-1)*nodeSize, nil
+	case SealPhasePreCommit2:
+		// tree_c is an octree over the columns of the layers, split into the
+		// same files as tree_r_last, of which only the top rows are kept.
+		files := treeRLastFileCount(ssize)
+		return files * uint64(treeCFileSize(leaves/files)+treeRLastCachedSize(leaves/files)), nil
+	default:
+		return 0, xerrors.Errorf("unknown seal phase %d", phase)
+	}
+}
+
+// treeCFileSize returns the size of a tree_c file over the given number of
+// leaves, the whole octree being persisted.
+func treeCFileSize(leaves uint64) int64 {
+	var size uint64
+	for width := leaves; width >= 1; width /= treeRLastArity {
+		size += width * nodeSize
+	}
+	return int64(size)
+}
+
+// ErrInsufficientSpace is returned by CheckSealSpace when a filesystem can't
+// hold the files of a sealing phase.
+type ErrInsufficientSpace struct {
+	Phase SealPhase
+	// Paths are the sector paths written on the filesystem.
+	Paths []string
+	// Required and Available are the bytes needed and available on the
+	// filesystem.
+	Required  uint64
+	Available uint64
+}
+
+// Shortfall returns the number of bytes missing.
+func (e *ErrInsufficientSpace) Shortfall() uint64 {
+	return e.Required - e.Available
+}
+
+func (e *ErrInsufficientSpace) Error() string {
+	return fmt.Sprintf("not enough disk space for %s of %s: %d bytes required, %d available (%d short)",
+		e.Phase, strings.Join(e.Paths, ", "), e.Required, e.Available, e.Shortfall())
+}
+
+// CheckSealSpace checks that the filesystems of the cache directory and of
+// the sealed sector have the free space a sealing phase needs, adding up the
+// requirements of paths on the same filesystem. It returns an
+// *ErrInsufficientSpace for the first filesystem short of space.
+//
+// The staged sector is only read by the sealing phases and isn't checked.
+// The check is skipped on platforms which can't report free space.
+func CheckSealSpace(proofType abi.RegisteredSealProof, phase SealPhase, cacheDirPath string, sealedSectorPath string) error {
+	cache, err := EstimateCacheSize(proofType, phase)
+	if err != nil {
+		return err
+	}
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return err
+	}
+
+	required := map[string]uint64{cacheDirPath: cache}
+	if phase == SealPhasePreCommit1 {
+		// The sealed sector may be there already, from a failed attempt.
+		sealed := uint64(ssize)
+		if st, err := os.Stat(sealedSectorPath); err == nil && uint64(st.Size()) <= sealed {
+			sealed -= uint64(st.Size())
+		}
+		required[sealedSectorPath] += sealed
+	}
+
+	type filesystem struct {
+		paths     []string
+		required  uint64
+		available uint64
+	}
+	filesystems := make(map[uint64]*filesystem)
+	var devs []uint64
+	for _, path := range []string{cacheDirPath, sealedSectorPath} {
+		n, ok := required[path]
+		if !ok {
+			continue
+		}
+		delete(required, path)
+
+		dev, available, ok, err := diskSpace(existingAncestor(path))
+		if err != nil {
+			return xerrors.Errorf("checking disk space of %s: %w", path, err)
+		}
+		if !ok {
+			return nil
+		}
+
+		fs, found := filesystems[dev]
+		if !found {
+			fs = &filesystem{available: available}
+			filesystems[dev] = fs
+			devs = append(devs, dev)
+		}
+		fs.paths = append(fs.paths, path)
+		fs.required += n
+	}
+
+	sort.Slice(devs, func(i, j int) bool { return devs[i] < devs[j] })
+	for _, dev := range devs {
+		fs := filesystems[dev]
+		if fs.required > fs.available {
+			return &ErrInsufficientSpace{Phase: phase, Paths: fs.paths, Required: fs.required, Available: fs.available}
+		}
+	}
+	return nil
+}
+
+// existingAncestor returns path, or its closest ancestor which exists.
+func existingAncestor(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,64 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateCacheSize(t *testing.T) {
+	// 32GiB sectors have 11 layers and a tree_d of twice the sector size.
+	pc1, err := EstimateCacheSize(abi.RegisteredSealProof_StackedDrg32GiBV1_1, SealPhasePreCommit1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(13*32<<30-32), pc1)
+
+	// tree_c is about 8/7 of the sector size, tree_r_last a few MiB.
+	pc2, err := EstimateCacheSize(abi.RegisteredSealProof_StackedDrg32GiBV1_1, SealPhasePreCommit2)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8*(153391689*nodeSize+299593*nodeSize)), pc2)
+
+	pc1, err = EstimateCacheSize(abi.RegisteredSealProof_StackedDrg2KiBV1_1, SealPhasePreCommit1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2*2048+127*32), pc1)
+
+	_, err = EstimateCacheSize(abi.RegisteredSealProof_StackedDrg2KiBV1_1, SealPhase(7))
+	assert.EqualError(t, err, "unknown seal phase 7")
+	_, err = EstimateCacheSize(abi.RegisteredSealProof(-1), SealPhasePreCommit1)
+	assert.Error(t, err)
+}
+
+func TestCheckSealSpace(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "cache", "s-t01000-1")
+	sealed := filepath.Join(dir, "sealed", "s-t01000-1")
+
+	_, available, ok, err := diskSpace(dir)
+	require.NoError(t, err)
+	if !ok {
+		t.Skip("disk space not available on this platform")
+	}
+
+	require.NoError(t, CheckSealSpace(abi.RegisteredSealProof_StackedDrg2KiBV1_1, SealPhasePreCommit1, cache, sealed))
+
+	// The layers, tree_d and the sealed copy of a 64GiB sector.
+	required := uint64(11*64<<30 + (2*64<<30 - 32) + 64<<30)
+	err = CheckSealSpace(abi.RegisteredSealProof_StackedDrg64GiBV1_1, SealPhasePreCommit1, cache, sealed)
+	if available >= required {
+		require.NoError(t, err)
+		return
+	}
+
+	var short *ErrInsufficientSpace
+	require.True(t, errors.As(err, &short), err)
+	assert.Equal(t, SealPhasePreCommit1, short.Phase)
+	assert.Equal(t, []string{cache, sealed}, short.Paths)
+	assert.Equal(t, required, short.Required)
+	assert.Equal(t, short.Required-short.Available, short.Shortfall())
+}
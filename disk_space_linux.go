@@ -0,0 +1,21 @@
+//go:build (cgo || ffimock) && linux
+// +build cgo ffimock
+// +build linux
+
+package ffi
+
+import "golang.org/x/sys/unix"
+
+// diskSpace returns the device of the filesystem of path, and the bytes
+// available on it to unprivileged users.
+func diskSpace(path string) (dev uint64, available uint64, ok bool, err error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return 0, 0, false, err
+	}
+	var fs unix.Statfs_t
+	if err := unix.Statfs(path, &fs); err != nil {
+		return 0, 0, false, err
+	}
+	return uint64(st.Dev), fs.Bavail * uint64(fs.Bsize), true, nil
+}
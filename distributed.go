@@ -1,9 +1,11 @@
-//go:build cgo
-// +build cgo
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
 
 package ffi
 
 import (
+	"context"
+
 	"github.com/filecoin-project/filecoin-ffi/cgo"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
@@ -65,6 +67,7 @@ func GenerateSingleVanillaProof(
 	if err != nil {
 		return nil, err
 	}
+	defer rep.Destroy()
 
 	return cgo.GenerateSingleVanillaProof(rep, cgo.AsSliceRefUint64(challenges))
 }
@@ -75,6 +78,22 @@ func GenerateWinningPoStWithVanilla(
 	randomness abi.PoStRandomness,
 	proofs [][]byte,
 ) ([]proof.PoStProof, error) {
+	return generateWinningPoStWithVanilla(context.Background(), proofType, minerID, randomness, proofs)
+}
+
+func generateWinningPoStWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof.PoStProof, error) {
+	release, err := admit(ctx, OpWinningPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	pp, err := toFilRegisteredPoStProof(proofType)
 	if err != nil {
 		return nil, err
@@ -84,7 +103,7 @@ func GenerateWinningPoStWithVanilla(
 	if err != nil {
 		return nil, err
 	}
-	fproofs, cleanup := toVanillaProofs(proofs)
+	fproofs, cleanup := cgo.PinSlicesBoxedUint8(proofs)
 	defer cleanup()
 
 	randomnessBytes := cgo.AsByteArray32(randomness)
@@ -107,6 +126,22 @@ func GenerateWindowPoStWithVanilla(
 	randomness abi.PoStRandomness,
 	proofs [][]byte,
 ) ([]proof.PoStProof, error) {
+	return generateWindowPoStWithVanilla(context.Background(), proofType, minerID, randomness, proofs)
+}
+
+func generateWindowPoStWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof.PoStProof, error) {
+	release, err := admit(ctx, OpWindowPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	pp, err := toFilRegisteredPoStProof(proofType)
 	if err != nil {
 		return nil, err
@@ -116,7 +151,7 @@ func GenerateWindowPoStWithVanilla(
 	if err != nil {
 		return nil, err
 	}
-	fproofs, cleaner := toVanillaProofs(proofs)
+	fproofs, cleaner := cgo.PinSlicesBoxedUint8(proofs)
 	defer cleaner()
 
 	randomnessBytes := cgo.AsByteArray32(randomness)
@@ -142,6 +177,23 @@ func GenerateSinglePartitionWindowPoStWithVanilla(
 	proofs [][]byte,
 	partitionIndex uint,
 ) (*PartitionProof, error) {
+	return generateSinglePartitionWindowPoStWithVanilla(context.Background(), proofType, minerID, randomness, proofs, partitionIndex)
+}
+
+func generateSinglePartitionWindowPoStWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+	partitionIndex uint,
+) (*PartitionProof, error) {
+	release, err := admit(ctx, OpWindowPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	pp, err := toFilRegisteredPoStProof(proofType)
 	if err != nil {
 		return nil, err
@@ -151,7 +203,7 @@ func GenerateSinglePartitionWindowPoStWithVanilla(
 	if err != nil {
 		return nil, err
 	}
-	fproofs, cleaner := toVanillaProofs(proofs)
+	fproofs, cleaner := cgo.PinSlicesBoxedUint8(proofs)
 	defer cleaner()
 
 	randomnessBytes := cgo.AsByteArray32(randomness)
@@ -188,7 +240,11 @@ func MergeWindowPoStPartitionProofs(
 		return nil, err
 	}
 
-	fproofs, cleaner := toPartitionProofs(partitionProofs)
+	proofs := make([][]byte, len(partitionProofs))
+	for i := range partitionProofs {
+		proofs[i] = partitionProofs[i].ProofBytes
+	}
+	fproofs, cleaner := cgo.PinSlicesBoxedUint8(proofs)
 	defer cleaner()
 
 	resp, err := cgo.MergeWindowPoStPartitionProofs(pp, cgo.AsSliceRefSliceBoxedUint8(fproofs))
@@ -208,12 +264,3 @@ func MergeWindowPoStPartitionProofs(
 
 	return &out, nil
 }
-
-func toPartitionProofs(src []PartitionProof) ([]cgo.SliceBoxedUint8, func()) {
-	out := make([]cgo.SliceBoxedUint8, len(src))
-	for idx := range out {
-		out[idx] = cgo.AllocSliceBoxedUint8(src[idx].ProofBytes)
-	}
-
-	return out, makeCleanerSBU(out, len(src))
-}
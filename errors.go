@@ -0,0 +1,25 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// Sentinel errors of the proof calls, to be tested with errors.Is: the errors
+// of the native library wrap the one matching their response status.
+var (
+	// ErrInvalidInput is returned for arguments rejected before calling into
+	// the native library, such as unsupported proof types.
+	ErrInvalidInput = cgo.ErrInvalidInput
+	// ErrCallerError matches the errors the native library attributes to its
+	// caller.
+	ErrCallerError = cgo.ErrCallerError
+	// ErrReceiverError matches the errors the native library attributes to
+	// itself or its environment.
+	ErrReceiverError = cgo.ErrReceiverError
+	// ErrPanic matches the errors of calls that panicked in the native
+	// library.
+	ErrPanic = cgo.ErrNativePanic
+)
@@ -0,0 +1,38 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+func TestPanicInjection(t *testing.T) {
+	info, err := GetLibraryBuildInfo()
+	require.NoError(t, err)
+	if !info.HasFeature(FeatureInjectPanic) {
+		t.Skip("library built without FFI_USE_INJECT_PANIC=1")
+	}
+
+	// makes the native clear_cache binding panic before doing anything
+	t.Setenv("FFI_INJECT_PANIC", "clear_cache")
+
+	err = ClearCache(2048, t.TempDir())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrPanic))
+	assert.Contains(t, err.Error(), "injected panic in clear_cache")
+
+	var statusErr *cgo.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.NotEmpty(t, statusErr.Backtrace)
+
+	// the process survived, and other bindings are unaffected
+	t.Setenv("FFI_INJECT_PANIC", "")
+	require.NoError(t, ClearCache(2048, t.TempDir()))
+}
@@ -0,0 +1,135 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package estimate
+
+import (
+	"context"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+)
+
+// Operation names recorded by ObservedProofs, matching the ffi.ProofsAPI
+// methods.
+const (
+	OpSealPreCommit1                               = "SealPreCommit1"
+	OpSealPreCommit2                               = "SealPreCommit2"
+	OpSealCommit1                                  = "SealCommit1"
+	OpSealCommit2                                  = "SealCommit2"
+	OpGenerateWinningPoSt                          = "GenerateWinningPoSt"
+	OpGenerateWindowPoSt                           = "GenerateWindowPoSt"
+	OpGenerateWinningPoStWithVanilla               = "GenerateWinningPoStWithVanilla"
+	OpGenerateWindowPoStWithVanilla                = "GenerateWindowPoStWithVanilla"
+	OpGenerateSinglePartitionWindowPoStWithVanilla = "GenerateSinglePartitionWindowPoStWithVanilla"
+)
+
+// ObservedProofs is a ffi.ProofsAPI recording the duration and output size of
+// every successful call in a Registry. Seal operations are keyed by seal
+// proof type, PoSt operations by PoSt proof type.
+type ObservedProofs struct {
+	ffi.ProofsAPI
+
+	registry *Registry
+}
+
+var _ ffi.ProofsAPI = (*ObservedProofs)(nil)
+
+// NewObservedProofs wraps api so that its calls are recorded in registry.
+func NewObservedProofs(api ffi.ProofsAPI, registry *Registry) *ObservedProofs {
+	return &ObservedProofs{
+		ProofsAPI: api,
+		registry:  registry,
+	}
+}
+
+func (o *ObservedProofs) SealPreCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) ([]byte, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.SealPreCommit1(ctx, sector, ticket, pieces)
+	if err == nil {
+		o.registry.Observe(OpSealPreCommit1, int64(sector.ProofType), time.Since(start), uint64(len(out)))
+	}
+	return out, err
+}
+
+func (o *ObservedProofs) SealPreCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) (ffi.SectorCids, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.SealPreCommit2(ctx, sector, phase1Output)
+	if err == nil {
+		o.registry.Observe(OpSealPreCommit2, int64(sector.ProofType), time.Since(start), 0)
+	}
+	return out, err
+}
+
+func (o *ObservedProofs) SealCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids ffi.SectorCids) ([]byte, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.SealCommit1(ctx, sector, ticket, seed, pieces, cids)
+	if err == nil {
+		o.registry.Observe(OpSealCommit1, int64(sector.ProofType), time.Since(start), uint64(len(out)))
+	}
+	return out, err
+}
+
+func (o *ObservedProofs) SealCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) ([]byte, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.SealCommit2(ctx, sector, phase1Output)
+	if err == nil {
+		o.registry.Observe(OpSealCommit2, int64(sector.ProofType), time.Since(start), uint64(len(out)))
+	}
+	return out, err
+}
+
+func (o *ObservedProofs) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+	if err == nil && len(out) > 0 {
+		o.registry.Observe(OpGenerateWinningPoSt, int64(out[0].PoStProof), time.Since(start), proofsSize(out))
+	}
+	return out, err
+}
+
+func (o *ObservedProofs) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorID, error) {
+	start := time.Now()
+	out, skipped, err := o.ProofsAPI.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+	if err == nil && len(out) > 0 {
+		o.registry.Observe(OpGenerateWindowPoSt, int64(out[0].PoStProof), time.Since(start), proofsSize(out))
+	}
+	return out, skipped, err
+}
+
+func (o *ObservedProofs) GenerateWinningPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.GenerateWinningPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+	if err == nil {
+		o.registry.Observe(OpGenerateWinningPoStWithVanilla, int64(proofType), time.Since(start), proofsSize(out))
+	}
+	return out, err
+}
+
+func (o *ObservedProofs) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.GenerateWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+	if err == nil {
+		o.registry.Observe(OpGenerateWindowPoStWithVanilla, int64(proofType), time.Since(start), proofsSize(out))
+	}
+	return out, err
+}
+
+func (o *ObservedProofs) GenerateSinglePartitionWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte, partitionIndex uint) (*ffi.PartitionProof, error) {
+	start := time.Now()
+	out, err := o.ProofsAPI.GenerateSinglePartitionWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs, partitionIndex)
+	if err == nil {
+		o.registry.Observe(OpGenerateSinglePartitionWindowPoStWithVanilla, int64(proofType), time.Since(start), uint64(len(out.ProofBytes)))
+	}
+	return out, err
+}
+
+func proofsSize(proofs []proof5.PoStProof) uint64 {
+	var size uint64
+	for _, p := range proofs {
+		size += uint64(len(p.ProofBytes))
+	}
+	return size
+}
@@ -0,0 +1,212 @@
+// Package estimate keeps self-calibrating estimates of how long proving
+// operations take on this machine and how large their outputs are, per
+// operation and proof type. Estimates are exponentially weighted moving
+// averages of the observations, and can be persisted across restarts.
+package estimate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// DefaultAlpha is the weight of a new observation in the moving averages.
+const DefaultAlpha = 0.2
+
+// Key identifies what an estimate is for. ProofType is the numeric value of
+// the abi.RegisteredSealProof, RegisteredPoStProof, etc. the operation ran
+// with.
+type Key struct {
+	Op        string
+	ProofType int64
+}
+
+// Estimate is the current estimate for a Key.
+type Estimate struct {
+	// Duration is the average duration of the operation.
+	Duration time.Duration
+	// DurationStdDev is the standard deviation of the duration.
+	DurationStdDev time.Duration
+	// Size is the average size of the output in bytes.
+	Size uint64
+	// Samples is the number of observations the estimate is made of.
+	Samples uint64
+	// LastObserved is the time of the last observation.
+	LastObserved time.Time
+}
+
+// Upper returns a pessimistic duration estimate, the average plus z standard
+// deviations; z = 1.28 covers about 90% of the runs.
+func (e Estimate) Upper(z float64) time.Duration {
+	return e.Duration + time.Duration(z*float64(e.DurationStdDev))
+}
+
+type entry struct {
+	Key Key
+
+	// moving averages, in nanoseconds and bytes
+	Mean     float64
+	Variance float64
+	Size     float64
+
+	Samples      uint64
+	LastObserved time.Time
+}
+
+// Registry records observations and serves estimates. It is safe for
+// concurrent use.
+type Registry struct {
+	alpha float64
+
+	lk      sync.RWMutex
+	entries map[Key]*entry
+}
+
+// NewRegistry returns an empty Registry weighting new observations by alpha,
+// in (0, 1]. A zero alpha selects DefaultAlpha.
+func NewRegistry(alpha float64) *Registry {
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultAlpha
+	}
+
+	return &Registry{
+		alpha:   alpha,
+		entries: map[Key]*entry{},
+	}
+}
+
+// Observe records one run of an operation. The first observation of a Key
+// is taken as is.
+func (r *Registry) Observe(op string, proofType int64, duration time.Duration, size uint64) {
+	key := Key{Op: op, ProofType: proofType}
+	d := float64(duration)
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	e, ok := r.entries[key]
+	if !ok {
+		r.entries[key] = &entry{
+			Key:          key,
+			Mean:         d,
+			Size:         float64(size),
+			Samples:      1,
+			LastObserved: time.Now(),
+		}
+		return
+	}
+
+	// incremental exponentially weighted mean and variance
+	diff := d - e.Mean
+	incr := r.alpha * diff
+	e.Mean += incr
+	e.Variance = (1 - r.alpha) * (e.Variance + diff*incr)
+	e.Size += r.alpha * (float64(size) - e.Size)
+
+	e.Samples++
+	e.LastObserved = time.Now()
+}
+
+// Estimate returns the estimate for an operation, if it was observed.
+func (r *Registry) Estimate(op string, proofType int64) (Estimate, bool) {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+
+	e, ok := r.entries[Key{Op: op, ProofType: proofType}]
+	if !ok {
+		return Estimate{}, false
+	}
+	return e.estimate(), true
+}
+
+// All returns every estimate in the registry.
+func (r *Registry) All() map[Key]Estimate {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+
+	out := make(map[Key]Estimate, len(r.entries))
+	for k, e := range r.entries {
+		out[k] = e.estimate()
+	}
+	return out
+}
+
+func (e *entry) estimate() Estimate {
+	return Estimate{
+		Duration:       time.Duration(e.Mean),
+		DurationStdDev: time.Duration(math.Sqrt(e.Variance)),
+		Size:           uint64(e.Size),
+		Samples:        e.Samples,
+		LastObserved:   e.LastObserved,
+	}
+}
+
+// Save writes the registry to path. The file is replaced atomically.
+func (r *Registry) Save(path string) error {
+	r.lk.RLock()
+	entries := make([]entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+	r.lk.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key.Op != entries[j].Key.Op {
+			return entries[i].Key.Op < entries[j].Key.Op
+		}
+		return entries[i].Key.ProofType < entries[j].Key.ProofType
+	})
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return xerrors.Errorf("saving estimates: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return xerrors.Errorf("saving estimates: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return xerrors.Errorf("saving estimates: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads a registry saved with Save. A missing file yields an empty
+// registry.
+func Load(path string, alpha float64) (*Registry, error) {
+	r := NewRegistry(alpha)
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("loading estimates: %w", err)
+	}
+
+	var entries []entry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, xerrors.Errorf("decoding estimates %s: %w", path, err)
+	}
+
+	for i := range entries {
+		r.entries[entries[i].Key] = &entries[i]
+	}
+
+	return r, nil
+}
@@ -0,0 +1,58 @@
+package estimate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(0.5)
+
+	_, ok := r.Estimate("SealCommit2", 8)
+	assert.False(t, ok)
+
+	r.Observe("SealCommit2", 8, 10*time.Second, 1920)
+	e, ok := r.Estimate("SealCommit2", 8)
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, e.Duration)
+	assert.Equal(t, time.Duration(0), e.DurationStdDev)
+	assert.Equal(t, uint64(1920), e.Size)
+
+	r.Observe("SealCommit2", 8, 20*time.Second, 1920)
+	e, _ = r.Estimate("SealCommit2", 8)
+	assert.Equal(t, 15*time.Second, e.Duration)
+	assert.Equal(t, uint64(2), e.Samples)
+	assert.Greater(t, e.Upper(1.28), e.Duration)
+
+	// other proof types are tracked separately
+	r.Observe("SealCommit2", 9, time.Minute, 1920)
+	e, _ = r.Estimate("SealCommit2", 8)
+	assert.Equal(t, 15*time.Second, e.Duration)
+	assert.Len(t, r.All(), 2)
+}
+
+func TestRegistryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "estimates.json")
+
+	r, err := Load(path, 0)
+	require.NoError(t, err)
+	assert.Empty(t, r.All())
+
+	r.Observe("SealPreCommit1", 8, time.Hour, 1<<20)
+	r.Observe("SealPreCommit1", 8, 2*time.Hour, 1<<20)
+	require.NoError(t, r.Save(path))
+
+	loaded, err := Load(path, 0)
+	require.NoError(t, err)
+
+	expected, _ := r.Estimate("SealPreCommit1", 8)
+	actual, ok := loaded.Estimate("SealPreCommit1", 8)
+	require.True(t, ok)
+	assert.Equal(t, expected.Duration, actual.Duration)
+	assert.Equal(t, expected.DurationStdDev, actual.DurationStdDev)
+	assert.Equal(t, expected.Samples, actual.Samples)
+}
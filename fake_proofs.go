@@ -0,0 +1,177 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// Fake proofs stand in for seal and PoSt proofs on local devnets, where
+// sealing real sectors would take hours. A fake proof is a tag followed by the
+// SHA-256 of the public inputs of the proof: it proves nothing, but binds the
+// sector it was made for.
+//
+// Fake proofs are only generated and accepted once EnableFakeProofs has been
+// called. VerifySeal, VerifyWinningPoSt and VerifyWindowPoSt then check fake
+// proofs in Go and still hand the others to the native library; without the
+// switch, fake proofs go to the native library, which rejects them.
+
+var (
+	fakeSealTag = []byte("FAKESEAL")
+	fakePoStTag = []byte("FAKEPOST")
+)
+
+// ErrFakeProofsDisabled is returned when generating or verifying fake proofs
+// before EnableFakeProofs has been called.
+var ErrFakeProofsDisabled = xerrors.New("fake proofs are disabled")
+
+var fakeProofs int32
+
+// EnableFakeProofs enables the generation and verification of fake proofs for
+// the rest of the life of the process. It must never be called on a node of a
+// real network.
+func EnableFakeProofs() {
+	atomic.StoreInt32(&fakeProofs, 1)
+}
+
+// FakeProofsEnabled returns whether EnableFakeProofs has been called.
+func FakeProofsEnabled() bool {
+	return atomic.LoadInt32(&fakeProofs) == 1
+}
+
+// GenerateFakeSeal returns a fake seal proof of the sector.
+func GenerateFakeSeal(
+	proofType abi.RegisteredSealProof,
+	sectorID abi.SectorID,
+	sealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	ticket abi.SealRandomness,
+	seed abi.InteractiveSealRandomness,
+) ([]byte, error) {
+	if !FakeProofsEnabled() {
+		return nil, ErrFakeProofsDisabled
+	}
+	return fakeSeal(proofType, sectorID, sealedCID, unsealedCID, ticket, seed), nil
+}
+
+// VerifyFakeSeal returns true if the proof is the fake seal proof of the
+// sector.
+func VerifyFakeSeal(info proof5.SealVerifyInfo) (bool, error) {
+	if !FakeProofsEnabled() {
+		return false, ErrFakeProofsDisabled
+	}
+
+	expected := fakeSeal(info.SealProof, info.SectorID, info.SealedCID, info.UnsealedCID, info.Randomness, info.InteractiveRandomness)
+	return bytes.Equal(info.Proof, expected), nil
+}
+
+// GenerateFakePoSt returns a fake PoSt proof of the sectors, for a winning or
+// window PoSt depending on proofType.
+func GenerateFakePoSt(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	sectors []proof5.SectorInfo,
+) ([]proof5.PoStProof, error) {
+	if !FakeProofsEnabled() {
+		return nil, ErrFakeProofsDisabled
+	}
+	return []proof5.PoStProof{{
+		PoStProof:  proofType,
+		ProofBytes: fakePoSt(proofType, minerID, randomness, sectors),
+	}}, nil
+}
+
+// VerifyFakeWinningPoSt returns true if the proof is the fake PoSt proof of
+// the challenged sectors.
+func VerifyFakeWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
+	return verifyFakePoSt(info.Proofs, info.Prover, info.Randomness, info.ChallengedSectors)
+}
+
+// VerifyFakeWindowPoSt returns true if the proof is the fake PoSt proof of
+// the challenged sectors.
+func VerifyFakeWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error) {
+	return verifyFakePoSt(info.Proofs, info.Prover, info.Randomness, info.ChallengedSectors)
+}
+
+func verifyFakePoSt(proofs []proof5.PoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, sectors []proof5.SectorInfo) (bool, error) {
+	if !FakeProofsEnabled() {
+		return false, ErrFakeProofsDisabled
+	}
+	if len(proofs) != 1 {
+		return false, nil
+	}
+
+	expected := fakePoSt(proofs[0].PoStProof, minerID, randomness, sectors)
+	return bytes.Equal(proofs[0].ProofBytes, expected), nil
+}
+
+// isFakeSeal returns whether a seal proof is to be checked by VerifyFakeSeal.
+func isFakeSeal(proof []byte) bool {
+	return FakeProofsEnabled() && bytes.HasPrefix(proof, fakeSealTag)
+}
+
+// isFakePoSt returns whether PoSt proofs are to be checked by the fake PoSt
+// verifiers.
+func isFakePoSt(proofs []proof5.PoStProof) bool {
+	if !FakeProofsEnabled() {
+		return false
+	}
+	for _, p := range proofs {
+		if bytes.HasPrefix(p.ProofBytes, fakePoStTag) {
+			return true
+		}
+	}
+	return false
+}
+
+func fakeSeal(
+	proofType abi.RegisteredSealProof,
+	sectorID abi.SectorID,
+	sealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	ticket abi.SealRandomness,
+	seed abi.InteractiveSealRandomness,
+) []byte {
+	h := sha256.New()
+	writeFakeInput(h.Write, uint64(proofType), uint64(sectorID.Miner), uint64(sectorID.Number))
+	writeFakeBytes(h.Write, sealedCID.Bytes(), unsealedCID.Bytes(), ticket, seed)
+	return h.Sum(append([]byte(nil), fakeSealTag...))
+}
+
+func fakePoSt(proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, sectors []proof5.SectorInfo) []byte {
+	h := sha256.New()
+	writeFakeInput(h.Write, uint64(proofType), uint64(minerID))
+	writeFakeBytes(h.Write, randomness)
+	for _, s := range sectors {
+		writeFakeInput(h.Write, uint64(s.SectorNumber))
+		writeFakeBytes(h.Write, s.SealedCID.Bytes())
+	}
+	return h.Sum(append([]byte(nil), fakePoStTag...))
+}
+
+func writeFakeInput(write func([]byte) (int, error), values ...uint64) {
+	var b [8]byte
+	for _, v := range values {
+		binary.BigEndian.PutUint64(b[:], v)
+		_, _ = write(b[:])
+	}
+}
+
+// writeFakeBytes writes every part prefixed by its length, so that the
+// inputs of distinct proofs never hash the same.
+func writeFakeBytes(write func([]byte) (int, error), parts ...[]byte) {
+	for _, p := range parts {
+		writeFakeInput(write, uint64(len(p)))
+		_, _ = write(p)
+	}
+}
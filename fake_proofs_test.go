@@ -0,0 +1,114 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"sync/atomic"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enableFakeProofs(t *testing.T) {
+	EnableFakeProofs()
+	t.Cleanup(func() { atomic.StoreInt32(&fakeProofs, 0) })
+}
+
+func TestFakeProofsDisabled(t *testing.T) {
+	require.False(t, FakeProofsEnabled())
+
+	_, err := GenerateFakeSeal(abi.RegisteredSealProof_StackedDrg2KiBV1_1, abi.SectorID{}, fakeCommR(t, 1), fakeCommR(t, 2), nil, nil)
+	assert.Equal(t, ErrFakeProofsDisabled, err)
+
+	_, err = GenerateFakePoSt(abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, 1000, nil, nil)
+	assert.Equal(t, ErrFakeProofsDisabled, err)
+
+	_, err = VerifyFakeSeal(proof5.SealVerifyInfo{Proof: fakeSealTag})
+	assert.Equal(t, ErrFakeProofsDisabled, err)
+	assert.False(t, isFakeSeal(fakeSealTag))
+}
+
+func TestFakeSeal(t *testing.T) {
+	enableFakeProofs(t)
+
+	info := proof5.SealVerifyInfo{
+		SealProof:             abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+		SectorID:              abi.SectorID{Miner: 1000, Number: 7},
+		Randomness:            abi.SealRandomness{1, 2, 3},
+		InteractiveRandomness: abi.InteractiveSealRandomness{4, 5, 6},
+		SealedCID:             fakeCommR(t, 3),
+		UnsealedCID:           fakeCommR(t, 4),
+	}
+
+	var err error
+	info.Proof, err = GenerateFakeSeal(info.SealProof, info.SectorID, info.SealedCID, info.UnsealedCID, info.Randomness, info.InteractiveRandomness)
+	require.NoError(t, err)
+
+	ok, err := VerifySeal(info)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	info.SectorID.Number++
+	ok, err = VerifySeal(info)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	valid := info
+	valid.SectorID.Number--
+	results, err := VerifySeals([]proof5.SealVerifyInfo{info, valid})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, results)
+}
+
+func TestFakePoSt(t *testing.T) {
+	enableFakeProofs(t)
+
+	sectors := []proof5.SectorInfo{
+		{SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1_1, SectorNumber: 1, SealedCID: fakeCommR(t, 5)},
+		{SealProof: abi.RegisteredSealProof_StackedDrg2KiBV1_1, SectorNumber: 2, SealedCID: fakeCommR(t, 6)},
+	}
+	randomness := abi.PoStRandomness{9, 9, 9}
+
+	proofs, err := GenerateFakePoSt(abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, 1000, randomness, sectors)
+	require.NoError(t, err)
+
+	window := proof5.WindowPoStVerifyInfo{Randomness: randomness, Proofs: proofs, ChallengedSectors: sectors, Prover: 1000}
+	ok, err := VerifyWindowPoSt(window)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	invalid := window
+	invalid.ChallengedSectors = sectors[:1]
+	ok, err = VerifyWindowPoSt(invalid)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	results, err := VerifyWindowPoSts([]proof5.WindowPoStVerifyInfo{window, invalid})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, results)
+
+	proofs, err = GenerateFakePoSt(abi.RegisteredPoStProof_StackedDrgWinning2KiBV1, 1000, randomness, sectors[1:])
+	require.NoError(t, err)
+
+	winning := proof5.WinningPoStVerifyInfo{Randomness: randomness, Proofs: proofs, ChallengedSectors: sectors[1:], Prover: 1000}
+	ok, err = VerifyWinningPoSt(winning)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	winning.Prover++
+	ok, err = VerifyWinningPoSt(winning)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func fakeCommR(t *testing.T, b byte) cid.Cid {
+	c, err := commcid.ReplicaCommitmentV1ToCID(append(make([]byte, 31), b))
+	require.NoError(t, err)
+	return c
+}
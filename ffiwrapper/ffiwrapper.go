@@ -0,0 +1,101 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package ffiwrapper implements the proof verifier and prover interfaces of
+// the lotus `ffiwrapper` package on top of the ffi package, so that lotus
+// code built around ffiwrapper.ProofVerifier and ffiwrapper.ProofProver can
+// use this package by changing its imports only.
+//
+// The interfaces are copied here, rather than imported, to avoid depending
+// on lotus. Go interfaces are satisfied structurally: ProofVerifier can be
+// passed where lotus expects its own ffiwrapper.Verifier.
+package ffiwrapper
+
+import (
+	"context"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof7 "github.com/filecoin-project/specs-actors/v7/actors/runtime/proof"
+)
+
+// Verifier mirrors the lotus `ffiwrapper.Verifier`.
+type Verifier interface {
+	VerifySeal(proof7.SealVerifyInfo) (bool, error)
+	VerifyAggregateSeals(aggregate proof7.AggregateSealVerifyProofAndInfos) (bool, error)
+	VerifyReplicaUpdate(update proof7.ReplicaUpdateInfo) (bool, error)
+	VerifyWinningPoSt(ctx context.Context, info proof7.WinningPoStVerifyInfo) (bool, error)
+	VerifyWindowPoSt(ctx context.Context, info proof7.WindowPoStVerifyInfo) (bool, error)
+
+	GenerateWinningPoStSectorChallenge(context.Context, abi.RegisteredPoStProof, abi.ActorID, abi.PoStRandomness, uint64) ([]uint64, error)
+}
+
+// Prover mirrors the lotus `ffiwrapper.Prover`.
+type Prover interface {
+	AggregateSealProofs(aggregateInfo proof7.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error)
+}
+
+// ProofVerifier is the Verifier backed by the ffi package.
+var ProofVerifier Verifier = proofVerifier{}
+
+// ProofProver is the Prover backed by the ffi package.
+var ProofProver Prover = proofProver{}
+
+type proofVerifier struct{}
+
+func (proofVerifier) VerifySeal(info proof7.SealVerifyInfo) (bool, error) {
+	return ffi.VerifySeal(info)
+}
+
+func (proofVerifier) VerifyAggregateSeals(aggregate proof7.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return ffi.VerifyAggregateSeals(aggregate)
+}
+
+func (proofVerifier) VerifyReplicaUpdate(update proof7.ReplicaUpdateInfo) (bool, error) {
+	return ffi.SectorUpdate.VerifyUpdateProof(update)
+}
+
+// VerifyWinningPoSt verifies a winning PoSt, after clearing the two most
+// significant bits of its randomness like lotus does. The caller's
+// randomness is left untouched.
+func (proofVerifier) VerifyWinningPoSt(ctx context.Context, info proof7.WinningPoStVerifyInfo) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	info.Randomness = postRandomness(info.Randomness)
+	return ffi.VerifyWinningPoSt(info)
+}
+
+// VerifyWindowPoSt verifies a window PoSt, after clearing the two most
+// significant bits of its randomness like lotus does.
+func (proofVerifier) VerifyWindowPoSt(ctx context.Context, info proof7.WindowPoStVerifyInfo) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	info.Randomness = postRandomness(info.Randomness)
+	return ffi.VerifyWindowPoSt(info)
+}
+
+func (proofVerifier) GenerateWinningPoStSectorChallenge(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, eligibleSectorCount uint64) ([]uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ffi.GenerateWinningPoStSectorChallenge(proofType, minerID, postRandomness(randomness), eligibleSectorCount)
+}
+
+type proofProver struct{}
+
+func (proofProver) AggregateSealProofs(aggregateInfo proof7.AggregateSealVerifyProofAndInfos, proofs [][]byte) ([]byte, error) {
+	return ffi.AggregateSealProofs(aggregateInfo, proofs)
+}
+
+// postRandomness returns a copy of the randomness with the two most
+// significant bits cleared, so that it is a valid field element.
+func postRandomness(randomness abi.PoStRandomness) abi.PoStRandomness {
+	out := make(abi.PoStRandomness, len(randomness))
+	copy(out, randomness)
+	if len(out) == 32 {
+		out[31] &= 0x3f
+	}
+	return out
+}
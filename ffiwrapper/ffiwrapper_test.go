@@ -0,0 +1,21 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffiwrapper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoStRandomness(t *testing.T) {
+	randomness := abi.PoStRandomness(bytes.Repeat([]byte{0xff}, 32))
+
+	out := postRandomness(randomness)
+	assert.Equal(t, byte(0x3f), out[31])
+	assert.Equal(t, byte(0xff), out[30])
+	assert.Equal(t, byte(0xff), randomness[31], "the input must not be modified")
+}
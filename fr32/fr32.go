@@ -0,0 +1,190 @@
+// Package fr32 implements the Fr32 padding used to fit sector data into
+// BLS12-381 field elements: every 254 bits of data are stored in a 32 byte
+// node, the two most significant bits of which are zero. 127 bytes of
+// unpadded data thus take 128 padded bytes.
+//
+// This is the Go counterpart of the padding done by the native AddPiece and
+// unseal paths, for callers that pad or unpad data themselves. Blocks are
+// processed as 64 bit words, with an AVX2 implementation on amd64, and large
+// inputs are split across GOMAXPROCS goroutines.
+package fr32
+
+import (
+	"encoding/binary"
+	"runtime"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// UnpaddedBlockSize is the size of a block of unpadded data.
+	UnpaddedBlockSize = 127
+	// PaddedBlockSize is the size of a padded block, i.e. four nodes.
+	PaddedBlockSize = 128
+
+	nodeMask = 0x3fffffffffffffff
+
+	// parallelThreshold is the number of blocks below which padding is done
+	// on the calling goroutine.
+	parallelThreshold = 64 << 10 / UnpaddedBlockSize
+)
+
+// Pad fr32-pads in into out. The length of in must be a multiple of 127, and
+// the length of out the corresponding multiple of 128.
+func Pad(in, out []byte) error {
+	blocks, err := checkLengths(len(in), len(out), UnpaddedBlockSize, PaddedBlockSize)
+	if err != nil {
+		return err
+	}
+
+	parallel(blocks, func(from, to int) {
+		padBlocks(in[from*UnpaddedBlockSize:to*UnpaddedBlockSize], out[from*PaddedBlockSize:to*PaddedBlockSize])
+	})
+
+	return nil
+}
+
+// Unpad removes the fr32 padding of in into out. The length of in must be a
+// multiple of 128, and the length of out the corresponding multiple of 127.
+func Unpad(in, out []byte) error {
+	blocks, err := checkLengths(len(in), len(out), PaddedBlockSize, UnpaddedBlockSize)
+	if err != nil {
+		return err
+	}
+
+	parallel(blocks, func(from, to int) {
+		unpadBlocks(in[from*PaddedBlockSize:to*PaddedBlockSize], out[from*UnpaddedBlockSize:to*UnpaddedBlockSize])
+	})
+
+	return nil
+}
+
+func checkLengths(inLen, outLen, inBlock, outBlock int) (int, error) {
+	if inLen%inBlock != 0 {
+		return 0, xerrors.Errorf("input length %d is not a multiple of %d", inLen, inBlock)
+	}
+
+	blocks := inLen / inBlock
+	if outLen != blocks*outBlock {
+		return 0, xerrors.Errorf("output length %d, expected %d", outLen, blocks*outBlock)
+	}
+
+	return blocks, nil
+}
+
+// parallel splits [0, blocks) in ranges processed concurrently.
+func parallel(blocks int, fn func(from, to int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if blocks < parallelThreshold || workers == 1 {
+		fn(0, blocks)
+		return
+	}
+
+	per := (blocks + workers - 1) / workers
+	if per < parallelThreshold {
+		per = parallelThreshold
+	}
+
+	var wg sync.WaitGroup
+	for from := 0; from < blocks; from += per {
+		to := from + per
+		if to > blocks {
+			to = blocks
+		}
+
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			fn(from, to)
+		}(from, to)
+	}
+	wg.Wait()
+}
+
+func padBlocksGeneric(in, out []byte) {
+	for b := 0; b < len(in)/UnpaddedBlockSize; b++ {
+		padBlock(in[b*UnpaddedBlockSize:(b+1)*UnpaddedBlockSize], out[b*PaddedBlockSize:(b+1)*PaddedBlockSize])
+	}
+}
+
+func unpadBlocksGeneric(in, out []byte) {
+	for b := 0; b < len(in)/PaddedBlockSize; b++ {
+		unpadBlock(in[b*PaddedBlockSize:(b+1)*PaddedBlockSize], out[b*UnpaddedBlockSize:(b+1)*UnpaddedBlockSize])
+	}
+}
+
+// The four nodes of a padded block start at bits 0, 254, 508 and 762 of the
+// unpadded block, i.e. at bit 0, 62, 60 and 58 of its words 0, 3, 7 and 11.
+
+func padBlock(in, out []byte) {
+	_ = in[126]
+	_ = out[127]
+
+	var w [16]uint64
+	for i := 0; i < 15; i++ {
+		w[i] = binary.LittleEndian.Uint64(in[i*8:])
+	}
+	// the last word only holds 7 bytes
+	w[15] = uint64(in[120]) | uint64(in[121])<<8 | uint64(in[122])<<16 | uint64(in[123])<<24 |
+		uint64(in[124])<<32 | uint64(in[125])<<40 | uint64(in[126])<<48
+
+	binary.LittleEndian.PutUint64(out[0:], w[0])
+	binary.LittleEndian.PutUint64(out[8:], w[1])
+	binary.LittleEndian.PutUint64(out[16:], w[2])
+	binary.LittleEndian.PutUint64(out[24:], w[3]&nodeMask)
+
+	binary.LittleEndian.PutUint64(out[32:], w[3]>>62|w[4]<<2)
+	binary.LittleEndian.PutUint64(out[40:], w[4]>>62|w[5]<<2)
+	binary.LittleEndian.PutUint64(out[48:], w[5]>>62|w[6]<<2)
+	binary.LittleEndian.PutUint64(out[56:], (w[6]>>62|w[7]<<2)&nodeMask)
+
+	binary.LittleEndian.PutUint64(out[64:], w[7]>>60|w[8]<<4)
+	binary.LittleEndian.PutUint64(out[72:], w[8]>>60|w[9]<<4)
+	binary.LittleEndian.PutUint64(out[80:], w[9]>>60|w[10]<<4)
+	binary.LittleEndian.PutUint64(out[88:], (w[10]>>60|w[11]<<4)&nodeMask)
+
+	binary.LittleEndian.PutUint64(out[96:], w[11]>>58|w[12]<<6)
+	binary.LittleEndian.PutUint64(out[104:], w[12]>>58|w[13]<<6)
+	binary.LittleEndian.PutUint64(out[112:], w[13]>>58|w[14]<<6)
+	binary.LittleEndian.PutUint64(out[120:], (w[14]>>58|w[15]<<6)&nodeMask)
+}
+
+func unpadBlock(in, out []byte) {
+	_ = in[127]
+	_ = out[126]
+
+	var n [16]uint64
+	for i := 0; i < 16; i++ {
+		n[i] = binary.LittleEndian.Uint64(in[i*8:])
+	}
+	n[3] &= nodeMask
+	n[7] &= nodeMask
+	n[11] &= nodeMask
+	n[15] &= nodeMask
+
+	var w [16]uint64
+	w[0] = n[0]
+	w[1] = n[1]
+	w[2] = n[2]
+	w[3] = n[3] | n[4]<<62
+	w[4] = n[4]>>2 | n[5]<<62
+	w[5] = n[5]>>2 | n[6]<<62
+	w[6] = n[6]>>2 | n[7]<<62
+	w[7] = n[7]>>2 | n[8]<<60
+	w[8] = n[8]>>4 | n[9]<<60
+	w[9] = n[9]>>4 | n[10]<<60
+	w[10] = n[10]>>4 | n[11]<<60
+	w[11] = n[11]>>4 | n[12]<<58
+	w[12] = n[12]>>6 | n[13]<<58
+	w[13] = n[13]>>6 | n[14]<<58
+	w[14] = n[14]>>6 | n[15]<<58
+	w[15] = n[15] >> 6
+
+	for i := 0; i < 15; i++ {
+		binary.LittleEndian.PutUint64(out[i*8:], w[i])
+	}
+	for i := 0; i < 7; i++ {
+		out[120+i] = byte(w[15] >> (8 * i))
+	}
+}
@@ -0,0 +1,38 @@
+//go:build amd64
+// +build amd64
+
+package fr32
+
+import "golang.org/x/sys/cpu"
+
+var useAVX2 = cpu.X86.HasAVX2
+
+//go:noescape
+func padBlocksAVX2(in, out *byte, blocks int)
+
+//go:noescape
+func unpadBlocksAVX2(in, out *byte, blocks int)
+
+func padBlocks(in, out []byte) {
+	blocks := len(in) / UnpaddedBlockSize
+	if !useAVX2 || blocks < 2 {
+		padBlocksGeneric(in, out)
+		return
+	}
+
+	// The AVX2 loop reads 7 bytes past the end of a block, so the last one is
+	// done in Go.
+	padBlocksAVX2(&in[0], &out[0], blocks-1)
+	last := blocks - 1
+	padBlock(in[last*UnpaddedBlockSize:], out[last*PaddedBlockSize:])
+}
+
+func unpadBlocks(in, out []byte) {
+	blocks := len(in) / PaddedBlockSize
+	if !useAVX2 || blocks == 0 {
+		unpadBlocksGeneric(in, out)
+		return
+	}
+
+	unpadBlocksAVX2(&in[0], &out[0], blocks)
+}
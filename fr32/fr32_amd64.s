@@ -0,0 +1,148 @@
+//go:build amd64
+// +build amd64
+
+#include "textflag.h"
+
+// Clears the two padding bits of the last word of a node.
+DATA nodemask<>+0(SB)/8, $0xffffffffffffffff
+DATA nodemask<>+8(SB)/8, $0xffffffffffffffff
+DATA nodemask<>+16(SB)/8, $0xffffffffffffffff
+DATA nodemask<>+24(SB)/8, $0x3fffffffffffffff
+GLOBL nodemask<>(SB), RODATA|NOPTR, $32
+
+// Clears the padding bits of the previous node's last word, loaded in the
+// first lane when unpadding.
+DATA prevmask<>+0(SB)/8, $0x3fffffffffffffff
+DATA prevmask<>+8(SB)/8, $0xffffffffffffffff
+DATA prevmask<>+16(SB)/8, $0xffffffffffffffff
+DATA prevmask<>+24(SB)/8, $0xffffffffffffffff
+GLOBL prevmask<>(SB), RODATA|NOPTR, $32
+
+// Right shift counts bringing in the top bits of the previous word when
+// unpadding a node shifted left by 6, 4 and 2 bits. The previous node only
+// holds 62 bits in its last word.
+DATA shift6<>+0(SB)/8, $56
+DATA shift6<>+8(SB)/8, $58
+DATA shift6<>+16(SB)/8, $58
+DATA shift6<>+24(SB)/8, $58
+GLOBL shift6<>(SB), RODATA|NOPTR, $32
+
+DATA shift4<>+0(SB)/8, $58
+DATA shift4<>+8(SB)/8, $60
+DATA shift4<>+16(SB)/8, $60
+DATA shift4<>+24(SB)/8, $60
+GLOBL shift4<>(SB), RODATA|NOPTR, $32
+
+DATA shift2<>+0(SB)/8, $60
+DATA shift2<>+8(SB)/8, $62
+DATA shift2<>+16(SB)/8, $62
+DATA shift2<>+24(SB)/8, $62
+GLOBL shift2<>(SB), RODATA|NOPTR, $32
+
+// Node k of a padded block is the 254 bits of the unpadded block starting at
+// byte 0, 31, 63 and 95, shifted right by 0, 6, 4 and 2 bits. Each shifted
+// word is completed with the low bits of the next one, loaded 8 bytes further.
+//
+// func padBlocksAVX2(in, out *byte, blocks int)
+TEXT ·padBlocksAVX2(SB), NOSPLIT, $0-24
+	MOVQ in+0(FP), SI
+	MOVQ out+8(FP), DI
+	MOVQ blocks+16(FP), CX
+	TESTQ CX, CX
+	JZ   done
+
+	VMOVDQU nodemask<>(SB), Y15
+
+loop:
+	VMOVDQU 0(SI), Y0
+	VPAND   Y15, Y0, Y0
+	VMOVDQU Y0, 0(DI)
+
+	VMOVDQU 31(SI), Y1
+	VMOVDQU 39(SI), Y2
+	VPSRLQ  $6, Y1, Y1
+	VPSLLQ  $58, Y2, Y2
+	VPOR    Y2, Y1, Y1
+	VPAND   Y15, Y1, Y1
+	VMOVDQU Y1, 32(DI)
+
+	VMOVDQU 63(SI), Y3
+	VMOVDQU 71(SI), Y4
+	VPSRLQ  $4, Y3, Y3
+	VPSLLQ  $60, Y4, Y4
+	VPOR    Y4, Y3, Y3
+	VPAND   Y15, Y3, Y3
+	VMOVDQU Y3, 64(DI)
+
+	VMOVDQU 95(SI), Y5
+	VMOVDQU 103(SI), Y6
+	VPSRLQ  $2, Y5, Y5
+	VPSLLQ  $62, Y6, Y6
+	VPOR    Y6, Y5, Y5
+	VPAND   Y15, Y5, Y5
+	VMOVDQU Y5, 96(DI)
+
+	ADDQ $127, SI
+	ADDQ $128, DI
+	DECQ CX
+	JNZ  loop
+
+	VZEROUPPER
+
+done:
+	RET
+
+// Node k of a padded block is written at byte 0, 31, 63 and 95 of the
+// unpadded block, shifted left by 0, 6, 4 and 2 bits. The stores overlap by
+// one byte, which each store completes with the top bits of the previous node.
+//
+// func unpadBlocksAVX2(in, out *byte, blocks int)
+TEXT ·unpadBlocksAVX2(SB), NOSPLIT, $0-24
+	MOVQ in+0(FP), SI
+	MOVQ out+8(FP), DI
+	MOVQ blocks+16(FP), CX
+	TESTQ CX, CX
+	JZ   done
+
+	VMOVDQU prevmask<>(SB), Y15
+	VMOVDQU shift6<>(SB), Y14
+	VMOVDQU shift4<>(SB), Y13
+	VMOVDQU shift2<>(SB), Y12
+
+loop:
+	VMOVDQU 0(SI), Y0
+	VMOVDQU Y0, 0(DI)
+
+	VMOVDQU 24(SI), Y1
+	VMOVDQU 32(SI), Y2
+	VPAND   Y15, Y1, Y1
+	VPSRLVQ Y14, Y1, Y1
+	VPSLLQ  $6, Y2, Y2
+	VPOR    Y1, Y2, Y2
+	VMOVDQU Y2, 31(DI)
+
+	VMOVDQU 56(SI), Y3
+	VMOVDQU 64(SI), Y4
+	VPAND   Y15, Y3, Y3
+	VPSRLVQ Y13, Y3, Y3
+	VPSLLQ  $4, Y4, Y4
+	VPOR    Y3, Y4, Y4
+	VMOVDQU Y4, 63(DI)
+
+	VMOVDQU 88(SI), Y5
+	VMOVDQU 96(SI), Y6
+	VPAND   Y15, Y5, Y5
+	VPSRLVQ Y12, Y5, Y5
+	VPSLLQ  $2, Y6, Y6
+	VPOR    Y5, Y6, Y6
+	VMOVDQU Y6, 95(DI)
+
+	ADDQ $128, SI
+	ADDQ $127, DI
+	DECQ CX
+	JNZ  loop
+
+	VZEROUPPER
+
+done:
+	RET
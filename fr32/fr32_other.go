@@ -0,0 +1,12 @@
+//go:build !amd64
+// +build !amd64
+
+package fr32
+
+func padBlocks(in, out []byte) {
+	padBlocksGeneric(in, out)
+}
+
+func unpadBlocks(in, out []byte) {
+	unpadBlocksGeneric(in, out)
+}
@@ -0,0 +1,108 @@
+package fr32
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// padReference pads bit by bit.
+func padReference(in []byte) []byte {
+	out := make([]byte, len(in)/UnpaddedBlockSize*PaddedBlockSize)
+
+	for b := 0; b < len(in)/UnpaddedBlockSize; b++ {
+		src := in[b*UnpaddedBlockSize:]
+		dst := out[b*PaddedBlockSize:]
+
+		for bit := 0; bit < UnpaddedBlockSize*8; bit++ {
+			if src[bit/8]&(1<<(bit%8)) == 0 {
+				continue
+			}
+			outBit := bit/254*256 + bit%254
+			dst[outBit/8] |= 1 << (outBit % 8)
+		}
+	}
+
+	return out
+}
+
+func TestPad(t *testing.T) {
+	for _, blocks := range []int{0, 1, 2, 7, parallelThreshold + 3} {
+		in := make([]byte, blocks*UnpaddedBlockSize)
+		rand.New(rand.NewSource(int64(blocks))).Read(in)
+
+		padded := make([]byte, blocks*PaddedBlockSize)
+		require.NoError(t, Pad(in, padded))
+		assert.Equal(t, padReference(in), padded, "%d blocks", blocks)
+
+		unpadded := make([]byte, len(in))
+		require.NoError(t, Unpad(padded, unpadded))
+		assert.True(t, bytes.Equal(in, unpadded), "%d blocks", blocks)
+
+		generic := make([]byte, len(padded))
+		padBlocksGeneric(in, generic)
+		assert.Equal(t, padded, generic, "%d blocks", blocks)
+	}
+}
+
+func TestUnpadMatchesGeneric(t *testing.T) {
+	// padded data with arbitrary padding bits
+	in := make([]byte, 9*PaddedBlockSize)
+	rand.New(rand.NewSource(42)).Read(in)
+
+	out := make([]byte, 9*UnpaddedBlockSize)
+	require.NoError(t, Unpad(in, out))
+
+	generic := make([]byte, len(out))
+	unpadBlocksGeneric(in, generic)
+	assert.Equal(t, generic, out)
+}
+
+func TestUnpadIgnoresTopBits(t *testing.T) {
+	padded := bytes.Repeat([]byte{0xff}, PaddedBlockSize)
+	unpadded := make([]byte, UnpaddedBlockSize)
+	require.NoError(t, Unpad(padded, unpadded))
+	assert.Equal(t, bytes.Repeat([]byte{0xff}, UnpaddedBlockSize), unpadded)
+
+	repadded := make([]byte, PaddedBlockSize)
+	require.NoError(t, Pad(unpadded, repadded))
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, byte(0x3f), repadded[i*32+31])
+	}
+}
+
+func TestLengths(t *testing.T) {
+	assert.Error(t, Pad(make([]byte, 128), make([]byte, 128)))
+	assert.Error(t, Pad(make([]byte, 127), make([]byte, 127)))
+	assert.Error(t, Unpad(make([]byte, 127), make([]byte, 127)))
+	assert.Error(t, Unpad(make([]byte, 128), make([]byte, 128)))
+}
+
+func BenchmarkPad(b *testing.B) {
+	in := make([]byte, 127<<20)
+	out := make([]byte, 128<<20)
+	b.SetBytes(int64(len(in)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := Pad(in, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnpad(b *testing.B) {
+	in := make([]byte, 128<<20)
+	out := make([]byte, 127<<20)
+	b.SetBytes(int64(len(in)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := Unpad(in, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
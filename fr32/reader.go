@@ -0,0 +1,120 @@
+package fr32
+
+import (
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// readerBlocks is the number of blocks converted per read of the source.
+const readerBlocks = 256
+
+// PaddedSize returns the padded size of size unpadded bytes, the last block
+// being zero-filled.
+func PaddedSize(size uint64) uint64 {
+	return (size + UnpaddedBlockSize - 1) / UnpaddedBlockSize * PaddedBlockSize
+}
+
+// UnpaddedSize returns the unpadded size of size padded bytes, which must be
+// a multiple of 128.
+func UnpaddedSize(size uint64) (uint64, error) {
+	if size%PaddedBlockSize != 0 {
+		return 0, xerrors.Errorf("padded size %d is not a multiple of %d", size, PaddedBlockSize)
+	}
+	return size / PaddedBlockSize * UnpaddedBlockSize, nil
+}
+
+// PieceSize returns the padded size of the smallest piece holding n unpadded
+// bytes: the next power of two of their padded size, and at least one block.
+// Its unpadded size is the largest piece payload of that size.
+func PieceSize(unpadded uint64) uint64 {
+	padded := PaddedSize(unpadded)
+	size := uint64(PaddedBlockSize)
+	for size < padded {
+		size <<= 1
+	}
+	return size
+}
+
+// PadReader returns a reader of the fr32 padding of the data read from r.
+// When the data doesn't end on a block boundary, its last block is
+// zero-filled.
+func PadReader(r io.Reader) io.Reader {
+	return newBlockReader(r, UnpaddedBlockSize, PaddedBlockSize, true, Pad)
+}
+
+// UnpadReader returns a reader of the data fr32 padded in r, e.g. a staged
+// sector file. Reading fails with io.ErrUnexpectedEOF when r doesn't end on a
+// block boundary.
+func UnpadReader(r io.Reader) io.Reader {
+	return newBlockReader(r, PaddedBlockSize, UnpaddedBlockSize, false, Unpad)
+}
+
+type blockReader struct {
+	r        io.Reader
+	inBlock  int
+	zeroFill bool
+	convert  func(in, out []byte) error
+
+	in      []byte
+	out     []byte
+	pending []byte
+	err     error
+}
+
+func newBlockReader(r io.Reader, inBlock, outBlock int, zeroFill bool, convert func(in, out []byte) error) *blockReader {
+	return &blockReader{
+		r:        r,
+		inBlock:  inBlock,
+		zeroFill: zeroFill,
+		convert:  convert,
+		in:       make([]byte, readerBlocks*inBlock),
+		out:      make([]byte, readerBlocks*outBlock),
+	}
+}
+
+func (b *blockReader) Read(p []byte) (int, error) {
+	for len(b.pending) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		b.fill()
+	}
+
+	n := copy(p, b.pending)
+	b.pending = b.pending[n:]
+	return n, nil
+}
+
+// fill converts the next blocks of the source into pending.
+func (b *blockReader) fill() {
+	n, err := io.ReadFull(b.r, b.in)
+	switch err {
+	case nil:
+	case io.EOF:
+		b.err = io.EOF
+		return
+	case io.ErrUnexpectedEOF:
+		b.err = io.EOF
+		if rest := n % b.inBlock; rest != 0 {
+			if b.zeroFill {
+				n += copy(b.in[n:], make([]byte, b.inBlock-rest))
+			} else {
+				b.err = io.ErrUnexpectedEOF
+				n -= rest
+			}
+		}
+	default:
+		// convert the complete blocks read before the error
+		b.err = err
+		n -= n % b.inBlock
+	}
+
+	blocks := n / b.inBlock
+	out := b.out[:blocks*len(b.out)/readerBlocks]
+	if err := b.convert(b.in[:n], out); err != nil {
+		b.err = err
+		return
+	}
+	b.pending = out
+}
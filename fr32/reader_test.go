@@ -0,0 +1,58 @@
+package fr32
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPadReader(t *testing.T) {
+	for _, size := range []int{0, 1, 127, 128, readerBlocks*UnpaddedBlockSize + 5} {
+		in := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(in)
+
+		padded, err := io.ReadAll(iotest.OneByteReader(PadReader(bytes.NewReader(in))))
+		require.NoError(t, err)
+		assert.Equal(t, PaddedSize(uint64(size)), uint64(len(padded)), "%d bytes", size)
+
+		filled := make([]byte, len(padded)/PaddedBlockSize*UnpaddedBlockSize)
+		copy(filled, in)
+		assert.Equal(t, padReference(filled), padded, "%d bytes", size)
+
+		unpadded, err := io.ReadAll(UnpadReader(bytes.NewReader(padded)))
+		require.NoError(t, err)
+		assert.Equal(t, filled, unpadded, "%d bytes", size)
+	}
+}
+
+func TestUnpadReaderPartialBlock(t *testing.T) {
+	padded := padReference(make([]byte, 2*UnpaddedBlockSize))
+
+	out, err := io.ReadAll(UnpadReader(bytes.NewReader(padded[:PaddedBlockSize+10])))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Len(t, out, UnpaddedBlockSize)
+}
+
+func TestUnpaddedSize(t *testing.T) {
+	size, err := UnpaddedSize(2048)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2032), size)
+
+	_, err = UnpaddedSize(100)
+	assert.Error(t, err)
+}
+
+func TestPieceSize(t *testing.T) {
+	assert.Equal(t, uint64(128), PieceSize(0))
+	assert.Equal(t, uint64(128), PieceSize(127))
+	assert.Equal(t, uint64(256), PieceSize(128))
+	assert.Equal(t, uint64(512), PieceSize(3*127))
+	assert.Equal(t, uint64(2048), PieceSize(2032))
+	assert.Equal(t, uint64(4096), PieceSize(2033))
+	assert.Equal(t, uint64(32<<30), PieceSize(32<<30/128*127))
+}
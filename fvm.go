@@ -1,5 +1,6 @@
-//go:build cgo && (amd64 || arm64 || riscv64)
+//go:build cgo && !ffimock && (amd64 || arm64 || riscv64)
 // +build cgo
+// +build !ffimock
 // +build amd64 arm64 riscv64
 
 package ffi
@@ -11,12 +12,10 @@ package ffi
 import "C"
 import (
 	"context"
-	gobig "math/big"
 	"runtime"
 
 	"github.com/filecoin-project/filecoin-ffi/cgo"
 	"github.com/filecoin-project/go-state-types/abi"
-	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/network"
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
@@ -158,38 +157,3 @@ type ApplyRet struct {
 	ExecTraceBytes []byte
 	FailureInfo    string
 }
-
-// NOTE: We only support 64bit platforms
-
-// returns hi, lo
-func splitBigInt(i big.Int) (hi uint64, lo uint64, err error) {
-	if i.Sign() < 0 {
-		return 0, 0, xerrors.Errorf("negative number: %s", i)
-	}
-	words := i.Bits()
-	switch len(words) {
-	case 2:
-		hi = uint64(words[1])
-		fallthrough
-	case 1:
-		lo = uint64(words[0])
-	case 0:
-	default:
-		return 0, 0, xerrors.Errorf("exceeds max bigint size: %s", i)
-	}
-	return hi, lo, nil
-}
-
-func reformBigInt(hi, lo uint64) big.Int {
-	var words []gobig.Word
-	if hi > 0 {
-		words = []gobig.Word{gobig.Word(lo), gobig.Word(hi)}
-	} else if lo > 0 {
-		words = []gobig.Word{gobig.Word(lo)}
-	} else {
-		return big.Zero()
-	}
-	int := new(gobig.Int)
-	int.SetBits(words)
-	return big.NewFromGo(int)
-}
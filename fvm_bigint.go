@@ -0,0 +1,47 @@
+//go:build (cgo || ffimock) && (amd64 || arm64 || riscv64)
+// +build cgo ffimock
+// +build amd64 arm64 riscv64
+
+package ffi
+
+import (
+	gobig "math/big"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"golang.org/x/xerrors"
+)
+
+// NOTE: We only support 64bit platforms
+
+// returns hi, lo
+func splitBigInt(i big.Int) (hi uint64, lo uint64, err error) {
+	if i.Sign() < 0 {
+		return 0, 0, xerrors.Errorf("negative number: %s", i)
+	}
+	words := i.Bits()
+	switch len(words) {
+	case 2:
+		hi = uint64(words[1])
+		fallthrough
+	case 1:
+		lo = uint64(words[0])
+	case 0:
+	default:
+		return 0, 0, xerrors.Errorf("exceeds max bigint size: %s", i)
+	}
+	return hi, lo, nil
+}
+
+func reformBigInt(hi, lo uint64) big.Int {
+	var words []gobig.Word
+	if hi > 0 {
+		words = []gobig.Word{gobig.Word(lo), gobig.Word(hi)}
+	} else if lo > 0 {
+		words = []gobig.Word{gobig.Word(lo)}
+	} else {
+		return big.Zero()
+	}
+	int := new(gobig.Int)
+	int.SetBits(words)
+	return big.NewFromGo(int)
+}
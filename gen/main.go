@@ -0,0 +1,48 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Command gen generates the CBOR encoders of the ffi types, see cbor_gen.go.
+// Run it from the root of the repository:
+//
+//	go run ./gen
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	gen "github.com/whyrusleeping/cbor-gen"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+)
+
+// buildTags are prepended to the generated file, as some of the types are
+// only defined with cgo.
+const buildTags = "//go:build cgo || ffimock\n// +build cgo ffimock\n\n"
+
+func main() {
+	const fname = "./cbor_gen.go"
+
+	err := gen.WriteTupleEncodersToFile(fname, "ffi",
+		ffi.PrivateSectorInfo{},
+		ffi.PartitionProof{},
+		ffi.SectorRef{},
+		ffi.SectorCids{},
+	)
+	if err == nil {
+		err = prependBuildTags(fname)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func prependBuildTags(fname string) error {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, append([]byte(buildTags), data...), 0644)
+}
@@ -0,0 +1,124 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// GPUBackend is a GPU framework the native library runs its kernels on.
+type GPUBackend string
+
+const (
+	GPUBackendCUDA   GPUBackend = "cuda"
+	GPUBackendOpenCL GPUBackend = "opencl"
+)
+
+// GPUBackendEnv is the environment variable selecting the backend of the
+// process, when the native library is built with both.
+const GPUBackendEnv = "EC_GPU_FRAMEWORK"
+
+// ErrGPUBackendUnsupported is returned when selecting a GPU backend the
+// native library isn't built with, or which another process serves.
+var ErrGPUBackendUnsupported = xerrors.New("GPU backend not available")
+
+func (b GPUBackend) valid() bool {
+	return b == GPUBackendCUDA || b == GPUBackendOpenCL
+}
+
+var gpuBackends struct {
+	once     sync.Once
+	backends []GPUBackend
+	err      error
+}
+
+// GPUBackends returns the GPU backends the native library is built with,
+// CUDA first. The library was built without GPU support when it is empty.
+func GPUBackends() ([]GPUBackend, error) {
+	gpuBackends.once.Do(func() {
+		names, err := gpuFrameworks()
+		if err != nil {
+			gpuBackends.err = err
+			return
+		}
+		for _, b := range []GPUBackend{GPUBackendCUDA, GPUBackendOpenCL} {
+			for _, name := range names {
+				if GPUBackend(name) == b {
+					gpuBackends.backends = append(gpuBackends.backends, b)
+				}
+			}
+		}
+	})
+	return append([]GPUBackend(nil), gpuBackends.backends...), gpuBackends.err
+}
+
+// ProcessGPUBackend returns the GPU backend the native library uses in this
+// process: the one selected by GPUBackendEnv, set by Init, or the first
+// supported one. It is empty without GPU support.
+func ProcessGPUBackend() (GPUBackend, error) {
+	backends, err := GPUBackends()
+	if err != nil {
+		return "", err
+	}
+	if len(backends) == 0 {
+		return "", nil
+	}
+
+	if env := GPUBackend(os.Getenv(GPUBackendEnv)); env != "" {
+		if err := checkSupported(backends, env); err != nil {
+			return "", xerrors.Errorf("invalid %s: %w", GPUBackendEnv, err)
+		}
+		return env, nil
+	}
+	return backends[0], nil
+}
+
+func checkSupported(backends []GPUBackend, b GPUBackend) error {
+	for _, s := range backends {
+		if s == b {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q, the proofs library supports %v", ErrGPUBackendUnsupported, b, backends)
+}
+
+type gpuBackendKey struct{}
+
+// WithGPUBackend returns a context requiring the calls made with it to run on
+// GPU backend b. The GPU backend of a process is fixed: the calls go to the
+// process using b by routing ProofsAPIs, such as a gpupool.Pool of devices
+// with different backends. The calls of Proofs fail with
+// ErrGPUBackendUnsupported unless b is the backend of the process.
+func WithGPUBackend(ctx context.Context, b GPUBackend) context.Context {
+	return context.WithValue(ctx, gpuBackendKey{}, b)
+}
+
+// GPUBackendFrom returns the GPU backend required by ctx, if any.
+func GPUBackendFrom(ctx context.Context) (GPUBackend, bool) {
+	b, ok := ctx.Value(gpuBackendKey{}).(GPUBackend)
+	return b, ok
+}
+
+// checkGPUBackend fails when ctx requires another GPU backend than the one of
+// the process.
+func checkGPUBackend(ctx context.Context) error {
+	b, ok := GPUBackendFrom(ctx)
+	if !ok {
+		return nil
+	}
+
+	process, err := ProcessGPUBackend()
+	if err != nil {
+		return err
+	}
+	if b != process {
+		return fmt.Errorf("%w: %q required, the process uses %q", ErrGPUBackendUnsupported, b, process)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+// setGPUBackends makes GPUBackends return backends for the duration of the
+// test, instead of asking the native library.
+func setGPUBackends(t *testing.T, backends ...GPUBackend) {
+	reset := func() {
+		gpuBackends.once = sync.Once{}
+		gpuBackends.backends = nil
+		gpuBackends.err = nil
+	}
+	reset()
+	gpuBackends.once.Do(func() { gpuBackends.backends = backends })
+	t.Cleanup(reset)
+}
+
+func TestProcessGPUBackend(t *testing.T) {
+	t.Setenv(GPUBackendEnv, "")
+	require.NoError(t, os.Unsetenv(GPUBackendEnv))
+
+	setGPUBackends(t, GPUBackendCUDA, GPUBackendOpenCL)
+	b, err := ProcessGPUBackend()
+	require.NoError(t, err)
+	assert.Equal(t, GPUBackendCUDA, b)
+
+	t.Setenv(GPUBackendEnv, "opencl")
+	b, err = ProcessGPUBackend()
+	require.NoError(t, err)
+	assert.Equal(t, GPUBackendOpenCL, b)
+
+	setGPUBackends(t, GPUBackendCUDA)
+	_, err = ProcessGPUBackend()
+	assert.True(t, xerrors.Is(err, ErrGPUBackendUnsupported), err)
+	assert.EqualError(t, err, `invalid EC_GPU_FRAMEWORK: GPU backend not available: "opencl", the proofs library supports [cuda]`)
+
+	setGPUBackends(t)
+	b, err = ProcessGPUBackend()
+	require.NoError(t, err)
+	assert.Equal(t, GPUBackend(""), b)
+}
+
+func TestCheckGPUBackend(t *testing.T) {
+	t.Setenv(GPUBackendEnv, "")
+	require.NoError(t, os.Unsetenv(GPUBackendEnv))
+	setGPUBackends(t, GPUBackendCUDA, GPUBackendOpenCL)
+
+	ctx := context.Background()
+	assert.NoError(t, checkGPUBackend(ctx))
+	assert.NoError(t, checkGPUBackend(WithGPUBackend(ctx, GPUBackendCUDA)))
+
+	err := checkGPUBackend(WithGPUBackend(ctx, GPUBackendOpenCL))
+	assert.True(t, xerrors.Is(err, ErrGPUBackendUnsupported), err)
+
+	// The calls needing the GPU are rejected before reaching the library.
+	_, err = Proofs.SealCommit2(WithGPUBackend(ctx, GPUBackendOpenCL), SectorRef{}, nil)
+	assert.True(t, xerrors.Is(err, ErrGPUBackendUnsupported), err)
+}
+
+func TestInitGPUBackend(t *testing.T) {
+	clearConfigEnv(t)
+	setGPUBackends(t, GPUBackendOpenCL)
+
+	assert.Error(t, Init(WithProcessGPUBackend("vulkan")))
+	err := Init(WithProcessGPUBackend(GPUBackendCUDA))
+	assert.True(t, xerrors.Is(err, ErrGPUBackendUnsupported), err)
+
+	require.NoError(t, Init(WithProcessGPUBackend(GPUBackendOpenCL)))
+	assert.Equal(t, "opencl", os.Getenv(GPUBackendEnv))
+	cfg, err := CurrentConfig()
+	require.NoError(t, err)
+	assert.Equal(t, GPUBackendOpenCL, cfg.GPUBackend)
+}
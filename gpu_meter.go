@@ -0,0 +1,100 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GPUUsage is the GPU time of a GPU heavy call: the time from its admission,
+// by the package Scheduler if any, to its return.
+//
+// The native library doesn't report the time its kernels run: a call is
+// accounted for the whole time it holds its GPU, including the parts of it
+// running on the CPU, which is what a tenant occupying the GPU is billed for.
+type GPUUsage struct {
+	Class OpClass
+	// Job is the job of the context of the call, set with WithJob.
+	Job string
+	// GPU is the index of the GPU slot the package Scheduler gave the call,
+	// or -1 when its GPUs are not limited.
+	GPU      int
+	Start    time.Time
+	Duration time.Duration
+}
+
+type jobKey struct{}
+
+// WithJob returns a context attributing the GPU time of the calls made with
+// it, through the Ctx variants, to job, for example the tenant and sector of
+// the call.
+func WithJob(ctx context.Context, job string) context.Context {
+	return context.WithValue(ctx, jobKey{}, job)
+}
+
+type gpuMeter func(GPUUsage)
+
+var (
+	gpuMetersLk sync.Mutex
+	// gpuMeters holds a []*gpuMeter, replaced on every change.
+	gpuMeters    atomic.Value
+	numGPUMeters int32
+)
+
+// AddGPUMeter calls meter with the GPU time of every GPU heavy call once it
+// returned, and returns a function removing it. meter runs on the goroutine
+// of the call, so it should be quick.
+func AddGPUMeter(meter func(GPUUsage)) (remove func()) {
+	gpuMetersLk.Lock()
+	defer gpuMetersLk.Unlock()
+
+	m := (*gpuMeter)(&meter)
+	current, _ := gpuMeters.Load().([]*gpuMeter)
+	gpuMeters.Store(append(append([]*gpuMeter(nil), current...), m))
+	atomic.AddInt32(&numGPUMeters, 1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			gpuMetersLk.Lock()
+			defer gpuMetersLk.Unlock()
+
+			current, _ := gpuMeters.Load().([]*gpuMeter)
+			next := make([]*gpuMeter, 0, len(current))
+			for _, c := range current {
+				if c != m {
+					next = append(next, c)
+				}
+			}
+			gpuMeters.Store(next)
+			atomic.AddInt32(&numGPUMeters, -1)
+		})
+	}
+}
+
+// meterGPU returns release, also reporting the GPU time of the call to the
+// meters, if any, once called.
+func meterGPU(ctx context.Context, class OpClass, gpu int, release func()) func() {
+	if atomic.LoadInt32(&numGPUMeters) == 0 {
+		return release
+	}
+
+	job, _ := ctx.Value(jobKey{}).(string)
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			release()
+
+			usage := GPUUsage{Class: class, Job: job, GPU: gpu, Start: start, Duration: time.Since(start)}
+			current, _ := gpuMeters.Load().([]*gpuMeter)
+			for _, m := range current {
+				(*m)(usage)
+			}
+		})
+	}
+}
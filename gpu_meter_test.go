@@ -0,0 +1,45 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGPUMeter(t *testing.T) {
+	var usages []GPUUsage
+	remove := AddGPUMeter(func(u GPUUsage) { usages = append(usages, u) })
+
+	SetScheduler(NewScheduler(SchedulerConfig{GPUSlots: []int{1, 1}}))
+	defer SetScheduler(nil)
+
+	ctx := WithJob(context.Background(), "tenant-a/sector-7")
+	first, err := admit(ctx, OpSealCommit2)
+	require.NoError(t, err)
+	second, err := admit(context.Background(), OpWindowPoSt)
+	require.NoError(t, err)
+	assert.Empty(t, usages)
+
+	first()
+	first()
+	second()
+	require.Len(t, usages, 2)
+	assert.Equal(t, OpSealCommit2, usages[0].Class)
+	assert.Equal(t, "tenant-a/sector-7", usages[0].Job)
+	assert.Equal(t, OpWindowPoSt, usages[1].Class)
+	assert.Empty(t, usages[1].Job)
+	// the calls ran on both GPUs
+	assert.ElementsMatch(t, []int{0, 1}, []int{usages[0].GPU, usages[1].GPU})
+	assert.False(t, usages[0].Start.IsZero())
+
+	remove()
+	release, err := admit(ctx, OpSealCommit2)
+	require.NoError(t, err)
+	release()
+	assert.Len(t, usages, 2)
+}
@@ -0,0 +1,463 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package gpupool spreads the GPU heavy proving calls of a machine over its
+// GPUs, each served by its own ffi.ProofsAPI.
+//
+// The native library doesn't take a device per call: within a process, it
+// uses the GPUs it sees. A Pool is therefore given one ProofsAPI per device,
+// usually an isolate.Supervisor whose worker runs with CUDA_VISIBLE_DEVICES
+// (or GPU_DEVICE_ORDINAL for OpenCL) set to that device, or a worker.Client
+// of a remote worker bound to it.
+//
+// The Pool routes the PC2, C2 and PoSt calls to the least busy device with a
+// free job slot and enough unreserved VRAM, and the other calls to its
+// default ProofsAPI. Calls requiring a GPU backend, see ffi.WithGPUBackend,
+// only go to the devices of that backend. Calls no device can take right
+// away wait for one by priority, see ffi.WithPriority, winning PoSts first by
+// default. Devices can be excluded at runtime, by hand or when one of their
+// calls fails.
+package gpupool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// ErrNoDevice is returned for calls which no device of the pool can run: all
+// the devices are excluded, or none has enough VRAM or the GPU backend for the
+// call.
+var ErrNoDevice = xerrors.New("no GPU device available")
+
+// ErrUnknownDevice is returned by Exclude and Include for devices not in the
+// pool.
+var ErrUnknownDevice = xerrors.New("unknown GPU device")
+
+// Device is a GPU of the pool.
+type Device struct {
+	// Name identifies the device in Exclude, Include and Stats.
+	Name string
+
+	// API runs the proving calls on this device only.
+	API ffi.ProofsAPI
+
+	// MaxJobs is the number of calls the device runs at once. Defaults to 1.
+	MaxJobs int
+
+	// VRAM is the memory of the device, in bytes. Zero disables the VRAM
+	// accounting of the device.
+	VRAM uint64
+
+	// Backend is the GPU backend API runs on. Calls requiring a backend
+	// with ffi.WithGPUBackend only run on the devices of that backend.
+	Backend ffi.GPUBackend
+}
+
+// Config configures a Pool.
+type Config struct {
+	Devices []Device
+
+	// Default runs the calls which don't use the GPU: PC1 and C1. Defaults
+	// to ffi.Proofs.
+	Default ffi.ProofsAPI
+
+	// JobVRAM returns the VRAM a call of the given class reserves on its
+	// device while it runs, for sectors of the given size. Defaults to
+	// reserving nothing.
+	JobVRAM func(class ffi.OpClass, sectorSize abi.SectorSize) uint64
+
+	// ExcludeOnError, when set, is called with the errors of the calls run
+	// by a device, and excludes the device when it returns true. This takes
+	// devices out of the pool on driver faults or when they run out of
+	// memory. It may call the methods of the Pool.
+	ExcludeOnError func(device string, err error) bool
+}
+
+// DeviceStats is the state of a device.
+type DeviceStats struct {
+	Name     string
+	Excluded bool
+	// Running is the number of calls the device runs.
+	Running int
+	// VRAMReserved is the VRAM reserved by the calls the device runs.
+	VRAMReserved uint64
+	VRAM         uint64
+	// Done and Failed count the calls the device ran.
+	Done   uint64
+	Failed uint64
+}
+
+// Pool is a ffi.ProofsAPI running the GPU heavy calls on the GPU devices with
+// the most room.
+type Pool struct {
+	def     ffi.ProofsAPI
+	jobVRAM func(ffi.OpClass, abi.SectorSize) uint64
+	onError func(string, error) bool
+
+	lk      sync.Mutex
+	devices []*device
+	waiting []*waiter
+}
+
+var _ ffi.ProofsAPI = (*Pool)(nil)
+
+type device struct {
+	Device
+	stats DeviceStats
+}
+
+type waiter struct {
+	class    ffi.OpClass
+	priority ffi.Priority
+	vram     uint64
+	// backend is the backend required by the call, if any.
+	backend ffi.GPUBackend
+	// granted receives the device of the waiter once admitted, or nil when
+	// no device can run the call any more.
+	granted chan *device
+}
+
+// New returns a Pool of the devices of cfg.
+func New(cfg Config) (*Pool, error) {
+	if len(cfg.Devices) == 0 {
+		return nil, xerrors.New("no GPU devices")
+	}
+
+	p := &Pool{
+		def:     cfg.Default,
+		jobVRAM: cfg.JobVRAM,
+		onError: cfg.ExcludeOnError,
+	}
+	if p.def == nil {
+		p.def = ffi.Proofs
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range cfg.Devices {
+		if d.API == nil {
+			return nil, xerrors.Errorf("GPU device %q: no ProofsAPI", d.Name)
+		}
+		if seen[d.Name] {
+			return nil, xerrors.Errorf("duplicate GPU device %q", d.Name)
+		}
+		seen[d.Name] = true
+
+		if d.MaxJobs <= 0 {
+			d.MaxJobs = 1
+		}
+		p.devices = append(p.devices, &device{
+			Device: d,
+			stats:  DeviceStats{Name: d.Name, VRAM: d.VRAM},
+		})
+	}
+	return p, nil
+}
+
+// Exclude stops scheduling calls on a device. The calls it runs complete.
+func (p *Pool) Exclude(name string) error {
+	return p.setExcluded(name, true)
+}
+
+// Include schedules calls on an excluded device again.
+func (p *Pool) Include(name string) error {
+	return p.setExcluded(name, false)
+}
+
+func (p *Pool) setExcluded(name string, excluded bool) error {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	for _, d := range p.devices {
+		if d.Name == name {
+			d.stats.Excluded = excluded
+			p.dispatch()
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownDevice, name)
+}
+
+// Stats returns the state of the devices, in the order of the Config.
+func (p *Pool) Stats() []DeviceStats {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	out := make([]DeviceStats, len(p.devices))
+	for i, d := range p.devices {
+		out[i] = d.stats
+	}
+	return out
+}
+
+// Waiting returns the number of calls waiting for a device.
+func (p *Pool) Waiting() int {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	return len(p.waiting)
+}
+
+// run runs fn on a device once one can take a call of the given class.
+func (p *Pool) run(ctx context.Context, class ffi.OpClass, sectorSize abi.SectorSize, fn func(ffi.ProofsAPI) error) (err error) {
+	var vram uint64
+	if p.jobVRAM != nil {
+		vram = p.jobVRAM(class, sectorSize)
+	}
+
+	backend, _ := ffi.GPUBackendFrom(ctx)
+	d, err := p.acquire(ctx, &waiter{
+		class:    class,
+		priority: ffi.PriorityFrom(ctx, class),
+		vram:     vram,
+		backend:  backend,
+		granted:  make(chan *device, 1),
+	})
+	if err != nil {
+		return err
+	}
+
+	// the device is released even when fn or ExcludeOnError panic, which
+	// counts as a failure
+	returned, exclude := false, false
+	defer func() {
+		p.lk.Lock()
+		defer p.lk.Unlock()
+
+		p.stop(d, vram)
+		if returned && err == nil {
+			d.stats.Done++
+		} else {
+			d.stats.Failed++
+		}
+		if exclude {
+			d.stats.Excluded = true
+		}
+		p.dispatch()
+	}()
+
+	err = fn(d.API)
+	returned = true
+
+	// without the lock, for ExcludeOnError to use the Pool
+	if err != nil && p.onError != nil {
+		exclude = p.onError(d.Name, err)
+	}
+	return err
+}
+
+// acquire waits for a device to take a call.
+func (p *Pool) acquire(ctx context.Context, w *waiter) (*device, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.lk.Lock()
+	p.enqueue(w)
+	p.dispatch()
+	p.lk.Unlock()
+
+	select {
+	case d := <-w.granted:
+		if d == nil {
+			return nil, ErrNoDevice
+		}
+		return d, nil
+	case <-ctx.Done():
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	select {
+	case d := <-w.granted:
+		// admitted while giving up
+		if d != nil {
+			p.stop(d, w.vram)
+			p.dispatch()
+		}
+	default:
+		p.remove(w)
+	}
+	return nil, ctx.Err()
+}
+
+func (p *Pool) stop(d *device, vram uint64) {
+	d.stats.Running--
+	if d.VRAM > 0 {
+		d.stats.VRAMReserved -= vram
+	}
+}
+
+// fits reports whether d could ever run the call of w.
+func (d *device) fits(w *waiter) bool {
+	if d.stats.Excluded || (w.backend != "" && w.backend != d.Backend) {
+		return false
+	}
+	return d.VRAM == 0 || w.vram <= d.VRAM
+}
+
+// free reports whether d can run the call of w now.
+func (d *device) free(w *waiter) bool {
+	return d.fits(w) && d.stats.Running < d.MaxJobs && (d.VRAM == 0 || d.stats.VRAMReserved+w.vram <= d.VRAM)
+}
+
+// pick returns the device with a free slot running the fewest calls, then
+// with the most free VRAM, or nil.
+func (p *Pool) pick(w *waiter) *device {
+	var best *device
+	for _, d := range p.devices {
+		if !d.free(w) {
+			continue
+		}
+		if best == nil || d.stats.Running < best.stats.Running ||
+			(d.stats.Running == best.stats.Running && d.freeVRAM() > best.freeVRAM()) {
+			best = d
+		}
+	}
+	return best
+}
+
+func (d *device) freeVRAM() uint64 {
+	return d.VRAM - d.stats.VRAMReserved
+}
+
+// enqueue inserts w after the waiters of higher or equal priority, that of
+// ffi.WithPriority or else of their class: winning PoSts first, then window
+// PoSts, then sealing.
+func (p *Pool) enqueue(w *waiter) {
+	i := len(p.waiting)
+	for i > 0 && p.waiting[i-1].priority < w.priority {
+		i--
+	}
+	p.waiting = append(p.waiting, nil)
+	copy(p.waiting[i+1:], p.waiting[i:])
+	p.waiting[i] = w
+}
+
+// dispatch hands devices to the waiting calls that can now run, by priority,
+// and fails those no device can run.
+func (p *Pool) dispatch() {
+	for i := 0; i < len(p.waiting); {
+		w := p.waiting[i]
+
+		d := p.pick(w)
+		if d == nil && p.canFit(w) {
+			i++
+			continue
+		}
+		if d != nil {
+			d.stats.Running++
+			if d.VRAM > 0 {
+				d.stats.VRAMReserved += w.vram
+			}
+		}
+		w.granted <- d
+		p.waiting = append(p.waiting[:i], p.waiting[i+1:]...)
+	}
+}
+
+func (p *Pool) canFit(w *waiter) bool {
+	for _, d := range p.devices {
+		if d.fits(w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pool) remove(w *waiter) {
+	for i, o := range p.waiting {
+		if o == w {
+			p.waiting = append(p.waiting[:i], p.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+func sealSectorSize(p abi.RegisteredSealProof) abi.SectorSize {
+	size, _ := p.SectorSize()
+	return size
+}
+
+func postSectorSize(p abi.RegisteredPoStProof) abi.SectorSize {
+	size, _ := p.SectorSize()
+	return size
+}
+
+func sectorsSize(sectorInfo ffi.SortedPrivateSectorInfo) abi.SectorSize {
+	sectors := sectorInfo.Values()
+	if len(sectors) == 0 {
+		return 0
+	}
+	return postSectorSize(sectors[0].PoStProofType)
+}
+
+func (p *Pool) SealPreCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) ([]byte, error) {
+	return p.def.SealPreCommit1(ctx, sector, ticket, pieces)
+}
+
+func (p *Pool) SealPreCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) (out ffi.SectorCids, err error) {
+	err = p.run(ctx, ffi.OpSealPreCommit2, sealSectorSize(sector.ProofType), func(api ffi.ProofsAPI) (err error) {
+		out, err = api.SealPreCommit2(ctx, sector, phase1Output)
+		return err
+	})
+	return out, err
+}
+
+func (p *Pool) SealCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids ffi.SectorCids) ([]byte, error) {
+	return p.def.SealCommit1(ctx, sector, ticket, seed, pieces, cids)
+}
+
+func (p *Pool) SealCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) (out []byte, err error) {
+	err = p.run(ctx, ffi.OpSealCommit2, sealSectorSize(sector.ProofType), func(api ffi.ProofsAPI) (err error) {
+		out, err = api.SealCommit2(ctx, sector, phase1Output)
+		return err
+	})
+	return out, err
+}
+
+func (p *Pool) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) (out []proof5.PoStProof, err error) {
+	err = p.run(ctx, ffi.OpWinningPoSt, sectorsSize(sectorInfo), func(api ffi.ProofsAPI) (err error) {
+		out, err = api.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+		return err
+	})
+	return out, err
+}
+
+func (p *Pool) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) (out []proof5.PoStProof, skipped []abi.SectorID, err error) {
+	err = p.run(ctx, ffi.OpWindowPoSt, sectorsSize(sectorInfo), func(api ffi.ProofsAPI) (err error) {
+		out, skipped, err = api.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+		return err
+	})
+	return out, skipped, err
+}
+
+func (p *Pool) GenerateWinningPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) (out []proof5.PoStProof, err error) {
+	err = p.run(ctx, ffi.OpWinningPoSt, postSectorSize(proofType), func(api ffi.ProofsAPI) (err error) {
+		out, err = api.GenerateWinningPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+		return err
+	})
+	return out, err
+}
+
+func (p *Pool) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) (out []proof5.PoStProof, err error) {
+	err = p.run(ctx, ffi.OpWindowPoSt, postSectorSize(proofType), func(api ffi.ProofsAPI) (err error) {
+		out, err = api.GenerateWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+		return err
+	})
+	return out, err
+}
+
+func (p *Pool) GenerateSinglePartitionWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte, partitionIndex uint) (out *ffi.PartitionProof, err error) {
+	err = p.run(ctx, ffi.OpWindowPoSt, postSectorSize(proofType), func(api ffi.ProofsAPI) (err error) {
+		out, err = api.GenerateSinglePartitionWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs, partitionIndex)
+		return err
+	})
+	return out, err
+}
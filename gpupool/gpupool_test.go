@@ -0,0 +1,312 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package gpupool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+// fakeDevice answers SealCommit2 with its name once release is closed, with
+// an error for "fail" outputs, or panics for "panic" outputs.
+type fakeDevice struct {
+	ffi.ProofsAPI
+
+	name    string
+	started chan struct{}
+	release chan struct{}
+
+	lk    sync.Mutex
+	calls []string
+}
+
+func (f *fakeDevice) record(call string) {
+	f.lk.Lock()
+	f.calls = append(f.calls, call)
+	f.lk.Unlock()
+	f.started <- struct{}{}
+}
+
+func newFakeDevice(name string) *fakeDevice {
+	return &fakeDevice{name: name, started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (f *fakeDevice) SealCommit2(ctx context.Context, _ ffi.SectorRef, phase1Output []byte) ([]byte, error) {
+	f.record(string(phase1Output))
+	select {
+	case <-f.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	switch string(phase1Output) {
+	case "fail":
+		return nil, errors.New("CUDA error: out of memory")
+	case "panic":
+		panic("device lost")
+	}
+	return []byte(f.name), nil
+}
+
+var sector32GiB = ffi.SectorRef{ProofType: abi.RegisteredSealProof_StackedDrg32GiBV1_1}
+
+func commit2(p *Pool, ctx context.Context, input string) <-chan string {
+	out := make(chan string, 1)
+	go func() {
+		proof, err := p.SealCommit2(ctx, sector32GiB, []byte(input))
+		if err != nil {
+			out <- "error: " + err.Error()
+			return
+		}
+		out <- string(proof)
+	}()
+	return out
+}
+
+func waitStarted(t *testing.T, d *fakeDevice) {
+	select {
+	case <-d.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no call started on %s", d.name)
+	}
+}
+
+func TestPoolSpreadsCalls(t *testing.T) {
+	gpu0, gpu1 := newFakeDevice("gpu0"), newFakeDevice("gpu1")
+	p, err := New(Config{Devices: []Device{
+		{Name: "gpu0", API: gpu0},
+		{Name: "gpu1", API: gpu1},
+	}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	first := commit2(p, ctx, "a")
+	waitStarted(t, gpu0)
+	second := commit2(p, ctx, "b")
+	waitStarted(t, gpu1)
+
+	// Both devices are busy: the third call waits.
+	third := commit2(p, ctx, "c")
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, 5*time.Second, time.Millisecond)
+
+	stats := p.Stats()
+	assert.Equal(t, 1, stats[0].Running)
+	assert.Equal(t, 1, stats[1].Running)
+
+	close(gpu1.release)
+	assert.Equal(t, "gpu1", <-second)
+	assert.Equal(t, "gpu1", <-third)
+
+	close(gpu0.release)
+	assert.Equal(t, "gpu0", <-first)
+	assert.Equal(t, uint64(2), p.Stats()[1].Done)
+}
+
+func TestPoolVRAM(t *testing.T) {
+	small, large := newFakeDevice("small"), newFakeDevice("large")
+	close(small.release)
+	close(large.release)
+
+	p, err := New(Config{
+		Devices: []Device{
+			{Name: "small", API: small, MaxJobs: 4, VRAM: 8 << 30},
+			{Name: "large", API: large, MaxJobs: 4, VRAM: 24 << 30},
+		},
+		JobVRAM: func(class ffi.OpClass, size abi.SectorSize) uint64 {
+			assert.Equal(t, ffi.OpSealCommit2, class)
+			assert.Equal(t, abi.SectorSize(32<<30), size)
+			return 12 << 30
+		},
+	})
+	require.NoError(t, err)
+
+	// Only the large device has the VRAM for the calls.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "large", <-commit2(p, context.Background(), "a"))
+	}
+	assert.Equal(t, uint64(0), p.Stats()[1].VRAMReserved)
+
+	require.NoError(t, p.Exclude("large"))
+	assert.Equal(t, "error: "+ErrNoDevice.Error(), <-commit2(p, context.Background(), "a"))
+}
+
+func TestPoolExclude(t *testing.T) {
+	gpu0, gpu1 := newFakeDevice("gpu0"), newFakeDevice("gpu1")
+	close(gpu1.release)
+
+	p, err := New(Config{
+		Devices: []Device{
+			{Name: "gpu0", API: gpu0},
+			{Name: "gpu1", API: gpu1},
+		},
+		ExcludeOnError: func(device string, err error) bool {
+			return device == "gpu1"
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, p.Exclude("gpu1"))
+	ctx := context.Background()
+	first := commit2(p, ctx, "a")
+	waitStarted(t, gpu0)
+
+	// gpu0 is busy and gpu1 excluded: the call waits for either.
+	second := commit2(p, ctx, "fail")
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, p.Include("gpu1"))
+	assert.Equal(t, "error: CUDA error: out of memory", <-second)
+
+	// The failure excluded gpu1.
+	stats := p.Stats()
+	assert.True(t, stats[1].Excluded)
+	assert.Equal(t, uint64(1), stats[1].Failed)
+
+	third := commit2(p, ctx, "b")
+	close(gpu0.release)
+	assert.Equal(t, "gpu0", <-first)
+	assert.Equal(t, "gpu0", <-third)
+
+	err = p.Exclude("gpu2")
+	assert.True(t, xerrors.Is(err, ErrUnknownDevice), err)
+	assert.EqualError(t, err, `unknown GPU device: "gpu2"`)
+}
+
+func TestPoolExcludeOnErrorUsesPool(t *testing.T) {
+	gpu := newFakeDevice("gpu")
+	close(gpu.release)
+
+	var p *Pool
+	var stats []DeviceStats
+	p, err := New(Config{
+		Devices: []Device{{Name: "gpu", API: gpu}},
+		ExcludeOnError: func(device string, err error) bool {
+			stats = p.Stats()
+			return false
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "error: CUDA error: out of memory", <-commit2(p, context.Background(), "fail"))
+	require.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Running)
+	assert.Equal(t, uint64(1), p.Stats()[0].Failed)
+}
+
+func TestPoolReleasesOnPanic(t *testing.T) {
+	gpu := newFakeDevice("gpu")
+	close(gpu.release)
+
+	p, err := New(Config{
+		Devices: []Device{{Name: "gpu", API: gpu, VRAM: 10 << 30}},
+		JobVRAM: func(ffi.OpClass, abi.SectorSize) uint64 { return 8 << 30 },
+	})
+	require.NoError(t, err)
+
+	assert.PanicsWithValue(t, "device lost", func() {
+		_, _ = p.SealCommit2(context.Background(), sector32GiB, []byte("panic"))
+	})
+
+	stats := p.Stats()[0]
+	assert.Zero(t, stats.Running)
+	assert.Zero(t, stats.VRAMReserved)
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.Equal(t, "gpu", <-commit2(p, context.Background(), "a"))
+}
+
+func (f *fakeDevice) GenerateWinningPoStWithVanilla(ctx context.Context, _ abi.RegisteredPoStProof, _ abi.ActorID, _ abi.PoStRandomness, _ [][]byte) ([]proof5.PoStProof, error) {
+	f.record("winning")
+	return []proof5.PoStProof{{ProofBytes: []byte(f.name)}}, nil
+}
+
+func TestPoolPriority(t *testing.T) {
+	gpu := newFakeDevice("gpu")
+	p, err := New(Config{Devices: []Device{{Name: "gpu", API: gpu}}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	first := commit2(p, ctx, "a")
+	waitStarted(t, gpu)
+
+	second := commit2(p, ctx, "b")
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, 5*time.Second, time.Millisecond)
+
+	winning := make(chan error, 1)
+	go func() {
+		_, err := p.GenerateWinningPoStWithVanilla(ctx, abi.RegisteredPoStProof_StackedDrgWinning32GiBV1, 1000, nil, nil)
+		winning <- err
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 2 }, 5*time.Second, time.Millisecond)
+
+	// The winning PoSt queued last runs first.
+	gpu.release <- struct{}{}
+	assert.Equal(t, "gpu", <-first)
+	require.NoError(t, <-winning)
+	close(gpu.release)
+	assert.Equal(t, "gpu", <-second)
+	assert.Equal(t, []string{"a", "winning", "b"}, gpu.calls)
+
+	// Calls giving up leave the queue.
+	gpu.release = make(chan struct{})
+	busy := commit2(p, ctx, "a")
+	cctx, cancel := context.WithCancel(ctx)
+	waiting := commit2(p, cctx, "b")
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, 5*time.Second, time.Millisecond)
+	cancel()
+	assert.Equal(t, "error: "+context.Canceled.Error(), <-waiting)
+	assert.Equal(t, 0, p.Waiting())
+	close(gpu.release)
+	assert.Equal(t, "gpu", <-busy)
+}
+
+func TestPoolWithPriority(t *testing.T) {
+	gpu := newFakeDevice("gpu")
+	p, err := New(Config{Devices: []Device{{Name: "gpu", API: gpu}}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	first := commit2(p, ctx, "a")
+	waitStarted(t, gpu)
+	second := commit2(p, ctx, "b")
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, 5*time.Second, time.Millisecond)
+
+	// WithPriority overrides the priority of the class.
+	critical := commit2(p, ffi.WithPriority(ctx, ffi.PriorityCritical), "critical")
+	require.Eventually(t, func() bool { return p.Waiting() == 2 }, 5*time.Second, time.Millisecond)
+
+	close(gpu.release)
+	assert.Equal(t, "gpu", <-first)
+	assert.Equal(t, "gpu", <-critical)
+	assert.Equal(t, "gpu", <-second)
+	assert.Equal(t, []string{"a", "critical", "b"}, gpu.calls)
+}
+
+func TestPoolGPUBackend(t *testing.T) {
+	cuda, opencl := newFakeDevice("cuda"), newFakeDevice("opencl")
+	close(cuda.release)
+	close(opencl.release)
+
+	p, err := New(Config{Devices: []Device{
+		{Name: "cuda", API: cuda, Backend: ffi.GPUBackendCUDA},
+		{Name: "opencl", API: opencl, Backend: ffi.GPUBackendOpenCL},
+	}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "opencl", <-commit2(p, ffi.WithGPUBackend(ctx, ffi.GPUBackendOpenCL), "a"))
+	}
+
+	require.NoError(t, p.Exclude("opencl"))
+	assert.Equal(t, "error: "+ErrNoDevice.Error(), <-commit2(p, ffi.WithGPUBackend(ctx, ffi.GPUBackendOpenCL), "a"))
+	assert.Equal(t, "cuda", <-commit2(p, ctx, "a"))
+}
@@ -0,0 +1,188 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package grpcprover
+
+import (
+	"context"
+	"io"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+)
+
+// Client calls a remote prover service. Its errors are gRPC status errors.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+var _ ffi.ProofsAPI = (*Client)(nil)
+var _ Verifier = (*Client)(nil)
+
+// NewClient returns a Client making its calls on cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req interface{}, reply interface{}) error {
+	return c.cc.Invoke(ctx, "/"+ServiceName+"/"+method, req, reply, grpc.CallContentSubtype(codecName))
+}
+
+func (c *Client) stream(ctx context.Context, desc *grpc.StreamDesc) (grpc.ClientStream, error) {
+	return c.cc.NewStream(ctx, desc, "/"+ServiceName+"/"+desc.StreamName, grpc.CallContentSubtype(codecName))
+}
+
+func (c *Client) SealPreCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) ([]byte, error) {
+	var out Bytes
+	err := c.invoke(ctx, "SealPreCommit1", &PreCommit1Request{Sector: sector, Ticket: ticket, Pieces: pieces}, &out)
+	return out.Data, err
+}
+
+func (c *Client) SealPreCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) (ffi.SectorCids, error) {
+	var out ffi.SectorCids
+	err := c.invoke(ctx, "SealPreCommit2", &PreCommit2Request{Sector: sector, Phase1Output: phase1Output}, &out)
+	return out, err
+}
+
+// SealCommit1 returns the Commit1 output computed by the server, after
+// checking its envelope.
+func (c *Client) SealCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids ffi.SectorCids) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.stream(ctx, &serviceDesc.Streams[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(&Commit1Request{Sector: sector, Ticket: ticket, Seed: seed, Pieces: pieces, Cids: cids}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	var env []byte
+	for {
+		var chunk Bytes
+		err := stream.RecvMsg(&chunk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, chunk.Data...)
+	}
+
+	c1, err := ffi.DecodeCommit1Output(env)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding commit 1 output: %w", err)
+	}
+	return c1.Phase1Output, nil
+}
+
+// SealCommit2 uploads the Commit1 output in chunks, and returns the proof
+// computed by the server. Only the ID and the proof type of the sector are
+// sent.
+func (c *Client) SealCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.stream(ctx, &serviceDesc.Streams[1])
+	if err != nil {
+		return nil, err
+	}
+
+	env := ffi.EncodeCommit1Output(sector.ProofType, sector.ID, phase1Output)
+	for len(env) > 0 {
+		n := chunkSize
+		if n > len(env) {
+			n = len(env)
+		}
+		if err := stream.SendMsg(&Bytes{Data: env[:n]}); err != nil {
+			// the status is only known once received
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		env = env[n:]
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	var out Bytes
+	if err := stream.RecvMsg(&out); err != nil {
+		return nil, err
+	}
+	return out.Data, nil
+}
+
+func (c *Client) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, error) {
+	var out PoStReply
+	err := c.invoke(ctx, "GenerateWinningPoSt", &PoStRequest{MinerID: minerID, Sectors: sectorInfo, Randomness: randomness}, &out)
+	return out.Proofs, err
+}
+
+func (c *Client) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorID, error) {
+	var out PoStReply
+	err := c.invoke(ctx, "GenerateWindowPoSt", &PoStRequest{MinerID: minerID, Sectors: sectorInfo, Randomness: randomness}, &out)
+	return out.Proofs, out.Skipped, err
+}
+
+func (c *Client) GenerateWinningPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error) {
+	var out PoStReply
+	err := c.invoke(ctx, "GenerateWinningPoStWithVanilla", &PoStWithVanillaRequest{ProofType: proofType, MinerID: minerID, Randomness: randomness, Proofs: proofs}, &out)
+	return out.Proofs, err
+}
+
+func (c *Client) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error) {
+	var out PoStReply
+	err := c.invoke(ctx, "GenerateWindowPoStWithVanilla", &PoStWithVanillaRequest{ProofType: proofType, MinerID: minerID, Randomness: randomness, Proofs: proofs}, &out)
+	return out.Proofs, err
+}
+
+func (c *Client) GenerateSinglePartitionWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte, partitionIndex uint) (*ffi.PartitionProof, error) {
+	var out ffi.PartitionProof
+	err := c.invoke(ctx, "GenerateSinglePartitionWindowPoStWithVanilla", &PartitionPoStRequest{ProofType: proofType, MinerID: minerID, Randomness: randomness, Proofs: proofs, PartitionIndex: partitionIndex}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifySeal verifies a seal proof on the server, without a context as
+// Verifier requires. VerifySealCtx takes one.
+func (c *Client) VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	return c.VerifySealCtx(context.Background(), info)
+}
+
+func (c *Client) VerifySealCtx(ctx context.Context, info proof5.SealVerifyInfo) (bool, error) {
+	var out VerifyReply
+	err := c.invoke(ctx, "VerifySeal", &info, &out)
+	return out.Valid, err
+}
+
+func (c *Client) VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
+	return c.VerifyWinningPoStCtx(context.Background(), info)
+}
+
+func (c *Client) VerifyWinningPoStCtx(ctx context.Context, info proof5.WinningPoStVerifyInfo) (bool, error) {
+	var out VerifyReply
+	err := c.invoke(ctx, "VerifyWinningPoSt", &info, &out)
+	return out.Valid, err
+}
+
+func (c *Client) VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error) {
+	return c.VerifyWindowPoStCtx(context.Background(), info)
+}
+
+func (c *Client) VerifyWindowPoStCtx(ctx context.Context, info proof5.WindowPoStVerifyInfo) (bool, error) {
+	var out VerifyReply
+	err := c.invoke(ctx, "VerifyWindowPoSt", &info, &out)
+	return out.Valid, err
+}
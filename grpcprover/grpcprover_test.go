@@ -0,0 +1,95 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package grpcprover
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeProofs struct {
+	ffi.ProofsAPI
+
+	c1o []byte
+}
+
+func (f *fakeProofs) SealCommit1(context.Context, ffi.SectorRef, abi.SealRandomness, abi.InteractiveSealRandomness, []abi.PieceInfo, ffi.SectorCids) ([]byte, error) {
+	return f.c1o, nil
+}
+
+func (f *fakeProofs) SealCommit2(_ context.Context, sector ffi.SectorRef, phase1Output []byte) ([]byte, error) {
+	if sector.ID.Number != 42 || !bytes.Equal(phase1Output, f.c1o) {
+		return nil, xerrors.New("unexpected commit 2 input")
+	}
+	return []byte("proof"), nil
+}
+
+func (f *fakeProofs) GenerateWindowPoSt(context.Context, abi.ActorID, ffi.SortedPrivateSectorInfo, abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorID, error) {
+	return nil, nil, xerrors.Errorf("no sectors: %w", ffi.ErrInvalidInput)
+}
+
+type fakeVerifier struct {
+	Verifier
+}
+
+func (fakeVerifier) VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	return bytes.Equal(info.Proof, []byte("proof")), nil
+}
+
+func newTestClient(t *testing.T, proofs ffi.ProofsAPI) *Client {
+	l := bufconn.Listen(1 << 20)
+
+	gs := grpc.NewServer()
+	NewServer(proofs, fakeVerifier{}).Register(gs)
+	go gs.Serve(l) // nolint: errcheck
+	t.Cleanup(gs.Stop)
+
+	cc, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+		grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	return NewClient(cc)
+}
+
+func TestClientServer(t *testing.T) {
+	// larger than the default message size limit
+	proofs := &fakeProofs{c1o: bytes.Repeat([]byte{7}, 5<<20)}
+	client := newTestClient(t, proofs)
+	ctx := context.Background()
+
+	sector := ffi.SectorRef{ID: abi.SectorID{Miner: 1000, Number: 42}, ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1}
+
+	c1o, err := client.SealCommit1(ctx, sector, nil, nil, nil, ffi.SectorCids{})
+	require.NoError(t, err)
+	assert.Equal(t, proofs.c1o, c1o)
+
+	out, err := client.SealCommit2(ctx, sector, c1o)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("proof"), out)
+
+	_, err = client.SealCommit2(ctx, ffi.SectorRef{}, c1o)
+	assert.Equal(t, codes.Unknown, status.Code(err))
+
+	_, _, err = client.GenerateWindowPoSt(ctx, 1000, ffi.SortedPrivateSectorInfo{}, nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ok, err := client.VerifySeal(proof5.SealVerifyInfo{Proof: []byte("proof")})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
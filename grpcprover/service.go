@@ -0,0 +1,327 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package grpcprover serves the sealing, PoSt and verification calls of the
+// ffi package over gRPC, and provides the matching client.
+//
+// The package ships no protobuf definitions: messages are the Go types of
+// this package, encoded as JSON with the "json" gRPC codec, i.e. the
+// application/grpc+json content type. The Commit1 outputs, which reach tens
+// of MiB, are streamed in chunks of an ffi.Commit1Output envelope rather than
+// sent as single messages, so that they fit the default gRPC message size
+// limits and are checked for damage before Commit2 runs.
+//
+// Status codes follow the errors of the ffi package: InvalidArgument for
+// ErrInvalidInput and ErrCallerError, DataLoss for uploaded Commit1 outputs
+// failing their checksum, and Canceled or DeadlineExceeded for the context
+// errors.
+package grpcprover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the full gRPC name of the prover service.
+const ServiceName = "filecoin.ffi.Prover"
+
+// codecName is the gRPC content subtype of the messages.
+const codecName = "json"
+
+// chunkSize is the size of the chunks of the streamed Commit1 outputs.
+const chunkSize = 1 << 20
+
+// maxCommit1Size bounds the size of an uploaded Commit1 output envelope.
+const maxCommit1Size = 512 << 20
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return codecName }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// The request and reply messages of the service.
+
+type PreCommit1Request struct {
+	Sector ffi.SectorRef
+	Ticket abi.SealRandomness
+	Pieces []abi.PieceInfo
+}
+
+type PreCommit2Request struct {
+	Sector       ffi.SectorRef
+	Phase1Output []byte
+}
+
+type Commit1Request struct {
+	Sector ffi.SectorRef
+	Ticket abi.SealRandomness
+	Seed   abi.InteractiveSealRandomness
+	Pieces []abi.PieceInfo
+	Cids   ffi.SectorCids
+}
+
+type PoStRequest struct {
+	MinerID    abi.ActorID
+	Sectors    ffi.SortedPrivateSectorInfo
+	Randomness abi.PoStRandomness
+}
+
+type PoStWithVanillaRequest struct {
+	ProofType  abi.RegisteredPoStProof
+	MinerID    abi.ActorID
+	Randomness abi.PoStRandomness
+	Proofs     [][]byte
+}
+
+type PartitionPoStRequest struct {
+	ProofType      abi.RegisteredPoStProof
+	MinerID        abi.ActorID
+	Randomness     abi.PoStRandomness
+	Proofs         [][]byte
+	PartitionIndex uint
+}
+
+type PoStReply struct {
+	Proofs  []proof5.PoStProof
+	Skipped []abi.SectorID
+}
+
+type VerifyReply struct {
+	Valid bool
+}
+
+// Bytes carries a single byte payload, such as a Commit2 proof.
+type Bytes struct {
+	Data []byte
+}
+
+// Verifier verifies proofs. The package level functions of the ffi package
+// are used by default.
+type Verifier interface {
+	VerifySeal(info proof5.SealVerifyInfo) (bool, error)
+	VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error)
+	VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error)
+}
+
+type ffiVerifier struct{}
+
+func (ffiVerifier) VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	return ffi.VerifySeal(info)
+}
+
+func (ffiVerifier) VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
+	return ffi.VerifyWinningPoSt(info)
+}
+
+func (ffiVerifier) VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error) {
+	return ffi.VerifyWindowPoSt(info)
+}
+
+// Server implements the prover service.
+type Server struct {
+	proofs   ffi.ProofsAPI
+	verifier Verifier
+}
+
+// NewServer returns a Server computing proofs with proofs and verifying them
+// with verifier, or the ffi functions when verifier is nil.
+func NewServer(proofs ffi.ProofsAPI, verifier Verifier) *Server {
+	if verifier == nil {
+		verifier = ffiVerifier{}
+	}
+	return &Server{proofs: proofs, verifier: verifier}
+}
+
+// Register registers the service on gs.
+func (s *Server) Register(gs *grpc.Server) {
+	gs.RegisterService(&serviceDesc, s)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unary("SealPreCommit1", func() interface{} { return new(PreCommit1Request) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			r := req.(*PreCommit1Request)
+			out, err := s.proofs.SealPreCommit1(ctx, r.Sector, r.Ticket, r.Pieces)
+			return &Bytes{Data: out}, err
+		}),
+		unary("SealPreCommit2", func() interface{} { return new(PreCommit2Request) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			r := req.(*PreCommit2Request)
+			out, err := s.proofs.SealPreCommit2(ctx, r.Sector, r.Phase1Output)
+			return &out, err
+		}),
+		unary("GenerateWinningPoSt", func() interface{} { return new(PoStRequest) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			r := req.(*PoStRequest)
+			out, err := s.proofs.GenerateWinningPoSt(ctx, r.MinerID, r.Sectors, r.Randomness)
+			return &PoStReply{Proofs: out}, err
+		}),
+		unary("GenerateWindowPoSt", func() interface{} { return new(PoStRequest) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			r := req.(*PoStRequest)
+			out, skipped, err := s.proofs.GenerateWindowPoSt(ctx, r.MinerID, r.Sectors, r.Randomness)
+			return &PoStReply{Proofs: out, Skipped: skipped}, err
+		}),
+		unary("GenerateWinningPoStWithVanilla", func() interface{} { return new(PoStWithVanillaRequest) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			r := req.(*PoStWithVanillaRequest)
+			out, err := s.proofs.GenerateWinningPoStWithVanilla(ctx, r.ProofType, r.MinerID, r.Randomness, r.Proofs)
+			return &PoStReply{Proofs: out}, err
+		}),
+		unary("GenerateWindowPoStWithVanilla", func() interface{} { return new(PoStWithVanillaRequest) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			r := req.(*PoStWithVanillaRequest)
+			out, err := s.proofs.GenerateWindowPoStWithVanilla(ctx, r.ProofType, r.MinerID, r.Randomness, r.Proofs)
+			return &PoStReply{Proofs: out}, err
+		}),
+		unary("GenerateSinglePartitionWindowPoStWithVanilla", func() interface{} { return new(PartitionPoStRequest) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			r := req.(*PartitionPoStRequest)
+			return s.proofs.GenerateSinglePartitionWindowPoStWithVanilla(ctx, r.ProofType, r.MinerID, r.Randomness, r.Proofs, r.PartitionIndex)
+		}),
+		unary("VerifySeal", func() interface{} { return new(proof5.SealVerifyInfo) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			ok, err := s.verifier.VerifySeal(*req.(*proof5.SealVerifyInfo))
+			return &VerifyReply{Valid: ok}, err
+		}),
+		unary("VerifyWinningPoSt", func() interface{} { return new(proof5.WinningPoStVerifyInfo) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			ok, err := s.verifier.VerifyWinningPoSt(*req.(*proof5.WinningPoStVerifyInfo))
+			return &VerifyReply{Valid: ok}, err
+		}),
+		unary("VerifyWindowPoSt", func() interface{} { return new(proof5.WindowPoStVerifyInfo) }, func(ctx context.Context, s *Server, req interface{}) (interface{}, error) {
+			ok, err := s.verifier.VerifyWindowPoSt(*req.(*proof5.WindowPoStVerifyInfo))
+			return &VerifyReply{Valid: ok}, err
+		}),
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			// Commit1Request in, the encoded output out as Bytes chunks.
+			StreamName:    "SealCommit1",
+			Handler:       sealCommit1Handler,
+			ServerStreams: true,
+		},
+		{
+			// the encoded Commit1 output in as Bytes chunks, the proof out.
+			StreamName:    "SealCommit2",
+			Handler:       sealCommit2Handler,
+			ClientStreams: true,
+		},
+	},
+}
+
+type unaryCall func(ctx context.Context, s *Server, req interface{}) (interface{}, error)
+
+func unary(name string, newReq func() interface{}, call unaryCall) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := newReq()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+
+			s := srv.(*Server)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				out, err := call(ctx, s, req)
+				if err != nil {
+					return nil, toStatus(err)
+				}
+				return out, nil
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + name}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+func sealCommit1Handler(srv interface{}, stream grpc.ServerStream) error {
+	s := srv.(*Server)
+
+	var r Commit1Request
+	if err := stream.RecvMsg(&r); err != nil {
+		return err
+	}
+
+	out, err := s.proofs.SealCommit1(stream.Context(), r.Sector, r.Ticket, r.Seed, r.Pieces, r.Cids)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	env := ffi.EncodeCommit1Output(r.Sector.ProofType, r.Sector.ID, out)
+	for len(env) > 0 {
+		n := chunkSize
+		if n > len(env) {
+			n = len(env)
+		}
+		if err := stream.SendMsg(&Bytes{Data: env[:n]}); err != nil {
+			return err
+		}
+		env = env[n:]
+	}
+	return nil
+}
+
+func sealCommit2Handler(srv interface{}, stream grpc.ServerStream) error {
+	s := srv.(*Server)
+
+	var env []byte
+	for {
+		var chunk Bytes
+		err := stream.RecvMsg(&chunk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(env)+len(chunk.Data) > maxCommit1Size {
+			return status.Error(codes.ResourceExhausted, "commit 1 output too large")
+		}
+		env = append(env, chunk.Data...)
+	}
+
+	c1, err := ffi.DecodeCommit1Output(env)
+	if errors.Is(err, ffi.ErrCommit1Checksum) {
+		return status.Error(codes.DataLoss, err.Error())
+	}
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sector := ffi.SectorRef{ID: c1.SectorID, ProofType: c1.ProofType}
+	out, err := s.proofs.SealCommit2(stream.Context(), sector, c1.Phase1Output)
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.SendMsg(&Bytes{Data: out})
+}
+
+// toStatus returns the gRPC status error of err.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, ffi.ErrInvalidInput), errors.Is(err, ffi.ErrCallerError):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
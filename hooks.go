@@ -0,0 +1,28 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import "github.com/filecoin-project/filecoin-ffi/cgo"
+
+func toCallInfo(call cgo.Call) CallInfo {
+	return CallInfo{
+		Name:        call.Name,
+		ProofType:   call.ProofType,
+		SectorID:    call.SectorID,
+		HasSectorID: call.HasSectorID,
+	}
+}
+
+// RegisterHooks registers h for all the native calls, and returns a function
+// removing it. Several Hooks may be registered, and are called in the order
+// they were registered.
+func RegisterHooks(h Hooks) (unregister func()) {
+	return cgo.AddCallHook(func(call cgo.Call) func(cgo.CallResult) {
+		info := toCallInfo(call)
+		h.OnCallStart(info)
+		return func(res cgo.CallResult) {
+			h.OnCallEnd(info, res.Err, res.Duration)
+		}
+	})
+}
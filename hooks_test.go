@@ -0,0 +1,48 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+type recordingHooks struct {
+	started []CallInfo
+	ended   []CallInfo
+	errs    []error
+}
+
+func (h *recordingHooks) OnCallStart(call CallInfo) {
+	h.started = append(h.started, call)
+}
+
+func (h *recordingHooks) OnCallEnd(call CallInfo, err error, _ time.Duration) {
+	h.ended = append(h.ended, call)
+	h.errs = append(h.errs, err)
+}
+
+func TestRegisterHooks(t *testing.T) {
+	h := &recordingHooks{}
+	unregister := RegisterHooks(h)
+
+	// An invalid proof type fails before calling into the library.
+	_, err := cgo.GetMaxUserBytesPerStagedSector(cgo.RegisteredSealProof(1000))
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	want := CallInfo{Name: "get_max_user_bytes_per_staged_sector", ProofType: cgo.RegisteredSealProof(1000).String()}
+	assert.Equal(t, []CallInfo{want}, h.started)
+	assert.Equal(t, []CallInfo{want}, h.ended)
+	require.Len(t, h.errs, 1)
+	assert.ErrorIs(t, h.errs[0], ErrInvalidInput)
+
+	unregister()
+	_, _ = cgo.GetMaxUserBytesPerStagedSector(cgo.RegisteredSealProof(1000))
+	assert.Len(t, h.started, 1)
+}
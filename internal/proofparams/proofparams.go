@@ -0,0 +1,43 @@
+// Package proofparams holds the parameters of the registered proofs, as set
+// by filecoin-proofs for each sector size, so that they can be queried without
+// a call into the library. It has no cgo dependency, so that the cgo bindings
+// and the mock share them.
+package proofparams
+
+const (
+	// SnarkProofSize is the size of a groth16 proof, that of one partition.
+	SnarkProofSize = 192
+
+	WinningPoStChallengeCount = 66
+	WindowPoStChallengeCount  = 10
+	WinningPoStSectorCount    = 1
+)
+
+// SectorSizeInfo holds the parameters of the proofs of one sector size.
+type SectorSizeInfo struct {
+	SectorSize uint64
+
+	PoRepPartitions   uint64
+	PoRepChallenges   uint64
+	WindowPoStSectors uint64
+	UpdatePartitions  uint64
+}
+
+var (
+	Info2KiB   = SectorSizeInfo{2 << 10, 1, 2, 2, 1}
+	Info8MiB   = SectorSizeInfo{8 << 20, 1, 2, 2, 4}
+	Info512MiB = SectorSizeInfo{512 << 20, 1, 2, 2, 16}
+	Info32GiB  = SectorSizeInfo{32 << 30, 10, 176, 2349, 16}
+	Info64GiB  = SectorSizeInfo{64 << 30, 10, 176, 2300, 16}
+)
+
+// ForSectorSize returns the parameters of the proofs of sectors of size
+// bytes, and whether there are proofs of that size.
+func ForSectorSize(size uint64) (*SectorSizeInfo, bool) {
+	for _, info := range []*SectorSizeInfo{&Info2KiB, &Info8MiB, &Info512MiB, &Info32GiB, &Info64GiB} {
+		if info.SectorSize == size {
+			return info, true
+		}
+	}
+	return nil, false
+}
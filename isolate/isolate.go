@@ -0,0 +1,379 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package isolate runs the native proving calls in a supervised child
+// process, so that a crash of the native library, such as a segfault or a GPU
+// driver fault, doesn't take the whole daemon down.
+//
+// A Supervisor implements ffi.ProofsAPI by forwarding its calls to a worker
+// process over a unix socket. When the worker dies, the calls in flight fail
+// with ErrWorkerCrashed, the worker is restarted, and the calls which can be
+// repeated safely (commit 1 and 2, and the PoSt calls) are retried. The
+// pre-commit calls, which write the sector files, are not.
+//
+// By default the worker is the current executable run again with the same
+// arguments: programs using a Supervisor must call RunWorker at the start of
+// main. Errors are carried as text between the processes, so the worker's
+// errors don't match the ffi sentinel errors.
+package isolate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// SocketEnv is the environment variable giving a worker process the socket
+// of its supervisor.
+const SocketEnv = "FFI_ISOLATE_SOCKET"
+
+// ErrWorkerCrashed is returned for the calls in flight when the worker
+// process died, after their retries, if any.
+var ErrWorkerCrashed = errors.New("isolated worker crashed")
+
+// RunWorker serves the calls of the supervisor with ffi.Proofs, and then exits
+// the process, when the process was started as a worker. It returns right
+// away otherwise.
+func RunWorker() {
+	path := os.Getenv(SocketEnv)
+	if path == "" {
+		return
+	}
+
+	if err := runWorker(path, ffi.Proofs); err != nil {
+		fmt.Fprintf(os.Stderr, "isolated worker: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func runWorker(path string, api ffi.ProofsAPI) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return xerrors.Errorf("connecting to the supervisor: %w", err)
+	}
+	return serveConn(conn, api)
+}
+
+// Config configures a Supervisor.
+type Config struct {
+	// Command returns the command starting a worker, which must call
+	// RunWorker. Defaults to the current executable with the same arguments.
+	// The supervisor adds SocketEnv to its environment.
+	Command func() *exec.Cmd
+
+	// Retries is the number of times the calls which can be repeated are
+	// retried after a crash of the worker. Defaults to 1; negative disables
+	// the retries.
+	Retries int
+
+	// RestartDelay is the minimum delay between two starts of the worker.
+	// Defaults to a second.
+	RestartDelay time.Duration
+
+	// StartTimeout bounds the wait for a started worker to connect. Defaults
+	// to 30 seconds.
+	StartTimeout time.Duration
+
+	// GPUBackend, when set, is the GPU backend of the worker, set through
+	// ffi.GPUBackendEnv.
+	GPUBackend ffi.GPUBackend
+}
+
+// Supervisor forwards the proving calls to a worker process, restarting it
+// when it dies.
+type Supervisor struct {
+	cfg Config
+	dir string
+
+	lk        sync.Mutex
+	worker    *workerProc
+	lastStart time.Time
+	closed    bool
+}
+
+var _ ffi.ProofsAPI = (*Supervisor)(nil)
+
+type workerProc struct {
+	cmd    *exec.Cmd
+	client *rpc.Client
+	// exited is closed once the process exited.
+	exited chan struct{}
+}
+
+// NewSupervisor starts a worker and returns its Supervisor.
+func NewSupervisor(cfg Config) (*Supervisor, error) {
+	if cfg.Command == nil {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, xerrors.Errorf("finding the current executable: %w", err)
+		}
+		args := os.Args[1:]
+		cfg.Command = func() *exec.Cmd {
+			cmd := exec.Command(exe, args...)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			return cmd
+		}
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = 1
+	}
+	if cfg.RestartDelay <= 0 {
+		cfg.RestartDelay = time.Second
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = 30 * time.Second
+	}
+
+	dir, err := ioutil.TempDir("", "ffi-isolate")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Supervisor{cfg: cfg, dir: dir}
+	if _, err := s.client(); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close stops the worker. Calls in flight fail.
+func (s *Supervisor) Close() error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.worker != nil {
+		s.stop(s.worker)
+	}
+	return os.RemoveAll(s.dir)
+}
+
+// client returns the client of the worker, starting one if needed.
+func (s *Supervisor) client() (*workerProc, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.closed {
+		return nil, xerrors.New("supervisor closed")
+	}
+	if s.worker != nil {
+		select {
+		case <-s.worker.exited:
+			s.stop(s.worker)
+		default:
+			return s.worker, nil
+		}
+	}
+
+	if wait := s.cfg.RestartDelay - time.Since(s.lastStart); !s.lastStart.IsZero() && wait > 0 {
+		time.Sleep(wait)
+	}
+	s.lastStart = time.Now()
+
+	w, err := s.start()
+	if err != nil {
+		return nil, err
+	}
+	s.worker = w
+	return w, nil
+}
+
+// start runs a worker and waits for it to connect.
+func (s *Supervisor) start() (*workerProc, error) {
+	path := filepath.Join(s.dir, "worker.sock")
+	_ = os.Remove(path)
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, xerrors.Errorf("listening for the worker: %w", err)
+	}
+	defer os.Remove(path) // nolint: errcheck
+	defer l.Close()       // nolint: errcheck
+
+	cmd := s.cfg.Command()
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, SocketEnv+"="+path)
+	if s.cfg.GPUBackend != "" {
+		cmd.Env = append(cmd.Env, ffi.GPUBackendEnv+"="+string(s.cfg.GPUBackend))
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, xerrors.Errorf("starting the worker: %w", err)
+	}
+
+	w := &workerProc{cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		_ = cmd.Wait()
+		close(w.exited)
+	}()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	select {
+	case conn := <-accepted:
+		w.client = rpc.NewClientWithCodec(jsonrpc.NewClientCodec(conn))
+		return w, nil
+	case <-w.exited:
+		return nil, xerrors.Errorf("worker exited before connecting: %s", cmd.ProcessState)
+	case <-time.After(s.cfg.StartTimeout):
+		_ = cmd.Process.Kill()
+		return nil, xerrors.New("worker didn't connect in time")
+	}
+}
+
+// stop kills a worker and waits for it to exit.
+func (s *Supervisor) stop(w *workerProc) {
+	if s.worker == w {
+		s.worker = nil
+	}
+	if w.client != nil {
+		_ = w.client.Close()
+	}
+	_ = w.cmd.Process.Kill()
+	<-w.exited
+}
+
+// crashed stops w, after its connection failed, unless it was already
+// replaced.
+func (s *Supervisor) crashed(w *workerProc) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.worker == w {
+		s.stop(w)
+	}
+}
+
+// call makes a call on the worker, retrying it after crashes if it is
+// retryable. It returns when ctx is done, leaving the call to the worker.
+func (s *Supervisor) call(ctx context.Context, retryable bool, method string, args interface{}, reply interface{}) error {
+	attempts := 1
+	if retryable && s.cfg.Retries > 0 {
+		attempts += s.cfg.Retries
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var w *workerProc
+		if w, err = s.client(); err != nil {
+			return err
+		}
+
+		call := w.client.Go(serviceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+		select {
+		case <-call.Done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		err = call.Error
+		if !isConnError(err) {
+			return err
+		}
+		s.crashed(w)
+	}
+
+	return fmt.Errorf("%s: %w (%s)", method, ErrWorkerCrashed, err)
+}
+
+// isConnError reports whether err is a failure of the connection to the
+// worker, rather than an error returned by the call.
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var serverErr rpc.ServerError
+	if errors.As(err, &serverErr) {
+		return false
+	}
+	return true
+}
+
+func (s *Supervisor) SealPreCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) ([]byte, error) {
+	var out []byte
+	err := s.call(ctx, false, "SealPreCommit1", &PreCommit1Args{Sector: sector, Ticket: ticket, Pieces: pieces}, &out)
+	return out, err
+}
+
+func (s *Supervisor) SealPreCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) (ffi.SectorCids, error) {
+	var out ffi.SectorCids
+	err := s.call(ctx, false, "SealPreCommit2", &PreCommit2Args{Sector: sector, Phase1Output: phase1Output}, &out)
+	return out, err
+}
+
+func (s *Supervisor) SealCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids ffi.SectorCids) ([]byte, error) {
+	var out []byte
+	err := s.call(ctx, true, "SealCommit1", &Commit1Args{Sector: sector, Ticket: ticket, Seed: seed, Pieces: pieces, Cids: cids}, &out)
+	return out, err
+}
+
+func (s *Supervisor) SealCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) ([]byte, error) {
+	var out []byte
+	err := s.call(ctx, true, "SealCommit2", &Commit2Args{Sector: sector, Phase1Output: phase1Output}, &out)
+	return out, err
+}
+
+func (s *Supervisor) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, error) {
+	var out []proof.PoStProof
+	err := s.call(ctx, true, "GenerateWinningPoSt", &PoStArgs{MinerID: minerID, Sectors: sectorInfo, Randomness: randomness}, &out)
+	return out, err
+}
+
+func (s *Supervisor) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, []abi.SectorID, error) {
+	var out WindowPoStReply
+	err := s.call(ctx, true, "GenerateWindowPoSt", &PoStArgs{MinerID: minerID, Sectors: sectorInfo, Randomness: randomness}, &out)
+	return out.Proofs, out.Skipped, err
+}
+
+func (s *Supervisor) GenerateWinningPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof.PoStProof, error) {
+	var out []proof.PoStProof
+	err := s.call(ctx, true, "GenerateWinningPoStWithVanilla", &PoStWithVanillaArgs{ProofType: proofType, MinerID: minerID, Randomness: randomness, Proofs: proofs}, &out)
+	return out, err
+}
+
+func (s *Supervisor) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof.PoStProof, error) {
+	var out []proof.PoStProof
+	err := s.call(ctx, true, "GenerateWindowPoStWithVanilla", &PoStWithVanillaArgs{ProofType: proofType, MinerID: minerID, Randomness: randomness, Proofs: proofs}, &out)
+	return out, err
+}
+
+func (s *Supervisor) GenerateSinglePartitionWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte, partitionIndex uint) (*ffi.PartitionProof, error) {
+	var out ffi.PartitionProof
+	err := s.call(ctx, true, "GenerateSinglePartitionWindowPoStWithVanilla", &PartitionPoStArgs{ProofType: proofType, MinerID: minerID, Randomness: randomness, Proofs: proofs, PartitionIndex: partitionIndex}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,131 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package isolate
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// crashMarkerEnv names a file making the helper worker crash on its first
+// SealCommit2, creating the file.
+const crashMarkerEnv = "FFI_ISOLATE_TEST_CRASH_MARKER"
+
+func TestMain(m *testing.M) {
+	if path := os.Getenv(SocketEnv); path != "" {
+		if err := runWorker(path, &fakeProofs{}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type fakeProofs struct {
+	ffi.ProofsAPI
+}
+
+func (f *fakeProofs) SealCommit2(_ context.Context, sector ffi.SectorRef, phase1Output []byte) ([]byte, error) {
+	if string(phase1Output) == "crash" {
+		os.Exit(3)
+	}
+	if marker := os.Getenv(crashMarkerEnv); marker != "" {
+		if _, err := os.Stat(marker); os.IsNotExist(err) {
+			_ = os.WriteFile(marker, nil, 0644)
+			os.Exit(3)
+		}
+	}
+	if len(phase1Output) == 0 {
+		return nil, errors.New("empty commit 1 output")
+	}
+	return append([]byte("proof:"), phase1Output...), nil
+}
+
+func (f *fakeProofs) SealPreCommit1(context.Context, ffi.SectorRef, abi.SealRandomness, []abi.PieceInfo) ([]byte, error) {
+	os.Exit(3)
+	return nil, nil
+}
+
+func TestServeConn(t *testing.T) {
+	server, conn := net.Pipe()
+	go serveConn(server, &fakeProofs{}) // nolint: errcheck
+
+	client := rpc.NewClientWithCodec(jsonrpc.NewClientCodec(conn))
+	defer client.Close() // nolint: errcheck
+
+	var out []byte
+	args := &Commit2Args{
+		Sector:       ffi.SectorRef{ID: abi.SectorID{Miner: 1000, Number: 42}},
+		Phase1Output: []byte("c1o"),
+	}
+	require.NoError(t, client.Call(serviceName+".SealCommit2", args, &out))
+	assert.Equal(t, []byte("proof:c1o"), out)
+
+	err := client.Call(serviceName+".SealCommit2", &Commit2Args{}, &out)
+	assert.EqualError(t, err, "empty commit 1 output")
+	assert.False(t, isConnError(err))
+}
+
+func newTestSupervisor(t *testing.T, env ...string) *Supervisor {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	s, err := NewSupervisor(Config{
+		Command: func() *exec.Cmd {
+			cmd := exec.Command(exe)
+			cmd.Env = append(os.Environ(), env...)
+			return cmd
+		},
+		RestartDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestSupervisorRestart(t *testing.T) {
+	s := newTestSupervisor(t)
+	ctx := context.Background()
+
+	out, err := s.SealCommit2(ctx, ffi.SectorRef{}, []byte("c1o"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("proof:c1o"), out)
+
+	// crashing on every attempt
+	_, err = s.SealCommit2(ctx, ffi.SectorRef{}, []byte("crash"))
+	assert.True(t, errors.Is(err, ErrWorkerCrashed), err)
+	assert.Equal(t, 1, strings.Count(err.Error(), ErrWorkerCrashed.Error()), err)
+
+	// not retried
+	_, err = s.SealPreCommit1(ctx, ffi.SectorRef{}, nil, nil)
+	assert.True(t, errors.Is(err, ErrWorkerCrashed), err)
+
+	out, err = s.SealCommit2(ctx, ffi.SectorRef{}, []byte("again"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("proof:again"), out)
+}
+
+func TestSupervisorRetry(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "crashed")
+	s := newTestSupervisor(t, crashMarkerEnv+"="+marker)
+
+	out, err := s.SealCommit2(context.Background(), ffi.SectorRef{}, []byte("c1o"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("proof:c1o"), out)
+	assert.FileExists(t, marker)
+}
@@ -0,0 +1,136 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package isolate
+
+import (
+	"context"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+)
+
+// serviceName is the net/rpc name of the service of the worker.
+const serviceName = "Proofs"
+
+// The argument and reply types of the calls of the worker, as sent over the
+// socket. They are exported for net/rpc.
+
+type PreCommit1Args struct {
+	Sector ffi.SectorRef
+	Ticket abi.SealRandomness
+	Pieces []abi.PieceInfo
+}
+
+type PreCommit2Args struct {
+	Sector       ffi.SectorRef
+	Phase1Output []byte
+}
+
+type Commit1Args struct {
+	Sector ffi.SectorRef
+	Ticket abi.SealRandomness
+	Seed   abi.InteractiveSealRandomness
+	Pieces []abi.PieceInfo
+	Cids   ffi.SectorCids
+}
+
+type Commit2Args struct {
+	Sector       ffi.SectorRef
+	Phase1Output []byte
+}
+
+type PoStArgs struct {
+	MinerID    abi.ActorID
+	Sectors    ffi.SortedPrivateSectorInfo
+	Randomness abi.PoStRandomness
+}
+
+type WindowPoStReply struct {
+	Proofs  []proof.PoStProof
+	Skipped []abi.SectorID
+}
+
+type PoStWithVanillaArgs struct {
+	ProofType  abi.RegisteredPoStProof
+	MinerID    abi.ActorID
+	Randomness abi.PoStRandomness
+	Proofs     [][]byte
+}
+
+type PartitionPoStArgs struct {
+	ProofType      abi.RegisteredPoStProof
+	MinerID        abi.ActorID
+	Randomness     abi.PoStRandomness
+	Proofs         [][]byte
+	PartitionIndex uint
+}
+
+// service serves the calls of a supervisor with api.
+type service struct {
+	api ffi.ProofsAPI
+}
+
+func (s *service) SealPreCommit1(args *PreCommit1Args, out *[]byte) (err error) {
+	*out, err = s.api.SealPreCommit1(context.Background(), args.Sector, args.Ticket, args.Pieces)
+	return err
+}
+
+func (s *service) SealPreCommit2(args *PreCommit2Args, out *ffi.SectorCids) (err error) {
+	*out, err = s.api.SealPreCommit2(context.Background(), args.Sector, args.Phase1Output)
+	return err
+}
+
+func (s *service) SealCommit1(args *Commit1Args, out *[]byte) (err error) {
+	*out, err = s.api.SealCommit1(context.Background(), args.Sector, args.Ticket, args.Seed, args.Pieces, args.Cids)
+	return err
+}
+
+func (s *service) SealCommit2(args *Commit2Args, out *[]byte) (err error) {
+	*out, err = s.api.SealCommit2(context.Background(), args.Sector, args.Phase1Output)
+	return err
+}
+
+func (s *service) GenerateWinningPoSt(args *PoStArgs, out *[]proof.PoStProof) (err error) {
+	*out, err = s.api.GenerateWinningPoSt(context.Background(), args.MinerID, args.Sectors, args.Randomness)
+	return err
+}
+
+func (s *service) GenerateWindowPoSt(args *PoStArgs, out *WindowPoStReply) (err error) {
+	out.Proofs, out.Skipped, err = s.api.GenerateWindowPoSt(context.Background(), args.MinerID, args.Sectors, args.Randomness)
+	return err
+}
+
+func (s *service) GenerateWinningPoStWithVanilla(args *PoStWithVanillaArgs, out *[]proof.PoStProof) (err error) {
+	*out, err = s.api.GenerateWinningPoStWithVanilla(context.Background(), args.ProofType, args.MinerID, args.Randomness, args.Proofs)
+	return err
+}
+
+func (s *service) GenerateWindowPoStWithVanilla(args *PoStWithVanillaArgs, out *[]proof.PoStProof) (err error) {
+	*out, err = s.api.GenerateWindowPoStWithVanilla(context.Background(), args.ProofType, args.MinerID, args.Randomness, args.Proofs)
+	return err
+}
+
+func (s *service) GenerateSinglePartitionWindowPoStWithVanilla(args *PartitionPoStArgs, out *ffi.PartitionProof) error {
+	pp, err := s.api.GenerateSinglePartitionWindowPoStWithVanilla(context.Background(), args.ProofType, args.MinerID, args.Randomness, args.Proofs, args.PartitionIndex)
+	if err != nil {
+		return err
+	}
+	*out = *pp
+	return nil
+}
+
+// serveConn serves the calls read from conn with api until conn is closed.
+// Calls are served concurrently.
+func serveConn(conn io.ReadWriteCloser, api ffi.ProofsAPI) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(serviceName, &service{api: api}); err != nil {
+		return err
+	}
+	srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
@@ -0,0 +1,221 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+)
+
+// JobStatus is the state of a job.
+type JobStatus int32
+
+const (
+	// JobQueued jobs wait for the package Scheduler to admit them.
+	JobQueued JobStatus = iota
+	// JobRunning jobs are in the native library.
+	JobRunning
+	// JobDone jobs succeeded.
+	JobDone
+	// JobFailed jobs returned an error.
+	JobFailed
+	// JobCanceled jobs were canceled, or their context was done, before they
+	// completed.
+	JobCanceled
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	case JobCanceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// The Submit functions start GPU heavy calls as jobs, which orchestrators can
+// poll, wait for and cancel. Jobs queued by the package Scheduler only hold a
+// parked goroutine; each running job holds an OS thread for its native call.
+// Canceling a running job resolves it right away, but the native call keeps
+// running until it completes, as with the Ctx variants.
+
+// job is the state common to all the jobs.
+type job struct {
+	cancel context.CancelFunc
+	status int32
+	done   chan struct{}
+	err    error
+}
+
+// startJob runs fn as a job. fn must make its call with the context it's
+// given, for the job to know when the call is admitted.
+func startJob(ctx context.Context, fn func(ctx context.Context) error) *job {
+	ctx, cancel := context.WithCancel(ctx)
+	j := &job{cancel: cancel, done: make(chan struct{})}
+
+	var once sync.Once
+	ctx = withAdmitted(ctx, func() {
+		once.Do(func() {
+			atomic.CompareAndSwapInt32(&j.status, int32(JobQueued), int32(JobRunning))
+		})
+	})
+
+	go func() {
+		defer cancel()
+
+		err := fn(ctx)
+		status := JobDone
+		switch {
+		case err != nil && ctx.Err() != nil && err == ctx.Err():
+			status = JobCanceled
+		case err != nil:
+			status = JobFailed
+		}
+
+		j.err = err
+		atomic.StoreInt32(&j.status, int32(status))
+		close(j.done)
+	}()
+
+	return j
+}
+
+// Status returns the current state of the job.
+func (j *job) Status() JobStatus {
+	return JobStatus(atomic.LoadInt32(&j.status))
+}
+
+// Done returns a channel closed once the job is resolved.
+func (j *job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Cancel cancels the job. It is a no-op once the job is resolved.
+func (j *job) Cancel() {
+	j.cancel()
+}
+
+// Err waits for the job and returns its error.
+func (j *job) Err() error {
+	<-j.done
+	return j.err
+}
+
+// SealPreCommit2Job is a SealPreCommitPhase2 job.
+type SealPreCommit2Job struct {
+	*job
+	sealedCID, unsealedCID cid.Cid
+}
+
+// Result waits for the job and returns its result.
+func (j *SealPreCommit2Job) Result() (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	if err := j.Err(); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	return j.sealedCID, j.unsealedCID, nil
+}
+
+// SubmitSealPreCommit2 starts SealPreCommitPhase2Ctx as a job.
+func SubmitSealPreCommit2(ctx context.Context, phase1Output []byte, cacheDirPath string, sealedSectorPath string) *SealPreCommit2Job {
+	j := &SealPreCommit2Job{}
+	j.job = startJob(ctx, func(ctx context.Context) (err error) {
+		j.sealedCID, j.unsealedCID, err = SealPreCommitPhase2Ctx(ctx, phase1Output, cacheDirPath, sealedSectorPath)
+		return err
+	})
+	return j
+}
+
+// ProofJob is a job producing a proof.
+type ProofJob struct {
+	*job
+	proof []byte
+}
+
+// Result waits for the job and returns its proof.
+func (j *ProofJob) Result() ([]byte, error) {
+	if err := j.Err(); err != nil {
+		return nil, err
+	}
+	return j.proof, nil
+}
+
+// SubmitSealCommit2 starts SealCommitPhase2Ctx as a job.
+func SubmitSealCommit2(ctx context.Context, phase1Output []byte, sectorNum abi.SectorNumber, minerID abi.ActorID) *ProofJob {
+	j := &ProofJob{}
+	j.job = startJob(ctx, func(ctx context.Context) (err error) {
+		j.proof, err = SealCommitPhase2Ctx(ctx, phase1Output, sectorNum, minerID)
+		return err
+	})
+	return j
+}
+
+// SubmitUpdateProof starts SectorUpdate.GenerateUpdateProofCtx as a job.
+func SubmitUpdateProof(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+) *ProofJob {
+	j := &ProofJob{}
+	j.job = startJob(ctx, func(ctx context.Context) (err error) {
+		j.proof, err = SectorUpdate.GenerateUpdateProofCtx(ctx, proofType, oldSealedCID, newSealedCID, unsealedCID, newReplicaPath, newReplicaCachePath, sectorKeyPath, sectorKeyCachePath)
+		return err
+	})
+	return j
+}
+
+// PoStJob is a PoSt generation job.
+type PoStJob struct {
+	*job
+	proofs []proof5.PoStProof
+	faulty []abi.SectorNumber
+}
+
+// Result waits for the job and returns its proofs. The faulty sectors of a
+// window PoSt are returned along with its error.
+func (j *PoStJob) Result() (proofs []proof5.PoStProof, faulty []abi.SectorNumber, err error) {
+	err = j.Err()
+	if err != nil {
+		return nil, j.faulty, err
+	}
+	return j.proofs, j.faulty, nil
+}
+
+// SubmitWinningPoSt starts GenerateWinningPoStCtx as a job.
+func SubmitWinningPoSt(ctx context.Context, minerID abi.ActorID, privateSectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) *PoStJob {
+	j := &PoStJob{}
+	j.job = startJob(ctx, func(ctx context.Context) (err error) {
+		j.proofs, err = GenerateWinningPoStCtx(ctx, minerID, privateSectorInfo, randomness)
+		return err
+	})
+	return j
+}
+
+// SubmitWindowPoSt starts GenerateWindowPoStCtx as a job.
+func SubmitWindowPoSt(ctx context.Context, minerID abi.ActorID, privateSectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) *PoStJob {
+	j := &PoStJob{}
+	j.job = startJob(ctx, func(ctx context.Context) (err error) {
+		j.proofs, j.faulty, err = GenerateWindowPoStCtx(ctx, minerID, privateSectorInfo, randomness)
+		return err
+	})
+	return j
+}
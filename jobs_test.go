@@ -0,0 +1,68 @@
+package ffi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	SetScheduler(NewScheduler(SchedulerConfig{MaxConcurrent: map[OpClass]int{OpSealCommit2: 1}}))
+	defer SetScheduler(nil)
+
+	blocker, err := admit(context.Background(), OpSealCommit2)
+	require.NoError(t, err)
+
+	unblock := make(chan struct{})
+	j := startJob(context.Background(), func(ctx context.Context) error {
+		release, err := admit(ctx, OpSealCommit2)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		<-unblock
+		return errors.New("proving failed")
+	})
+	assert.Equal(t, JobQueued, j.Status())
+
+	blocker()
+	require.Eventually(t, func() bool { return j.Status() == JobRunning }, time.Second, time.Millisecond)
+
+	close(unblock)
+	<-j.Done()
+	assert.Equal(t, JobFailed, j.Status())
+	assert.EqualError(t, j.Err(), "proving failed")
+}
+
+func TestJobCancel(t *testing.T) {
+	SetScheduler(NewScheduler(SchedulerConfig{MaxConcurrent: map[OpClass]int{OpWindowPoSt: 1}}))
+	defer SetScheduler(nil)
+
+	blocker, err := admit(context.Background(), OpWindowPoSt)
+	require.NoError(t, err)
+	defer blocker()
+
+	j := startJob(context.Background(), func(ctx context.Context) error {
+		release, err := admit(ctx, OpWindowPoSt)
+		if err != nil {
+			return err
+		}
+		release()
+		return nil
+	})
+
+	j.Cancel()
+	require.ErrorIs(t, j.Err(), context.Canceled)
+	assert.Equal(t, JobCanceled, j.Status())
+	j.Cancel()
+
+	done := startJob(context.Background(), func(context.Context) error { return nil })
+	require.NoError(t, done.Err())
+	assert.Equal(t, JobDone, done.Status())
+	assert.Equal(t, "done", done.Status().String())
+}
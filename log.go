@@ -0,0 +1,16 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// InitLogFd makes the native library write its logs to fd, of which it takes
+// ownership, instead of stderr. It must be called before any other call into
+// the library, which initializes the logger on first use; it fails once the
+// logger is initialized.
+func InitLogFd(fd int32) error {
+	return cgo.InitLogFd(fd)
+}
@@ -0,0 +1,138 @@
+//go:build (cgo || ffimock) && go1.21
+// +build cgo ffimock
+// +build go1.21
+
+package ffi
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+var (
+	nativeLogger     atomic.Pointer[slog.Logger]
+	nativeLoggerOnce sync.Once
+	nativeLoggerErr  error
+)
+
+// SetLogger routes the logs of the native library to logger, each line of
+// the library becoming a record at the corresponding level with the Rust
+// module as "module" attribute. The first call sets up the route through
+// InitLogFd, so it has the same constraints; later calls only replace the
+// logger.
+func SetLogger(logger *slog.Logger) error {
+	nativeLogger.Store(logger)
+
+	nativeLoggerOnce.Do(func() {
+		nativeLoggerErr = initLogPipe()
+	})
+	return nativeLoggerErr
+}
+
+func initLogPipe() error {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	// the library owns its copy of the write end for the life of the process
+	fd, err := syscall.Dup(int(pw.Fd()))
+	_ = pw.Close()
+	if err != nil {
+		_ = pr.Close()
+		return err
+	}
+
+	if err := InitLogFd(int32(fd)); err != nil {
+		_ = syscall.Close(fd)
+		_ = pr.Close()
+		return err
+	}
+
+	go forwardNativeLogs(pr)
+	return nil
+}
+
+// maxNativeLogLine is the length past which the lines of the native library
+// are truncated.
+const maxNativeLogLine = 1 << 20
+
+// forwardNativeLogs logs the lines read from r until its end. It never stops
+// reading before then, as the native library blocks on a full pipe.
+func forwardNativeLogs(r io.Reader) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	var line []byte
+	for {
+		frag, isPrefix, err := br.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				_, _ = io.Copy(ioutil.Discard, r)
+			}
+			return
+		}
+		if room := maxNativeLogLine - len(line); room > 0 {
+			if len(frag) > room {
+				frag = frag[:room]
+			}
+			line = append(line, frag...)
+		}
+		if isPrefix {
+			continue
+		}
+
+		logNativeLine(string(line))
+		line = line[:0]
+	}
+}
+
+func logNativeLine(line string) {
+	logger := nativeLogger.Load()
+	if logger == nil {
+		return
+	}
+
+	level, module, msg := parseNativeLogLine(line)
+	if module != "" {
+		logger.Log(context.Background(), level, msg, slog.String("module", module))
+	} else {
+		logger.Log(context.Background(), level, msg)
+	}
+}
+
+// LevelTrace is the level of the trace logs of the native library.
+const LevelTrace = slog.LevelDebug - 4
+
+var (
+	// nativeLogLine matches "<time> <level> <module> > <message>".
+	nativeLogLine = regexp.MustCompile(`^\S+\s+(ERROR|WARN|INFO|DEBUG|TRACE)\s+(\S+)\s+>\s?(.*)$`)
+	ansiEscape    = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+var nativeLogLevels = map[string]slog.Level{
+	"ERROR": slog.LevelError,
+	"WARN":  slog.LevelWarn,
+	"INFO":  slog.LevelInfo,
+	"DEBUG": slog.LevelDebug,
+	"TRACE": LevelTrace,
+}
+
+// parseNativeLogLine splits a log line of the native library. Lines which
+// aren't in the logger format, such as continuations, are logged at the info
+// level.
+func parseNativeLogLine(line string) (slog.Level, string, string) {
+	line = ansiEscape.ReplaceAllString(line, "")
+
+	m := nativeLogLine.FindStringSubmatch(line)
+	if m == nil {
+		return slog.LevelInfo, "", line
+	}
+	return nativeLogLevels[m[1]], m[2], m[3]
+}
@@ -0,0 +1,69 @@
+//go:build go1.21
+// +build go1.21
+
+package ffi
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNativeLogLine(t *testing.T) {
+	level, module, msg := parseNativeLogLine("2022-03-01T10:00:00.123 INFO filecoin_proofs::api::seal > seal_pre_commit_phase1:start")
+	assert.Equal(t, slog.LevelInfo, level)
+	assert.Equal(t, "filecoin_proofs::api::seal", module)
+	assert.Equal(t, "seal_pre_commit_phase1:start", msg)
+
+	level, module, msg = parseNativeLogLine("\x1b[33m2022-03-01T10:00:00.123 WARN \x1b[0m storage_proofs_core::parameter_cache > parameter set is bigger than expected")
+	assert.Equal(t, slog.LevelWarn, level)
+	assert.Equal(t, "storage_proofs_core::parameter_cache", module)
+	assert.Equal(t, "parameter set is bigger than expected", msg)
+
+	level, _, _ = parseNativeLogLine("2022-03-01T10:00:00.123 TRACE bellperson::gpu > kernel")
+	assert.Equal(t, LevelTrace, level)
+
+	level, module, msg = parseNativeLogLine("   0: backtrace frame")
+	assert.Equal(t, slog.LevelInfo, level)
+	assert.Empty(t, module)
+	assert.Equal(t, "   0: backtrace frame", msg)
+}
+
+func TestForwardNativeLogsLongLine(t *testing.T) {
+	var records []string
+	handler := &recordingHandler{records: &records}
+	nativeLogger.Store(slog.New(handler))
+	t.Cleanup(func() { nativeLogger.Store(nil) })
+
+	pr, pw, err := os.Pipe()
+	require.NoError(t, err)
+	go func() {
+		_, _ = pw.Write(bytes.Repeat([]byte("x"), 3<<20))
+		_, _ = pw.Write([]byte("\n2022-03-01T10:00:00.123 INFO filecoin_proofs::api > after\n"))
+		_ = pw.Close()
+	}()
+
+	// returns once the write end is closed, having read everything
+	forwardNativeLogs(pr)
+
+	require.Len(t, records, 2)
+	assert.Len(t, records[0], maxNativeLogLine)
+	assert.Equal(t, "after", records[1])
+}
+
+type recordingHandler struct {
+	slog.Handler
+	records *[]string
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	*h.records = append(*h.records, r.Message)
+	return nil
+}
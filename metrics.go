@@ -0,0 +1,128 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// callMetrics are the collectors of the native calls, labelled by call name.
+type callMetrics struct {
+	calls         *prometheus.CounterVec
+	failures      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	returnedBytes *prometheus.HistogramVec
+	gpuSeconds    *prometheus.CounterVec
+}
+
+func newCallMetrics() *callMetrics {
+	return &callMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ffi",
+			Name:      "calls_total",
+			Help:      "Number of native calls.",
+		}, []string{"call", "proof_type"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ffi",
+			Name:      "call_failures_total",
+			Help:      "Number of failed native calls, by response status.",
+		}, []string{"call", "proof_type", "status"}),
+		// From a millisecond for the verifications to hours for PC1.
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ffi",
+			Name:      "call_duration_seconds",
+			Help:      "Duration of the native calls.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 14),
+		}, []string{"call", "proof_type"}),
+		returnedBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ffi",
+			Name:      "call_returned_bytes",
+			Help:      "Size of the proofs and outputs returned by the native calls.",
+			Buckets:   prometheus.ExponentialBuckets(32, 4, 12),
+		}, []string{"call", "proof_type"}),
+		gpuSeconds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ffi",
+			Name:      "gpu_seconds_total",
+			Help:      "Time the GPU heavy calls held their GPU, by class and GPU slot.",
+		}, []string{"class", "gpu"}),
+	}
+}
+
+func (m *callMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.calls, m.failures, m.duration, m.returnedBytes, m.gpuSeconds}
+}
+
+func (m *callMetrics) gpuMeter(usage GPUUsage) {
+	m.gpuSeconds.WithLabelValues(usage.Class.String(), strconv.Itoa(usage.GPU)).Add(usage.Duration.Seconds())
+}
+
+func (m *callMetrics) hook(call cgo.Call) func(cgo.CallResult) {
+	return func(res cgo.CallResult) {
+		m.calls.WithLabelValues(call.Name, call.ProofType).Inc()
+		m.duration.WithLabelValues(call.Name, call.ProofType).Observe(res.Duration.Seconds())
+		if res.Err != nil {
+			m.failures.WithLabelValues(call.Name, call.ProofType, callStatus(res.Err)).Inc()
+		} else if res.ReturnedBytes > 0 {
+			m.returnedBytes.WithLabelValues(call.Name, call.ProofType).Observe(float64(res.ReturnedBytes))
+		}
+	}
+}
+
+var (
+	metricsLk     sync.Mutex
+	removeMetrics func()
+)
+
+// RegisterMetrics registers with reg the metrics of the native calls returning
+// an error: calls, failures by response status, duration and size of the
+// returned proofs, labelled by native function and proof type, and the GPU
+// time of the GPU heavy calls, see GPUUsage, by class and GPU slot. The
+// metrics are registered with a single registry at a time; unregister removes
+// them and stops collecting.
+func RegisterMetrics(reg prometheus.Registerer) (unregister func(), err error) {
+	metricsLk.Lock()
+	defer metricsLk.Unlock()
+
+	if removeMetrics != nil {
+		return nil, errors.New("ffi metrics are already registered")
+	}
+
+	m := newCallMetrics()
+	collectors := m.collectors()
+	for i, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			for _, r := range collectors[:i] {
+				reg.Unregister(r)
+			}
+			return nil, errors.Wrap(err, "registering ffi metrics")
+		}
+	}
+
+	removeHook := cgo.AddCallHook(m.hook)
+	removeMeter := AddGPUMeter(m.gpuMeter)
+	removeMetrics = func() {
+		removeHook()
+		removeMeter()
+		for _, c := range collectors {
+			reg.Unregister(c)
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			metricsLk.Lock()
+			defer metricsLk.Unlock()
+
+			removeMetrics()
+			removeMetrics = nil
+		})
+	}, nil
+}
@@ -0,0 +1,50 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+func TestCallMetrics(t *testing.T) {
+	m := newCallMetrics()
+
+	call := cgo.Call{Name: "seal_commit_phase2", ProofType: "StackedDrg32GiBV1_1"}
+	m.hook(call)(cgo.CallResult{Duration: 20 * time.Minute, ReturnedBytes: 1920})
+	m.hook(call)(cgo.CallResult{Err: errors.Wrap(ErrInvalidInput, "bad"), Duration: time.Millisecond})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.calls.WithLabelValues(call.Name, call.ProofType)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.failures.WithLabelValues(call.Name, call.ProofType, "invalid_input")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.duration))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.returnedBytes))
+
+	m.gpuMeter(GPUUsage{Class: OpSealCommit2, GPU: 1, Duration: 90 * time.Second})
+	m.gpuMeter(GPUUsage{Class: OpSealCommit2, GPU: 1, Duration: 30 * time.Second})
+	assert.Equal(t, 120.0, testutil.ToFloat64(m.gpuSeconds.WithLabelValues("SealCommit2", "1")))
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	unregister, err := RegisterMetrics(reg)
+	require.NoError(t, err)
+
+	_, err = RegisterMetrics(prometheus.NewRegistry())
+	require.Error(t, err)
+
+	unregister()
+	unregister()
+
+	unregister, err = RegisterMetrics(reg)
+	require.NoError(t, err)
+	unregister()
+}
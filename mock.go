@@ -0,0 +1,179 @@
+//go:build ffimock
+// +build ffimock
+
+package ffi
+
+// Building with the ffimock tag replaces the bindings of the native library
+// with pure Go stand-ins, for testing code using this package on machines
+// without the library, a GPU or the proof parameters. Nothing is linked and
+// every call returns at once:
+//
+//   - piece and unsealed sector commitments are the real ones, and staged and
+//     sealed sector files hold the fr32 padded data, so that unsealing
+//     returns the data added;
+//   - sealed CIDs, seal, PoSt and update proofs are hashes of their public
+//     inputs, so that verification accepts exactly the proofs generated for
+//     the same inputs;
+//   - BLS keys and signatures are hashes too, aggregated by XOR: they are
+//     deterministic and verify as real ones do, but sign nothing securely;
+//   - the FVM executes nothing.
+//
+// There are no native calls to observe: RegisterMetrics, SetTracerProvider,
+// RegisterHooks, AddCallReporter, SetProfilerLabels, SetCallLogger and
+// TrackActiveCalls record nothing, ActiveCalls returns none, and
+// StartWatchdog is not available.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"os"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/internal/proofparams"
+)
+
+// Sentinel errors of the proof calls, to be tested with errors.Is.
+var (
+	// ErrInvalidInput is returned for arguments rejected by the mock, such as
+	// unsupported proof types.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrCallerError is never returned by the mock.
+	ErrCallerError = errors.New("caller error")
+	// ErrReceiverError is never returned by the mock.
+	ErrReceiverError = errors.New("receiver error")
+	// ErrPanic is never returned by the mock.
+	ErrPanic = errors.New("native panic")
+)
+
+// TracerName is the instrumentation name of the tracer of the native calls.
+const TracerName = "github.com/filecoin-project/filecoin-ffi"
+
+// InitLogFd closes fd: the mock logs nothing.
+func InitLogFd(fd int32) error {
+	return os.NewFile(uintptr(fd), "log").Close()
+}
+
+// RegisterMetrics registers nothing: there are no native calls to measure.
+func RegisterMetrics(reg prometheus.Registerer) (unregister func(), err error) {
+	return func() {}, nil
+}
+
+// SetTracerProvider does nothing: there are no native calls to trace.
+func SetTracerProvider(tp trace.TracerProvider) {}
+
+// RegisterHooks never calls h: there are no native calls.
+func RegisterHooks(h Hooks) (unregister func()) {
+	return func() {}
+}
+
+// AddCallReporter never calls report: there are no native calls.
+func AddCallReporter(report func(CallReport)) (remove func()) {
+	return func() {}
+}
+
+// SetProfilerLabels does nothing: there are no native calls to label.
+func SetProfilerLabels(enabled bool) {}
+
+func withCallerCtx(_ context.Context, fn func() error) error {
+	return fn()
+}
+
+// TrackActiveCalls does nothing: there are no native calls to track.
+func TrackActiveCalls() (stop func()) {
+	return func() {}
+}
+
+// ActiveCalls returns nil: there are no native calls.
+func ActiveCalls() []ActiveCall {
+	return nil
+}
+
+// mockSizeInfo returns the parameters of the proofs of sectors of size, those
+// of the native library.
+func mockSizeInfo(size abi.SectorSize) proofparams.SectorSizeInfo {
+	if info, ok := proofparams.ForSectorSize(uint64(size)); ok {
+		return *info
+	}
+	return proofparams.SectorSizeInfo{}
+}
+
+func mockSealInfo(p abi.RegisteredSealProof) (abi.SectorSize, proofparams.SectorSizeInfo, error) {
+	size, err := p.SectorSize()
+	if err != nil {
+		return 0, proofparams.SectorSizeInfo{}, xerrors.Errorf("%s: %w", err, ErrInvalidInput)
+	}
+	return size, mockSizeInfo(size), nil
+}
+
+func mockPoStInfo(p abi.RegisteredPoStProof) (proofparams.SectorSizeInfo, error) {
+	size, err := p.SectorSize()
+	if err != nil {
+		return proofparams.SectorSizeInfo{}, xerrors.Errorf("%s: %w", err, ErrInvalidInput)
+	}
+	return mockSizeInfo(size), nil
+}
+
+func mockUpdateInfo(p abi.RegisteredUpdateProof) (abi.SectorSize, proofparams.SectorSizeInfo, error) {
+	for _, info := range abi.SealProofInfos {
+		if info.UpdateProof == p {
+			return info.SectorSize, mockSizeInfo(info.SectorSize), nil
+		}
+	}
+	return 0, proofparams.SectorSizeInfo{}, xerrors.Errorf("unsupported update proof %d: %w", p, ErrInvalidInput)
+}
+
+func mockIsWinning(p abi.RegisteredPoStProof) bool {
+	switch p {
+	case abi.RegisteredPoStProof_StackedDrgWinning2KiBV1,
+		abi.RegisteredPoStProof_StackedDrgWinning8MiBV1,
+		abi.RegisteredPoStProof_StackedDrgWinning512MiBV1,
+		abi.RegisteredPoStProof_StackedDrgWinning32GiBV1,
+		abi.RegisteredPoStProof_StackedDrgWinning64GiBV1:
+		return true
+	default:
+		return false
+	}
+}
+
+// mockHash returns the SHA-256 of the length-prefixed domain and parts.
+func mockHash(domain string, parts ...[]byte) [32]byte {
+	h := sha256.New()
+	for _, p := range append([][]byte{[]byte(domain)}, parts...) {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(p)))
+		h.Write(size[:])
+		h.Write(p)
+	}
+
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// mockExpand stretches seed to n bytes.
+func mockExpand(seed [32]byte, n int) []byte {
+	out := make([]byte, 0, n+len(seed))
+	for i := uint64(0); len(out) < n; i++ {
+		block := mockHash("expand", seed[:], u64(i))
+		out = append(out, block[:]...)
+	}
+	return out[:n]
+}
+
+// mockCommitment makes a field element of h, as the commitments are.
+func mockCommitment(h [32]byte) []byte {
+	h[31] &= 0x3f
+	return h[:]
+}
+
+func u64(v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return b[:]
+}
@@ -0,0 +1,156 @@
+//go:build ffimock
+// +build ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"crypto/rand"
+)
+
+// The mock signature of a digest by a public key is a hash of both, and the
+// aggregate of signatures is their XOR: Verify holds for the aggregate of the
+// signatures of distinct messages, as it does with BLS.
+
+// Hash computes the digest of a message
+func Hash(message Message) Digest {
+	var digest Digest
+	copy(digest[:], mockExpand(mockHash("bls_hash", message), DigestBytes))
+	return digest
+}
+
+// Verify verifies that a signature is the aggregated signature of digests - pubkeys
+func Verify(signature *Signature, digests []Digest, publicKeys []PublicKey) bool {
+	if signature == nil || len(digests) == 0 || len(digests) != len(publicKeys) {
+		return false
+	}
+
+	var expected Signature
+	for i := range digests {
+		sig := mockSignature(publicKeys[i], digests[i])
+		mockXor(&expected, &sig)
+	}
+	return bytes.Equal(signature[:], expected[:])
+}
+
+// HashVerify verifies that a signature is the aggregated signature of hashed messages.
+func HashVerify(signature *Signature, messages []Message, publicKeys []PublicKey) bool {
+	digests := make([]Digest, len(messages))
+	for i, m := range messages {
+		digests[i] = Hash(m)
+	}
+	return Verify(signature, digests, publicKeys)
+}
+
+// BatchVerify verifies a batch of independent signatures, where signatures[i]
+// is the signature of messages[i] by publicKeys[i]. It returns true only if
+// every signature is valid.
+func BatchVerify(signatures []Signature, messages []Message, publicKeys []PublicKey) bool {
+	if len(signatures) != len(messages) || len(signatures) != len(publicKeys) {
+		return false
+	}
+
+	for i := range signatures {
+		if !HashVerify(&signatures[i], messages[i:i+1], publicKeys[i:i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Aggregate aggregates signatures together into a new signature. It returns
+// nil when there is no signature, as the native library does.
+func Aggregate(signatures []Signature) *Signature {
+	if len(signatures) == 0 {
+		return nil
+	}
+
+	var out Signature
+	for i := range signatures {
+		mockXor(&out, &signatures[i])
+	}
+	return &out
+}
+
+// AggregatePublicKeys aggregates public keys together into a new public key.
+// The mock aggregate is the XOR of the keys, which the mock signatures don't
+// verify against. It returns nil when there is no key, or a key is the zero
+// key.
+func AggregatePublicKeys(publicKeys []PublicKey) *PublicKey {
+	if len(publicKeys) == 0 {
+		return nil
+	}
+
+	var out PublicKey
+	for i := range publicKeys {
+		if PublicKeyStatus(publicKeys[i]) != PointValid {
+			return nil
+		}
+		for j := range out {
+			out[j] ^= publicKeys[i][j]
+		}
+	}
+	return &out
+}
+
+// PrivateKeyGenerate generates a private key
+func PrivateKeyGenerate() PrivateKey {
+	var seed PrivateKeyGenSeed
+	if _, err := rand.Read(seed[:]); err != nil {
+		return PrivateKey{}
+	}
+	return PrivateKeyGenerateWithSeed(seed)
+}
+
+// PrivateKeyGenerate generates a private key in a predictable manner.
+func PrivateKeyGenerateWithSeed(seed PrivateKeyGenSeed) PrivateKey {
+	return mockHash("bls_private_key", seed[:])
+}
+
+// PrivateKeySign signs a message
+func PrivateKeySign(privateKey PrivateKey, message Message) *Signature {
+	sig := mockSignature(*PrivateKeyPublicKey(privateKey), Hash(message))
+	return &sig
+}
+
+// PrivateKeyPublicKey gets the public key for a private key
+func PrivateKeyPublicKey(privateKey PrivateKey) *PublicKey {
+	var pk PublicKey
+	copy(pk[:], mockExpand(mockHash("bls_public_key", privateKey[:]), PublicKeyBytes))
+	return &pk
+}
+
+// CreateZeroSignature creates a zero signature, used as placeholder in filecoin.
+func CreateZeroSignature() Signature {
+	return Signature{}
+}
+
+// PublicKeyStatus decodes a public key the way HashVerify does. The mock has
+// no curve: the zero key stands for the identity, and the others are valid.
+func PublicKeyStatus(publicKey PublicKey) PointStatus {
+	if publicKey == (PublicKey{}) {
+		return PointIdentity
+	}
+	return PointValid
+}
+
+// SignatureStatus decodes a signature the way HashVerify does. The zero
+// signature is the identity, and the others are valid.
+func SignatureStatus(signature Signature) PointStatus {
+	if signature == CreateZeroSignature() {
+		return PointIdentity
+	}
+	return PointValid
+}
+
+func mockSignature(publicKey PublicKey, digest Digest) Signature {
+	var sig Signature
+	copy(sig[:], mockExpand(mockHash("bls_signature", publicKey[:], digest[:]), SignatureBytes))
+	return sig
+}
+
+func mockXor(dst, src *Signature) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
@@ -0,0 +1,9 @@
+//go:build ffimock && go1.21
+// +build ffimock,go1.21
+
+package ffi
+
+import "log/slog"
+
+// SetCallLogger does nothing: there are no native calls to log.
+func SetCallLogger(logger *slog.Logger) {}
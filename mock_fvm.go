@@ -0,0 +1,77 @@
+//go:build ffimock && (amd64 || arm64 || riscv64)
+// +build ffimock
+// +build amd64 arm64 riscv64
+
+package ffi
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/network"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// FVM is a machine executing nothing: its state root stays the one it was
+// created with.
+type FVM struct {
+	stateRoot cid.Cid
+}
+
+type FVMOpts struct {
+	FVMVersion uint64
+	// Externs is unused by the mock: it takes the cgo.Externs of the native
+	// build.
+	Externs interface{}
+
+	Epoch          abi.ChainEpoch
+	BaseFee        abi.TokenAmount
+	BaseCircSupply abi.TokenAmount
+	NetworkVersion network.Version
+	StateBase      cid.Cid
+	Manifest       cid.Cid
+	Tracing        bool
+}
+
+// CreateFVM creates a new FVM instance.
+func CreateFVM(opts *FVMOpts) (*FVM, error) {
+	if _, _, err := splitBigInt(opts.BaseFee); err != nil {
+		return nil, xerrors.Errorf("invalid basefee: %w", err)
+	}
+	if _, _, err := splitBigInt(opts.BaseCircSupply); err != nil {
+		return nil, xerrors.Errorf("invalid circ supply: %w", err)
+	}
+
+	return &FVM{stateRoot: opts.StateBase}, nil
+}
+
+// ApplyMessage returns a successful receipt using no gas.
+func (f *FVM) ApplyMessage(msgBytes []byte, chainLen uint) (*ApplyRet, error) {
+	return mockApplyRet(), nil
+}
+
+// ApplyImplicitMessage returns a successful receipt using no gas.
+func (f *FVM) ApplyImplicitMessage(msgBytes []byte) (*ApplyRet, error) {
+	return mockApplyRet(), nil
+}
+
+func (f *FVM) Flush() (cid.Cid, error) {
+	return f.stateRoot, nil
+}
+
+type ApplyRet struct {
+	Return         []byte
+	ExitCode       uint64
+	GasUsed        int64
+	MinerPenalty   abi.TokenAmount
+	MinerTip       abi.TokenAmount
+	ExecTraceBytes []byte
+	FailureInfo    string
+}
+
+func mockApplyRet() *ApplyRet {
+	return &ApplyRet{
+		MinerPenalty: big.Zero(),
+		MinerTip:     big.Zero(),
+	}
+}
@@ -0,0 +1,493 @@
+//go:build ffimock
+// +build ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/internal/proofparams"
+)
+
+type FallbackChallenges struct {
+	Sectors    []abi.SectorNumber
+	Challenges map[abi.SectorNumber][]uint64
+}
+
+type PartitionProof proof.PoStProof
+
+// mockVanillaProof is a vanilla proof of the mock GenerateSingleVanillaProof.
+type mockVanillaProof struct {
+	PoStProof    abi.RegisteredPoStProof
+	SectorNumber abi.SectorNumber
+	SealedCID    cid.Cid
+}
+
+// mockPoStSector is a proven sector.
+type mockPoStSector struct {
+	number    abi.SectorNumber
+	sealedCID cid.Cid
+}
+
+// VerifyWinningPoSt returns true if the proof is the one generated by the
+// mock for the challenged sectors.
+func VerifyWinningPoSt(info proof.WinningPoStVerifyInfo) (bool, error) {
+	if isFakePoSt(info.Proofs) {
+		return VerifyFakeWinningPoSt(info)
+	}
+
+	if len(info.Proofs) != 1 {
+		return false, xerrors.Errorf("%d winning PoSt proofs, 1 expected: %w", len(info.Proofs), ErrInvalidInput)
+	}
+
+	expected, err := mockPartitionProof(info.Proofs[0].PoStProof, info.Prover, info.Randomness, 0, mockPublicSectors(info.ChallengedSectors))
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(info.Proofs[0].ProofBytes, expected), nil
+}
+
+// VerifyWindowPoSt returns true if the proof is the one generated by the mock
+// for the challenged sectors.
+func VerifyWindowPoSt(info proof.WindowPoStVerifyInfo) (bool, error) {
+	if isFakePoSt(info.Proofs) {
+		return VerifyFakeWindowPoSt(info)
+	}
+
+	if len(info.Proofs) != 1 {
+		return false, xerrors.Errorf("%d window PoSt proofs, 1 expected: %w", len(info.Proofs), ErrInvalidInput)
+	}
+
+	expected, err := mockWindowProof(info.Proofs[0].PoStProof, info.Prover, info.Randomness, mockPublicSectors(info.ChallengedSectors))
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(info.Proofs[0].ProofBytes, expected.ProofBytes), nil
+}
+
+// VerifyWindowPoSts verifies the PoSts with VerifyWindowPoSt.
+func VerifyWindowPoSts(infos []proof.WindowPoStVerifyInfo) ([]bool, error) {
+	out := make([]bool, len(infos))
+	for i, info := range infos {
+		ok, err := VerifyWindowPoSt(info)
+		if err != nil {
+			return nil, xerrors.Errorf("window PoSt %d: %w", i, err)
+		}
+		out[i] = ok
+	}
+	return out, nil
+}
+
+// GenerateWinningPoStSectorChallenge picks the index of the sector to prove.
+func GenerateWinningPoStSectorChallenge(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	eligibleSectorsLen uint64,
+) ([]uint64, error) {
+	if _, err := mockPoStInfo(proofType); err != nil {
+		return nil, err
+	}
+	if eligibleSectorsLen == 0 {
+		return nil, xerrors.Errorf("no eligible sector: %w", ErrInvalidInput)
+	}
+
+	challenges := make([]uint64, proofparams.WinningPoStSectorCount)
+	for i := range challenges {
+		h := mockHash("winning_challenge", u64(uint64(proofType)), u64(uint64(minerID)), randomness, u64(uint64(i)))
+		challenges[i] = binary.BigEndian.Uint64(h[:8]) % eligibleSectorsLen
+	}
+	return challenges, nil
+}
+
+// GenerateWinningPoSt proves the sectors in a single partition.
+func GenerateWinningPoSt(
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof.PoStProof, error) {
+	return generateWinningPoSt(context.Background(), minerID, privateSectorInfo, randomness)
+}
+
+func generateWinningPoSt(
+	ctx context.Context,
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof.PoStProof, error) {
+	release, err := admit(ctx, OpWinningPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	proofType, sectors, err := mockPrivateSectors(privateSectorInfo.Values())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create private replica info array for FFI")
+	}
+
+	proofBytes, err := mockPartitionProof(proofType, minerID, randomness, 0, sectors)
+	if err != nil {
+		return nil, err
+	}
+	return []proof.PoStProof{{PoStProof: proofType, ProofBytes: proofBytes}}, nil
+}
+
+// GenerateWindowPoSt proves the sectors, returning as faulty those of which
+// the sealed sector file is missing.
+func GenerateWindowPoSt(
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof.PoStProof, []abi.SectorNumber, error) {
+	return generateWindowPoSt(context.Background(), minerID, privateSectorInfo, randomness)
+}
+
+func generateWindowPoSt(
+	ctx context.Context,
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof.PoStProof, []abi.SectorNumber, error) {
+	release, err := admit(ctx, OpWindowPoSt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	var faulty []abi.SectorNumber
+	for _, s := range privateSectorInfo.Values() {
+		if s.SealedSectorPath == "" {
+			continue
+		}
+		if _, err := os.Stat(s.SealedSectorPath); err != nil {
+			faulty = append(faulty, s.SectorNumber)
+		}
+	}
+	if len(faulty) > 0 {
+		return nil, faulty, xerrors.Errorf("%d faulty sectors", len(faulty))
+	}
+
+	proofType, sectors, err := mockPrivateSectors(privateSectorInfo.Values())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create private replica info array for FFI")
+	}
+
+	p, err := mockWindowProof(proofType, minerID, randomness, sectors)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []proof.PoStProof{p}, nil, nil
+}
+
+// GetNumPartitionForFallbackPost returns the number of partitions proving
+// numSectors sectors.
+func GetNumPartitionForFallbackPost(proofType abi.RegisteredPoStProof, numSectors uint) (uint, error) {
+	partitionSectors, err := mockPartitionSectors(proofType)
+	if err != nil {
+		return 0, err
+	}
+	return (numSectors + uint(partitionSectors) - 1) / uint(partitionSectors), nil
+}
+
+// GeneratePoStFallbackSectorChallenges picks the challenges of every sector.
+func GeneratePoStFallbackSectorChallenges(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	sectorIds []abi.SectorNumber,
+) (*FallbackChallenges, error) {
+	if _, err := mockPoStInfo(proofType); err != nil {
+		return nil, err
+	}
+
+	count := proofparams.WindowPoStChallengeCount
+	if mockIsWinning(proofType) {
+		count = proofparams.WinningPoStChallengeCount
+	}
+
+	out := FallbackChallenges{
+		Sectors:    make([]abi.SectorNumber, len(sectorIds)),
+		Challenges: make(map[abi.SectorNumber][]uint64),
+	}
+	for idx, secNum := range sectorIds {
+		challenges := make([]uint64, count)
+		for i := range challenges {
+			h := mockHash("fallback_challenge", u64(uint64(proofType)), u64(uint64(minerID)), randomness,
+				u64(uint64(secNum)), u64(uint64(i)))
+			challenges[i] = binary.BigEndian.Uint64(h[:8])
+		}
+		out.Sectors[idx] = secNum
+		out.Challenges[secNum] = challenges
+	}
+
+	return &out, nil
+}
+
+// GenerateSingleVanillaProof returns a vanilla proof identifying the sector.
+func GenerateSingleVanillaProof(
+	replica PrivateSectorInfo,
+	challenges []uint64,
+) ([]byte, error) {
+	if _, err := mockPoStInfo(replica.PoStProofType); err != nil {
+		return nil, err
+	}
+	if replica.SealedSectorPath != "" {
+		if _, err := os.Stat(replica.SealedSectorPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(mockVanillaProof{
+		PoStProof:    replica.PoStProofType,
+		SectorNumber: replica.SectorNumber,
+		SealedCID:    replica.SealedCID,
+	})
+}
+
+// GenerateWinningPoStWithVanilla proves the sectors of the vanilla proofs in a
+// single partition, as GenerateWinningPoSt does.
+func GenerateWinningPoStWithVanilla(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof.PoStProof, error) {
+	return generateWinningPoStWithVanilla(context.Background(), proofType, minerID, randomness, proofs)
+}
+
+func generateWinningPoStWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof.PoStProof, error) {
+	release, err := admit(ctx, OpWinningPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	sectors, err := mockVanillaSectors(proofType, proofs)
+	if err != nil {
+		return nil, err
+	}
+
+	proofBytes, err := mockPartitionProof(proofType, minerID, randomness, 0, sectors)
+	if err != nil {
+		return nil, err
+	}
+	return []proof.PoStProof{{PoStProof: proofType, ProofBytes: proofBytes}}, nil
+}
+
+// GenerateWindowPoStWithVanilla proves the sectors of the vanilla proofs, as
+// GenerateWindowPoSt does.
+func GenerateWindowPoStWithVanilla(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof.PoStProof, error) {
+	return generateWindowPoStWithVanilla(context.Background(), proofType, minerID, randomness, proofs)
+}
+
+func generateWindowPoStWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+) ([]proof.PoStProof, error) {
+	release, err := admit(ctx, OpWindowPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	sectors, err := mockVanillaSectors(proofType, proofs)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := mockWindowProof(proofType, minerID, randomness, sectors)
+	if err != nil {
+		return nil, err
+	}
+	return []proof.PoStProof{p}, nil
+}
+
+// GenerateSinglePartitionWindowPoStWithVanilla proves the sectors of the
+// vanilla proofs as the partition partitionIndex.
+func GenerateSinglePartitionWindowPoStWithVanilla(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+	partitionIndex uint,
+) (*PartitionProof, error) {
+	return generateSinglePartitionWindowPoStWithVanilla(context.Background(), proofType, minerID, randomness, proofs, partitionIndex)
+}
+
+func generateSinglePartitionWindowPoStWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	proofs [][]byte,
+	partitionIndex uint,
+) (*PartitionProof, error) {
+	release, err := admit(ctx, OpWindowPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	sectors, err := mockVanillaSectors(proofType, proofs)
+	if err != nil {
+		return nil, err
+	}
+
+	proofBytes, err := mockPartitionProof(proofType, minerID, randomness, partitionIndex, sectors)
+	if err != nil {
+		return nil, err
+	}
+	return &PartitionProof{PoStProof: proofType, ProofBytes: proofBytes}, nil
+}
+
+// MergeWindowPoStPartitionProofs concatenates the partition proofs, in order.
+func MergeWindowPoStPartitionProofs(
+	proofType abi.RegisteredPoStProof,
+	partitionProofs []PartitionProof,
+) (*proof.PoStProof, error) {
+	if _, err := mockPoStInfo(proofType); err != nil {
+		return nil, err
+	}
+
+	out := proof.PoStProof{PoStProof: proofType}
+	for _, p := range partitionProofs {
+		if p.PoStProof != proofType {
+			return nil, xerrors.Errorf("partition proof of type %d, %d expected: %w", p.PoStProof, proofType, ErrInvalidInput)
+		}
+		out.ProofBytes = append(out.ProofBytes, p.ProofBytes...)
+	}
+
+	return &out, nil
+}
+
+func mockPartitionSectors(proofType abi.RegisteredPoStProof) (uint64, error) {
+	info, err := mockPoStInfo(proofType)
+	if err != nil {
+		return 0, err
+	}
+	if mockIsWinning(proofType) {
+		return proofparams.WinningPoStSectorCount, nil
+	}
+	return info.WindowPoStSectors, nil
+}
+
+// mockWindowProof proves the sectors, sorted by number, in partitions.
+func mockWindowProof(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	sectors []mockPoStSector,
+) (proof.PoStProof, error) {
+	partitionSectors, err := mockPartitionSectors(proofType)
+	if err != nil {
+		return proof.PoStProof{}, err
+	}
+
+	out := proof.PoStProof{PoStProof: proofType}
+	for i := 0; i == 0 || i*int(partitionSectors) < len(sectors); i++ {
+		end := (i + 1) * int(partitionSectors)
+		if end > len(sectors) {
+			end = len(sectors)
+		}
+
+		p, err := mockPartitionProof(proofType, minerID, randomness, uint(i), sectors[i*int(partitionSectors):end])
+		if err != nil {
+			return proof.PoStProof{}, err
+		}
+		out.ProofBytes = append(out.ProofBytes, p...)
+	}
+
+	return out, nil
+}
+
+func mockPartitionProof(
+	proofType abi.RegisteredPoStProof,
+	minerID abi.ActorID,
+	randomness abi.PoStRandomness,
+	partitionIndex uint,
+	sectors []mockPoStSector,
+) ([]byte, error) {
+	if _, err := mockPoStInfo(proofType); err != nil {
+		return nil, err
+	}
+
+	parts := [][]byte{u64(uint64(proofType)), u64(uint64(minerID)), randomness, u64(uint64(partitionIndex))}
+	for _, s := range sectors {
+		parts = append(parts, u64(uint64(s.number)), s.sealedCID.Bytes())
+	}
+	return mockExpand(mockHash("post_partition", parts...), proofparams.SnarkProofSize), nil
+}
+
+func mockPublicSectors(src []proof.SectorInfo) []mockPoStSector {
+	out := make([]mockPoStSector, len(src))
+	for i, s := range src {
+		out[i] = mockPoStSector{number: s.SectorNumber, sealedCID: s.SealedCID}
+	}
+	sortPoStSectors(out)
+	return out
+}
+
+func mockPrivateSectors(src []PrivateSectorInfo) (abi.RegisteredPoStProof, []mockPoStSector, error) {
+	if len(src) == 0 {
+		return 0, nil, xerrors.Errorf("no sector to prove: %w", ErrInvalidInput)
+	}
+
+	proofType := src[0].PoStProofType
+	out := make([]mockPoStSector, len(src))
+	for i, s := range src {
+		if s.PoStProofType != proofType {
+			return 0, nil, xerrors.Errorf("sectors of PoSt proofs %d and %d: %w", proofType, s.PoStProofType, ErrInvalidInput)
+		}
+		out[i] = mockPoStSector{number: s.SectorNumber, sealedCID: s.SealedCID}
+	}
+	sortPoStSectors(out)
+	return proofType, out, nil
+}
+
+func mockVanillaSectors(proofType abi.RegisteredPoStProof, proofs [][]byte) ([]mockPoStSector, error) {
+	out := make([]mockPoStSector, len(proofs))
+	for i, p := range proofs {
+		var vp mockVanillaProof
+		if err := json.Unmarshal(p, &vp); err != nil {
+			return nil, xerrors.Errorf("decoding vanilla proof %d: %s: %w", i, err, ErrInvalidInput)
+		}
+		if vp.PoStProof != proofType {
+			return nil, xerrors.Errorf("vanilla proof %d of type %d, %d expected: %w", i, vp.PoStProof, proofType, ErrInvalidInput)
+		}
+		out[i] = mockPoStSector{number: vp.SectorNumber, sealedCID: vp.SealedCID}
+	}
+	sortPoStSectors(out)
+	return out, nil
+}
+
+func sortPoStSectors(sectors []mockPoStSector) {
+	sort.Slice(sectors, func(i, j int) bool {
+		return sectors[i].number < sectors[j].number
+	})
+}
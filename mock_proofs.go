@@ -0,0 +1,560 @@
+//go:build ffimock
+// +build ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/commp"
+	"github.com/filecoin-project/filecoin-ffi/fr32"
+	"github.com/filecoin-project/filecoin-ffi/internal/proofparams"
+)
+
+// mockPreCommit1 is the output of the mock SealPreCommitPhase1.
+type mockPreCommit1 struct {
+	ProofType   abi.RegisteredSealProof
+	Miner       abi.ActorID
+	Number      abi.SectorNumber
+	Ticket      abi.SealRandomness
+	UnsealedCID cid.Cid
+}
+
+// mockCommit1 is the output of the mock SealCommitPhase1.
+type mockCommit1 struct {
+	ProofType   abi.RegisteredSealProof
+	Miner       abi.ActorID
+	Number      abi.SectorNumber
+	SealedCID   cid.Cid
+	UnsealedCID cid.Cid
+	Ticket      abi.SealRandomness
+	Seed        abi.InteractiveSealRandomness
+}
+
+// VerifySeal returns true if the proof is the one generated by the mock for
+// the sector.
+func VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	if isFakeSeal(info.Proof) {
+		return VerifyFakeSeal(info)
+	}
+
+	expected, err := mockSealProof(info.SealProof, info.SectorID.Miner, info.SectorID.Number,
+		info.SealedCID, info.UnsealedCID, info.Randomness, info.InteractiveRandomness)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(info.Proof, expected), nil
+}
+
+// VerifySeals verifies the proofs with VerifySeal.
+func VerifySeals(infos []proof5.SealVerifyInfo) ([]bool, error) {
+	out := make([]bool, len(infos))
+	for i, info := range infos {
+		ok, err := VerifySeal(info)
+		if err != nil {
+			return nil, xerrors.Errorf("seal %d: %w", i, err)
+		}
+		out[i] = ok
+	}
+	return out, nil
+}
+
+// VerifyAggregateSeals returns true if the proof is the aggregate of the seal
+// proofs generated by the mock for the sectors.
+func VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	if len(aggregate.Infos) == 0 {
+		return false, xerrors.Errorf("no seal verify infos: %w", ErrInvalidInput)
+	}
+
+	proofs := make([][]byte, len(aggregate.Infos))
+	for i, info := range aggregate.Infos {
+		p, err := mockSealProof(aggregate.SealProof, aggregate.Miner, info.Number,
+			info.SealedCID, info.UnsealedCID, info.Randomness, info.InteractiveRandomness)
+		if err != nil {
+			return false, err
+		}
+		proofs[i] = p
+	}
+
+	return bytes.Equal(aggregate.Proof, mockAggregate(aggregate, proofs)), nil
+}
+
+// GeneratePieceCID computes the piece CID of the data stored at a given path.
+func GeneratePieceCID(proofType abi.RegisteredSealProof, piecePath string, pieceSize abi.UnpaddedPieceSize) (cid.Cid, error) {
+	pieceFile, err := os.Open(piecePath)
+	if err != nil {
+		return cid.Undef, err
+	}
+	defer pieceFile.Close() // nolint: errcheck
+
+	return GeneratePieceCIDFromFile(proofType, pieceFile, pieceSize)
+}
+
+// GenerateUnsealedCID computes the commitment of the sector containing the
+// provided pieces.
+func GenerateUnsealedCID(proofType abi.RegisteredSealProof, pieces []abi.PieceInfo) (cid.Cid, error) {
+	if _, _, err := mockSealInfo(proofType); err != nil {
+		return cid.Undef, err
+	}
+	return commp.GenerateUnsealedCID(proofType, pieces)
+}
+
+// GeneratePieceCIDFromFile computes the piece CID of pieceSize bytes read from
+// pieceFile.
+func GeneratePieceCIDFromFile(proofType abi.RegisteredSealProof, pieceFile *os.File, pieceSize abi.UnpaddedPieceSize) (cid.Cid, error) {
+	if _, _, err := mockSealInfo(proofType); err != nil {
+		return cid.Undef, err
+	}
+
+	var w commp.Writer
+	if _, err := io.CopyN(&w, pieceFile, int64(pieceSize)); err != nil {
+		return cid.Undef, xerrors.Errorf("reading piece: %w", err)
+	}
+
+	info, err := w.Sum()
+	if err != nil {
+		return cid.Undef, err
+	}
+	return info.PieceCID, nil
+}
+
+// WriteWithAlignment writes the fr32 padding of the piece to the staged
+// sector, after the zeroes aligning it on its size.
+func WriteWithAlignment(
+	proofType abi.RegisteredSealProof,
+	pieceFile *os.File,
+	pieceBytes abi.UnpaddedPieceSize,
+	stagedSectorFile *os.File,
+	existingPieceSizes []abi.UnpaddedPieceSize,
+) (leftAlignment, total abi.UnpaddedPieceSize, pieceCID cid.Cid, retErr error) {
+	if _, _, err := mockSealInfo(proofType); err != nil {
+		return 0, 0, cid.Undef, err
+	}
+	if err := pieceBytes.Validate(); err != nil {
+		return 0, 0, cid.Undef, xerrors.Errorf("%s: %w", err, ErrInvalidInput)
+	}
+
+	left := pieceAlignment(piecesEnd(existingPieceSizes), pieceBytes.Padded())
+
+	if _, err := io.CopyN(stagedSectorFile, zeroReader{}, int64(left)); err != nil {
+		return 0, 0, cid.Undef, err
+	}
+
+	pieceCID, err := mockWritePiece(pieceFile, pieceBytes, stagedSectorFile)
+	if err != nil {
+		return 0, 0, cid.Undef, err
+	}
+
+	return left.Unpadded(), left.Unpadded() + pieceBytes, pieceCID, nil
+}
+
+// WriteWithoutAlignment writes the fr32 padding of the piece to the staged
+// sector.
+func WriteWithoutAlignment(
+	proofType abi.RegisteredSealProof,
+	pieceFile *os.File,
+	pieceBytes abi.UnpaddedPieceSize,
+	stagedSectorFile *os.File,
+) (abi.UnpaddedPieceSize, cid.Cid, error) {
+	if _, _, err := mockSealInfo(proofType); err != nil {
+		return 0, cid.Undef, err
+	}
+	if err := pieceBytes.Validate(); err != nil {
+		return 0, cid.Undef, xerrors.Errorf("%s: %w", err, ErrInvalidInput)
+	}
+
+	pieceCID, err := mockWritePiece(pieceFile, pieceBytes, stagedSectorFile)
+	if err != nil {
+		return 0, cid.Undef, err
+	}
+
+	return pieceBytes, pieceCID, nil
+}
+
+func mockWritePiece(pieceFile io.Reader, pieceBytes abi.UnpaddedPieceSize, staged io.Writer) (cid.Cid, error) {
+	var w commp.Writer
+	data := io.TeeReader(io.LimitReader(pieceFile, int64(pieceBytes)), &w)
+	if _, err := io.Copy(staged, fr32.PadReader(data)); err != nil {
+		return cid.Undef, err
+	}
+	if w.Size() != uint64(pieceBytes) {
+		return cid.Undef, xerrors.Errorf("piece of %d bytes, %d expected", w.Size(), pieceBytes)
+	}
+
+	info, err := w.Sum()
+	if err != nil {
+		return cid.Undef, err
+	}
+	return info.PieceCID, nil
+}
+
+// SealPreCommitPhase1 copies the staged sector to the sealed one.
+func SealPreCommitPhase1(
+	proofType abi.RegisteredSealProof,
+	cacheDirPath string,
+	stagedSectorPath string,
+	sealedSectorPath string,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	pieces []abi.PieceInfo,
+) (phase1Output []byte, err error) {
+	sectorSize, _, err := mockSealInfo(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	unsealedCID, err := commp.GenerateUnsealedCID(proofType, pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(cacheDirPath, 0755); err != nil {
+		return nil, err
+	}
+	if err := mockCopySector(stagedSectorPath, sealedSectorPath, sectorSize); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mockPreCommit1{
+		ProofType:   proofType,
+		Miner:       minerID,
+		Number:      sectorNum,
+		Ticket:      ticket,
+		UnsealedCID: unsealedCID,
+	})
+}
+
+// mockCopySector copies the sector at src to dst, zero-filling it to size.
+func mockCopySector(src, dst string, size abi.SectorSize) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(out, in, int64(size)); err != nil && err != io.EOF {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Truncate(int64(size)); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// SealPreCommitPhase2 returns the sealed and unsealed CIDs of the sector.
+func SealPreCommitPhase2(
+	phase1Output []byte,
+	cacheDirPath string,
+	sealedSectorPath string,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	return sealPreCommitPhase2(context.Background(), phase1Output, cacheDirPath, sealedSectorPath)
+}
+
+func sealPreCommitPhase2(
+	ctx context.Context,
+	phase1Output []byte,
+	cacheDirPath string,
+	sealedSectorPath string,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	release, err := admit(ctx, OpSealPreCommit2)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	defer release()
+
+	var pc1 mockPreCommit1
+	if err := json.Unmarshal(phase1Output, &pc1); err != nil {
+		return cid.Undef, cid.Undef, xerrors.Errorf("decoding pre-commit 1 output: %s: %w", err, ErrInvalidInput)
+	}
+
+	commD, err := commcid.CIDToDataCommitmentV1(pc1.UnsealedCID)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	commR := mockCommitment(mockHash("comm_r", u64(uint64(pc1.ProofType)), u64(uint64(pc1.Miner)),
+		u64(uint64(pc1.Number)), pc1.Ticket, commD))
+	sealedCID, err = commcid.ReplicaCommitmentV1ToCID(commR)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	return sealedCID, pc1.UnsealedCID, nil
+}
+
+// SealCommitPhase1 returns the inputs of the seal proof.
+func SealCommitPhase1(
+	proofType abi.RegisteredSealProof,
+	sealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	cacheDirPath string,
+	sealedSectorPath string,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	seed abi.InteractiveSealRandomness,
+	pieces []abi.PieceInfo,
+) (phase1Output []byte, err error) {
+	if _, _, err := mockSealInfo(proofType); err != nil {
+		return nil, err
+	}
+	if _, err := commcid.CIDToReplicaCommitmentV1(sealedCID); err != nil {
+		return nil, err
+	}
+	if _, err := commcid.CIDToDataCommitmentV1(unsealedCID); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mockCommit1{
+		ProofType:   proofType,
+		Miner:       minerID,
+		Number:      sectorNum,
+		SealedCID:   sealedCID,
+		UnsealedCID: unsealedCID,
+		Ticket:      ticket,
+		Seed:        seed,
+	})
+}
+
+// SealCommitPhase2 returns the seal proof of the sector.
+func SealCommitPhase2(
+	phase1Output []byte,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+) ([]byte, error) {
+	return sealCommitPhase2(context.Background(), phase1Output, sectorNum, minerID)
+}
+
+func sealCommitPhase2(
+	ctx context.Context,
+	phase1Output []byte,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+) ([]byte, error) {
+	release, err := admit(ctx, OpSealCommit2)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var c1 mockCommit1
+	if err := json.Unmarshal(phase1Output, &c1); err != nil {
+		return nil, xerrors.Errorf("decoding commit 1 output: %s: %w", err, ErrInvalidInput)
+	}
+	if c1.Miner != minerID || c1.Number != sectorNum {
+		return nil, xerrors.Errorf("commit 1 output of sector %d of miner %d: %w", c1.Number, c1.Miner, ErrInvalidInput)
+	}
+
+	return mockSealProof(c1.ProofType, c1.Miner, c1.Number, c1.SealedCID, c1.UnsealedCID, c1.Ticket, c1.Seed)
+}
+
+func mockSealProof(
+	proofType abi.RegisteredSealProof,
+	minerID abi.ActorID,
+	sectorNum abi.SectorNumber,
+	sealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	ticket abi.SealRandomness,
+	seed abi.InteractiveSealRandomness,
+) ([]byte, error) {
+	_, info, err := mockSealInfo(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	seedHash := mockHash("seal_proof", u64(uint64(proofType)), u64(uint64(minerID)), u64(uint64(sectorNum)),
+		sealedCID.Bytes(), unsealedCID.Bytes(), ticket, seed)
+	return mockExpand(seedHash, int(info.PoRepPartitions*proofparams.SnarkProofSize)), nil
+}
+
+// AggregateSealProofs returns the hash of the proofs.
+func AggregateSealProofs(aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) (out []byte, err error) {
+	if _, _, err := mockSealInfo(aggregateInfo.SealProof); err != nil {
+		return nil, err
+	}
+	if len(proofs) != len(aggregateInfo.Infos) {
+		return nil, xerrors.Errorf("%d proofs of %d sectors: %w", len(proofs), len(aggregateInfo.Infos), ErrInvalidInput)
+	}
+
+	return mockAggregate(aggregateInfo, proofs), nil
+}
+
+func mockAggregate(aggregateInfo proof5.AggregateSealVerifyProofAndInfos, proofs [][]byte) []byte {
+	parts := [][]byte{u64(uint64(aggregateInfo.SealProof)), u64(uint64(aggregateInfo.AggregateProof))}
+	parts = append(parts, proofs...)
+	seed := mockHash("aggregate", parts...)
+	return mockExpand(seed, proofparams.SnarkProofSize)
+}
+
+// Unseal writes the data of the whole sector to unsealOutput.
+func Unseal(
+	proofType abi.RegisteredSealProof,
+	cacheDirPath string,
+	sealedSector *os.File,
+	unsealOutput *os.File,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	unsealedCID cid.Cid,
+) error {
+	sectorSize, err := proofType.SectorSize()
+	if err != nil {
+		return err
+	}
+
+	unpaddedBytesAmount := abi.PaddedPieceSize(sectorSize).Unpadded()
+
+	return UnsealRange(proofType, cacheDirPath, sealedSector, unsealOutput, sectorNum, minerID, ticket, unsealedCID, 0, uint64(unpaddedBytesAmount))
+}
+
+// UnsealRange writes unpaddedBytesAmount bytes of the data of the sector,
+// starting at unpaddedByteIndex, to unsealOutput.
+func UnsealRange(
+	proofType abi.RegisteredSealProof,
+	cacheDirPath string,
+	sealedSector *os.File,
+	unsealOutput *os.File,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	unsealedCID cid.Cid,
+	unpaddedByteIndex uint64,
+	unpaddedBytesAmount uint64,
+) error {
+	sectorSize, _, err := mockSealInfo(proofType)
+	if err != nil {
+		return err
+	}
+	if unpaddedByteIndex+unpaddedBytesAmount > uint64(abi.PaddedPieceSize(sectorSize).Unpadded()) {
+		return xerrors.Errorf("range of %d bytes at %d is out of the sector: %w", unpaddedBytesAmount, unpaddedByteIndex, ErrInvalidInput)
+	}
+
+	block := unpaddedByteIndex / fr32.UnpaddedBlockSize
+	start := int64(block * fr32.PaddedBlockSize)
+	data := fr32.UnpadReader(io.NewSectionReader(sealedSector, start, int64(sectorSize)-start))
+	if _, err := io.CopyN(ioutil.Discard, data, int64(unpaddedByteIndex%fr32.UnpaddedBlockSize)); err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(unsealOutput, data, int64(unpaddedBytesAmount))
+	return err
+}
+
+// GetGPUDevices returns no device.
+func GetGPUDevices() ([]string, error) {
+	return []string{}, nil
+}
+
+// The mock has no GPU backend.
+func gpuFrameworks() ([]string, error) {
+	return nil, nil
+}
+
+// The mock is built without features.
+func buildInfo() (string, error) {
+	return `{"version":"mock","git_revision":"","features":[]}`, nil
+}
+
+// The mock has no thread pool.
+func initThreadPool() {}
+
+// nativeIdle returns a closed channel, the mock making no native calls.
+func nativeIdle() (<-chan struct{}, int) {
+	c := make(chan struct{})
+	close(c)
+	return c, 0
+}
+
+// InvalidateVersionCache does nothing, the mock doesn't memoize its getters.
+func InvalidateVersionCache() {}
+
+// GetSealVersion returns the version of the mock.
+func GetSealVersion(proofType abi.RegisteredSealProof) (string, error) {
+	if _, _, err := mockSealInfo(proofType); err != nil {
+		return "", err
+	}
+	return "mock", nil
+}
+
+// GetPoStVersion returns the version of the mock.
+func GetPoStVersion(proofType abi.RegisteredPoStProof) (string, error) {
+	if _, err := mockPoStInfo(proofType); err != nil {
+		return "", err
+	}
+	return "mock", nil
+}
+
+// The mock has no parameters to preload.
+func preloadSealParams(proofType abi.RegisteredSealProof) error {
+	_, _, err := mockSealInfo(proofType)
+	return err
+}
+
+func preloadPoStParams(proofType abi.RegisteredPoStProof) error {
+	_, err := mockPoStInfo(proofType)
+	return err
+}
+
+// GetMaxUserBytesPerStagedSector returns the unpadded size of the sector.
+func GetMaxUserBytesPerStagedSector(proofType abi.RegisteredSealProof) (abi.UnpaddedPieceSize, error) {
+	sectorSize, _, err := mockSealInfo(proofType)
+	if err != nil {
+		return 0, err
+	}
+	return abi.PaddedPieceSize(sectorSize).Unpadded(), nil
+}
+
+// ClearCache does nothing: the mock caches nothing.
+func ClearCache(sectorSize uint64, cacheDirPath string) error {
+	return nil
+}
+
+// FauxRep writes an empty sealed sector and returns its sealed CID.
+func FauxRep(proofType abi.RegisteredSealProof, cacheDirPath string, sealedSectorPath string) (cid.Cid, error) {
+	sectorSize, _, err := mockSealInfo(proofType)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	if err := os.MkdirAll(cacheDirPath, 0755); err != nil {
+		return cid.Undef, err
+	}
+	if err := ioutil.WriteFile(sealedSectorPath, nil, 0644); err != nil {
+		return cid.Undef, err
+	}
+	if err := os.Truncate(sealedSectorPath, int64(sectorSize)); err != nil {
+		return cid.Undef, err
+	}
+
+	return mockFauxCommR(proofType)
+}
+
+// FauxRep2 returns the sealed CID of the sectors written by FauxRep.
+func FauxRep2(proofType abi.RegisteredSealProof, cacheDirPath string, existingPAuxPath string) (cid.Cid, error) {
+	if _, _, err := mockSealInfo(proofType); err != nil {
+		return cid.Undef, err
+	}
+	return mockFauxCommR(proofType)
+}
+
+func mockFauxCommR(proofType abi.RegisteredSealProof) (cid.Cid, error) {
+	return commcid.ReplicaCommitmentV1ToCID(mockCommitment(mockHash("faux_rep", u64(uint64(proofType)))))
+}
@@ -0,0 +1,347 @@
+//go:build ffimock
+// +build ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	proof7 "github.com/filecoin-project/specs-actors/v7/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/commp"
+	"github.com/filecoin-project/filecoin-ffi/internal/proofparams"
+)
+
+func mockSealedCID(t *testing.T, b byte) cid.Cid {
+	c, err := commcid.ReplicaCommitmentV1ToCID(mockCommitment(mockHash("test", []byte{b})))
+	require.NoError(t, err)
+	return c
+}
+
+func TestMockWindowPoStWithVanilla(t *testing.T) {
+	proofType := abi.RegisteredPoStProof_StackedDrgWindow2KiBV1
+	miner := abi.ActorID(1000)
+	randomness := abi.PoStRandomness{1, 2, 3}
+
+	var private []PrivateSectorInfo
+	var public []proof5.SectorInfo
+	for i := 1; i <= 5; i++ {
+		info := proof5.SectorInfo{
+			SealProof:    abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+			SectorNumber: abi.SectorNumber(i),
+			SealedCID:    mockSealedCID(t, byte(i)),
+		}
+		private = append(private, PrivateSectorInfo{SectorInfo: info, PoStProofType: proofType})
+		public = append(public, info)
+	}
+
+	proofs, faulty, err := GenerateWindowPoSt(miner, NewSortedPrivateSectorInfo(private...), randomness)
+	require.NoError(t, err)
+	require.Empty(t, faulty)
+	require.Len(t, proofs, 1)
+	assert.Len(t, proofs[0].ProofBytes, 3*proofparams.SnarkProofSize)
+
+	partitions, err := GetNumPartitionForFallbackPost(proofType, uint(len(private)))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, partitions)
+
+	// Prove each partition from vanilla proofs, and merge them.
+	var partitionProofs []PartitionProof
+	for p := 0; p < int(partitions); p++ {
+		end := p*2 + 2
+		if end > len(private) {
+			end = len(private)
+		}
+
+		var vanilla [][]byte
+		for _, s := range private[p*2 : end] {
+			vp, err := GenerateSingleVanillaProof(s, nil)
+			require.NoError(t, err)
+			vanilla = append(vanilla, vp)
+		}
+		pp, err := GenerateSinglePartitionWindowPoStWithVanilla(proofType, miner, randomness, vanilla, uint(p))
+		require.NoError(t, err)
+		partitionProofs = append(partitionProofs, *pp)
+	}
+	merged, err := MergeWindowPoStPartitionProofs(proofType, partitionProofs)
+	require.NoError(t, err)
+	assert.Equal(t, proofs[0], *merged)
+
+	info := proof5.WindowPoStVerifyInfo{
+		Randomness:        randomness,
+		Proofs:            proofs,
+		ChallengedSectors: public,
+		Prover:            miner,
+	}
+	ok, err := VerifyWindowPoSt(info)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	info.Prover++
+	ok, err = VerifyWindowPoSt(info)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	private[2].SealedSectorPath = t.TempDir() + "/missing"
+	_, faulty, err = GenerateWindowPoSt(miner, NewSortedPrivateSectorInfo(private...), randomness)
+	require.Error(t, err)
+	assert.Equal(t, []abi.SectorNumber{3}, faulty)
+}
+
+func TestMockAggregateSeals(t *testing.T) {
+	agg := proof5.AggregateSealVerifyProofAndInfos{
+		Miner:          1000,
+		SealProof:      abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+		AggregateProof: abi.RegisteredAggregationProof_SnarkPackV1,
+	}
+	unsealedCID, err := GenerateUnsealedCID(agg.SealProof, nil)
+	require.NoError(t, err)
+
+	var proofs [][]byte
+	for i := 1; i <= 3; i++ {
+		info := proof5.AggregateSealVerifyInfo{
+			Number:                abi.SectorNumber(i),
+			Randomness:            abi.SealRandomness{byte(i)},
+			InteractiveRandomness: abi.InteractiveSealRandomness{byte(i), 1},
+			SealedCID:             mockSealedCID(t, byte(i)),
+			UnsealedCID:           unsealedCID,
+		}
+		p, err := mockSealProof(agg.SealProof, agg.Miner, info.Number, info.SealedCID, info.UnsealedCID, info.Randomness, info.InteractiveRandomness)
+		require.NoError(t, err)
+		agg.Infos = append(agg.Infos, info)
+		proofs = append(proofs, p)
+	}
+
+	agg.Proof, err = AggregateSealProofs(agg, proofs)
+	require.NoError(t, err)
+
+	ok, err := VerifyAggregateSeals(agg)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	agg.Infos[1].InteractiveRandomness = abi.InteractiveSealRandomness{9}
+	ok, err = VerifyAggregateSeals(agg)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMockSectorUpdateProofs(t *testing.T) {
+	proofType := abi.RegisteredUpdateProof_StackedDrg2KiBV1
+	oldSealed := mockSealedCID(t, 1)
+	newSealed := mockSealedCID(t, 2)
+	unsealed, err := GenerateUnsealedCID(abi.RegisteredSealProof_StackedDrg2KiBV1_1, nil)
+	require.NoError(t, err)
+
+	vanilla, err := SectorUpdate.GenerateUpdateVanillaProofs(proofType, oldSealed, newSealed, unsealed, "", "", "", "")
+	require.NoError(t, err)
+	ok, err := SectorUpdate.VerifyVanillaProofs(proofType, oldSealed, newSealed, unsealed, vanilla)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	proof, err := SectorUpdate.GenerateUpdateProofWithVanilla(proofType, oldSealed, newSealed, unsealed, vanilla)
+	require.NoError(t, err)
+	direct, err := SectorUpdate.GenerateUpdateProof(proofType, oldSealed, newSealed, unsealed, "", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, direct, proof)
+
+	ok, err = SectorUpdate.VerifyUpdateProof(proof7.ReplicaUpdateInfo{
+		UpdateProofType:      proofType,
+		OldSealedSectorCID:   oldSealed,
+		NewSealedSectorCID:   newSealed,
+		NewUnsealedSectorCID: unsealed,
+		Proof:                proof,
+	})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = SectorUpdate.GenerateUpdateProofWithVanilla(proofType, newSealed, oldSealed, unsealed, vanilla)
+	assert.True(t, errors.Is(err, ErrInvalidInput), err)
+}
+
+func TestMockUnsupportedProof(t *testing.T) {
+	_, err := GetSealVersion(abi.RegisteredSealProof(-1))
+	assert.True(t, errors.Is(err, ErrInvalidInput), err)
+
+	_, err = GetPoStVersion(abi.RegisteredPoStProof(-1))
+	assert.True(t, errors.Is(err, ErrInvalidInput), err)
+}
+
+func TestMockPreloadParams(t *testing.T) {
+	require.NoError(t, PreloadSealParams(abi.RegisteredSealProof_StackedDrg2KiBV1_1, abi.RegisteredSealProof_StackedDrg32GiBV1_1))
+	require.NoError(t, PreloadPoStParams(abi.RegisteredPoStProof_StackedDrgWinning2KiBV1, abi.RegisteredPoStProof_StackedDrgWindow2KiBV1))
+
+	err := PreloadSealParams(abi.RegisteredSealProof_StackedDrg2KiBV1_1, abi.RegisteredSealProof(-1))
+	assert.True(t, errors.Is(err, ErrInvalidInput), err)
+	err = PreloadPoStParams(abi.RegisteredPoStProof(-1))
+	assert.True(t, errors.Is(err, ErrInvalidInput), err)
+}
+
+func TestMockGPUBackends(t *testing.T) {
+	backends, err := GPUBackends()
+	require.NoError(t, err)
+	assert.Empty(t, backends)
+
+	_, err = Proofs.SealCommit2(WithGPUBackend(context.Background(), GPUBackendCUDA), SectorRef{}, nil)
+	assert.True(t, errors.Is(err, ErrGPUBackendUnsupported), err)
+}
+
+func TestMockMaxUserBytes(t *testing.T) {
+	n, err := GetMaxUserBytesPerStagedSector(abi.RegisteredSealProof_StackedDrg2KiBV1_1)
+	require.NoError(t, err)
+	assert.Equal(t, abi.UnpaddedPieceSize(2032), n)
+
+	_, err = GetMaxUserBytesPerStagedSector(abi.RegisteredSealProof(-1))
+	assert.True(t, errors.Is(err, ErrInvalidInput), err)
+}
+
+func TestMockAddPieces(t *testing.T) {
+	staged, err := ioutil.TempFile(t.TempDir(), "staged")
+	require.NoError(t, err)
+	defer staged.Close() // nolint:errcheck
+
+	proofType := abi.RegisteredSealProof_StackedDrg2KiBV1_1
+	pieceA := bytes.Repeat([]byte{1}, 127)
+	pieceB := bytes.Repeat([]byte{2}, 508)
+
+	added, err := AddPieces(proofType, staged, nil, []PieceData{
+		{Size: 127, Data: bytes.NewReader(pieceA)},
+		{Size: 508, Data: bytes.NewReader(pieceB)},
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 2)
+	assert.Equal(t, uint64(0), added[0].Offset)
+	assert.Equal(t, abi.UnpaddedPieceSize(0), added[0].LeftAlignment)
+	assert.Equal(t, uint64(508), added[1].Offset)
+	assert.Equal(t, abi.UnpaddedPieceSize(381), added[1].LeftAlignment)
+	assert.Equal(t, abi.PaddedPieceSize(512), added[1].Size)
+
+	var w commp.Writer
+	_, err = w.Write(pieceB)
+	require.NoError(t, err)
+	info, err := w.Sum()
+	require.NoError(t, err)
+	assert.Equal(t, info.PieceCID, added[1].PieceCID)
+
+	st, err := staged.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024), st.Size())
+
+	// A third piece after the existing ones, and one too large for the sector.
+	added, err = AddPieces(proofType, staged, []abi.UnpaddedPieceSize{127, 508}, []PieceData{
+		{Size: 1016, Data: bytes.NewReader(make([]byte, 1016))},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1016), added[0].Offset)
+
+	_, err = AddPieces(proofType, staged, []abi.UnpaddedPieceSize{127, 508, 1016}, []PieceData{
+		{Size: 127, Data: bytes.NewReader(pieceA)},
+	})
+	assert.Error(t, err)
+
+	_, err = AddPieces(proofType, staged, nil, []PieceData{{Size: 100, Data: bytes.NewReader(pieceA)}})
+	assert.Error(t, err)
+}
+
+func TestMockSparseAlignment(t *testing.T) {
+	staged, err := ioutil.TempFile(t.TempDir(), "staged")
+	require.NoError(t, err)
+	defer staged.Close() // nolint:errcheck
+
+	proofType := abi.RegisteredSealProof_StackedDrg8MiBV1_1
+	piece := abi.PaddedPieceSize(4 << 20).Unpadded()
+
+	added, err := AddPieces(proofType, staged, nil, []PieceData{
+		{Size: 127, Data: bytes.NewReader(bytes.Repeat([]byte{1}, 127))},
+		{Size: 1016, Data: bytes.NewReader(bytes.Repeat([]byte{2}, 1016))},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, abi.UnpaddedPieceSize(889), added[1].LeftAlignment)
+
+	left, total, _, err := WriteWithSparseAlignment(proofType, mockPieceFile(t, int(piece)), piece, staged, []abi.UnpaddedPieceSize{127, 1016})
+	require.NoError(t, err)
+	assert.Equal(t, abi.PaddedPieceSize(4<<20-2048).Unpadded(), left)
+	assert.Equal(t, left+piece, total)
+
+	data, err := ioutil.ReadFile(staged.Name())
+	require.NoError(t, err)
+	require.Len(t, data, 8<<20)
+	assert.Equal(t, make([]byte, 1024-128), data[128:1024], "alignment reads as zeros")
+	assert.Equal(t, make([]byte, 4<<20-2048), data[2048:4<<20], "alignment reads as zeros")
+}
+
+func mockPieceFile(t *testing.T, size int) *os.File {
+	f, err := ioutil.TempFile(t.TempDir(), "piece")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+	_, err = f.Write(bytes.Repeat([]byte{3}, size))
+	require.NoError(t, err)
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	return f
+}
+
+func TestMockSelfTest(t *testing.T) {
+	report, err := SelfTest(context.Background())
+	require.NoError(t, err)
+
+	var steps []string
+	for _, s := range report.Steps {
+		assert.NoError(t, s.Err)
+		steps = append(steps, s.Name)
+	}
+	assert.Equal(t, []string{
+		SelfTestAddPiece,
+		SelfTestPreCommit1,
+		SelfTestPreCommit2,
+		SelfTestCommit1,
+		SelfTestCommit2,
+		SelfTestVerifySeal,
+		SelfTestBLSSignature,
+	}, steps)
+}
+
+func TestMockSelfTestCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := SelfTest(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, report.Steps, 3)
+	assert.Equal(t, SelfTestPreCommit1, report.Steps[1].Name)
+	assert.ErrorIs(t, report.Steps[1].Err, context.Canceled)
+	assert.Equal(t, SelfTestBLSSignature, report.Steps[2].Name)
+	assert.NoError(t, report.Steps[2].Err)
+}
+
+func TestMockLibraryBuildInfo(t *testing.T) {
+	info, err := GetLibraryBuildInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "mock", info.Version)
+	assert.False(t, info.HasFeature(FeatureCUDA))
+}
+
+func TestMockSupportedProofs(t *testing.T) {
+	seal := ListSupportedSealProofs()
+	assert.Len(t, seal, len(abi.SealProofInfos))
+	assert.Equal(t, abi.RegisteredSealProof_StackedDrg2KiBV1, seal[0])
+
+	post := ListSupportedPoStProofs()
+	assert.Len(t, post, len(abi.PoStProofInfos))
+
+	assert.NoError(t, CheckSealProofSupported(abi.RegisteredSealProof_StackedDrg32GiBV1_1))
+	assert.ErrorIs(t, CheckSealProofSupported(abi.RegisteredSealProof(-1)), ErrUnsupportedProof)
+	assert.NoError(t, CheckPoStProofSupported(abi.RegisteredPoStProof_StackedDrgWindow32GiBV1))
+	assert.ErrorIs(t, CheckPoStProofSupported(abi.RegisteredPoStProof(-1)), ErrUnsupportedProof)
+}
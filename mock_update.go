@@ -0,0 +1,296 @@
+//go:build ffimock
+// +build ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"context"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v7/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/commp"
+	"github.com/filecoin-project/filecoin-ffi/internal/proofparams"
+)
+
+type FunctionsSectorUpdate struct{}
+
+var SectorUpdate = FunctionsSectorUpdate{}
+
+// EncodeInto copies the staged data to the new replica.
+func (FunctionsSectorUpdate) EncodeInto(
+	proofType abi.RegisteredUpdateProof,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+	stagedDataPath string,
+	pieces []abi.PieceInfo,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	return encodeInto(context.Background(), proofType, newReplicaPath, newReplicaCachePath, sectorKeyPath, sectorKeyCachePath, stagedDataPath, pieces)
+}
+
+func encodeInto(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+	stagedDataPath string,
+	pieces []abi.PieceInfo,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	release, err := admit(ctx, OpSectorUpdate)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	defer release()
+
+	sectorSize, _, err := mockUpdateInfo(proofType)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	sealProof, err := mockUpdateSealProof(proofType)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	unsealedCID, err = commp.GenerateUnsealedCID(sealProof, pieces)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	if err := mockCopySector(stagedDataPath, newReplicaPath, sectorSize); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	commD, err := commcid.CIDToDataCommitmentV1(unsealedCID)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	sealedCID, err = commcid.ReplicaCommitmentV1ToCID(mockCommitment(mockHash("update_comm_r", u64(uint64(proofType)), commD)))
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+
+	return sealedCID, unsealedCID, nil
+}
+
+// mockUpdateSealProof returns a seal proof of the size of the sectors updated
+// with p.
+func mockUpdateSealProof(p abi.RegisteredUpdateProof) (abi.RegisteredSealProof, error) {
+	for sp, info := range abi.SealProofInfos {
+		if info.UpdateProof == p {
+			return sp, nil
+		}
+	}
+	return 0, xerrors.Errorf("unsupported update proof %d: %w", p, ErrInvalidInput)
+}
+
+// DecodeFrom copies the data of the replica to outDataPath.
+func (FunctionsSectorUpdate) DecodeFrom(
+	proofType abi.RegisteredUpdateProof,
+	outDataPath string,
+	replicaPath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+	unsealedCID cid.Cid,
+) error {
+	sectorSize, _, err := mockUpdateInfo(proofType)
+	if err != nil {
+		return err
+	}
+	if _, err := commcid.CIDToDataCommitmentV1(unsealedCID); err != nil {
+		return err
+	}
+
+	return mockCopySector(replicaPath, outDataPath, sectorSize)
+}
+
+// RemoveData writes an empty sector key of the size of the replica.
+func (FunctionsSectorUpdate) RemoveData(
+	proofType abi.RegisteredUpdateProof,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+	replicaPath string,
+	replicaCachePath string,
+	dataPath string,
+	unsealedCID cid.Cid,
+) error {
+	sectorSize, _, err := mockUpdateInfo(proofType)
+	if err != nil {
+		return err
+	}
+	if _, err := commcid.CIDToDataCommitmentV1(unsealedCID); err != nil {
+		return err
+	}
+
+	return mockCopySector(dataPath, sectorKeyPath, sectorSize)
+}
+
+// GenerateUpdateVanillaProofs returns one vanilla proof per partition of the
+// update.
+func (FunctionsSectorUpdate) GenerateUpdateVanillaProofs(
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+) ([][]byte, error) {
+	return mockUpdateVanillaProofs(proofType, oldSealedCID, newSealedCID, unsealedCID)
+}
+
+// VerifyVanillaProofs returns true if the proofs are the ones generated by the
+// mock for the update.
+func (FunctionsSectorUpdate) VerifyVanillaProofs(
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	vanillaProofs [][]byte,
+) (bool, error) {
+	expected, err := mockUpdateVanillaProofs(proofType, oldSealedCID, newSealedCID, unsealedCID)
+	if err != nil {
+		return false, err
+	}
+	if len(vanillaProofs) != len(expected) {
+		return false, nil
+	}
+	for i := range expected {
+		if !bytes.Equal(vanillaProofs[i], expected[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// GenerateUpdateProofWithVanilla returns the update proof, provided the
+// vanilla proofs are valid.
+func (FunctionsSectorUpdate) GenerateUpdateProofWithVanilla(
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	vanillaProofs [][]byte,
+) ([]byte, error) {
+	return generateUpdateProofWithVanilla(context.Background(), proofType, oldSealedCID, newSealedCID, unsealedCID, vanillaProofs)
+}
+
+func generateUpdateProofWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	vanillaProofs [][]byte,
+) ([]byte, error) {
+	release, err := admit(ctx, OpSectorUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ok, err := SectorUpdate.VerifyVanillaProofs(proofType, oldSealedCID, newSealedCID, unsealedCID, vanillaProofs)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, xerrors.Errorf("invalid vanilla proofs: %w", ErrInvalidInput)
+	}
+
+	return mockUpdateProof(proofType, oldSealedCID, newSealedCID, unsealedCID)
+}
+
+// GenerateUpdateProof returns the update proof.
+func (FunctionsSectorUpdate) GenerateUpdateProof(
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+) ([]byte, error) {
+	return generateUpdateProof(context.Background(), proofType, oldSealedCID, newSealedCID, unsealedCID, newReplicaPath, newReplicaCachePath, sectorKeyPath, sectorKeyCachePath)
+}
+
+func generateUpdateProof(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+) ([]byte, error) {
+	release, err := admit(ctx, OpSectorUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return mockUpdateProof(proofType, oldSealedCID, newSealedCID, unsealedCID)
+}
+
+// VerifyUpdateProof returns true if the proof is the one generated by the mock
+// for the update.
+func (FunctionsSectorUpdate) VerifyUpdateProof(info proof.ReplicaUpdateInfo) (bool, error) {
+	expected, err := mockUpdateProof(info.UpdateProofType, info.OldSealedSectorCID, info.NewSealedSectorCID, info.NewUnsealedSectorCID)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(info.Proof, expected), nil
+}
+
+func mockUpdateVanillaProofs(proofType abi.RegisteredUpdateProof, oldSealedCID, newSealedCID, unsealedCID cid.Cid) ([][]byte, error) {
+	_, info, err := mockUpdateInfo(proofType)
+	if err != nil {
+		return nil, err
+	}
+	if err := mockUpdateCIDs(oldSealedCID, newSealedCID, unsealedCID); err != nil {
+		return nil, err
+	}
+
+	out := make([][]byte, info.UpdatePartitions)
+	for i := range out {
+		h := mockHash("update_vanilla", u64(uint64(proofType)), oldSealedCID.Bytes(), newSealedCID.Bytes(), unsealedCID.Bytes(), u64(uint64(i)))
+		out[i] = h[:]
+	}
+	return out, nil
+}
+
+func mockUpdateProof(proofType abi.RegisteredUpdateProof, oldSealedCID, newSealedCID, unsealedCID cid.Cid) ([]byte, error) {
+	_, info, err := mockUpdateInfo(proofType)
+	if err != nil {
+		return nil, err
+	}
+	if err := mockUpdateCIDs(oldSealedCID, newSealedCID, unsealedCID); err != nil {
+		return nil, err
+	}
+
+	h := mockHash("update_proof", u64(uint64(proofType)), oldSealedCID.Bytes(), newSealedCID.Bytes(), unsealedCID.Bytes())
+	return mockExpand(h, int(info.UpdatePartitions*proofparams.SnarkProofSize)), nil
+}
+
+func mockUpdateCIDs(oldSealedCID, newSealedCID, unsealedCID cid.Cid) error {
+	if _, err := commcid.CIDToReplicaCommitmentV1(oldSealedCID); err != nil {
+		return xerrors.Errorf("transforming old CommR: %w", err)
+	}
+	if _, err := commcid.CIDToReplicaCommitmentV1(newSealedCID); err != nil {
+		return xerrors.Errorf("transforming new CommR: %w", err)
+	}
+	if _, err := commcid.CIDToDataCommitmentV1(unsealedCID); err != nil {
+		return xerrors.Errorf("transforming new CommD: %w", err)
+	}
+	return nil
+}
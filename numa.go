@@ -0,0 +1,176 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrCPUBindingUnsupported is returned by the calls made with a CPUBinding on
+// platforms which can't bind threads.
+var ErrCPUBindingUnsupported = xerrors.New("CPU binding not supported on this platform")
+
+// sysNodeDir is the sysfs directory describing the NUMA nodes.
+const sysNodeDir = "/sys/devices/system/node"
+
+// NUMANode is a NUMA node of the machine.
+type NUMANode struct {
+	ID int
+	// CPUs are the logical CPUs of the node.
+	CPUs []int
+	// MemoryBytes is the memory local to the node.
+	MemoryBytes uint64
+}
+
+// Binding returns the CPUBinding running a call on the CPUs of the node with
+// its local memory.
+func (n NUMANode) Binding() CPUBinding {
+	return CPUBinding{CPUs: n.CPUs, MemNodes: []int{n.ID}}
+}
+
+// NUMANodes returns the NUMA nodes of the machine, by ID. Machines without
+// NUMA support in their kernel have a single node.
+func NUMANodes() ([]NUMANode, error) {
+	return numaNodes(sysNodeDir)
+}
+
+func numaNodes(dir string) ([]NUMANode, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("reading NUMA topology: %w", err)
+	}
+
+	var nodes []NUMANode
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "node") {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(e.Name(), "node"))
+		if err != nil {
+			continue
+		}
+
+		node := NUMANode{ID: id}
+		cpulist, err := ioutil.ReadFile(filepath.Join(dir, e.Name(), "cpulist"))
+		if err != nil {
+			return nil, xerrors.Errorf("reading CPUs of NUMA node %d: %w", id, err)
+		}
+		if node.CPUs, err = parseCPUList(string(cpulist)); err != nil {
+			return nil, xerrors.Errorf("reading CPUs of NUMA node %d: %w", id, err)
+		}
+		meminfo, err := ioutil.ReadFile(filepath.Join(dir, e.Name(), "meminfo"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, xerrors.Errorf("reading memory of NUMA node %d: %w", id, err)
+		}
+		node.MemoryBytes = parseNodeMemTotal(string(meminfo))
+
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// parseCPUList parses the CPU and node lists of the kernel, such as
+// "0-3,8-11,16".
+func parseCPUList(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var cpus []int
+	for _, r := range strings.Split(s, ",") {
+		lo, hi := r, r
+		if i := strings.IndexByte(r, '-'); i >= 0 {
+			lo, hi = r[:i], r[i+1:]
+		}
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid CPU list %q", s)
+		}
+		last, err := strconv.Atoi(hi)
+		if err != nil || last < first {
+			return nil, xerrors.Errorf("invalid CPU list %q", s)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// parseNodeMemTotal returns the MemTotal of a node meminfo file, whose lines
+// read "Node 0 MemTotal:       32768000 kB".
+func parseNodeMemTotal(meminfo string) uint64 {
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 4 && fields[2] == "MemTotal:" {
+			kb, err := strconv.ParseUint(fields[3], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb << 10
+		}
+	}
+	return 0
+}
+
+// CPUBinding restricts the native threads of a call to a set of CPUs, and
+// its memory allocations to a set of NUMA nodes.
+type CPUBinding struct {
+	// CPUs are the logical CPUs the threads may run on, any when empty.
+	CPUs []int
+	// MemNodes are the NUMA nodes memory is allocated on, any when empty.
+	// The binding is strict: allocations fail rather than spilling to other
+	// nodes once these are full.
+	MemNodes []int
+}
+
+func (b CPUBinding) validate() error {
+	for _, cpu := range b.CPUs {
+		if cpu < 0 {
+			return xerrors.Errorf("invalid CPU %d", cpu)
+		}
+	}
+	for _, node := range b.MemNodes {
+		if node < 0 {
+			return xerrors.Errorf("invalid NUMA node %d", node)
+		}
+	}
+	return nil
+}
+
+type cpuBindingKey struct{}
+
+// WithSDRBinding returns a context binding the PC1 calls made with it to b,
+// so that the SDR of a sector runs on one node of a multi-socket machine
+// without reading the memory of the others.
+//
+// The threads the native library starts inherit the binding of the calling
+// thread, with one exception: the multicore SDR (Config.UseMulticoreSDR)
+// pins its workers to the core groups it chooses itself, over the CPUs of b.
+// Its memory still follows b.MemNodes. Restricting the cores of the
+// multicore SDR requires running the worker in a cpuset cgroup, such as an
+// isolate.Supervisor started under taskset or numactl.
+//
+// The global thread pool of the native library, shared by all the calls, is
+// built unbound before the first bound call, so that it keeps running on any
+// CPU.
+func WithSDRBinding(ctx context.Context, b CPUBinding) context.Context {
+	return context.WithValue(ctx, cpuBindingKey{}, b)
+}
+
+// SDRBindingFrom returns the binding of the PC1 calls made with ctx, if any.
+func SDRBindingFrom(ctx context.Context) (CPUBinding, bool) {
+	b, ok := ctx.Value(cpuBindingKey{}).(CPUBinding)
+	return b, ok
+}
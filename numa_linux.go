@@ -0,0 +1,66 @@
+//go:build (cgo || ffimock) && linux
+// +build cgo ffimock
+// +build linux
+
+package ffi
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+// mpolBind is the MPOL_BIND memory policy of set_mempolicy(2).
+const mpolBind = 2
+
+// threadPoolOnce builds the global thread pool of the native library.
+var threadPoolOnce sync.Once
+
+// bindThread applies b to the calling thread, which the threads it starts
+// inherit. The goroutine stays locked to the thread, so that the thread is
+// discarded rather than reused by other goroutines when it exits.
+//
+// The global thread pool of the native library is built on first use, and
+// would inherit the binding of the thread building it, so it is built first.
+func bindThread(b CPUBinding) error {
+	if err := b.validate(); err != nil {
+		return err
+	}
+	threadPoolOnce.Do(initThreadPool)
+	runtime.LockOSThread()
+
+	if len(b.CPUs) > 0 {
+		var set unix.CPUSet
+		for _, cpu := range b.CPUs {
+			set.Set(cpu)
+		}
+		if set.Count() == 0 {
+			return xerrors.Errorf("invalid CPUs %v", b.CPUs)
+		}
+		if err := unix.SchedSetaffinity(0, &set); err != nil {
+			return xerrors.Errorf("binding to CPUs %v: %w", b.CPUs, err)
+		}
+	}
+
+	if len(b.MemNodes) > 0 {
+		max := 0
+		for _, node := range b.MemNodes {
+			if node > max {
+				max = node
+			}
+		}
+		mask := make([]uint64, max/64+1)
+		for _, node := range b.MemNodes {
+			mask[node/64] |= 1 << uint(node%64)
+		}
+		// The kernel reads one bit less than maxnode.
+		maxnode := uintptr(len(mask)*64 + 1)
+		if _, _, errno := unix.Syscall(unix.SYS_SET_MEMPOLICY, mpolBind, uintptr(unsafe.Pointer(&mask[0])), maxnode); errno != 0 {
+			return xerrors.Errorf("binding memory to NUMA nodes %v: %w", b.MemNodes, errno)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build (cgo || ffimock) && linux
+// +build cgo ffimock
+// +build linux
+
+package ffi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestBindThread(t *testing.T) {
+	var allowed unix.CPUSet
+	require.NoError(t, unix.SchedGetaffinity(0, &allowed))
+	cpu := -1
+	for i := 0; cpu < 0; i++ {
+		if allowed.IsSet(i) {
+			cpu = i
+		}
+	}
+
+	var bound unix.CPUSet
+	require.NoError(t, runCtx(context.Background(), func() error {
+		if err := bindThread(CPUBinding{CPUs: []int{cpu}}); err != nil {
+			return err
+		}
+		return unix.SchedGetaffinity(0, &bound)
+	}))
+	assert.Equal(t, 1, bound.Count())
+	assert.True(t, bound.IsSet(cpu))
+
+	// The binding doesn't leak to the other threads.
+	var after unix.CPUSet
+	require.NoError(t, unix.SchedGetaffinity(0, &after))
+	assert.Equal(t, allowed.Count(), after.Count())
+}
@@ -0,0 +1,9 @@
+//go:build (cgo || ffimock) && !linux
+// +build cgo ffimock
+// +build !linux
+
+package ffi
+
+func bindThread(CPUBinding) error {
+	return ErrCPUBindingUnsupported
+}
@@ -0,0 +1,62 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := parseCPUList("0-3,8-9,16\n")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 8, 9, 16}, cpus)
+
+	cpus, err = parseCPUList("\n")
+	require.NoError(t, err)
+	assert.Empty(t, cpus)
+
+	for _, s := range []string{"a", "3-1", "0-", "1,,2"} {
+		_, err := parseCPUList(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestNUMANodes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("node1/cpulist", "8-15\n")
+	write("node1/meminfo", "Node 1 MemTotal:       1024 kB\nNode 1 MemFree:        512 kB\n")
+	write("node0/cpulist", "0-7\n")
+	write("online", "0-1\n")
+
+	nodes, err := numaNodes(dir)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, NUMANode{ID: 0, CPUs: []int{0, 1, 2, 3, 4, 5, 6, 7}}, nodes[0])
+	assert.Equal(t, NUMANode{ID: 1, CPUs: []int{8, 9, 10, 11, 12, 13, 14, 15}, MemoryBytes: 1 << 20}, nodes[1])
+	assert.Equal(t, CPUBinding{CPUs: nodes[1].CPUs, MemNodes: []int{1}}, nodes[1].Binding())
+}
+
+func TestSDRBinding(t *testing.T) {
+	_, ok := SDRBindingFrom(context.Background())
+	assert.False(t, ok)
+
+	b := CPUBinding{CPUs: []int{0}}
+	got, ok := SDRBindingFrom(WithSDRBinding(context.Background(), b))
+	assert.True(t, ok)
+	assert.Equal(t, b, got)
+
+	err := runCtx(context.Background(), func() error { return bindThread(CPUBinding{CPUs: []int{-1}}) })
+	assert.Error(t, err)
+}
@@ -0,0 +1,371 @@
+// Package paramfetch downloads the Groth16 parameters, verifying keys and SRS
+// files of the registered proofs to the directory the native library reads
+// them from.
+//
+// The files are described by manifests such as parameters.json and
+// srs-inner-product.json, which give the IPFS CID and digest of every file.
+// Files are fetched from an IPFS gateway, several at a time. Interrupted
+// downloads are resumed from their partial file on the next fetch, and every
+// file is checked against its digest before being moved in place.
+//
+// Fetch doesn't lock the directory: processes fetching to the same directory
+// at the same time may corrupt each other's partial files, which are then
+// fetched again.
+package paramfetch
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/xerrors"
+)
+
+// DefaultGateway is the IPFS gateway the files are fetched from.
+const DefaultGateway = "https://proofs.filecoin.io/ipfs/"
+
+// GatewayEnv overrides DefaultGateway.
+const GatewayEnv = "IPFS_GATEWAY"
+
+// DirEnv is the directory the native library reads the parameters from.
+const DirEnv = "FIL_PROOFS_PARAMETER_CACHE"
+
+// DefaultDir is the directory of the parameters when DirEnv is unset.
+const DefaultDir = "/var/tmp/filecoin-proof-parameters"
+
+// partSuffix is appended to the name of the files being downloaded.
+const partSuffix = ".part"
+
+// ErrDigest is returned for files not matching the digest of their manifest.
+var ErrDigest = xerrors.New("parameter file digest mismatch")
+
+// File is the manifest entry of a parameter file.
+type File struct {
+	CID    string `json:"cid"`
+	Digest string `json:"digest"`
+	// SectorSize is the size of the sectors of the proofs using the file, 0
+	// for files used by every sector size.
+	SectorSize uint64 `json:"sector_size"`
+}
+
+// Parse decodes a manifest, mapping file names to their entry.
+func Parse(manifest []byte) (map[string]File, error) {
+	var files map[string]File
+	if err := json.Unmarshal(manifest, &files); err != nil {
+		return nil, xerrors.Errorf("decoding parameters manifest: %w", err)
+	}
+	for name, f := range files {
+		if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+			return nil, xerrors.Errorf("invalid parameter file name %q", name)
+		}
+		if f.CID == "" || f.Digest == "" {
+			return nil, xerrors.Errorf("parameter file %s: missing cid or digest", name)
+		}
+	}
+	return files, nil
+}
+
+// Select returns the files of proofs of sectors of the given sizes, and those
+// used by every sector size.
+func Select(files map[string]File, sizes ...abi.SectorSize) map[string]File {
+	out := make(map[string]File)
+	for name, f := range files {
+		if f.SectorSize == 0 {
+			out[name] = f
+			continue
+		}
+		for _, size := range sizes {
+			if f.SectorSize == uint64(size) {
+				out[name] = f
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Progress reports the download of a file.
+type Progress struct {
+	Name string
+	// Done is the number of bytes of the file on disk, including those of a
+	// resumed download.
+	Done int64
+	// Total is the size of the file, or -1 when the gateway doesn't tell.
+	Total int64
+	// Finished is set on the last report of the file, once it is verified
+	// and in place.
+	Finished bool
+}
+
+// Options configures Fetch. The zero value fetches from DefaultGateway to the
+// directory of DirEnv, or DefaultDir.
+type Options struct {
+	// Dir is the directory the files are written to.
+	Dir string
+	// Gateway is the URL prefix of the CIDs of the files.
+	Gateway string
+	// Client makes the requests, http.DefaultClient by default.
+	Client *http.Client
+	// Concurrency is the number of files downloaded at a time, 4 by default.
+	Concurrency int
+	// Retries is the number of times a failed download is resumed, or
+	// restarted after a digest mismatch. It is 3 by default, and negative
+	// values disable retries.
+	Retries int
+	// RetryDelay is the time waited before retrying, 5s by default.
+	RetryDelay time.Duration
+	// Trust skips the digest check of the files already in place, which
+	// reads them entirely.
+	Trust bool
+	// Progress, when set, is called as the files are downloaded, possibly
+	// concurrently for distinct files.
+	Progress func(Progress)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Dir == "" {
+		o.Dir = os.Getenv(DirEnv)
+	}
+	if o.Dir == "" {
+		o.Dir = DefaultDir
+	}
+	if o.Gateway == "" {
+		o.Gateway = os.Getenv(GatewayEnv)
+	}
+	if o.Gateway == "" {
+		o.Gateway = DefaultGateway
+	}
+	if !strings.HasSuffix(o.Gateway, "/") {
+		o.Gateway += "/"
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.Retries == 0 {
+		o.Retries = 3
+	}
+	if o.RetryDelay == 0 {
+		o.RetryDelay = 5 * time.Second
+	}
+	return o
+}
+
+// Fetch makes sure every file is in the directory of opts and matches its
+// digest, downloading those missing or damaged. It returns once every file
+// is fetched or has failed, with the errors of the failed files. When ctx is
+// done, no more downloads are started, and Fetch returns the context error
+// once the running ones stopped.
+func Fetch(ctx context.Context, files map[string]File, opts Options) error {
+	opts = opts.withDefaults()
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return xerrors.Errorf("creating parameters directory: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		wg   sync.WaitGroup
+		lk   sync.Mutex
+		errs []string
+		sem  = make(chan struct{}, opts.Concurrency)
+	)
+	for _, name := range names {
+		name, f := name, files[name]
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// don't leave the running downloads writing to the directory
+			// after returning
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fetchFile(ctx, name, f, opts); err != nil {
+				lk.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+				lk.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return xerrors.Errorf("fetching %d of %d parameter files failed: %s", len(errs), len(files), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Verify checks the file at path against its digest.
+func Verify(path string, f File) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, in); err != nil {
+		return err
+	}
+
+	// The manifests give the first 16 bytes of the BLAKE2b-512 of the files.
+	if hex.EncodeToString(h.Sum(nil)[:16]) != f.Digest {
+		return ErrDigest
+	}
+	return nil
+}
+
+func fetchFile(ctx context.Context, name string, f File, opts Options) error {
+	path := filepath.Join(opts.Dir, name)
+	if st, err := os.Stat(path); err == nil {
+		if opts.Trust || Verify(path, f) == nil {
+			report(opts, Progress{Name: name, Done: st.Size(), Total: st.Size(), Finished: true})
+			return nil
+		}
+		// The file is damaged: download it again.
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	part := path + partSuffix
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = download(ctx, name, f, part, opts); err == nil {
+			if err = Verify(part, f); err == nil {
+				break
+			}
+			// Resuming a damaged file can't fix it.
+			_ = os.Remove(part)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= opts.Retries {
+			return err
+		}
+
+		select {
+		case <-time.After(opts.RetryDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := os.Rename(part, path); err != nil {
+		return err
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	report(opts, Progress{Name: name, Done: st.Size(), Total: st.Size(), Finished: true})
+	return nil
+}
+
+// download appends the rest of the file to its partial file.
+func download(ctx context.Context, name string, f File, part string, opts Options) error {
+	out, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close() // nolint: errcheck
+
+	offset, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.Gateway+f.CID, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	total := int64(-1)
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+	case http.StatusOK:
+		// The gateway ignored the range: start over.
+		if offset > 0 {
+			if err := out.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := out.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset = 0
+		}
+		total = resp.ContentLength
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is complete, or longer than the file.
+		return nil
+	default:
+		return xerrors.Errorf("fetching %s: %s", f.CID, resp.Status)
+	}
+
+	w := &progressWriter{w: out, opts: opts, p: Progress{Name: name, Done: offset, Total: total}}
+	report(opts, w.p)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return xerrors.Errorf("fetching %s: %w", f.CID, err)
+	}
+	return out.Close()
+}
+
+type progressWriter struct {
+	w    io.Writer
+	opts Options
+	p    Progress
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.p.Done += int64(n)
+	report(w.opts, w.p)
+	return n, err
+}
+
+func report(opts Options, p Progress) {
+	if opts.Progress != nil {
+		opts.Progress(p)
+	}
+}
@@ -0,0 +1,278 @@
+package paramfetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+func digestOf(data []byte) string {
+	sum := blake2b.Sum512(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// gateway serves files by CID, counting the requests and the bytes served.
+type gateway struct {
+	files    map[string][]byte
+	requests int32
+	served   int64
+	// ignoreRange serves whole files to range requests.
+	ignoreRange bool
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&g.requests, 1)
+	data, ok := g.files[strings.TrimPrefix(r.URL.Path, "/ipfs/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if g.ignoreRange {
+		r.Header.Del("Range")
+	}
+	cw := &countingWriter{ResponseWriter: w, n: &g.served}
+	http.ServeContent(cw, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	n *int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	atomic.AddInt64(w.n, int64(n))
+	return n, err
+}
+
+func setup(t *testing.T, n int) (*gateway, map[string]File, Options) {
+	g := &gateway{files: make(map[string][]byte)}
+	files := make(map[string]File)
+	for i := 0; i < n; i++ {
+		data := make([]byte, 64<<10+i)
+		rand.New(rand.NewSource(int64(i))).Read(data)
+
+		cid := "cid" + string(rune('a'+i))
+		g.files[cid] = data
+		files["v28-file-"+cid+".params"] = File{CID: cid, Digest: digestOf(data), SectorSize: 2048}
+	}
+
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+
+	return g, files, Options{
+		Dir:        t.TempDir(),
+		Gateway:    srv.URL + "/ipfs",
+		RetryDelay: time.Millisecond,
+	}
+}
+
+func TestFetch(t *testing.T) {
+	g, files, opts := setup(t, 5)
+	opts.Concurrency = 2
+
+	var lk sync.Mutex
+	finished := make(map[string]bool)
+	opts.Progress = func(p Progress) {
+		lk.Lock()
+		defer lk.Unlock()
+		assert.False(t, finished[p.Name], "progress of %s after it finished", p.Name)
+		finished[p.Name] = p.Finished
+	}
+
+	require.NoError(t, Fetch(context.Background(), files, opts))
+	for name, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(opts.Dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, g.files[f.CID], data)
+		assert.True(t, finished[name])
+	}
+
+	// Fetching again only verifies the files.
+	finished = make(map[string]bool)
+	requests := atomic.LoadInt32(&g.requests)
+	require.NoError(t, Fetch(context.Background(), files, opts))
+	assert.Equal(t, requests, atomic.LoadInt32(&g.requests))
+}
+
+func TestFetchResumes(t *testing.T) {
+	g, files, opts := setup(t, 1)
+
+	var name string
+	var f File
+	for name, f = range files {
+	}
+	data := g.files[f.CID]
+	require.NoError(t, ioutil.WriteFile(filepath.Join(opts.Dir, name+partSuffix), data[:1000], 0644))
+
+	require.NoError(t, Fetch(context.Background(), files, opts))
+	assert.Equal(t, int64(len(data)-1000), atomic.LoadInt64(&g.served))
+
+	got, err := ioutil.ReadFile(filepath.Join(opts.Dir, name))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	_, err = os.Stat(filepath.Join(opts.Dir, name+partSuffix))
+	assert.True(t, os.IsNotExist(err))
+
+	// A gateway ignoring ranges makes the download start over.
+	g.ignoreRange = true
+	require.NoError(t, os.Rename(filepath.Join(opts.Dir, name), filepath.Join(opts.Dir, name+partSuffix)))
+	require.NoError(t, os.Truncate(filepath.Join(opts.Dir, name+partSuffix), 1000))
+	require.NoError(t, Fetch(context.Background(), files, opts))
+	got, err = ioutil.ReadFile(filepath.Join(opts.Dir, name))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestFetchReplacesDamagedFiles(t *testing.T) {
+	g, files, opts := setup(t, 2)
+
+	for name := range files {
+		// A damaged file in place, and a damaged partial file.
+		require.NoError(t, ioutil.WriteFile(filepath.Join(opts.Dir, name), []byte("junk"), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(opts.Dir, name+partSuffix), []byte("junk"), 0644))
+	}
+
+	require.NoError(t, Fetch(context.Background(), files, opts))
+	for name, f := range files {
+		assert.NoError(t, Verify(filepath.Join(opts.Dir, name), f))
+	}
+
+	// Trusted files aren't checked.
+	for name := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(opts.Dir, name), []byte("junk"), 0644))
+	}
+	opts.Trust = true
+	requests := atomic.LoadInt32(&g.requests)
+	require.NoError(t, Fetch(context.Background(), files, opts))
+	assert.Equal(t, requests, atomic.LoadInt32(&g.requests))
+}
+
+func TestFetchErrors(t *testing.T) {
+	g, files, opts := setup(t, 2)
+	opts.Retries = 2
+
+	var bad string
+	for name, f := range files {
+		bad = name
+		f.Digest = digestOf([]byte("other"))
+		files[name] = f
+		break
+	}
+	files["missing.vk"] = File{CID: "missing", Digest: digestOf(nil)}
+
+	err := Fetch(context.Background(), files, opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fetching 2 of 3 parameter files failed")
+	assert.Contains(t, err.Error(), bad+": "+ErrDigest.Error())
+	assert.Contains(t, err.Error(), "missing.vk: fetching missing: 404")
+
+	// Both failed files were tried 3 times, the other one once.
+	assert.Equal(t, int32(7), atomic.LoadInt32(&g.requests))
+	for name := range files {
+		_, err := os.Stat(filepath.Join(opts.Dir, name))
+		assert.Equal(t, name != bad && name != "missing.vk", err == nil, name)
+	}
+}
+
+// closeTracker counts the response bodies still open, which are slow to
+// close, and closes received with the first response.
+type closeTracker struct {
+	open     int32
+	received chan struct{}
+	once     sync.Once
+}
+
+func (c *closeTracker) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt32(&c.open, 1)
+	resp.Body = &trackedBody{ReadCloser: resp.Body, open: &c.open}
+	c.once.Do(func() { close(c.received) })
+	return resp, nil
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	open *int32
+	once sync.Once
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(b.open, -1)
+	})
+	return err
+}
+
+func TestFetchCanceled(t *testing.T) {
+	_, files, opts := setup(t, 2)
+	opts.Concurrency = 1
+	opts.Retries = -1
+
+	// The first download stalls until its request is canceled.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		_, _ = w.Write([]byte{0})
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	opts.Gateway = srv.URL + "/ipfs/"
+
+	tracker := &closeTracker{received: make(chan struct{})}
+	opts.Client = &http.Client{Transport: tracker}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-tracker.received
+		cancel()
+	}()
+
+	err := Fetch(ctx, files, opts)
+	assert.ErrorIs(t, err, context.Canceled)
+	// The stalled download stopped before Fetch returned.
+	assert.Equal(t, int32(0), atomic.LoadInt32(&tracker.open))
+}
+
+func TestParse(t *testing.T) {
+	files, err := Parse([]byte(`{
+		"a.params": {"cid": "Qma", "digest": "00", "sector_size": 2048},
+		"b.vk": {"cid": "Qmb", "digest": "01", "sector_size": 34359738368},
+		"srs.srs": {"cid": "Qmc", "digest": "02", "sector_size": 0}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, File{CID: "Qmb", Digest: "01", SectorSize: 32 << 30}, files["b.vk"])
+
+	selected := Select(files, abi.SectorSize(2048))
+	assert.Len(t, selected, 2)
+	assert.Contains(t, selected, "a.params")
+	assert.Contains(t, selected, "srs.srs")
+	assert.Len(t, Select(files, 2048, 32<<30), 3)
+
+	_, err = Parse([]byte(`{"../a.params": {"cid": "Qma", "digest": "00"}}`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`{"a.params": {"cid": "Qma"}}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+package ffi
+
+import (
+	_ "embed" // for the parameter manifests
+)
+
+// ParametersJSON is the manifest of the Groth16 parameters and verifying keys
+// of the proofs supported by this version of the bindings, as read by the
+// paramfetch package.
+//
+//go:embed parameters.json
+var ParametersJSON []byte
+
+// SRSInnerProductJSON is the manifest of the SRS files used to aggregate seal
+// proofs.
+//
+//go:embed srs-inner-product.json
+var SRSInnerProductJSON []byte
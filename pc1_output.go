@@ -0,0 +1,130 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// PC1OutputVersion is the version of the files written by SavePC1Output.
+const PC1OutputVersion = 1
+
+// pc1OutputMagic prefixes every saved PC1 output.
+var pc1OutputMagic = [4]byte{'F', 'P', '1', 'O'}
+
+// pc1HeaderSize is the size of the file before the output itself.
+const pc1HeaderSize = 4 + 1 + 8 + 8 + 8 + 8
+
+// ErrPC1OutputChecksum is returned when loading a PC1 output damaged on disk.
+var ErrPC1OutputChecksum = xerrors.New("PC1 output checksum mismatch")
+
+// ErrPC1OutputMismatch is returned when loading the PC1 output of another
+// sector or proof type than the one expected.
+var ErrPC1OutputMismatch = xerrors.New("PC1 output of another sector")
+
+// SavePC1Output writes the output of SealPreCommitPhase1 to path, so that PC2
+// can run after a restart without redoing PC1.
+//
+// The file is a flat big-endian layout, the same as the Commit1Output
+// envelope:
+//
+//	magic "FP1O" | version u8 | seal proof i64 | miner u64 |
+//	sector number u64 | output len u64 | output | CRC-32C u32
+//
+// It is written to a temporary file synced and renamed over path, so that a
+// crash leaves either the previous file or the new one.
+func SavePC1Output(path string, proofType abi.RegisteredSealProof, sectorID abi.SectorID, phase1Output []byte) error {
+	var buf bytes.Buffer
+	buf.Grow(pc1HeaderSize + len(phase1Output) + 4)
+
+	buf.Write(pc1OutputMagic[:])
+	buf.WriteByte(PC1OutputVersion)
+	writeUint64(&buf, uint64(proofType))
+	writeUint64(&buf, uint64(sectorID.Miner))
+	writeUint64(&buf, uint64(sectorID.Number))
+	writeUint64(&buf, uint64(len(phase1Output)))
+	buf.Write(phase1Output)
+	writeUint32(&buf, crc32.Checksum(buf.Bytes(), castagnoli))
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return xerrors.Errorf("saving PC1 output: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return xerrors.Errorf("saving PC1 output: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return xerrors.Errorf("saving PC1 output: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return xerrors.Errorf("saving PC1 output: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadPC1Output reads a PC1 output saved with SavePC1Output, checking it is
+// intact and was computed for sectorID with proofType.
+func LoadPC1Output(path string, proofType abi.RegisteredSealProof, sectorID abi.SectorID) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("loading PC1 output: %w", err)
+	}
+
+	out, err := decodePC1Output(data, proofType, sectorID)
+	if err != nil {
+		return nil, xerrors.Errorf("loading PC1 output %s: %w", path, err)
+	}
+	return out, nil
+}
+
+func decodePC1Output(data []byte, proofType abi.RegisteredSealProof, sectorID abi.SectorID) ([]byte, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], pc1OutputMagic[:]) {
+		return nil, xerrors.New("not a saved PC1 output")
+	}
+	if len(data) < 5 {
+		return nil, xerrors.New("truncated PC1 output")
+	}
+	if data[4] != PC1OutputVersion {
+		return nil, xerrors.Errorf("unsupported PC1 output version %d", data[4])
+	}
+	if len(data) < pc1HeaderSize+4 {
+		return nil, xerrors.New("truncated PC1 output")
+	}
+
+	size := binary.BigEndian.Uint64(data[29:pc1HeaderSize])
+	if size != uint64(len(data)-pc1HeaderSize-4) {
+		return nil, xerrors.Errorf("PC1 output of %d bytes in a file of %d bytes", size, len(data))
+	}
+
+	end := len(data) - 4
+	if crc32.Checksum(data[:end], castagnoli) != binary.BigEndian.Uint32(data[end:]) {
+		return nil, ErrPC1OutputChecksum
+	}
+
+	gotProof := abi.RegisteredSealProof(binary.BigEndian.Uint64(data[5:13]))
+	gotID := abi.SectorID{
+		Miner:  abi.ActorID(binary.BigEndian.Uint64(data[13:21])),
+		Number: abi.SectorNumber(binary.BigEndian.Uint64(data[21:29])),
+	}
+	if gotProof != proofType || gotID != sectorID {
+		return nil, fmt.Errorf("%w: saved for sector %d of miner %d with proof %d, expected sector %d of miner %d with proof %d",
+			ErrPC1OutputMismatch, gotID.Number, gotID.Miner, gotProof, sectorID.Number, sectorID.Miner, proofType)
+	}
+
+	return data[pc1HeaderSize:end], nil
+}
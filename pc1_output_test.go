@@ -0,0 +1,61 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPC1OutputRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pc1.out")
+	sid := abi.SectorID{Miner: 1000, Number: 42}
+	proofType := abi.RegisteredSealProof_StackedDrg32GiBV1_1
+
+	require.NoError(t, SavePC1Output(path, proofType, sid, []byte("pc1o")))
+	out, err := LoadPC1Output(path, proofType, sid)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pc1o"), out)
+
+	// Saving again replaces the file.
+	require.NoError(t, SavePC1Output(path, proofType, sid, []byte("pc1o2")))
+	out, err = LoadPC1Output(path, proofType, sid)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pc1o2"), out)
+
+	_, err = LoadPC1Output(path, proofType, abi.SectorID{Miner: 1000, Number: 43})
+	assert.True(t, errors.Is(err, ErrPC1OutputMismatch), err)
+	assert.True(t, strings.HasSuffix(err.Error(), ": PC1 output of another sector: saved for sector 42 of miner 1000 with proof 8, expected sector 43 of miner 1000 with proof 8"), err)
+	_, err = LoadPC1Output(path, abi.RegisteredSealProof_StackedDrg64GiBV1_1, sid)
+	assert.True(t, errors.Is(err, ErrPC1OutputMismatch), err)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	damaged := append([]byte(nil), data...)
+	damaged[pc1HeaderSize] ^= 1
+	_, err = decodePC1Output(damaged, proofType, sid)
+	assert.True(t, errors.Is(err, ErrPC1OutputChecksum), err)
+
+	_, err = decodePC1Output(data[:len(data)-1], proofType, sid)
+	assert.Error(t, err)
+
+	future := append([]byte(nil), data...)
+	future[4] = PC1OutputVersion + 1
+	_, err = decodePC1Output(future, proofType, sid)
+	assert.EqualError(t, err, "unsupported PC1 output version 2")
+
+	_, err = decodePC1Output([]byte("pc1o"), proofType, sid)
+	assert.Error(t, err)
+
+	_, err = LoadPC1Output(filepath.Join(t.TempDir(), "missing"), proofType, sid)
+	assert.Error(t, err)
+}
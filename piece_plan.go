@@ -0,0 +1,81 @@
+package ffi
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// PlacedPiece is the position of a piece in a PiecePlan.
+type PlacedPiece struct {
+	Size abi.UnpaddedPieceSize
+	// Offset is the position of the piece in the unpadded sector.
+	Offset uint64
+	// LeftAlignment is the zero padding written before the piece to align
+	// it on a multiple of its size.
+	LeftAlignment abi.UnpaddedPieceSize
+}
+
+// PiecePlan is the layout of pieces added to a sector with alignment.
+type PiecePlan struct {
+	// Pieces are the incoming pieces, in order.
+	Pieces []PlacedPiece
+	// End is the padded end of the last piece.
+	End abi.PaddedPieceSize
+	// Overflow is set when the pieces don't fit in the sector, in which case
+	// Free is 0.
+	Overflow bool
+	// Free is the padded space left after the last piece.
+	Free abi.PaddedPieceSize
+}
+
+// PlanPieces computes where incoming pieces are written when added after the
+// existing ones with WriteWithAlignment or AddPieces, without writing
+// anything. It only fails on invalid piece sizes: a layout not fitting in the
+// sector is reported by PiecePlan.Overflow.
+func PlanPieces(sectorSize abi.SectorSize, existing, incoming []abi.UnpaddedPieceSize) (PiecePlan, error) {
+	for i, size := range existing {
+		if err := size.Validate(); err != nil {
+			return PiecePlan{}, xerrors.Errorf("existing piece %d: %w", i, err)
+		}
+	}
+
+	plan := PiecePlan{End: piecesEnd(existing)}
+	for i, size := range incoming {
+		if err := size.Validate(); err != nil {
+			return PiecePlan{}, xerrors.Errorf("piece %d: %w", i, err)
+		}
+
+		padded := size.Padded()
+		left := pieceAlignment(plan.End, padded)
+		plan.Pieces = append(plan.Pieces, PlacedPiece{
+			Size:          size,
+			Offset:        uint64((plan.End + left).Unpadded()),
+			LeftAlignment: left.Unpadded(),
+		})
+		plan.End += left + padded
+	}
+
+	if plan.End > abi.PaddedPieceSize(sectorSize) {
+		plan.Overflow = true
+	} else {
+		plan.Free = abi.PaddedPieceSize(sectorSize) - plan.End
+	}
+	return plan, nil
+}
+
+// pieceAlignment returns the zero padding written at offset before a piece
+// of the given padded size.
+func pieceAlignment(offset, size abi.PaddedPieceSize) abi.PaddedPieceSize {
+	return (size - offset%size) % size
+}
+
+// piecesEnd returns the padded end of pieces written with alignment, the way
+// the native library computes it from the existing piece sizes.
+func piecesEnd(sizes []abi.UnpaddedPieceSize) abi.PaddedPieceSize {
+	var end abi.PaddedPieceSize
+	for _, size := range sizes {
+		padded := size.Padded()
+		end += pieceAlignment(end, padded) + padded
+	}
+	return end
+}
@@ -0,0 +1,46 @@
+package ffi
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPiecesEnd(t *testing.T) {
+	assert.Equal(t, abi.PaddedPieceSize(0), piecesEnd(nil))
+	// 128 | pad 128 | 256 | 128 | pad 384 | 512
+	assert.Equal(t, abi.PaddedPieceSize(1536), piecesEnd([]abi.UnpaddedPieceSize{127, 254, 127, 508}))
+	assert.Equal(t, abi.PaddedPieceSize(384), pieceAlignment(640, 512))
+	assert.Equal(t, abi.PaddedPieceSize(0), pieceAlignment(1024, 512))
+}
+
+func TestPlanPieces(t *testing.T) {
+	plan, err := PlanPieces(2048, []abi.UnpaddedPieceSize{127}, []abi.UnpaddedPieceSize{508, 127})
+	require.NoError(t, err)
+	assert.Equal(t, PiecePlan{
+		Pieces: []PlacedPiece{
+			{Size: 508, Offset: 508, LeftAlignment: 381},
+			{Size: 127, Offset: 1016, LeftAlignment: 0},
+		},
+		End:  1152,
+		Free: 896,
+	}, plan)
+
+	plan, err = PlanPieces(2048, []abi.UnpaddedPieceSize{127}, []abi.UnpaddedPieceSize{1016, 127})
+	require.NoError(t, err)
+	assert.True(t, plan.Overflow)
+	assert.Equal(t, abi.PaddedPieceSize(2176), plan.End)
+	assert.Equal(t, abi.PaddedPieceSize(0), plan.Free)
+	assert.Equal(t, uint64(1016), plan.Pieces[0].Offset)
+
+	plan, err = PlanPieces(2048, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, PiecePlan{Free: 2048}, plan)
+
+	_, err = PlanPieces(2048, []abi.UnpaddedPieceSize{0}, nil)
+	assert.Error(t, err)
+	_, err = PlanPieces(2048, nil, []abi.UnpaddedPieceSize{100})
+	assert.Error(t, err)
+}
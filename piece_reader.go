@@ -0,0 +1,184 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/fr32"
+)
+
+// PaddedSize returns the unpadded size of the smallest piece which can hold
+// payloadSize bytes, i.e. the size markets pad deal data to before adding it
+// to a sector.
+//
+// Deprecated: despite its name, PaddedSize returns an unpadded size; use
+// fr32.PieceSize, which returns the padded size of the piece.
+func PaddedSize(payloadSize uint64) abi.UnpaddedPieceSize {
+	return pieceSize(payloadSize)
+}
+
+// pieceSize returns the unpadded size of the smallest piece which can hold
+// payloadSize bytes.
+func pieceSize(payloadSize uint64) abi.UnpaddedPieceSize {
+	return abi.PaddedPieceSize(fr32.PieceSize(payloadSize)).Unpadded()
+}
+
+// PaddedPieceReader reads a deal payload followed by the zero padding up to
+// the size of its piece, computing the piece commitment of the data as it is
+// read. The data it yields is what AddPiece expects for the piece, so transferring a
+// deal and computing its CommP can share a single pass over the payload.
+type PaddedPieceReader struct {
+	src  io.Reader
+	size abi.UnpaddedPieceSize
+
+	pw        *os.File
+	closeOnce sync.Once
+	complete  bool
+
+	done  chan struct{}
+	commP cid.Cid
+	err   error
+}
+
+// NewPaddedPieceReader returns a PaddedPieceReader over the payloadSize bytes
+// read from r. Reading fails if r holds less than payloadSize bytes; any
+// further bytes are not read.
+func NewPaddedPieceReader(proofType abi.RegisteredSealProof, r io.Reader, payloadSize uint64) (*PaddedPieceReader, error) {
+	size := pieceSize(payloadSize)
+
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+	if size.Padded() > abi.PaddedPieceSize(ssize) {
+		return nil, xerrors.Errorf("payload of %d bytes does not fit in a %d byte sector", payloadSize, ssize)
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, xerrors.Errorf("creating commp pipe: %w", err)
+	}
+
+	out := &PaddedPieceReader{
+		src: io.MultiReader(
+			&exactReader{r: r, remaining: payloadSize},
+			io.LimitReader(zeroReader{}, int64(uint64(size)-payloadSize)),
+		),
+		size: size,
+		pw:   pw,
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(out.done)
+		defer pr.Close() // nolint:errcheck
+
+		out.commP, out.err = GeneratePieceCIDFromFile(proofType, pr, size)
+	}()
+
+	return out, nil
+}
+
+// Size returns the unpadded size of the piece, which is the total number of
+// bytes the reader yields.
+func (r *PaddedPieceReader) Size() abi.UnpaddedPieceSize {
+	return r.size
+}
+
+func (r *PaddedPieceReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+	if n > 0 {
+		if _, werr := r.pw.Write(p[:n]); werr != nil {
+			r.closePipe()
+			<-r.done
+			if r.err != nil {
+				return n, xerrors.Errorf("computing piece commitment: %w", r.err)
+			}
+			return n, xerrors.Errorf("writing to commp pipe: %w", werr)
+		}
+	}
+
+	if err == io.EOF {
+		r.complete = true
+		r.closePipe()
+	}
+
+	return n, err
+}
+
+// PieceInfo returns the size and commitment of the piece. It must only be
+// called once the reader has been read to EOF, and blocks until the
+// commitment is computed.
+func (r *PaddedPieceReader) PieceInfo() (abi.PieceInfo, error) {
+	if !r.complete {
+		return abi.PieceInfo{}, xerrors.New("piece has not been fully read")
+	}
+
+	<-r.done
+	if r.err != nil {
+		return abi.PieceInfo{}, r.err
+	}
+
+	return abi.PieceInfo{
+		Size:     r.size.Padded(),
+		PieceCID: r.commP,
+	}, nil
+}
+
+// Close stops the commitment computation if the piece was not fully read,
+// and waits for it to finish. It does not close the underlying reader.
+func (r *PaddedPieceReader) Close() error {
+	r.closePipe()
+	<-r.done
+	return nil
+}
+
+func (r *PaddedPieceReader) closePipe() {
+	r.closeOnce.Do(func() {
+		_ = r.pw.Close()
+	})
+}
+
+// exactReader reads exactly remaining bytes from r, failing with
+// io.ErrUnexpectedEOF if r ends early.
+type exactReader struct {
+	r         io.Reader
+	remaining uint64
+}
+
+func (e *exactReader) Read(p []byte) (int, error) {
+	if e.remaining == 0 {
+		return 0, io.EOF
+	}
+	if uint64(len(p)) > e.remaining {
+		p = p[:e.remaining]
+	}
+
+	n, err := e.r.Read(p)
+	e.remaining -= uint64(n)
+	if err == io.EOF {
+		if e.remaining > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		err = nil
+	}
+
+	return n, err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,43 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaddedSize(t *testing.T) {
+	for payload, expected := range map[uint64]abi.UnpaddedPieceSize{
+		0:        127,
+		1:        127,
+		127:      127,
+		128:      254,
+		254:      254,
+		255:      508,
+		1000:     1016,
+		1 << 20:  2080768,
+		1040384:  1040384,
+		1040385:  2080768,
+		32 << 30: abi.PaddedPieceSize(64 << 30).Unpadded(),
+	} {
+		assert.Equal(t, expected, PaddedSize(payload), "payload %d", payload)
+		assert.NoError(t, PaddedSize(payload).Validate())
+	}
+}
+
+func TestExactReader(t *testing.T) {
+	out, err := ioutil.ReadAll(&exactReader{r: bytes.NewReader([]byte("hello world")), remaining: 5})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), out)
+
+	_, err = ioutil.ReadAll(&exactReader{r: bytes.NewReader([]byte("hi")), remaining: 5})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,196 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// ErrPinLimit is returned by PinnedSectors.Pin when pinning the sector would
+// exceed the configured memory limit.
+var ErrPinLimit = xerrors.New("pinned sectors memory limit exceeded")
+
+// PinnedSectors keeps the tree_r_last and p_aux files of a set of hot sectors
+// resident in memory, so that proving them doesn't wait on storage IO for the
+// trees. This is meant for the sectors eligible for winning PoSt, which must
+// be proven within the block time even when the storage is busy.
+//
+// The files are memory mapped and locked, which keeps their pages in the page
+// cache the prover reads them from. The process must be allowed to lock that
+// much memory (RLIMIT_MEMLOCK). Pinning is only supported on Linux.
+type PinnedSectors struct {
+	maxBytes int64
+
+	lk      sync.Mutex
+	used    int64
+	sectors map[abi.SectorID]*pinnedSector
+}
+
+type pinnedSector struct {
+	cacheDirPath string
+	size         int64
+	mappings     [][]byte
+}
+
+// NewPinnedSectors returns an empty set of pinned sectors, which will lock at
+// most maxBytes of memory. A maxBytes of zero means no limit.
+func NewPinnedSectors(maxBytes int64) *PinnedSectors {
+	return &PinnedSectors{
+		maxBytes: maxBytes,
+		sectors:  map[abi.SectorID]*pinnedSector{},
+	}
+}
+
+// Pin loads the tree_r_last and p_aux files of a sector from its cache
+// directory and keeps them resident until the sector is unpinned. Pinning an
+// already pinned sector refreshes its files if the cache directory changed.
+func (p *PinnedSectors) Pin(id abi.SectorID, sealProof abi.RegisteredSealProof, cacheDirPath string) error {
+	ssize, err := sealProof.SectorSize()
+	if err != nil {
+		return err
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if old, ok := p.sectors[id]; ok {
+		if old.cacheDirPath == cacheDirPath {
+			return nil
+		}
+		p.unpin(id, old)
+	}
+
+	paths := []string{filepath.Join(cacheDirPath, cachePAuxName)}
+	files := treeRLastFileCount(ssize)
+	for i := uint64(0); i < files; i++ {
+		paths = append(paths, treeRLastPath(cacheDirPath, i, files))
+	}
+
+	sector := &pinnedSector{cacheDirPath: cacheDirPath}
+	for _, path := range paths {
+		st, err := os.Stat(path)
+		if err != nil {
+			unlockSector(sector)
+			return xerrors.Errorf("pinning sector %d: %w", id.Number, err)
+		}
+
+		if p.maxBytes > 0 && p.used+sector.size+st.Size() > p.maxBytes {
+			unlockSector(sector)
+			return ErrPinLimit
+		}
+
+		if st.Size() == 0 {
+			continue
+		}
+
+		b, err := mapAndLock(path, st.Size())
+		if err != nil {
+			unlockSector(sector)
+			return xerrors.Errorf("pinning sector %d: %w", id.Number, err)
+		}
+		sector.mappings = append(sector.mappings, b)
+		sector.size += st.Size()
+	}
+
+	p.sectors[id] = sector
+	p.used += sector.size
+
+	return nil
+}
+
+// Unpin releases the files of a sector. Unpinning a sector which isn't pinned
+// is not an error.
+func (p *PinnedSectors) Unpin(id abi.SectorID) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if sector, ok := p.sectors[id]; ok {
+		p.unpin(id, sector)
+	}
+}
+
+func (p *PinnedSectors) unpin(id abi.SectorID, sector *pinnedSector) {
+	unlockSector(sector)
+	p.used -= sector.size
+	delete(p.sectors, id)
+}
+
+func unlockSector(sector *pinnedSector) {
+	for _, b := range sector.mappings {
+		_ = unlockAndUnmap(b)
+	}
+	sector.mappings = nil
+}
+
+// Pinned returns the pinned sectors, ordered by miner and sector number.
+func (p *PinnedSectors) Pinned() []abi.SectorID {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	out := make([]abi.SectorID, 0, len(p.sectors))
+	for id := range p.sectors {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Miner != out[j].Miner {
+			return out[i].Miner < out[j].Miner
+		}
+		return out[i].Number < out[j].Number
+	})
+
+	return out
+}
+
+// PinnedBytes returns the amount of memory locked by the pinned sectors.
+func (p *PinnedSectors) PinnedBytes() int64 {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	return p.used
+}
+
+// Close unpins all the sectors.
+func (p *PinnedSectors) Close() {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	for id, sector := range p.sectors {
+		p.unpin(id, sector)
+	}
+}
+
+// GenerateWinningPoSt is GenerateWinningPoSt, issuing read-ahead for the
+// replica regions the challenges of the sectors will read before proving.
+// Together with pinning the sectors' trees, this keeps proving from queueing
+// behind other storage IO.
+func (p *PinnedSectors) GenerateWinningPoSt(
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof5.PoStProof, error) {
+	sectors := privateSectorInfo.Values()
+	if len(sectors) > 0 {
+		numbers := make([]abi.SectorNumber, len(sectors))
+		for i, s := range sectors {
+			numbers[i] = s.SectorNumber
+		}
+
+		challenges, err := GeneratePoStFallbackSectorChallenges(sectors[0].PoStProofType, minerID, randomness, numbers)
+		if err != nil {
+			return nil, xerrors.Errorf("generating challenges: %w", err)
+		}
+		if err := PrefetchPoStChallenges(sectors, challenges); err != nil {
+			return nil, err
+		}
+	}
+
+	return GenerateWinningPoSt(minerID, privateSectorInfo, randomness)
+}
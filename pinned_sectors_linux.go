@@ -0,0 +1,39 @@
+//go:build (cgo || ffimock) && linux
+// +build cgo ffimock
+// +build linux
+
+package ffi
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+func mapAndLock(path string, size int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	b, err := unix.Mmap(int(f.Fd()), 0, int(size), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, xerrors.Errorf("mapping %s: %w", path, err)
+	}
+
+	if err := unix.Mlock(b); err != nil {
+		_ = unix.Munmap(b)
+		return nil, xerrors.Errorf("locking %s (check RLIMIT_MEMLOCK): %w", path, err)
+	}
+
+	return b, nil
+}
+
+func unlockAndUnmap(b []byte) error {
+	if err := unix.Munlock(b); err != nil {
+		return err
+	}
+	return unix.Munmap(b)
+}
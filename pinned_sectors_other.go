@@ -0,0 +1,15 @@
+//go:build (cgo || ffimock) && !linux
+// +build cgo ffimock
+// +build !linux
+
+package ffi
+
+import "golang.org/x/xerrors"
+
+func mapAndLock(string, int64) ([]byte, error) {
+	return nil, xerrors.New("pinning sector files is not supported on this platform")
+}
+
+func unlockAndUnmap([]byte) error {
+	return nil
+}
@@ -0,0 +1,45 @@
+package ffi
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnedSectors(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pinning is only supported on linux")
+	}
+
+	cache := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cache, "p_aux"), make([]byte, 64), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cache, "sc-02-data-tree-r-last.dat"), make([]byte, 4096), 0644))
+
+	pinned := NewPinnedSectors(8192)
+	defer pinned.Close()
+
+	a := abi.SectorID{Miner: 1000, Number: 1}
+	b := abi.SectorID{Miner: 1000, Number: 2}
+
+	require.NoError(t, pinned.Pin(a, abi.RegisteredSealProof_StackedDrg2KiBV1_1, cache))
+	assert.Equal(t, int64(4160), pinned.PinnedBytes())
+
+	// pinning again is a no-op
+	require.NoError(t, pinned.Pin(a, abi.RegisteredSealProof_StackedDrg2KiBV1_1, cache))
+	assert.Equal(t, int64(4160), pinned.PinnedBytes())
+
+	require.ErrorIs(t, pinned.Pin(b, abi.RegisteredSealProof_StackedDrg2KiBV1_1, cache), ErrPinLimit)
+	require.Error(t, pinned.Pin(b, abi.RegisteredSealProof_StackedDrg2KiBV1_1, t.TempDir()))
+	assert.Equal(t, []abi.SectorID{a}, pinned.Pinned())
+
+	pinned.Unpin(a)
+	assert.Equal(t, int64(0), pinned.PinnedBytes())
+
+	require.NoError(t, pinned.Pin(b, abi.RegisteredSealProof_StackedDrg2KiBV1_1, cache))
+	assert.Equal(t, []abi.SectorID{b}, pinned.Pinned())
+}
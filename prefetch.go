@@ -0,0 +1,224 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+const (
+	nodeSize = 32
+
+	// treeRLastArity is the arity of the tree_r_last octrees.
+	treeRLastArity = 8
+
+	// treeRLastRowsToDiscard is the rust-fil-proofs default for the number of
+	// tree_r_last rows above the leaves which are not persisted, and are
+	// rebuilt from the replica when proving.
+	treeRLastRowsToDiscard = 2
+)
+
+// prefetchRegion is a byte range of a file which will be read.
+type prefetchRegion struct {
+	offset int64
+	length int64
+}
+
+// PrefetchPoStChallenges issues read-ahead for the regions of the replica and
+// of the tree_r_last files which proving the given challenges will read, so
+// that the IO is in flight before the prover asks for it. This cuts proving
+// time on high-latency storage.
+//
+// The read-ahead is advisory: it returns once the requests are issued, and
+// sectors whose files can't be found are skipped. It is a no-op on platforms
+// without posix_fadvise.
+func PrefetchPoStChallenges(sectors []PrivateSectorInfo, challenges *FallbackChallenges) error {
+	for _, sector := range sectors {
+		sectorChallenges, ok := challenges.Challenges[sector.SectorNumber]
+		if !ok || len(sectorChallenges) == 0 {
+			continue
+		}
+
+		ssize, err := sector.SealProof.SectorSize()
+		if err != nil {
+			return err
+		}
+
+		if err := prefetchSector(sector, ssize, sectorChallenges); err != nil {
+			return xerrors.Errorf("prefetching sector %d: %w", sector.SectorNumber, err)
+		}
+	}
+
+	return nil
+}
+
+func prefetchSector(sector PrivateSectorInfo, ssize abi.SectorSize, challenges []uint64) error {
+	nodes := uint64(ssize) / nodeSize
+	files := treeRLastFileCount(ssize)
+	nodesPerFile := nodes / files
+
+	// The discarded rows are rebuilt from the replica: every challenge needs
+	// all the leaves below its ancestor in the lowest persisted row.
+	leavesPerBlock := pow(treeRLastArity, treeRLastRowsToDiscard+1)
+	if leavesPerBlock > nodesPerFile {
+		leavesPerBlock = nodesPerFile
+	}
+
+	replica := make([]prefetchRegion, 0, len(challenges))
+	trees := make(map[uint64][]prefetchRegion)
+	for _, c := range challenges {
+		if c >= nodes {
+			return xerrors.Errorf("challenge %d out of range for %d nodes", c, nodes)
+		}
+
+		replica = append(replica, prefetchRegion{
+			offset: int64(c / leavesPerBlock * leavesPerBlock * nodeSize),
+			length: int64(leavesPerBlock * nodeSize),
+		})
+
+		file := c / nodesPerFile
+		trees[file] = append(trees[file], treeRLastRegions(nodesPerFile, c%nodesPerFile)...)
+	}
+
+	if err := prefetchFile(sector.SealedSectorPath, replica, 0); err != nil {
+		return err
+	}
+
+	for file, regions := range trees {
+		path := treeRLastPath(sector.CacheDirPath, file, files)
+		if err := prefetchFile(path, regions, treeRLastCachedSize(nodesPerFile)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// treeRLastRegions returns the regions of a tree_r_last file holding the
+// path of a leaf, together with the siblings of each node on it, through the
+// persisted rows. The file holds the persisted rows one after the other,
+// starting from the lowest one.
+func treeRLastRegions(leaves uint64, leaf uint64) []prefetchRegion {
+	var out []prefetchRegion
+
+	var rowOffset uint64
+	row := uint64(treeRLastRowsToDiscard + 1)
+	for width := leaves / pow(treeRLastArity, row); width > 1; width /= treeRLastArity {
+		idx := leaf / pow(treeRLastArity, row)
+		group := idx / treeRLastArity * treeRLastArity
+
+		out = append(out, prefetchRegion{
+			offset: int64((rowOffset + group) * nodeSize),
+			length: treeRLastArity * nodeSize,
+		})
+
+		rowOffset += width
+		row++
+	}
+
+	return out
+}
+
+// treeRLastCachedSize returns the expected size of a tree_r_last file over
+// the given number of leaves.
+func treeRLastCachedSize(leaves uint64) int64 {
+	var size uint64
+	for width := leaves / pow(treeRLastArity, treeRLastRowsToDiscard+1); width >= 1; width /= treeRLastArity {
+		size += width * nodeSize
+	}
+	return int64(size)
+}
+
+// treeRLastFileCount returns the number of files tree_r_last is split into,
+// following the sector shapes of rust-fil-proofs.
+func treeRLastFileCount(ssize abi.SectorSize) uint64 {
+	switch ssize {
+	case 4 << 10, 16 << 20, 1 << 30:
+		return 2
+	case 16 << 10, 32 << 30:
+		return 8
+	case 32 << 10, 64 << 30:
+		return 16
+	default:
+		return 1
+	}
+}
+
+func treeRLastPath(cacheDirPath string, file uint64, files uint64) string {
+	if files == 1 {
+		return filepath.Join(cacheDirPath, "sc-02-data-tree-r-last.dat")
+	}
+	return filepath.Join(cacheDirPath, fmt.Sprintf("sc-02-data-tree-r-last-%d.dat", file))
+}
+
+// prefetchFile issues read-ahead for the given regions of a file. When
+// expectedSize is set and the file size differs, the layout is not the one
+// the regions were computed for and the whole file is prefetched instead.
+func prefetchFile(path string, regions []prefetchRegion, expectedSize int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close() // nolint:errcheck
+
+	if expectedSize > 0 {
+		st, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if st.Size() != expectedSize {
+			regions = []prefetchRegion{{offset: 0, length: st.Size()}}
+		}
+	}
+
+	for _, r := range mergeRegions(regions) {
+		if err := fadviseWillNeed(f, r.offset, r.length); err != nil {
+			return xerrors.Errorf("read-ahead of %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// mergeRegions sorts regions and coalesces the overlapping or adjacent ones.
+func mergeRegions(regions []prefetchRegion) []prefetchRegion {
+	if len(regions) == 0 {
+		return nil
+	}
+
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].offset < regions[j].offset
+	})
+
+	out := []prefetchRegion{regions[0]}
+	for _, r := range regions[1:] {
+		last := &out[len(out)-1]
+		if r.offset <= last.offset+last.length {
+			if end := r.offset + r.length; end > last.offset+last.length {
+				last.length = end - last.offset
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+
+	return out
+}
+
+func pow(base, exp uint64) uint64 {
+	out := uint64(1)
+	for i := uint64(0); i < exp; i++ {
+		out *= base
+	}
+	return out
+}
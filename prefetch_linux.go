@@ -0,0 +1,19 @@
+//go:build (cgo || ffimock) && linux
+// +build cgo ffimock
+// +build linux
+
+package ffi
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func fadviseWillNeed(f *os.File, offset, length int64) error {
+	return unix.Fadvise(int(f.Fd()), offset, length, unix.FADV_WILLNEED)
+}
+
+func fadviseSequential(f *os.File) error {
+	return unix.Fadvise(int(f.Fd()), 0, 0, unix.FADV_SEQUENTIAL)
+}
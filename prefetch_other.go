@@ -0,0 +1,15 @@
+//go:build (cgo || ffimock) && !linux
+// +build cgo ffimock
+// +build !linux
+
+package ffi
+
+import "os"
+
+func fadviseWillNeed(*os.File, int64, int64) error {
+	return nil
+}
+
+func fadviseSequential(*os.File) error {
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeRLastLayout(t *testing.T) {
+	// 32GiB sectors have 8 tree_r_last files of 2^27 leaves each, of which the
+	// rows from 2^18 nodes up to the root are persisted
+	assert.Equal(t, uint64(8), treeRLastFileCount(32<<30))
+	for ssize, files := range map[uint64]uint64{2 << 10: 1, 8 << 20: 1, 512 << 20: 1, 64 << 30: 16} {
+		assert.Equal(t, files, treeRLastFileCount(abi.SectorSize(ssize)), "sector size %d", ssize)
+	}
+	assert.Equal(t, int64(299593*nodeSize), treeRLastCachedSize(1<<27))
+
+	regions := treeRLastRegions(1<<27, 1<<27-1)
+	assert.Len(t, regions, 6)
+	assert.Equal(t, prefetchRegion{offset: (1<<18 - 8) * nodeSize, length: 8 * nodeSize}, regions[0])
+	last := regions[len(regions)-1]
+	assert.Equal(t, treeRLastCachedSize(1<<27)-nodeSize, last.offset+last.length)
+}
+
+func TestMergeRegions(t *testing.T) {
+	merged := mergeRegions([]prefetchRegion{
+		{offset: 100, length: 10},
+		{offset: 0, length: 50},
+		{offset: 40, length: 20},
+		{offset: 60, length: 5},
+	})
+	assert.Equal(t, []prefetchRegion{{offset: 0, length: 65}, {offset: 100, length: 10}}, merged)
+}
+
+func TestReadThrough(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v28-test.vk")
+	require.NoError(t, ioutil.WriteFile(path, make([]byte, 3<<20+1), 0644))
+	assert.NoError(t, readThrough(path))
+
+	err := readThrough(path + ".missing")
+	assert.True(t, os.IsNotExist(err), err)
+}
@@ -0,0 +1,68 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io"
+	"os"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// PreloadSealParams loads the Groth16 parameters and verifying keys of the
+// seal proofs ahead of their first use, so that the first seal commit or
+// verification doesn't wait on the files being read from storage.
+//
+// The files are read through the page cache, and the verifying keys are
+// loaded into the in-memory cache of the native library by verifying a dummy
+// proof. The parameters are loaded by the native library on their first use,
+// from the page cache: they stay there as long as the system has the memory
+// to spare. Reading the parameters of large sectors takes as long as reading
+// tens of GiB from storage.
+func PreloadSealParams(proofTypes ...abi.RegisteredSealProof) error {
+	for _, p := range proofTypes {
+		if err := preloadSealParams(p); err != nil {
+			return xerrors.Errorf("preloading parameters of seal proof %d: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// PreloadPoStParams loads the Groth16 parameters and verifying keys of the
+// PoSt proofs ahead of their first use, in the same way as PreloadSealParams.
+// It is meant to be called on startup, so that the first winning PoSt doesn't
+// spend its time budget on loading the parameters.
+func PreloadPoStParams(proofTypes ...abi.RegisteredPoStProof) error {
+	for _, p := range proofTypes {
+		if err := preloadPoStParams(p); err != nil {
+			return xerrors.Errorf("preloading parameters of PoSt proof %d: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// readThrough reads the file at path, pulling it into the page cache.
+func readThrough(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint: errcheck
+
+	if err := fadviseSequential(f); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1<<20)
+	for {
+		_, err := f.Read(buf)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
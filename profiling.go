@@ -0,0 +1,67 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// Labels of the goroutines making native calls.
+const (
+	labelCall      = "ffi.call"
+	labelProofType = "ffi.proof_type"
+	labelSectorID  = "ffi.sector_id"
+)
+
+var (
+	profilerLabelsLk     sync.Mutex
+	removeProfilerLabels func()
+)
+
+// SetProfilerLabels makes every native call set the pprof
+// labels of its goroutine to the name of the native function, the proof type
+// and the sector number of the call, so that CPU profiles attribute the time
+// spent in the native library to the proof operations. It is disabled by
+// default.
+//
+// Only the samples of the calling thread carry the labels, not those of the
+// threads the native library starts. The Ctx variants add the labels to those
+// of their context, set by the caller with pprof.Do, and restore them when
+// the call returns. Other functions clear the labels of the goroutine when
+// the call returns, including those set by the caller.
+func SetProfilerLabels(enabled bool) {
+	profilerLabelsLk.Lock()
+	defer profilerLabelsLk.Unlock()
+
+	if removeProfilerLabels != nil {
+		removeProfilerLabels()
+		removeProfilerLabels = nil
+		atomic.AddInt32(&callerCtxUsers, -1)
+	}
+	if enabled {
+		atomic.AddInt32(&callerCtxUsers, 1)
+		removeProfilerLabels = cgo.AddCallHook(profilerLabelsHook)
+	}
+}
+
+func profilerLabelsHook(call cgo.Call) func(cgo.CallResult) {
+	labels := []string{labelCall, call.Name}
+	if call.ProofType != "" {
+		labels = append(labels, labelProofType, call.ProofType)
+	}
+	if call.HasSectorID {
+		labels = append(labels, labelSectorID, strconv.FormatUint(call.SectorID, 10))
+	}
+
+	base := callerCtx()
+	pprof.SetGoroutineLabels(pprof.WithLabels(base, pprof.Labels(labels...)))
+	return func(cgo.CallResult) {
+		pprof.SetGoroutineLabels(base)
+	}
+}
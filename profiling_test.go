@@ -0,0 +1,54 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+func goroutineLabels(t *testing.T) string {
+	var buf bytes.Buffer
+	require.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+	return buf.String()
+}
+
+func TestProfilerLabelsHook(t *testing.T) {
+	done := profilerLabelsHook(cgo.Call{Name: "seal_pre_commit_phase1", ProofType: "StackedDrg2KiBV1_1", SectorID: 42, HasSectorID: true})
+
+	labels := goroutineLabels(t)
+	assert.Contains(t, labels, `"ffi.call":"seal_pre_commit_phase1"`)
+	assert.Contains(t, labels, `"ffi.proof_type":"StackedDrg2KiBV1_1"`)
+	assert.Contains(t, labels, `"ffi.sector_id":"42"`)
+
+	done(cgo.CallResult{})
+	assert.NotContains(t, goroutineLabels(t), `"ffi.call"`)
+}
+
+func TestProfilerLabelsHookCallerLabels(t *testing.T) {
+	SetProfilerLabels(true)
+	defer SetProfilerLabels(false)
+
+	pprof.Do(context.Background(), pprof.Labels("caller", "test"), func(ctx context.Context) {
+		require.NoError(t, withCallerCtx(ctx, func() error {
+			done := profilerLabelsHook(cgo.Call{Name: "seal_pre_commit_phase1"})
+			labels := goroutineLabels(t)
+			assert.Contains(t, labels, `"caller":"test"`)
+			assert.Contains(t, labels, `"ffi.call":"seal_pre_commit_phase1"`)
+
+			done(cgo.CallResult{})
+			labels = goroutineLabels(t)
+			assert.Contains(t, labels, `"caller":"test"`)
+			assert.NotContains(t, labels, `"ffi.call"`)
+			return nil
+		}))
+	})
+}
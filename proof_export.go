@@ -0,0 +1,408 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// ExportedProofVersion is the version of the proof export format written by
+// the Export functions.
+const ExportedProofVersion = 1
+
+// exportedProofMagic prefixes every exported proof.
+var exportedProofMagic = [4]byte{'F', 'I', 'L', 'P'}
+
+// ExportedProofKind identifies the proof carried by an exported proof.
+type ExportedProofKind uint8
+
+const (
+	ExportedProofSeal ExportedProofKind = iota + 1
+	ExportedProofWinningPoSt
+	ExportedProofWindowPoSt
+)
+
+// ExportedProof is a decoded exported proof. Exactly one of the verify infos
+// is set, according to Kind.
+//
+// The export format is a flat big-endian layout intended to be cheap to parse
+// by light-clients and bridge contracts on other chains:
+//
+//	magic "FILP" | version u8 | kind u8 | body
+//
+// The seal body is
+//
+//	seal proof i64 | miner u64 | sector number u64 | ticket [32] | seed [32] |
+//	CommR [32] | CommD [32] | proof len u32 | proof
+//
+// and the PoSt body (winning and window) is
+//
+//	miner u64 | randomness [32] |
+//	sector count u32 | (seal proof i64 | sector number u64 | CommR [32])* |
+//	proof count u32 | (PoSt proof i64 | proof len u32 | proof)*
+//
+// Commitments are stored as raw 32 byte values rather than CIDs. Deal IDs are
+// not part of the seal body since they are not inputs of the proof.
+type ExportedProof struct {
+	Version uint8
+	Kind    ExportedProofKind
+
+	Seal        *proof5.SealVerifyInfo
+	WinningPoSt *proof5.WinningPoStVerifyInfo
+	WindowPoSt  *proof5.WindowPoStVerifyInfo
+}
+
+// ExportSealProof verifies the seal proof and serializes it together with its
+// public inputs in the proof export format.
+func ExportSealProof(info proof5.SealVerifyInfo) ([]byte, error) {
+	if err := requireValid(VerifySeal(info)); err != nil {
+		return nil, err
+	}
+
+	return encodeExportedProof(ExportedProof{Version: ExportedProofVersion, Kind: ExportedProofSeal, Seal: &info})
+}
+
+// ExportWinningPoStProof verifies the Winning PoSt and serializes it together
+// with its public inputs in the proof export format.
+func ExportWinningPoStProof(info proof5.WinningPoStVerifyInfo) ([]byte, error) {
+	if err := requireValid(VerifyWinningPoSt(info)); err != nil {
+		return nil, err
+	}
+
+	return encodeExportedProof(ExportedProof{Version: ExportedProofVersion, Kind: ExportedProofWinningPoSt, WinningPoSt: &info})
+}
+
+// ExportWindowPoStProof verifies the Window PoSt and serializes it together
+// with its public inputs in the proof export format.
+func ExportWindowPoStProof(info proof5.WindowPoStVerifyInfo) ([]byte, error) {
+	if err := requireValid(VerifyWindowPoSt(info)); err != nil {
+		return nil, err
+	}
+
+	return encodeExportedProof(ExportedProof{Version: ExportedProofVersion, Kind: ExportedProofWindowPoSt, WindowPoSt: &info})
+}
+
+// VerifyExportedProof decodes an exported proof and verifies it.
+func VerifyExportedProof(data []byte) (bool, error) {
+	ep, err := DecodeExportedProof(data)
+	if err != nil {
+		return false, err
+	}
+
+	switch ep.Kind {
+	case ExportedProofSeal:
+		return VerifySeal(*ep.Seal)
+	case ExportedProofWinningPoSt:
+		return VerifyWinningPoSt(*ep.WinningPoSt)
+	case ExportedProofWindowPoSt:
+		return VerifyWindowPoSt(*ep.WindowPoSt)
+	default:
+		return false, xerrors.Errorf("unknown exported proof kind %d", ep.Kind)
+	}
+}
+
+func requireValid(ok bool, err error) error {
+	if err != nil {
+		return xerrors.Errorf("verifying proof: %w", err)
+	}
+	if !ok {
+		return xerrors.New("refusing to export invalid proof")
+	}
+	return nil
+}
+
+func encodeExportedProof(ep ExportedProof) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(exportedProofMagic[:])
+	buf.WriteByte(ep.Version)
+	buf.WriteByte(byte(ep.Kind))
+
+	var err error
+	switch ep.Kind {
+	case ExportedProofSeal:
+		err = encodeSealBody(&buf, ep.Seal)
+	case ExportedProofWinningPoSt:
+		if ep.WinningPoSt == nil {
+			return nil, xerrors.New("missing winning PoSt verify info")
+		}
+		err = encodePoStBody(&buf, ep.WinningPoSt.Prover, ep.WinningPoSt.Randomness, ep.WinningPoSt.ChallengedSectors, ep.WinningPoSt.Proofs)
+	case ExportedProofWindowPoSt:
+		if ep.WindowPoSt == nil {
+			return nil, xerrors.New("missing window PoSt verify info")
+		}
+		err = encodePoStBody(&buf, ep.WindowPoSt.Prover, ep.WindowPoSt.Randomness, ep.WindowPoSt.ChallengedSectors, ep.WindowPoSt.Proofs)
+	default:
+		return nil, xerrors.Errorf("unknown exported proof kind %d", ep.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeSealBody(buf *bytes.Buffer, info *proof5.SealVerifyInfo) error {
+	if info == nil {
+		return xerrors.New("missing seal verify info")
+	}
+
+	commR, err := commcid.CIDToReplicaCommitmentV1(info.SealedCID)
+	if err != nil {
+		return err
+	}
+	commD, err := commcid.CIDToDataCommitmentV1(info.UnsealedCID)
+	if err != nil {
+		return err
+	}
+
+	writeUint64(buf, uint64(info.SealProof))
+	writeUint64(buf, uint64(info.SectorID.Miner))
+	writeUint64(buf, uint64(info.SectorID.Number))
+	if err := write32(buf, info.Randomness, "ticket"); err != nil {
+		return err
+	}
+	if err := write32(buf, info.InteractiveRandomness, "seed"); err != nil {
+		return err
+	}
+	buf.Write(commR)
+	buf.Write(commD)
+
+	return writeBytes(buf, info.Proof)
+}
+
+func encodePoStBody(buf *bytes.Buffer, prover abi.ActorID, randomness abi.PoStRandomness, sectors []proof5.SectorInfo, proofs []proof5.PoStProof) error {
+	writeUint64(buf, uint64(prover))
+	if err := write32(buf, randomness, "randomness"); err != nil {
+		return err
+	}
+
+	writeUint32(buf, uint32(len(sectors)))
+	for _, s := range sectors {
+		commR, err := commcid.CIDToReplicaCommitmentV1(s.SealedCID)
+		if err != nil {
+			return err
+		}
+
+		writeUint64(buf, uint64(s.SealProof))
+		writeUint64(buf, uint64(s.SectorNumber))
+		buf.Write(commR)
+	}
+
+	writeUint32(buf, uint32(len(proofs)))
+	for _, p := range proofs {
+		writeUint64(buf, uint64(p.PoStProof))
+		if err := writeBytes(buf, p.ProofBytes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecodeExportedProof parses an exported proof without verifying it.
+func DecodeExportedProof(data []byte) (ExportedProof, error) {
+	r := bytes.NewReader(data)
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil || magic != exportedProofMagic {
+		return ExportedProof{}, xerrors.New("not an exported proof")
+	}
+
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return ExportedProof{}, xerrors.Errorf("reading header: %w", err)
+	}
+
+	ep := ExportedProof{Version: hdr[0], Kind: ExportedProofKind(hdr[1])}
+	if ep.Version != ExportedProofVersion {
+		return ExportedProof{}, xerrors.Errorf("unsupported exported proof version %d", ep.Version)
+	}
+
+	var err error
+	switch ep.Kind {
+	case ExportedProofSeal:
+		ep.Seal, err = decodeSealBody(r)
+	case ExportedProofWinningPoSt:
+		var info proof5.WinningPoStVerifyInfo
+		info.Prover, info.Randomness, info.ChallengedSectors, info.Proofs, err = decodePoStBody(r)
+		ep.WinningPoSt = &info
+	case ExportedProofWindowPoSt:
+		var info proof5.WindowPoStVerifyInfo
+		info.Prover, info.Randomness, info.ChallengedSectors, info.Proofs, err = decodePoStBody(r)
+		ep.WindowPoSt = &info
+	default:
+		return ExportedProof{}, xerrors.Errorf("unknown exported proof kind %d", ep.Kind)
+	}
+	if err != nil {
+		return ExportedProof{}, xerrors.Errorf("decoding exported proof: %w", err)
+	}
+
+	if r.Len() != 0 {
+		return ExportedProof{}, xerrors.Errorf("%d trailing bytes after exported proof", r.Len())
+	}
+
+	return ep, nil
+}
+
+func decodeSealBody(r *bytes.Reader) (*proof5.SealVerifyInfo, error) {
+	var info proof5.SealVerifyInfo
+
+	var fixed struct {
+		SealProof uint64
+		Miner     uint64
+		Number    uint64
+		Ticket    [32]byte
+		Seed      [32]byte
+		CommR     [32]byte
+		CommD     [32]byte
+	}
+	if err := binary.Read(r, binary.BigEndian, &fixed); err != nil {
+		return nil, err
+	}
+
+	sealedCID, err := commcid.ReplicaCommitmentV1ToCID(fixed.CommR[:])
+	if err != nil {
+		return nil, err
+	}
+	unsealedCID, err := commcid.DataCommitmentV1ToCID(fixed.CommD[:])
+	if err != nil {
+		return nil, err
+	}
+
+	proofBytes, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	info.SealProof = abi.RegisteredSealProof(fixed.SealProof)
+	info.SectorID = abi.SectorID{Miner: abi.ActorID(fixed.Miner), Number: abi.SectorNumber(fixed.Number)}
+	info.Randomness = fixed.Ticket[:]
+	info.InteractiveRandomness = fixed.Seed[:]
+	info.SealedCID = sealedCID
+	info.UnsealedCID = unsealedCID
+	info.Proof = proofBytes
+
+	return &info, nil
+}
+
+func decodePoStBody(r *bytes.Reader) (abi.ActorID, abi.PoStRandomness, []proof5.SectorInfo, []proof5.PoStProof, error) {
+	var fixed struct {
+		Prover     uint64
+		Randomness [32]byte
+		NumSectors uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &fixed); err != nil {
+		return 0, nil, nil, nil, err
+	}
+
+	// each sector takes 48 bytes, bound the allocation by what is left
+	if uint64(fixed.NumSectors)*48 > uint64(r.Len()) {
+		return 0, nil, nil, nil, io.ErrUnexpectedEOF
+	}
+
+	sectors := make([]proof5.SectorInfo, fixed.NumSectors)
+	for i := range sectors {
+		var s struct {
+			SealProof uint64
+			Number    uint64
+			CommR     [32]byte
+		}
+		if err := binary.Read(r, binary.BigEndian, &s); err != nil {
+			return 0, nil, nil, nil, err
+		}
+
+		sealedCID, err := commcid.ReplicaCommitmentV1ToCID(s.CommR[:])
+		if err != nil {
+			return 0, nil, nil, nil, err
+		}
+
+		sectors[i] = proof5.SectorInfo{
+			SealProof:    abi.RegisteredSealProof(s.SealProof),
+			SectorNumber: abi.SectorNumber(s.Number),
+			SealedCID:    sealedCID,
+		}
+	}
+
+	var numProofs uint32
+	if err := binary.Read(r, binary.BigEndian, &numProofs); err != nil {
+		return 0, nil, nil, nil, err
+	}
+
+	// each proof takes at least 12 bytes
+	if uint64(numProofs)*12 > uint64(r.Len()) {
+		return 0, nil, nil, nil, io.ErrUnexpectedEOF
+	}
+
+	proofs := make([]proof5.PoStProof, numProofs)
+	for i := range proofs {
+		var pt uint64
+		if err := binary.Read(r, binary.BigEndian, &pt); err != nil {
+			return 0, nil, nil, nil, err
+		}
+
+		proofBytes, err := readBytes(r)
+		if err != nil {
+			return 0, nil, nil, nil, err
+		}
+
+		proofs[i] = proof5.PoStProof{
+			PoStProof:  abi.RegisteredPoStProof(pt),
+			ProofBytes: proofBytes,
+		}
+	}
+
+	return abi.ActorID(fixed.Prover), fixed.Randomness[:], sectors, proofs, nil
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func write32(buf *bytes.Buffer, v []byte, name string) error {
+	if len(v) != 32 {
+		return xerrors.Errorf("%s must be 32 bytes, got %d", name, len(v))
+	}
+	buf.Write(v)
+	return nil
+}
+
+func writeBytes(buf *bytes.Buffer, v []byte) error {
+	if uint64(len(v)) > uint64(^uint32(0)) {
+		return xerrors.Errorf("proof too large: %d bytes", len(v))
+	}
+	writeUint32(buf, uint32(len(v)))
+	buf.Write(v)
+	return nil
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if uint64(n) > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	out := make([]byte, n)
+	if _, err := io.ReadFull(r, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
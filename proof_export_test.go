@@ -0,0 +1,68 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"bytes"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportedProofRoundTrip(t *testing.T) {
+	commR, err := commcid.ReplicaCommitmentV1ToCID(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	commD, err := commcid.DataCommitmentV1ToCID(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+
+	seal := proof5.SealVerifyInfo{
+		SealProof:             abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+		SectorID:              abi.SectorID{Miner: 1000, Number: 42},
+		Randomness:            bytes.Repeat([]byte{3}, 32),
+		InteractiveRandomness: bytes.Repeat([]byte{4}, 32),
+		Proof:                 []byte("seal proof"),
+		SealedCID:             commR,
+		UnsealedCID:           commD,
+	}
+
+	data, err := encodeExportedProof(ExportedProof{Version: ExportedProofVersion, Kind: ExportedProofSeal, Seal: &seal})
+	require.NoError(t, err)
+
+	ep, err := DecodeExportedProof(data)
+	require.NoError(t, err)
+	assert.Equal(t, ExportedProofSeal, ep.Kind)
+	assert.Equal(t, seal, *ep.Seal)
+
+	window := proof5.WindowPoStVerifyInfo{
+		Randomness: bytes.Repeat([]byte{5}, 32),
+		Proofs: []proof5.PoStProof{{
+			PoStProof:  abi.RegisteredPoStProof_StackedDrgWindow2KiBV1,
+			ProofBytes: []byte("post proof"),
+		}},
+		ChallengedSectors: []proof5.SectorInfo{{
+			SealProof:    abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+			SectorNumber: 42,
+			SealedCID:    commR,
+		}},
+		Prover: 1000,
+	}
+
+	data, err = encodeExportedProof(ExportedProof{Version: ExportedProofVersion, Kind: ExportedProofWindowPoSt, WindowPoSt: &window})
+	require.NoError(t, err)
+
+	ep, err = DecodeExportedProof(data)
+	require.NoError(t, err)
+	assert.Equal(t, ExportedProofWindowPoSt, ep.Kind)
+	assert.Equal(t, window, *ep.WindowPoSt)
+
+	// truncated and trailing data are rejected
+	_, err = DecodeExportedProof(data[:len(data)-1])
+	assert.Error(t, err)
+	_, err = DecodeExportedProof(append(data, 0))
+	assert.Error(t, err)
+}
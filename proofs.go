@@ -1,5 +1,5 @@
-//go:build cgo
-// +build cgo
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
 
 package ffi
 
@@ -9,8 +9,8 @@ package ffi
 // #include "./filcrypto.h"
 import "C"
 import (
+	"context"
 	"os"
-	"runtime"
 
 	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
@@ -27,6 +27,10 @@ import (
 // VerifySeal returns true if the sealing operation from which its inputs were
 // derived was valid, and false if not.
 func VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	if isFakeSeal(info.Proof) {
+		return VerifyFakeSeal(info)
+	}
+
 	sp, err := toFilRegisteredSealProof(info.SealProof)
 	if err != nil {
 		return false, err
@@ -103,15 +107,20 @@ func VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) (bo
 // VerifyWinningPoSt returns true if the Winning PoSt-generation operation from which its
 // inputs were derived was valid, and false if not.
 func VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
+	if isFakePoSt(info.Proofs) {
+		return VerifyFakeWinningPoSt(info)
+	}
+
 	filPublicReplicaInfos, err := toFilPublicReplicaInfos(info.ChallengedSectors, "winning")
 	if err != nil {
 		return false, errors.Wrap(err, "failed to create public replica info array for FFI")
 	}
 
-	filPoStProofs, err := toFilPoStProofs(info.Proofs)
+	filPoStProofs, cleanup, err := toFilPoStProofs(info.Proofs)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to create PoSt proofs array for FFI")
 	}
+	defer cleanup()
 
 	proverID, err := toProverID(info.Prover)
 	if err != nil {
@@ -130,15 +139,20 @@ func VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
 // VerifyWindowPoSt returns true if the Winning PoSt-generation operation from which its
 // inputs were derived was valid, and false if not.
 func VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error) {
+	if isFakePoSt(info.Proofs) {
+		return VerifyFakeWindowPoSt(info)
+	}
+
 	filPublicReplicaInfos, err := toFilPublicReplicaInfos(info.ChallengedSectors, "window")
 	if err != nil {
 		return false, errors.Wrap(err, "failed to create public replica info array for FFI")
 	}
 
-	filPoStProofs, err := toFilPoStProofs(info.Proofs)
+	filPoStProofs, cleanup, err := toFilPoStProofs(info.Proofs)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to create PoSt proofs array for FFI")
 	}
+	defer cleanup()
 
 	proverID, err := toProverID(info.Prover)
 	if err != nil {
@@ -199,10 +213,7 @@ func GeneratePieceCIDFromFile(proofType abi.RegisteredSealProof, pieceFile *os.F
 		return cid.Undef, err
 	}
 
-	pieceFd := pieceFile.Fd()
-	defer runtime.KeepAlive(pieceFile)
-
-	resp, err := cgo.GeneratePieceCommitment(sp, int32(pieceFd), uint64(pieceSize))
+	resp, err := cgo.GeneratePieceCommitmentFile(sp, pieceFile, uint64(pieceSize))
 	if err != nil {
 		return cid.Undef, err
 	}
@@ -223,15 +234,9 @@ func WriteWithAlignment(
 		return 0, 0, cid.Undef, err
 	}
 
-	pieceFd := pieceFile.Fd()
-	defer runtime.KeepAlive(pieceFile)
-
-	stagedSectorFd := stagedSectorFile.Fd()
-	defer runtime.KeepAlive(stagedSectorFile)
-
 	filExistingPieceSizes := toFilExistingPieceSizes(existingPieceSizes)
 
-	leftAlignmentUnpadded, totalWriteUnpadded, commPRaw, err := cgo.WriteWithAlignment(sp, int32(pieceFd), uint64(pieceBytes), int32(stagedSectorFd), cgo.AsSliceRefUint64(filExistingPieceSizes))
+	leftAlignmentUnpadded, totalWriteUnpadded, commPRaw, err := cgo.WriteWithAlignmentFile(sp, pieceFile, uint64(pieceBytes), stagedSectorFile, cgo.AsSliceRefUint64(filExistingPieceSizes))
 	if err != nil {
 		return 0, 0, cid.Undef, err
 	}
@@ -256,13 +261,7 @@ func WriteWithoutAlignment(
 		return 0, cid.Undef, err
 	}
 
-	pieceFd := pieceFile.Fd()
-	defer runtime.KeepAlive(pieceFile)
-
-	stagedSectorFd := stagedSectorFile.Fd()
-	defer runtime.KeepAlive(stagedSectorFile)
-
-	totalWriteUnpadded, commPRaw, err := cgo.WriteWithoutAlignment(sp, int32(pieceFd), uint64(pieceBytes), int32(stagedSectorFd))
+	totalWriteUnpadded, commPRaw, err := cgo.WriteWithoutAlignmentFile(sp, pieceFile, uint64(pieceBytes), stagedSectorFile)
 	if err != nil {
 		return 0, cid.Undef, err
 	}
@@ -320,6 +319,21 @@ func SealPreCommitPhase2(
 	cacheDirPath string,
 	sealedSectorPath string,
 ) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	return sealPreCommitPhase2(context.Background(), phase1Output, cacheDirPath, sealedSectorPath)
+}
+
+func sealPreCommitPhase2(
+	ctx context.Context,
+	phase1Output []byte,
+	cacheDirPath string,
+	sealedSectorPath string,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	release, err := admit(ctx, OpSealPreCommit2)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	defer release()
+
 	commRRaw, commDRaw, err := cgo.SealPreCommitPhase2(
 		cgo.AsSliceRefUint8(phase1Output),
 		cgo.AsSliceRefUint8([]byte(cacheDirPath)),
@@ -400,6 +414,21 @@ func SealCommitPhase2(
 	sectorNum abi.SectorNumber,
 	minerID abi.ActorID,
 ) ([]byte, error) {
+	return sealCommitPhase2(context.Background(), phase1Output, sectorNum, minerID)
+}
+
+func sealCommitPhase2(
+	ctx context.Context,
+	phase1Output []byte,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+) ([]byte, error) {
+	release, err := admit(ctx, OpSealCommit2)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	proverID, err := toProverID(minerID)
 	if err != nil {
 		return nil, err
@@ -425,7 +454,7 @@ func AggregateSealProofs(aggregateInfo proof5.AggregateSealVerifyProofAndInfos,
 		}
 	}
 
-	pfs, cleaner := toVanillaProofs(proofs)
+	pfs, cleaner := cgo.PinSlicesBoxedUint8(proofs)
 	defer cleaner()
 
 	rap, err := toFilRegisteredAggregationProof(aggregateInfo.AggregateProof)
@@ -491,18 +520,12 @@ func UnsealRange(
 		return err
 	}
 
-	sealedSectorFd := sealedSector.Fd()
-	defer runtime.KeepAlive(sealedSector)
-
-	unsealOutputFd := unsealOutput.Fd()
-	defer runtime.KeepAlive(unsealOutput)
-
 	ticketBytes := cgo.AsByteArray32(ticket)
-	return cgo.UnsealRange(
+	return cgo.UnsealRangeFile(
 		sp,
 		cgo.AsSliceRefUint8([]byte(cacheDirPath)),
-		int32(sealedSectorFd),
-		int32(unsealOutputFd),
+		sealedSector,
+		unsealOutput,
 		uint64(sectorNum),
 		&proverID,
 		&ticketBytes,
@@ -540,6 +563,21 @@ func GenerateWinningPoSt(
 	privateSectorInfo SortedPrivateSectorInfo,
 	randomness abi.PoStRandomness,
 ) ([]proof5.PoStProof, error) {
+	return generateWinningPoSt(context.Background(), minerID, privateSectorInfo, randomness)
+}
+
+func generateWinningPoSt(
+	ctx context.Context,
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof5.PoStProof, error) {
+	release, err := admit(ctx, OpWinningPoSt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	filReplicas, cleanup, err := toFilPrivateReplicaInfos(privateSectorInfo.Values(), "winning")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create private replica info array for FFI")
@@ -570,6 +608,21 @@ func GenerateWindowPoSt(
 	privateSectorInfo SortedPrivateSectorInfo,
 	randomness abi.PoStRandomness,
 ) ([]proof5.PoStProof, []abi.SectorNumber, error) {
+	return generateWindowPoSt(context.Background(), minerID, privateSectorInfo, randomness)
+}
+
+func generateWindowPoSt(
+	ctx context.Context,
+	minerID abi.ActorID,
+	privateSectorInfo SortedPrivateSectorInfo,
+	randomness abi.PoStRandomness,
+) ([]proof5.PoStProof, []abi.SectorNumber, error) {
+	release, err := admit(ctx, OpWindowPoSt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
 	filReplicas, cleanup, err := toFilPrivateReplicaInfos(privateSectorInfo.Values(), "window")
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to create private replica info array for FFI")
@@ -602,6 +655,26 @@ func GetGPUDevices() ([]string, error) {
 	return cgo.GetGpuDevices()
 }
 
+func gpuFrameworks() ([]string, error) {
+	return cgo.GetGpuFrameworks()
+}
+
+func buildInfo() (string, error) {
+	return cgo.GetBuildInfo()
+}
+
+// initThreadPool builds the global thread pool of the native library on the
+// calling thread, if not built yet.
+func initThreadPool() {
+	cgo.InitThreadPool()
+}
+
+// nativeIdle returns a channel closed once no native call is running, and
+// the number of running calls.
+func nativeIdle() (<-chan struct{}, int) {
+	return cgo.Idle(), cgo.ActiveCalls()
+}
+
 // GetSealVersion
 func GetSealVersion(proofType abi.RegisteredSealProof) (string, error) {
 	sp, err := toFilRegisteredSealProof(proofType)
@@ -609,7 +682,13 @@ func GetSealVersion(proofType abi.RegisteredSealProof) (string, error) {
 		return "", err
 	}
 
-	return cgo.GetSealVersion(sp)
+	v, err := pureCalls.do(pureCallKey{call: "get_seal_version", proof: int64(proofType)}, func() (interface{}, error) {
+		return cgo.GetSealVersion(sp)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
 }
 
 // GetPoStVersion
@@ -619,7 +698,91 @@ func GetPoStVersion(proofType abi.RegisteredPoStProof) (string, error) {
 		return "", err
 	}
 
-	return cgo.GetPoStVersion(pp)
+	v, err := pureCalls.do(pureCallKey{call: "get_post_version", proof: int64(proofType)}, func() (interface{}, error) {
+		return cgo.GetPoStVersion(pp)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// preloadCommitment is the commitment of the dummy proofs verified to load the
+// verifying keys. The native library rejects all zero commitments before
+// loading the keys.
+var preloadCommitment = cgo.AsByteArray32([]byte{1})
+
+func preloadSealParams(proofType abi.RegisteredSealProof) error {
+	sp, err := toFilRegisteredSealProof(proofType)
+	if err != nil {
+		return err
+	}
+
+	paramsPath, err := cgo.GetSealParamsPath(sp)
+	if err != nil {
+		return err
+	}
+	vkPath, err := cgo.GetSealVerifyingKeyPath(sp)
+	if err != nil {
+		return err
+	}
+	for _, path := range []string{vkPath, paramsPath} {
+		if err := readThrough(path); err != nil {
+			return err
+		}
+	}
+
+	proofSize, err := sp.SealProofSize()
+	if err != nil {
+		return err
+	}
+
+	// The verifying key is loaded before the proof is checked: the result
+	// of the verification doesn't matter.
+	var zero cgo.ByteArray32
+	_, _ = cgo.VerifySeal(sp, &preloadCommitment, &preloadCommitment, &zero, &zero, &zero, 0, cgo.AsSliceRefUint8(make([]byte, proofSize)))
+	return nil
+}
+
+func preloadPoStParams(proofType abi.RegisteredPoStProof) error {
+	pp, err := toFilRegisteredPoStProof(proofType)
+	if err != nil {
+		return err
+	}
+
+	paramsPath, err := cgo.GetPoStParamsPath(pp)
+	if err != nil {
+		return err
+	}
+	vkPath, err := cgo.GetPoStVerifyingKeyPath(pp)
+	if err != nil {
+		return err
+	}
+	for _, path := range []string{vkPath, paramsPath} {
+		if err := readThrough(path); err != nil {
+			return err
+		}
+	}
+
+	proofSize, err := pp.ProofSize()
+	if err != nil {
+		return err
+	}
+	proofs, cleanup, err := toFilPoStProofs([]proof5.PoStProof{{PoStProof: proofType, ProofBytes: make([]byte, proofSize)}})
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	replicas := []cgo.PublicReplicaInfo{cgo.NewPublicReplicaInfo(pp, preloadCommitment, 0)}
+
+	// As for seal proofs, only loading the verifying key matters.
+	var zero cgo.ByteArray32
+	if pp.IsWinning() {
+		_, _ = cgo.VerifyWinningPoSt(&zero, cgo.AsSliceRefPublicReplicaInfo(replicas), cgo.AsSliceRefPoStProof(proofs), &zero)
+	} else {
+		_, _ = cgo.VerifyWindowPoSt(&zero, cgo.AsSliceRefPublicReplicaInfo(replicas), cgo.AsSliceRefPoStProof(proofs), &zero)
+	}
+	return nil
 }
 
 func GetNumPartitionForFallbackPost(proofType abi.RegisteredPoStProof, numSectors uint) (uint, error) {
@@ -628,7 +791,28 @@ func GetNumPartitionForFallbackPost(proofType abi.RegisteredPoStProof, numSector
 		return 0, err
 	}
 
-	return cgo.GetNumPartitionForFallbackPost(pp, numSectors)
+	v, err := pureCalls.do(pureCallKey{call: "get_num_partition_for_fallback_post", proof: int64(proofType), arg: uint64(numSectors)}, func() (interface{}, error) {
+		return cgo.GetNumPartitionForFallbackPost(pp, numSectors)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint), nil
+}
+
+// GetMaxUserBytesPerStagedSector returns the number of unpadded bytes of
+// pieces fitting in a sector of the given proof type.
+func GetMaxUserBytesPerStagedSector(proofType abi.RegisteredSealProof) (abi.UnpaddedPieceSize, error) {
+	sp, err := toFilRegisteredSealProof(proofType)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := cgo.GetMaxUserBytesPerStagedSector(sp)
+	if err != nil {
+		return 0, err
+	}
+	return abi.UnpaddedPieceSize(n), nil
 }
 
 // ClearCache
@@ -758,7 +942,7 @@ func makeCleanerPRI(src []cgo.PrivateReplicaInfo, limit int) func() {
 }
 
 func toFilPrivateReplicaInfos(src []PrivateSectorInfo, typ string) ([]cgo.PrivateReplicaInfo, func(), error) {
-	out := make([]cgo.PrivateReplicaInfo, len(src))
+	out := cgo.MakePrivateReplicaInfos(len(src))
 
 	for idx := range out {
 		commR, err := to32ByteCommR(src[idx].SealedCID)
@@ -812,18 +996,27 @@ func fromFilPoStProofs(src []cgo.PoStProofGo) ([]proof5.PoStProof, error) {
 	return out, nil
 }
 
-func toFilPoStProofs(src []proof5.PoStProof) ([]cgo.PoStProof, error) {
-	out := make([]cgo.PoStProof, len(src))
+func makeCleanerPoStProofs(src []cgo.PoStProof, limit int) func() {
+	return func() {
+		for i := 0; i < limit; i++ {
+			src[i].Destroy()
+		}
+	}
+}
+
+func toFilPoStProofs(src []proof5.PoStProof) ([]cgo.PoStProof, func(), error) {
+	out := cgo.MakePoStProofs(len(src))
 	for idx := range out {
 		pp, err := toFilRegisteredPoStProof(src[idx].PoStProof)
 		if err != nil {
-			return nil, err
+			makeCleanerPoStProofs(out, idx)()
+			return nil, nil, err
 		}
 
 		out[idx] = cgo.NewPoStProof(pp, src[idx].ProofBytes)
 	}
 
-	return out, nil
+	return out, makeCleanerPoStProofs(out, len(src)), nil
 }
 
 func toProverID(minerID abi.ActorID) (cgo.ByteArray32, error) {
@@ -859,7 +1052,7 @@ func fromFilRegisteredPoStProof(p cgo.RegisteredPoStProof) (abi.RegisteredPoStPr
 	case cgo.RegisteredPoStProofStackedDrgWindow64GiBV1:
 		return abi.RegisteredPoStProof_StackedDrgWindow64GiBV1, nil
 	default:
-		return 0, errors.Errorf("no mapping to abi.RegisteredPoStProof value available for: %v", p)
+		return 0, errors.Wrapf(ErrInvalidInput, "no mapping to abi.RegisteredPoStProof value available for: %v", p)
 	}
 }
 
@@ -887,7 +1080,7 @@ func toFilRegisteredPoStProof(p abi.RegisteredPoStProof) (cgo.RegisteredPoStProo
 	case abi.RegisteredPoStProof_StackedDrgWindow64GiBV1:
 		return cgo.RegisteredPoStProofStackedDrgWindow64GiBV1, nil
 	default:
-		return 0, errors.Errorf("no mapping to abi.RegisteredPoStProof value available for: %v", p)
+		return 0, errors.Wrapf(ErrInvalidInput, "no mapping to abi.RegisteredPoStProof value available for: %v", p)
 	}
 }
 
@@ -915,7 +1108,7 @@ func toFilRegisteredSealProof(p abi.RegisteredSealProof) (cgo.RegisteredSealProo
 	case abi.RegisteredSealProof_StackedDrg64GiBV1_1:
 		return cgo.RegisteredSealProofStackedDrg64GiBV11, nil
 	default:
-		return 0, errors.Errorf("no mapping to C.FFIRegisteredSealProof value available for: %v", p)
+		return 0, errors.Wrapf(ErrInvalidInput, "no mapping to C.FFIRegisteredSealProof value available for: %v", p)
 	}
 }
 
@@ -924,7 +1117,7 @@ func toFilRegisteredAggregationProof(p abi.RegisteredAggregationProof) (cgo.Regi
 	case abi.RegisteredAggregationProof_SnarkPackV1:
 		return cgo.RegisteredAggregationProofSnarkPackV1, nil
 	default:
-		return 0, errors.Errorf("no mapping to abi.RegisteredAggregationProof value available for: %v", p)
+		return 0, errors.Wrapf(ErrInvalidInput, "no mapping to abi.RegisteredAggregationProof value available for: %v", p)
 	}
 }
 
@@ -954,21 +1147,3 @@ func to32ByteCommP(pieceCID cid.Cid) (cgo.ByteArray32, error) {
 
 	return cgo.AsByteArray32(commP), nil
 }
-
-func makeCleanerSBU(src []cgo.SliceBoxedUint8, limit int) func() {
-	return func() {
-		for i := 0; i < limit; i++ {
-			src[i].Destroy()
-		}
-	}
-}
-
-func toVanillaProofs(src [][]byte) ([]cgo.SliceBoxedUint8, func()) {
-	out := make([]cgo.SliceBoxedUint8, len(src))
-
-	for i := range out {
-		out[i] = cgo.AllocSliceBoxedUint8(src[i])
-	}
-
-	return out, makeCleanerSBU(out, len(src))
-}
@@ -0,0 +1,61 @@
+package proofs
+
+import (
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// CommRToCID returns the sealed CID of a CommR: the fil-commitment-sealed
+// codec with the poseidon-bls12_381-a2-fc1 multihash.
+func CommRToCID(commR Commitment) (cid.Cid, error) {
+	return commcid.ReplicaCommitmentV1ToCID(commR[:])
+}
+
+// CIDToCommR returns the CommR of a sealed CID.
+func CIDToCommR(c cid.Cid) (Commitment, error) {
+	commR, err := commcid.CIDToReplicaCommitmentV1(c)
+	if err != nil {
+		return Commitment{}, xerrors.Errorf("converting sealed CID to CommR: %w", err)
+	}
+	return toCommitment(commR)
+}
+
+// CommDToCID returns the unsealed CID of a CommD: the
+// fil-commitment-unsealed codec with the sha2-256-trunc254-padded multihash.
+func CommDToCID(commD Commitment) (cid.Cid, error) {
+	return commcid.DataCommitmentV1ToCID(commD[:])
+}
+
+// CIDToCommD returns the CommD of an unsealed CID.
+func CIDToCommD(c cid.Cid) (Commitment, error) {
+	commD, err := commcid.CIDToDataCommitmentV1(c)
+	if err != nil {
+		return Commitment{}, xerrors.Errorf("converting unsealed CID to CommD: %w", err)
+	}
+	return toCommitment(commD)
+}
+
+// CommPToCID returns the piece CID of a CommP, which uses the same codec
+// and multihash as unsealed CIDs.
+func CommPToCID(commP Commitment) (cid.Cid, error) {
+	return commcid.PieceCommitmentV1ToCID(commP[:])
+}
+
+// CIDToCommP returns the CommP of a piece CID.
+func CIDToCommP(c cid.Cid) (Commitment, error) {
+	commP, err := commcid.CIDToPieceCommitmentV1(c)
+	if err != nil {
+		return Commitment{}, xerrors.Errorf("converting piece CID to CommP: %w", err)
+	}
+	return toCommitment(commP)
+}
+
+func toCommitment(b []byte) (Commitment, error) {
+	var out Commitment
+	if len(b) != len(out) {
+		return out, xerrors.Errorf("expected a 32 byte commitment, got %d bytes", len(b))
+	}
+	copy(out[:], b)
+	return out, nil
+}
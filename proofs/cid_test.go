@@ -0,0 +1,40 @@
+package proofs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitmentCIDs(t *testing.T) {
+	var comm Commitment
+	for i := range comm {
+		comm[i] = byte(i)
+	}
+
+	commR, err := CommRToCID(comm)
+	require.NoError(t, err)
+	back, err := CIDToCommR(commR)
+	require.NoError(t, err)
+	assert.Equal(t, comm, back)
+
+	commD, err := CommDToCID(comm)
+	require.NoError(t, err)
+	back, err = CIDToCommD(commD)
+	require.NoError(t, err)
+	assert.Equal(t, comm, back)
+
+	commP, err := CommPToCID(comm)
+	require.NoError(t, err)
+	assert.Equal(t, commD, commP)
+	back, err = CIDToCommP(commP)
+	require.NoError(t, err)
+	assert.Equal(t, comm, back)
+
+	// sealed and unsealed CIDs aren't interchangeable
+	_, err = CIDToCommD(commR)
+	assert.Error(t, err)
+	_, err = CIDToCommR(commD)
+	assert.Error(t, err)
+}
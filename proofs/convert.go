@@ -0,0 +1,208 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package proofs
+
+import (
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+var sealProofs = map[abi.RegisteredSealProof]cgo.RegisteredSealProof{
+	abi.RegisteredSealProof_StackedDrg2KiBV1:   cgo.RegisteredSealProofStackedDrg2KiBV1,
+	abi.RegisteredSealProof_StackedDrg8MiBV1:   cgo.RegisteredSealProofStackedDrg8MiBV1,
+	abi.RegisteredSealProof_StackedDrg512MiBV1: cgo.RegisteredSealProofStackedDrg512MiBV1,
+	abi.RegisteredSealProof_StackedDrg32GiBV1:  cgo.RegisteredSealProofStackedDrg32GiBV1,
+	abi.RegisteredSealProof_StackedDrg64GiBV1:  cgo.RegisteredSealProofStackedDrg64GiBV1,
+
+	abi.RegisteredSealProof_StackedDrg2KiBV1_1:   cgo.RegisteredSealProofStackedDrg2KiBV11,
+	abi.RegisteredSealProof_StackedDrg8MiBV1_1:   cgo.RegisteredSealProofStackedDrg8MiBV11,
+	abi.RegisteredSealProof_StackedDrg512MiBV1_1: cgo.RegisteredSealProofStackedDrg512MiBV11,
+	abi.RegisteredSealProof_StackedDrg32GiBV1_1:  cgo.RegisteredSealProofStackedDrg32GiBV11,
+	abi.RegisteredSealProof_StackedDrg64GiBV1_1:  cgo.RegisteredSealProofStackedDrg64GiBV11,
+}
+
+var postProofs = map[abi.RegisteredPoStProof]cgo.RegisteredPoStProof{
+	abi.RegisteredPoStProof_StackedDrgWinning2KiBV1:   cgo.RegisteredPoStProofStackedDrgWinning2KiBV1,
+	abi.RegisteredPoStProof_StackedDrgWinning8MiBV1:   cgo.RegisteredPoStProofStackedDrgWinning8MiBV1,
+	abi.RegisteredPoStProof_StackedDrgWinning512MiBV1: cgo.RegisteredPoStProofStackedDrgWinning512MiBV1,
+	abi.RegisteredPoStProof_StackedDrgWinning32GiBV1:  cgo.RegisteredPoStProofStackedDrgWinning32GiBV1,
+	abi.RegisteredPoStProof_StackedDrgWinning64GiBV1:  cgo.RegisteredPoStProofStackedDrgWinning64GiBV1,
+
+	abi.RegisteredPoStProof_StackedDrgWindow2KiBV1:   cgo.RegisteredPoStProofStackedDrgWindow2KiBV1,
+	abi.RegisteredPoStProof_StackedDrgWindow8MiBV1:   cgo.RegisteredPoStProofStackedDrgWindow8MiBV1,
+	abi.RegisteredPoStProof_StackedDrgWindow512MiBV1: cgo.RegisteredPoStProofStackedDrgWindow512MiBV1,
+	abi.RegisteredPoStProof_StackedDrgWindow32GiBV1:  cgo.RegisteredPoStProofStackedDrgWindow32GiBV1,
+	abi.RegisteredPoStProof_StackedDrgWindow64GiBV1:  cgo.RegisteredPoStProofStackedDrgWindow64GiBV1,
+}
+
+var updateProofs = map[abi.RegisteredUpdateProof]cgo.RegisteredUpdateProof{
+	abi.RegisteredUpdateProof_StackedDrg2KiBV1:   cgo.RegisteredUpdateProofStackedDrg2KiBV1,
+	abi.RegisteredUpdateProof_StackedDrg8MiBV1:   cgo.RegisteredUpdateProofStackedDrg8MiBV1,
+	abi.RegisteredUpdateProof_StackedDrg512MiBV1: cgo.RegisteredUpdateProofStackedDrg512MiBV1,
+	abi.RegisteredUpdateProof_StackedDrg32GiBV1:  cgo.RegisteredUpdateProofStackedDrg32GiBV1,
+	abi.RegisteredUpdateProof_StackedDrg64GiBV1:  cgo.RegisteredUpdateProofStackedDrg64GiBV1,
+}
+
+var aggregationProofs = map[abi.RegisteredAggregationProof]cgo.RegisteredAggregationProof{
+	abi.RegisteredAggregationProof_SnarkPackV1: cgo.RegisteredAggregationProofSnarkPackV1,
+}
+
+func toSealProof(p abi.RegisteredSealProof) (cgo.RegisteredSealProof, error) {
+	out, ok := sealProofs[p]
+	if !ok {
+		return 0, xerrors.Errorf("unsupported seal proof type %d: %w", p, cgo.ErrInvalidInput)
+	}
+	return out, nil
+}
+
+func toPoStProof(p abi.RegisteredPoStProof) (cgo.RegisteredPoStProof, error) {
+	out, ok := postProofs[p]
+	if !ok {
+		return 0, xerrors.Errorf("unsupported PoSt proof type %d: %w", p, cgo.ErrInvalidInput)
+	}
+	return out, nil
+}
+
+func fromPoStProof(p cgo.RegisteredPoStProof) (abi.RegisteredPoStProof, error) {
+	for k, v := range postProofs {
+		if v == p {
+			return k, nil
+		}
+	}
+	return 0, xerrors.Errorf("unknown native PoSt proof type %d: %w", p, cgo.ErrInvalidInput)
+}
+
+func toUpdateProof(p abi.RegisteredUpdateProof) (cgo.RegisteredUpdateProof, error) {
+	out, ok := updateProofs[p]
+	if !ok {
+		return 0, xerrors.Errorf("unsupported update proof type %d: %w", p, cgo.ErrInvalidInput)
+	}
+	return out, nil
+}
+
+func toAggregationProof(p abi.RegisteredAggregationProof) (cgo.RegisteredAggregationProof, error) {
+	out, ok := aggregationProofs[p]
+	if !ok {
+		return 0, xerrors.Errorf("unsupported aggregation proof type %d: %w", p, cgo.ErrInvalidInput)
+	}
+	return out, nil
+}
+
+// proverID returns the prover id of a miner: the payload of its ID address.
+func proverID(miner uint64) (cgo.ByteArray32, error) {
+	maddr, err := address.NewIDAddress(miner)
+	if err != nil {
+		return cgo.ByteArray32{}, xerrors.Errorf("computing prover id: %w", err)
+	}
+	return cgo.AsByteArray32(maddr.Payload()), nil
+}
+
+func toByteArray32(b [32]byte) cgo.ByteArray32 {
+	return cgo.AsByteArray32(b[:])
+}
+
+func toPublicPieceInfos(pieces []PieceInfo) ([]cgo.PublicPieceInfo, error) {
+	out := make([]cgo.PublicPieceInfo, len(pieces))
+	for i, p := range pieces {
+		if err := p.Size.Validate(); err != nil {
+			return nil, xerrors.Errorf("piece %d: %w", i, err)
+		}
+		out[i] = cgo.NewPublicPieceInfo(uint64(p.Size.Unpadded()), toByteArray32(p.CommP))
+	}
+	return out, nil
+}
+
+// toPrivateReplicaInfos converts sectors, in sector number order. The
+// returned function frees the native copies of the paths.
+func toPrivateReplicaInfos(sectors []PrivateSector) ([]cgo.PrivateReplicaInfo, func(), error) {
+	sorted := make([]PrivateSector, len(sectors))
+	copy(sorted, sectors)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Number < sorted[j].Number
+	})
+
+	out := cgo.MakePrivateReplicaInfos(len(sorted))
+	cleanup := func() {
+		for i := range out {
+			out[i].Destroy()
+		}
+	}
+
+	for i, s := range sorted {
+		pp, err := toPoStProof(s.ProofType)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		out[i] = cgo.NewPrivateReplicaInfo(pp, s.CacheDir, toByteArray32(s.CommR), s.SealedPath, s.Number)
+	}
+
+	return out, cleanup, nil
+}
+
+func toPrivateReplicaInfo(s PrivateSector) (cgo.PrivateReplicaInfo, error) {
+	pp, err := toPoStProof(s.ProofType)
+	if err != nil {
+		return cgo.PrivateReplicaInfo{}, err
+	}
+	return cgo.NewPrivateReplicaInfo(pp, s.CacheDir, toByteArray32(s.CommR), s.SealedPath, s.Number), nil
+}
+
+// toPublicReplicaInfos converts sectors, in sector number order.
+func toPublicReplicaInfos(sectors []PublicSector) ([]cgo.PublicReplicaInfo, error) {
+	sorted := make([]PublicSector, len(sectors))
+	copy(sorted, sectors)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Number < sorted[j].Number
+	})
+
+	out := make([]cgo.PublicReplicaInfo, len(sorted))
+	for i, s := range sorted {
+		pp, err := toPoStProof(s.ProofType)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = cgo.NewPublicReplicaInfo(pp, toByteArray32(s.CommR), s.Number)
+	}
+	return out, nil
+}
+
+// toNativePoStProofs converts proofs. The returned function frees the native
+// copies of the proof bytes.
+func toNativePoStProofs(proofs []PoStProof) ([]cgo.PoStProof, func(), error) {
+	out := cgo.MakePoStProofs(len(proofs))
+	cleanup := func() {
+		for i := range out {
+			out[i].Destroy()
+		}
+	}
+
+	for i, p := range proofs {
+		pp, err := toPoStProof(p.ProofType)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		out[i] = cgo.NewPoStProof(pp, p.Proof)
+	}
+
+	return out, cleanup, nil
+}
+
+func fromNativePoStProofs(proofs []cgo.PoStProofGo) ([]PoStProof, error) {
+	out := make([]PoStProof, len(proofs))
+	for i, p := range proofs {
+		pp, err := fromPoStProof(p.RegisteredProof)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = PoStProof{ProofType: pp, Proof: p.Proof}
+	}
+	return out, nil
+}
@@ -0,0 +1,49 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package proofs
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+func TestProofTypeMappings(t *testing.T) {
+	sp, err := toSealProof(abi.RegisteredSealProof_StackedDrg32GiBV1_1)
+	require.NoError(t, err)
+	assert.Equal(t, cgo.RegisteredSealProof(cgo.RegisteredSealProofStackedDrg32GiBV11), sp)
+
+	_, err = toSealProof(abi.RegisteredSealProof(-1))
+	require.Error(t, err)
+
+	for p := range postProofs {
+		native, err := toPoStProof(p)
+		require.NoError(t, err)
+
+		back, err := fromPoStProof(native)
+		require.NoError(t, err)
+		assert.Equal(t, p, back)
+	}
+}
+
+func TestProverID(t *testing.T) {
+	id, err := proverID(1000)
+	require.NoError(t, err)
+
+	// the ID address payload is the uvarint of the actor ID
+	assert.Equal(t, cgo.AsByteArray32([]byte{0xe8, 0x07}), id)
+}
+
+func TestPublicPieceInfos(t *testing.T) {
+	infos, err := toPublicPieceInfos([]PieceInfo{{Size: 128}, {Size: 2048}})
+	require.NoError(t, err)
+	assert.Len(t, infos, 2)
+
+	_, err = toPublicPieceInfos([]PieceInfo{{Size: 128}, {Size: 1000}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package proofs
+
+import (
+	"os"
+
+	"golang.org/x/xerrors"
+)
+
+// FileOption changes how the sector files of a call are handled.
+//
+// The native library does its own reads and writes with unaligned buffers,
+// so the files it is given can't be opened with O_DIRECT. DropPageCache
+// evicts them from the page cache once the call returns instead.
+type FileOption func(*fileOptions)
+
+type fileOptions struct {
+	preallocate bool
+	dropCache   bool
+}
+
+func applyFileOptions(opts []FileOption) fileOptions {
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Preallocate allocates the output of UnsealRange, and the updated replica of
+// EncodeInto when it already exists, up front with fallocate, so that the
+// filesystem lays it out in few extents. The size of the files is unchanged.
+// It is a no-op on filesystems and platforms without fallocate.
+func Preallocate() FileOption {
+	return func(o *fileOptions) { o.preallocate = true }
+}
+
+// DropPageCache evicts the sector files read and written by a call from the
+// page cache once it returns, writing back their dirty pages first, so that
+// sealing doesn't push the working set of the host out of memory. It is best
+// effort, and a no-op on platforms without posix_fadvise.
+func DropPageCache() FileOption {
+	return func(o *fileOptions) { o.dropCache = true }
+}
+
+// dropPageCaches evicts the files from the page cache when o says so.
+func (o fileOptions) dropPageCaches(paths ...string) {
+	if !o.dropCache {
+		return
+	}
+	for _, path := range paths {
+		dropPageCache(path)
+	}
+}
+
+// preallocateExisting allocates the file at path, when it exists, to the size
+// of the file at like.
+func preallocateExisting(path string, like string) error {
+	st, err := os.Stat(like)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := preallocate(f, st.Size()); err != nil {
+		_ = f.Close()
+		return xerrors.Errorf("preallocating %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,43 @@
+//go:build cgo && !ffimock && linux
+// +build cgo,!ffimock,linux
+
+package proofs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreallocateExisting(t *testing.T) {
+	dir := t.TempDir()
+	like := filepath.Join(dir, "sector-key")
+	replica := filepath.Join(dir, "replica")
+	require.NoError(t, ioutil.WriteFile(like, make([]byte, 1<<20), 0644))
+
+	// A missing replica is left for the native library to report.
+	require.NoError(t, preallocateExisting(replica, like))
+	_, err := os.Stat(replica)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ioutil.WriteFile(replica, nil, 0644))
+	require.NoError(t, preallocateExisting(replica, like))
+
+	st, err := os.Stat(replica)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), st.Size(), "the size is kept")
+	if blocks := st.Sys().(*syscall.Stat_t).Blocks; blocks == 0 {
+		t.Log("filesystem without fallocate")
+	} else {
+		assert.GreaterOrEqual(t, blocks*512, int64(1<<20))
+	}
+
+	// Dropping the cache of any file is harmless.
+	dropPageCache(replica)
+	dropPageCache(filepath.Join(dir, "missing"))
+}
@@ -0,0 +1,12 @@
+//go:build cgo && !ffimock && !linux
+// +build cgo,!ffimock,!linux
+
+package proofs
+
+import "os"
+
+func preallocate(*os.File, int64) error {
+	return nil
+}
+
+func dropPageCache(string) {}
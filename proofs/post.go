@@ -0,0 +1,130 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package proofs
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// GenerateWinningPoStSectorChallenge returns the indexes, in a set of
+// eligibleSectors sectors, of the sectors challenged for winning PoSt.
+func GenerateWinningPoStSectorChallenge(proofType abi.RegisteredPoStProof, miner uint64, randomness Randomness, eligibleSectors uint64) ([]uint64, error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	prover, err := proverID(miner)
+	if err != nil {
+		return nil, err
+	}
+
+	randomnessBytes := toByteArray32(randomness)
+	return cgo.GenerateWinningPoStSectorChallenge(pp, &randomnessBytes, eligibleSectors, &prover)
+}
+
+// GenerateWinningPoSt generates a winning PoSt for the challenged sectors.
+func GenerateWinningPoSt(miner uint64, sectors []PrivateSector, randomness Randomness) ([]PoStProof, error) {
+	replicas, cleanup, err := toPrivateReplicaInfos(sectors)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	prover, err := proverID(miner)
+	if err != nil {
+		return nil, err
+	}
+
+	randomnessBytes := toByteArray32(randomness)
+	proofs, err := cgo.GenerateWinningPoSt(&randomnessBytes, cgo.AsSliceRefPrivateReplicaInfo(replicas), &prover)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromNativePoStProofs(proofs)
+}
+
+// GenerateWindowPoSt generates a window PoSt for the sectors of a partition.
+// When sectors can't be proven, their numbers are returned along with the
+// error.
+func GenerateWindowPoSt(miner uint64, sectors []PrivateSector, randomness Randomness) (proofs []PoStProof, faulty []uint64, err error) {
+	replicas, cleanup, err := toPrivateReplicaInfos(sectors)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+
+	prover, err := proverID(miner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	randomnessBytes := toByteArray32(randomness)
+	rawProofs, faulty, err := cgo.GenerateWindowPoSt(&randomnessBytes, cgo.AsSliceRefPrivateReplicaInfo(replicas), &prover)
+	if err != nil {
+		return nil, faulty, err
+	}
+
+	proofs, err = fromNativePoStProofs(rawProofs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return proofs, nil, nil
+}
+
+// VerifyWinningPoSt returns whether a winning PoSt is valid.
+func VerifyWinningPoSt(info PoStVerifyInfo) (bool, error) {
+	return verifyPoSt(info, cgo.VerifyWinningPoSt)
+}
+
+// VerifyWindowPoSt returns whether a window PoSt is valid.
+func VerifyWindowPoSt(info PoStVerifyInfo) (bool, error) {
+	return verifyPoSt(info, cgo.VerifyWindowPoSt)
+}
+
+func verifyPoSt(info PoStVerifyInfo, verify func(*cgo.ByteArray32, cgo.SliceRefPublicReplicaInfo, cgo.SliceRefPoStProof, *cgo.ByteArray32) (bool, error)) (bool, error) {
+	replicas, err := toPublicReplicaInfos(info.Sectors)
+	if err != nil {
+		return false, err
+	}
+
+	proofs, cleanup, err := toNativePoStProofs(info.Proofs)
+	if err != nil {
+		return false, err
+	}
+	defer cleanup()
+
+	prover, err := proverID(info.Miner)
+	if err != nil {
+		return false, err
+	}
+
+	randomness := toByteArray32(info.Randomness)
+	return verify(&randomness, cgo.AsSliceRefPublicReplicaInfo(replicas), cgo.AsSliceRefPoStProof(proofs), &prover)
+}
+
+// NumPartitions returns the number of window PoSt partitions of numSectors
+// sectors.
+func NumPartitions(proofType abi.RegisteredPoStProof, numSectors uint) (uint, error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return 0, err
+	}
+
+	return cgo.GetNumPartitionForFallbackPost(pp, numSectors)
+}
+
+// PoStVersion returns the version of the PoSt proof type.
+func PoStVersion(proofType abi.RegisteredPoStProof) (string, error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return "", err
+	}
+
+	return cgo.GetPoStVersion(pp)
+}
@@ -0,0 +1,376 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+// Package proofs is a high-level interface to the Filecoin proofs, taking
+// plain Go values: byte slices, 32 byte arrays and file paths. Conversion to
+// the native types and the lifetime of the native memory are handled
+// internally, so callers don't deal with the cgo package.
+//
+// Unlike the root package, commitments are raw 32 byte values rather than
+// CIDs, and proof and sector types don't depend on specs-actors. CommRToCID,
+// CommDToCID and CommPToCID and their inverses convert between the two.
+package proofs
+
+import (
+	"os"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// GeneratePieceCommitment returns the CommP of the unpadded piece of the given
+// size stored at piecePath.
+func GeneratePieceCommitment(proofType abi.RegisteredSealProof, piecePath string, pieceSize abi.UnpaddedPieceSize) (Commitment, error) {
+	f, err := os.Open(piecePath)
+	if err != nil {
+		return Commitment{}, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	return GeneratePieceCommitmentFromFile(proofType, f, pieceSize)
+}
+
+// GeneratePieceCommitmentFromFile returns the CommP of the unpadded piece of
+// the given size read from f.
+func GeneratePieceCommitmentFromFile(proofType abi.RegisteredSealProof, f *os.File, pieceSize abi.UnpaddedPieceSize) (Commitment, error) {
+	sp, err := toSealProof(proofType)
+	if err != nil {
+		return Commitment{}, err
+	}
+	if err := pieceSize.Validate(); err != nil {
+		return Commitment{}, err
+	}
+
+	commP, err := cgo.GeneratePieceCommitmentFile(sp, f, uint64(pieceSize))
+	if err != nil {
+		return Commitment{}, err
+	}
+
+	return toCommitment(commP)
+}
+
+// GenerateDataCommitment returns the CommD of a sector holding the given
+// pieces, in order.
+func GenerateDataCommitment(proofType abi.RegisteredSealProof, pieces []PieceInfo) (Commitment, error) {
+	sp, err := toSealProof(proofType)
+	if err != nil {
+		return Commitment{}, err
+	}
+
+	infos, err := toPublicPieceInfos(pieces)
+	if err != nil {
+		return Commitment{}, err
+	}
+
+	commD, err := cgo.GenerateDataCommitment(sp, cgo.AsSliceRefPublicPieceInfo(infos))
+	if err != nil {
+		return Commitment{}, err
+	}
+
+	return toCommitment(commD)
+}
+
+// SealPreCommitPhase1 runs the first pre-commit phase, and returns its output
+// which is the input of SealPreCommitPhase2. DropPageCache applies to the
+// staged and sealed sectors.
+func SealPreCommitPhase1(
+	proofType abi.RegisteredSealProof,
+	paths SectorPaths,
+	sector SectorID,
+	ticket Randomness,
+	pieces []PieceInfo,
+	opts ...FileOption,
+) ([]byte, error) {
+	sp, err := toSealProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	prover, err := proverID(sector.Miner)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := toPublicPieceInfos(pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	o := applyFileOptions(opts)
+	defer o.dropPageCaches(paths.Staged, paths.Sealed)
+
+	ticketBytes := toByteArray32(ticket)
+	return cgo.SealPreCommitPhase1(
+		sp,
+		cgo.AsSliceRefUint8([]byte(paths.Cache)),
+		cgo.AsSliceRefUint8([]byte(paths.Staged)),
+		cgo.AsSliceRefUint8([]byte(paths.Sealed)),
+		sector.Number,
+		&prover,
+		&ticketBytes,
+		cgo.AsSliceRefPublicPieceInfo(infos),
+	)
+}
+
+// SealPreCommitPhase2 runs the second pre-commit phase, and returns the
+// sector commitments. DropPageCache applies to the sealed sector.
+func SealPreCommitPhase2(phase1Output []byte, paths SectorPaths, opts ...FileOption) (commR Commitment, commD Commitment, err error) {
+	defer applyFileOptions(opts).dropPageCaches(paths.Sealed)
+
+	rawCommR, rawCommD, err := cgo.SealPreCommitPhase2(
+		cgo.AsSliceRefUint8(phase1Output),
+		cgo.AsSliceRefUint8([]byte(paths.Cache)),
+		cgo.AsSliceRefUint8([]byte(paths.Sealed)),
+	)
+	if err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+
+	if commR, err = toCommitment(rawCommR); err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+	if commD, err = toCommitment(rawCommD); err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+
+	return commR, commD, nil
+}
+
+// SealCommitPhase1 runs the first commit phase, and returns its output which
+// is the input of SealCommitPhase2.
+func SealCommitPhase1(
+	proofType abi.RegisteredSealProof,
+	paths SectorPaths,
+	sector SectorID,
+	commR Commitment,
+	commD Commitment,
+	ticket Randomness,
+	seed Randomness,
+	pieces []PieceInfo,
+) ([]byte, error) {
+	sp, err := toSealProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	prover, err := proverID(sector.Miner)
+	if err != nil {
+		return nil, err
+	}
+
+	commRBytes := toByteArray32(commR)
+	commDBytes := toByteArray32(commD)
+	ticketBytes := toByteArray32(ticket)
+	seedBytes := toByteArray32(seed)
+
+	infos, err := toPublicPieceInfos(pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	return cgo.SealCommitPhase1(
+		sp,
+		&commRBytes,
+		&commDBytes,
+		cgo.AsSliceRefUint8([]byte(paths.Cache)),
+		cgo.AsSliceRefUint8([]byte(paths.Sealed)),
+		sector.Number,
+		&prover,
+		&ticketBytes,
+		&seedBytes,
+		cgo.AsSliceRefPublicPieceInfo(infos),
+	)
+}
+
+// SealCommitPhase2 generates the seal proof of a sector from the output of
+// SealCommitPhase1.
+func SealCommitPhase2(phase1Output []byte, sector SectorID) ([]byte, error) {
+	prover, err := proverID(sector.Miner)
+	if err != nil {
+		return nil, err
+	}
+
+	return cgo.SealCommitPhase2(cgo.AsSliceRefUint8(phase1Output), sector.Number, &prover)
+}
+
+// AggregateSealProofs aggregates the seal proofs of sectors. The commRs and
+// seeds are those of the sectors, in the order of the proofs.
+func AggregateSealProofs(
+	proofType abi.RegisteredSealProof,
+	aggregateType abi.RegisteredAggregationProof,
+	commRs []Commitment,
+	seeds []Randomness,
+	proofs [][]byte,
+) ([]byte, error) {
+	if len(commRs) != len(proofs) || len(seeds) != len(proofs) {
+		return nil, xerrors.Errorf("got %d proofs for %d commitments and %d seeds", len(proofs), len(commRs), len(seeds))
+	}
+
+	sp, err := toSealProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	ap, err := toAggregationProof(aggregateType)
+	if err != nil {
+		return nil, err
+	}
+
+	rawCommRs := make([]cgo.ByteArray32, len(commRs))
+	rawSeeds := make([]cgo.ByteArray32, len(seeds))
+	for i := range commRs {
+		rawCommRs[i] = toByteArray32(commRs[i])
+		rawSeeds[i] = toByteArray32(seeds[i])
+	}
+
+	boxed, cleanup := cgo.PinSlicesBoxedUint8(proofs)
+	defer cleanup()
+
+	return cgo.AggregateSealProofs(
+		sp,
+		ap,
+		cgo.AsSliceRefByteArray32(rawCommRs),
+		cgo.AsSliceRefByteArray32(rawSeeds),
+		cgo.AsSliceRefSliceBoxedUint8(boxed),
+	)
+}
+
+// VerifySeal returns whether the seal proof of a sector is valid.
+func VerifySeal(info SealVerifyInfo) (bool, error) {
+	sp, err := toSealProof(info.ProofType)
+	if err != nil {
+		return false, err
+	}
+
+	prover, err := proverID(info.Sector.Miner)
+	if err != nil {
+		return false, err
+	}
+
+	commR := toByteArray32(info.CommR)
+	commD := toByteArray32(info.CommD)
+	ticket := toByteArray32(info.Ticket)
+	seed := toByteArray32(info.Seed)
+
+	return cgo.VerifySeal(sp, &commR, &commD, &prover, &ticket, &seed, info.Sector.Number, cgo.AsSliceRefUint8(info.Proof))
+}
+
+// VerifyAggregateSeals returns whether an aggregate of seal proofs is valid.
+func VerifyAggregateSeals(info AggregateVerifyInfo) (bool, error) {
+	if len(info.Sectors) == 0 {
+		return false, xerrors.New("no sectors in aggregate")
+	}
+
+	sp, err := toSealProof(info.ProofType)
+	if err != nil {
+		return false, err
+	}
+
+	ap, err := toAggregationProof(info.AggregateType)
+	if err != nil {
+		return false, err
+	}
+
+	prover, err := proverID(info.Miner)
+	if err != nil {
+		return false, err
+	}
+
+	inputs := make([]cgo.AggregationInputs, len(info.Sectors))
+	for i, s := range info.Sectors {
+		inputs[i] = cgo.NewAggregationInputs(
+			toByteArray32(s.CommR),
+			toByteArray32(s.CommD),
+			s.Number,
+			toByteArray32(s.Ticket),
+			toByteArray32(s.Seed),
+		)
+	}
+
+	return cgo.VerifyAggregateSealProof(sp, ap, &prover, cgo.AsSliceRefUint8(info.Proof), cgo.AsSliceRefAggregationInputs(inputs))
+}
+
+// UnsealRange unseals length unpadded bytes of a sector starting at offset,
+// and writes them to the file at outputPath, which is created if needed.
+// Preallocate applies to the output, and DropPageCache to the sealed sector
+// and the output.
+func UnsealRange(
+	proofType abi.RegisteredSealProof,
+	paths SectorPaths,
+	outputPath string,
+	sector SectorID,
+	ticket Randomness,
+	commD Commitment,
+	offset uint64,
+	length uint64,
+	opts ...FileOption,
+) error {
+	sp, err := toSealProof(proofType)
+	if err != nil {
+		return err
+	}
+
+	prover, err := proverID(sector.Miner)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := os.Open(paths.Sealed)
+	if err != nil {
+		return err
+	}
+	defer sealed.Close() // nolint:errcheck
+
+	output, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	o := applyFileOptions(opts)
+	defer o.dropPageCaches(paths.Sealed, outputPath)
+	if o.preallocate {
+		if err := preallocate(output, int64(length)); err != nil {
+			_ = output.Close()
+			return xerrors.Errorf("preallocating %s: %w", outputPath, err)
+		}
+	}
+
+	ticketBytes := toByteArray32(ticket)
+	commDBytes := toByteArray32(commD)
+	err = cgo.UnsealRangeFile(
+		sp,
+		cgo.AsSliceRefUint8([]byte(paths.Cache)),
+		sealed,
+		output,
+		sector.Number,
+		&prover,
+		&ticketBytes,
+		&commDBytes,
+		offset,
+		length,
+	)
+	if err != nil {
+		_ = output.Close()
+		return err
+	}
+
+	return output.Close()
+}
+
+// ClearCache removes the sealing intermediates of a sector of the given size
+// from its cache directory, keeping the files needed for PoSt.
+func ClearCache(sectorSize abi.SectorSize, cacheDir string) error {
+	return cgo.ClearCache(uint64(sectorSize), cgo.AsSliceRefUint8([]byte(cacheDir)))
+}
+
+// SealVersion returns the version of the seal proof type.
+func SealVersion(proofType abi.RegisteredSealProof) (string, error) {
+	sp, err := toSealProof(proofType)
+	if err != nil {
+		return "", err
+	}
+
+	return cgo.GetSealVersion(sp)
+}
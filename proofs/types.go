@@ -0,0 +1,129 @@
+package proofs
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// Commitment is a raw 32 byte commitment: a CommP, CommD or CommR.
+type Commitment [32]byte
+
+// Randomness is a 32 byte ticket, seed or PoSt randomness.
+type Randomness [32]byte
+
+// SectorID identifies a sector of a miner.
+type SectorID struct {
+	Miner  uint64
+	Number uint64
+}
+
+// PieceInfo describes a piece of a sector.
+type PieceInfo struct {
+	Size  abi.PaddedPieceSize
+	CommP Commitment
+}
+
+// SectorPaths are the local files of a sector.
+type SectorPaths struct {
+	// Cache is the sector cache directory.
+	Cache string
+	// Staged is the unsealed sector file.
+	Staged string
+	// Sealed is the sealed sector (replica) file.
+	Sealed string
+}
+
+// SealVerifyInfo is what is needed to verify the seal proof of a sector.
+type SealVerifyInfo struct {
+	ProofType abi.RegisteredSealProof
+	Sector    SectorID
+
+	CommR  Commitment
+	CommD  Commitment
+	Ticket Randomness
+	Seed   Randomness
+
+	Proof []byte
+}
+
+// AggregateSector is a sector of a seal proof aggregate.
+type AggregateSector struct {
+	Number uint64
+
+	CommR  Commitment
+	CommD  Commitment
+	Ticket Randomness
+	Seed   Randomness
+}
+
+// AggregateVerifyInfo is what is needed to verify an aggregate of seal
+// proofs.
+type AggregateVerifyInfo struct {
+	ProofType     abi.RegisteredSealProof
+	AggregateType abi.RegisteredAggregationProof
+	Miner         uint64
+
+	// Sectors are in the order their proofs were aggregated.
+	Sectors []AggregateSector
+
+	Proof []byte
+}
+
+// PrivateSector is a sealed sector to generate a PoSt for.
+type PrivateSector struct {
+	Number    uint64
+	ProofType abi.RegisteredPoStProof
+	CommR     Commitment
+
+	CacheDir   string
+	SealedPath string
+}
+
+// PublicSector is a sector a PoSt is verified for.
+type PublicSector struct {
+	Number    uint64
+	ProofType abi.RegisteredPoStProof
+	CommR     Commitment
+}
+
+// PoStProof is a winning or window PoSt proof.
+type PoStProof struct {
+	ProofType abi.RegisteredPoStProof
+	Proof     []byte
+}
+
+// PoStVerifyInfo is what is needed to verify a winning or window PoSt.
+type PoStVerifyInfo struct {
+	Miner      uint64
+	Randomness Randomness
+	Sectors    []PublicSector
+	Proofs     []PoStProof
+}
+
+// UpdatePaths are the local files of a sector updated with deal data.
+type UpdatePaths struct {
+	// SectorKey is the original sealed (CC) replica, and SectorKeyCache its
+	// cache directory.
+	SectorKey      string
+	SectorKeyCache string
+
+	// Replica is the updated replica, and ReplicaCache its cache directory.
+	Replica      string
+	ReplicaCache string
+}
+
+// UpdateCommitments are the commitments of a sector update.
+type UpdateCommitments struct {
+	// OldCommR is the CommR of the sector key replica.
+	OldCommR Commitment
+	// NewCommR and NewCommD are the commitments of the updated replica.
+	NewCommR Commitment
+	NewCommD Commitment
+}
+
+// FallbackChallenges are the PoSt challenges of a set of sectors.
+type FallbackChallenges struct {
+	// Sectors are the challenged sectors, in the order they were given.
+	Sectors []uint64
+	// Challenges are the challenged leaves of each sector.
+	Challenges map[uint64][]uint64
+}
@@ -0,0 +1,199 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package proofs
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// EncodeInto encodes the staged deal data into a copy of the sector key
+// replica, writing the updated replica and its cache to the paths' Replica
+// and ReplicaCache. It returns the new CommR and CommD of the sector.
+// Preallocate applies to the updated replica, and DropPageCache to both
+// replicas and the staged data.
+func EncodeInto(
+	proofType abi.RegisteredUpdateProof,
+	paths UpdatePaths,
+	stagedDataPath string,
+	pieces []PieceInfo,
+	opts ...FileOption,
+) (commR Commitment, commD Commitment, err error) {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+
+	o := applyFileOptions(opts)
+	defer o.dropPageCaches(paths.SectorKey, paths.Replica, stagedDataPath)
+	if o.preallocate {
+		if err := preallocateExisting(paths.Replica, paths.SectorKey); err != nil {
+			return Commitment{}, Commitment{}, err
+		}
+	}
+
+	infos, err := toPublicPieceInfos(pieces)
+	if err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+
+	rawCommR, rawCommD, err := cgo.EmptySectorUpdateEncodeInto(
+		up,
+		cgo.AsSliceRefUint8([]byte(paths.Replica)),
+		cgo.AsSliceRefUint8([]byte(paths.ReplicaCache)),
+		cgo.AsSliceRefUint8([]byte(paths.SectorKey)),
+		cgo.AsSliceRefUint8([]byte(paths.SectorKeyCache)),
+		cgo.AsSliceRefUint8([]byte(stagedDataPath)),
+		cgo.AsSliceRefPublicPieceInfo(infos),
+	)
+	if err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+
+	if commR, err = toCommitment(rawCommR); err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+	if commD, err = toCommitment(rawCommD); err != nil {
+		return Commitment{}, Commitment{}, err
+	}
+
+	return commR, commD, nil
+}
+
+// DecodeFrom decodes the deal data of an updated replica, given the sector
+// key replica and the new CommD, and writes it to outDataPath.
+func DecodeFrom(
+	proofType abi.RegisteredUpdateProof,
+	paths UpdatePaths,
+	outDataPath string,
+	commD Commitment,
+) error {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return err
+	}
+
+	commDBytes := toByteArray32(commD)
+	return cgo.EmptySectorUpdateDecodeFrom(
+		up,
+		cgo.AsSliceRefUint8([]byte(outDataPath)),
+		cgo.AsSliceRefUint8([]byte(paths.Replica)),
+		cgo.AsSliceRefUint8([]byte(paths.SectorKey)),
+		cgo.AsSliceRefUint8([]byte(paths.SectorKeyCache)),
+		&commDBytes,
+	)
+}
+
+func (c UpdateCommitments) native() (oldCommR, newCommR, newCommD cgo.ByteArray32) {
+	return toByteArray32(c.OldCommR), toByteArray32(c.NewCommR), toByteArray32(c.NewCommD)
+}
+
+// GenerateUpdateProof generates the proof that the updated replica encodes
+// the deal data into the sector key replica.
+func GenerateUpdateProof(proofType abi.RegisteredUpdateProof, comms UpdateCommitments, paths UpdatePaths) ([]byte, error) {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	oldCommR, newCommR, newCommD := comms.native()
+	return cgo.GenerateEmptySectorUpdateProof(
+		up,
+		&oldCommR,
+		&newCommR,
+		&newCommD,
+		cgo.AsSliceRefUint8([]byte(paths.SectorKey)),
+		cgo.AsSliceRefUint8([]byte(paths.SectorKeyCache)),
+		cgo.AsSliceRefUint8([]byte(paths.Replica)),
+		cgo.AsSliceRefUint8([]byte(paths.ReplicaCache)),
+	)
+}
+
+// GenerateUpdateVanillaProofs is the first phase of GenerateUpdateProof: it
+// reads the replicas and generates the vanilla proofs of every partition.
+// Like the output of SealCommitPhase1, these can be sent to another machine
+// for GenerateUpdateProofWithVanilla.
+func GenerateUpdateVanillaProofs(proofType abi.RegisteredUpdateProof, comms UpdateCommitments, paths UpdatePaths) ([][]byte, error) {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	oldCommR, newCommR, newCommD := comms.native()
+	return cgo.GenerateEmptySectorUpdatePartitionProofs(
+		up,
+		&oldCommR,
+		&newCommR,
+		&newCommD,
+		cgo.AsSliceRefUint8([]byte(paths.SectorKey)),
+		cgo.AsSliceRefUint8([]byte(paths.SectorKeyCache)),
+		cgo.AsSliceRefUint8([]byte(paths.Replica)),
+		cgo.AsSliceRefUint8([]byte(paths.ReplicaCache)),
+	)
+}
+
+// GenerateUpdateProofWithVanilla is the second phase of GenerateUpdateProof:
+// it generates the update proof from the vanilla proofs, without access to
+// the replicas.
+func GenerateUpdateProofWithVanilla(proofType abi.RegisteredUpdateProof, comms UpdateCommitments, vanillaProofs [][]byte) ([]byte, error) {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	boxed, cleanup := cgo.PinSlicesBoxedUint8(vanillaProofs)
+	defer cleanup()
+
+	oldCommR, newCommR, newCommD := comms.native()
+	return cgo.GenerateEmptySectorUpdateProofWithVanilla(up, cgo.AsSliceRefSliceBoxedUint8(boxed), &oldCommR, &newCommR, &newCommD)
+}
+
+// VerifyUpdateProof returns whether a sector update proof is valid.
+func VerifyUpdateProof(proofType abi.RegisteredUpdateProof, comms UpdateCommitments, proof []byte) (bool, error) {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return false, err
+	}
+
+	oldCommR, newCommR, newCommD := comms.native()
+	return cgo.VerifyEmptySectorUpdateProof(up, cgo.AsSliceRefUint8(proof), &oldCommR, &newCommR, &newCommD)
+}
+
+// VerifyUpdateVanillaProofs returns whether the partition proofs returned by
+// GenerateUpdateVanillaProofs are valid, so that they can be checked before
+// the final proof is generated from them.
+func VerifyUpdateVanillaProofs(proofType abi.RegisteredUpdateProof, comms UpdateCommitments, vanillaProofs [][]byte) (bool, error) {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return false, err
+	}
+
+	boxed, cleanup := cgo.PinSlicesBoxedUint8(vanillaProofs)
+	defer cleanup()
+
+	oldCommR, newCommR, newCommD := comms.native()
+	return cgo.VerifyEmptySectorUpdatePartitionProofs(up, cgo.AsSliceRefSliceBoxedUint8(boxed), &oldCommR, &newCommR, &newCommD)
+}
+
+// RemoveData reverts an updated replica to its CC state: it removes the
+// encoded data of dataPath from the paths' Replica, writing the sector key
+// replica and its cache to SectorKey and SectorKeyCache.
+func RemoveData(proofType abi.RegisteredUpdateProof, paths UpdatePaths, dataPath string, commD Commitment) error {
+	up, err := toUpdateProof(proofType)
+	if err != nil {
+		return err
+	}
+
+	commDBytes := toByteArray32(commD)
+	return cgo.EmptySectorUpdateRemoveEncodedData(
+		up,
+		cgo.AsSliceRefUint8([]byte(paths.SectorKey)),
+		cgo.AsSliceRefUint8([]byte(paths.SectorKeyCache)),
+		cgo.AsSliceRefUint8([]byte(paths.Replica)),
+		cgo.AsSliceRefUint8([]byte(paths.ReplicaCache)),
+		cgo.AsSliceRefUint8([]byte(dataPath)),
+		&commDBytes,
+	)
+}
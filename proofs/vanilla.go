@@ -0,0 +1,184 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package proofs
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// GenerateFallbackSectorChallenges returns the PoSt challenges of the given
+// sectors. Computing them only needs public inputs, so a PoSt coordinator can
+// compute them and hand each sector's challenges to the machine storing it,
+// which generates the vanilla proof with GenerateSingleVanillaProof.
+func GenerateFallbackSectorChallenges(
+	proofType abi.RegisteredPoStProof,
+	miner uint64,
+	randomness Randomness,
+	sectors []uint64,
+) (*FallbackChallenges, error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	prover, err := proverID(miner)
+	if err != nil {
+		return nil, err
+	}
+
+	randomnessBytes := toByteArray32(randomness)
+	ids, challenges, err := cgo.GenerateFallbackSectorChallenges(pp, &randomnessBytes, cgo.AsSliceRefUint64(sectors), &prover)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &FallbackChallenges{
+		Sectors:    ids,
+		Challenges: make(map[uint64][]uint64, len(ids)),
+	}
+	for i, id := range ids {
+		out.Challenges[id] = challenges[i]
+	}
+
+	return out, nil
+}
+
+// GenerateSingleVanillaProof generates the vanilla PoSt proof of a sector for
+// its challenges. It reads the sector files, but doesn't need a GPU.
+func GenerateSingleVanillaProof(sector PrivateSector, challenges []uint64) ([]byte, error) {
+	replica, err := toPrivateReplicaInfo(sector)
+	if err != nil {
+		return nil, err
+	}
+	defer replica.Destroy()
+
+	return cgo.GenerateSingleVanillaProof(replica, cgo.AsSliceRefUint64(challenges))
+}
+
+// GenerateWinningPoStWithVanilla generates a winning PoSt from the vanilla
+// proofs of the challenged sectors, without access to the sector files.
+func GenerateWinningPoStWithVanilla(proofType abi.RegisteredPoStProof, miner uint64, randomness Randomness, vanillaProofs [][]byte) ([]PoStProof, error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return nil, err
+	}
+
+	prover, err := proverID(miner)
+	if err != nil {
+		return nil, err
+	}
+
+	boxed, cleanup := cgo.PinSlicesBoxedUint8(vanillaProofs)
+	defer cleanup()
+
+	randomnessBytes := toByteArray32(randomness)
+	proofs, err := cgo.GenerateWinningPoStWithVanilla(pp, &randomnessBytes, &prover, cgo.AsSliceRefSliceBoxedUint8(boxed))
+	if err != nil {
+		return nil, err
+	}
+
+	return fromNativePoStProofs(proofs)
+}
+
+// GenerateWindowPoStWithVanilla generates a window PoSt from the vanilla
+// proofs of the sectors of a partition, without access to the sector files.
+// When sectors can't be proven, their numbers are returned along with the
+// error.
+func GenerateWindowPoStWithVanilla(proofType abi.RegisteredPoStProof, miner uint64, randomness Randomness, vanillaProofs [][]byte) (proofs []PoStProof, faulty []uint64, err error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prover, err := proverID(miner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	boxed, cleanup := cgo.PinSlicesBoxedUint8(vanillaProofs)
+	defer cleanup()
+
+	randomnessBytes := toByteArray32(randomness)
+	rawProofs, faulty, err := cgo.GenerateWindowPoStWithVanilla(pp, &randomnessBytes, &prover, cgo.AsSliceRefSliceBoxedUint8(boxed))
+	if err != nil {
+		return nil, faulty, err
+	}
+
+	proofs, err = fromNativePoStProofs(rawProofs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return proofs, nil, nil
+}
+
+// GenerateSinglePartitionWindowPoStWithVanilla generates the proof of one
+// partition of a window PoSt from the vanilla proofs of its sectors. The
+// partition proofs of a deadline can be generated on different machines and
+// combined with MergeWindowPoStPartitionProofs. When sectors can't be proven,
+// their numbers are returned along with the error.
+func GenerateSinglePartitionWindowPoStWithVanilla(
+	proofType abi.RegisteredPoStProof,
+	miner uint64,
+	randomness Randomness,
+	vanillaProofs [][]byte,
+	partitionIndex uint,
+) (proof PoStProof, faulty []uint64, err error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return PoStProof{}, nil, err
+	}
+
+	prover, err := proverID(miner)
+	if err != nil {
+		return PoStProof{}, nil, err
+	}
+
+	boxed, cleanup := cgo.PinSlicesBoxedUint8(vanillaProofs)
+	defer cleanup()
+
+	randomnessBytes := toByteArray32(randomness)
+	resp, faulty, err := cgo.GenerateSingleWindowPoStWithVanilla(pp, &randomnessBytes, &prover, cgo.AsSliceRefSliceBoxedUint8(boxed), partitionIndex)
+	if err != nil {
+		return PoStProof{}, faulty, err
+	}
+
+	rpp, err := fromPoStProof(resp.RegisteredProof)
+	if err != nil {
+		return PoStProof{}, nil, err
+	}
+
+	return PoStProof{ProofType: rpp, Proof: resp.Proof}, nil, nil
+}
+
+// MergeWindowPoStPartitionProofs combines the partition proofs of a window
+// PoSt, in partition order, into the proof of the whole deadline.
+func MergeWindowPoStPartitionProofs(proofType abi.RegisteredPoStProof, partitionProofs []PoStProof) (PoStProof, error) {
+	pp, err := toPoStProof(proofType)
+	if err != nil {
+		return PoStProof{}, err
+	}
+
+	raw := make([][]byte, len(partitionProofs))
+	for i, p := range partitionProofs {
+		raw[i] = p.Proof
+	}
+
+	boxed, cleanup := cgo.PinSlicesBoxedUint8(raw)
+	defer cleanup()
+
+	resp, err := cgo.MergeWindowPoStPartitionProofs(pp, cgo.AsSliceRefSliceBoxedUint8(boxed))
+	if err != nil {
+		return PoStProof{}, err
+	}
+
+	rpp, err := fromPoStProof(resp.RegisteredProof)
+	if err != nil {
+		return PoStProof{}, err
+	}
+
+	return PoStProof{ProofType: rpp, Proof: resp.Proof}, nil
+}
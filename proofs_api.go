@@ -0,0 +1,162 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+)
+
+// SectorRef identifies a sector and the files backing it. It mirrors the
+// lotus `storage.SectorRef`, extended with the paths lotus would otherwise
+// resolve through its sector provider.
+type SectorRef struct {
+	ID        abi.SectorID            `json:"ID"`
+	ProofType abi.RegisteredSealProof `json:"ProofType"`
+
+	StagedSectorPath string `json:"StagedSectorPath"`
+	SealedSectorPath string `json:"SealedSectorPath"`
+	CacheDirPath     string `json:"CacheDirPath"`
+}
+
+// SectorCids holds the commitments produced by pre-commit phase 2.
+type SectorCids struct {
+	Unsealed cid.Cid `json:"Unsealed"`
+	Sealed   cid.Cid `json:"Sealed"`
+}
+
+// ProofsAPI is the proving engine contract used by the lotus sealing stack
+// (the `storiface` worker calls and the `storage.Prover` PoSt calls). The
+// default implementation, Proofs, is backed by this package.
+type ProofsAPI interface {
+	SealPreCommit1(ctx context.Context, sector SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) ([]byte, error)
+	SealPreCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) (SectorCids, error)
+	SealCommit1(ctx context.Context, sector SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids SectorCids) ([]byte, error)
+	SealCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) ([]byte, error)
+
+	GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, error)
+	GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorID, error)
+
+	GenerateWinningPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error)
+	GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error)
+	GenerateSinglePartitionWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte, partitionIndex uint) (*PartitionProof, error)
+}
+
+// FunctionsProofs implements ProofsAPI on top of the package level functions.
+// Calls return early when their context is done, like the *Ctx functions.
+type FunctionsProofs struct{}
+
+var _ ProofsAPI = FunctionsProofs{}
+
+// Proofs is the default ProofsAPI implementation.
+var Proofs = FunctionsProofs{}
+
+func (FunctionsProofs) SealPreCommit1(ctx context.Context, sector SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) ([]byte, error) {
+	return SealPreCommitPhase1Ctx(
+		ctx,
+		sector.ProofType,
+		sector.CacheDirPath,
+		sector.StagedSectorPath,
+		sector.SealedSectorPath,
+		sector.ID.Number,
+		sector.ID.Miner,
+		ticket,
+		pieces,
+	)
+}
+
+func (FunctionsProofs) SealPreCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) (SectorCids, error) {
+	if err := checkGPUBackend(ctx); err != nil {
+		return SectorCids{}, err
+	}
+
+	sealedCID, unsealedCID, err := SealPreCommitPhase2Ctx(ctx, phase1Output, sector.CacheDirPath, sector.SealedSectorPath)
+	if err != nil {
+		return SectorCids{}, err
+	}
+
+	return SectorCids{
+		Unsealed: unsealedCID,
+		Sealed:   sealedCID,
+	}, nil
+}
+
+func (FunctionsProofs) SealCommit1(ctx context.Context, sector SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids SectorCids) ([]byte, error) {
+	return SealCommitPhase1Ctx(
+		ctx,
+		sector.ProofType,
+		cids.Sealed,
+		cids.Unsealed,
+		sector.CacheDirPath,
+		sector.SealedSectorPath,
+		sector.ID.Number,
+		sector.ID.Miner,
+		ticket,
+		seed,
+		pieces,
+	)
+}
+
+func (FunctionsProofs) SealCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) ([]byte, error) {
+	if err := checkGPUBackend(ctx); err != nil {
+		return nil, err
+	}
+
+	return SealCommitPhase2Ctx(ctx, phase1Output, sector.ID.Number, sector.ID.Miner)
+}
+
+func (FunctionsProofs) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, error) {
+	if err := checkGPUBackend(ctx); err != nil {
+		return nil, err
+	}
+
+	return GenerateWinningPoStCtx(ctx, minerID, sectorInfo, randomness)
+}
+
+// GenerateWindowPoSt returns the faulty sectors as sector IDs, matching the
+// lotus `storage.Prover` contract.
+func (FunctionsProofs) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) ([]proof5.PoStProof, []abi.SectorID, error) {
+	if err := checkGPUBackend(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	proofs, faulty, err := GenerateWindowPoStCtx(ctx, minerID, sectorInfo, randomness)
+
+	var skipped []abi.SectorID
+	for _, num := range faulty {
+		skipped = append(skipped, abi.SectorID{
+			Miner:  minerID,
+			Number: num,
+		})
+	}
+
+	return proofs, skipped, err
+}
+
+func (FunctionsProofs) GenerateWinningPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error) {
+	if err := checkGPUBackend(ctx); err != nil {
+		return nil, err
+	}
+
+	return GenerateWinningPoStWithVanillaCtx(ctx, proofType, minerID, randomness, proofs)
+}
+
+func (FunctionsProofs) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) ([]proof5.PoStProof, error) {
+	if err := checkGPUBackend(ctx); err != nil {
+		return nil, err
+	}
+
+	return GenerateWindowPoStWithVanillaCtx(ctx, proofType, minerID, randomness, proofs)
+}
+
+func (FunctionsProofs) GenerateSinglePartitionWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte, partitionIndex uint) (*PartitionProof, error) {
+	if err := checkGPUBackend(ctx); err != nil {
+		return nil, err
+	}
+
+	return GenerateSinglePartitionWindowPoStWithVanillaCtx(ctx, proofType, minerID, randomness, proofs, partitionIndex)
+}
@@ -0,0 +1,208 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+)
+
+// transientErrorPatterns are the lowercase fragments of the messages of the
+// native library, and of the CUDA and OpenCL runtimes below it, reporting a
+// failure to get resources rather than a problem with the inputs.
+var transientErrorPatterns = []string{
+	"out of memory",
+	"out_of_memory",
+	"cl_out_of_resources",
+	"cl_out_of_host_memory",
+	"cl_mem_object_allocation_failure",
+	"cuda_error_launch_out_of_resources",
+	"device busy",
+	"device or resource busy",
+	"cuda_error_device_unavailable",
+	"device unavailable",
+}
+
+// IsTransientError reports whether err is a failure of a call which may
+// succeed when repeated: the GPU running out of memory, or being busy with
+// another process. The errors the native library attributes to its caller,
+// the invalid inputs and the context errors are never transient.
+func IsTransientError(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrInvalidInput),
+		errors.Is(err, ErrCallerError),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range transientErrorPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPolicy configures the retries of failed calls.
+type RetryPolicy struct {
+	// Attempts is the maximum number of times a call is made, the first one
+	// included. Defaults to 3.
+	Attempts int
+
+	// Backoff is the delay before the first retry, doubled for each of the
+	// following ones up to MaxBackoff. Defaults to a second, and MaxBackoff
+	// to a minute.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Retryable tells which errors are retried. Defaults to
+	// IsTransientError.
+	Retryable func(err error) bool
+
+	// OnRetry, when set, is called with the error of a failed attempt before
+	// the call is retried.
+	OnRetry func(call string, attempt int, err error)
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Attempts <= 0 {
+		p.Attempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = time.Minute
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsTransientError
+	}
+	return p
+}
+
+// Do calls fn until it succeeds, fails with an error which isn't retryable,
+// or the attempts run out, waiting between the attempts. It returns the
+// error of the last attempt, or the context error when ctx is done while
+// waiting.
+//
+// Only errors are retried: to retry a verification, fn returns its outcome
+// through a variable, so that an invalid proof isn't verified again.
+func (p RetryPolicy) Do(ctx context.Context, call string, fn func() error) error {
+	p = p.withDefaults()
+
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !p.Retryable(err) {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(call, attempt, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w (retrying after: %s)", call, ctx.Err(), err)
+		}
+		if backoff *= 2; backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// RetryingProofs is a ProofsAPI retrying the calls of another one which fail
+// with transient errors, as told by its policy.
+type RetryingProofs struct {
+	api    ProofsAPI
+	policy RetryPolicy
+}
+
+var _ ProofsAPI = (*RetryingProofs)(nil)
+
+// NewRetryingProofs returns a RetryingProofs making the calls on api.
+func NewRetryingProofs(api ProofsAPI, policy RetryPolicy) *RetryingProofs {
+	return &RetryingProofs{api: api, policy: policy}
+}
+
+func (r *RetryingProofs) SealPreCommit1(ctx context.Context, sector SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (out []byte, err error) {
+	err = r.policy.Do(ctx, "SealPreCommit1", func() error {
+		out, err = r.api.SealPreCommit1(ctx, sector, ticket, pieces)
+		return err
+	})
+	return out, err
+}
+
+func (r *RetryingProofs) SealPreCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) (out SectorCids, err error) {
+	err = r.policy.Do(ctx, "SealPreCommit2", func() error {
+		out, err = r.api.SealPreCommit2(ctx, sector, phase1Output)
+		return err
+	})
+	return out, err
+}
+
+func (r *RetryingProofs) SealCommit1(ctx context.Context, sector SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids SectorCids) (out []byte, err error) {
+	err = r.policy.Do(ctx, "SealCommit1", func() error {
+		out, err = r.api.SealCommit1(ctx, sector, ticket, seed, pieces, cids)
+		return err
+	})
+	return out, err
+}
+
+func (r *RetryingProofs) SealCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) (out []byte, err error) {
+	err = r.policy.Do(ctx, "SealCommit2", func() error {
+		out, err = r.api.SealCommit2(ctx, sector, phase1Output)
+		return err
+	})
+	return out, err
+}
+
+func (r *RetryingProofs) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) (out []proof5.PoStProof, err error) {
+	err = r.policy.Do(ctx, "GenerateWinningPoSt", func() error {
+		out, err = r.api.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+		return err
+	})
+	return out, err
+}
+
+func (r *RetryingProofs) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo SortedPrivateSectorInfo, randomness abi.PoStRandomness) (out []proof5.PoStProof, faulty []abi.SectorID, err error) {
+	err = r.policy.Do(ctx, "GenerateWindowPoSt", func() error {
+		out, faulty, err = r.api.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+		return err
+	})
+	return out, faulty, err
+}
+
+func (r *RetryingProofs) GenerateWinningPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) (out []proof5.PoStProof, err error) {
+	err = r.policy.Do(ctx, "GenerateWinningPoStWithVanilla", func() error {
+		out, err = r.api.GenerateWinningPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+		return err
+	})
+	return out, err
+}
+
+func (r *RetryingProofs) GenerateWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte) (out []proof5.PoStProof, err error) {
+	err = r.policy.Do(ctx, "GenerateWindowPoStWithVanilla", func() error {
+		out, err = r.api.GenerateWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs)
+		return err
+	})
+	return out, err
+}
+
+func (r *RetryingProofs) GenerateSinglePartitionWindowPoStWithVanilla(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, randomness abi.PoStRandomness, proofs [][]byte, partitionIndex uint) (out *PartitionProof, err error) {
+	err = r.policy.Do(ctx, "GenerateSinglePartitionWindowPoStWithVanilla", func() error {
+		out, err = r.api.GenerateSinglePartitionWindowPoStWithVanilla(ctx, proofType, minerID, randomness, proofs, partitionIndex)
+		return err
+	})
+	return out, err
+}
@@ -0,0 +1,72 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestIsTransientError(t *testing.T) {
+	assert.False(t, IsTransientError(nil))
+	assert.True(t, IsTransientError(errors.New("CUDA error: out of memory")))
+	assert.True(t, IsTransientError(xerrors.Errorf("proving: %w", errors.New("OpenCL error: CL_OUT_OF_RESOURCES"))))
+	assert.True(t, IsTransientError(errors.New("GPU device busy")))
+	assert.False(t, IsTransientError(errors.New("invalid proof")))
+	assert.False(t, IsTransientError(xerrors.Errorf("out of memory: %w", ErrInvalidInput)))
+	assert.False(t, IsTransientError(xerrors.Errorf("out of memory: %w", context.Canceled)))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var retried []int
+	p := RetryPolicy{
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+		OnRetry:  func(call string, attempt int, err error) { retried = append(retried, attempt) },
+	}
+
+	// transient errors are retried until the call succeeds
+	calls := 0
+	err := p.Do(context.Background(), "test", func() error {
+		if calls++; calls < 3 {
+			return errors.New("CUDA error: out of memory")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, retried)
+
+	// or the attempts run out
+	calls = 0
+	err = p.Do(context.Background(), "test", func() error {
+		calls++
+		return errors.New("CUDA error: out of memory")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	// other errors aren't
+	calls = 0
+	err = p.Do(context.Background(), "test", func() error {
+		calls++
+		return errors.New("invalid proof")
+	})
+	assert.EqualError(t, err, "invalid proof")
+	assert.Equal(t, 1, calls)
+
+	// the backoff stops with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	p.Backoff = time.Hour
+	p.OnRetry = func(string, int, error) { cancel() }
+	err = p.Do(ctx, "test", func() error { return errors.New("device busy") })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.EqualError(t, err, "test: context canceled (retrying after: device busy)")
+}
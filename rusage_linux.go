@@ -0,0 +1,28 @@
+//go:build cgo && !ffimock && linux
+// +build cgo,!ffimock,linux
+
+package ffi
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// usage is the resource usage of the process.
+type usage struct {
+	cpu    time.Duration
+	maxRSS uint64
+}
+
+func processUsage() (usage, bool) {
+	var ru unix.Rusage
+	if err := unix.Getrusage(unix.RUSAGE_SELF, &ru); err != nil {
+		return usage{}, false
+	}
+	return usage{
+		cpu: time.Duration(ru.Utime.Nano() + ru.Stime.Nano()),
+		// ru_maxrss is in kilobytes on Linux.
+		maxRSS: uint64(ru.Maxrss) * 1024,
+	}, true
+}
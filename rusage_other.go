@@ -0,0 +1,16 @@
+//go:build cgo && !ffimock && !linux
+// +build cgo,!ffimock,!linux
+
+package ffi
+
+import "time"
+
+// usage is the resource usage of the process.
+type usage struct {
+	cpu    time.Duration
+	maxRSS uint64
+}
+
+func processUsage() (usage, bool) {
+	return usage{}, false
+}
@@ -2,16 +2,20 @@ use bls_signatures::{
     aggregate as aggregate_sig, hash as hash_sig, verify as verify_sig,
     verify_messages as verify_messages_sig, Error, PrivateKey, PublicKey, Serialize, Signature,
 };
-use blstrs::{G2Affine, G2Projective};
+use blstrs::{Bls12, G1Affine, G1Projective, G2Affine, G2Prepared, G2Projective, Scalar};
 use group::prime::PrimeCurveAffine;
-use group::GroupEncoding;
+use group::{Curve, Group, GroupEncoding};
+use pairing::{MillerLoopResult, MultiMillerLoop};
 
 use rand::rngs::OsRng;
+use rand::RngCore;
 use rand::SeedableRng;
 use rand_chacha::ChaChaRng;
 use rayon::prelude::*;
 use safer_ffi::prelude::*;
 
+use crate::util::types::catch_panic_value;
+
 pub const SIGNATURE_BYTES: usize = 96;
 pub const PRIVATE_KEY_BYTES: usize = 32;
 pub const PUBLIC_KEY_BYTES: usize = 48;
@@ -22,6 +26,23 @@ pub type BLSPrivateKey = [u8; PRIVATE_KEY_BYTES];
 pub type BLSPublicKey = [u8; PUBLIC_KEY_BYTES];
 pub type BLSDigest = [u8; DIGEST_BYTES];
 
+/// Outcome of the decoding of a compressed public key or signature.
+#[derive_ReprC]
+#[repr(i32)]
+#[derive(PartialEq, Debug, Copy, Clone)]
+pub enum BLSPointStatus {
+    /// A point of the prime order subgroup, other than the identity.
+    Valid = 0,
+    /// Not the encoding of a point of the subgroup: of the wrong length, not
+    /// on the curve, or of the wrong order.
+    Malformed = 1,
+    /// The identity point.
+    Identity = 2,
+}
+
+// The bindings return plain values rather than responses: they catch panics
+// with catch_panic_value, returning false, None or Malformed instead.
+
 /// Unwraps or returns the passed in value.
 macro_rules! try_ffi {
     ($res:expr, $val:expr) => {{
@@ -57,12 +78,14 @@ fn destroy_box_bls_signature(ptr: repr_c::Box<BLSSignature>) {
 ///
 /// * `message` - reference to a message byte array
 #[ffi_export]
-pub fn hash(message: c_slice::Ref<u8>) -> repr_c::Box<BLSDigest> {
-    // call method
-    let raw_digest = hash_sig(&message).to_bytes();
-    let digest: [u8; DIGEST_BYTES] = raw_digest.as_ref().try_into().expect("known size");
-
-    repr_c::Box::new(digest)
+pub fn hash(message: c_slice::Ref<u8>) -> Option<repr_c::Box<BLSDigest>> {
+    catch_panic_value("hash", None, || {
+        // call method
+        let raw_digest = hash_sig(&message).to_bytes();
+        let digest: [u8; DIGEST_BYTES] = raw_digest.as_ref().try_into().expect("known size");
+
+        Some(repr_c::Box::new(digest))
+    })
 }
 
 /// Aggregate signatures together into a new signature
@@ -74,23 +97,69 @@ pub fn hash(message: c_slice::Ref<u8>) -> repr_c::Box<BLSDigest> {
 /// Returns `None` on error. Result must be freed using `destroy_aggregate_response`.
 #[ffi_export]
 pub fn aggregate(flattened_signatures: c_slice::Ref<u8>) -> Option<repr_c::Box<BLSSignature>> {
-    // prep request
-    let signatures = try_ffi!(
-        flattened_signatures
-            .par_chunks(SIGNATURE_BYTES)
-            .map(|item| { Signature::from_bytes(item) })
-            .collect::<Result<Vec<_>, _>>(),
-        None
-    );
-
-    let mut signature: [u8; SIGNATURE_BYTES] = [0; SIGNATURE_BYTES];
-
-    let aggregated = try_ffi!(aggregate_sig(&signatures), None);
-    aggregated
-        .write_bytes(&mut signature.as_mut())
-        .expect("preallocated");
-
-    Some(repr_c::Box::new(signature))
+    catch_panic_value("aggregate", None, || {
+        // prep request
+        let signatures = try_ffi!(
+            flattened_signatures
+                .par_chunks(SIGNATURE_BYTES)
+                .map(|item| { Signature::from_bytes(item) })
+                .collect::<Result<Vec<_>, _>>(),
+            None
+        );
+
+        let mut signature: [u8; SIGNATURE_BYTES] = [0; SIGNATURE_BYTES];
+
+        let aggregated = try_ffi!(aggregate_sig(&signatures), None);
+        aggregated
+            .write_bytes(&mut signature.as_mut())
+            .expect("preallocated");
+
+        Some(repr_c::Box::new(signature))
+    })
+}
+
+/// Aggregate public keys together into a new public key, which verifies the
+/// aggregate of the signatures of a single message by all the keys
+///
+/// # Arguments
+///
+/// * `flattened_public_keys` - byte array containing public keys
+///
+/// Returns `None` when there is no key, or a key is malformed or the identity.
+/// Result must be freed using `destroy_box_bls_public_key`.
+#[ffi_export]
+pub fn aggregate_public_keys(
+    flattened_public_keys: c_slice::Ref<u8>,
+) -> Option<repr_c::Box<BLSPublicKey>> {
+    catch_panic_value("aggregate_public_keys", None, || {
+        if flattened_public_keys.is_empty() || flattened_public_keys.len() % PUBLIC_KEY_BYTES != 0 {
+            return None;
+        }
+
+        let public_keys: Vec<G1Affine> = try_ffi!(
+            flattened_public_keys
+                .par_chunks(PUBLIC_KEY_BYTES)
+                .map(|item| {
+                    let mut raw = [0u8; PUBLIC_KEY_BYTES];
+                    raw.copy_from_slice(item);
+                    Option::from(G1Affine::from_compressed(&raw)).ok_or(Error::CurveDecode)
+                })
+                .collect::<Result<_, Error>>(),
+            None
+        );
+
+        if public_keys.iter().any(|pk| bool::from(pk.is_identity())) {
+            return None;
+        }
+
+        let aggregated = public_keys
+            .iter()
+            .fold(G1Projective::identity(), |acc, pk| {
+                acc + G1Projective::from(pk)
+            });
+
+        Some(repr_c::Box::new(aggregated.to_affine().to_compressed()))
+    })
 }
 
 /// Verify that a signature is the aggregated signature of hashes - pubkeys
@@ -106,43 +175,46 @@ pub fn verify(
     flattened_digests: c_slice::Ref<u8>,
     flattened_public_keys: c_slice::Ref<u8>,
 ) -> bool {
-    // prep request
-    let signature = try_ffi!(Signature::from_bytes(&signature), false);
+    catch_panic_value("verify", false, || {
+        // prep request
+        let signature = try_ffi!(Signature::from_bytes(&signature), false);
 
-    if flattened_digests.len() % DIGEST_BYTES != 0 {
-        return false;
-    }
-    if flattened_public_keys.len() % PUBLIC_KEY_BYTES != 0 {
-        return false;
-    }
+        if flattened_digests.len() % DIGEST_BYTES != 0 {
+            return false;
+        }
+        if flattened_public_keys.len() % PUBLIC_KEY_BYTES != 0 {
+            return false;
+        }
 
-    if flattened_digests.len() / DIGEST_BYTES != flattened_public_keys.len() / PUBLIC_KEY_BYTES {
-        return false;
-    }
+        if flattened_digests.len() / DIGEST_BYTES != flattened_public_keys.len() / PUBLIC_KEY_BYTES
+        {
+            return false;
+        }
+
+        let digests: Vec<_> = try_ffi!(
+            flattened_digests
+                .par_chunks(DIGEST_BYTES)
+                .map(|item: &[u8]| {
+                    let mut digest = [0u8; DIGEST_BYTES];
+                    digest.as_mut().copy_from_slice(item);
+
+                    let affine: Option<G2Affine> = Option::from(G2Affine::from_compressed(&digest));
+                    affine.map(Into::into).ok_or(Error::CurveDecode)
+                })
+                .collect::<Result<Vec<G2Projective>, Error>>(),
+            false
+        );
+
+        let public_keys: Vec<_> = try_ffi!(
+            flattened_public_keys
+                .par_chunks(PUBLIC_KEY_BYTES)
+                .map(|item| { PublicKey::from_bytes(item) })
+                .collect::<Result<_, _>>(),
+            false
+        );
 
-    let digests: Vec<_> = try_ffi!(
-        flattened_digests
-            .par_chunks(DIGEST_BYTES)
-            .map(|item: &[u8]| {
-                let mut digest = [0u8; DIGEST_BYTES];
-                digest.as_mut().copy_from_slice(item);
-
-                let affine: Option<G2Affine> = Option::from(G2Affine::from_compressed(&digest));
-                affine.map(Into::into).ok_or(Error::CurveDecode)
-            })
-            .collect::<Result<Vec<G2Projective>, Error>>(),
-        false
-    );
-
-    let public_keys: Vec<_> = try_ffi!(
-        flattened_public_keys
-            .par_chunks(PUBLIC_KEY_BYTES)
-            .map(|item| { PublicKey::from_bytes(item) })
-            .collect::<Result<_, _>>(),
-        false
-    );
-
-    verify_sig(&signature, digests.as_slice(), public_keys.as_slice())
+        verify_sig(&signature, digests.as_slice(), public_keys.as_slice())
+    })
 }
 
 /// Verify that a signature is the aggregated signature of the hashed messages
@@ -161,41 +233,195 @@ pub fn hash_verify(
     message_sizes: c_slice::Ref<libc::size_t>,
     flattened_public_keys: c_slice::Ref<u8>,
 ) -> bool {
-    // prep request
-    let signature = try_ffi!(Signature::from_bytes(&signature), false);
-
-    // split the flattened message array into slices of individual messages to be hashed
-    let mut messages: Vec<&[u8]> = Vec::with_capacity(message_sizes.len());
-    let mut offset = 0;
-    for chunk_size in message_sizes.iter() {
-        messages.push(&flattened_messages[offset..offset + *chunk_size]);
-        offset += *chunk_size
-    }
+    catch_panic_value("hash_verify", false, || {
+        // prep request
+        let signature = try_ffi!(Signature::from_bytes(&signature), false);
+
+        // split the flattened message array into slices of individual messages to be hashed
+        let mut messages: Vec<&[u8]> = Vec::with_capacity(message_sizes.len());
+        let mut offset = 0;
+        for chunk_size in message_sizes.iter() {
+            messages.push(&flattened_messages[offset..offset + *chunk_size]);
+            offset += *chunk_size
+        }
 
-    if flattened_public_keys.len() % PUBLIC_KEY_BYTES != 0 {
-        return false;
-    }
+        if flattened_public_keys.len() % PUBLIC_KEY_BYTES != 0 {
+            return false;
+        }
 
-    let public_keys: Vec<_> = try_ffi!(
-        flattened_public_keys
-            .par_chunks(PUBLIC_KEY_BYTES)
-            .map(|item| { PublicKey::from_bytes(item) })
-            .collect::<Result<_, _>>(),
-        false
-    );
+        let public_keys: Vec<_> = try_ffi!(
+            flattened_public_keys
+                .par_chunks(PUBLIC_KEY_BYTES)
+                .map(|item| { PublicKey::from_bytes(item) })
+                .collect::<Result<_, _>>(),
+            false
+        );
 
-    verify_messages_sig(&signature, &messages, &public_keys)
+        verify_messages_sig(&signature, &messages, &public_keys)
+    })
 }
 
-/// Generate a new private key
+/// Verify a batch of signatures, each over a single message by a single public
+/// key. Returns true only if every signature is valid.
+///
+/// Every signature is weighted by a random non-zero 64 bit scalar before the
+/// pairing check, so that invalid signatures can't cancel each other out. This
+/// makes it safe to use on untrusted input, unlike aggregating the signatures
+/// and calling `hash_verify`. Messages need not be distinct.
+///
+/// # Arguments
+///
+/// * `flattened_signatures`  - byte array containing signatures
+/// * `flattened_messages`    - byte array containing the messages
+/// * `message_sizes`         - array containing the lengths of the messages
+/// * `flattened_public_keys` - byte array containing public keys
 #[ffi_export]
-pub fn private_key_generate() -> repr_c::Box<BLSPrivateKey> {
-    let mut raw_private_key: [u8; PRIVATE_KEY_BYTES] = [0; PRIVATE_KEY_BYTES];
-    PrivateKey::generate(&mut OsRng)
-        .write_bytes(&mut raw_private_key.as_mut())
-        .expect("preallocated");
+pub fn batch_verify(
+    flattened_signatures: c_slice::Ref<u8>,
+    flattened_messages: c_slice::Ref<u8>,
+    message_sizes: c_slice::Ref<libc::size_t>,
+    flattened_public_keys: c_slice::Ref<u8>,
+) -> bool {
+    catch_panic_value("batch_verify", false, || {
+        let count = message_sizes.len();
+        if count == 0 {
+            return false;
+        }
+        if flattened_signatures.len() != count * SIGNATURE_BYTES {
+            return false;
+        }
+        if flattened_public_keys.len() != count * PUBLIC_KEY_BYTES {
+            return false;
+        }
+
+        // split the flattened message array into slices of individual messages to be hashed
+        let mut messages: Vec<&[u8]> = Vec::with_capacity(count);
+        let mut offset = 0;
+        for chunk_size in message_sizes.iter() {
+            if offset + *chunk_size > flattened_messages.len() {
+                return false;
+            }
+            messages.push(&flattened_messages[offset..offset + *chunk_size]);
+            offset += *chunk_size
+        }
+
+        let signatures: Vec<G2Affine> = try_ffi!(
+            flattened_signatures
+                .par_chunks(SIGNATURE_BYTES)
+                .map(|item| {
+                    let mut raw = [0u8; SIGNATURE_BYTES];
+                    raw.copy_from_slice(item);
+                    Option::from(G2Affine::from_compressed(&raw)).ok_or(Error::CurveDecode)
+                })
+                .collect::<Result<_, Error>>(),
+            false
+        );
+
+        let public_keys: Vec<G1Affine> = try_ffi!(
+            flattened_public_keys
+                .par_chunks(PUBLIC_KEY_BYTES)
+                .map(|item| {
+                    let mut raw = [0u8; PUBLIC_KEY_BYTES];
+                    raw.copy_from_slice(item);
+                    Option::from(G1Affine::from_compressed(&raw)).ok_or(Error::CurveDecode)
+                })
+                .collect::<Result<_, Error>>(),
+            false
+        );
+
+        if public_keys.iter().any(|pk| bool::from(pk.is_identity())) {
+            return false;
+        }
 
-    repr_c::Box::new(raw_private_key)
+        let scalars: Vec<Scalar> = (0..count)
+            .map(|_| Scalar::from(OsRng.next_u64() | 1))
+            .collect();
+
+        let weighted_signature = signatures
+            .iter()
+            .zip(scalars.iter())
+            .fold(G2Projective::identity(), |acc, (sig, r)| {
+                acc + G2Projective::from(sig) * r
+            });
+
+        let weighted_public_keys: Vec<G1Affine> = public_keys
+            .par_iter()
+            .zip(scalars.par_iter())
+            .map(|(pk, r)| (G1Projective::from(pk) * r).to_affine())
+            .collect();
+
+        let hashes: Vec<G2Prepared> = messages
+            .par_iter()
+            .map(|message| G2Prepared::from(hash_sig(message).to_affine()))
+            .collect();
+
+        // e(-g1, sum(r_i * sig_i)) * prod(e(r_i * pk_i, H(m_i))) == 1
+        let neg_generator = -G1Affine::generator();
+        let signature_prepared = G2Prepared::from(weighted_signature.to_affine());
+
+        let mut terms: Vec<(&G1Affine, &G2Prepared)> = Vec::with_capacity(count + 1);
+        terms.push((&neg_generator, &signature_prepared));
+        terms.extend(weighted_public_keys.iter().zip(hashes.iter()));
+
+        bool::from(
+            Bls12::multi_miller_loop(&terms)
+                .final_exponentiation()
+                .is_identity(),
+        )
+    })
+}
+
+/// Check a compressed public key, as decoded by `hash_verify`.
+///
+/// # Arguments
+///
+/// * `raw_public_key` - public key byte array (PUBLIC_KEY_BYTES long)
+#[ffi_export]
+pub fn public_key_status(raw_public_key: c_slice::Ref<u8>) -> BLSPointStatus {
+    catch_panic_value("public_key_status", BLSPointStatus::Malformed, || {
+        let raw: [u8; PUBLIC_KEY_BYTES] = match raw_public_key[..].try_into() {
+            Ok(raw) => raw,
+            Err(_) => return BLSPointStatus::Malformed,
+        };
+        match Option::<G1Affine>::from(G1Affine::from_compressed(&raw)) {
+            None => BLSPointStatus::Malformed,
+            Some(point) if bool::from(point.is_identity()) => BLSPointStatus::Identity,
+            Some(_) => BLSPointStatus::Valid,
+        }
+    })
+}
+
+/// Check a compressed signature, as decoded by `hash_verify`.
+///
+/// # Arguments
+///
+/// * `signature` - signature byte array (SIGNATURE_BYTES long)
+#[ffi_export]
+pub fn signature_status(signature: c_slice::Ref<u8>) -> BLSPointStatus {
+    catch_panic_value("signature_status", BLSPointStatus::Malformed, || {
+        let raw: [u8; SIGNATURE_BYTES] = match signature[..].try_into() {
+            Ok(raw) => raw,
+            Err(_) => return BLSPointStatus::Malformed,
+        };
+        match Option::<G2Affine>::from(G2Affine::from_compressed(&raw)) {
+            None => BLSPointStatus::Malformed,
+            Some(point) if bool::from(point.is_identity()) => BLSPointStatus::Identity,
+            Some(_) => BLSPointStatus::Valid,
+        }
+    })
+}
+
+/// Generate a new private key
+#[ffi_export]
+pub fn private_key_generate() -> Option<repr_c::Box<BLSPrivateKey>> {
+    catch_panic_value("private_key_generate", None, || {
+        let mut raw_private_key: [u8; PRIVATE_KEY_BYTES] = [0; PRIVATE_KEY_BYTES];
+        PrivateKey::generate(&mut OsRng)
+            .write_bytes(&mut raw_private_key.as_mut())
+            .expect("preallocated");
+
+        Some(repr_c::Box::new(raw_private_key))
+    })
 }
 
 /// Generate a new private key with seed
@@ -206,15 +432,17 @@ pub fn private_key_generate() -> repr_c::Box<BLSPrivateKey> {
 ///
 /// * `raw_seed` - a seed byte array with 32 bytes
 #[ffi_export]
-pub fn private_key_generate_with_seed(raw_seed: &[u8; 32]) -> repr_c::Box<BLSPrivateKey> {
-    let rng = &mut ChaChaRng::from_seed(*raw_seed);
+pub fn private_key_generate_with_seed(raw_seed: &[u8; 32]) -> Option<repr_c::Box<BLSPrivateKey>> {
+    catch_panic_value("private_key_generate_with_seed", None, || {
+        let rng = &mut ChaChaRng::from_seed(*raw_seed);
 
-    let mut raw_private_key: [u8; PRIVATE_KEY_BYTES] = [0; PRIVATE_KEY_BYTES];
-    PrivateKey::generate(rng)
-        .write_bytes(&mut raw_private_key.as_mut())
-        .expect("preallocated");
+        let mut raw_private_key: [u8; PRIVATE_KEY_BYTES] = [0; PRIVATE_KEY_BYTES];
+        PrivateKey::generate(rng)
+            .write_bytes(&mut raw_private_key.as_mut())
+            .expect("preallocated");
 
-    repr_c::Box::new(raw_private_key)
+        Some(repr_c::Box::new(raw_private_key))
+    })
 }
 
 /// Sign a message with a private key and return the signature
@@ -230,14 +458,16 @@ pub fn private_key_sign(
     raw_private_key: c_slice::Ref<u8>,
     message: c_slice::Ref<u8>,
 ) -> Option<repr_c::Box<BLSSignature>> {
-    let private_key = try_ffi!(PrivateKey::from_bytes(&raw_private_key), None);
+    catch_panic_value("private_key_sign", None, || {
+        let private_key = try_ffi!(PrivateKey::from_bytes(&raw_private_key), None);
 
-    let mut raw_signature: [u8; SIGNATURE_BYTES] = [0; SIGNATURE_BYTES];
-    PrivateKey::sign(&private_key, &message[..])
-        .write_bytes(&mut raw_signature.as_mut())
-        .expect("preallocated");
+        let mut raw_signature: [u8; SIGNATURE_BYTES] = [0; SIGNATURE_BYTES];
+        PrivateKey::sign(&private_key, &message[..])
+            .write_bytes(&mut raw_signature.as_mut())
+            .expect("preallocated");
 
-    Some(repr_c::Box::new(raw_signature))
+        Some(repr_c::Box::new(raw_signature))
+    })
 }
 
 /// Generate the public key for a private key
@@ -251,30 +481,34 @@ pub fn private_key_sign(
 pub fn private_key_public_key(
     raw_private_key: c_slice::Ref<u8>,
 ) -> Option<repr_c::Box<BLSPublicKey>> {
-    let private_key = try_ffi!(PrivateKey::from_bytes(&raw_private_key), None);
+    catch_panic_value("private_key_public_key", None, || {
+        let private_key = try_ffi!(PrivateKey::from_bytes(&raw_private_key), None);
 
-    let mut raw_public_key: [u8; PUBLIC_KEY_BYTES] = [0; PUBLIC_KEY_BYTES];
-    private_key
-        .public_key()
-        .write_bytes(&mut raw_public_key.as_mut())
-        .expect("preallocated");
+        let mut raw_public_key: [u8; PUBLIC_KEY_BYTES] = [0; PUBLIC_KEY_BYTES];
+        private_key
+            .public_key()
+            .write_bytes(&mut raw_public_key.as_mut())
+            .expect("preallocated");
 
-    Some(repr_c::Box::new(raw_public_key))
+        Some(repr_c::Box::new(raw_public_key))
+    })
 }
 
 /// Returns a zero signature, used as placeholder in Filecoin.
 ///
 /// The return value is a pointer to a compressed signature in bytes, of length `SIGNATURE_BYTES`
 #[ffi_export]
-pub fn create_zero_signature() -> repr_c::Box<BLSSignature> {
-    let sig: Signature = G2Affine::identity().into();
+pub fn create_zero_signature() -> Option<repr_c::Box<BLSSignature>> {
+    catch_panic_value("create_zero_signature", None, || {
+        let sig: Signature = G2Affine::identity().into();
 
-    let mut raw_signature: [u8; SIGNATURE_BYTES] = [0; SIGNATURE_BYTES];
+        let mut raw_signature: [u8; SIGNATURE_BYTES] = [0; SIGNATURE_BYTES];
 
-    sig.write_bytes(&mut raw_signature.as_mut())
-        .expect("preallocated");
+        sig.write_bytes(&mut raw_signature.as_mut())
+            .expect("preallocated");
 
-    repr_c::Box::new(raw_signature)
+        Some(repr_c::Box::new(raw_signature))
+    })
 }
 
 #[cfg(test)]
@@ -283,10 +517,10 @@ mod tests {
 
     #[test]
     fn key_verification() {
-        let private_key = private_key_generate();
+        let private_key = private_key_generate().unwrap();
         let public_key = private_key_public_key(private_key[..].into()).unwrap();
         let message = b"hello world";
-        let digest = hash(message[..].into());
+        let digest = hash(message[..].into()).unwrap();
         let signature = private_key_sign(private_key[..].into(), message[..].into()).unwrap();
         let verified = verify(
             signature[..].into(),
@@ -309,7 +543,7 @@ mod tests {
         assert!(verified);
 
         let different_message = b"bye world";
-        let different_digest = hash(different_message[..].into());
+        let different_digest = hash(different_message[..].into()).unwrap();
         let not_verified = verify(
             signature[..].into(),
             different_digest[..].into(),
@@ -329,10 +563,122 @@ mod tests {
         assert!(!not_verified);
     }
 
+    #[test]
+    fn batch_verification() {
+        let messages: Vec<&[u8]> = vec![b"hello world", b"bye world", b"hello world"];
+
+        let mut flattened_signatures = Vec::new();
+        let mut flattened_public_keys = Vec::new();
+        for message in &messages {
+            let private_key = private_key_generate().unwrap();
+            let public_key = private_key_public_key(private_key[..].into()).unwrap();
+            let signature = private_key_sign(private_key[..].into(), message[..].into()).unwrap();
+
+            flattened_signatures.extend_from_slice(&signature[..]);
+            flattened_public_keys.extend_from_slice(&public_key[..]);
+        }
+
+        let message_sizes: Vec<usize> = messages.iter().map(|m| m.len()).collect();
+        let flattened_messages = messages.concat();
+
+        assert!(batch_verify(
+            flattened_signatures[..].into(),
+            flattened_messages[..].into(),
+            message_sizes[..].into(),
+            flattened_public_keys[..].into(),
+        ));
+
+        // swapping two signatures keeps their sum, but must fail
+        let mut swapped = flattened_signatures.clone();
+        swapped[..SIGNATURE_BYTES]
+            .copy_from_slice(&flattened_signatures[SIGNATURE_BYTES..2 * SIGNATURE_BYTES]);
+        swapped[SIGNATURE_BYTES..2 * SIGNATURE_BYTES]
+            .copy_from_slice(&flattened_signatures[..SIGNATURE_BYTES]);
+
+        assert!(!batch_verify(
+            swapped[..].into(),
+            flattened_messages[..].into(),
+            message_sizes[..].into(),
+            flattened_public_keys[..].into(),
+        ));
+    }
+
+    #[test]
+    fn public_key_aggregation() {
+        let message = b"hello world";
+        let digest = hash(message[..].into()).unwrap();
+
+        let mut flattened_signatures = Vec::new();
+        let mut flattened_public_keys = Vec::new();
+        for _ in 0..3 {
+            let private_key = private_key_generate().unwrap();
+            let public_key = private_key_public_key(private_key[..].into()).unwrap();
+            let signature = private_key_sign(private_key[..].into(), message[..].into()).unwrap();
+
+            flattened_signatures.extend_from_slice(&signature[..]);
+            flattened_public_keys.extend_from_slice(&public_key[..]);
+        }
+
+        let signature = aggregate(flattened_signatures[..].into()).unwrap();
+        let public_key = aggregate_public_keys(flattened_public_keys[..].into()).unwrap();
+        assert!(verify(
+            signature[..].into(),
+            digest[..].into(),
+            public_key[..].into(),
+        ));
+
+        let public_key =
+            aggregate_public_keys(flattened_public_keys[PUBLIC_KEY_BYTES..].into()).unwrap();
+        assert!(!verify(
+            signature[..].into(),
+            digest[..].into(),
+            public_key[..].into(),
+        ));
+
+        assert!(aggregate_public_keys(flattened_public_keys[1..].into()).is_none());
+        assert!(aggregate_public_keys([0u8; PUBLIC_KEY_BYTES][..].into()).is_none());
+    }
+
+    #[test]
+    fn point_status() {
+        let private_key = private_key_generate().unwrap();
+        let public_key = private_key_public_key(private_key[..].into()).unwrap();
+        let signature =
+            private_key_sign(private_key[..].into(), b"hello world"[..].into()).unwrap();
+
+        assert_eq!(
+            BLSPointStatus::Valid,
+            public_key_status(public_key[..].into())
+        );
+        assert_eq!(
+            BLSPointStatus::Valid,
+            signature_status(signature[..].into())
+        );
+
+        let zero = create_zero_signature().unwrap();
+        assert_eq!(BLSPointStatus::Identity, signature_status(zero[..].into()));
+        let mut identity = [0u8; PUBLIC_KEY_BYTES];
+        identity[0] = 0xc0;
+        assert_eq!(
+            BLSPointStatus::Identity,
+            public_key_status(identity[..].into())
+        );
+
+        // wrong length, and missing compression flag
+        assert_eq!(
+            BLSPointStatus::Malformed,
+            public_key_status(public_key[1..].into())
+        );
+        assert_eq!(
+            BLSPointStatus::Malformed,
+            signature_status([0u8; SIGNATURE_BYTES][..].into())
+        );
+    }
+
     #[test]
     fn private_key_with_seed() {
         let seed = [5u8; 32];
-        let private_key = private_key_generate_with_seed(&seed);
+        let private_key = private_key_generate_with_seed(&seed).unwrap();
         assert_eq!(
             &[
                 56, 13, 181, 159, 37, 1, 12, 96, 45, 77, 254, 118, 103, 235, 218, 176, 220, 241,
@@ -344,7 +690,7 @@ mod tests {
 
     #[test]
     fn test_zero_key() {
-        let resp = create_zero_signature();
+        let resp = create_zero_signature().unwrap();
         let sig = Signature::from_bytes(&(*resp)).unwrap();
 
         assert_eq!(sig, Signature::from(G2Affine::identity()));
@@ -1,3 +1,4 @@
+#![feature(backtrace)]
 #![deny(clippy::all)]
 #![allow(clippy::missing_safety_doc)]
 #![allow(clippy::upper_case_acronyms)]
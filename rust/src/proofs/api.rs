@@ -13,7 +13,8 @@ use super::helpers::{to_private_replica_info_map, to_public_replica_info_map};
 use super::types::*;
 use crate::destructor;
 use crate::util::types::{
-    as_path_buf, catch_panic_response, catch_panic_response_raw, FCPResponseStatus,
+    as_path_buf, catch_panic_response, catch_panic_response_raw, catch_panic_response_raw_no_log,
+    FCPResponseStatus,
 };
 
 #[ffi_export]
@@ -378,6 +379,49 @@ fn verify_seal(
     })
 }
 
+/// Verifies seal proofs of the same registered proof in parallel, the proof
+/// `i` being that of `inputs[i]` sealed by `prover_ids[i]`. Returns one byte
+/// per proof, 1 when it is valid. Fails if verifying any of the proofs fails.
+#[ffi_export]
+fn verify_seals(
+    registered_proof: RegisteredSealProof,
+    inputs: c_slice::Ref<AggregationInputs>,
+    prover_ids: c_slice::Ref<[u8; 32]>,
+    proofs: c_slice::Ref<c_slice::Box<u8>>,
+) -> repr_c::Box<VerifyBatchResponse> {
+    catch_panic_response("verify_seals", || {
+        if prover_ids.len() != inputs.len() || proofs.len() != inputs.len() {
+            return Err(anyhow::anyhow!(
+                "{} seal inputs, {} prover ids and {} proofs",
+                inputs.len(),
+                prover_ids.len(),
+                proofs.len()
+            ));
+        }
+
+        let valid: Vec<u8> = inputs
+            .par_iter()
+            .zip(prover_ids.par_iter())
+            .zip(proofs.par_iter())
+            .map(|((input, prover_id), proof)| {
+                let valid = seal::verify_seal(
+                    registered_proof.into(),
+                    input.comm_r,
+                    input.comm_d,
+                    *prover_id,
+                    SectorId::from(input.sector_id),
+                    input.ticket,
+                    input.seed,
+                    proof,
+                )?;
+                Ok(valid as u8)
+            })
+            .collect::<anyhow::Result<_>>()?;
+
+        Ok(valid.into_boxed_slice().into())
+    })
+}
+
 /// TODO: document
 #[ffi_export]
 fn generate_winning_post_sector_challenge(
@@ -682,6 +726,77 @@ fn verify_window_post(
     })
 }
 
+/// Verifies window PoSts in parallel. The PoSt `i` is over the next
+/// `replica_counts[i]` replicas of `replicas` and made of the next
+/// `proof_counts[i]` proofs of `proofs`, with `randomness[i]` and
+/// `prover_ids[i]`. Returns one byte per PoSt, 1 when it is valid. Fails if
+/// verifying any of the PoSts fails.
+#[ffi_export]
+fn verify_window_posts(
+    randomness: c_slice::Ref<[u8; 32]>,
+    prover_ids: c_slice::Ref<[u8; 32]>,
+    replicas: c_slice::Ref<PublicReplicaInfo>,
+    replica_counts: c_slice::Ref<libc::size_t>,
+    proofs: c_slice::Ref<PoStProof>,
+    proof_counts: c_slice::Ref<libc::size_t>,
+) -> repr_c::Box<VerifyBatchResponse> {
+    catch_panic_response("verify_window_posts", || {
+        let count = randomness.len();
+        if prover_ids.len() != count || replica_counts.len() != count || proof_counts.len() != count
+        {
+            return Err(anyhow::anyhow!(
+                "{} randomness, {} prover ids, {} replica counts and {} proof counts",
+                count,
+                prover_ids.len(),
+                replica_counts.len(),
+                proof_counts.len()
+            ));
+        }
+
+        // split the flattened replicas and proofs
+        let mut posts = Vec::with_capacity(count);
+        let (mut replica_offset, mut proof_offset) = (0, 0);
+        for (replica_count, proof_count) in replica_counts.iter().zip(proof_counts.iter()) {
+            if replica_offset + replica_count > replicas.len()
+                || proof_offset + proof_count > proofs.len()
+            {
+                return Err(anyhow::anyhow!("counts exceed the replicas or proofs"));
+            }
+            posts.push((
+                &replicas[replica_offset..replica_offset + replica_count],
+                &proofs[proof_offset..proof_offset + proof_count],
+            ));
+            replica_offset += replica_count;
+            proof_offset += proof_count;
+        }
+
+        let valid: Vec<u8> = posts
+            .par_iter()
+            .zip(randomness.par_iter())
+            .zip(prover_ids.par_iter())
+            .map(|(((replicas, proofs), randomness), prover_id)| {
+                let replicas = to_public_replica_info_map((*replicas).into());
+                let proofs: Vec<(api::RegisteredPoStProof, &[u8])> = proofs
+                    .iter()
+                    .map(|x| {
+                        (
+                            api::RegisteredPoStProof::from(x.registered_proof),
+                            &x.proof[..],
+                        )
+                    })
+                    .collect();
+
+                let valid = filecoin_proofs_api::post::verify_window_post(
+                    randomness, &proofs, &replicas, *prover_id,
+                )?;
+                Ok(valid as u8)
+            })
+            .collect::<anyhow::Result<_>>()?;
+
+        Ok(valid.into_boxed_slice().into())
+    })
+}
+
 /// TODO: document
 #[ffi_export]
 fn merge_window_post_partition_proofs(
@@ -721,6 +836,22 @@ fn get_num_partition_for_fallback_post(
     })
 }
 
+/// Returns the number of partitions of the proofs of an empty sector update,
+/// that is of the partition proofs it is made of.
+#[ffi_export]
+fn get_num_partition_for_update(
+    registered_proof: RegisteredUpdateProof,
+) -> repr_c::Box<GetNumPartitionForUpdateResponse> {
+    catch_panic_response("get_num_partition_for_update", || {
+        let sector_size: u64 = api::RegisteredUpdateProof::from(registered_proof)
+            .sector_size()
+            .into();
+        // the sector nodes are 32 bytes
+        let result = storage_proofs_update::constants::partition_count(sector_size as usize / 32);
+        Ok(result)
+    })
+}
+
 /// TODO: document
 #[ffi_export]
 fn generate_single_window_post_with_vanilla(
@@ -1182,22 +1313,22 @@ fn registered_seal_proof_accessor(
     registered_proof: RegisteredSealProof,
     op: fn(api::RegisteredSealProof) -> anyhow::Result<String>,
 ) -> repr_c::Box<StringResponse> {
-    let rsp: api::RegisteredSealProof = registered_proof.into();
+    catch_panic_response_raw_no_log(|| {
+        let rsp: api::RegisteredSealProof = registered_proof.into();
 
-    repr_c::Box::new(StringResponse::from(
-        op(rsp).map(|v| v.into_bytes().into_boxed_slice().into()),
-    ))
+        StringResponse::from(op(rsp).map(|v| v.into_bytes().into_boxed_slice().into()))
+    })
 }
 
 fn registered_post_proof_accessor(
     registered_proof: RegisteredPoStProof,
     op: fn(api::RegisteredPoStProof) -> anyhow::Result<String>,
 ) -> repr_c::Box<StringResponse> {
-    let rsp: api::RegisteredPoStProof = registered_proof.into();
+    catch_panic_response_raw_no_log(|| {
+        let rsp: api::RegisteredPoStProof = registered_proof.into();
 
-    repr_c::Box::new(StringResponse::from(
-        op(rsp).map(|v| v.into_bytes().into_boxed_slice().into()),
-    ))
+        StringResponse::from(op(rsp).map(|v| v.into_bytes().into_boxed_slice().into()))
+    })
 }
 
 destructor!(
@@ -1249,6 +1380,7 @@ destructor!(
     destroy_verify_window_post_response,
     VerifyWindowPoStResponse
 );
+destructor!(destroy_verify_batch_response, VerifyBatchResponse);
 destructor!(
     destroy_generate_fallback_sector_challenges_response,
     GenerateFallbackSectorChallengesResponse
@@ -1265,6 +1397,10 @@ destructor!(
     destroy_get_num_partition_for_fallback_post_response,
     GetNumPartitionForFallbackPoStResponse
 );
+destructor!(
+    destroy_get_num_partition_for_update_response,
+    GetNumPartitionForUpdateResponse
+);
 destructor!(
     destroy_merge_window_post_partition_proofs_response,
     MergeWindowPoStPartitionProofsResponse
@@ -357,6 +357,8 @@ pub struct GenerateSingleWindowPoStWithVanilla {
 
 pub type GetNumPartitionForFallbackPoStResponse = Result<libc::size_t>;
 
+pub type GetNumPartitionForUpdateResponse = Result<libc::size_t>;
+
 pub type MergeWindowPoStPartitionProofsResponse = Result<PoStProof>;
 
 pub type WriteWithAlignmentResponse = Result<WriteWithAlignment>;
@@ -429,6 +431,9 @@ pub type VerifyWinningPoStResponse = Result<bool>;
 
 pub type VerifyWindowPoStResponse = Result<bool>;
 
+/// One byte per verified proof, 1 when it is valid and 0 when it is not.
+pub type VerifyBatchResponse = Result<c_slice::Box<u8>>;
+
 pub type FinalizeTicketResponse = Result<[u8; 32]>;
 
 pub type GeneratePieceCommitmentResponse = Result<GeneratePieceCommitment>;
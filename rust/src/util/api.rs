@@ -8,6 +8,7 @@ use safer_ffi::prelude::*;
 use super::types::{
     catch_panic_response, catch_panic_response_no_log, GpuDeviceResponse, InitLogFdResponse,
 };
+use crate::proofs::types::StringResponse;
 
 /// Protects the init off the logger.
 static LOG_INIT: Once = Once::new();
@@ -46,6 +47,65 @@ pub fn get_gpu_devices() -> repr_c::Box<GpuDeviceResponse> {
     })
 }
 
+/// Returns the names of the GPU frameworks this library was built with, `cuda`
+/// and/or `opencl`. When both are, `EC_GPU_FRAMEWORK` selects the one used.
+#[ffi_export]
+pub fn get_gpu_frameworks() -> repr_c::Box<GpuDeviceResponse> {
+    catch_panic_response("get_gpu_frameworks", || {
+        let mut frameworks: Vec<c_slice::Box<u8>> = Vec::new();
+        if cfg!(feature = "cuda") {
+            frameworks.push(b"cuda".to_vec().into_boxed_slice().into());
+        }
+        if cfg!(feature = "opencl") {
+            frameworks.push(b"opencl".to_vec().into_boxed_slice().into());
+        }
+
+        Ok(frameworks.into_boxed_slice().into())
+    })
+}
+
+/// The cargo features reported by `get_build_info`, and whether this library
+/// was built with them.
+const FEATURES: [(&str, bool); 5] = [
+    ("cuda", cfg!(feature = "cuda")),
+    ("opencl", cfg!(feature = "opencl")),
+    ("multicore-sdr", cfg!(feature = "multicore-sdr")),
+    ("blst-portable", cfg!(feature = "blst-portable")),
+    ("inject-panic", cfg!(feature = "inject-panic")),
+];
+
+/// Returns the build information of this library as a JSON object: its
+/// `version`, the `git_revision` it was built from, empty when unknown, and the
+/// cargo `features` it was built with.
+#[ffi_export]
+pub fn get_build_info() -> repr_c::Box<StringResponse> {
+    catch_panic_response("get_build_info", || {
+        let features: Vec<&str> = FEATURES
+            .iter()
+            .filter(|(_, enabled)| *enabled)
+            .map(|(name, _)| *name)
+            .collect();
+
+        let info = serde_json::json!({
+            "version": env!("CARGO_PKG_VERSION"),
+            "git_revision": option_env!("FFI_GIT_REV").unwrap_or(""),
+            "features": features,
+        });
+
+        Ok(info.to_string().into_bytes().into_boxed_slice().into())
+    })
+}
+
+/// Builds the global thread pool, if not built yet, and returns its number of
+/// threads.
+///
+/// Its threads inherit the CPU and memory bindings of the calling thread, so
+/// it must be built before binding a thread to run a call.
+#[ffi_export]
+pub fn init_thread_pool() -> usize {
+    rayon::current_num_threads()
+}
+
 /// Initializes the logger with a file descriptor where logs will be logged into.
 ///
 /// This is usually a pipe that was opened on the receiving side of the logs. The logger is
@@ -68,7 +128,7 @@ pub fn init_log_fd(log_fd: libc::c_int) -> repr_c::Box<InitLogFdResponse> {
 #[cfg(test)]
 mod tests {
 
-    use crate::util::api::get_gpu_devices;
+    use crate::util::api::{get_build_info, get_gpu_devices, get_gpu_frameworks, FEATURES};
     use crate::util::types::destroy_gpu_device_response;
 
     #[test]
@@ -89,6 +149,33 @@ mod tests {
         destroy_gpu_device_response(resp);
     }
 
+    #[test]
+    fn test_get_gpu_frameworks() {
+        let resp = get_gpu_frameworks();
+        assert!(resp.error_msg.is_empty());
+        assert_eq!(
+            resp.value.len(),
+            cfg!(feature = "cuda") as usize + cfg!(feature = "opencl") as usize
+        );
+
+        destroy_gpu_device_response(resp);
+    }
+
+    #[test]
+    fn test_get_build_info() {
+        let resp = get_build_info();
+        assert!(resp.error_msg.is_empty());
+
+        let info: serde_json::Value = serde_json::from_slice(&resp.value).unwrap();
+        assert_eq!(info["version"], env!("CARGO_PKG_VERSION"));
+        assert_eq!(
+            info["features"].as_array().unwrap().len(),
+            FEATURES.iter().filter(|(_, enabled)| *enabled).count()
+        );
+
+        drop(resp);
+    }
+
     #[test]
     #[ignore]
     #[cfg(target_os = "linux")]
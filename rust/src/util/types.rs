@@ -1,4 +1,7 @@
-use std::{fmt::Display, mem::MaybeUninit, ops::Deref, panic, path::PathBuf, str::Utf8Error};
+use std::{
+    any::Any, backtrace::Backtrace, cell::RefCell, fmt::Display, mem::MaybeUninit, ops::Deref,
+    panic, path::PathBuf, str::Utf8Error, sync::Once,
+};
 
 use safer_ffi::prelude::*;
 
@@ -149,6 +152,89 @@ pub fn destroy_init_log_fd_response(ptr: repr_c::Box<InitLogFdResponse>) {
     drop(ptr)
 }
 
+/// Environment variable naming the bindings to panic in, comma separated, or
+/// `*` for all of them. Only meant to test the panic handling of callers, and
+/// only read by builds with the `inject-panic` feature.
+#[cfg(feature = "inject-panic")]
+const INJECT_PANIC_ENV: &str = "FFI_INJECT_PANIC";
+
+/// Marks the backtraces of panics in the error messages.
+const BACKTRACE_MARKER: &str = "\nstack backtrace:\n";
+
+static PANIC_HOOK_INIT: Once = Once::new();
+
+thread_local! {
+    /// The backtrace of the last panic of the thread, captured by the panic
+    /// hook as it is lost once unwound.
+    static PANIC_BACKTRACE: RefCell<Option<String>> = RefCell::new(None);
+}
+
+/// Installs a panic hook recording the backtrace of panics, in addition to
+/// running the previous hook.
+fn init_panic_hook() {
+    PANIC_HOOK_INIT.call_once(|| {
+        let previous = panic::take_hook();
+        panic::set_hook(Box::new(move |info| {
+            let backtrace = Backtrace::force_capture().to_string();
+            PANIC_BACKTRACE.with(|b| *b.borrow_mut() = Some(backtrace));
+            previous(info);
+        }));
+    });
+}
+
+/// Returns the error message of a caught panic: its payload followed by the
+/// backtrace, if any.
+fn panic_error_msg(panic: Box<dyn Any + Send>) -> String {
+    let message = if let Some(message) = panic.downcast_ref::<&'static str>() {
+        message.to_string()
+    } else if let Some(message) = panic.downcast_ref::<String>() {
+        message.clone()
+    } else {
+        "no unwind information".to_string()
+    };
+
+    match PANIC_BACKTRACE.with(|b| b.borrow_mut().take()) {
+        Some(backtrace) => format!("Rust panic: {}{}{}", message, BACKTRACE_MARKER, backtrace),
+        None => format!("Rust panic: {}", message),
+    }
+}
+
+/// Panics if the binding was selected with `FFI_INJECT_PANIC`.
+#[cfg(feature = "inject-panic")]
+fn maybe_inject_panic(name: &str) {
+    if let Ok(names) = std::env::var(INJECT_PANIC_ENV) {
+        if names.split(',').any(|n| n == "*" || n == name) {
+            panic!("injected panic in {}", name);
+        }
+    }
+}
+
+#[cfg(not(feature = "inject-panic"))]
+#[inline(always)]
+fn maybe_inject_panic(_name: &str) {}
+
+/// Catch panics of the bindings returning plain values rather than responses,
+/// which have no error to report them in: the panic is logged and `default`
+/// returned instead.
+pub fn catch_panic_value<F, T>(name: &str, default: T, callback: F) -> T
+where
+    F: FnOnce() -> T + std::panic::UnwindSafe,
+{
+    init_panic_hook();
+
+    match panic::catch_unwind(|| {
+        maybe_inject_panic(name);
+        callback()
+    }) {
+        Ok(t) => t,
+        Err(panic) => {
+            init_log();
+            log::error!("{}: {}", name, panic_error_msg(panic));
+            default
+        }
+    }
+}
+
 /// Catch panics and return an error response
 pub fn catch_panic_response<F, T>(name: &str, callback: F) -> repr_c::Box<Result<T>>
 where
@@ -173,16 +259,11 @@ where
     T: Sized + Default,
     F: FnOnce() -> Result<T> + std::panic::UnwindSafe,
 {
+    init_panic_hook();
+
     let result = match panic::catch_unwind(callback) {
         Ok(t) => t,
-        Err(panic) => {
-            let error_msg = match panic.downcast_ref::<&'static str>() {
-                Some(message) => message,
-                _ => "no unwind information",
-            };
-
-            Result::from(Err(format!("Rust panic: {}", error_msg)))
-        }
+        Err(panic) => Result::from(Err(panic_error_msg(panic))),
     };
 
     repr_c::Box::new(result)
@@ -196,6 +277,7 @@ where
     catch_panic_response_raw_no_log(|| {
         init_log();
         log::info!("{}: start", name);
+        maybe_inject_panic(name);
         let res = callback();
         log::info!("{}: end", name);
         res
@@ -210,9 +292,12 @@ where
     T: Sized,
     F: FnOnce() -> anyhow::Result<T> + std::panic::UnwindSafe,
 {
+    init_panic_hook();
+
     let result = match panic::catch_unwind(|| {
         init_log();
         log::info!("{}: start", name);
+        maybe_inject_panic(name);
         let res = callback();
         log::info!("{}: end", name);
         res
@@ -222,16 +307,7 @@ where
             Err(err) => Result::err_no_default(err.to_string().into_bytes().into_boxed_slice()),
         },
         Err(panic) => {
-            let error_msg = match panic.downcast_ref::<&'static str>() {
-                Some(message) => message,
-                _ => "no unwind information",
-            };
-
-            Result::err_no_default(
-                format!("Rust panic: {}", error_msg)
-                    .into_bytes()
-                    .into_boxed_slice(),
-            )
+            Result::err_no_default(panic_error_msg(panic).into_bytes().into_boxed_slice())
         }
     };
 
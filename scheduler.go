@@ -0,0 +1,358 @@
+package ffi
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// OpClass is a class of GPU heavy calls, scheduled by a Scheduler.
+type OpClass int
+
+const (
+	// OpSealPreCommit2 is SealPreCommitPhase2, building the sector trees.
+	OpSealPreCommit2 OpClass = iota
+	// OpSealCommit2 is SealCommitPhase2, proving a seal.
+	OpSealCommit2
+	// OpWinningPoSt is the generation of winning PoSts.
+	OpWinningPoSt
+	// OpWindowPoSt is the generation of window PoSts and of their single
+	// partitions.
+	OpWindowPoSt
+	// OpSectorUpdate is EncodeInto and the generation of update proofs.
+	OpSectorUpdate
+
+	numOpClasses
+)
+
+var opClassNames = [numOpClasses]string{
+	OpSealPreCommit2: "SealPreCommit2",
+	OpSealCommit2:    "SealCommit2",
+	OpWinningPoSt:    "WinningPoSt",
+	OpWindowPoSt:     "WindowPoSt",
+	OpSectorUpdate:   "SectorUpdate",
+}
+
+func (c OpClass) String() string {
+	if c < 0 || c >= numOpClasses {
+		return "OpClass(unknown)"
+	}
+	return opClassNames[c]
+}
+
+// MarshalText encodes c as its name, e.g. "SealCommit2", so that the
+// MaxConcurrent limits of a SchedulerConfig read well in JSON.
+func (c OpClass) MarshalText() ([]byte, error) {
+	if c < 0 || c >= numOpClasses {
+		return nil, xerrors.Errorf("unknown op class %d", int(c))
+	}
+	return []byte(opClassNames[c]), nil
+}
+
+// UnmarshalText decodes the name of a class.
+func (c *OpClass) UnmarshalText(text []byte) error {
+	for class, name := range opClassNames {
+		if name == string(text) {
+			*c = OpClass(class)
+			return nil
+		}
+	}
+	return xerrors.Errorf("unknown op class %q", text)
+}
+
+// Priority orders the calls waiting for a Scheduler: higher priority calls are
+// admitted first, and calls of equal priority in arrival order.
+type Priority int
+
+const (
+	// PriorityBackground is for calls which can wait, such as the proving of
+	// sectors with plenty of time left.
+	PriorityBackground Priority = -1
+	// PriorityNormal is the priority of the sealing and update calls.
+	PriorityNormal Priority = 0
+	// PriorityHigh is the priority of window PoSts.
+	PriorityHigh Priority = 1
+	// PriorityCritical is the priority of winning PoSts, and is meant for
+	// window PoSts close to their deadline.
+	PriorityCritical Priority = 2
+)
+
+// defaultPriority returns the priority of the calls of a class not tagged by
+// WithPriority.
+func (c OpClass) defaultPriority() Priority {
+	switch c {
+	case OpWinningPoSt:
+		return PriorityCritical
+	case OpWindowPoSt:
+		return PriorityHigh
+	default:
+		return PriorityNormal
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context tagging the calls made with it, through the
+// Ctx variants, with priority p instead of the default priority of their
+// class.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority of the calls of class made with ctx: the
+// one set with WithPriority, or the default priority of the class.
+func PriorityFrom(ctx context.Context, class OpClass) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return class.defaultPriority()
+}
+
+// SchedulerConfig configures a Scheduler.
+type SchedulerConfig struct {
+	// MaxConcurrent is the maximum number of calls of each class running at
+	// once. Classes missing or set to zero are not limited.
+	MaxConcurrent map[OpClass]int
+
+	// GPUSlots is the number of calls each GPU runs at once, by GPU index.
+	// Every call holds a slot of the least busy GPU while it runs. Empty
+	// means the GPUs are not limited.
+	//
+	// The native library picks the GPU a call runs on by itself: the slots
+	// bound the number of calls all the GPUs run, so as not to exhaust their
+	// memory, assuming the library spreads them over its GPUs.
+	GPUSlots []int
+}
+
+// Scheduler bounds the number of concurrent GPU heavy calls by class and by
+// GPU, so that provers don't run out of GPU memory. Calls waiting for a slot
+// are admitted by priority, each as soon as its own limits allow: a freed GPU
+// slot goes to the highest priority call waiting for one, so that winning
+// PoSts jump the queue of seal commits. Running calls are never preempted.
+type Scheduler struct {
+	lk sync.Mutex
+
+	classLimit   [numOpClasses]int
+	classRunning [numOpClasses]int
+	gpuSlots     []int
+	gpuRunning   []int
+
+	waiting []*schedWaiter
+}
+
+type schedWaiter struct {
+	class    OpClass
+	priority Priority
+	// granted receives the GPU of the waiter once admitted.
+	granted chan int
+}
+
+// NewScheduler returns a Scheduler enforcing cfg.
+func NewScheduler(cfg SchedulerConfig) *Scheduler {
+	s := &Scheduler{
+		gpuSlots:   append([]int(nil), cfg.GPUSlots...),
+		gpuRunning: make([]int, len(cfg.GPUSlots)),
+	}
+	for class, limit := range cfg.MaxConcurrent {
+		if class >= 0 && class < numOpClasses && limit > 0 {
+			s.classLimit[class] = limit
+		}
+	}
+	return s
+}
+
+// Do runs fn once a call of the given class is admitted, with the priority
+// of ctx. It returns the context error when ctx is done first.
+func (s *Scheduler) Do(ctx context.Context, class OpClass, fn func() error) error {
+	release, err := s.acquire(ctx, class)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn()
+}
+
+// acquire waits for a call of the given class to be admitted, and returns
+// the function to call once it's done.
+func (s *Scheduler) acquire(ctx context.Context, class OpClass) (func(), error) {
+	release, _, err := s.acquireGPU(ctx, class)
+	return release, err
+}
+
+// acquireGPU is acquire also returning the GPU of the call, or -1 when the
+// GPUs are not limited.
+func (s *Scheduler) acquireGPU(ctx context.Context, class OpClass) (func(), int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, -1, err
+	}
+
+	w := &schedWaiter{class: class, priority: PriorityFrom(ctx, class), granted: make(chan int, 1)}
+
+	s.lk.Lock()
+	s.enqueue(w)
+	s.dispatch()
+	s.lk.Unlock()
+
+	select {
+	case gpu := <-w.granted:
+		return s.releaser(class, gpu), gpu, nil
+	case <-ctx.Done():
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	select {
+	case gpu := <-w.granted:
+		// admitted while giving up
+		s.stop(class, gpu)
+		s.dispatch()
+	default:
+		s.remove(w)
+	}
+	return nil, -1, ctx.Err()
+}
+
+func (s *Scheduler) releaser(class OpClass, gpu int) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.lk.Lock()
+			defer s.lk.Unlock()
+
+			s.stop(class, gpu)
+			s.dispatch()
+		})
+	}
+}
+
+// runnable reports whether a call of the given class can start.
+func (s *Scheduler) runnable(class OpClass) bool {
+	return s.classAllows(class) && (len(s.gpuSlots) == 0 || s.freeGPU() >= 0)
+}
+
+// classAllows reports whether the limit of a class allows one more call.
+func (s *Scheduler) classAllows(class OpClass) bool {
+	limit := s.classLimit[class]
+	return limit == 0 || s.classRunning[class] < limit
+}
+
+// freeGPU returns the least busy GPU with a free slot, or -1.
+func (s *Scheduler) freeGPU() int {
+	best := -1
+	for i, slots := range s.gpuSlots {
+		if s.gpuRunning[i] >= slots {
+			continue
+		}
+		if best < 0 || s.gpuRunning[i] < s.gpuRunning[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// start accounts for a runnable call, and returns its GPU, or -1 when the
+// GPUs are not limited.
+func (s *Scheduler) start(class OpClass) int {
+	s.classRunning[class]++
+	if len(s.gpuSlots) == 0 {
+		return -1
+	}
+	gpu := s.freeGPU()
+	s.gpuRunning[gpu]++
+	return gpu
+}
+
+func (s *Scheduler) stop(class OpClass, gpu int) {
+	s.classRunning[class]--
+	if gpu >= 0 {
+		s.gpuRunning[gpu]--
+	}
+}
+
+// enqueue inserts w after the waiters of higher or equal priority.
+func (s *Scheduler) enqueue(w *schedWaiter) {
+	i := len(s.waiting)
+	for i > 0 && s.waiting[i-1].priority < w.priority {
+		i--
+	}
+	s.waiting = append(s.waiting, nil)
+	copy(s.waiting[i+1:], s.waiting[i:])
+	s.waiting[i] = w
+}
+
+// dispatch admits the waiting calls that can now run, by priority.
+func (s *Scheduler) dispatch() {
+	for i := 0; i < len(s.waiting); {
+		w := s.waiting[i]
+		if !s.runnable(w.class) {
+			i++
+			continue
+		}
+		w.granted <- s.start(w.class)
+		s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+	}
+}
+
+func (s *Scheduler) remove(w *schedWaiter) {
+	for i, o := range s.waiting {
+		if o == w {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+var (
+	schedulerLk sync.RWMutex
+	scheduler   *Scheduler
+)
+
+// SetScheduler makes the GPU heavy functions of the package wait for s to
+// admit them: SealPreCommitPhase2, SealCommitPhase2, the PoSt generation
+// functions, SectorUpdate.EncodeInto and the update proof generation. Their
+// Ctx variants stop waiting when their context is done, and take the
+// priority of their context. A nil s, the default, runs them right away.
+func SetScheduler(s *Scheduler) {
+	schedulerLk.Lock()
+	scheduler = s
+	schedulerLk.Unlock()
+}
+
+// admit waits for the package scheduler, if any, to admit a call. Calls are
+// refused after Shutdown, including those admitted while it waits.
+func admit(ctx context.Context, class OpClass) (release func(), err error) {
+	if err := checkShutdown(); err != nil {
+		return nil, err
+	}
+
+	schedulerLk.RLock()
+	s := scheduler
+	schedulerLk.RUnlock()
+
+	release, gpu := func() {}, -1
+	if s != nil {
+		if release, gpu, err = s.acquireGPU(ctx, class); err != nil {
+			return nil, err
+		}
+		if err := checkShutdown(); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	if admitted, ok := ctx.Value(admittedKey{}).(func()); ok {
+		admitted()
+	}
+	return meterGPU(ctx, class, gpu, release), nil
+}
+
+type admittedKey struct{}
+
+// withAdmitted returns a context making admit call fn once it admitted a
+// call made with it.
+func withAdmitted(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, admittedKey{}, fn)
+}
@@ -0,0 +1,139 @@
+package ffi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerClassLimit(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{
+		MaxConcurrent: map[OpClass]int{OpSealCommit2: 1},
+	})
+
+	release, err := s.acquire(context.Background(), OpSealCommit2)
+	require.NoError(t, err)
+
+	// another class isn't limited
+	other, err := s.acquire(context.Background(), OpWinningPoSt)
+	require.NoError(t, err)
+	other()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.acquire(ctx, OpSealCommit2)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, s.waiting)
+
+	admitted := make(chan struct{})
+	go func() {
+		assert.NoError(t, s.Do(context.Background(), OpSealCommit2, func() error {
+			close(admitted)
+			return nil
+		}))
+	}()
+
+	select {
+	case <-admitted:
+		t.Fatal("admitted over the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	release()
+	release() // no-op
+	<-admitted
+}
+
+func TestSchedulerGPUSlots(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{GPUSlots: []int{1, 2}})
+
+	var releases []func()
+	for i := 0; i < 3; i++ {
+		release, err := s.acquire(context.Background(), OpWindowPoSt)
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+	assert.Equal(t, []int{1, 2}, s.gpuRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.acquire(ctx, OpSealPreCommit2)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	for _, release := range releases {
+		release()
+	}
+	assert.Equal(t, []int{0, 0}, s.gpuRunning)
+}
+
+func TestSetScheduler(t *testing.T) {
+	release, err := admit(context.Background(), OpSealCommit2)
+	require.NoError(t, err)
+	release()
+
+	SetScheduler(NewScheduler(SchedulerConfig{MaxConcurrent: map[OpClass]int{OpSealCommit2: 1}}))
+	defer SetScheduler(nil)
+
+	release, err = admit(context.Background(), OpSealCommit2)
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = admit(ctx, OpSealCommit2)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSchedulerConfigJSON(t *testing.T) {
+	var cfg SchedulerConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"MaxConcurrent":{"SealCommit2":1,"WindowPoSt":2},"GPUSlots":[2]}`), &cfg))
+	assert.Equal(t, map[OpClass]int{OpSealCommit2: 1, OpWindowPoSt: 2}, cfg.MaxConcurrent)
+	assert.Equal(t, []int{2}, cfg.GPUSlots)
+
+	b, err := json.Marshal(map[OpClass]int{OpSectorUpdate: 1})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"SectorUpdate":1}`, string(b))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"MaxConcurrent":{"SealCommit3":1}}`), &cfg))
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{GPUSlots: []int{1}})
+
+	release, err := s.acquire(context.Background(), OpSealCommit2)
+	require.NoError(t, err)
+
+	order := make(chan OpClass, 3)
+	wait := func(ctx context.Context, class OpClass) {
+		go func() {
+			assert.NoError(t, s.Do(ctx, class, func() error {
+				order <- class
+				return nil
+			}))
+		}()
+		// queued in this order
+		require.Eventually(t, func() bool {
+			s.lk.Lock()
+			defer s.lk.Unlock()
+			for _, w := range s.waiting {
+				if w.class == class {
+					return true
+				}
+			}
+			return false
+		}, time.Second, time.Millisecond)
+	}
+
+	wait(context.Background(), OpSealCommit2)
+	wait(WithPriority(context.Background(), PriorityBackground), OpWindowPoSt)
+	wait(context.Background(), OpWinningPoSt)
+
+	release()
+	assert.Equal(t, OpWinningPoSt, <-order)
+	assert.Equal(t, OpSealCommit2, <-order)
+	assert.Equal(t, OpWindowPoSt, <-order)
+}
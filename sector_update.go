@@ -1,9 +1,11 @@
-//go:build cgo
-// +build cgo
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
 
 package ffi
 
 import (
+	"context"
+
 	"github.com/filecoin-project/filecoin-ffi/cgo"
 	commcid "github.com/filecoin-project/go-fil-commcid"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -61,6 +63,25 @@ func (FunctionsSectorUpdate) EncodeInto(
 	stagedDataPath string,
 	pieces []abi.PieceInfo,
 ) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	return encodeInto(context.Background(), proofType, newReplicaPath, newReplicaCachePath, sectorKeyPath, sectorKeyCachePath, stagedDataPath, pieces)
+}
+
+func encodeInto(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+	stagedDataPath string,
+	pieces []abi.PieceInfo,
+) (sealedCID cid.Cid, unsealedCID cid.Cid, err error) {
+	release, err := admit(ctx, OpSectorUpdate)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	defer release()
+
 	up, err := toFilRegisteredUpdateProof(proofType)
 	if err != nil {
 		return cid.Undef, cid.Undef, err
@@ -219,7 +240,7 @@ func (FunctionsSectorUpdate) VerifyVanillaProofs(
 		return false, xerrors.Errorf("transorming new CommD: %w", err)
 	}
 
-	proofs, cleanup := toUpdateVanillaProofs(vanillaProofs)
+	proofs, cleanup := cgo.PinSlicesBoxedUint8(vanillaProofs)
 	defer cleanup()
 
 	return cgo.VerifyEmptySectorUpdatePartitionProofs(
@@ -238,6 +259,23 @@ func (FunctionsSectorUpdate) GenerateUpdateProofWithVanilla(
 	unsealedCID cid.Cid,
 	vanillaProofs [][]byte,
 ) ([]byte, error) {
+	return generateUpdateProofWithVanilla(context.Background(), proofType, oldSealedCID, newSealedCID, unsealedCID, vanillaProofs)
+}
+
+func generateUpdateProofWithVanilla(
+	ctx context.Context,
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	vanillaProofs [][]byte,
+) ([]byte, error) {
+	release, err := admit(ctx, OpSectorUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	up, err := toFilRegisteredUpdateProof(proofType)
 	if err != nil {
 		return nil, err
@@ -256,7 +294,7 @@ func (FunctionsSectorUpdate) GenerateUpdateProofWithVanilla(
 		return nil, xerrors.Errorf("transorming new CommD: %w", err)
 	}
 
-	proofs, cleanup := toUpdateVanillaProofs(vanillaProofs)
+	proofs, cleanup := cgo.PinSlicesBoxedUint8(vanillaProofs)
 	defer cleanup()
 
 	return cgo.GenerateEmptySectorUpdateProofWithVanilla(
@@ -268,20 +306,21 @@ func (FunctionsSectorUpdate) GenerateUpdateProofWithVanilla(
 	)
 }
 
-func toUpdateVanillaProofs(src [][]byte) ([]cgo.SliceBoxedUint8, func()) {
-	out := make([]cgo.SliceBoxedUint8, len(src))
-	for idx := range out {
-		out[idx] = cgo.AllocSliceBoxedUint8(src[idx])
-	}
-
-	return out, func() {
-		for idx := range out {
-			out[idx].Destroy()
-		}
-	}
+func (FunctionsSectorUpdate) GenerateUpdateProof(
+	proofType abi.RegisteredUpdateProof,
+	oldSealedCID cid.Cid,
+	newSealedCID cid.Cid,
+	unsealedCID cid.Cid,
+	newReplicaPath string,
+	newReplicaCachePath string,
+	sectorKeyPath string,
+	sectorKeyCachePath string,
+) ([]byte, error) {
+	return generateUpdateProof(context.Background(), proofType, oldSealedCID, newSealedCID, unsealedCID, newReplicaPath, newReplicaCachePath, sectorKeyPath, sectorKeyCachePath)
 }
 
-func (FunctionsSectorUpdate) GenerateUpdateProof(
+func generateUpdateProof(
+	ctx context.Context,
 	proofType abi.RegisteredUpdateProof,
 	oldSealedCID cid.Cid,
 	newSealedCID cid.Cid,
@@ -291,6 +330,12 @@ func (FunctionsSectorUpdate) GenerateUpdateProof(
 	sectorKeyPath string,
 	sectorKeyCachePath string,
 ) ([]byte, error) {
+	release, err := admit(ctx, OpSectorUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	up, err := toFilRegisteredUpdateProof(proofType)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,103 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package sectormeta
+
+import (
+	"context"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// RecordingProofs is a ffi.ProofsAPI recording the outputs of the seal calls
+// it forwards in a Store.
+type RecordingProofs struct {
+	ffi.ProofsAPI
+
+	store *Store
+}
+
+var _ ffi.ProofsAPI = (*RecordingProofs)(nil)
+
+// NewRecordingProofs wraps api so that the seal calls record sector metadata
+// in store.
+func NewRecordingProofs(api ffi.ProofsAPI, store *Store) *RecordingProofs {
+	return &RecordingProofs{
+		ProofsAPI: api,
+		store:     store,
+	}
+}
+
+func (r *RecordingProofs) SealPreCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) ([]byte, error) {
+	out, err := r.ProofsAPI.SealPreCommit1(ctx, sector, ticket, pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.store.Update(sector.ID, func(meta *SectorMeta) error {
+		setRef(meta, sector)
+		meta.Ticket = ticket
+		meta.Pieces = pieces
+		return nil
+	}); err != nil {
+		return nil, xerrors.Errorf("recording sector metadata: %w", err)
+	}
+
+	return out, nil
+}
+
+func (r *RecordingProofs) SealPreCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) (ffi.SectorCids, error) {
+	cids, err := r.ProofsAPI.SealPreCommit2(ctx, sector, phase1Output)
+	if err != nil {
+		return ffi.SectorCids{}, err
+	}
+
+	if err := r.store.Update(sector.ID, func(meta *SectorMeta) error {
+		setRef(meta, sector)
+		meta.SealedCID = cids.Sealed
+		meta.UnsealedCID = cids.Unsealed
+		return nil
+	}); err != nil {
+		return ffi.SectorCids{}, xerrors.Errorf("recording sector metadata: %w", err)
+	}
+
+	return cids, nil
+}
+
+func (r *RecordingProofs) SealCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids ffi.SectorCids) ([]byte, error) {
+	out, err := r.ProofsAPI.SealCommit1(ctx, sector, ticket, seed, pieces, cids)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.store.Update(sector.ID, func(meta *SectorMeta) error {
+		setRef(meta, sector)
+		meta.Ticket = ticket
+		meta.Seed = seed
+		meta.Pieces = pieces
+		meta.SealedCID = cids.Sealed
+		meta.UnsealedCID = cids.Unsealed
+		return nil
+	}); err != nil {
+		return nil, xerrors.Errorf("recording sector metadata: %w", err)
+	}
+
+	return out, nil
+}
+
+// setRef records the proof type and paths of a sector, keeping the recorded
+// paths the ref leaves empty.
+func setRef(meta *SectorMeta, sector ffi.SectorRef) {
+	meta.ProofType = sector.ProofType
+	if sector.StagedSectorPath != "" {
+		meta.StagedSectorPath = sector.StagedSectorPath
+	}
+	if sector.SealedSectorPath != "" {
+		meta.SealedSectorPath = sector.SealedSectorPath
+	}
+	if sector.CacheDirPath != "" {
+		meta.CacheDirPath = sector.CacheDirPath
+	}
+}
@@ -0,0 +1,237 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package sectormeta is an embedded store of the sector metadata produced by
+// sealing: commitments, randomness, proof type and file locations. It lets
+// small deployments generate PoSt for their sectors without keeping their own
+// sector database.
+package sectormeta
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+// ErrNotFound is returned when a sector is not in the store.
+var ErrNotFound = xerrors.New("sector not found")
+
+var sectorsBucket = []byte("sectors")
+
+// SectorMeta is the metadata recorded for a sector. Fields are filled in as
+// the sector goes through the seal calls.
+type SectorMeta struct {
+	ID        abi.SectorID
+	ProofType abi.RegisteredSealProof
+
+	Ticket abi.SealRandomness
+	Seed   abi.InteractiveSealRandomness
+	Pieces []abi.PieceInfo
+
+	SealedCID   cid.Cid // CommR
+	UnsealedCID cid.Cid // CommD
+
+	StagedSectorPath string
+	SealedSectorPath string
+	CacheDirPath     string
+
+	UpdatedAt time.Time
+}
+
+// Sealed returns whether pre-commit phase 2 completed for the sector.
+func (m SectorMeta) Sealed() bool {
+	return m.SealedCID.Defined() && m.UnsealedCID.Defined()
+}
+
+// Store is a bbolt backed sector metadata store. It is safe for concurrent
+// use.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens, or creates, the store at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, xerrors.Errorf("opening sector metadata store: %w", err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sectorsBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("initializing sector metadata store: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put records the metadata of a sector, replacing any existing record.
+func (s *Store) Put(meta SectorMeta) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, meta)
+	})
+}
+
+// Update atomically modifies the metadata of a sector. The sector is created
+// if it doesn't exist yet.
+func (s *Store) Update(id abi.SectorID, fn func(*SectorMeta) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		meta, err := get(tx, id)
+		if err == ErrNotFound {
+			meta = SectorMeta{ID: id}
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(&meta); err != nil {
+			return err
+		}
+		meta.ID = id
+
+		return put(tx, meta)
+	})
+}
+
+// Get returns the metadata of a sector, or ErrNotFound.
+func (s *Store) Get(id abi.SectorID) (SectorMeta, error) {
+	var meta SectorMeta
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		meta, err = get(tx, id)
+		return err
+	})
+	return meta, err
+}
+
+// Delete removes a sector from the store. Deleting a missing sector is not an
+// error.
+func (s *Store) Delete(id abi.SectorID) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sectorsBucket).Delete(sectorKey(id))
+	})
+}
+
+// List returns the sectors of a miner, ordered by sector number.
+func (s *Store) List(miner abi.ActorID) ([]SectorMeta, error) {
+	var out []SectorMeta
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := make([]byte, 8)
+		binary.BigEndian.PutUint64(prefix, uint64(miner))
+
+		c := tx.Bucket(sectorsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && len(k) == 16 && string(k[:8]) == string(prefix); k, v = c.Next() {
+			var meta SectorMeta
+			if err := json.Unmarshal(v, &meta); err != nil {
+				return xerrors.Errorf("decoding sector metadata: %w", err)
+			}
+			out = append(out, meta)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// PrivateSectorInfo returns the PoSt input of a sealed sector.
+func (s *Store) PrivateSectorInfo(id abi.SectorID, postProofType abi.RegisteredPoStProof) (ffi.PrivateSectorInfo, error) {
+	meta, err := s.Get(id)
+	if err != nil {
+		return ffi.PrivateSectorInfo{}, err
+	}
+	if !meta.Sealed() {
+		return ffi.PrivateSectorInfo{}, xerrors.Errorf("sector %d has no commitments recorded", id.Number)
+	}
+
+	return ffi.PrivateSectorInfo{
+		SectorInfo: proof.SectorInfo{
+			SealProof:    meta.ProofType,
+			SectorNumber: meta.ID.Number,
+			SealedCID:    meta.SealedCID,
+		},
+		CacheDirPath:     meta.CacheDirPath,
+		PoStProofType:    postProofType,
+		SealedSectorPath: meta.SealedSectorPath,
+	}, nil
+}
+
+// WindowPoStSectorInfo returns the sorted Window PoSt input for the given
+// sectors of a miner.
+func (s *Store) WindowPoStSectorInfo(miner abi.ActorID, sectors []abi.SectorNumber) (ffi.SortedPrivateSectorInfo, error) {
+	return s.postSectorInfo(miner, sectors, abi.RegisteredSealProof.RegisteredWindowPoStProof)
+}
+
+// WinningPoStSectorInfo returns the sorted Winning PoSt input for the given
+// sectors of a miner.
+func (s *Store) WinningPoStSectorInfo(miner abi.ActorID, sectors []abi.SectorNumber) (ffi.SortedPrivateSectorInfo, error) {
+	return s.postSectorInfo(miner, sectors, abi.RegisteredSealProof.RegisteredWinningPoStProof)
+}
+
+func (s *Store) postSectorInfo(miner abi.ActorID, sectors []abi.SectorNumber, postProof func(abi.RegisteredSealProof) (abi.RegisteredPoStProof, error)) (ffi.SortedPrivateSectorInfo, error) {
+	infos := make([]ffi.PrivateSectorInfo, 0, len(sectors))
+	for _, number := range sectors {
+		id := abi.SectorID{Miner: miner, Number: number}
+
+		meta, err := s.Get(id)
+		if err != nil {
+			return ffi.SortedPrivateSectorInfo{}, xerrors.Errorf("sector %d: %w", number, err)
+		}
+
+		ppt, err := postProof(meta.ProofType)
+		if err != nil {
+			return ffi.SortedPrivateSectorInfo{}, err
+		}
+
+		info, err := s.PrivateSectorInfo(id, ppt)
+		if err != nil {
+			return ffi.SortedPrivateSectorInfo{}, err
+		}
+		infos = append(infos, info)
+	}
+
+	return ffi.NewSortedPrivateSectorInfo(infos...), nil
+}
+
+func put(tx *bolt.Tx, meta SectorMeta) error {
+	meta.UpdatedAt = time.Now()
+
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return xerrors.Errorf("encoding sector metadata: %w", err)
+	}
+
+	return tx.Bucket(sectorsBucket).Put(sectorKey(meta.ID), b)
+}
+
+func get(tx *bolt.Tx, id abi.SectorID) (SectorMeta, error) {
+	v := tx.Bucket(sectorsBucket).Get(sectorKey(id))
+	if v == nil {
+		return SectorMeta{}, ErrNotFound
+	}
+
+	var meta SectorMeta
+	if err := json.Unmarshal(v, &meta); err != nil {
+		return SectorMeta{}, xerrors.Errorf("decoding sector metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// sectorKey orders sectors by miner, then sector number.
+func sectorKey(id abi.SectorID) []byte {
+	k := make([]byte, 16)
+	binary.BigEndian.PutUint64(k[:8], uint64(id.Miner))
+	binary.BigEndian.PutUint64(k[8:], uint64(id.Number))
+	return k
+}
@@ -0,0 +1,92 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package sectormeta
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProofs struct {
+	ffi.ProofsAPI
+
+	cids ffi.SectorCids
+}
+
+func (f *fakeProofs) SealPreCommit1(context.Context, ffi.SectorRef, abi.SealRandomness, []abi.PieceInfo) ([]byte, error) {
+	return []byte("p1o"), nil
+}
+
+func (f *fakeProofs) SealPreCommit2(context.Context, ffi.SectorRef, []byte) (ffi.SectorCids, error) {
+	return f.cids, nil
+}
+
+func TestRecordingProofs(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "sectors.db"))
+	require.NoError(t, err)
+	defer store.Close() // nolint:errcheck
+
+	commR, err := commcid.ReplicaCommitmentV1ToCID(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	commD, err := commcid.DataCommitmentV1ToCID(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+
+	api := NewRecordingProofs(&fakeProofs{cids: ffi.SectorCids{Sealed: commR, Unsealed: commD}}, store)
+	ctx := context.Background()
+
+	for _, number := range []abi.SectorNumber{3, 1, 2} {
+		sector := ffi.SectorRef{
+			ID:               abi.SectorID{Miner: 1000, Number: number},
+			ProofType:        abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+			StagedSectorPath: "/staged",
+			SealedSectorPath: "/sealed",
+			CacheDirPath:     "/cache",
+		}
+
+		_, err = api.SealPreCommit1(ctx, sector, abi.SealRandomness{9}, nil)
+		require.NoError(t, err)
+
+		if number != 2 {
+			// the paths are kept when not passed again
+			_, err = api.SealPreCommit2(ctx, ffi.SectorRef{ID: sector.ID, ProofType: sector.ProofType}, []byte("p1o"))
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = store.Get(abi.SectorID{Miner: 1001, Number: 1})
+	assert.Equal(t, ErrNotFound, err)
+
+	sectors, err := store.List(1000)
+	require.NoError(t, err)
+	require.Len(t, sectors, 3)
+	for i, meta := range sectors {
+		assert.Equal(t, abi.SectorNumber(i+1), meta.ID.Number)
+		assert.Equal(t, abi.SealRandomness{9}, meta.Ticket)
+		assert.Equal(t, "/sealed", meta.SealedSectorPath)
+		assert.Equal(t, meta.ID.Number != 2, meta.Sealed())
+	}
+
+	info, err := store.WindowPoStSectorInfo(1000, []abi.SectorNumber{1, 3})
+	require.NoError(t, err)
+	require.Len(t, info.Values(), 2)
+	assert.Equal(t, abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, info.Values()[0].PoStProofType)
+	assert.Equal(t, commR, info.Values()[0].SealedCID)
+	assert.Equal(t, "/cache", info.Values()[0].CacheDirPath)
+
+	_, err = store.WindowPoStSectorInfo(1000, []abi.SectorNumber{2})
+	assert.Error(t, err)
+
+	require.NoError(t, store.Delete(abi.SectorID{Miner: 1000, Number: 2}))
+	sectors, err = store.List(1000)
+	require.NoError(t, err)
+	assert.Len(t, sectors, 2)
+}
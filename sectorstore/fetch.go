@@ -0,0 +1,276 @@
+package sectorstore
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// ObjectStore is the object storage API used by Fetch. Keys are slash
+// separated; sector files are stored under their lotus layout path, e.g.
+// `sealed/s-t01000-1` and `cache/s-t01000-1/p_aux`.
+type ObjectStore interface {
+	// Get returns the content of an object. It returns an error matching
+	// os.ErrNotExist when there is no such object.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Put creates or replaces an object.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// List returns the keys starting with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Fetch is a SectorStore of sectors kept in object storage. Acquired files are
+// downloaded to a local scratch directory, shared between concurrent read
+// leases and removed once the last lease is released. Files written under a
+// ModeWrite lease are uploaded when it is released with persist set.
+type Fetch struct {
+	objects ObjectStore
+	scratch string
+
+	lk      sync.Mutex
+	fetched map[fetchKey]*fetchedFile
+}
+
+var _ SectorStore = (*Fetch)(nil)
+
+type fetchKey struct {
+	id abi.SectorID
+	t  FileType
+}
+
+type fetchedFile struct {
+	refs  int
+	write bool
+
+	// ready is closed once the download completed, with err set on failure
+	ready chan struct{}
+	err   error
+}
+
+// NewFetch returns a store of the sectors in objects, downloaded to scratch.
+func NewFetch(objects ObjectStore, scratch string) *Fetch {
+	return &Fetch{
+		objects: objects,
+		scratch: scratch,
+		fetched: map[fetchKey]*fetchedFile{},
+	}
+}
+
+func (f *Fetch) Acquire(ctx context.Context, id abi.SectorID, types FileType, mode Mode) (Lease, error) {
+	lease := Lease{ID: id, Mode: mode}
+
+	for _, t := range FileTypes {
+		if !types.Has(t) {
+			continue
+		}
+
+		if err := f.acquire(ctx, id, t, mode); err != nil {
+			_ = f.Release(ctx, lease, false)
+			return Lease{}, xerrors.Errorf("acquiring %s of sector %d: %w", t.dir(), id.Number, err)
+		}
+
+		lease.Types |= t
+		lease.Set(t, f.localPath(id, t))
+	}
+
+	return lease, nil
+}
+
+func (f *Fetch) acquire(ctx context.Context, id abi.SectorID, t FileType, mode Mode) error {
+	key := fetchKey{id: id, t: t}
+
+	f.lk.Lock()
+	if ff, ok := f.fetched[key]; ok {
+		if mode == ModeWrite || ff.write {
+			f.lk.Unlock()
+			return xerrors.New("already acquired for writing")
+		}
+		ff.refs++
+		f.lk.Unlock()
+
+		select {
+		case <-ff.ready:
+		case <-ctx.Done():
+			f.release(key)
+			return ctx.Err()
+		}
+		if ff.err != nil {
+			f.release(key)
+			return ff.err
+		}
+		return nil
+	}
+
+	ff := &fetchedFile{refs: 1, write: mode == ModeWrite, ready: make(chan struct{})}
+	f.fetched[key] = ff
+	f.lk.Unlock()
+
+	ff.err = f.download(ctx, id, t, mode)
+	close(ff.ready)
+	if ff.err != nil {
+		f.release(key)
+	}
+
+	return ff.err
+}
+
+func (f *Fetch) Release(ctx context.Context, lease Lease, persist bool) error {
+	var uploadErr error
+	for _, t := range FileTypes {
+		if !lease.Types.Has(t) {
+			continue
+		}
+
+		if persist && lease.Mode == ModeWrite && uploadErr == nil {
+			uploadErr = f.upload(ctx, lease.ID, t)
+		}
+		f.release(fetchKey{id: lease.ID, t: t})
+	}
+
+	return uploadErr
+}
+
+// release drops a reference to a fetched file, removing it with the last one.
+func (f *Fetch) release(key fetchKey) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	ff, ok := f.fetched[key]
+	if !ok {
+		return
+	}
+	if ff.refs--; ff.refs > 0 {
+		return
+	}
+
+	delete(f.fetched, key)
+	_ = os.RemoveAll(f.localPath(key.id, key.t))
+}
+
+func (f *Fetch) localPath(id abi.SectorID, t FileType) string {
+	return filepath.Join(f.scratch, t.dir(), SectorName(id))
+}
+
+func objectKey(id abi.SectorID, t FileType) string {
+	return path.Join(t.dir(), SectorName(id))
+}
+
+// download fetches a sector file. For ModeWrite, files which don't exist yet
+// are not an error, and a cache directory is always created.
+func (f *Fetch) download(ctx context.Context, id abi.SectorID, t FileType, mode Mode) error {
+	local := f.localPath(id, t)
+	key := objectKey(id, t)
+
+	if !t.isDir() {
+		if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+			return err
+		}
+		err := f.downloadObject(ctx, key, local)
+		if mode == ModeWrite && xerrors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(local, 0755); err != nil {
+		return err
+	}
+
+	keys, err := f.objects.List(ctx, key+"/")
+	if err != nil {
+		return xerrors.Errorf("listing %s: %w", key, err)
+	}
+	if len(keys) == 0 && mode == ModeRead {
+		return xerrors.Errorf("%s: %w", key, os.ErrNotExist)
+	}
+
+	for _, k := range keys {
+		dst := filepath.Join(local, filepath.FromSlash(strings.TrimPrefix(k, key+"/")))
+		// Join cleans the path: keys with ".." elements must not write
+		// outside of the cache directory
+		if !strings.HasPrefix(dst, local+string(filepath.Separator)) {
+			return xerrors.Errorf("listing %s: object %q is outside of the directory", key, k)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := f.downloadObject(ctx, k, dst); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Fetch) downloadObject(ctx context.Context, key string, dst string) error {
+	r, err := f.objects.Get(ctx, key)
+	if err != nil {
+		return xerrors.Errorf("fetching %s: %w", key, err)
+	}
+	defer r.Close() // nolint:errcheck
+
+	tmp := dst + ".fetch"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return xerrors.Errorf("fetching %s: %w", key, err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+// upload persists a written sector file. Files which were not created are
+// skipped.
+func (f *Fetch) upload(ctx context.Context, id abi.SectorID, t FileType) error {
+	local := f.localPath(id, t)
+	key := objectKey(id, t)
+
+	if !t.isDir() {
+		err := f.uploadObject(ctx, local, key)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return filepath.Walk(local, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(local, p)
+		if err != nil {
+			return err
+		}
+		return f.uploadObject(ctx, p, path.Join(key, filepath.ToSlash(rel)))
+	})
+}
+
+func (f *Fetch) uploadObject(ctx context.Context, src string, key string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint:errcheck
+
+	if err := f.objects.Put(ctx, key, in); err != nil {
+		return xerrors.Errorf("uploading %s: %w", key, err)
+	}
+	return nil
+}
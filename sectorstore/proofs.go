@@ -0,0 +1,155 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package sectorstore
+
+import (
+	"context"
+	"os"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// StoreProofs is a ffi.ProofsAPI taking sector files from a SectorStore. The
+// paths set in the sector refs and PoSt sector infos it is given are ignored:
+// files are acquired from the store for each call, and written files are
+// persisted when the call succeeds.
+type StoreProofs struct {
+	ffi.ProofsAPI
+
+	store SectorStore
+}
+
+var _ ffi.ProofsAPI = (*StoreProofs)(nil)
+
+// NewStoreProofs wraps api so that sector files are acquired from store.
+func NewStoreProofs(api ffi.ProofsAPI, store SectorStore) *StoreProofs {
+	return &StoreProofs{
+		ProofsAPI: api,
+		store:     store,
+	}
+}
+
+// withSector runs fn with the sector ref paths pointing at the acquired
+// files.
+func (s *StoreProofs) withSector(ctx context.Context, sector ffi.SectorRef, read FileType, write FileType, fn func(ffi.SectorRef) error) (err error) {
+	var leases []Lease
+	defer func() {
+		for _, l := range leases {
+			if rerr := s.store.Release(ctx, l, err == nil); rerr != nil && err == nil {
+				err = xerrors.Errorf("releasing sector files: %w", rerr)
+			}
+		}
+	}()
+
+	for _, acq := range []struct {
+		types FileType
+		mode  Mode
+	}{{read, ModeRead}, {write, ModeWrite}} {
+		if acq.types == 0 {
+			continue
+		}
+
+		l, err := s.store.Acquire(ctx, sector.ID, acq.types, acq.mode)
+		if err != nil {
+			return err
+		}
+		leases = append(leases, l)
+
+		for _, t := range FileTypes {
+			if !acq.types.Has(t) {
+				continue
+			}
+			switch t {
+			case FTUnsealed:
+				sector.StagedSectorPath = l.Unsealed
+			case FTSealed:
+				sector.SealedSectorPath = l.Sealed
+			case FTCache:
+				sector.CacheDirPath = l.Cache
+			}
+		}
+	}
+
+	return fn(sector)
+}
+
+func (s *StoreProofs) SealPreCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (out []byte, err error) {
+	err = s.withSector(ctx, sector, FTUnsealed, FTSealed|FTCache, func(sector ffi.SectorRef) error {
+		// like lotus, create the replica file the sealing writes into
+		f, err := os.OpenFile(sector.SealedSectorPath, os.O_RDONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return xerrors.Errorf("creating sealed sector file: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		out, err = s.ProofsAPI.SealPreCommit1(ctx, sector, ticket, pieces)
+		return err
+	})
+	return out, err
+}
+
+func (s *StoreProofs) SealPreCommit2(ctx context.Context, sector ffi.SectorRef, phase1Output []byte) (cids ffi.SectorCids, err error) {
+	err = s.withSector(ctx, sector, 0, FTSealed|FTCache, func(sector ffi.SectorRef) error {
+		cids, err = s.ProofsAPI.SealPreCommit2(ctx, sector, phase1Output)
+		return err
+	})
+	return cids, err
+}
+
+func (s *StoreProofs) SealCommit1(ctx context.Context, sector ffi.SectorRef, ticket abi.SealRandomness, seed abi.InteractiveSealRandomness, pieces []abi.PieceInfo, cids ffi.SectorCids) (out []byte, err error) {
+	err = s.withSector(ctx, sector, FTSealed|FTCache, 0, func(sector ffi.SectorRef) error {
+		out, err = s.ProofsAPI.SealCommit1(ctx, sector, ticket, seed, pieces, cids)
+		return err
+	})
+	return out, err
+}
+
+func (s *StoreProofs) GenerateWinningPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) (out []proof5.PoStProof, err error) {
+	err = s.withPoStSectors(ctx, minerID, sectorInfo, func(sectorInfo ffi.SortedPrivateSectorInfo) error {
+		out, err = s.ProofsAPI.GenerateWinningPoSt(ctx, minerID, sectorInfo, randomness)
+		return err
+	})
+	return out, err
+}
+
+func (s *StoreProofs) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, randomness abi.PoStRandomness) (out []proof5.PoStProof, skipped []abi.SectorID, err error) {
+	err = s.withPoStSectors(ctx, minerID, sectorInfo, func(sectorInfo ffi.SortedPrivateSectorInfo) error {
+		out, skipped, err = s.ProofsAPI.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+		return err
+	})
+	return out, skipped, err
+}
+
+// withPoStSectors runs fn with the sector infos pointing at the acquired
+// sealed sectors and caches.
+func (s *StoreProofs) withPoStSectors(ctx context.Context, minerID abi.ActorID, sectorInfo ffi.SortedPrivateSectorInfo, fn func(ffi.SortedPrivateSectorInfo) error) error {
+	sectors := sectorInfo.Values()
+
+	leases := make([]Lease, 0, len(sectors))
+	defer func() {
+		for _, l := range leases {
+			_ = s.store.Release(ctx, l, false)
+		}
+	}()
+
+	infos := make([]ffi.PrivateSectorInfo, len(sectors))
+	for i, info := range sectors {
+		l, err := s.store.Acquire(ctx, abi.SectorID{Miner: minerID, Number: info.SectorNumber}, FTSealed|FTCache, ModeRead)
+		if err != nil {
+			return err
+		}
+		leases = append(leases, l)
+
+		info.SealedSectorPath = l.Sealed
+		info.CacheDirPath = l.Cache
+		infos[i] = info
+	}
+
+	return fn(ffi.NewSortedPrivateSectorInfo(infos...))
+}
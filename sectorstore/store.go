@@ -0,0 +1,257 @@
+// Package sectorstore abstracts where sector files live. The proving calls
+// need local paths; a SectorStore hands them out for the duration of a call,
+// whether the files are on a local disk, a network mount, or fetched to
+// scratch space from object storage.
+package sectorstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+// FileType is a set of sector file kinds.
+type FileType int
+
+const (
+	// FTUnsealed is the unsealed (staged) sector file.
+	FTUnsealed FileType = 1 << iota
+	// FTSealed is the sealed sector (replica) file.
+	FTSealed
+	// FTCache is the sector cache directory.
+	FTCache
+	// FTUpdate is the replica of an updated sector.
+	FTUpdate
+	// FTUpdateCache is the cache directory of an updated sector.
+	FTUpdateCache
+)
+
+// FileTypes lists the file kinds in the order they are handled.
+var FileTypes = []FileType{FTUnsealed, FTSealed, FTCache, FTUpdate, FTUpdateCache}
+
+// Has returns whether t includes all of other.
+func (t FileType) Has(other FileType) bool {
+	return t&other == other
+}
+
+// dir is the lotus storage directory of a single file kind.
+func (t FileType) dir() string {
+	switch t {
+	case FTUnsealed:
+		return "unsealed"
+	case FTSealed:
+		return "sealed"
+	case FTCache:
+		return "cache"
+	case FTUpdate:
+		return "update"
+	case FTUpdateCache:
+		return "update-cache"
+	default:
+		panic(fmt.Sprintf("unknown file type %d", t))
+	}
+}
+
+// isDir returns whether the file kind is a directory.
+func (t FileType) isDir() bool {
+	return t == FTCache || t == FTUpdateCache
+}
+
+// Mode is how acquired files are used.
+type Mode int
+
+const (
+	// ModeRead acquires existing files for reading.
+	ModeRead Mode = iota
+	// ModeWrite acquires files to be created or modified. Cache directories
+	// are created; other files may not exist yet.
+	ModeWrite
+)
+
+// SectorPaths are the local paths of sector files. Paths of file kinds which
+// were not acquired are empty.
+type SectorPaths struct {
+	Unsealed    string
+	Sealed      string
+	Cache       string
+	Update      string
+	UpdateCache string
+}
+
+// Get returns the path of a single file kind.
+func (p SectorPaths) Get(t FileType) string {
+	switch t {
+	case FTUnsealed:
+		return p.Unsealed
+	case FTSealed:
+		return p.Sealed
+	case FTCache:
+		return p.Cache
+	case FTUpdate:
+		return p.Update
+	case FTUpdateCache:
+		return p.UpdateCache
+	default:
+		return ""
+	}
+}
+
+// Set sets the path of a single file kind.
+func (p *SectorPaths) Set(t FileType, path string) {
+	switch t {
+	case FTUnsealed:
+		p.Unsealed = path
+	case FTSealed:
+		p.Sealed = path
+	case FTCache:
+		p.Cache = path
+	case FTUpdate:
+		p.Update = path
+	case FTUpdateCache:
+		p.UpdateCache = path
+	}
+}
+
+// Lease is a set of sector files acquired from a SectorStore.
+type Lease struct {
+	ID    abi.SectorID
+	Types FileType
+	Mode  Mode
+
+	SectorPaths
+}
+
+// SectorStore hands out local paths to sector files.
+type SectorStore interface {
+	// Acquire makes the given file kinds of a sector available locally until
+	// the lease is released.
+	Acquire(ctx context.Context, id abi.SectorID, types FileType, mode Mode) (Lease, error)
+
+	// Release ends a lease. When it was acquired with ModeWrite and persist
+	// is set, the files written are persisted to the store; otherwise they
+	// may be discarded.
+	Release(ctx context.Context, lease Lease, persist bool) error
+}
+
+// SectorName returns the lotus file name of a sector, `s-t0<miner>-<number>`.
+func SectorName(id abi.SectorID) string {
+	return fmt.Sprintf("s-t0%d-%d", id.Miner, id.Number)
+}
+
+// Local is a SectorStore of files in the lotus storage layout under a local
+// directory, e.g. `<root>/sealed/s-t01000-1`. Paths point straight at the
+// files, so releasing is a no-op.
+type Local struct {
+	Root string
+}
+
+var _ SectorStore = Local{}
+
+func (l Local) Acquire(ctx context.Context, id abi.SectorID, types FileType, mode Mode) (Lease, error) {
+	lease := Lease{ID: id, Types: types, Mode: mode}
+
+	for _, t := range FileTypes {
+		if !types.Has(t) {
+			continue
+		}
+
+		path := filepath.Join(l.Root, t.dir(), SectorName(id))
+		switch mode {
+		case ModeRead:
+			if _, err := os.Stat(path); err != nil {
+				return Lease{}, xerrors.Errorf("acquiring %s of sector %d: %w", t.dir(), id.Number, err)
+			}
+		case ModeWrite:
+			dir := filepath.Dir(path)
+			if t.isDir() {
+				dir = path
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return Lease{}, xerrors.Errorf("acquiring %s of sector %d: %w", t.dir(), id.Number, err)
+			}
+		}
+
+		lease.Set(t, path)
+	}
+
+	return lease, nil
+}
+
+func (l Local) Release(context.Context, Lease, bool) error {
+	return nil
+}
+
+// NFS is a Local store on a network mount. Acquire checks that the mount is
+// reachable, and releasing a written lease flushes the files to the server,
+// so that other hosts see them once Release returns.
+type NFS struct {
+	Local
+}
+
+var _ SectorStore = NFS{}
+
+// NewNFS returns a store of the sectors under the mounted root.
+func NewNFS(root string) NFS {
+	return NFS{Local: Local{Root: root}}
+}
+
+func (n NFS) Acquire(ctx context.Context, id abi.SectorID, types FileType, mode Mode) (Lease, error) {
+	if st, err := os.Stat(n.Root); err != nil {
+		return Lease{}, xerrors.Errorf("storage root not reachable: %w", err)
+	} else if !st.IsDir() {
+		return Lease{}, xerrors.Errorf("storage root %s is not a directory", n.Root)
+	}
+
+	return n.Local.Acquire(ctx, id, types, mode)
+}
+
+func (n NFS) Release(ctx context.Context, lease Lease, persist bool) error {
+	if lease.Mode != ModeWrite || !persist {
+		return nil
+	}
+
+	for _, t := range FileTypes {
+		if !lease.Types.Has(t) {
+			continue
+		}
+
+		path := lease.Get(t)
+		if t.isDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if e.Type().IsRegular() {
+					if err := syncPath(filepath.Join(path, e.Name())); err != nil {
+						return err
+					}
+				}
+			}
+		} else if err := syncPath(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err := syncPath(filepath.Dir(path)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func syncPath(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return xerrors.Errorf("syncing %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,153 @@
+package sectorstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSector = abi.SectorID{Miner: 1000, Number: 7}
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	store := Local{Root: t.TempDir()}
+
+	_, err := store.Acquire(ctx, testSector, FTSealed, ModeRead)
+	require.Error(t, err)
+
+	lease, err := store.Acquire(ctx, testSector, FTSealed|FTCache, ModeWrite)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(store.Root, "sealed", "s-t01000-7"), lease.Sealed)
+	assert.Equal(t, filepath.Join(store.Root, "cache", "s-t01000-7"), lease.Cache)
+	assert.Empty(t, lease.Unsealed)
+	assert.DirExists(t, lease.Cache)
+
+	require.NoError(t, ioutil.WriteFile(lease.Sealed, []byte("replica"), 0644))
+	require.NoError(t, store.Release(ctx, lease, true))
+
+	lease, err = store.Acquire(ctx, testSector, FTSealed, ModeRead)
+	require.NoError(t, err)
+	require.NoError(t, store.Release(ctx, lease, false))
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	objects := &memObjects{objects: map[string][]byte{}}
+	scratch := t.TempDir()
+	store := NewFetch(objects, scratch)
+
+	_, err := store.Acquire(ctx, testSector, FTSealed, ModeRead)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// write a sector and persist it
+	lease, err := store.Acquire(ctx, testSector, FTSealed|FTCache, ModeWrite)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(lease.Sealed, []byte("replica"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(lease.Cache, "p_aux"), []byte("aux"), 0644))
+
+	_, err = store.Acquire(ctx, testSector, FTSealed, ModeRead)
+	require.Error(t, err, "sector is being written")
+
+	require.NoError(t, store.Release(ctx, lease, true))
+	assert.Equal(t, []byte("replica"), objects.objects["sealed/s-t01000-7"])
+	assert.Equal(t, []byte("aux"), objects.objects["cache/s-t01000-7/p_aux"])
+	assertEmptyDir(t, scratch)
+
+	// concurrent readers share the download
+	a, err := store.Acquire(ctx, testSector, FTSealed|FTCache, ModeRead)
+	require.NoError(t, err)
+	b, err := store.Acquire(ctx, testSector, FTCache, ModeRead)
+	require.NoError(t, err)
+	assert.Equal(t, a.Cache, b.Cache)
+
+	aux, err := ioutil.ReadFile(filepath.Join(b.Cache, "p_aux"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("aux"), aux)
+	assert.Equal(t, 2, objects.gets)
+
+	require.NoError(t, store.Release(ctx, a, false))
+	assert.DirExists(t, b.Cache)
+	require.NoError(t, store.Release(ctx, b, false))
+	assertEmptyDir(t, scratch)
+}
+
+func TestFetchRejectsKeysOutsideOfDirectory(t *testing.T) {
+	ctx := context.Background()
+	objects := &memObjects{objects: map[string][]byte{
+		"cache/s-t01000-7/p_aux":            []byte("aux"),
+		"cache/s-t01000-7/../../../escaped": []byte("escaped"),
+	}}
+	scratch := t.TempDir()
+	store := NewFetch(objects, filepath.Join(scratch, "fetch"))
+
+	_, err := store.Acquire(ctx, testSector, FTCache, ModeRead)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside of the directory")
+	assert.NoFileExists(t, filepath.Join(scratch, "escaped"))
+}
+
+func assertEmptyDir(t *testing.T, dir string) {
+	var files []string
+	require.NoError(t, filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, p)
+		}
+		return err
+	}))
+	assert.Empty(t, files)
+}
+
+type memObjects struct {
+	lk      sync.Mutex
+	objects map[string][]byte
+	gets    int
+}
+
+func (m *memObjects) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	b, ok := m.objects[key]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	m.gets++
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *memObjects) Put(_ context.Context, key string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.objects[key] = b
+	return nil
+}
+
+func (m *memObjects) List(_ context.Context, prefix string) ([]string, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	var keys []string
+	for k := range m.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,221 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// The steps of SelfTest.
+const (
+	SelfTestAddPiece     = "add_piece"
+	SelfTestPreCommit1   = "pre_commit_1"
+	SelfTestPreCommit2   = "pre_commit_2"
+	SelfTestCommit1      = "commit_1"
+	SelfTestCommit2      = "commit_2"
+	SelfTestVerifySeal   = "verify_seal"
+	SelfTestBLSSignature = "bls_signature"
+)
+
+// SelfTestStep is the outcome of a step of SelfTest.
+type SelfTestStep struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// SelfTestReport is the result of SelfTest.
+type SelfTestReport struct {
+	// Steps are the steps run, in order. The seal steps after a failed one
+	// are not run.
+	Steps []SelfTestStep
+}
+
+// Err returns the error of the first failed step, or nil.
+func (r SelfTestReport) Err() error {
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			return xerrors.Errorf("self-test %s: %w", s.Name, s.Err)
+		}
+	}
+	return nil
+}
+
+// SelfTest seals a 2KiB sector of random data and verifies its proof, and
+// signs a message with a new BLS key and verifies the signature, to check
+// that the native library, its parameters and the GPU drivers work before
+// taking on real work. The parameters of the 2KiB sectors must have been
+// fetched.
+//
+// The sector is sealed in a temporary directory, removed on return. The
+// returned error is that of the first failed step, also in the report.
+func SelfTest(ctx context.Context) (SelfTestReport, error) {
+	var report SelfTestReport
+	step := func(name string, fn func() error) bool {
+		start := time.Now()
+		err := fn()
+		report.Steps = append(report.Steps, SelfTestStep{Name: name, Duration: time.Since(start), Err: err})
+		return err == nil
+	}
+
+	dir, err := ioutil.TempDir("", "ffi-selftest")
+	if err != nil {
+		return report, xerrors.Errorf("self-test: %w", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	selfTestSeal(ctx, dir, step)
+	step(SelfTestBLSSignature, selfTestBLS)
+
+	return report, report.Err()
+}
+
+func selfTestSeal(ctx context.Context, dir string, step func(string, func() error) bool) {
+	proofType := abi.RegisteredSealProof_StackedDrg2KiBV1_1
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+
+	cacheDir := filepath.Join(dir, "cache")
+	stagedPath := filepath.Join(dir, "staged")
+	sealedPath := filepath.Join(dir, "sealed")
+
+	ticket := make(abi.SealRandomness, 32)
+	seed := make(abi.InteractiveSealRandomness, 32)
+	var pieces []abi.PieceInfo
+	if !step(SelfTestAddPiece, func() (err error) {
+		if _, err := rand.Read(ticket); err != nil {
+			return err
+		}
+		if _, err := rand.Read(seed); err != nil {
+			return err
+		}
+		// keep the randomness in the field
+		ticket[31] &= 0x3f
+		seed[31] &= 0x3f
+
+		if err := os.Mkdir(cacheDir, 0755); err != nil {
+			return err
+		}
+		// the sealed file must exist
+		if err := ioutil.WriteFile(sealedPath, nil, 0644); err != nil {
+			return err
+		}
+		piece, err := selfTestPiece(proofType, dir, stagedPath)
+		if err != nil {
+			return err
+		}
+		pieces = []abi.PieceInfo{piece}
+		return nil
+	}) {
+		return
+	}
+
+	var pc1o []byte
+	if !step(SelfTestPreCommit1, func() (err error) {
+		pc1o, err = SealPreCommitPhase1Ctx(ctx, proofType, cacheDir, stagedPath, sealedPath, sid.Number, sid.Miner, ticket, pieces)
+		return err
+	}) {
+		return
+	}
+
+	info := proof5.SealVerifyInfo{
+		SealProof:             proofType,
+		SectorID:              sid,
+		DealIDs:               []abi.DealID{},
+		Randomness:            ticket,
+		InteractiveRandomness: seed,
+	}
+	if !step(SelfTestPreCommit2, func() (err error) {
+		info.SealedCID, info.UnsealedCID, err = SealPreCommitPhase2Ctx(ctx, pc1o, cacheDir, sealedPath)
+		return err
+	}) {
+		return
+	}
+
+	var c1o []byte
+	if !step(SelfTestCommit1, func() (err error) {
+		c1o, err = SealCommitPhase1Ctx(ctx, proofType, info.SealedCID, info.UnsealedCID, cacheDir, sealedPath, sid.Number, sid.Miner, ticket, seed, pieces)
+		return err
+	}) {
+		return
+	}
+
+	if !step(SelfTestCommit2, func() (err error) {
+		info.Proof, err = SealCommitPhase2Ctx(ctx, c1o, sid.Number, sid.Miner)
+		return err
+	}) {
+		return
+	}
+
+	step(SelfTestVerifySeal, func() error {
+		ok, err := VerifySeal(info)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return xerrors.New("seal proof is invalid")
+		}
+		return nil
+	})
+}
+
+// selfTestPiece fills the staged sector with a piece of random data.
+func selfTestPiece(proofType abi.RegisteredSealProof, dir, stagedPath string) (abi.PieceInfo, error) {
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+	size := abi.PaddedPieceSize(ssize).Unpadded()
+
+	pieceFile, err := ioutil.TempFile(dir, "piece")
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+	defer pieceFile.Close() // nolint: errcheck
+
+	if _, err := io.CopyN(pieceFile, rand.Reader, int64(size)); err != nil {
+		return abi.PieceInfo{}, err
+	}
+	if _, err := pieceFile.Seek(0, io.SeekStart); err != nil {
+		return abi.PieceInfo{}, err
+	}
+
+	staged, err := os.Create(stagedPath)
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+	defer staged.Close() // nolint: errcheck
+
+	_, pieceCID, err := WriteWithoutAlignment(proofType, pieceFile, size, staged)
+	if err != nil {
+		return abi.PieceInfo{}, err
+	}
+	return abi.PieceInfo{Size: size.Padded(), PieceCID: pieceCID}, staged.Close()
+}
+
+func selfTestBLS() error {
+	message := Message("filecoin-ffi self-test")
+	key := PrivateKeyGenerate()
+	signature := PrivateKeySign(key, message)
+	if signature == nil {
+		return xerrors.New("signing failed")
+	}
+	publicKey := PrivateKeyPublicKey(key)
+	if publicKey == nil {
+		return xerrors.New("deriving the public key failed")
+	}
+	if !HashVerify(signature, []Message{message}, []PublicKey{*publicKey}) {
+		return xerrors.New("signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrShutdown is returned by the operations started after Shutdown.
+var ErrShutdown = xerrors.New("ffi: shut down")
+
+var shutDown int32
+
+// Shutdown stops the package from starting new operations, and waits for the
+// native calls in flight to return, or for ctx to be done. Miners call it
+// before exiting, so that no sealing call is killed halfway through writing
+// the cache of its sector.
+//
+// After Shutdown, the Ctx variants, the jobs, the FunctionsProofs calls and
+// the calls waiting for the package Scheduler fail with ErrShutdown. The
+// package functions without a context can't be refused and still run, but
+// are waited for like the others.
+//
+// The GPU contexts of the native library are only released when the process
+// exits: it has no call to release them before.
+func Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&shutDown, 1)
+
+	idle, _ := nativeIdle()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		_, running := nativeIdle()
+		return xerrors.Errorf("waiting for %d native calls: %w", running, ctx.Err())
+	}
+}
+
+// checkShutdown returns ErrShutdown once Shutdown was called.
+func checkShutdown() error {
+	if atomic.LoadInt32(&shutDown) != 0 {
+		return ErrShutdown
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Cleanup(func() { atomic.StoreInt32(&shutDown, 0) })
+
+	ran := false
+	require.NoError(t, runCtx(context.Background(), func() error {
+		ran = true
+		return nil
+	}))
+	assert.True(t, ran)
+
+	require.NoError(t, Shutdown(context.Background()))
+
+	ran = false
+	err := runCtx(context.Background(), func() error {
+		ran = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrShutdown)
+	assert.False(t, ran)
+
+	_, err = admit(context.Background(), OpSealCommit2)
+	assert.ErrorIs(t, err, ErrShutdown)
+}
@@ -0,0 +1,102 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// TracerName is the instrumentation name of the tracer of the native calls.
+const TracerName = "github.com/filecoin-project/filecoin-ffi"
+
+// Attributes of the spans of the native calls.
+const (
+	attrProofType     = attribute.Key("ffi.proof_type")
+	attrSectorID      = attribute.Key("ffi.sector_id")
+	attrDurationMs    = attribute.Key("ffi.duration_ms")
+	attrReturnedBytes = attribute.Key("ffi.returned_bytes")
+	attrStatus        = attribute.Key("ffi.status")
+)
+
+var (
+	tracingLk     sync.Mutex
+	removeTracing func()
+)
+
+// SetTracerProvider makes every native call record a span from a tracer of
+// tp, named after the native function, with the proof type, sector number,
+// duration and response status of the call. A nil tp disables tracing, which
+// is the default.
+//
+// The spans of the Ctx variants are children of the span of their context.
+// The other functions take no context, so their spans are roots of their own
+// traces.
+func SetTracerProvider(tp trace.TracerProvider) {
+	tracingLk.Lock()
+	defer tracingLk.Unlock()
+
+	if removeTracing != nil {
+		removeTracing()
+		removeTracing = nil
+		atomic.AddInt32(&callerCtxUsers, -1)
+	}
+	if tp == nil {
+		return
+	}
+
+	atomic.AddInt32(&callerCtxUsers, 1)
+	removeTracing = cgo.AddCallHook(tracingHook(tp.Tracer(TracerName)))
+}
+
+func tracingHook(tracer trace.Tracer) cgo.CallHook {
+	return func(call cgo.Call) func(cgo.CallResult) {
+		attrs := make([]attribute.KeyValue, 0, 2)
+		if call.ProofType != "" {
+			attrs = append(attrs, attrProofType.String(call.ProofType))
+		}
+		if call.HasSectorID {
+			attrs = append(attrs, attrSectorID.Int64(int64(call.SectorID)))
+		}
+
+		_, span := tracer.Start(callerCtx(), call.Name, trace.WithAttributes(attrs...))
+		return func(res cgo.CallResult) {
+			span.SetAttributes(
+				attrDurationMs.Float64(float64(res.Duration.Microseconds())/1000),
+				attrReturnedBytes.Int(res.ReturnedBytes),
+				attrStatus.String(callStatus(res.Err)),
+			)
+			if res.Err != nil {
+				span.RecordError(res.Err)
+				span.SetStatus(codes.Error, res.Err.Error())
+			}
+			span.End()
+		}
+	}
+}
+
+// callStatus returns the name of the response status of a call.
+func callStatus(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrInvalidInput):
+		return "invalid_input"
+	case errors.Is(err, ErrPanic):
+		return "panic"
+	case errors.Is(err, ErrCallerError):
+		return "caller_error"
+	case errors.Is(err, ErrReceiverError):
+		return "receiver_error"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,53 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// parentTracer records the span context of the parents of the spans started.
+type parentTracer struct {
+	parents []trace.SpanContext
+}
+
+func (t *parentTracer) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return t
+}
+
+func (t *parentTracer) Start(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.parents = append(t.parents, trace.SpanContextFromContext(ctx))
+	return ctx, trace.SpanFromContext(ctx)
+}
+
+func TestTracingHookCallerSpan(t *testing.T) {
+	var tracer parentTracer
+	SetTracerProvider(&tracer)
+	defer SetTracerProvider(nil)
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+	hook := tracingHook(&tracer)
+	require.NoError(t, withCallerCtx(ctx, func() error {
+		hook(cgo.Call{Name: "seal_pre_commit_phase1"})(cgo.CallResult{})
+		return nil
+	}))
+	hook(cgo.Call{Name: "hash"})(cgo.CallResult{})
+
+	require.Len(t, tracer.parents, 2)
+	assert.Equal(t, parent, tracer.parents[0])
+	assert.False(t, tracer.parents[1].IsValid())
+}
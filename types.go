@@ -43,6 +43,20 @@ type Digest = [DigestBytes]byte
 // Used when generating a private key deterministically
 type PrivateKeyGenSeed = [32]byte
 
+// PointStatus is the outcome of the decoding of a public key or signature
+type PointStatus int
+
+const (
+	// PointValid is a point of the prime order subgroup, other than the
+	// identity
+	PointValid PointStatus = iota
+	// PointMalformed is not the encoding of a point of the subgroup: not on
+	// the curve, or of the wrong order
+	PointMalformed
+	// PointIdentity is the identity point, the zero signature
+	PointIdentity
+)
+
 // Proofs
 
 // SortedPublicSectorInfo is a slice of publicSectorInfo sorted
@@ -122,16 +136,19 @@ func (s *SortedPrivateSectorInfo) UnmarshalJSON(b []byte) error {
 }
 
 type publicSectorInfo struct {
-	PoStProofType abi.RegisteredPoStProof
-	SealedCID     cid.Cid
-	SectorNum     abi.SectorNumber
+	PoStProofType abi.RegisteredPoStProof `json:"PoStProofType"`
+	SealedCID     cid.Cid                 `json:"SealedCID"`
+	SectorNum     abi.SectorNumber        `json:"SectorNum"`
 }
 
+// PrivateSectorInfo is a sector proven by PoSt, with the location of its
+// files. It has JSON and (tuple) CBOR encodings, see cbor_gen.go; the JSON
+// tags pin the field names of the encoding.
 type PrivateSectorInfo struct {
 	proof.SectorInfo
-	CacheDirPath     string
-	PoStProofType    abi.RegisteredPoStProof
-	SealedSectorPath string
+	CacheDirPath     string                  `json:"CacheDirPath"`
+	PoStProofType    abi.RegisteredPoStProof `json:"PoStProofType"`
+	SealedSectorPath string                  `json:"SealedSectorPath"`
 }
 
 // AllocationManager is an interface that provides Free() capability.
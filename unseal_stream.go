@@ -0,0 +1,122 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"io"
+	"os"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// UnsealRangeStream is UnsealRange reading the sealed sector from an
+// io.ReaderAt and writing the unsealed bytes to an io.Writer, so that callers
+// can unseal from and to network streams or object storage without temporary
+// files. Readers and writers which aren't *os.File are bridged to the native
+// call through pipes, the sealed sector being read sequentially from its
+// start.
+func UnsealRangeStream(
+	proofType abi.RegisteredSealProof,
+	cacheDirPath string,
+	sealedSector io.ReaderAt,
+	unsealOutput io.Writer,
+	sectorNum abi.SectorNumber,
+	minerID abi.ActorID,
+	ticket abi.SealRandomness,
+	unsealedCID cid.Cid,
+	unpaddedByteIndex uint64,
+	unpaddedBytesAmount uint64,
+) error {
+	ssize, err := proofType.SectorSize()
+	if err != nil {
+		return err
+	}
+
+	sealed, sealedDone, err := readerFile(sealedSector, int64(ssize))
+	if err != nil {
+		return err
+	}
+
+	output, outputDone, err := writerFile(unsealOutput)
+	if err != nil {
+		_ = sealedDone()
+		return err
+	}
+
+	unsealErr := UnsealRange(proofType, cacheDirPath, sealed, output, sectorNum, minerID, ticket, unsealedCID, unpaddedByteIndex, unpaddedBytesAmount)
+
+	// the reader side can only fail once the call stopped reading, which
+	// isn't an error
+	_ = sealedDone()
+	outputErr := outputDone()
+
+	if unsealErr != nil {
+		return unsealErr
+	}
+	if outputErr != nil {
+		return xerrors.Errorf("writing unsealed data: %w", outputErr)
+	}
+	return nil
+}
+
+// readerFile returns a file to read the first size bytes of r from. When r
+// isn't a file, they are fed through a pipe. The returned function must be
+// called once done reading; it releases the pipe and returns the error
+// reading r, if any.
+func readerFile(r io.ReaderAt, size int64) (*os.File, func() error, error) {
+	if f, ok := r.(*os.File); ok {
+		return f, func() error { return nil }, nil
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(pw, io.NewSectionReader(r, 0, size))
+		_ = pw.Close()
+		done <- err
+	}()
+
+	return pr, func() error {
+		// unblocks the feeder if the reader stopped early
+		_ = pr.Close()
+		return <-done
+	}, nil
+}
+
+// writerFile returns a file whose writes are forwarded to w. When w isn't a
+// file, they go through a pipe. The returned function must be called once
+// done writing; it flushes the pipe and returns the error writing to w, if
+// any.
+func writerFile(w io.Writer) (*os.File, func() error, error) {
+	if f, ok := w.(*os.File); ok {
+		return f, func() error { return nil }, nil
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(w, pr)
+		if err != nil {
+			// keep draining so that the writer doesn't block
+			_, _ = io.Copy(io.Discard, pr)
+		}
+		_ = pr.Close()
+		done <- err
+	}()
+
+	return pw, func() error {
+		_ = pw.Close()
+		return <-done
+	}, nil
+}
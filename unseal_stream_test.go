@@ -0,0 +1,50 @@
+package ffi
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsealStreamPipes(t *testing.T) {
+	data := make([]byte, 1<<20)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	// a reader stopping early doesn't block the feeder
+	in, inDone, err := readerFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	head := make([]byte, 1000)
+	_, err = io.ReadFull(in, head)
+	require.NoError(t, err)
+	assert.Equal(t, data[:1000], head)
+	_ = inDone()
+
+	in, inDone, err = readerFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	outFile, outDone, err := writerFile(&out)
+	require.NoError(t, err)
+
+	_, err = io.Copy(outFile, in)
+	require.NoError(t, err)
+	require.NoError(t, inDone())
+	require.NoError(t, outDone())
+	assert.Equal(t, data, out.Bytes())
+
+	// files are used as is
+	f, err := ioutil.TempFile(t.TempDir(), "")
+	require.NoError(t, err)
+	defer f.Close() // nolint:errcheck
+
+	same, _, err := writerFile(f)
+	require.NoError(t, err)
+	assert.Equal(t, f, same)
+}
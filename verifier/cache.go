@@ -0,0 +1,191 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package verifier
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"sync/atomic"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+)
+
+// Cache is a Verifier remembering the outcome of the most recent
+// verifications, so that proofs seen again, as happens on reorgs or when
+// messages are gossiped by several peers, are answered without verifying
+// them. Entries are keyed by a hash of a proof and of its public inputs, and
+// only the verifications which completed without error are remembered.
+//
+// A Cache is used as the Verifier of a Pool, or on its own.
+type Cache struct {
+	verifier Verifier
+	entries  *lru.Cache
+
+	hits, misses uint64
+}
+
+var _ Verifier = (*Cache)(nil)
+
+// CacheStats counts the lookups of a Cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Len is the number of remembered verifications.
+	Len int
+}
+
+// NewCache returns a Cache of the last size verifications of v, or of the ffi
+// functions when v is nil.
+func NewCache(v Verifier, size int) (*Cache, error) {
+	if v == nil {
+		v = ffiVerifier{}
+	}
+	entries, err := lru.New(size)
+	if err != nil {
+		return nil, xerrors.Errorf("creating verification cache: %w", err)
+	}
+	return &Cache{verifier: v, entries: entries}, nil
+}
+
+// Stats returns the lookup counts of the cache.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Len:    c.entries.Len(),
+	}
+}
+
+// Purge forgets every remembered verification.
+func (c *Cache) Purge() {
+	c.entries.Purge()
+}
+
+// VerifySeal is the VerifySeal of the cached Verifier, unless info was
+// verified recently.
+func (c *Cache) VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	k := newKeyHasher(ClassSeal)
+	k.int(int64(info.SealProof))
+	k.uint(uint64(info.SectorID.Miner))
+	k.uint(uint64(info.SectorID.Number))
+	k.bytes(info.Randomness)
+	k.bytes(info.InteractiveRandomness)
+	k.cid(info.SealedCID)
+	k.cid(info.UnsealedCID)
+	k.bytes(info.Proof)
+
+	return c.verify(k.sum(), func() (bool, error) { return c.verifier.VerifySeal(info) })
+}
+
+// VerifyAggregateSeals is the VerifyAggregateSeals of the cached Verifier,
+// unless aggregate was verified recently.
+func (c *Cache) VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	k := newKeyHasher(ClassAggregate)
+	k.uint(uint64(aggregate.Miner))
+	k.int(int64(aggregate.SealProof))
+	k.int(int64(aggregate.AggregateProof))
+	k.uint(uint64(len(aggregate.Infos)))
+	for _, info := range aggregate.Infos {
+		k.uint(uint64(info.Number))
+		k.bytes(info.Randomness)
+		k.bytes(info.InteractiveRandomness)
+		k.cid(info.SealedCID)
+		k.cid(info.UnsealedCID)
+	}
+	k.bytes(aggregate.Proof)
+
+	return c.verify(k.sum(), func() (bool, error) { return c.verifier.VerifyAggregateSeals(aggregate) })
+}
+
+// VerifyWinningPoSt is the VerifyWinningPoSt of the cached Verifier, unless
+// info was verified recently.
+func (c *Cache) VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
+	k := newKeyHasher(ClassWinningPoSt)
+	k.post(info.Prover, info.Randomness, info.ChallengedSectors, info.Proofs)
+
+	return c.verify(k.sum(), func() (bool, error) { return c.verifier.VerifyWinningPoSt(info) })
+}
+
+// VerifyWindowPoSt is the VerifyWindowPoSt of the cached Verifier, unless
+// info was verified recently.
+func (c *Cache) VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error) {
+	k := newKeyHasher(ClassWindowPoSt)
+	k.post(info.Prover, info.Randomness, info.ChallengedSectors, info.Proofs)
+
+	return c.verify(k.sum(), func() (bool, error) { return c.verifier.VerifyWindowPoSt(info) })
+}
+
+func (c *Cache) verify(key [sha256.Size]byte, verify func() (bool, error)) (bool, error) {
+	if valid, ok := c.entries.Get(key); ok {
+		atomic.AddUint64(&c.hits, 1)
+		return valid.(bool), nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	valid, err := verify()
+	if err != nil {
+		return false, err
+	}
+	c.entries.Add(key, valid)
+	return valid, nil
+}
+
+// keyHasher hashes the inputs of a verification into a cache key. Variable
+// length values are prefixed with their length, so that distinct inputs
+// can't encode to the same bytes.
+type keyHasher struct {
+	h   hash.Hash
+	buf [8]byte
+}
+
+func newKeyHasher(class Class) *keyHasher {
+	k := &keyHasher{h: sha256.New()}
+	k.uint(uint64(class))
+	return k
+}
+
+func (k *keyHasher) uint(v uint64) {
+	binary.BigEndian.PutUint64(k.buf[:], v)
+	_, _ = k.h.Write(k.buf[:])
+}
+
+func (k *keyHasher) int(v int64) {
+	k.uint(uint64(v))
+}
+
+func (k *keyHasher) bytes(b []byte) {
+	k.uint(uint64(len(b)))
+	_, _ = k.h.Write(b)
+}
+
+func (k *keyHasher) cid(c cid.Cid) {
+	k.bytes(c.Bytes())
+}
+
+func (k *keyHasher) post(prover abi.ActorID, randomness abi.PoStRandomness, sectors []proof5.SectorInfo, proofs []proof5.PoStProof) {
+	k.uint(uint64(prover))
+	k.bytes(randomness)
+	k.uint(uint64(len(sectors)))
+	for _, s := range sectors {
+		k.int(int64(s.SealProof))
+		k.uint(uint64(s.SectorNumber))
+		k.cid(s.SealedCID)
+	}
+	k.uint(uint64(len(proofs)))
+	for _, p := range proofs {
+		k.int(int64(p.PoStProof))
+		k.bytes(p.ProofBytes)
+	}
+}
+
+func (k *keyHasher) sum() [sha256.Size]byte {
+	var out [sha256.Size]byte
+	k.h.Sum(out[:0])
+	return out
+}
@@ -0,0 +1,61 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package verifier
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	v := newFakeVerifier()
+	close(v.release)
+	c, err := NewCache(v, 2)
+	require.NoError(t, err)
+
+	seal := proof5.SealVerifyInfo{SectorID: abi.SectorID{Miner: 1, Number: 7}, Proof: []byte{1, 2, 3}}
+	for i := 0; i < 3; i++ {
+		ok, err := c.VerifySeal(seal)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1, Len: 1}, c.Stats())
+
+	// a different proof for the same inputs is verified
+	other := seal
+	other.Proof = []byte{1, 2, 4}
+	_, err = c.VerifySeal(other)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), c.Stats().Misses)
+
+	// but not the deals, which aren't an input of the proof
+	withDeals := seal
+	withDeals.DealIDs = []abi.DealID{42}
+	_, err = c.VerifySeal(withDeals)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), c.Stats().Hits)
+
+	// the same bytes as a PoSt don't hit the seal entries
+	post := proof5.WindowPoStVerifyInfo{Prover: 1, Proofs: []proof5.PoStProof{{ProofBytes: []byte{1, 2, 3}}}}
+	ok, err := c.VerifyWindowPoSt(post)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), c.Stats().Misses)
+	assert.Equal(t, 2, c.Stats().Len)
+
+	// failed verifications aren't remembered
+	failing := proof5.WinningPoStVerifyInfo{Prover: 2}
+	for i := 0; i < 2; i++ {
+		_, err := c.VerifyWinningPoSt(failing)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, uint64(5), c.Stats().Misses)
+
+	c.Purge()
+	assert.Equal(t, 0, c.Stats().Len)
+}
@@ -0,0 +1,319 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package verifier runs proof verifications in the background, for gateways
+// and other services checking the proofs of many peers.
+//
+// A Pool reads Requests from its input channel and runs them on a fixed set
+// of workers, with a limit on how many of each Class run at once, so that a
+// flood of one kind of proof, say window PoSts, doesn't hold up the winning
+// PoSts. Results are sent on the output channel of the pool as verifications
+// complete, not in the order of the requests. A Cache of the recent results
+// can be used as its Verifier.
+package verifier
+
+import (
+	"fmt"
+	"runtime"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// ErrInvalidRequest is the error of the Results of Requests not holding
+// exactly one proof.
+var ErrInvalidRequest = xerrors.New("invalid verification request")
+
+// Class is a kind of verification.
+type Class int
+
+const (
+	ClassWinningPoSt Class = iota
+	ClassWindowPoSt
+	ClassSeal
+	ClassAggregate
+
+	numClasses
+)
+
+func (c Class) String() string {
+	switch c {
+	case ClassWinningPoSt:
+		return "winning-post"
+	case ClassWindowPoSt:
+		return "window-post"
+	case ClassSeal:
+		return "seal"
+	case ClassAggregate:
+		return "aggregate"
+	default:
+		return fmt.Sprintf("Class(%d)", int(c))
+	}
+}
+
+// Verifier verifies proofs. The package level functions of the ffi package
+// are used by default.
+type Verifier interface {
+	VerifySeal(info proof5.SealVerifyInfo) (bool, error)
+	VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error)
+	VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error)
+	VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error)
+}
+
+type ffiVerifier struct{}
+
+func (ffiVerifier) VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	return ffi.VerifySeal(info)
+}
+
+func (ffiVerifier) VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return ffi.VerifyAggregateSeals(aggregate)
+}
+
+func (ffiVerifier) VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
+	return ffi.VerifyWinningPoSt(info)
+}
+
+func (ffiVerifier) VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error) {
+	return ffi.VerifyWindowPoSt(info)
+}
+
+// Request is a verification to run. Exactly one of its proofs must be set.
+type Request struct {
+	// ID is chosen by the caller, and copied to the Result.
+	ID uint64
+
+	Seal        *proof5.SealVerifyInfo
+	Aggregate   *proof5.AggregateSealVerifyProofAndInfos
+	WinningPoSt *proof5.WinningPoStVerifyInfo
+	WindowPoSt  *proof5.WindowPoStVerifyInfo
+}
+
+// Class returns the class of the proof of r, or false when r doesn't hold
+// exactly one proof.
+func (r Request) Class() (Class, bool) {
+	class, n := Class(-1), 0
+	if r.WinningPoSt != nil {
+		class, n = ClassWinningPoSt, n+1
+	}
+	if r.WindowPoSt != nil {
+		class, n = ClassWindowPoSt, n+1
+	}
+	if r.Seal != nil {
+		class, n = ClassSeal, n+1
+	}
+	if r.Aggregate != nil {
+		class, n = ClassAggregate, n+1
+	}
+	return class, n == 1
+}
+
+// Result is the outcome of a Request.
+type Result struct {
+	Request Request
+	// Valid is set when the proof verified. It is false when Err is set.
+	Valid bool
+	Err   error
+}
+
+// Config configures a Pool.
+type Config struct {
+	// Verifier verifies the proofs. Defaults to the ffi functions.
+	Verifier Verifier
+
+	// Workers is the number of verifications running at once. Defaults to
+	// the number of CPUs.
+	Workers int
+
+	// Concurrency is the number of verifications of a class running at
+	// once. Classes not in the map, or with a limit of zero, can use all the
+	// workers.
+	Concurrency map[Class]int
+
+	// Buffer is the capacity of the input and output channels. Defaults to
+	// Workers.
+	Buffer int
+}
+
+// Pool verifies the Requests sent on its input channel on a bounded set of
+// workers. Closing the input channel stops the pool: it then completes the
+// requests it received and closes its output channel.
+type Pool struct {
+	verifier Verifier
+	limits   [numClasses]int
+
+	requests chan Request
+	results  chan Result
+
+	work chan job
+	done chan Class
+}
+
+type job struct {
+	req   Request
+	class Class
+	// valid is unset for Requests without exactly one proof.
+	valid bool
+}
+
+// New starts a Pool configured by cfg.
+func New(cfg Config) *Pool {
+	if cfg.Verifier == nil {
+		cfg.Verifier = ffiVerifier{}
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = cfg.Workers
+	}
+
+	p := &Pool{
+		verifier: cfg.Verifier,
+		requests: make(chan Request, cfg.Buffer),
+		results:  make(chan Result, cfg.Buffer),
+		work:     make(chan job),
+		done:     make(chan Class),
+	}
+	for c := range p.limits {
+		p.limits[c] = cfg.Workers
+		if n := cfg.Concurrency[Class(c)]; n > 0 && n < cfg.Workers {
+			p.limits[c] = n
+		}
+	}
+
+	workers := make(chan struct{})
+	for i := 0; i < cfg.Workers; i++ {
+		go func() {
+			defer func() { workers <- struct{}{} }()
+			p.worker()
+		}()
+	}
+	go func() {
+		p.dispatch(cfg.Workers)
+		close(p.work)
+		for i := 0; i < cfg.Workers; i++ {
+			<-workers
+		}
+		close(p.results)
+	}()
+
+	return p
+}
+
+// Requests returns the input channel of the pool.
+func (p *Pool) Requests() chan<- Request {
+	return p.requests
+}
+
+// Results returns the output channel of the pool, which receives a Result
+// for every Request. It is closed once the input channel is closed and the
+// last verification completed. The pool stalls when the results aren't
+// read.
+func (p *Pool) Results() <-chan Result {
+	return p.results
+}
+
+// dispatch hands the queued requests to the workers, in the order of the
+// classes then of arrival, keeping within the limits of the classes. It
+// returns once the input channel is closed and every request completed.
+func (p *Pool) dispatch(workers int) {
+	var (
+		queues  [numClasses][]job
+		invalid []job
+		running [numClasses]int
+		busy    int
+		in      = p.requests
+	)
+
+	for in != nil || busy > 0 || len(invalid) > 0 || queued(&queues) {
+		// pick the next job a worker can run, if any
+		var (
+			next job
+			out  chan job
+		)
+		if busy < workers {
+			if len(invalid) > 0 {
+				next, out = invalid[0], p.work
+			} else {
+				for c := range queues {
+					if len(queues[c]) > 0 && running[c] < p.limits[c] {
+						next, out = queues[c][0], p.work
+						break
+					}
+				}
+			}
+		}
+
+		select {
+		case req, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			class, valid := req.Class()
+			if !valid {
+				invalid = append(invalid, job{req: req})
+				continue
+			}
+			queues[class] = append(queues[class], job{req: req, class: class, valid: true})
+
+		case out <- next:
+			busy++
+			if !next.valid {
+				invalid = invalid[1:]
+				continue
+			}
+			running[next.class]++
+			queues[next.class] = queues[next.class][1:]
+
+		case class := <-p.done:
+			busy--
+			if class >= 0 {
+				running[class]--
+			}
+		}
+	}
+}
+
+func queued(queues *[numClasses][]job) bool {
+	for _, q := range queues {
+		if len(q) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pool) worker() {
+	for j := range p.work {
+		res := Result{Request: j.req}
+		class := Class(-1)
+		if j.valid {
+			class = j.class
+			res.Valid, res.Err = p.verify(j)
+		} else {
+			res.Err = ErrInvalidRequest
+		}
+		if res.Err != nil {
+			res.Valid = false
+		}
+
+		p.results <- res
+		p.done <- class
+	}
+}
+
+func (p *Pool) verify(j job) (bool, error) {
+	switch j.class {
+	case ClassWinningPoSt:
+		return p.verifier.VerifyWinningPoSt(*j.req.WinningPoSt)
+	case ClassWindowPoSt:
+		return p.verifier.VerifyWindowPoSt(*j.req.WindowPoSt)
+	case ClassSeal:
+		return p.verifier.VerifySeal(*j.req.Seal)
+	default:
+		return p.verifier.VerifyAggregateSeals(*j.req.Aggregate)
+	}
+}
@@ -0,0 +1,155 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package verifier
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVerifier accepts the proofs of miner 1 once release is closed, and
+// fails those of miner 2, recording how many verifications of each class run
+// at once.
+type fakeVerifier struct {
+	release chan struct{}
+	started chan Class
+
+	lk      sync.Mutex
+	running map[Class]int
+	peak    map[Class]int
+	// total and peakTotal count the verifications of all classes.
+	total     int
+	peakTotal int
+}
+
+func newFakeVerifier() *fakeVerifier {
+	return &fakeVerifier{release: make(chan struct{}), started: make(chan Class, 64), running: map[Class]int{}, peak: map[Class]int{}}
+}
+
+func (f *fakeVerifier) run(class Class, miner uint64) (bool, error) {
+	f.lk.Lock()
+	f.running[class]++
+	if f.running[class] > f.peak[class] {
+		f.peak[class] = f.running[class]
+	}
+	f.total++
+	if f.total > f.peakTotal {
+		f.peakTotal = f.total
+	}
+	f.lk.Unlock()
+	f.started <- class
+
+	<-f.release
+
+	f.lk.Lock()
+	f.running[class]--
+	f.total--
+	f.lk.Unlock()
+
+	if miner == 2 {
+		return false, errors.New("bad proof")
+	}
+	return miner == 1, nil
+}
+
+func (f *fakeVerifier) VerifySeal(info proof5.SealVerifyInfo) (bool, error) {
+	return f.run(ClassSeal, uint64(info.SectorID.Miner))
+}
+
+func (f *fakeVerifier) VerifyAggregateSeals(aggregate proof5.AggregateSealVerifyProofAndInfos) (bool, error) {
+	return f.run(ClassAggregate, uint64(aggregate.Miner))
+}
+
+func (f *fakeVerifier) VerifyWinningPoSt(info proof5.WinningPoStVerifyInfo) (bool, error) {
+	return f.run(ClassWinningPoSt, uint64(info.Prover))
+}
+
+func (f *fakeVerifier) VerifyWindowPoSt(info proof5.WindowPoStVerifyInfo) (bool, error) {
+	return f.run(ClassWindowPoSt, uint64(info.Prover))
+}
+
+func collect(t *testing.T, p *Pool, n int) map[uint64]Result {
+	out := make(map[uint64]Result)
+	for i := 0; i < n; i++ {
+		select {
+		case res := <-p.Results():
+			out[res.Request.ID] = res
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of %d results", i, n)
+		}
+	}
+	return out
+}
+
+func TestPool(t *testing.T) {
+	v := newFakeVerifier()
+	p := New(Config{
+		Verifier:    v,
+		Workers:     4,
+		Concurrency: map[Class]int{ClassWindowPoSt: 2},
+		Buffer:      32,
+	})
+
+	p.Requests() <- Request{ID: 11}
+	p.Requests() <- Request{ID: 12, Seal: &proof5.SealVerifyInfo{}, Aggregate: &proof5.AggregateSealVerifyProofAndInfos{}}
+	for i := uint64(0); i < 8; i++ {
+		p.Requests() <- Request{ID: i, WindowPoSt: &proof5.WindowPoStVerifyInfo{Prover: 1}}
+	}
+	p.Requests() <- Request{ID: 8, Seal: &proof5.SealVerifyInfo{SectorID: abi.SectorID{Miner: 1}}}
+	p.Requests() <- Request{ID: 9, Seal: &proof5.SealVerifyInfo{SectorID: abi.SectorID{Miner: 2}}}
+	p.Requests() <- Request{ID: 10, WinningPoSt: &proof5.WinningPoStVerifyInfo{Prover: 3}}
+	close(p.Requests())
+
+	// the invalid requests don't reach the verifier
+	invalid := collect(t, p, 2)
+	for _, id := range []uint64{11, 12} {
+		require.Contains(t, invalid, id)
+		assert.ErrorIs(t, invalid[id].Err, ErrInvalidRequest)
+	}
+
+	close(v.release)
+	results := collect(t, p, 11)
+	for i := uint64(0); i < 9; i++ {
+		assert.True(t, results[i].Valid, "request %d", i)
+		assert.NoError(t, results[i].Err, "request %d", i)
+	}
+	assert.False(t, results[9].Valid)
+	assert.Error(t, results[9].Err)
+	assert.False(t, results[10].Valid)
+	assert.NoError(t, results[10].Err)
+
+	_, open := <-p.Results()
+	assert.False(t, open, "results not closed")
+
+	assert.LessOrEqual(t, v.peak[ClassWindowPoSt], 2)
+	assert.LessOrEqual(t, v.peakTotal, 4)
+}
+
+func TestPoolClassPriority(t *testing.T) {
+	v := newFakeVerifier()
+	p := New(Config{Verifier: v, Workers: 1, Buffer: 8})
+
+	// the worker is busy with the first window PoSt until release is closed,
+	// by which time the winning PoSt is queued behind the other one
+	p.Requests() <- Request{ID: 0, WindowPoSt: &proof5.WindowPoStVerifyInfo{Prover: 1}}
+	<-v.started
+	p.Requests() <- Request{ID: 1, WindowPoSt: &proof5.WindowPoStVerifyInfo{Prover: 1}}
+	p.Requests() <- Request{ID: 2, WinningPoSt: &proof5.WinningPoStVerifyInfo{Prover: 1}}
+	close(p.Requests())
+	time.Sleep(50 * time.Millisecond)
+	close(v.release)
+
+	var order []uint64
+	for res := range p.Results() {
+		order = append(order, res.Request.ID)
+	}
+	assert.Equal(t, []uint64{0, 2, 1}, order)
+}
@@ -0,0 +1,165 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// VerifySeals verifies seal proofs, returning whether each is valid like
+// VerifySeal. The proofs of a seal proof type are verified in parallel by a
+// single native call, so a batch mixing n proof types crosses into the
+// library n times. It fails if verifying any of the proofs fails.
+func VerifySeals(infos []proof5.SealVerifyInfo) ([]bool, error) {
+	out := make([]bool, len(infos))
+
+	byType := make(map[abi.RegisteredSealProof][]int)
+	var types []abi.RegisteredSealProof
+	for i, info := range infos {
+		if isFakeSeal(info.Proof) {
+			ok, err := VerifyFakeSeal(info)
+			if err != nil {
+				return nil, errors.Wrapf(err, "seal %d", i)
+			}
+			out[i] = ok
+			continue
+		}
+		if _, ok := byType[info.SealProof]; !ok {
+			types = append(types, info.SealProof)
+		}
+		byType[info.SealProof] = append(byType[info.SealProof], i)
+	}
+
+	for _, spt := range types {
+		idx := byType[spt]
+		valid, err := verifySealsOfType(spt, infos, idx)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range idx {
+			out[i] = valid[j]
+		}
+	}
+	return out, nil
+}
+
+func verifySealsOfType(spt abi.RegisteredSealProof, infos []proof5.SealVerifyInfo, idx []int) ([]bool, error) {
+	sp, err := toFilRegisteredSealProof(spt)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]cgo.AggregationInputs, len(idx))
+	proverIDs := make([]cgo.ByteArray32, len(idx))
+	proofs := make([][]byte, len(idx))
+	for j, i := range idx {
+		info := infos[i]
+		commR, err := to32ByteCommR(info.SealedCID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "seal %d", i)
+		}
+		commD, err := to32ByteCommD(info.UnsealedCID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "seal %d", i)
+		}
+		proverIDs[j], err = toProverID(info.Miner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "seal %d", i)
+		}
+
+		inputs[j] = cgo.NewAggregationInputs(
+			commR,
+			commD,
+			uint64(info.Number),
+			cgo.AsByteArray32(info.Randomness),
+			cgo.AsByteArray32(info.InteractiveRandomness),
+		)
+		proofs[j] = info.Proof
+	}
+
+	pfs, cleaner := cgo.PinSlicesBoxedUint8(proofs)
+	defer cleaner()
+
+	valid, err := cgo.VerifySeals(sp, cgo.AsSliceRefAggregationInputs(inputs), cgo.AsSliceRefByteArray32(proverIDs), cgo.AsSliceRefSliceBoxedUint8(pfs))
+	if err != nil {
+		return nil, err
+	}
+	if len(valid) != len(idx) {
+		return nil, errors.Errorf("verified %d seals out of %d", len(valid), len(idx))
+	}
+	return valid, nil
+}
+
+// VerifyWindowPoSts verifies window PoSts, returning whether each is valid
+// like VerifyWindowPoSt. The PoSts are verified in parallel by a single native
+// call. It fails if verifying any of the PoSts fails.
+func VerifyWindowPoSts(infos []proof5.WindowPoStVerifyInfo) ([]bool, error) {
+	out := make([]bool, len(infos))
+
+	var (
+		idx                        []int
+		randomness, proverIDs      []cgo.ByteArray32
+		replicas                   []cgo.PublicReplicaInfo
+		proofs                     []cgo.PoStProof
+		replicaCounts, proofCounts []uint
+	)
+	for i, info := range infos {
+		if isFakePoSt(info.Proofs) {
+			ok, err := VerifyFakeWindowPoSt(info)
+			if err != nil {
+				return nil, errors.Wrapf(err, "window PoSt %d", i)
+			}
+			out[i] = ok
+			continue
+		}
+
+		r, err := toFilPublicReplicaInfos(info.ChallengedSectors, "window")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create public replica info array for FFI of window PoSt %d", i)
+		}
+		p, cleanup, err := toFilPoStProofs(info.Proofs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create PoSt proofs array for FFI of window PoSt %d", i)
+		}
+		defer cleanup()
+		proverID, err := toProverID(info.Prover)
+		if err != nil {
+			return nil, errors.Wrapf(err, "window PoSt %d", i)
+		}
+
+		idx = append(idx, i)
+		randomness = append(randomness, cgo.AsByteArray32(info.Randomness))
+		proverIDs = append(proverIDs, proverID)
+		replicas = append(replicas, r...)
+		replicaCounts = append(replicaCounts, uint(len(r)))
+		proofs = append(proofs, p...)
+		proofCounts = append(proofCounts, uint(len(p)))
+	}
+	if len(idx) == 0 {
+		return out, nil
+	}
+
+	valid, err := cgo.VerifyWindowPoSts(
+		cgo.AsSliceRefByteArray32(randomness),
+		cgo.AsSliceRefByteArray32(proverIDs),
+		cgo.AsSliceRefPublicReplicaInfo(replicas),
+		cgo.AsSliceRefUint(replicaCounts),
+		cgo.AsSliceRefPoStProof(proofs),
+		cgo.AsSliceRefUint(proofCounts),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(valid) != len(idx) {
+		return nil, errors.Errorf("verified %d window PoSts out of %d", len(valid), len(idx))
+	}
+	for j, i := range idx {
+		out[i] = valid[j]
+	}
+	return out, nil
+}
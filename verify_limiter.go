@@ -0,0 +1,233 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package ffi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	proof5 "github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	proof7 "github.com/filecoin-project/specs-actors/v7/actors/runtime/proof"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+)
+
+// ErrVerifyLimited is returned by VerifyLimiter when a verification is
+// rejected by admission control.
+var ErrVerifyLimited = xerrors.New("verification rejected: limit exceeded")
+
+// VerifyLimiterConfig configures a VerifyLimiter.
+type VerifyLimiterConfig struct {
+	// Rate is the sustained number of proofs admitted per second. Zero
+	// disables rate limiting.
+	Rate float64
+
+	// Burst is the number of proofs which can be admitted at once above the
+	// sustained rate. Defaults to Rate rounded up, and at least 1.
+	Burst int
+
+	// MaxConcurrent is the maximum number of verifications running at once.
+	// Defaults to the number of CPUs.
+	MaxConcurrent int
+
+	// MaxQueue is the maximum number of verifications waiting for admission.
+	// Further ones are rejected right away. Zero means no limit.
+	MaxQueue int
+
+	// MaxWait is how long a verification waits for admission before being
+	// rejected. Zero means until its context is done.
+	MaxWait time.Duration
+}
+
+// VerifyLimiter bounds the rate and concurrency of proof verification, so that
+// services verifying untrusted proofs can't be overloaded by spammed or
+// malformed ones. Each proof costs one token of the rate limit: an aggregate
+// costs the number of seals it covers, up to Burst, so that it can still be
+// admitted. Rejected calls fail with ErrVerifyLimited, without reaching the
+// verifier, and get their tokens back.
+type VerifyLimiter struct {
+	cfg VerifyLimiterConfig
+
+	limiter *rate.Limiter
+	slots   chan struct{}
+	waiting int64
+}
+
+// NewVerifyLimiter returns a VerifyLimiter enforcing cfg.
+func NewVerifyLimiter(cfg VerifyLimiterConfig) *VerifyLimiter {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = runtime.NumCPU()
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = int(math.Ceil(cfg.Rate))
+		if cfg.Burst < 1 {
+			cfg.Burst = 1
+		}
+	}
+
+	l := &VerifyLimiter{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxConcurrent),
+	}
+	if cfg.Rate > 0 {
+		l.limiter = rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst)
+	}
+
+	return l
+}
+
+// Do runs fn once admitted, charging cost proofs, up to Burst, to the rate
+// limit. It returns ErrVerifyLimited when rejected, or the context error when ctx is
+// done first.
+func (l *VerifyLimiter) Do(ctx context.Context, cost int, fn func() error) error {
+	release, err := l.admit(ctx, cost)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn()
+}
+
+func (l *VerifyLimiter) admit(ctx context.Context, cost int) (func(), error) {
+	if n := atomic.AddInt64(&l.waiting, 1); l.cfg.MaxQueue > 0 && n > int64(l.cfg.MaxQueue) {
+		atomic.AddInt64(&l.waiting, -1)
+		return nil, ErrVerifyLimited
+	}
+	defer atomic.AddInt64(&l.waiting, -1)
+
+	parent := ctx
+	if l.cfg.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.cfg.MaxWait)
+		defer cancel()
+	}
+
+	rejected := func() error {
+		if err := parent.Err(); err != nil {
+			return err
+		}
+		return ErrVerifyLimited
+	}
+
+	// the slot is taken first, so that the tokens are reserved right before
+	// running, and can be returned if the call is rejected
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, rejected()
+	}
+	release := func() { <-l.slots }
+
+	if l.limiter != nil {
+		// a call can't cost more than the whole burst, or it would never be
+		// admitted
+		if cost > l.cfg.Burst {
+			cost = l.cfg.Burst
+		}
+		if cost < 1 {
+			cost = 1
+		}
+
+		r := l.limiter.ReserveN(time.Now(), cost)
+		delay := r.Delay()
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			r.Cancel()
+			release()
+			return nil, rejected()
+		}
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				r.Cancel()
+				release()
+				return nil, rejected()
+			}
+		}
+	}
+
+	return release, nil
+}
+
+// VerifySeal is VerifySeal under admission control.
+func (l *VerifyLimiter) VerifySeal(ctx context.Context, info proof5.SealVerifyInfo) (ok bool, err error) {
+	err = l.Do(ctx, 1, func() error {
+		ok, err = VerifySeal(info)
+		return err
+	})
+	return ok, err
+}
+
+// VerifyAggregateSeals is VerifyAggregateSeals under admission control.
+func (l *VerifyLimiter) VerifyAggregateSeals(ctx context.Context, aggregate proof5.AggregateSealVerifyProofAndInfos) (ok bool, err error) {
+	err = l.Do(ctx, len(aggregate.Infos), func() error {
+		ok, err = VerifyAggregateSeals(aggregate)
+		return err
+	})
+	return ok, err
+}
+
+// VerifyWinningPoSt is VerifyWinningPoSt under admission control.
+func (l *VerifyLimiter) VerifyWinningPoSt(ctx context.Context, info proof5.WinningPoStVerifyInfo) (ok bool, err error) {
+	err = l.Do(ctx, 1, func() error {
+		ok, err = VerifyWinningPoSt(info)
+		return err
+	})
+	return ok, err
+}
+
+// VerifyWindowPoSt is VerifyWindowPoSt under admission control.
+func (l *VerifyLimiter) VerifyWindowPoSt(ctx context.Context, info proof5.WindowPoStVerifyInfo) (ok bool, err error) {
+	err = l.Do(ctx, len(info.Proofs), func() error {
+		ok, err = VerifyWindowPoSt(info)
+		return err
+	})
+	return ok, err
+}
+
+// VerifyUpdateProof is SectorUpdate.VerifyUpdateProof under admission
+// control.
+func (l *VerifyLimiter) VerifyUpdateProof(ctx context.Context, info proof7.ReplicaUpdateInfo) (ok bool, err error) {
+	err = l.Do(ctx, 1, func() error {
+		ok, err = SectorUpdate.VerifyUpdateProof(info)
+		return err
+	})
+	return ok, err
+}
+
+// VerifyExportedProof is VerifyExportedProof under admission control.
+func (l *VerifyLimiter) VerifyExportedProof(ctx context.Context, data []byte) (ok bool, err error) {
+	err = l.Do(ctx, 1, func() error {
+		ok, err = VerifyExportedProof(data)
+		return err
+	})
+	return ok, err
+}
+
+// Middleware applies admission control to the requests of an HTTP service
+// verifying proofs, each request costing one proof. Rejected requests get a
+// 429 response, and never reach next.
+func (l *VerifyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, err := l.admit(r.Context(), 1)
+		if err != nil {
+			if l.limiter != nil {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(1/l.cfg.Rate))))
+			}
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
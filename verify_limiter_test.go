@@ -0,0 +1,128 @@
+package ffi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyLimiterConcurrency(t *testing.T) {
+	l := NewVerifyLimiter(VerifyLimiterConfig{
+		MaxConcurrent: 2,
+		MaxWait:       10 * time.Millisecond,
+	})
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, l.Do(context.Background(), 1, func() error {
+				started <- struct{}{}
+				<-unblock
+				return nil
+			}))
+		}()
+	}
+	<-started
+	<-started
+
+	// both slots are taken
+	err := l.Do(context.Background(), 1, func() error { return nil })
+	require.ErrorIs(t, err, ErrVerifyLimited)
+
+	// the caller's context takes precedence
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = l.Do(ctx, 1, func() error { return nil })
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(unblock)
+	wg.Wait()
+
+	require.NoError(t, l.Do(context.Background(), 1, func() error { return nil }))
+}
+
+func TestVerifyLimiterRate(t *testing.T) {
+	l := NewVerifyLimiter(VerifyLimiterConfig{
+		Rate:    1,
+		Burst:   3,
+		MaxWait: 10 * time.Millisecond,
+	})
+
+	noop := func() error { return nil }
+
+	// an aggregate larger than the burst is charged the whole burst
+	require.NoError(t, l.Do(context.Background(), 10, noop))
+	require.ErrorIs(t, l.Do(context.Background(), 1, noop), ErrVerifyLimited)
+}
+
+func TestVerifyLimiterReturnsTokens(t *testing.T) {
+	l := NewVerifyLimiter(VerifyLimiterConfig{
+		Rate:          1,
+		Burst:         2,
+		MaxConcurrent: 1,
+		MaxWait:       10 * time.Millisecond,
+	})
+
+	noop := func() error { return nil }
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- l.Do(context.Background(), 1, func() error {
+			close(started)
+			<-unblock
+			return nil
+		})
+	}()
+	<-started
+
+	// rejected waiting for the slot, without being charged
+	require.ErrorIs(t, l.Do(context.Background(), 1, noop), ErrVerifyLimited)
+
+	close(unblock)
+	require.NoError(t, <-done)
+
+	// the last token of the burst is still there
+	require.NoError(t, l.Do(context.Background(), 1, noop))
+	require.ErrorIs(t, l.Do(context.Background(), 1, noop), ErrVerifyLimited)
+}
+
+func TestVerifyLimiterMiddleware(t *testing.T) {
+	l := NewVerifyLimiter(VerifyLimiterConfig{
+		Rate:  1,
+		Burst: 1,
+		// reject rather than wait for the next token
+		MaxWait: time.Millisecond,
+	})
+
+	var calls int
+	srv := httptest.NewServer(l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,59 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import "sync"
+
+// pureCallKey identifies a call of a native getter and its arguments.
+type pureCallKey struct {
+	call  string
+	proof int64
+	arg   uint64
+}
+
+// pureCallCache memoizes the successful results of the native getters, which
+// only depend on their arguments and the library.
+type pureCallCache struct {
+	lk      sync.RWMutex
+	results map[pureCallKey]interface{}
+}
+
+func (c *pureCallCache) do(key pureCallKey, fn func() (interface{}, error)) (interface{}, error) {
+	c.lk.RLock()
+	v, ok := c.results[key]
+	c.lk.RUnlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	c.lk.Lock()
+	if c.results == nil {
+		c.results = make(map[pureCallKey]interface{})
+	}
+	c.results[key] = v
+	c.lk.Unlock()
+	return v, nil
+}
+
+func (c *pureCallCache) reset() {
+	c.lk.Lock()
+	c.results = nil
+	c.lk.Unlock()
+}
+
+// pureCalls holds the results of GetSealVersion, GetPoStVersion and
+// GetNumPartitionForFallbackPost.
+var pureCalls pureCallCache
+
+// InvalidateVersionCache drops the memoized results of GetSealVersion,
+// GetPoStVersion and GetNumPartitionForFallbackPost, which are otherwise
+// computed once per process. They only change with the native library.
+func InvalidateVersionCache() {
+	pureCalls.reset()
+}
@@ -0,0 +1,46 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPureCallCache(t *testing.T) {
+	var c pureCallCache
+	calls := 0
+	get := func(key pureCallKey, fail bool) (interface{}, error) {
+		return c.do(key, func() (interface{}, error) {
+			calls++
+			if fail {
+				return nil, errors.New("failed")
+			}
+			return "v", nil
+		})
+	}
+
+	key := pureCallKey{call: "get_seal_version", proof: 8}
+	_, err := get(key, true)
+	require.Error(t, err)
+
+	for i := 0; i < 3; i++ {
+		v, err := get(key, false)
+		require.NoError(t, err)
+		assert.Equal(t, "v", v)
+	}
+	assert.Equal(t, 2, calls, "errors are not cached")
+
+	_, err = get(pureCallKey{call: "get_seal_version", proof: 9}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	c.reset()
+	_, err = get(key, false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+}
@@ -0,0 +1,161 @@
+//go:build cgo && !ffimock
+// +build cgo,!ffimock
+
+package ffi
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+// WatchdogConfig configures StartWatchdog.
+type WatchdogConfig struct {
+	// Timeouts are the deadlines of the native calls, by native function
+	// name, e.g. "seal_commit_phase2".
+	Timeouts map[string]time.Duration
+
+	// DefaultTimeout is the deadline of the calls missing from Timeouts.
+	// Zero leaves them unwatched.
+	DefaultTimeout time.Duration
+
+	// Interval is how often the calls are checked. Defaults to a second.
+	Interval time.Duration
+
+	// OnHung is called, from the watchdog goroutine, once for every call
+	// exceeding its deadline.
+	OnHung func(HungCall)
+
+	// KillAfter, if not zero, makes the watchdog kill the process once a call
+	// exceeded its deadline by that long, after dumping the goroutines to
+	// stderr. It is meant for worker processes, restarted by their
+	// supervisor: a hung native call can't be interrupted otherwise.
+	KillAfter time.Duration
+}
+
+// HungCall is a native call running past its deadline.
+type HungCall struct {
+	Call    cgo.Call
+	Started time.Time
+	Elapsed time.Duration
+}
+
+// killProcess terminates the process when a call hangs past the KillAfter
+// delay.
+var killProcess = func(hung HungCall) {
+	fmt.Fprintf(os.Stderr, "ffi watchdog: %s hung for %s, killing the process\n", hung.Call.Name, hung.Elapsed)
+	_ = pprof.Lookup("goroutine").WriteTo(os.Stderr, 2)
+	os.Exit(2)
+}
+
+type watchdog struct {
+	cfg WatchdogConfig
+
+	lk      sync.Mutex
+	nextID  uint64
+	running map[uint64]*watchedCall
+}
+
+type watchedCall struct {
+	call     cgo.Call
+	started  time.Time
+	deadline time.Duration
+	reported bool
+}
+
+// StartWatchdog watches the native calls for calls exceeding their deadline,
+// until stopped.
+func StartWatchdog(cfg WatchdogConfig) (stop func()) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+
+	w := &watchdog{cfg: cfg, running: map[uint64]*watchedCall{}}
+	removeHook := cgo.AddCallHook(w.hook)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				w.check(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			removeHook()
+			close(done)
+		})
+	}
+}
+
+func (w *watchdog) timeout(name string) time.Duration {
+	if d, ok := w.cfg.Timeouts[name]; ok {
+		return d
+	}
+	return w.cfg.DefaultTimeout
+}
+
+func (w *watchdog) hook(call cgo.Call) func(cgo.CallResult) {
+	deadline := w.timeout(call.Name)
+	if deadline <= 0 {
+		return nil
+	}
+
+	w.lk.Lock()
+	id := w.nextID
+	w.nextID++
+	w.running[id] = &watchedCall{call: call, started: time.Now(), deadline: deadline}
+	w.lk.Unlock()
+
+	return func(cgo.CallResult) {
+		w.lk.Lock()
+		delete(w.running, id)
+		w.lk.Unlock()
+	}
+}
+
+// check reports the calls past their deadline, and kills the process if one
+// is past the KillAfter delay.
+func (w *watchdog) check(now time.Time) {
+	var hung, kill []HungCall
+
+	w.lk.Lock()
+	for _, c := range w.running {
+		elapsed := now.Sub(c.started)
+		if elapsed < c.deadline {
+			continue
+		}
+
+		h := HungCall{Call: c.call, Started: c.started, Elapsed: elapsed}
+		if !c.reported {
+			c.reported = true
+			hung = append(hung, h)
+		}
+		if w.cfg.KillAfter > 0 && elapsed >= c.deadline+w.cfg.KillAfter {
+			kill = append(kill, h)
+		}
+	}
+	w.lk.Unlock()
+
+	if w.cfg.OnHung != nil {
+		for _, h := range hung {
+			w.cfg.OnHung(h)
+		}
+	}
+	if len(kill) > 0 {
+		killProcess(kill[0])
+	}
+}
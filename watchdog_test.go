@@ -0,0 +1,59 @@
+//go:build !ffimock
+// +build !ffimock
+
+package ffi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/filecoin-ffi/cgo"
+)
+
+func TestWatchdog(t *testing.T) {
+	var hung []HungCall
+	var killed []HungCall
+	defer func(orig func(HungCall)) { killProcess = orig }(killProcess)
+	killProcess = func(h HungCall) { killed = append(killed, h) }
+
+	w := &watchdog{
+		cfg: WatchdogConfig{
+			Timeouts:  map[string]time.Duration{"seal_commit_phase2": time.Minute, "verify_seal": 0},
+			OnHung:    func(h HungCall) { hung = append(hung, h) },
+			KillAfter: time.Minute,
+		},
+		running: map[uint64]*watchedCall{},
+	}
+
+	// unwatched without a deadline
+	assert.Nil(t, w.hook(cgo.Call{Name: "verify_seal"}))
+	assert.Nil(t, w.hook(cgo.Call{Name: "generate_window_post"}))
+
+	end := w.hook(cgo.Call{Name: "seal_commit_phase2", SectorID: 3, HasSectorID: true})
+	require.NotNil(t, end)
+	done := w.hook(cgo.Call{Name: "seal_commit_phase2", SectorID: 4, HasSectorID: true})
+	done(cgo.CallResult{})
+
+	now := time.Now()
+	w.check(now)
+	assert.Empty(t, hung)
+
+	w.check(now.Add(90 * time.Second))
+	require.Len(t, hung, 1)
+	assert.Equal(t, uint64(3), hung[0].Call.SectorID)
+	assert.Empty(t, killed)
+
+	// reported once
+	w.check(now.Add(100 * time.Second))
+	assert.Len(t, hung, 1)
+
+	w.check(now.Add(3 * time.Minute))
+	require.Len(t, killed, 1)
+	assert.Equal(t, "seal_commit_phase2", killed[0].Call.Name)
+
+	end(cgo.CallResult{})
+	assert.Empty(t, w.running)
+}
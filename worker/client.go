@@ -0,0 +1,208 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// Client calls API on a remote Server or lotus worker over HTTP. Pointed at
+// the RPC endpoint of a lotus miner, it calls the ReturnAPI and WorkerConnect
+// methods of the miner instead.
+type Client struct {
+	addr   string
+	header http.Header
+	http   *http.Client
+
+	nextID int64
+}
+
+var _ API = (*Client)(nil)
+var _ ReturnAPI = (*Client)(nil)
+
+// NewClient returns a Client for the RPC endpoint at addr, e.g.
+// "http://127.0.0.1:3456/rpc/v0". The header is sent with every request and
+// typically carries the `Authorization: Bearer <token>` of a lotus worker.
+func NewClient(addr string, header http.Header) *Client {
+	return &Client{
+		addr:   addr,
+		header: header,
+		http:   http.DefaultClient,
+	}
+}
+
+func (c *Client) Version(ctx context.Context) (uint32, error) {
+	var out uint32
+	err := c.call(ctx, MethodVersion, &out)
+	return out, err
+}
+
+func (c *Client) Session(ctx context.Context) (string, error) {
+	var out string
+	err := c.call(ctx, MethodSession, &out)
+	return out, err
+}
+
+func (c *Client) ProcessSession(ctx context.Context) (string, error) {
+	var out string
+	err := c.call(ctx, MethodProcessSession, &out)
+	return out, err
+}
+
+func (c *Client) Info(ctx context.Context) (WorkerInfo, error) {
+	var out WorkerInfo
+	err := c.call(ctx, MethodInfo, &out)
+	return out, err
+}
+
+func (c *Client) TaskTypes(ctx context.Context) (map[TaskType]struct{}, error) {
+	var out map[TaskType]struct{}
+	err := c.call(ctx, MethodTaskTypes, &out)
+	return out, err
+}
+
+func (c *Client) Paths(ctx context.Context) ([]StoragePath, error) {
+	var out []StoragePath
+	err := c.call(ctx, MethodPaths, &out)
+	return out, err
+}
+
+func (c *Client) TaskEnable(ctx context.Context, tt TaskType) error {
+	return c.call(ctx, MethodTaskEnable, nil, tt)
+}
+
+func (c *Client) TaskDisable(ctx context.Context, tt TaskType) error {
+	return c.call(ctx, MethodTaskDisable, nil, tt)
+}
+
+func (c *Client) Enabled(ctx context.Context) (bool, error) {
+	var out bool
+	err := c.call(ctx, MethodEnabled, &out)
+	return out, err
+}
+
+func (c *Client) SetEnabled(ctx context.Context, enabled bool) error {
+	return c.call(ctx, MethodSetEnabled, nil, enabled)
+}
+
+// SealCommit2 starts computing the proof on the worker, which returns it to
+// its miner under the CallID.
+func (c *Client) SealCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) (CallID, error) {
+	var out CallID
+	err := c.call(ctx, MethodSealCommit2, &out, sector, phase1Output)
+	return out, err
+}
+
+func (c *Client) GenerateWinningPoSt(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, sectors []PostSectorChallenge, randomness abi.PoStRandomness) ([]proof.PoStProof, error) {
+	var out []proof.PoStProof
+	err := c.call(ctx, MethodGenerateWinningPoSt, &out, proofType, minerID, sectors, randomness)
+	return out, err
+}
+
+func (c *Client) GenerateWindowPoSt(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, sectors []PostSectorChallenge, partitionIdx int, randomness abi.PoStRandomness) (WindowPoStResult, error) {
+	var out WindowPoStResult
+	err := c.call(ctx, MethodGenerateWindowPoSt, &out, proofType, minerID, sectors, partitionIdx, randomness)
+	return out, err
+}
+
+// WorkerConnect asks a lotus miner to attach the worker serving the RPC
+// endpoint at url.
+func (c *Client) WorkerConnect(ctx context.Context, url string) error {
+	return c.call(ctx, MethodWorkerConnect, nil, url)
+}
+
+// ReturnSealCommit2 delivers the result of a SealCommit2 task to a lotus
+// miner.
+func (c *Client) ReturnSealCommit2(ctx context.Context, callID CallID, proof []byte, callErr *CallError) error {
+	return c.call(ctx, MethodReturnSealCommit2, nil, callID, proof, callErr)
+}
+
+func (c *Client) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	rawParams := make([]json.RawMessage, len(params))
+	for i := range params {
+		b, err := json.Marshal(params[i])
+		if err != nil {
+			return xerrors.Errorf("marshaling param %d: %w", i, err)
+		}
+		rawParams[i] = b
+	}
+
+	id, err := json.Marshal(atomic.AddInt64(&c.nextID, 1))
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(request{
+		Jsonrpc: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return xerrors.Errorf("marshaling request: %w", err)
+	}
+
+	respBody, err := c.post(ctx, body)
+	if err != nil {
+		return xerrors.Errorf("calling %s: %w", method, err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return xerrors.Errorf("decoding %s response: %w", method, err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return xerrors.Errorf("unmarshaling %s result: %w", method, err)
+	}
+
+	return nil
+}
+
+// Forward sends an encoded JSON-RPC request as is and returns the encoded
+// response, or nil for notifications.
+func (c *Client) Forward(ctx context.Context, request []byte) ([]byte, error) {
+	return c.post(ctx, request)
+}
+
+func (c *Client) post(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, xerrors.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package worker
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"golang.org/x/xerrors"
+)
+
+// Commit2ContentType is the content type of the requests and responses of
+// the Commit2 endpoint.
+const Commit2ContentType = "application/octet-stream"
+
+// Commit2Handler returns a handler computing Commit2 proofs over plain HTTP,
+// to be mounted next to the JSON-RPC endpoint. Requests are POSTed with an
+// ffi.EncodeCommit1Output envelope as body, and answered with the raw proof.
+// It avoids the base64 encoding of JSON-RPC, and the envelope checksum
+// catches outputs damaged in transit before any GPU time is spent on them.
+func (s *Server) Commit2Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		c1, err := ffi.DecodeCommit1Output(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.sealCommit2(r.Context(), SectorRef{ID: c1.SectorID, ProofType: c1.ProofType}, c1.Phase1Output)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", Commit2ContentType)
+		_, _ = w.Write(out)
+	})
+}
+
+// Commit2Client calls the Commit2 endpoint of a Server.
+type Commit2Client struct {
+	addr   string
+	header http.Header
+	http   *http.Client
+}
+
+// NewCommit2Client returns a Commit2Client for the endpoint at addr, where a
+// Server.Commit2Handler is mounted. The header is sent with every request.
+func NewCommit2Client(addr string, header http.Header) *Commit2Client {
+	return &Commit2Client{
+		addr:   addr,
+		header: header,
+		http:   http.DefaultClient,
+	}
+}
+
+// SealCommit2 ships the output of SealCommitPhase1 to the server, and returns
+// the proof it computed.
+func (c *Commit2Client) SealCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) ([]byte, error) {
+	body := ffi.EncodeCommit1Output(sector.ProofType, sector.ID, phase1Output)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", Commit2ContentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("calling commit 2: %w", err)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, xerrors.Errorf("reading commit 2 response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("commit 2 failed with HTTP status %s: %s", resp.Status, strings.TrimSpace(string(out)))
+	}
+
+	return out, nil
+}
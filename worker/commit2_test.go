@@ -0,0 +1,45 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommit2Endpoint(t *testing.T) {
+	proofs := &fakeProofs{}
+	srv, err := NewServer(proofs, nil)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(srv.Commit2Handler())
+	defer ts.Close()
+
+	client := NewCommit2Client(ts.URL, nil)
+	sector := SectorRef{
+		ID:        abi.SectorID{Miner: 1000, Number: 42},
+		ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+	}
+
+	out, err := client.SealCommit2(context.Background(), sector, []byte("c1o"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("proof:c1o"), out)
+	assert.Equal(t, sector.ID, proofs.sector.ID)
+	assert.Equal(t, sector.ProofType, proofs.sector.ProofType)
+
+	body := ffi.EncodeCommit1Output(sector.ProofType, sector.ID, []byte("c1o"))
+	body[len(body)-1] ^= 1
+	resp, err := http.Post(ts.URL, Commit2ContentType, strings.NewReader(string(body)))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
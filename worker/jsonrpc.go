@@ -0,0 +1,71 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Namespace is the JSON-RPC method namespace used by lotus for the worker
+// API, e.g. "Filecoin.SealCommit2".
+const Namespace = "Filecoin"
+
+// Method names of the supported subset of the lotus worker API.
+const (
+	MethodVersion             = Namespace + ".Version"
+	MethodSession             = Namespace + ".Session"
+	MethodProcessSession      = Namespace + ".ProcessSession"
+	MethodInfo                = Namespace + ".Info"
+	MethodTaskTypes           = Namespace + ".TaskTypes"
+	MethodPaths               = Namespace + ".Paths"
+	MethodTaskEnable          = Namespace + ".TaskEnable"
+	MethodTaskDisable         = Namespace + ".TaskDisable"
+	MethodEnabled             = Namespace + ".Enabled"
+	MethodSetEnabled          = Namespace + ".SetEnabled"
+	MethodSealCommit2         = Namespace + ".SealCommit2"
+	MethodGenerateWinningPoSt = Namespace + ".GenerateWinningPoSt"
+	MethodGenerateWindowPoSt  = Namespace + ".GenerateWindowPoSt"
+)
+
+// Method names of the lotus miner API called by a worker.
+const (
+	MethodWorkerConnect     = Namespace + ".WorkerConnect"
+	MethodReturnSealCommit2 = Namespace + ".ReturnSealCommit2"
+)
+
+// Error codes returned in JSON-RPC error objects. They follow the JSON-RPC 2.0
+// reserved range, apart from errCodeCall which go-jsonrpc uses for errors
+// returned by the method itself.
+const (
+	errCodeParse          = -32700
+	errCodeInvalidRequest = -32600
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeCall           = 1
+)
+
+type request struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC error object as sent on the wire.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("RPC error (%d): %s", e.Code, e.Message)
+}
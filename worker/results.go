@@ -0,0 +1,65 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Results is a ReturnAPI keeping the results of the tasks of a Server until
+// they are waited for, for the callers of SealCommit2 which aren't lotus
+// miners.
+type Results struct {
+	lk      sync.Mutex
+	results map[CallID]chan result
+}
+
+type result struct {
+	proof   []byte
+	callErr *CallError
+}
+
+var _ ReturnAPI = (*Results)(nil)
+
+// NewResults returns a Results holding no result.
+func NewResults() *Results {
+	return &Results{results: map[CallID]chan result{}}
+}
+
+func (r *Results) ReturnSealCommit2(_ context.Context, callID CallID, proof []byte, callErr *CallError) error {
+	r.result(callID) <- result{proof: proof, callErr: callErr}
+	return nil
+}
+
+// Wait returns the proof of the SealCommit2 task callID, once it is returned.
+func (r *Results) Wait(ctx context.Context, callID CallID) ([]byte, error) {
+	select {
+	case res := <-r.result(callID):
+		r.lk.Lock()
+		delete(r.results, callID)
+		r.lk.Unlock()
+
+		if res.callErr != nil {
+			return nil, res.callErr
+		}
+		return res.proof, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// result returns the channel of the result of callID, made by whichever of
+// the task and the caller waiting for it comes first.
+func (r *Results) result(callID CallID) chan result {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	ch, ok := r.results[callID]
+	if !ok {
+		ch = make(chan result, 1)
+		r.results[callID] = ch
+	}
+	return ch
+}
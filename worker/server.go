@@ -0,0 +1,568 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"golang.org/x/xerrors"
+)
+
+// maxRequestSize bounds the size of a request body. Commit1 outputs of 64GiB
+// sectors are in the order of tens of MiB once base64 encoded.
+const maxRequestSize = 512 << 20
+
+// The attempts at returning a result to the miner, and the delay before the
+// first retry, doubled at each attempt.
+const returnAttempts = 8
+
+var returnRetryDelay = time.Second
+
+type handler func(ctx context.Context, params []json.RawMessage) (interface{}, error)
+
+// Server serves API over HTTP, using JSON-RPC 2.0 with positional params as
+// produced by go-jsonrpc.
+type Server struct {
+	proofs  ffi.ProofsAPI
+	paths   PathResolver
+	session string
+
+	lk       sync.Mutex
+	returns  ReturnAPI
+	storage  []StoragePath
+	tasks    map[TaskType]struct{}
+	disabled bool
+
+	methods map[string]handler
+}
+
+var _ API = (*Server)(nil)
+var _ http.Handler = (*Server)(nil)
+
+// NewServer returns a Server computing proofs with the given ProofsAPI. The
+// resolver is used to locate sector files for PoSt; it may be nil when the
+// server only handles SealCommit2. The server declares the PoSt tasks when it
+// has a resolver, and SealCommit2 otherwise.
+func NewServer(proofs ffi.ProofsAPI, paths PathResolver) (*Server, error) {
+	session, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		proofs:  proofs,
+		paths:   paths,
+		session: session,
+		tasks:   map[TaskType]struct{}{TTCommit2: {}},
+	}
+	if paths != nil {
+		s.tasks = map[TaskType]struct{}{TTGenerateWinningPoSt: {}, TTGenerateWindowPoSt: {}}
+	}
+
+	s.methods = map[string]handler{
+		MethodVersion: func(ctx context.Context, _ []json.RawMessage) (interface{}, error) {
+			return s.Version(ctx)
+		},
+		MethodSession: func(ctx context.Context, _ []json.RawMessage) (interface{}, error) {
+			return s.Session(ctx)
+		},
+		MethodProcessSession: func(ctx context.Context, _ []json.RawMessage) (interface{}, error) {
+			return s.ProcessSession(ctx)
+		},
+		MethodInfo: func(ctx context.Context, _ []json.RawMessage) (interface{}, error) {
+			return s.Info(ctx)
+		},
+		MethodTaskTypes: func(ctx context.Context, _ []json.RawMessage) (interface{}, error) {
+			return s.TaskTypes(ctx)
+		},
+		MethodPaths: func(ctx context.Context, _ []json.RawMessage) (interface{}, error) {
+			return s.Paths(ctx)
+		},
+		MethodTaskEnable: func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var tt TaskType
+			if err := decodeParams(params, &tt); err != nil {
+				return nil, err
+			}
+			return nil, s.TaskEnable(ctx, tt)
+		},
+		MethodTaskDisable: func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var tt TaskType
+			if err := decodeParams(params, &tt); err != nil {
+				return nil, err
+			}
+			return nil, s.TaskDisable(ctx, tt)
+		},
+		MethodEnabled: func(ctx context.Context, _ []json.RawMessage) (interface{}, error) {
+			return s.Enabled(ctx)
+		},
+		MethodSetEnabled: func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var enabled bool
+			if err := decodeParams(params, &enabled); err != nil {
+				return nil, err
+			}
+			return nil, s.SetEnabled(ctx, enabled)
+		},
+		MethodSealCommit2: func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var sector SectorRef
+			var c1o []byte
+			if err := decodeParams(params, &sector, &c1o); err != nil {
+				return nil, err
+			}
+			return s.SealCommit2(ctx, sector, c1o)
+		},
+		MethodGenerateWinningPoSt: func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var proofType abi.RegisteredPoStProof
+			var minerID abi.ActorID
+			var sectors []PostSectorChallenge
+			var randomness abi.PoStRandomness
+			if err := decodeParams(params, &proofType, &minerID, &sectors, &randomness); err != nil {
+				return nil, err
+			}
+			return s.GenerateWinningPoSt(ctx, proofType, minerID, sectors, randomness)
+		},
+		MethodGenerateWindowPoSt: func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var proofType abi.RegisteredPoStProof
+			var minerID abi.ActorID
+			var sectors []PostSectorChallenge
+			var partitionIdx int
+			var randomness abi.PoStRandomness
+			if err := decodeParams(params, &proofType, &minerID, &sectors, &partitionIdx, &randomness); err != nil {
+				return nil, err
+			}
+			return s.GenerateWindowPoSt(ctx, proofType, minerID, sectors, partitionIdx, randomness)
+		},
+	}
+
+	return s, nil
+}
+
+func (s *Server) Version(context.Context) (uint32, error) {
+	return APIVersion, nil
+}
+
+// Session returns an identifier which changes every time the server is
+// restarted, or ClosedWorkerID while it is disabled. The lotus miner uses it
+// to detect worker restarts.
+func (s *Server) Session(ctx context.Context) (string, error) {
+	if enabled, _ := s.Enabled(ctx); !enabled {
+		return ClosedWorkerID, nil
+	}
+	return s.session, nil
+}
+
+// ProcessSession returns the session of the server, even when it is
+// disabled.
+func (s *Server) ProcessSession(context.Context) (string, error) {
+	return s.session, nil
+}
+
+// Info reports the host of the server to the miner, which accounts the
+// resources of the tasks it schedules on it with its default resource table.
+func (s *Server) Info(context.Context) (WorkerInfo, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return WorkerInfo{}, xerrors.Errorf("getting hostname: %w", err)
+	}
+
+	gpus, err := ffi.GetGPUDevices()
+	if err != nil {
+		return WorkerInfo{}, xerrors.Errorf("listing GPUs: %w", err)
+	}
+
+	res := WorkerResources{
+		CPUs: uint64(runtime.NumCPU()),
+		GPUs: gpus,
+	}
+	if mem, err := ioutil.ReadFile("/proc/meminfo"); err == nil {
+		res.MemPhysical, res.MemUsed, res.MemSwap, res.MemSwapUsed = parseMemInfo(string(mem))
+	}
+
+	return WorkerInfo{Hostname: hostname, Resources: res}, nil
+}
+
+// TaskTypes returns the task types the server declares to the miner.
+func (s *Server) TaskTypes(context.Context) (map[TaskType]struct{}, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	out := make(map[TaskType]struct{}, len(s.tasks))
+	for tt := range s.tasks {
+		out[tt] = struct{}{}
+	}
+	return out, nil
+}
+
+// Paths returns the storage paths set with SetStoragePaths. The miner reads
+// them to find the sectors the worker has access to.
+func (s *Server) Paths(context.Context) ([]StoragePath, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return append([]StoragePath(nil), s.storage...), nil
+}
+
+// SetStoragePaths sets the storage paths reported by Paths, those declared
+// to the miner for the directories the PathResolver reads.
+func (s *Server) SetStoragePaths(paths []StoragePath) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.storage = append([]StoragePath(nil), paths...)
+}
+
+// TaskEnable declares tt to the miner.
+func (s *Server) TaskEnable(_ context.Context, tt TaskType) error {
+	switch tt {
+	case TTCommit2, TTGenerateWinningPoSt, TTGenerateWindowPoSt:
+	default:
+		return xerrors.Errorf("unsupported task type %s", tt)
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.tasks[tt] = struct{}{}
+	return nil
+}
+
+// TaskDisable stops declaring tt to the miner.
+func (s *Server) TaskDisable(_ context.Context, tt TaskType) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	delete(s.tasks, tt)
+	return nil
+}
+
+func (s *Server) Enabled(context.Context) (bool, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return !s.disabled, nil
+}
+
+// SetEnabled enables or disables the server. A disabled server reports
+// ClosedWorkerID as its session, for the miner to stop scheduling tasks on
+// it.
+func (s *Server) SetEnabled(_ context.Context, enabled bool) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.disabled = !enabled
+	return nil
+}
+
+// SetReturn sets where the results of the SealCommit2 tasks are returned.
+func (s *Server) SetReturn(returns ReturnAPI) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.returns = returns
+}
+
+// Attach registers the server with a lotus miner called through miner, as a
+// worker reachable at url, e.g. "http://10.0.0.2:3456/rpc/v0". The results of
+// the SealCommit2 tasks are returned to that miner.
+func (s *Server) Attach(ctx context.Context, miner *Client, url string) error {
+	s.SetReturn(miner)
+
+	if err := miner.WorkerConnect(ctx, url); err != nil {
+		return xerrors.Errorf("connecting to miner: %w", err)
+	}
+	return nil
+}
+
+// SealCommit2 starts computing the proof, and returns its CallID. The result
+// is delivered to the ReturnAPI set with SetReturn or Attach when the proof
+// is computed, even after the request is canceled.
+func (s *Server) SealCommit2(_ context.Context, sector SectorRef, phase1Output []byte) (CallID, error) {
+	s.lk.Lock()
+	returns := s.returns
+	s.lk.Unlock()
+
+	if returns == nil {
+		return CallID{}, xerrors.New("no miner to return the proof to, see Server.Attach")
+	}
+
+	id, err := newUUID()
+	if err != nil {
+		return CallID{}, err
+	}
+	callID := CallID{Sector: sector.ID, ID: id}
+
+	go func() {
+		var callErr *CallError
+		out, err := s.sealCommit2(context.Background(), sector, phase1Output)
+		if err != nil {
+			callErr = &CallError{Code: ErrUnknown, Message: err.Error()}
+		}
+		returnResult(returns, callID, out, callErr)
+	}()
+
+	return callID, nil
+}
+
+// sealCommit2 computes the proof of sector.
+func (s *Server) sealCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) ([]byte, error) {
+	return s.proofs.SealCommit2(ctx, ffi.SectorRef{
+		ID:        sector.ID,
+		ProofType: sector.ProofType,
+	}, phase1Output)
+}
+
+// returnResult delivers the result of a task, retrying while the miner is
+// unreachable as a lotus worker does. The result is dropped after
+// returnAttempts failed attempts.
+func returnResult(returns ReturnAPI, callID CallID, proof []byte, callErr *CallError) {
+	delay := returnRetryDelay
+	for i := 0; i < returnAttempts; i++ {
+		if returns.ReturnSealCommit2(context.Background(), callID, proof, callErr) == nil {
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (s *Server) GenerateWinningPoSt(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, sectors []PostSectorChallenge, randomness abi.PoStRandomness) ([]proof.PoStProof, error) {
+	vanilla, skipped, err := s.vanillaProofs(ctx, proofType, minerID, sectors)
+	if err != nil {
+		return nil, err
+	}
+	if len(skipped) > 0 {
+		return nil, xerrors.Errorf("failed to generate vanilla proofs for %d sectors", len(skipped))
+	}
+
+	return s.proofs.GenerateWinningPoStWithVanilla(ctx, proofType, minerID, postRandomness(randomness), vanilla)
+}
+
+func (s *Server) GenerateWindowPoSt(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, sectors []PostSectorChallenge, partitionIdx int, randomness abi.PoStRandomness) (WindowPoStResult, error) {
+	if partitionIdx < 0 {
+		return WindowPoStResult{}, xerrors.Errorf("invalid partition index %d", partitionIdx)
+	}
+
+	vanilla, skipped, err := s.vanillaProofs(ctx, proofType, minerID, sectors)
+	if err != nil {
+		return WindowPoStResult{}, err
+	}
+	if len(skipped) > 0 {
+		return WindowPoStResult{Skipped: skipped}, nil
+	}
+
+	pp, err := s.proofs.GenerateSinglePartitionWindowPoStWithVanilla(ctx, proofType, minerID, postRandomness(randomness), vanilla, uint(partitionIdx))
+	if err != nil {
+		return WindowPoStResult{}, xerrors.Errorf("generating partition proof: %w", err)
+	}
+
+	return WindowPoStResult{PoStProofs: proof.PoStProof(*pp)}, nil
+}
+
+// vanillaProofs generates the vanilla proof of every challenged sector,
+// returning the sectors which could not be read as skipped.
+func (s *Server) vanillaProofs(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, sectors []PostSectorChallenge) ([][]byte, []abi.SectorID, error) {
+	if s.paths == nil {
+		return nil, nil, xerrors.New("no sector path resolver configured")
+	}
+
+	var skipped []abi.SectorID
+	out := make([][]byte, 0, len(sectors))
+	for _, sector := range sectors {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		sid := abi.SectorID{Miner: minerID, Number: sector.SectorNumber}
+
+		cacheDirPath, sealedSectorPath, err := s.paths(ctx, sid, sector.Update)
+		if err != nil {
+			skipped = append(skipped, sid)
+			continue
+		}
+
+		vanilla, err := ffi.GenerateSingleVanillaProof(ffi.PrivateSectorInfo{
+			SectorInfo: proof.SectorInfo{
+				SealProof:    sector.SealProof,
+				SectorNumber: sector.SectorNumber,
+				SealedCID:    sector.SealedCID,
+			},
+			CacheDirPath:     cacheDirPath,
+			PoStProofType:    proofType,
+			SealedSectorPath: sealedSectorPath,
+		}, sector.Challenge)
+		if err != nil {
+			skipped = append(skipped, sid)
+			continue
+		}
+
+		out = append(out, vanilla)
+	}
+
+	return out, skipped, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	resp := s.Handle(r.Context(), body)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
+
+// Handle executes a single encoded JSON-RPC request and returns the encoded
+// response, or nil for notifications. It lets the server be embedded without
+// going through HTTP.
+func (s *Server) Handle(ctx context.Context, body []byte) []byte {
+	var req request
+	resp := response{Jsonrpc: "2.0"}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		resp.Error = &RPCError{Code: errCodeParse, Message: err.Error()}
+		return encodeResponse(resp)
+	}
+	resp.ID = req.ID
+
+	if req.Jsonrpc != "2.0" {
+		resp.Error = &RPCError{Code: errCodeInvalidRequest, Message: "unsupported JSON-RPC version"}
+		return encodeResponse(resp)
+	}
+
+	h, ok := s.methods[req.Method]
+	if !ok {
+		resp.Error = &RPCError{Code: errCodeMethodNotFound, Message: fmt.Sprintf("method '%s' not found", req.Method)}
+		return encodeResponse(resp)
+	}
+
+	res, err := h(ctx, req.Params)
+	if err != nil {
+		code := errCodeCall
+		if xerrors.As(err, new(*paramsError)) {
+			code = errCodeInvalidParams
+		}
+		resp.Error = &RPCError{Code: code, Message: err.Error()}
+		return encodeResponse(resp)
+	}
+
+	resp.Result, err = json.Marshal(res)
+	if err != nil {
+		resp.Error = &RPCError{Code: errCodeCall, Message: xerrors.Errorf("marshaling result: %w", err).Error()}
+	}
+
+	return encodeResponse(resp)
+}
+
+// A request without an ID is a notification which, per JSON-RPC 2.0, must not
+// be answered. It is still executed.
+func encodeResponse(resp response) []byte {
+	if resp.ID == nil && resp.Error == nil {
+		return nil
+	}
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		// only the result can fail to marshal, and it was marshaled already
+		panic(err)
+	}
+	return out
+}
+
+type paramsError struct {
+	msg string
+}
+
+func (e *paramsError) Error() string {
+	return e.msg
+}
+
+func decodeParams(params []json.RawMessage, out ...interface{}) error {
+	if len(params) != len(out) {
+		return &paramsError{msg: fmt.Sprintf("expected %d params, got %d", len(out), len(params))}
+	}
+
+	for i := range out {
+		if err := json.Unmarshal(params[i], out[i]); err != nil {
+			return &paramsError{msg: fmt.Sprintf("unmarshaling param %d: %s", i, err)}
+		}
+	}
+
+	return nil
+}
+
+// postRandomness clears the two most significant bits of the randomness so
+// that it is a valid field element, as lotus does before calling into the
+// proofs library.
+func postRandomness(randomness abi.PoStRandomness) abi.PoStRandomness {
+	out := make(abi.PoStRandomness, len(randomness))
+	copy(out, randomness)
+	if len(out) == 32 {
+		out[31] &= 0x3f
+	}
+	return out
+}
+
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", xerrors.Errorf("generating UUID: %w", err)
+	}
+
+	// Format as a version 4 UUID, which is what lotus workers use.
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// parseMemInfo returns the physical memory and swap of /proc/meminfo, and how
+// much of them is in use.
+func parseMemInfo(meminfo string) (mem, memUsed, swap, swapUsed uint64) {
+	values := map[string]uint64{}
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = kb << 10
+	}
+
+	mem, swap = values["MemTotal"], values["SwapTotal"]
+	if avail := values["MemAvailable"]; avail <= mem {
+		memUsed = mem - avail
+	}
+	if free := values["SwapFree"]; free <= swap {
+		swapUsed = swap - free
+	}
+	return mem, memUsed, swap, swapUsed
+}
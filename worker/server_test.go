@@ -0,0 +1,181 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProofs struct {
+	ffi.ProofsAPI
+
+	sector ffi.SectorRef
+}
+
+func (f *fakeProofs) SealCommit2(_ context.Context, sector ffi.SectorRef, phase1Output []byte) ([]byte, error) {
+	f.sector = sector
+	return append([]byte("proof:"), phase1Output...), nil
+}
+
+func TestClientServerRoundTrip(t *testing.T) {
+	proofs := &fakeProofs{}
+	srv, err := NewServer(proofs, nil)
+	require.NoError(t, err)
+	results := NewResults()
+	srv.SetReturn(results)
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	ctx := context.Background()
+
+	version, err := client.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(APIVersion), version)
+
+	session, err := client.Session(ctx)
+	require.NoError(t, err)
+	assert.Len(t, session, 36)
+
+	sector := SectorRef{
+		ID:        abi.SectorID{Miner: 1000, Number: 42},
+		ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+	}
+	callID, err := client.SealCommit2(ctx, sector, []byte("c1o"))
+	require.NoError(t, err)
+	assert.Equal(t, sector.ID, callID.Sector)
+	out, err := results.Wait(ctx, callID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("proof:c1o"), out)
+	assert.Equal(t, sector.ID, proofs.sector.ID)
+	assert.Equal(t, sector.ProofType, proofs.sector.ProofType)
+
+	_, err = client.GenerateWindowPoSt(ctx, abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, 1000, nil, 0, make([]byte, 32))
+	assert.Error(t, err)
+}
+
+func TestServerErrors(t *testing.T) {
+	srv, err := NewServer(&fakeProofs{}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{body: `{`, code: errCodeParse},
+		{body: `{"jsonrpc":"1.0","id":1,"method":"Filecoin.Version","params":[]}`, code: errCodeInvalidRequest},
+		{body: `{"jsonrpc":"2.0","id":1,"method":"Filecoin.AddPiece","params":[]}`, code: errCodeMethodNotFound},
+		{body: `{"jsonrpc":"2.0","id":1,"method":"Filecoin.SealCommit2","params":[{}]}`, code: errCodeInvalidParams},
+	} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/rpc/v0", strings.NewReader(tc.body)))
+		assert.Contains(t, rec.Body.String(), `"code":`+strconv.Itoa(tc.code), tc.body)
+	}
+}
+
+func TestServerAttach(t *testing.T) {
+	returned := make(chan []json.RawMessage, 1)
+	var connected string
+	miner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch req.Method {
+		case MethodWorkerConnect:
+			require.NoError(t, json.Unmarshal(req.Params[0], &connected))
+		case MethodReturnSealCommit2:
+			returned <- req.Params
+		}
+		_, _ = w.Write(encodeResponse(response{Jsonrpc: "2.0", ID: req.ID, Result: json.RawMessage("null")}))
+	}))
+	defer miner.Close()
+
+	srv, err := NewServer(&fakeProofs{}, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = srv.SealCommit2(ctx, SectorRef{}, nil)
+	assert.Error(t, err, "no miner to return to")
+
+	require.NoError(t, srv.Attach(ctx, NewClient(miner.URL, nil), "http://10.0.0.2:3456/rpc/v0"))
+	assert.Equal(t, "http://10.0.0.2:3456/rpc/v0", connected)
+
+	callID, err := srv.SealCommit2(ctx, SectorRef{ID: abi.SectorID{Miner: 1000, Number: 42}}, []byte("c1o"))
+	require.NoError(t, err)
+
+	params := <-returned
+	require.Len(t, params, 3)
+	var gotID CallID
+	var proof []byte
+	require.NoError(t, json.Unmarshal(params[0], &gotID))
+	require.NoError(t, json.Unmarshal(params[1], &proof))
+	assert.Equal(t, callID, gotID)
+	assert.Equal(t, []byte("proof:c1o"), proof)
+	assert.Equal(t, "null", string(params[2]))
+}
+
+func TestServerWorkerInfo(t *testing.T) {
+	srv, err := NewServer(&fakeProofs{}, nil)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client := NewClient(ts.URL, nil)
+	ctx := context.Background()
+
+	info, err := client.Info(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, info.Hostname)
+	assert.NotZero(t, info.Resources.CPUs)
+
+	tasks, err := client.TaskTypes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[TaskType]struct{}{TTCommit2: {}}, tasks)
+
+	require.NoError(t, client.TaskEnable(ctx, TTGenerateWindowPoSt))
+	require.NoError(t, client.TaskDisable(ctx, TTCommit2))
+	tasks, err = client.TaskTypes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[TaskType]struct{}{TTGenerateWindowPoSt: {}}, tasks)
+	assert.Error(t, client.TaskEnable(ctx, "seal/v0/addpiece"))
+
+	srv.SetStoragePaths([]StoragePath{{ID: "storage", Weight: 10, LocalPath: "/data", CanStore: true}})
+	paths, err := client.Paths(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []StoragePath{{ID: "storage", Weight: 10, LocalPath: "/data", CanStore: true}}, paths)
+
+	session, err := client.Session(ctx)
+	require.NoError(t, err)
+	require.NoError(t, client.SetEnabled(ctx, false))
+	enabled, err := client.Enabled(ctx)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	closed, err := client.Session(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ClosedWorkerID, closed)
+	processSession, err := client.ProcessSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, session, processSession)
+}
+
+func TestParseMemInfo(t *testing.T) {
+	mem, memUsed, swap, swapUsed := parseMemInfo("MemTotal:       16000 kB\nMemFree:         1000 kB\nMemAvailable:    4000 kB\nSwapTotal:       2000 kB\nSwapFree:        1500 kB\n")
+	assert.Equal(t, uint64(16000<<10), mem)
+	assert.Equal(t, uint64(12000<<10), memUsed)
+	assert.Equal(t, uint64(2000<<10), swap)
+	assert.Equal(t, uint64(500<<10), swapUsed)
+}
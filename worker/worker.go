@@ -0,0 +1,187 @@
+//go:build cgo || ffimock
+// +build cgo ffimock
+
+// Package worker speaks the subset of the lotus seal-worker JSON-RPC API used
+// for remote Commit2 and PoSt computation, backed by the proving functions of
+// the ffi package, and a Client can drive either a Server or a lotus worker.
+//
+// A Server takes the place of a `lotus-worker` process running C2 or PoSt
+// tasks: Server.Attach registers it with the miner, which then queries it
+// with the methods it calls on every worker (Info, TaskTypes, Paths,
+// Enabled, Session) and schedules tasks on it. As with a lotus worker,
+// SealCommit2 answers with a CallID and the proof is delivered later through
+// the ReturnSealCommit2 method of the miner.
+//
+// The miner uses workers declaring PoSt tasks for PoSt only, so a Server
+// declares the PoSt tasks when it has a PathResolver, and SealCommit2
+// otherwise; TaskEnable and TaskDisable change that.
+//
+// Outside of the lotus API, Server.Commit2Handler and Commit2Client ship
+// Commit1 outputs as checksummed binary envelopes, see ffi.Commit1Output.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v5/actors/runtime/proof"
+	"github.com/ipfs/go-cid"
+)
+
+// APIVersion is the worker API version reported by Filecoin.Version. It is
+// the lotus WorkerAPIVersion0 (1.5.0) value, which the miner checks when a
+// worker connects.
+const APIVersion = 0x010500
+
+// ClosedWorkerID is the session reported by a disabled worker, the lotus
+// `storiface.ClosedWorkerID`, for the miner to stop scheduling tasks on it.
+const ClosedWorkerID = "00000000-0000-0000-0000-000000000000"
+
+// TaskType mirrors the lotus `sealtasks.TaskType`.
+type TaskType string
+
+// The task types a Server runs.
+const (
+	TTCommit2             TaskType = "seal/v0/commit/2"
+	TTGenerateWinningPoSt TaskType = "post/v0/winningproof"
+	TTGenerateWindowPoSt  TaskType = "post/v0/windowproof"
+)
+
+// CallID mirrors the lotus `storiface.CallID`: it identifies a task started
+// on a worker, whose result is returned to the miner when it completes.
+type CallID struct {
+	Sector abi.SectorID
+	ID     string
+}
+
+// ErrUnknown is the `storiface.ErrUnknown` code of the CallError of a failed
+// task.
+const ErrUnknown = 0
+
+// CallError mirrors the lotus `storiface.CallError`, the error returned to
+// the miner for a failed task.
+type CallError struct {
+	Code    int
+	Message string
+}
+
+func (e *CallError) Error() string {
+	return fmt.Sprintf("storage call error %d: %s", e.Code, e.Message)
+}
+
+// StoragePath mirrors the lotus `storiface.StoragePath`, a storage path of a
+// worker as reported by Paths.
+type StoragePath struct {
+	ID     string
+	Weight uint64
+
+	LocalPath string
+
+	CanSeal  bool
+	CanStore bool
+}
+
+// WorkerInfo mirrors the lotus `storiface.WorkerInfo`.
+type WorkerInfo struct {
+	Hostname string
+
+	// IgnoreResources makes the miner schedule tasks regardless of the
+	// resources of the worker.
+	IgnoreResources bool
+	Resources       WorkerResources
+}
+
+// WorkerResources mirrors the lotus `storiface.WorkerResources`. The miner
+// uses its default resource table when Resources is nil.
+type WorkerResources struct {
+	MemPhysical uint64
+	MemUsed     uint64
+	MemSwap     uint64
+	MemSwapUsed uint64
+
+	CPUs uint64
+	GPUs []string
+
+	Resources map[TaskType]map[abi.RegisteredSealProof]json.RawMessage `json:",omitempty"`
+}
+
+// SectorRef mirrors the lotus `storage.SectorRef`.
+type SectorRef struct {
+	ID        abi.SectorID
+	ProofType abi.RegisteredSealProof
+}
+
+// PostSectorChallenge mirrors the lotus `storiface.PostSectorChallenge`: a
+// sector and the challenges the worker has to generate a vanilla proof for.
+type PostSectorChallenge struct {
+	SealProof    abi.RegisteredSealProof
+	SectorNumber abi.SectorNumber
+	SealedCID    cid.Cid
+	Challenge    []uint64
+	Update       bool
+}
+
+// WindowPoStResult mirrors the lotus `storiface.WindowPoStResult`. When any
+// sector fails to produce a vanilla proof, Skipped is set and PoStProofs is
+// left empty so that the caller can retry without those sectors.
+type WindowPoStResult struct {
+	PoStProofs proof.PoStProof
+	Skipped    []abi.SectorID
+}
+
+// PathResolver returns the cache directory and sealed (or, for updated
+// sectors, replica update) file of a sector stored on the worker.
+type PathResolver func(ctx context.Context, sector abi.SectorID, update bool) (cacheDirPath string, sealedSectorPath string, err error)
+
+// API is the method set served by Server and implemented by Client.
+type API interface {
+	Version(ctx context.Context) (uint32, error)
+	Session(ctx context.Context) (string, error)
+	ProcessSession(ctx context.Context) (string, error)
+
+	Info(ctx context.Context) (WorkerInfo, error)
+	TaskTypes(ctx context.Context) (map[TaskType]struct{}, error)
+	Paths(ctx context.Context) ([]StoragePath, error)
+	TaskEnable(ctx context.Context, tt TaskType) error
+	TaskDisable(ctx context.Context, tt TaskType) error
+	Enabled(ctx context.Context) (bool, error)
+	SetEnabled(ctx context.Context, enabled bool) error
+
+	SealCommit2(ctx context.Context, sector SectorRef, phase1Output []byte) (CallID, error)
+
+	GenerateWinningPoSt(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, sectors []PostSectorChallenge, randomness abi.PoStRandomness) ([]proof.PoStProof, error)
+	GenerateWindowPoSt(ctx context.Context, proofType abi.RegisteredPoStProof, minerID abi.ActorID, sectors []PostSectorChallenge, partitionIdx int, randomness abi.PoStRandomness) (WindowPoStResult, error)
+}
+
+// ReturnAPI is the part of the miner API a worker delivers the results of its
+// tasks to, implemented by Client for a lotus miner and by Results.
+type ReturnAPI interface {
+	ReturnSealCommit2(ctx context.Context, callID CallID, proof []byte, callErr *CallError) error
+}
+
+// StoragePaths returns a PathResolver for sectors kept in the lotus storage
+// path layout under root, i.e. `sealed/s-t0<miner>-<number>` and
+// `cache/s-t0<miner>-<number>`, or `update` and `update-cache` for updated
+// sectors.
+func StoragePaths(root string) PathResolver {
+	return func(_ context.Context, sector abi.SectorID, update bool) (string, string, error) {
+		name := fmt.Sprintf("s-t0%d-%d", sector.Miner, sector.Number)
+
+		cacheDir, sealedDir := "cache", "sealed"
+		if update {
+			cacheDir, sealedDir = "update-cache", "update"
+		}
+
+		cacheDirPath := filepath.Join(root, cacheDir, name)
+		sealedSectorPath := filepath.Join(root, sealedDir, name)
+		if _, err := os.Stat(sealedSectorPath); err != nil {
+			return "", "", err
+		}
+
+		return cacheDirPath, sealedSectorPath, nil
+	}
+}
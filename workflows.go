@@ -1,5 +1,5 @@
-//go:build cgo
-// +build cgo
+//go:build cgo || ffimock
+// +build cgo ffimock
 
 package ffi
 